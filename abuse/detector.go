@@ -0,0 +1,374 @@
+// Package abuse stops brute-force attacks on credentials. It counts authentication failures
+// per client IP and per credential, and bans a subject that fails too often within a window,
+// for twice as long with every repeated ban.
+package abuse
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/clock"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Prefixes of the subjects failures are counted for
+const (
+	ipPrefix     = "ip:"
+	keyPrefix    = "key:"
+	clientPrefix = "client:"
+)
+
+// IPSubject is the subject of a client IP
+func IPSubject(clientIP string) string {
+	return ipPrefix + clientIP
+}
+
+// KeySubject is the subject of an API or admin key, by its key ID
+func KeySubject(keyID string) string {
+	return keyPrefix + keyID
+}
+
+// ClientSubject is the subject of an OAuth client ID
+func ClientSubject(clientID string) string {
+	return clientPrefix + clientID
+}
+
+// subjectRecord holds a subject's recent failures and bans
+type subjectRecord struct {
+	failures    []time.Time // Within the window, oldest first
+	offenses    int         // Bans since the subject was last forgiven
+	bannedSince time.Time
+	bannedUntil time.Time
+	lastSeen    time.Time     // Latest failure or ban end
+	element     *list.Element // In the detector's recency list (nil = banned, until swept)
+}
+
+// Detector bans the client IPs and credentials that fail authentication repeatedly. At most
+// maxSubjects subjects are tracked: a new subject beyond the cap evicts the least recently
+// seen one that is not banned, so floods of spoofed addresses cannot keep others from being
+// tracked. A nil detector is valid and bans nothing.
+type Detector struct {
+	threshold   int
+	window      time.Duration
+	banDuration time.Duration
+	maxBan      time.Duration
+	maxSubjects int
+	exempt      []*net.IPNet
+	logger      logger.Logger
+	clock       clock.Clock
+
+	mutex     sync.Mutex
+	subjects  map[string]*subjectRecord
+	recency   *list.List // Subjects not banned, from most to least recently seen
+	failures  int64
+	bans      int64
+	evicted   int64
+	untracked int64
+}
+
+// NewDetector creates a detector with the configured thresholds, or returns nil when
+// abuse detection is disabled
+func NewDetector(configuration config.AbuseConfig, log logger.Logger) (*Detector, error) {
+	if !configuration.Enabled {
+		return nil, nil
+	}
+	if configuration.Threshold <= 0 || configuration.Window <= 0 || configuration.BanDuration <= 0 {
+		return nil, fmt.Errorf("ABUSE_FAILURE_THRESHOLD, ABUSE_FAILURE_WINDOW_SECONDS and ABUSE_BAN_SECONDS must be positive")
+	}
+	if configuration.MaxBan < configuration.BanDuration {
+		return nil, fmt.Errorf("ABUSE_MAX_BAN_SECONDS must not be shorter than ABUSE_BAN_SECONDS")
+	}
+	if configuration.MaxSubjects < 0 {
+		return nil, fmt.Errorf("ABUSE_MAX_SUBJECTS must not be negative")
+	}
+
+	exempt := make([]*net.IPNet, 0, len(configuration.ExemptCIDRs))
+	for _, cidr := range configuration.ExemptCIDRs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABUSE_EXEMPT_CIDRS entry %q: %w", cidr, err)
+		}
+		exempt = append(exempt, network)
+	}
+
+	return &Detector{
+		threshold:   configuration.Threshold,
+		window:      configuration.Window,
+		banDuration: configuration.BanDuration,
+		maxBan:      configuration.MaxBan,
+		maxSubjects: configuration.MaxSubjects,
+		exempt:      exempt,
+		logger:      log,
+		clock:       clock.System,
+		subjects:    make(map[string]*subjectRecord),
+		recency:     list.New(),
+	}, nil
+}
+
+// Start forgets, every interval until the context is done, the subjects without a ban to
+// remember or failures in the window
+func (detector *Detector) Start(ctx context.Context, interval time.Duration) {
+	if detector == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				detector.mutex.Lock()
+				detector.sweep(detector.clock.Now())
+				detector.mutex.Unlock()
+			}
+		}
+	}()
+}
+
+// Check returns how long the longest ban of the subjects lasts, and whether any is banned
+func (detector *Detector) Check(subjects ...string) (time.Duration, bool) {
+	if detector == nil {
+		return 0, false
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	now := detector.clock.Now()
+	var retryAfter time.Duration
+	for _, subject := range subjects {
+		if record, found := detector.subjects[subject]; found && record.bannedUntil.After(now) {
+			retryAfter = max(retryAfter, record.bannedUntil.Sub(now))
+		}
+	}
+	return retryAfter, retryAfter > 0
+}
+
+// Fail counts an authentication failure of each subject, banning those that reach the
+// threshold. Exempt client IPs and empty subjects are skipped. A new subject at the cap
+// evicts the least recently seen subject that is not banned; when every tracked subject is
+// banned, its failure is counted as untracked.
+func (detector *Detector) Fail(subjects ...string) {
+	if detector == nil {
+		return
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	now := detector.clock.Now()
+	detector.failures++
+	for _, subject := range subjects {
+		if subject == "" || strings.HasSuffix(subject, ":") || detector.exempted(subject) {
+			continue
+		}
+		record, found := detector.subjects[subject]
+		if !found {
+			if detector.maxSubjects > 0 && len(detector.subjects) >= detector.maxSubjects && !detector.evict() {
+				detector.untracked++
+				continue
+			}
+			record = &subjectRecord{}
+			detector.subjects[subject] = record
+		}
+		detector.fail(subject, record, now)
+	}
+}
+
+// fail counts a failure of a tracked subject (caller holds the lock)
+func (detector *Detector) fail(subject string, record *subjectRecord, now time.Time) {
+	if record.bannedUntil.After(now) {
+		return // Requests of banned subjects are refused before they can fail
+	}
+	// A subject that stayed clean for the longest ban is forgiven its past bans
+	if record.offenses > 0 && now.Sub(record.lastSeen) > detector.maxBan {
+		record.offenses = 0
+	}
+	record.lastSeen = now
+	if record.element == nil {
+		record.element = detector.recency.PushFront(subject)
+	} else {
+		detector.recency.MoveToFront(record.element)
+	}
+
+	windowStart := now.Add(-detector.window)
+	kept := record.failures[:0]
+	for _, failure := range record.failures {
+		if failure.After(windowStart) {
+			kept = append(kept, failure)
+		}
+	}
+	record.failures = append(kept, now)
+	if len(record.failures) < detector.threshold {
+		return
+	}
+
+	record.offenses++
+	duration := detector.banDuration
+	for i := 1; i < record.offenses && duration < detector.maxBan; i++ {
+		duration *= 2
+	}
+	duration = min(duration, detector.maxBan)
+	record.failures = nil
+	record.bannedSince = now
+	record.bannedUntil = now.Add(duration)
+	record.lastSeen = record.bannedUntil
+	// Banned subjects are never evicted, so their bans cannot be flooded away
+	detector.recency.Remove(record.element)
+	record.element = nil
+	detector.bans++
+	detector.logger.Warnf("Banned %s for %s after %d authentication failures (ban %d)", subject, duration, detector.threshold, record.offenses)
+}
+
+// evict drops the least recently seen subject that is not banned, reporting whether there
+// was one (caller holds the lock)
+func (detector *Detector) evict() bool {
+	element := detector.recency.Back()
+	if element == nil {
+		return false
+	}
+	detector.forget(element.Value.(string))
+	detector.evicted++
+	return true
+}
+
+// forget drops a subject (caller holds the lock)
+func (detector *Detector) forget(subject string) {
+	if record, found := detector.subjects[subject]; found && record.element != nil {
+		detector.recency.Remove(record.element)
+	}
+	delete(detector.subjects, subject)
+}
+
+// exempted reports whether the subject is a client IP in an exempt network
+func (detector *Detector) exempted(subject string) bool {
+	address, isIP := strings.CutPrefix(subject, ipPrefix)
+	if !isIP {
+		return false
+	}
+	ip := net.ParseIP(address)
+	for _, network := range detector.exempt {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sweep drops the subjects without a ban to remember or failures in the window, and makes
+// the subjects whose ban ended evictable again, as the least recently seen (caller holds
+// the lock)
+func (detector *Detector) sweep(now time.Time) {
+	for subject, record := range detector.subjects {
+		if record.bannedUntil.After(now) {
+			continue
+		}
+		forgotten := record.offenses == 0 || now.Sub(record.lastSeen) > detector.maxBan
+		if forgotten && now.Sub(record.lastSeen) > detector.window {
+			detector.forget(subject)
+		} else if record.element == nil {
+			record.element = detector.recency.PushBack(subject)
+		}
+	}
+}
+
+// Bans returns the active bans, the longest lasting first
+func (detector *Detector) Bans() []models.Ban {
+	bans := []models.Ban{}
+	if detector == nil {
+		return bans
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	now := detector.clock.Now()
+	for subject, record := range detector.subjects {
+		if record.bannedUntil.After(now) {
+			bans = append(bans, models.Ban{Subject: subject, Offenses: record.offenses, Since: record.bannedSince, Until: record.bannedUntil})
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if !bans[i].Until.Equal(bans[j].Until) {
+			return bans[i].Until.After(bans[j].Until)
+		}
+		return bans[i].Subject < bans[j].Subject
+	})
+	return bans
+}
+
+// Clear lifts the subject's ban and forgets its failures and past bans. It reports
+// whether the subject was tracked.
+func (detector *Detector) Clear(subject string) bool {
+	if detector == nil {
+		return false
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	_, found := detector.subjects[subject]
+	detector.forget(subject)
+	return found
+}
+
+// ClearAll lifts every ban and forgets all failures, returning how many bans were lifted
+func (detector *Detector) ClearAll() int {
+	if detector == nil {
+		return 0
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	now := detector.clock.Now()
+	lifted := 0
+	for _, record := range detector.subjects {
+		if record.bannedUntil.After(now) {
+			lifted++
+		}
+	}
+	detector.subjects = make(map[string]*subjectRecord)
+	detector.recency.Init()
+	return lifted
+}
+
+// AuthFailures returns the authentication failures counted since startup, for alerting
+func (detector *Detector) AuthFailures() int64 {
+	if detector == nil {
+		return 0
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+	return detector.failures
+}
+
+// Stats reports the tracked subjects, active bans and the counts since startup
+func (detector *Detector) Stats() *models.AbuseStats {
+	if detector == nil {
+		return nil
+	}
+	detector.mutex.Lock()
+	defer detector.mutex.Unlock()
+
+	now := detector.clock.Now()
+	stats := &models.AbuseStats{
+		Tracked:   len(detector.subjects),
+		Failures:  detector.failures,
+		Bans:      detector.bans,
+		Evicted:   detector.evicted,
+		Untracked: detector.untracked,
+	}
+	for _, record := range detector.subjects {
+		if record.bannedUntil.After(now) {
+			stats.ActiveBans++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,208 @@
+package abuse
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func testDetector(t *testing.T) (*Detector, *testutils.FakeClock) {
+	t.Helper()
+	detector, err := NewDetector(config.AbuseConfig{
+		Enabled:     true,
+		Threshold:   3,
+		Window:      time.Minute,
+		BanDuration: 10 * time.Second,
+		MaxBan:      30 * time.Second,
+		ExemptCIDRs: []string{"10.0.0.0/8"},
+	}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+	fakeClock := testutils.NewFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	detector.clock = fakeClock
+	return detector, fakeClock
+}
+
+func TestDetector_ProgressiveBans(t *testing.T) {
+	detector, fakeClock := testDetector(t)
+	attacker := IPSubject("203.0.113.7")
+	failRepeatedly := func() {
+		for i := 0; i < 3; i++ {
+			detector.Fail(attacker)
+		}
+	}
+
+	detector.Fail(attacker)
+	detector.Fail(attacker)
+	if _, banned := detector.Check(attacker); banned {
+		t.Fatal("Check() banned below the threshold")
+	}
+
+	// Each repeated ban doubles, up to the longest ban
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second} {
+		failRepeatedly()
+		retryAfter, banned := detector.Check(attacker)
+		if !banned || retryAfter != want {
+			t.Fatalf("Check() = %v, %v, want a ban of %v", retryAfter, banned, want)
+		}
+		fakeClock.Advance(retryAfter)
+		if _, banned := detector.Check(attacker); banned {
+			t.Fatalf("Check() still banned after %v", retryAfter)
+		}
+	}
+
+	// Staying clean for the longest ban forgives the past bans
+	fakeClock.Advance(31 * time.Second)
+	failRepeatedly()
+	if retryAfter, _ := detector.Check(attacker); retryAfter != 10*time.Second {
+		t.Errorf("Check() after forgiveness = %v, want the first ban of 10s", retryAfter)
+	}
+}
+
+func TestDetector_Window(t *testing.T) {
+	detector, fakeClock := testDetector(t)
+	key := KeySubject("a1b2c3")
+
+	detector.Fail(key)
+	detector.Fail(key)
+	fakeClock.Advance(2 * time.Minute)
+	detector.Fail(key)
+
+	if _, banned := detector.Check(key); banned {
+		t.Error("Check() banned for failures outside the window")
+	}
+}
+
+func TestDetector_Exempt(t *testing.T) {
+	detector, _ := testDetector(t)
+
+	for i := 0; i < 5; i++ {
+		detector.Fail(IPSubject("10.1.2.3"), ClientSubject("billing"))
+	}
+
+	if _, banned := detector.Check(IPSubject("10.1.2.3")); banned {
+		t.Error("Check() banned an exempt client IP")
+	}
+	if _, banned := detector.Check(ClientSubject("billing")); !banned {
+		t.Error("Check() did not ban the credential failing from an exempt client IP")
+	}
+}
+
+func TestDetector_BansAndClear(t *testing.T) {
+	detector, _ := testDetector(t)
+	for i := 0; i < 3; i++ {
+		detector.Fail(IPSubject("203.0.113.7"), KeySubject("a1b2c3"))
+	}
+
+	bans := detector.Bans()
+	if len(bans) != 2 || bans[0].Subject != "ip:203.0.113.7" || bans[0].Offenses != 1 {
+		t.Fatalf("Bans() = %+v, want the IP and the key", bans)
+	}
+	if stats := detector.Stats(); stats.ActiveBans != 2 || stats.Bans != 2 || stats.Failures != 3 {
+		t.Errorf("Stats() = %+v, want 2 active bans of 3 failures", stats)
+	}
+
+	if !detector.Clear("ip:203.0.113.7") {
+		t.Error("Clear() = false for a banned subject")
+	}
+	if _, banned := detector.Check(IPSubject("203.0.113.7")); banned {
+		t.Error("Check() banned after Clear()")
+	}
+	if detector.Clear("ip:198.51.100.1") {
+		t.Error("Clear() = true for an unknown subject")
+	}
+	if lifted := detector.ClearAll(); lifted != 1 {
+		t.Errorf("ClearAll() = %d, want the key's ban", lifted)
+	}
+}
+
+func TestDetector_MaxSubjects(t *testing.T) {
+	detector, _ := testDetector(t)
+	detector.maxSubjects = 2
+	banned := IPSubject("203.0.113.7")
+	for i := 0; i < 3; i++ {
+		detector.Fail(banned)
+	}
+
+	// New subjects evict the least recently seen subject that is not banned
+	detector.Fail(IPSubject("198.51.100.1"))
+	detector.Fail(IPSubject("198.51.100.2"))
+	detector.Fail(KeySubject("a1b2c3"))
+	detector.Fail(KeySubject("a1b2c3"))
+	detector.Fail(KeySubject("a1b2c3"))
+	if _, isBanned := detector.Check(banned); !isBanned {
+		t.Error("Check() = not banned, want the ban kept at the subject cap")
+	}
+	if _, isBanned := detector.Check(KeySubject("a1b2c3")); !isBanned {
+		t.Error("Check() = not banned, want subjects past the cap still banned")
+	}
+	if stats := detector.Stats(); stats.Tracked != 2 || stats.Evicted != 2 {
+		t.Errorf("Stats() = %+v, want two subjects tracked and two evicted", stats)
+	}
+
+	// With every tracked subject banned, failures of new subjects are not tracked
+	detector.Fail(IPSubject("198.51.100.3"))
+	if stats := detector.Stats(); stats.Tracked != 2 || stats.Untracked != 1 {
+		t.Errorf("Stats() = %+v, want one failure untracked", stats)
+	}
+}
+
+func TestDetector_Start(t *testing.T) {
+	detector, fakeClock := testDetector(t)
+	detector.Fail(IPSubject("203.0.113.7"))
+	fakeClock.Advance(2 * time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	detector.Start(ctx, time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for detector.Stats().Tracked != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Stats().Tracked = 1, want the idle subject swept")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewDetector(t *testing.T) {
+	valid := config.AbuseConfig{Enabled: true, Threshold: 10, Window: time.Minute, BanDuration: time.Minute, MaxBan: time.Hour}
+	tests := []struct {
+		name    string
+		modify  func(configuration *config.AbuseConfig)
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*config.AbuseConfig) {}},
+		{name: "disabled", modify: func(configuration *config.AbuseConfig) { configuration.Enabled = false }, wantNil: true},
+		{name: "zero threshold", modify: func(configuration *config.AbuseConfig) { configuration.Threshold = 0 }, wantNil: true, wantErr: true},
+		{name: "longest ban too short", modify: func(configuration *config.AbuseConfig) { configuration.MaxBan = time.Second }, wantNil: true, wantErr: true},
+		{name: "invalid CIDR", modify: func(configuration *config.AbuseConfig) { configuration.ExemptCIDRs = []string{"10.0.0.1"} }, wantNil: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configuration := valid
+			tt.modify(&configuration)
+			detector, err := NewDetector(configuration, testutils.MockLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDetector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (detector == nil) != tt.wantNil {
+				t.Errorf("NewDetector() = %v, wantNil %v", detector, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestDetector_Nil(t *testing.T) {
+	var detector *Detector
+	detector.Fail(IPSubject("203.0.113.7"))
+	if _, banned := detector.Check(IPSubject("203.0.113.7")); banned || detector.Stats() != nil || len(detector.Bans()) != 0 {
+		t.Error("nil Detector banned or reported stats")
+	}
+}
@@ -0,0 +1,213 @@
+// Package admission caps the API requests served at once. Under overload, requests wait
+// in per-client queues served by weighted fair queueing, so one client's burst cannot
+// starve the others.
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// ErrOverloaded is returned when a request can neither be served nor queued
+var ErrOverloaded = errors.New("server is at its concurrency limit")
+
+// waiter is a queued request, granted a slot by closing ready
+type waiter struct {
+	ready  chan struct{}
+	tag    float64 // Virtual time the request starts at; the smallest tag is served first
+	client *client
+}
+
+// client holds the queued requests of one client, in arrival order
+type client struct {
+	key    string
+	queue  []*waiter
+	finish float64 // Virtual time the client's last queued request finishes at
+}
+
+// Scheduler admits at most maxInFlight requests at once. Requests beyond the cap queue
+// per client, and a freed slot goes to the queued request with the smallest start tag:
+// each request advances its client's virtual time by 1/weight, so waiting clients are
+// served in proportion to their weights whatever their queue lengths.
+// A nil value is valid and admits every request.
+type Scheduler struct {
+	maxInFlight    int
+	queuePerClient int
+	queueSize      int
+	queueTimeout   time.Duration
+	tierWeights    map[string]int
+
+	mutex       sync.Mutex
+	inFlight    int
+	queued      int
+	virtualTime float64
+	clients     map[string]*client // Clients with queued requests
+	admitted    int64
+	delayed     int64
+	rejected    int64
+	timedOut    int64
+}
+
+// NewScheduler returns the scheduler of the configured concurrency limit, or nil when
+// requests are unlimited
+func NewScheduler(configuration config.AdmissionConfig) *Scheduler {
+	if configuration.MaxInFlight <= 0 {
+		return nil
+	}
+	return &Scheduler{
+		maxInFlight:    configuration.MaxInFlight,
+		queuePerClient: max(configuration.QueuePerClient, 0),
+		queueSize:      max(configuration.QueueSize, 0),
+		queueTimeout:   configuration.QueueTimeout,
+		tierWeights:    configuration.TierWeights,
+		clients:        make(map[string]*client),
+	}
+}
+
+// Acquire admits a request of the client, queueing it while the scheduler is at its cap.
+// Requests of a tier are weighted by the tier's weight, others by 1. It fails with
+// ErrOverloaded when the queues are full or the request waited out the queue timeout,
+// and with the context's error when the context ends first. The returned function frees
+// the slot once the request is served.
+func (scheduler *Scheduler) Acquire(ctx context.Context, key, tier string) (func(), error) {
+	if scheduler == nil {
+		return func() {}, nil
+	}
+
+	scheduler.mutex.Lock()
+	if scheduler.inFlight < scheduler.maxInFlight && scheduler.queued == 0 {
+		scheduler.inFlight++
+		scheduler.admitted++
+		scheduler.mutex.Unlock()
+		return scheduler.release, nil
+	}
+
+	queuedClient := scheduler.clients[key]
+	clientQueued := 0
+	if queuedClient != nil {
+		clientQueued = len(queuedClient.queue)
+	}
+	if scheduler.queued >= scheduler.queueSize || clientQueued >= scheduler.queuePerClient {
+		scheduler.rejected++
+		scheduler.mutex.Unlock()
+		return nil, ErrOverloaded
+	}
+	if queuedClient == nil {
+		queuedClient = &client{key: key, finish: scheduler.virtualTime}
+		scheduler.clients[key] = queuedClient
+	}
+	weight, found := scheduler.tierWeights[tier]
+	if !found || weight <= 0 {
+		weight = 1
+	}
+	request := &waiter{
+		ready:  make(chan struct{}),
+		tag:    max(queuedClient.finish, scheduler.virtualTime),
+		client: queuedClient,
+	}
+	queuedClient.finish = request.tag + 1/float64(weight)
+	queuedClient.queue = append(queuedClient.queue, request)
+	scheduler.queued++
+	scheduler.mutex.Unlock()
+
+	var timeout <-chan time.Time
+	if scheduler.queueTimeout > 0 {
+		timer := time.NewTimer(scheduler.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	err := ErrOverloaded
+	select {
+	case <-request.ready:
+		return scheduler.release, nil
+	case <-timeout:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	select {
+	case <-request.ready:
+		// Granted a slot while the context ended: the request is served after all
+		return scheduler.release, nil
+	default:
+	}
+	scheduler.dequeue(request)
+	scheduler.timedOut++
+	return nil, err
+}
+
+// release frees a slot, handing it to the next queued request
+func (scheduler *Scheduler) release() {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	next := scheduler.next()
+	if next == nil {
+		scheduler.inFlight--
+		return
+	}
+	scheduler.dequeue(next)
+	scheduler.virtualTime = next.tag
+	scheduler.admitted++
+	scheduler.delayed++
+	close(next.ready)
+}
+
+// next returns the queued request with the smallest start tag, breaking ties by client key
+// so the order is deterministic (caller holds the lock)
+func (scheduler *Scheduler) next() *waiter {
+	var next *waiter
+	for _, queuedClient := range scheduler.clients {
+		head := queuedClient.queue[0]
+		if next == nil || head.tag < next.tag || (head.tag == next.tag && head.client.key < next.client.key) {
+			next = head
+		}
+	}
+	return next
+}
+
+// dequeue removes a queued request, forgetting its client once nothing of it is queued
+// (caller holds the lock)
+func (scheduler *Scheduler) dequeue(request *waiter) {
+	queuedClient := request.client
+	for index, queuedRequest := range queuedClient.queue {
+		if queuedRequest == request {
+			queuedClient.queue = append(queuedClient.queue[:index], queuedClient.queue[index+1:]...)
+			break
+		}
+	}
+	scheduler.queued--
+	if len(queuedClient.queue) == 0 {
+		delete(scheduler.clients, queuedClient.key)
+	}
+}
+
+// Stats reports the requests in flight and queued, or nothing when unlimited
+func (scheduler *Scheduler) Stats() *models.AdmissionStats {
+	if scheduler == nil {
+		return nil
+	}
+
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+
+	return &models.AdmissionStats{
+		MaxInFlight:    scheduler.maxInFlight,
+		InFlight:       scheduler.inFlight,
+		Queued:         scheduler.queued,
+		QueuedClients:  len(scheduler.clients),
+		QueueSize:      scheduler.queueSize,
+		QueuePerClient: scheduler.queuePerClient,
+		Admitted:       scheduler.admitted,
+		Delayed:        scheduler.delayed,
+		Rejected:       scheduler.rejected,
+		TimedOut:       scheduler.timedOut,
+	}
+}
@@ -0,0 +1,156 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// grant is a queued request admitted by the scheduler
+type grant struct {
+	key     string
+	release func()
+}
+
+// enqueue starts a request of the client and waits until it is queued; admitted
+// requests are sent on granted
+func enqueue(t *testing.T, scheduler *Scheduler, key, tier string, granted chan<- grant) {
+	t.Helper()
+	queued := scheduler.Stats().Queued
+	go func() {
+		release, err := scheduler.Acquire(context.Background(), key, tier)
+		if err != nil {
+			t.Errorf("Acquire(%s) error = %v", key, err)
+			return
+		}
+		granted <- grant{key: key, release: release}
+	}()
+	for scheduler.Stats().Queued == queued {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// serveOrder releases the held slot and records the order the queued requests are admitted in
+func serveOrder(scheduler *Scheduler, release func(), granted <-chan grant, count int) []string {
+	order := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		release()
+		next := <-granted
+		order = append(order, next.key)
+		release = next.release
+	}
+	release()
+	return order
+}
+
+func TestNewScheduler(t *testing.T) {
+	if scheduler := NewScheduler(config.AdmissionConfig{}); scheduler != nil {
+		t.Error("NewScheduler() without a limit should return nil")
+	}
+
+	var scheduler *Scheduler
+	release, err := scheduler.Acquire(context.Background(), "client", "")
+	if err != nil {
+		t.Fatalf("Acquire() on a nil scheduler error = %v", err)
+	}
+	release()
+	if scheduler.Stats() != nil {
+		t.Error("Stats() of a nil scheduler should be nil")
+	}
+}
+
+func TestScheduler_FairQueueing(t *testing.T) {
+	scheduler := NewScheduler(config.AdmissionConfig{MaxInFlight: 1, QueuePerClient: 8, QueueSize: 16})
+	release, _ := scheduler.Acquire(context.Background(), "held", "")
+	granted := make(chan grant)
+
+	// A batch client queues first, yet a small client is not served behind its whole batch
+	for i := 0; i < 4; i++ {
+		enqueue(t, scheduler, "batch", "", granted)
+	}
+	enqueue(t, scheduler, "small", "", granted)
+
+	want := []string{"batch", "small", "batch", "batch", "batch"}
+	if order := serveOrder(scheduler, release, granted, len(want)); !equal(order, want) {
+		t.Errorf("admission order = %v, want %v", order, want)
+	}
+
+	stats := scheduler.Stats()
+	if stats.InFlight != 0 || stats.Queued != 0 || stats.QueuedClients != 0 || stats.Admitted != 6 || stats.Delayed != 5 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestScheduler_TierWeights(t *testing.T) {
+	scheduler := NewScheduler(config.AdmissionConfig{MaxInFlight: 1, QueuePerClient: 8, QueueSize: 16, TierWeights: map[string]int{"pro": 2}})
+	release, _ := scheduler.Acquire(context.Background(), "held", "")
+	granted := make(chan grant)
+
+	for i := 0; i < 4; i++ {
+		enqueue(t, scheduler, "a-pro", "pro", granted)
+	}
+	for i := 0; i < 2; i++ {
+		enqueue(t, scheduler, "b-free", "free", granted)
+	}
+
+	// The pro client gets two slots for each slot of the free client
+	want := []string{"a-pro", "b-free", "a-pro", "a-pro", "b-free", "a-pro"}
+	if order := serveOrder(scheduler, release, granted, len(want)); !equal(order, want) {
+		t.Errorf("admission order = %v, want %v", order, want)
+	}
+}
+
+func TestScheduler_QueueLimits(t *testing.T) {
+	scheduler := NewScheduler(config.AdmissionConfig{MaxInFlight: 1, QueuePerClient: 1, QueueSize: 2})
+	release, _ := scheduler.Acquire(context.Background(), "held", "")
+	granted := make(chan grant, 2)
+
+	enqueue(t, scheduler, "a", "", granted)
+	if _, err := scheduler.Acquire(context.Background(), "a", ""); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Acquire() beyond the client's queue error = %v, want %v", err, ErrOverloaded)
+	}
+	enqueue(t, scheduler, "b", "", granted)
+	if _, err := scheduler.Acquire(context.Background(), "c", ""); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Acquire() beyond the queue size error = %v, want %v", err, ErrOverloaded)
+	}
+	if stats := scheduler.Stats(); stats.Rejected != 2 {
+		t.Errorf("Stats().Rejected = %d, want 2", stats.Rejected)
+	}
+	serveOrder(scheduler, release, granted, 2)
+}
+
+func TestScheduler_QueueTimeout(t *testing.T) {
+	scheduler := NewScheduler(config.AdmissionConfig{MaxInFlight: 1, QueuePerClient: 1, QueueSize: 1, QueueTimeout: 10 * time.Millisecond})
+	release, _ := scheduler.Acquire(context.Background(), "held", "")
+	defer release()
+
+	if _, err := scheduler.Acquire(context.Background(), "a", ""); !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Acquire() past the queue timeout error = %v, want %v", err, ErrOverloaded)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := scheduler.Acquire(ctx, "a", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() with an ended context error = %v, want %v", err, context.Canceled)
+	}
+
+	stats := scheduler.Stats()
+	if stats.TimedOut != 2 || stats.Queued != 0 || stats.QueuedClients != 0 || stats.InFlight != 1 {
+		t.Errorf("Stats() = %+v, want two timed out requests and nothing queued", stats)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+// Package alert evaluates threshold rules on the metrics the service gathers and notifies
+// sinks when an alert starts firing or resolves.
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/clock"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/quota"
+	"github.com/dalfonso89/currency-exchange-service/service"
+)
+
+// Metrics that rules can be evaluated on
+const (
+	ProviderErrorRate = "provider_error_rate" // Failed fraction of each provider's calls since the last evaluation
+	QuotaConsumption  = "quota_consumption"   // Used fraction of each key's daily and monthly quota
+	CacheStaleness    = "cache_staleness"     // Seconds since each base's latest rates were fetched
+	RequestErrorRate  = "request_error_rate"  // Fraction of API requests since the last evaluation that failed with a 5xx
+	AuthFailures      = "auth_failures"       // Authentication failures since the last evaluation
+)
+
+// metricDescriptions name the metrics in alert messages
+var metricDescriptions = map[string]string{
+	ProviderErrorRate: "provider error rate",
+	QuotaConsumption:  "quota consumption",
+	CacheStaleness:    "cache staleness in seconds",
+	RequestErrorRate:  "request error rate",
+	AuthFailures:      "authentication failures",
+}
+
+// RequestCounter reports the API requests served and how many failed with a server error
+type RequestCounter interface {
+	RequestCounts() (total, errors int64)
+}
+
+// Sources are where the metrics are read from; metrics of a nil source are not evaluated
+type Sources struct {
+	Rates    *service.RatesService
+	Quotas   *quota.Manager
+	Requests RequestCounter
+	Abuse    *abuse.Detector
+}
+
+// sample is the value of a metric for one subject, such as a provider or base
+type sample struct {
+	subject string
+	value   float64
+}
+
+// alertKey identifies the alert of one rule and subject
+type alertKey struct {
+	rule    string
+	subject string
+}
+
+// alertState tracks a rule's subject while its metric is above the threshold
+type alertState struct {
+	breachingSince time.Time
+	firing         bool
+	alert          models.Alert
+}
+
+// delivery is a notification due to a rule's sinks
+type delivery struct {
+	notification Notification
+	sinks        []string
+}
+
+// counts are cumulative calls and failures, turned into rates between evaluations
+type counts struct {
+	total  int64
+	errors int64
+}
+
+// Engine evaluates the alert rules on an interval. A nil engine is valid and reports no
+// alerts.
+type Engine struct {
+	rules    []config.AlertRule
+	sinks    map[string]Sink
+	interval time.Duration
+	logger   logger.Logger
+	clock    clock.Clock
+
+	mutex         sync.Mutex
+	sources       Sources
+	states        map[alertKey]*alertState
+	lastProviders map[string]counts
+	lastRequests  counts
+	lastFailures  int64
+}
+
+// NewEngine creates an engine for the configured rules, or returns nil when there are
+// none. A sink named "log" is always available and notifies rules without sinks.
+func NewEngine(configuration config.AlertingConfig, sources Sources, log logger.Logger) (*Engine, error) {
+	if len(configuration.Rules) == 0 {
+		return nil, nil
+	}
+	if configuration.EvaluationInterval <= 0 {
+		return nil, fmt.Errorf("ALERT_EVALUATION_INTERVAL_SECONDS must be positive")
+	}
+
+	sinks := map[string]Sink{SinkLog: logSink{logger: log}}
+	for _, sinkConfiguration := range configuration.Sinks {
+		sink, err := newSink(sinkConfiguration, log)
+		if err != nil {
+			return nil, err
+		}
+		sinks[sinkConfiguration.Name] = sink
+	}
+
+	rules := make([]config.AlertRule, len(configuration.Rules))
+	for i, rule := range configuration.Rules {
+		if _, known := metricDescriptions[rule.Metric]; !known {
+			return nil, fmt.Errorf("alert rule %s has unknown metric %q", rule.Name, rule.Metric)
+		}
+		if len(rule.Sinks) == 0 {
+			rule.Sinks = []string{SinkLog}
+		}
+		for _, sinkName := range rule.Sinks {
+			if _, found := sinks[sinkName]; !found {
+				return nil, fmt.Errorf("alert rule %s names unknown sink %s", rule.Name, sinkName)
+			}
+		}
+		rules[i] = rule
+	}
+
+	return &Engine{
+		rules:         rules,
+		sinks:         sinks,
+		interval:      configuration.EvaluationInterval,
+		logger:        log,
+		clock:         clock.System,
+		sources:       sources,
+		states:        make(map[alertKey]*alertState),
+		lastProviders: make(map[string]counts),
+	}, nil
+}
+
+// SetRequestCounter sets the source of the request error rate, which is created after the
+// engine
+func (engine *Engine) SetRequestCounter(counter RequestCounter) {
+	if engine == nil {
+		return
+	}
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	engine.sources.Requests = counter
+}
+
+// Start evaluates the rules every interval until ctx is cancelled
+func (engine *Engine) Start(ctx context.Context) {
+	if engine == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(engine.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				engine.Evaluate(ctx)
+			}
+		}
+	}()
+}
+
+// Evaluate reads the metrics once, fires the alerts whose metric has stayed above the
+// threshold for the rule's duration and resolves those back at or below it. Subjects
+// without a value, such as providers that were not called, count as below.
+func (engine *Engine) Evaluate(ctx context.Context) {
+	engine.mutex.Lock()
+	now := engine.clock.Now()
+	samples := engine.collect(now)
+
+	var deliveries []delivery
+	for _, rule := range engine.rules {
+		breaching := make(map[string]bool)
+		for _, metricSample := range samples[rule.Metric] {
+			if metricSample.value <= rule.Threshold {
+				continue
+			}
+			key := alertKey{rule: rule.Name, subject: metricSample.subject}
+			breaching[metricSample.subject] = true
+			state, found := engine.states[key]
+			if !found {
+				state = &alertState{breachingSince: now}
+				engine.states[key] = state
+			}
+			state.alert = models.Alert{
+				Rule:      rule.Name,
+				Metric:    rule.Metric,
+				Subject:   metricSample.subject,
+				Severity:  rule.Severity,
+				Message:   message(rule, metricSample),
+				Value:     metricSample.value,
+				Threshold: rule.Threshold,
+				FiredAt:   state.alert.FiredAt,
+			}
+			if !state.firing && now.Sub(state.breachingSince) >= rule.For {
+				state.firing = true
+				state.alert.FiredAt = now
+				deliveries = append(deliveries, delivery{Notification{Status: StatusFiring, Alert: state.alert}, rule.Sinks})
+			}
+		}
+
+		for key, state := range engine.states {
+			if key.rule != rule.Name || breaching[key.subject] {
+				continue
+			}
+			delete(engine.states, key)
+			if state.firing {
+				resolvedAt := now
+				deliveries = append(deliveries, delivery{Notification{Status: StatusResolved, Alert: state.alert, ResolvedAt: &resolvedAt}, rule.Sinks})
+			}
+		}
+	}
+	engine.mutex.Unlock()
+
+	// Sinks are called without the lock, so a slow webhook does not block Active
+	for _, due := range deliveries {
+		for _, sinkName := range due.sinks {
+			notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+			if err := engine.sinks[sinkName].Notify(notifyCtx, due.notification); err != nil {
+				engine.logger.Errorf("Failed to notify alert sink %s of %s: %v", sinkName, due.notification.Rule, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Active returns the firing alerts, sorted by rule and subject
+func (engine *Engine) Active() []models.Alert {
+	alerts := []models.Alert{}
+	if engine == nil {
+		return alerts
+	}
+
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	for _, state := range engine.states {
+		if state.firing {
+			alerts = append(alerts, state.alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+		return alerts[i].Subject < alerts[j].Subject
+	})
+	return alerts
+}
+
+// collect reads the current value of every metric from its source (caller holds the lock)
+func (engine *Engine) collect(now time.Time) map[string][]sample {
+	samples := make(map[string][]sample)
+
+	if engine.sources.Rates != nil {
+		for _, status := range engine.sources.Rates.GetProviderStatus() {
+			current := counts{total: status.Calls, errors: status.Failures}
+			last := engine.lastProviders[status.Name]
+			engine.lastProviders[status.Name] = current
+			if current.total > last.total {
+				rate := float64(current.errors-last.errors) / float64(current.total-last.total)
+				samples[ProviderErrorRate] = append(samples[ProviderErrorRate], sample{subject: status.Name, value: rate})
+			}
+		}
+		for base, age := range engine.sources.Rates.CacheAges() {
+			samples[CacheStaleness] = append(samples[CacheStaleness], sample{subject: base, value: age.Seconds()})
+		}
+	}
+
+	if engine.sources.Quotas != nil {
+		for _, consumption := range engine.sources.Quotas.Consumption(now) {
+			samples[QuotaConsumption] = append(samples[QuotaConsumption], sample{
+				subject: consumption.KeyID + " " + consumption.Period,
+				value:   float64(consumption.Used) / float64(consumption.Limit),
+			})
+		}
+	}
+
+	if engine.sources.Requests != nil {
+		total, errors := engine.sources.Requests.RequestCounts()
+		current := counts{total: total, errors: errors}
+		last := engine.lastRequests
+		engine.lastRequests = current
+		if current.total > last.total {
+			rate := float64(current.errors-last.errors) / float64(current.total-last.total)
+			samples[RequestErrorRate] = append(samples[RequestErrorRate], sample{value: rate})
+		}
+	}
+
+	if engine.sources.Abuse != nil {
+		failures := engine.sources.Abuse.AuthFailures()
+		samples[AuthFailures] = append(samples[AuthFailures], sample{value: float64(failures - engine.lastFailures)})
+		engine.lastFailures = failures
+	}
+
+	return samples
+}
+
+// message describes the metric of a sample against the rule's threshold
+func message(rule config.AlertRule, metricSample sample) string {
+	description := metricDescriptions[rule.Metric]
+	if metricSample.subject != "" {
+		description += " of " + metricSample.subject
+	}
+	return fmt.Sprintf("%s is %g, above %g", description, metricSample.value, rule.Threshold)
+}
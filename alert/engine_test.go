@@ -0,0 +1,148 @@
+package alert
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/quota"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// recordingSink keeps the notifications it receives
+type recordingSink struct {
+	mutex         sync.Mutex
+	notifications []Notification
+}
+
+func (sink *recordingSink) Notify(ctx context.Context, notification Notification) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.notifications = append(sink.notifications, notification)
+	return nil
+}
+
+// fakeRequests reports scripted request counts
+type fakeRequests struct{ total, errors int64 }
+
+func (requests *fakeRequests) RequestCounts() (int64, int64) {
+	return requests.total, requests.errors
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	requests := &fakeRequests{}
+	quotas := quota.NewManager(nil, testutils.MockLogger())
+	engine, err := NewEngine(config.AlertingConfig{
+		EvaluationInterval: time.Minute,
+		Rules: []config.AlertRule{
+			{Name: "api_errors", Metric: RequestErrorRate, Threshold: 0.1, For: 2 * time.Minute, Severity: "critical", Sinks: []string{"pager"}},
+			{Name: "quota_nearly_spent", Metric: QuotaConsumption, Threshold: 0.8, Severity: "warning", Sinks: []string{"pager"}},
+		},
+		Sinks: []config.AlertSink{{Name: "pager", Type: SinkLog}},
+	}, Sources{Quotas: quotas, Requests: requests}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	sink := &recordingSink{}
+	engine.sinks["pager"] = sink
+	engine.clock = fakeClock
+	evaluate := func(total, errors int64) {
+		requests.total, requests.errors = requests.total+total, requests.errors+errors
+		engine.Evaluate(context.Background())
+		fakeClock.Advance(time.Minute)
+	}
+
+	// The error rate must stay above the threshold for two minutes before firing
+	evaluate(100, 50)
+	evaluate(100, 20)
+	if len(sink.notifications) != 0 || len(engine.Active()) != 0 {
+		t.Fatalf("notifications before the rule's duration = %+v", sink.notifications)
+	}
+	evaluate(100, 30)
+	if len(sink.notifications) != 1 || sink.notifications[0].Status != StatusFiring || sink.notifications[0].Value != 0.3 {
+		t.Fatalf("notifications = %+v, want one firing at 0.3", sink.notifications)
+	}
+	if active := engine.Active(); len(active) != 1 || active[0].Rule != "api_errors" || active[0].Severity != "critical" {
+		t.Errorf("Active() = %+v", active)
+	}
+
+	// Still firing: no new notification; then the rate drops and the alert resolves
+	evaluate(100, 40)
+	evaluate(100, 0)
+	if len(sink.notifications) != 2 || sink.notifications[1].Status != StatusResolved || sink.notifications[1].ResolvedAt == nil {
+		t.Fatalf("notifications = %+v, want firing then resolved", sink.notifications)
+	}
+	if active := engine.Active(); len(active) != 0 {
+		t.Errorf("Active() after resolution = %+v", active)
+	}
+
+	// Quota consumption fires per key and period at once
+	for i := 0; i < 9; i++ {
+		quotas.Allow("key-1", quota.Limits{Daily: 10, Monthly: 1000}, fakeClock.Now())
+	}
+	evaluate(0, 0)
+	active := engine.Active()
+	if len(active) != 1 || active[0].Subject != "key-1 daily" || active[0].Value != 0.9 {
+		t.Errorf("Active() = %+v, want the daily quota of key-1 at 0.9", active)
+	}
+}
+
+func TestEngine_AuthFailures(t *testing.T) {
+	detector, err := abuse.NewDetector(config.AbuseConfig{Enabled: true, Threshold: 100, Window: time.Minute, BanDuration: time.Minute, MaxBan: time.Hour}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+	engine, err := NewEngine(config.AlertingConfig{
+		EvaluationInterval: time.Minute,
+		Rules:              []config.AlertRule{{Name: "key_guessing", Metric: AuthFailures, Threshold: 5, Severity: "warning"}},
+	}, Sources{Abuse: detector}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+
+	for i := 0; i < 8; i++ {
+		detector.Fail(abuse.IPSubject("203.0.113.7"))
+	}
+	engine.Evaluate(context.Background())
+	if active := engine.Active(); len(active) != 1 || active[0].Value != 8 {
+		t.Fatalf("Active() = %+v, want the 8 failures firing", active)
+	}
+
+	// Failures are counted since the last evaluation
+	detector.Fail(abuse.IPSubject("203.0.113.7"))
+	engine.Evaluate(context.Background())
+	if active := engine.Active(); len(active) != 0 {
+		t.Errorf("Active() = %+v, want the alert resolved at 1 failure", active)
+	}
+}
+
+func TestNewEngine_Configuration(t *testing.T) {
+	if engine, err := NewEngine(config.AlertingConfig{EvaluationInterval: time.Minute}, Sources{}, testutils.MockLogger()); engine != nil || err != nil {
+		t.Errorf("NewEngine() without rules = %v, %v, want nil", engine, err)
+	}
+	if active := (*Engine)(nil).Active(); active == nil || len(active) != 0 {
+		t.Errorf("nil Engine.Active() = %v, want empty", active)
+	}
+
+	tests := []struct {
+		name          string
+		configuration config.AlertingConfig
+	}{
+		{"unknown metric", config.AlertingConfig{EvaluationInterval: time.Minute, Rules: []config.AlertRule{{Name: "r", Metric: "cpu"}}}},
+		{"unknown sink", config.AlertingConfig{EvaluationInterval: time.Minute, Rules: []config.AlertRule{{Name: "r", Metric: CacheStaleness, Sinks: []string{"pager"}}}}},
+		{"sink without URL", config.AlertingConfig{EvaluationInterval: time.Minute, Rules: []config.AlertRule{{Name: "r", Metric: CacheStaleness}}, Sinks: []config.AlertSink{{Name: "ops", Type: SinkSlack}}}},
+		{"unknown sink type", config.AlertingConfig{EvaluationInterval: time.Minute, Rules: []config.AlertRule{{Name: "r", Metric: CacheStaleness}}, Sinks: []config.AlertSink{{Name: "ops", Type: "email", URL: "x"}}}},
+		{"no interval", config.AlertingConfig{Rules: []config.AlertRule{{Name: "r", Metric: CacheStaleness}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEngine(tt.configuration, Sources{}, testutils.MockLogger()); err == nil {
+				t.Error("NewEngine() should reject the configuration")
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Outage webhook formats
+const (
+	FormatSlack = "slack" // Slack incoming webhook message with an attachment
+	FormatTeams = "teams" // Microsoft Teams connector message card
+)
+
+// outageQueueSize is how many notices may wait for the webhook before new ones are dropped
+const outageQueueSize = 64
+
+// maxErrorSummary caps the length of the error quoted in an outage notice
+const maxErrorSummary = 300
+
+// Colours of outage and recovery notices
+const (
+	outageColour   = "#D93F0B"
+	recoveryColour = "#2EB67D"
+)
+
+// outageNotice is a provider outage or recovery waiting to be posted
+type outageNotice struct {
+	provider  string
+	recovered bool
+	summary   string        // Error that took the provider out, or what brought it back
+	at        time.Time     // When the provider went out or recovered
+	downFor   time.Duration // How long the outage lasted (recoveries only)
+}
+
+// OutageNotifier posts to a Slack or Teams webhook when a provider is taken out of
+// fetches because of its failures, and again when it is back. Notices are posted in order
+// on a background goroutine. A nil notifier is valid and posts nothing.
+type OutageNotifier struct {
+	url        string
+	format     string
+	httpClient *http.Client
+	logger     logger.Logger
+
+	queue chan outageNotice
+	done  chan struct{}
+
+	mutex     sync.Mutex
+	downSince map[string]time.Time // Providers out since a failure, by name
+	closed    bool
+}
+
+// NewOutageNotifier creates a notifier for the configured outage webhook and starts
+// posting, or returns nil when no webhook is configured
+func NewOutageNotifier(configuration config.AlertingConfig, log logger.Logger) (*OutageNotifier, error) {
+	if configuration.OutageWebhookURL == "" {
+		return nil, nil
+	}
+	if configuration.OutageWebhookFormat != FormatSlack && configuration.OutageWebhookFormat != FormatTeams {
+		return nil, fmt.Errorf("PROVIDER_OUTAGE_WEBHOOK_FORMAT must be slack or teams, got %q", configuration.OutageWebhookFormat)
+	}
+
+	notifier := &OutageNotifier{
+		url:        configuration.OutageWebhookURL,
+		format:     configuration.OutageWebhookFormat,
+		httpClient: &http.Client{Timeout: notifyTimeout},
+		logger:     log,
+		queue:      make(chan outageNotice, outageQueueSize),
+		done:       make(chan struct{}),
+		downSince:  make(map[string]time.Time),
+	}
+	go notifier.run()
+	return notifier, nil
+}
+
+// ProviderTransition queues a notice when the transition takes a provider out because of
+// its failures, or brings back a provider that was. Transitions made by operators
+// otherwise go unannounced. It never blocks, so it can be the rates service's transition
+// listener.
+func (notifier *OutageNotifier) ProviderTransition(transition models.ProviderTransition) {
+	if notifier == nil {
+		return
+	}
+
+	notifier.mutex.Lock()
+	defer notifier.mutex.Unlock()
+	if notifier.closed {
+		return
+	}
+
+	since, down := notifier.downSince[transition.Provider]
+	var notice outageNotice
+	switch {
+	case transition.To == "enabled" && down:
+		delete(notifier.downSince, transition.Provider)
+		notice = outageNotice{
+			provider:  transition.Provider,
+			recovered: true,
+			summary:   fmt.Sprintf("re-enabled by %s: %s", transition.By, transition.Reason),
+			at:        transition.At,
+			downFor:   transition.At.Sub(since),
+		}
+	case transition.From == "enabled" && transition.By == "health":
+		notifier.downSince[transition.Provider] = transition.At
+		notice = outageNotice{provider: transition.Provider, summary: transition.Reason, at: transition.At}
+	default:
+		return
+	}
+
+	select {
+	case notifier.queue <- notice:
+	default:
+		notifier.logger.Warnf("Outage notification queue full, dropping notice for provider %s", transition.Provider)
+	}
+}
+
+// Close posts the queued notices and stops the notifier; later transitions are ignored
+func (notifier *OutageNotifier) Close() {
+	if notifier == nil {
+		return
+	}
+
+	notifier.mutex.Lock()
+	notifier.closed = true
+	close(notifier.queue)
+	notifier.mutex.Unlock()
+	<-notifier.done
+}
+
+// run posts queued notices until the queue is closed
+func (notifier *OutageNotifier) run() {
+	defer close(notifier.done)
+
+	for notice := range notifier.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		if err := postJSON(ctx, notifier.httpClient, notifier.url, notifier.payload(notice)); err != nil {
+			notifier.logger.Errorf("Failed to post outage notice for provider %s: %v", notice.provider, err)
+		}
+		cancel()
+	}
+}
+
+// payload formats the notice for the webhook: a title, a colour and the provider, error
+// summary and time or duration as fields
+func (notifier *OutageNotifier) payload(notice outageNotice) any {
+	title := fmt.Sprintf("Provider %s is down", notice.provider)
+	colour := outageColour
+	fields := []outageField{
+		{"Provider", notice.provider},
+		{"Error", truncate(notice.summary, maxErrorSummary)},
+		{"Down since", notice.at.UTC().Format(time.RFC3339)},
+	}
+	if notice.recovered {
+		title = fmt.Sprintf("Provider %s recovered", notice.provider)
+		colour = recoveryColour
+		fields = []outageField{
+			{"Provider", notice.provider},
+			{"Recovery", truncate(notice.summary, maxErrorSummary)},
+			{"Down for", notice.downFor.Round(time.Second).String()},
+		}
+	}
+
+	if notifier.format == FormatTeams {
+		facts := make([]teamsFact, len(fields))
+		for i, field := range fields {
+			facts[i] = teamsFact{Name: field.name, Value: field.value}
+		}
+		return teamsMessageCard{
+			Type:       "MessageCard",
+			Context:    "https://schema.org/extensions",
+			Summary:    title,
+			ThemeColor: colour[1:],
+			Title:      title,
+			Sections:   []teamsSection{{Facts: facts}},
+		}
+	}
+
+	attachmentFields := make([]slackField, len(fields))
+	for i, field := range fields {
+		attachmentFields[i] = slackField{Title: field.name, Value: field.value, Short: field.name != "Error" && field.name != "Recovery"}
+	}
+	return slackMessage{
+		Text:        title,
+		Attachments: []slackAttachment{{Color: colour, Fields: attachmentFields}},
+	}
+}
+
+// outageField is a named value of an outage notice
+type outageField struct {
+	name  string
+	value string
+}
+
+// slackAttachment is a coloured block of fields in a Slack message
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Fields []slackField `json:"fields"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// teamsMessageCard is the legacy message card accepted by Teams incoming webhooks
+type teamsMessageCard struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	Summary    string         `json:"summary"`
+	ThemeColor string         `json:"themeColor"`
+	Title      string         `json:"title"`
+	Sections   []teamsSection `json:"sections"`
+}
+
+type teamsSection struct {
+	Facts []teamsFact `json:"facts"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// truncate shortens text to at most limit bytes, marking the cut with an ellipsis
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit-3] + "..."
+}
@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// recordingWebhook keeps the bodies posted to it
+type recordingWebhook struct {
+	mutex  sync.Mutex
+	bodies [][]byte
+}
+
+func (webhook *recordingWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	webhook.mutex.Lock()
+	defer webhook.mutex.Unlock()
+	webhook.bodies = append(webhook.bodies, body)
+}
+
+func TestOutageNotifier_Slack(t *testing.T) {
+	webhook := &recordingWebhook{}
+	server := httptest.NewServer(webhook)
+	defer server.Close()
+
+	notifier, err := NewOutageNotifier(config.AlertingConfig{OutageWebhookURL: server.URL, OutageWebhookFormat: FormatSlack}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewOutageNotifier() error = %v", err)
+	}
+
+	down := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	notifier.ProviderTransition(models.ProviderTransition{Provider: "erapi", From: "enabled", To: "standby", By: "health", Reason: "status 401: invalid key", At: down})
+	// Operators disabling a provider are not an outage
+	notifier.ProviderTransition(models.ProviderTransition{Provider: "frankfurter", From: "enabled", To: "disabled", By: "operator", Reason: "maintenance", At: down})
+	notifier.ProviderTransition(models.ProviderTransition{Provider: "frankfurter", From: "disabled", To: "enabled", By: "operator", At: down.Add(time.Minute)})
+	notifier.ProviderTransition(models.ProviderTransition{Provider: "erapi", From: "standby", To: "enabled", By: "health", Reason: "3 consecutive successes", At: down.Add(90 * time.Minute)})
+	notifier.Close()
+
+	if len(webhook.bodies) != 2 {
+		t.Fatalf("posted %d notices, want 2", len(webhook.bodies))
+	}
+	var outage, recovery slackMessage
+	if err := json.Unmarshal(webhook.bodies[0], &outage); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(webhook.bodies[1], &recovery); err != nil {
+		t.Fatal(err)
+	}
+	if outage.Text != "Provider erapi is down" || outage.Attachments[0].Color != outageColour || outage.Attachments[0].Fields[1].Value != "status 401: invalid key" {
+		t.Errorf("outage notice = %s", webhook.bodies[0])
+	}
+	if recovery.Text != "Provider erapi recovered" || recovery.Attachments[0].Color != recoveryColour || recovery.Attachments[0].Fields[2].Value != "1h30m0s" {
+		t.Errorf("recovery notice = %s", webhook.bodies[1])
+	}
+}
+
+func TestOutageNotifier_Teams(t *testing.T) {
+	webhook := &recordingWebhook{}
+	server := httptest.NewServer(webhook)
+	defer server.Close()
+
+	notifier, err := NewOutageNotifier(config.AlertingConfig{OutageWebhookURL: server.URL, OutageWebhookFormat: FormatTeams}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewOutageNotifier() error = %v", err)
+	}
+	notifier.ProviderTransition(models.ProviderTransition{Provider: "erapi", From: "enabled", To: "standby", By: "health", Reason: "status 401", At: time.Now()})
+	notifier.Close()
+
+	var card teamsMessageCard
+	if len(webhook.bodies) != 1 || json.Unmarshal(webhook.bodies[0], &card) != nil {
+		t.Fatalf("posted %q, want one message card", webhook.bodies)
+	}
+	if card.Type != "MessageCard" || card.Title != "Provider erapi is down" || card.ThemeColor != outageColour[1:] || len(card.Sections[0].Facts) != 3 {
+		t.Errorf("message card = %s", webhook.bodies[0])
+	}
+}
+
+func TestNewOutageNotifier(t *testing.T) {
+	if notifier, err := NewOutageNotifier(config.AlertingConfig{}, testutils.MockLogger()); notifier != nil || err != nil {
+		t.Errorf("NewOutageNotifier() without a URL = %v, %v, want nil", notifier, err)
+	}
+	if _, err := NewOutageNotifier(config.AlertingConfig{OutageWebhookURL: "https://example.com", OutageWebhookFormat: "email"}, testutils.MockLogger()); err == nil {
+		t.Error("NewOutageNotifier() should reject an unknown format")
+	}
+
+	// A nil notifier ignores transitions
+	var notifier *OutageNotifier
+	notifier.ProviderTransition(models.ProviderTransition{Provider: "erapi", From: "enabled", To: "standby", By: "health"})
+	notifier.Close()
+}
@@ -0,0 +1,135 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Sink types
+const (
+	SinkLog     = "log"     // Log records
+	SinkWebhook = "webhook" // JSON notifications POSTed to a URL
+	SinkSlack   = "slack"   // Slack-compatible incoming webhook messages
+)
+
+// Notification statuses
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// notifyTimeout bounds the delivery of a notification to one sink
+const notifyTimeout = 10 * time.Second
+
+// Notification reports an alert that started firing or resolved
+type Notification struct {
+	Status string `json:"status"` // StatusFiring or StatusResolved
+	models.Alert
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Sink delivers notifications
+type Sink interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// newSink creates the sink of the configuration
+func newSink(configuration config.AlertSink, log logger.Logger) (Sink, error) {
+	switch configuration.Type {
+	case SinkLog:
+		return logSink{logger: log}, nil
+	case SinkWebhook, SinkSlack:
+		if configuration.URL == "" {
+			return nil, fmt.Errorf("alert sink %s needs a URL", configuration.Name)
+		}
+		return &webhookSink{
+			url:        configuration.URL,
+			slack:      configuration.Type == SinkSlack,
+			httpClient: &http.Client{Timeout: notifyTimeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("alert sink %s has unknown type %q: use log, webhook or slack", configuration.Name, configuration.Type)
+	}
+}
+
+// logSink writes notifications to the service log
+type logSink struct {
+	logger logger.Logger
+}
+
+// Notify logs the notification, firing alerts as warnings
+func (sink logSink) Notify(ctx context.Context, notification Notification) error {
+	entry := sink.logger.WithFields(logger.Fields{
+		"alert":     notification.Rule,
+		"metric":    notification.Metric,
+		"subject":   notification.Subject,
+		"severity":  notification.Severity,
+		"value":     notification.Value,
+		"threshold": notification.Threshold,
+	})
+	if notification.Status == StatusFiring {
+		entry.Warn("Alert firing: " + notification.Message)
+	} else {
+		entry.Info("Alert resolved: " + notification.Message)
+	}
+	return nil
+}
+
+// webhookSink POSTs notifications as JSON, or as Slack messages
+type webhookSink struct {
+	url        string
+	slack      bool
+	httpClient *http.Client
+}
+
+// slackMessage is the payload of Slack-compatible incoming webhooks
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+// Notify posts the notification as JSON, or as a Slack message
+func (sink *webhookSink) Notify(ctx context.Context, notification Notification) error {
+	var payload any = notification
+	if sink.slack {
+		payload = slackMessage{Text: fmt.Sprintf("[%s] %s (%s): %s",
+			strings.ToUpper(notification.Status), notification.Rule, notification.Severity, notification.Message)}
+	}
+	return postJSON(ctx, sink.httpClient, sink.url, payload)
+}
+
+// postJSON posts the payload as JSON; any status but 2xx is an error
+func postJSON(ctx context.Context, httpClient *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
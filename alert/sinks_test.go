@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestWebhookSinks(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	notification := Notification{Status: StatusFiring, Alert: models.Alert{
+		Rule: "stale_rates", Metric: CacheStaleness, Subject: "USD", Severity: "warning",
+		Message: "cache staleness in seconds of USD is 900, above 600", Value: 900, Threshold: 600,
+		FiredAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}}
+
+	webhook, err := newSink(config.AlertSink{Name: "ops", Type: SinkWebhook, URL: server.URL + "/hook"}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if err := webhook.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("webhook Notify() error = %v", err)
+	}
+	var delivered Notification
+	if err := json.Unmarshal([]byte(bodies[0]), &delivered); err != nil || delivered.Status != StatusFiring || delivered.Rule != "stale_rates" || delivered.Subject != "USD" {
+		t.Errorf("webhook payload = %s", bodies[0])
+	}
+
+	slack, err := newSink(config.AlertSink{Name: "chat", Type: SinkSlack, URL: server.URL + "/slack"}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("newSink() error = %v", err)
+	}
+	if err := slack.Notify(context.Background(), notification); err != nil {
+		t.Fatalf("slack Notify() error = %v", err)
+	}
+	var message slackMessage
+	if err := json.Unmarshal([]byte(bodies[1]), &message); err != nil || !strings.HasPrefix(message.Text, "[FIRING] stale_rates (warning): cache staleness") {
+		t.Errorf("slack payload = %s", bodies[1])
+	}
+
+	failing, _ := newSink(config.AlertSink{Name: "broken", Type: SinkWebhook, URL: server.URL + "/failing"}, testutils.MockLogger())
+	if err := failing.Notify(context.Background(), notification); err == nil {
+		t.Error("Notify() should fail on a 500 response")
+	}
+}
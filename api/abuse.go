@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/usage"
+)
+
+// failedCredentialContextKey holds the abuse subject of the credential a request failed
+// to authenticate with
+const failedCredentialContextKey = "failed_credential"
+
+// abuseMiddleware refuses the requests of banned client IPs and credentials, and counts
+// the authentication failures of the others towards bans. A failure is charged to the
+// client IP and to the credential that was rejected, never to other credentials the
+// request presents, so a valid key sent along a bad token or signature is not banned.
+// Client IPs are the connection's peer address unless clientIPTrusted, so spoofed
+// X-Forwarded-For headers neither evade a ban nor ban another address. Health probes are
+// never refused, so a banned load balancer address does not take the instance out of
+// rotation.
+func (handlers *Handlers) abuseMiddleware(clientIPTrusted bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if path := context.FullPath(); path == "/health" || path == "/health/ready" {
+			context.Next()
+			return
+		}
+
+		clientIP := context.RemoteIP()
+		if clientIPTrusted {
+			clientIP = context.ClientIP()
+		}
+
+		if retryAfter, banned := handlers.abuse.Check(abuseSubjects(context, clientIP)...); banned {
+			seconds := int64(math.Ceil(retryAfter.Seconds()))
+			context.Header("Retry-After", strconv.FormatInt(seconds, 10))
+			handlers.writeErrorResponse(context, http.StatusTooManyRequests, "too many authentication failures",
+				fmt.Sprintf("banned for %d seconds", seconds))
+			context.Abort()
+			return
+		}
+
+		context.Next()
+		if context.Writer.Status() == http.StatusUnauthorized {
+			failed := []string{abuse.IPSubject(clientIP)}
+			if subject := context.GetString(failedCredentialContextKey); subject != "" {
+				failed = append(failed, subject)
+			}
+			handlers.abuse.Fail(failed...)
+		}
+	}
+}
+
+// abuseSubjects returns the client IP of a request and the credentials it presents: API
+// and admin keys by their key IDs, and the client ID of HTTP basic authentication. Bearer
+// tokens are not tracked, as each guess is a different token.
+func abuseSubjects(context *gin.Context, clientIP string) []string {
+	subjects := []string{abuse.IPSubject(clientIP)}
+	for _, header := range []string{"X-API-Key", "X-Admin-Key"} {
+		if key := context.GetHeader(header); key != "" {
+			subjects = append(subjects, abuse.KeySubject(usage.KeyID(key)))
+		}
+	}
+	if clientID, _, basic := context.Request.BasicAuth(); basic && clientID != "" {
+		subjects = append(subjects, abuse.ClientSubject(clientID))
+	}
+	return subjects
+}
+
+// failCredential notes the credential a request failed to authenticate with, to charge
+// the failure to it
+func failCredential(context *gin.Context, subject string) {
+	context.Set(failedCredentialContextKey, subject)
+}
+
+// GetBans lists the client IPs and credentials banned for failing authentication, the
+// longest lasting first
+func (handlers *Handlers) GetBans(context *gin.Context) {
+	handlers.render(context, http.StatusOK, gin.H{"bans": handlers.abuse.Bans()})
+}
+
+// banQuery holds the parameters of the admin endpoint lifting a ban
+type banQuery struct {
+	Subject string `uri:"subject" binding:"required"`
+}
+
+// ClearBan lifts a ban and forgets the subject's failures and past bans
+func (handlers *Handlers) ClearBan(context *gin.Context) {
+	var query banQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	if !handlers.abuse.Clear(query.Subject) {
+		handlers.writeErrorResponse(context, http.StatusNotFound, "ban not found", fmt.Sprintf("%s has no failures or bans", query.Subject))
+		return
+	}
+	handlers.loggerFor(context).Infof("Ban of %s lifted via admin API", query.Subject)
+	context.Status(http.StatusNoContent)
+}
+
+// ClearBans lifts every ban and forgets all failures
+func (handlers *Handlers) ClearBans(context *gin.Context) {
+	lifted := handlers.abuse.ClearAll()
+	handlers.loggerFor(context).Infof("%d bans lifted via admin API", lifted)
+	handlers.render(context, http.StatusOK, gin.H{"lifted": lifted})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+	"github.com/dalfonso89/currency-exchange-service/usage"
+)
+
+func TestHandlers_AbuseBans(t *testing.T) {
+	logger := testutils.MockLogger()
+	detector, err := abuse.NewDetector(config.AbuseConfig{Enabled: true, Threshold: 3, Window: time.Minute, BanDuration: time.Minute, MaxBan: time.Hour}, logger)
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+	router := NewHandlers(HandlerConfig{
+		Logger:      logger,
+		Tenants:     tenant.NewRegistry([]config.Tenant{{ID: "acme", APIKeys: []string{"acme-key"}, RateLimitRequests: 100, RateLimitBurst: 10}}),
+		AdminAPIKey: "admin-secret",
+		Abuse:       detector,
+	}).SetupRoutes(RouterOptions{})
+	request := func(method, target, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// An attacker guessing keys is banned once failures reach the threshold
+	for _, guess := range []string{"guess-1", "guess-2", "guess-3"} {
+		if w := request("GET", "/api/v1/currencies", "203.0.113.7:4000", map[string]string{"X-API-Key": guess}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("GET with key %s status = %v, want %v", guess, w.Code, http.StatusUnauthorized)
+		}
+	}
+	w := request("GET", "/api/v1/currencies", "203.0.113.7:4000", map[string]string{"X-API-Key": "acme-key"})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("GET from a banned IP status = %v, Retry-After = %q, want %v after 60 seconds", w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+	if w := request("GET", "/health", "203.0.113.7:4000", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /health from a banned IP status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if w := request("GET", "/api/v1/currencies", "198.51.100.1:4000", map[string]string{"X-API-Key": "acme-key"}); w.Code != http.StatusOK {
+		t.Errorf("GET from another IP status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	// Admins see the ban and lift it
+	admin := map[string]string{"X-Admin-Key": "admin-secret"}
+	w = request("GET", "/admin/v1/bans", "198.51.100.1:4000", admin)
+	var response struct {
+		Bans []models.Ban `json:"bans"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Bans) != 1 || response.Bans[0].Subject != "ip:203.0.113.7" {
+		t.Fatalf("GET /admin/v1/bans = %+v, want the attacker's IP", response.Bans)
+	}
+	if w := request("DELETE", "/admin/v1/bans/ip:203.0.113.7", "198.51.100.1:4000", admin); w.Code != http.StatusNoContent {
+		t.Errorf("DELETE /admin/v1/bans/ip:203.0.113.7 status = %v, want %v", w.Code, http.StatusNoContent)
+	}
+	if w := request("DELETE", "/admin/v1/bans/ip:203.0.113.7", "198.51.100.1:4000", admin); w.Code != http.StatusNotFound {
+		t.Errorf("DELETE of a lifted ban status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+	if w := request("GET", "/api/v1/currencies", "203.0.113.7:4000", map[string]string{"X-API-Key": "acme-key"}); w.Code != http.StatusOK {
+		t.Errorf("GET after the ban was lifted status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandlers_AbuseChargesFailedCredential(t *testing.T) {
+	logger := testutils.MockLogger()
+	detector, err := abuse.NewDetector(config.AbuseConfig{Enabled: true, Threshold: 3, Window: time.Minute, BanDuration: time.Minute, MaxBan: time.Hour}, logger)
+	if err != nil {
+		t.Fatalf("NewDetector() error = %v", err)
+	}
+	router := NewHandlers(HandlerConfig{
+		Logger:      logger,
+		Tenants:     tenant.NewRegistry([]config.Tenant{{ID: "acme", APIKeys: []string{"acme-key"}, RateLimitRequests: 100, RateLimitBurst: 10}}),
+		AdminAPIKey: "admin-secret",
+		Abuse:       detector,
+		Signatures: auth.NewSignatureVerifier(config.RequestSignatureConfig{
+			Secrets:   map[string]string{"acme-key": "shared-secret"},
+			Tolerance: 5 * time.Minute,
+		}),
+	}).SetupRoutes(RouterOptions{})
+
+	// Each attempt comes from another IP, so only credentials can reach the threshold
+	for i, headers := range []map[string]string{
+		{"X-API-Key": "acme-key", "X-Signature": "forged"},
+		{"X-API-Key": "acme-key", "X-Signature": "forged"},
+		{"X-API-Key": "acme-key", "X-Signature": "forged"},
+		{"X-API-Key": "guess"},
+		{"X-API-Key": "guess"},
+		{"X-API-Key": "guess"},
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/currencies", nil)
+		req.RemoteAddr = fmt.Sprintf("203.0.113.%d:4000", i+1)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("GET with %v status = %v, want %v", headers, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// The forged signatures are not charged to the valid key they were sent with
+	bans := detector.Bans()
+	if len(bans) != 1 || bans[0].Subject != abuse.KeySubject(usage.KeyID("guess")) {
+		t.Errorf("Bans() = %+v, want only the guessed key", bans)
+	}
+}
+
+func TestHandlers_AbuseSpoofedForwardedFor(t *testing.T) {
+	logger := testutils.MockLogger()
+	tenants := []config.Tenant{{ID: "acme", APIKeys: []string{"acme-key"}, RateLimitRequests: 100, RateLimitBurst: 10}}
+	newRouter := func(options RouterOptions) *gin.Engine {
+		detector, err := abuse.NewDetector(config.AbuseConfig{Enabled: true, Threshold: 3, Window: time.Minute, BanDuration: time.Minute, MaxBan: time.Hour}, logger)
+		if err != nil {
+			t.Fatalf("NewDetector() error = %v", err)
+		}
+		return NewHandlers(HandlerConfig{Logger: logger, Tenants: tenant.NewRegistry(tenants), Abuse: detector}).SetupRoutes(options)
+	}
+	request := func(router *gin.Engine, remoteAddr, forwardedFor, apiKey string) int {
+		req := httptest.NewRequest("GET", "/api/v1/currencies", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Without trusted proxies, bans hold the connection's peer address
+	router := newRouter(RouterOptions{})
+	for _, guess := range []string{"guess-1", "guess-2", "guess-3"} {
+		request(router, "203.0.113.7:4000", "198.51.100.1", guess)
+	}
+	if code := request(router, "203.0.113.7:4000", "198.51.100.99", "acme-key"); code != http.StatusTooManyRequests {
+		t.Errorf("GET from a banned peer with a new X-Forwarded-For status = %v, want %v", code, http.StatusTooManyRequests)
+	}
+	if code := request(router, "198.51.100.1:4000", "", "acme-key"); code != http.StatusOK {
+		t.Errorf("GET from an address named in X-Forwarded-For status = %v, want %v", code, http.StatusOK)
+	}
+
+	// Behind trusted proxies, bans hold the client the proxy forwarded for
+	router = newRouter(RouterOptions{TrustedProxies: []string{"10.0.0.0/8"}})
+	for _, guess := range []string{"guess-1", "guess-2", "guess-3"} {
+		request(router, "10.0.0.2:4000", "203.0.113.7", guess)
+	}
+	if code := request(router, "10.0.0.2:4000", "203.0.113.7", "acme-key"); code != http.StatusTooManyRequests {
+		t.Errorf("GET forwarded for a banned client status = %v, want %v", code, http.StatusTooManyRequests)
+	}
+	if code := request(router, "10.0.0.2:4000", "198.51.100.1", "acme-key"); code != http.StatusOK {
+		t.Errorf("GET forwarded for another client through the same proxy status = %v, want %v", code, http.StatusOK)
+	}
+}
+
+func TestAbuseSubjects(t *testing.T) {
+	req := httptest.NewRequest("POST", "/oauth/token", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	req.Header.Set("X-API-Key", "acme-key")
+	req.SetBasicAuth("billing", "secret")
+	context, _ := gin.CreateTestContext(httptest.NewRecorder())
+	context.Request = req
+
+	subjects := abuseSubjects(context, "203.0.113.7")
+	want := []string{"ip:203.0.113.7", "key:" + usage.KeyID("acme-key"), "client:billing"}
+	if len(subjects) != len(want) {
+		t.Fatalf("abuseSubjects() = %v, want %v", subjects, want)
+	}
+	for i := range want {
+		if subjects[i] != want[i] {
+			t.Errorf("abuseSubjects()[%d] = %q, want %q", i, subjects[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/usage"
+)
+
+// providerStateQuery holds the parameters of the admin provider enable and disable
+// endpoints
+type providerStateQuery struct {
+	Name    string `uri:"name" binding:"required"`
+	Standby bool   `form:"standby"`
+	Reason  string `form:"reason" binding:"max=200"`
+}
+
+// maxAdminAuditEntries is how many admin audit entries the service keeps in memory
+const maxAdminAuditEntries = 500
+
+// adminPathPrefix is the path prefix of the admin routes
+const adminPathPrefix = "/admin/"
+
+// adminContextKey is the Gin context key holding the authenticated admin principal
+const adminContextKey = "admin"
+
+// adminAuditLog keeps the recent admin audit entries
+type adminAuditLog struct {
+	mutex   sync.Mutex
+	entries []models.AdminAuditEntry // Oldest first
+}
+
+// record appends an entry, dropping the oldest past maxAdminAuditEntries
+func (auditLog *adminAuditLog) record(entry models.AdminAuditEntry) {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	auditLog.entries = append(auditLog.entries, entry)
+	if len(auditLog.entries) > maxAdminAuditEntries {
+		auditLog.entries = auditLog.entries[len(auditLog.entries)-maxAdminAuditEntries:]
+	}
+}
+
+// list returns the recorded entries, newest first
+func (auditLog *adminAuditLog) list() []models.AdminAuditEntry {
+	auditLog.mutex.Lock()
+	defer auditLog.mutex.Unlock()
+	entries := make([]models.AdminAuditEntry, len(auditLog.entries))
+	for i, entry := range auditLog.entries {
+		entries[len(entries)-1-i] = entry
+	}
+	return entries
+}
+
+// adminRoute registers an admin endpoint with the permission it requires
+func (handlers *Handlers) adminRoute(group *gin.RouterGroup, method, path string, permission auth.AdminPermission, handler gin.HandlerFunc) {
+	handlers.adminPermissions[method+" "+group.BasePath()+path] = permission
+	group.Handle(method, path, handler)
+}
+
+// adminAuthMiddleware requires, on routes under /admin/, an admin key in the X-Admin-Key
+// header whose role grants the permission of the route. Routes registered without a
+// permission are denied to every role. Calls that change state, and denied calls, are
+// audited. The admin API is disabled entirely when no admin key is configured.
+func (handlers *Handlers) adminAuthMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !strings.HasPrefix(context.FullPath(), adminPathPrefix) {
+			context.Next()
+			return
+		}
+
+		if handlers.adminKeys.Empty() {
+			handlers.writeErrorResponse(context, http.StatusForbidden, "forbidden", "admin API is disabled")
+			context.Abort()
+			handlers.auditAdminCall(context, auth.AdminPrincipal{})
+			return
+		}
+
+		adminKey := context.GetHeader("X-Admin-Key")
+		principal, authenticated := handlers.adminKeys.Authenticate(adminKey)
+		if !authenticated {
+			if adminKey != "" {
+				failCredential(context, abuse.KeySubject(usage.KeyID(adminKey)))
+			}
+			handlers.writeErrorResponse(context, http.StatusUnauthorized, "unauthorized", "missing or invalid admin key")
+			context.Abort()
+			handlers.auditAdminCall(context, principal)
+			return
+		}
+
+		permission, declared := handlers.adminPermissions[context.Request.Method+" "+context.FullPath()]
+		if !declared || !principal.Can(permission) {
+			handlers.writeErrorResponse(context, http.StatusForbidden, "forbidden", fmt.Sprintf("admin role %s may not call this endpoint", principal.Role))
+			context.Abort()
+			handlers.auditAdminCall(context, principal)
+			return
+		}
+
+		context.Set(adminContextKey, principal.Name)
+		context.Next()
+		handlers.auditAdminCall(context, principal)
+	}
+}
+
+// auditAdminCall records a call that changed state or was denied in the audit log and
+// in the service log
+func (handlers *Handlers) auditAdminCall(context *gin.Context, principal auth.AdminPrincipal) {
+	status := context.Writer.Status()
+	mutation := context.Request.Method != http.MethodGet && context.Request.Method != http.MethodHead
+	if !mutation && status != http.StatusUnauthorized && status != http.StatusForbidden {
+		return
+	}
+
+	entry := models.AdminAuditEntry{
+		At:        time.Now().UTC(),
+		Admin:     principal.Name,
+		Role:      principal.Role,
+		Method:    context.Request.Method,
+		Path:      context.Request.URL.RequestURI(),
+		Status:    status,
+		ClientIP:  context.ClientIP(),
+		RequestID: context.GetString("request_id"),
+	}
+	handlers.adminAudit.record(entry)
+	handlers.loggerFor(context).WithFields(logger.Fields{
+		"audit":     true,
+		"admin":     entry.Admin,
+		"role":      entry.Role,
+		"method":    entry.Method,
+		"path":      entry.Path,
+		"status":    entry.Status,
+		"client_ip": entry.ClientIP,
+	}).Info("Admin API call")
+}
+
+// GetAdminAudit lists the recent admin calls that changed state or were denied, newest first
+func (handlers *Handlers) GetAdminAudit(context *gin.Context) {
+	handlers.render(context, http.StatusOK, gin.H{"entries": handlers.adminAudit.list()})
+}
+
+// PurgeCache drops all cached rates so the next request fetches fresh data
+func (handlers *Handlers) PurgeCache(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	handlers.ratesService.PurgeCache(context.Request.Context())
+	handlers.loggerFor(context).Info("Cache purged via admin API")
+	context.Status(http.StatusNoContent)
+}
+
+// cacheTTLQuery holds the parameters of the admin cache TTL endpoint
+type cacheTTLQuery struct {
+	Seconds *int `form:"seconds" binding:"required,min=0"`
+}
+
+// SetCacheTTL changes how long fetched rates are cached until the next restart, so cache
+// TTLs can be compared under load without restarting the service
+func (handlers *Handlers) SetCacheTTL(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	var query cacheTTLQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	handlers.ratesService.SetCacheTTL(context.Request.Context(), time.Duration(*query.Seconds)*time.Second)
+	handlers.render(context, http.StatusOK, handlers.ratesService.CacheStats())
+}
+
+// DisableProvider holds a provider out of fetches. With ?standby=true it is probed and
+// re-enabled automatically once it recovers; otherwise it stays disabled until enabled.
+func (handlers *Handlers) DisableProvider(context *gin.Context) {
+	handlers.changeProviderState(context, func(query providerStateQuery) error {
+		return handlers.ratesService.DisableProvider(query.Name, query.Standby, query.Reason)
+	})
+}
+
+// EnableProvider puts a disabled provider back into fetches
+func (handlers *Handlers) EnableProvider(context *gin.Context) {
+	handlers.changeProviderState(context, func(query providerStateQuery) error {
+		return handlers.ratesService.EnableProvider(query.Name, query.Reason)
+	})
+}
+
+// changeProviderState applies an operator's change to a provider and renders its status
+func (handlers *Handlers) changeProviderState(context *gin.Context, change func(query providerStateQuery) error) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	var query providerStateQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+	if query.Reason == "" {
+		query.Reason = "requested via admin API"
+	}
+
+	if err := change(query); err != nil {
+		if errors.Is(err, service.ErrUnknownProvider) {
+			handlers.writeErrorResponse(context, http.StatusNotFound, "provider not found", err.Error())
+			return
+		}
+		handlers.writeErrorResponse(context, http.StatusInternalServerError, "provider update failed", err.Error())
+		return
+	}
+	for _, status := range handlers.ratesService.GetProviderStatus() {
+		if status.Name == query.Name {
+			handlers.render(context, http.StatusOK, status)
+			return
+		}
+	}
+}
+
+// GetProviderTransitions lists the recent provider state transitions, newest first
+func (handlers *Handlers) GetProviderTransitions(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	handlers.render(context, http.StatusOK, gin.H{"transitions": handlers.ratesService.ProviderTransitions()})
+}
+
+// GetAlerts lists the firing alerts
+func (handlers *Handlers) GetAlerts(context *gin.Context) {
+	handlers.render(context, http.StatusOK, gin.H{"alerts": handlers.alerts.Active()})
+}
+
+// GetConfig returns the configuration the service started with, with secrets redacted,
+// and where each variable came from, so operators can confirm what the process loaded.
+// The cache TTL is the one in effect, which the admin API can change at runtime.
+func (handlers *Handlers) GetConfig(context *gin.Context) {
+	if handlers.config == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "configuration unavailable", "not configured")
+		return
+	}
+
+	dump := handlers.config.Dump()
+	if handlers.ratesService != nil {
+		dump["RatesCacheTTL"] = handlers.ratesService.CacheTTL().String()
+	}
+	context.JSON(http.StatusOK, models.ConfigDump{
+		StartedAt: handlers.startTime,
+		Config:    dump,
+		Sources:   handlers.config.Sources(),
+	})
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/admission"
+)
+
+// admissionMiddleware holds API requests to the concurrency limit, queueing them per
+// client under overload and rejecting them with 503 once the client's queue is full.
+// Clients are tenant keys, or IP addresses without one; JWT tiers set their weights.
+// Rate streams stay open indefinitely, so they are not held to the limit.
+func (handlers *Handlers) admissionMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if strings.HasSuffix(context.FullPath(), "/stream") {
+			context.Next()
+			return
+		}
+
+		clientKey, tier := "ip:"+context.ClientIP(), ""
+		if handlers.rateLimiter != nil {
+			clientKey = "ip:" + handlers.rateLimiter.GetClientIP(context.Request)
+		}
+		if resolvedCaller, err := handlers.authenticate(context); err == nil {
+			clientKey, tier = "key:"+resolvedCaller.keyID, resolvedCaller.tier
+		}
+
+		release, err := handlers.admission.Acquire(context.Request.Context(), clientKey, tier)
+		if err != nil {
+			if errors.Is(err, admission.ErrOverloaded) {
+				handlers.loggerFor(context).Warnf("Request of %s rejected: %v", clientKey, err)
+				context.Header("Retry-After", "1")
+			}
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "overloaded", "the server is at its concurrency limit, retry shortly")
+			context.Abort()
+			return
+		}
+		defer release()
+
+		context.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/admission"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_AdmissionMiddleware(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	scheduler := admission.NewScheduler(config.AdmissionConfig{MaxInFlight: 1})
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Admission:    scheduler,
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := request("/api/v1/currencies"); w.Code != http.StatusOK {
+		t.Fatalf("request below the limit status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	// With the only slot taken and no queue, API requests are turned away
+	release, _ := scheduler.Acquire(context.Background(), "held", "")
+	defer release()
+	w := request("/api/v1/currencies")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("request at the limit status = %v, Retry-After = %q, want %v and 1", w.Code, w.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+
+	// Health checks are not held to the limit
+	if w := request("/health"); w.Code != http.StatusOK {
+		t.Errorf("health check at the limit status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if stats := scheduler.Stats(); stats.Rejected != 1 || stats.Admitted != 2 {
+		t.Errorf("Stats() = %+v, want 2 admitted and 1 rejected", stats)
+	}
+}
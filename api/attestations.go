@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/attestation"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// attestConversions attests each conversion of a tenant that requires attestations,
+// setting their attestation IDs. A conversion that cannot be attested is not served: it
+// writes a 503 and returns false.
+func (handlers *Handlers) attestConversions(context *gin.Context, conversions []models.ConversionResponse) bool {
+	value, exists := context.Get(tenantContextKey)
+	if !exists || !value.(*config.Tenant).Attestations {
+		return true
+	}
+	tenantID := value.(*config.Tenant).ID
+	if handlers.attestations == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "attestations unavailable", "not configured")
+		return false
+	}
+
+	for i := range conversions {
+		issued, err := handlers.attestations.Attest(context.Request.Context(), tenantID, conversions[i])
+		if err != nil {
+			handlers.loggerFor(context).Errorf("Failed to attest conversion of tenant %s: %v", tenantID, err)
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "attestations unavailable", "the conversion could not be attested")
+			return false
+		}
+		conversions[i].AttestationID = issued.ID
+	}
+	return true
+}
+
+// GetAttestation returns one of the tenant's attestations by ID; those of other tenants
+// are not found
+func (handlers *Handlers) GetAttestation(context *gin.Context) {
+	value, exists := context.Get(tenantContextKey)
+	if !exists {
+		handlers.writeErrorResponse(context, http.StatusNotFound, "attestation not found", context.Param("id"))
+		return
+	}
+	handlers.writeAttestation(context, value.(*config.Tenant).ID)
+}
+
+// GetAnyAttestation returns an attestation of any tenant by ID, for auditors with the
+// admin key
+func (handlers *Handlers) GetAnyAttestation(context *gin.Context) {
+	handlers.writeAttestation(context, "")
+}
+
+// writeAttestation writes the attestation named by the id parameter, if it belongs to the
+// tenant ("" = any tenant)
+func (handlers *Handlers) writeAttestation(context *gin.Context, tenantID string) {
+	if handlers.attestations == nil {
+		handlers.writeErrorResponse(context, http.StatusNotFound, "attestation not found", context.Param("id"))
+		return
+	}
+
+	issued, err := handlers.attestations.Get(context.Request.Context(), context.Param("id"))
+	if errors.Is(err, attestation.ErrNotFound) || (err == nil && tenantID != "" && issued.Tenant != tenantID) {
+		handlers.writeErrorResponse(context, http.StatusNotFound, "attestation not found", context.Param("id"))
+		return
+	}
+	if err != nil {
+		handlers.loggerFor(context).Errorf("Failed to read attestation %s: %v", context.Param("id"), err)
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "attestations unavailable", "the attestation could not be read")
+		return
+	}
+	handlers.render(context, http.StatusOK, issued)
+}
+
+// GetAttestationKeys publishes the key set verifying attestations, for auditors
+func (handlers *Handlers) GetAttestationKeys(context *gin.Context) {
+	context.JSON(http.StatusOK, handlers.attestations.KeySet())
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/attestation"
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// memoryAttestations keeps attestations in a map
+type memoryAttestations struct {
+	mutex        sync.Mutex
+	attestations map[string]models.Attestation
+	err          error
+}
+
+func (store *memoryAttestations) RecordAttestation(ctx context.Context, attestation models.Attestation) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.err != nil {
+		return store.err
+	}
+	store.attestations[attestation.ID] = attestation
+	return nil
+}
+
+func (store *memoryAttestations) Attestation(ctx context.Context, id string) (models.Attestation, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	attestation, found := store.attestations[id]
+	return attestation, found, nil
+}
+
+func TestHandlers_Attestations(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "attestations.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := auth.NewAttestationSigner(config.AttestationConfig{SigningKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+	store := &memoryAttestations{attestations: map[string]models.Attestation{}}
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{
+		{ID: "bank", APIKeys: []string{"bank-key"}, RateLimitRequests: 100, RateLimitBurst: 10, Attestations: true},
+		{ID: "shop", APIKeys: []string{"shop-key"}, RateLimitRequests: 100, RateLimitBurst: 10},
+	}
+	logger := testutils.MockLogger()
+	router := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+		AdminAPIKey:  "admin-secret",
+		Attestations: attestation.NewLedger(signer, store),
+	}).SetupRoutes(RouterOptions{})
+
+	request := func(path, header, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(header, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/api/v1/convert?from=USD&to=EUR&amount=100", "X-API-Key", "bank-key")
+	if w.Code != http.StatusOK {
+		t.Fatalf("convert status = %d: %s", w.Code, w.Body.String())
+	}
+	var conversion models.ConversionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &conversion); err != nil || conversion.AttestationID == "" {
+		t.Fatalf("convert of an attesting tenant = %s, want an attestation ID", w.Body.String())
+	}
+
+	w = request("/api/v1/attestations/"+conversion.AttestationID, "X-API-Key", "bank-key")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET attestation status = %d: %s", w.Code, w.Body.String())
+	}
+	var issued models.Attestation
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil {
+		t.Fatalf("decoding attestation: %v", err)
+	}
+	if issued.Claims.Pair != "USD/EUR" || issued.Claims.Rate != conversion.Rate || issued.Claims.Provider != conversion.Provider || issued.Tenant != "bank" {
+		t.Errorf("attestation claims = %+v, want those of %+v", issued.Claims, conversion)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(issued.Payload)
+	if err := auth.VerifyAttestation(issued.JWS, payload, publicKey); err != nil {
+		t.Errorf("VerifyAttestation() error = %v", err)
+	}
+
+	// Other tenants cannot read the attestation; the admin key can
+	if w := request("/api/v1/attestations/"+conversion.AttestationID, "X-API-Key", "shop-key"); w.Code != http.StatusNotFound {
+		t.Errorf("GET attestation of another tenant status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := request("/admin/v1/attestations/"+conversion.AttestationID, "X-Admin-Key", "admin-secret"); w.Code != http.StatusOK {
+		t.Errorf("GET admin attestation status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// Every target of a multi-target conversion is attested; tenants without attestations get none
+	w = request("/api/v1/convert?from=USD&to=EUR,GBP&amount=100", "X-API-Key", "bank-key")
+	var conversions models.MultiConversionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &conversions); err != nil || len(conversions.Conversions) != 2 {
+		t.Fatalf("multi-target convert = %d %s", w.Code, w.Body.String())
+	}
+	for _, conversion := range conversions.Conversions {
+		if conversion.AttestationID == "" {
+			t.Errorf("conversion to %s has no attestation ID", conversion.To)
+		}
+	}
+	w = request("/api/v1/convert?from=USD&to=EUR&amount=100", "X-API-Key", "shop-key")
+	var unattested models.ConversionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &unattested); err != nil || unattested.AttestationID != "" {
+		t.Errorf("convert of a tenant without attestations = %s", w.Body.String())
+	}
+
+	w = request("/.well-known/attestation-keys.json", "", "")
+	var keySet auth.JSONWebKeySet
+	if err := json.Unmarshal(w.Body.Bytes(), &keySet); err != nil || len(keySet.Keys) != 1 || keySet.Keys[0].KeyID != issued.KeyID {
+		t.Errorf("GET /.well-known/attestation-keys.json = %d %s", w.Code, w.Body.String())
+	}
+
+	// A conversion that cannot be attested is not served
+	store.err = errors.New("database down")
+	if w := request("/api/v1/convert?from=USD&to=EUR&amount=100", "X-API-Key", "bank-key"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("convert without a working attestation store status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
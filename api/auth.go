@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/usage"
+)
+
+// Gin context keys of the authenticated caller
+const (
+	callerContextKey = "caller" // Remembered outcome of authenticating the request
+	keyIDContextKey  = "key_id" // Fingerprint of the caller's API key or token subject
+)
+
+// errMissingCredentials reports a request without a known API key or bearer token
+var errMissingCredentials = errors.New("missing or invalid API key")
+
+// caller is the authenticated client of a request
+type caller struct {
+	tenant *config.Tenant
+	keyID  string // Fingerprint of the API key or token subject, for usage and quotas
+	tier   string // Rate limit tier of a JWT ("" = the tenant's limits)
+	apiKey string // API key the caller authenticated with ("" = bearer token)
+}
+
+// authentication is the remembered outcome of authenticating a request
+type authentication struct {
+	caller *caller
+	err    error
+}
+
+// tenantMiddleware resolves the tenant from the bearer token or API key when tenants are
+// configured
+func (handlers *Handlers) tenantMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if (handlers.tenants == nil || !handlers.tenants.Enabled()) && handlers.jwt == nil {
+			context.Next()
+			return
+		}
+
+		resolvedCaller, err := handlers.authenticate(context)
+		if errors.Is(err, auth.ErrKeysUnavailable) {
+			handlers.loggerFor(context).Errorf("Token verification failed: %v", err)
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "authentication unavailable", "token signing keys could not be fetched")
+			context.Abort()
+			return
+		}
+		if err != nil {
+			// A rejected bearer token is not charged to an API key sent along with it
+			if _, bearer := bearerToken(context); !bearer || handlers.jwt == nil {
+				if apiKey := context.GetHeader("X-API-Key"); apiKey != "" {
+					failCredential(context, abuse.KeySubject(usage.KeyID(apiKey)))
+				}
+			}
+			handlers.writeErrorResponse(context, http.StatusUnauthorized, "unauthorized", err.Error())
+			context.Abort()
+			return
+		}
+
+		context.Set(tenantContextKey, resolvedCaller.tenant)
+		context.Set(keyIDContextKey, resolvedCaller.keyID)
+		context.Next()
+	}
+}
+
+// authenticate resolves the request's caller from its bearer token or API key. The
+// outcome is remembered, so the rate limiter and the tenant middleware verify a token once.
+func (handlers *Handlers) authenticate(context *gin.Context) (*caller, error) {
+	if value, exists := context.Get(callerContextKey); exists {
+		outcome := value.(authentication)
+		return outcome.caller, outcome.err
+	}
+
+	resolvedCaller, err := handlers.resolveCaller(context)
+	context.Set(callerContextKey, authentication{caller: resolvedCaller, err: err})
+	return resolvedCaller, err
+}
+
+// resolveCaller verifies a bearer token when JWTs are accepted, or resolves the API key
+func (handlers *Handlers) resolveCaller(context *gin.Context) (*caller, error) {
+	if token, found := bearerToken(context); found && handlers.jwt != nil {
+		principal, err := handlers.jwt.Verify(context.Request.Context(), token)
+		if err != nil {
+			return nil, err
+		}
+		if handlers.tenants != nil {
+			if resolvedTenant, found := handlers.tenants.Lookup(principal.Tenant); found {
+				return &caller{tenant: resolvedTenant, keyID: usage.KeyID("jwt:" + principal.Subject), tier: principal.Tier}, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unknown tenant %q", auth.ErrInvalidToken, principal.Tenant)
+	}
+
+	if handlers.tenants != nil {
+		apiKey := context.GetHeader("X-API-Key")
+		if resolvedTenant, found := handlers.tenants.Resolve(apiKey); found {
+			return &caller{tenant: resolvedTenant, keyID: usage.KeyID(apiKey), apiKey: apiKey}, nil
+		}
+	}
+	return nil, errMissingCredentials
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(context *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(context.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
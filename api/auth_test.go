@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/ratelimit"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+	"github.com/dalfonso89/currency-exchange-service/usage"
+)
+
+func TestHandlers_JWTAuthentication(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+	issuer := testutils.NewMockJWTIssuer()
+	defer issuer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{
+		{ID: "acme", APIKeys: []string{"acme-key"}, RateLimitRequests: 100, RateLimitBurst: 10},
+		{ID: "globex", RateLimitRequests: 100, RateLimitBurst: 10},
+	}
+	cfg.RateLimitTiers = map[string]config.RateLimitTier{"free": {Requests: 1, Burst: 1}}
+	logger := testutils.MockLogger()
+	rateLimiter := ratelimit.NewLimiter(cfg, logger)
+	defer rateLimiter.Stop()
+	tracker := usage.NewTracker(nil, logger)
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		RateLimiter:  rateLimiter,
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+		Usage:        tracker,
+		JWT: auth.NewVerifier(config.JWTConfig{
+			JWKSURL:      issuer.URL(),
+			Audience:     "currency-api",
+			JWKSCacheTTL: time.Hour,
+			TenantClaim:  "tenant",
+			TierClaim:    "tier",
+		}, nil, nil),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	token := func(tenantID, tier string) string {
+		return issuer.Token(map[string]interface{}{
+			"sub": "user-42", "aud": "currency-api", "tenant": tenantID, "tier": tier,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	request := func(setHeader func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/rates/EUR", nil)
+		setHeader(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// A token authenticates a tenant without API keys; API keys keep working
+	if w := request(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token("globex", "")) }); w.Code != http.StatusOK {
+		t.Errorf("bearer token status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if w := request(func(req *http.Request) { req.Header.Set("X-API-Key", "acme-key") }); w.Code != http.StatusOK {
+		t.Errorf("API key status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	for name, header := range map[string]string{
+		"unknown tenant": "Bearer " + token("initech", ""),
+		"garbage token":  "Bearer not-a-token",
+		"basic auth":     "Basic dXNlcjpwYXNz",
+	} {
+		if w := request(func(req *http.Request) { req.Header.Set("Authorization", header) }); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s status = %v, want %v", name, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Tokens of a tier get the tier's rate limits
+	freeToken := token("globex", "free")
+	if w := request(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+freeToken) }); w.Code != http.StatusOK {
+		t.Fatalf("first free tier request status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if w := request(func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+freeToken) }); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second free tier request status = %v, want %v", w.Code, http.StatusTooManyRequests)
+	}
+
+	// Usage is recorded against the token subject
+	report, err := tracker.Report(testutils.MockContext(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	subjectRequests := int64(0)
+	for _, entry := range report.Entries {
+		if entry.Tenant == "globex" && entry.KeyID == usage.KeyID("jwt:user-42") {
+			subjectRequests += entry.Requests
+		}
+	}
+	if subjectRequests != 2 {
+		t.Errorf("usage of the token subject = %d requests, want 2 (report %+v)", subjectRequests, report.Entries)
+	}
+}
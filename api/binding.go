@@ -0,0 +1,375 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/export"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Parameters of the API endpoints. Path parameters use uri tags and query parameters form
+// tags; both are validated with binding tags, including the custom validators below.
+
+// healthQuery holds the parameters of GET /health; verbose adds the dependency checks
+type healthQuery struct {
+	Verbose bool `form:"verbose"`
+}
+
+// ratesQuery holds the parameters of GET /rates; without a base, the service's default
+// base is used, and without symbols every rate is returned
+type ratesQuery struct {
+	Base    string `form:"base" binding:"omitempty,currency"`
+	Symbols string `form:"symbols" binding:"omitempty,currency_list"`
+	sideParameter
+}
+
+// basePath holds the base currency of the /rates/:base routes
+type basePath struct {
+	Base string `uri:"base" form:"-" binding:"currency"`
+}
+
+// ratesByBaseParameters holds the parameters of GET /rates/:base
+type ratesByBaseParameters struct {
+	basePath
+	Symbols string `form:"symbols" binding:"omitempty,currency_list"`
+	sideParameter
+}
+
+// sideParameter chooses the side of the quote rates are served at
+type sideParameter struct {
+	Side string `form:"side" binding:"omitempty,oneof=mid bid ask"`
+}
+
+// convertQuery holds the parameters of GET /convert; to may list several targets
+type convertQuery struct {
+	From   string  `form:"from" binding:"required,currency"`
+	To     string  `form:"to" binding:"required,currency_list"`
+	Amount float64 `form:"amount,default=1" binding:"finite,gt=0"`
+	Date   string  `form:"date" binding:"omitempty,datetime=2006-01-02"`
+	sideParameter
+	calendarParameter
+}
+
+// pairQuery holds the parameters of GET /rate
+type pairQuery struct {
+	Pair string `form:"pair" binding:"required,currency_pair"`
+}
+
+// exportQuery holds the parameters of GET /rates/:base/export
+type exportQuery struct {
+	basePath
+	Format string `form:"format,default=csv" binding:"export_format"`
+	Date   string `form:"date" binding:"omitempty,datetime=2006-01-02"`
+	calendarParameter
+}
+
+// timeSeriesParameters holds the parameters of GET /rates/:base/timeseries
+type timeSeriesParameters struct {
+	basePath
+	Symbol   string `form:"symbol" binding:"required,currency"`
+	Interval string `form:"interval,default=1d" binding:"oneof=1h 1d"`
+	From     string `form:"from" binding:"omitempty,timestamp"`
+	To       string `form:"to" binding:"omitempty,timestamp"`
+	calendarParameter
+}
+
+// calendarParameter chooses how a historical query treats non-trading days, overriding
+// MARKET_DATE_CONVENTION
+type calendarParameter struct {
+	Convention string `form:"convention" binding:"omitempty,oneof=none previous"`
+}
+
+// streamQuery holds the pairs of the stream endpoints, and the encoding of a new stream
+type streamQuery struct {
+	Pairs    string `form:"pairs" binding:"omitempty,currency_pairs"`
+	Encoding string `form:"encoding" binding:"omitempty,oneof=full delta"`
+}
+
+// fieldErrors lists every invalid parameter of a request
+type fieldErrors []models.FieldError
+
+func (errs fieldErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, fieldError := range errs {
+		messages[i] = strings.TrimSpace(fieldError.Field + " " + fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// fieldMessages explains each failed validation tag; %s is replaced by the tag parameter
+var fieldMessages = map[string]string{
+	"required":       "is required",
+	"gt":             "must be greater than %s",
+	"finite":         "must be a finite number",
+	"oneof":          "must be one of: %s",
+	"datetime":       "must be formatted as YYYY-MM-DD",
+	"currency":       "must be a three-letter ISO 4217 currency code",
+	"currency_list":  "must be a comma-separated list of ISO 4217 currency codes",
+	"currency_pair":  "must be formatted as FROM/TO",
+	"currency_pairs": "must be a comma-separated list of FROM/TO pairs",
+	"timestamp":      "must be an RFC 3339 time or a YYYY-MM-DD date",
+	"export_format":  "must be csv or xlsx",
+}
+
+var registerValidatorsOnce sync.Once
+
+// bindParameters binds the request's path and query parameters into target and
+// validates them. Currency parameters are normalized first, so aliases like "€" pass as
+// their codes. The error lists every invalid parameter, including values that could not
+// be parsed.
+func bindParameters(context *gin.Context, target interface{}) error {
+	registerValidatorsOnce.Do(registerValidators)
+
+	currencyParameters := currencyParameters(reflect.TypeOf(target).Elem())
+	pathValues := make(map[string][]string, len(context.Params))
+	for _, param := range context.Params {
+		pathValues[param.Key] = []string{param.Value}
+	}
+	normalizeCurrencyParameters(pathValues, currencyParameters)
+	if err := binding.MapFormWithTag(target, pathValues, "uri"); err != nil {
+		return fieldErrors{{Message: err.Error()}}
+	}
+
+	// A value that cannot be parsed stops the mapping, so it is reported and dropped
+	// until the remaining parameters map and can be validated
+	queryValues := context.Request.URL.Query()
+	normalizeCurrencyParameters(queryValues, currencyParameters)
+	var invalid fieldErrors
+	unparsed := make(map[string]bool)
+	for {
+		err := binding.MapFormWithTag(target, queryValues, "form")
+		if err == nil {
+			break
+		}
+		var numberError *strconv.NumError
+		name := ""
+		if errors.As(err, &numberError) {
+			name = parameterWithValue(target, queryValues, numberError.Num)
+		}
+		if name == "" {
+			return append(invalid, models.FieldError{Message: err.Error()})
+		}
+		invalid = append(invalid, models.FieldError{Field: name, Message: "must be a number"})
+		unparsed[name] = true
+		delete(queryValues, name)
+	}
+
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return append(invalid, models.FieldError{Message: err.Error()})
+		}
+		for _, validationError := range validationErrors {
+			if !unparsed[validationError.Field()] {
+				invalid = append(invalid, fieldError(validationError))
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+// fieldError explains a failed validation
+func fieldError(validationError validator.FieldError) models.FieldError {
+	message, known := fieldMessages[validationError.Tag()]
+	if !known {
+		message = "is invalid"
+	}
+	if strings.Contains(message, "%s") {
+		message = strings.ReplaceAll(message, "%s", strings.ReplaceAll(validationError.Param(), " ", ", "))
+	}
+	return models.FieldError{Field: validationError.Field(), Message: message}
+}
+
+// parameterWithValue returns the query parameter of target holding value
+func parameterWithValue(target interface{}, values map[string][]string, value string) string {
+	for _, name := range parameterNames(reflect.TypeOf(target).Elem()) {
+		if submitted, found := values[name]; found && len(submitted) > 0 && submitted[0] == value {
+			return name
+		}
+	}
+	return ""
+}
+
+// parameterNames lists the query parameter names of a binding struct
+func parameterNames(structType reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			names = append(names, parameterNames(field.Type)...)
+			continue
+		}
+		if name := parameterName(field); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// currencyTags are the validations of parameters holding currency codes
+var currencyTags = []string{"currency", "currency_list", "currency_pair", "currency_pairs"}
+
+// currencyParameters maps the parameters of a binding struct that hold currency codes to
+// their validation tag
+func currencyParameters(structType reflect.Type) map[string]string {
+	parameters := make(map[string]string)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name, tag := range currencyParameters(field.Type) {
+				parameters[name] = tag
+			}
+			continue
+		}
+		name := parameterName(field)
+		if name == "" {
+			continue
+		}
+		for _, validation := range strings.Split(field.Tag.Get("binding"), ",") {
+			for _, tag := range currencyTags {
+				if validation == tag {
+					parameters[name] = tag
+				}
+			}
+		}
+	}
+	return parameters
+}
+
+// normalizeCurrencyParameters replaces the currency aliases in the values of the
+// currency parameters with their codes
+func normalizeCurrencyParameters(values map[string][]string, parameters map[string]string) {
+	for name, tag := range parameters {
+		for i, value := range values[name] {
+			values[name][i] = normalizeCurrencies(value, tag)
+		}
+	}
+}
+
+// normalizeCurrencies normalizes each code of a value validated with the tag
+func normalizeCurrencies(value, tag string) string {
+	switch tag {
+	case "currency":
+		return currency.Normalize(value)
+	case "currency_pair":
+		return normalizePair(value)
+	}
+
+	entries := strings.Split(value, ",")
+	for i, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		if tag == "currency_pairs" {
+			entries[i] = normalizePair(entry)
+		} else {
+			entries[i] = currency.Normalize(entry)
+		}
+	}
+	return strings.Join(entries, ",")
+}
+
+// normalizePair normalizes both codes of a FROM/TO pair
+func normalizePair(value string) string {
+	from, to, found := strings.Cut(value, "/")
+	if !found {
+		return value
+	}
+	return currency.Normalize(from) + "/" + currency.Normalize(to)
+}
+
+// parameterName returns the path or query parameter a struct field is bound to
+func parameterName(field reflect.StructField) string {
+	for _, tag := range []string{"uri", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return ""
+}
+
+// registerValidators adds the currency and time validators to Gin's validator and
+// names validation errors after the parameters rather than the struct fields
+func registerValidators() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if name := parameterName(field); name != "" {
+			return name
+		}
+		return field.Name
+	})
+
+	engine.RegisterValidation("currency", func(field validator.FieldLevel) bool {
+		return isCurrencyCode(field.Field().String())
+	})
+	engine.RegisterValidation("currency_list", func(field validator.FieldLevel) bool {
+		codes := parseCurrencyList(field.Field().String())
+		for _, code := range codes {
+			if !isCurrencyCode(code) {
+				return false
+			}
+		}
+		return len(codes) > 0
+	})
+	engine.RegisterValidation("currency_pair", func(field validator.FieldLevel) bool {
+		return isCurrencyPair(field.Field().String())
+	})
+	engine.RegisterValidation("currency_pairs", func(field validator.FieldLevel) bool {
+		for _, pair := range streamPairs(field.Field().String()) {
+			if !isCurrencyPair(pair) {
+				return false
+			}
+		}
+		return true
+	})
+	engine.RegisterValidation("finite", func(field validator.FieldLevel) bool {
+		value := field.Field().Float()
+		return !math.IsInf(value, 0) && !math.IsNaN(value)
+	})
+	engine.RegisterValidation("timestamp", func(field validator.FieldLevel) bool {
+		_, _, err := parseTimeParameter(field.Field().String())
+		return err == nil
+	})
+	engine.RegisterValidation("export_format", func(field validator.FieldLevel) bool {
+		_, err := export.ParseFormat(field.Field().String())
+		return err == nil
+	})
+}
+
+// isCurrencyCode reports whether value has the form of an ISO 4217 alphabetic code, in
+// either case. Whether a provider supports the currency is checked when rates are fetched.
+func isCurrencyCode(value string) bool {
+	if len(value) != 3 {
+		return false
+	}
+	for _, letter := range value {
+		if (letter < 'A' || letter > 'Z') && (letter < 'a' || letter > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// isCurrencyPair reports whether value is formatted as FROM/TO
+func isCurrencyPair(value string) bool {
+	from, to, found := strings.Cut(value, "/")
+	return found && isCurrencyCode(from) && isCurrencyCode(to)
+}
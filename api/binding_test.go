@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestBindParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantFields []string
+		want       convertQuery
+	}{
+		{name: "valid", query: "from=usd&to=eur,%20GBP&amount=2.5", want: convertQuery{From: "USD", To: "EUR,GBP", Amount: 2.5}},
+		{name: "aliases and symbols", query: "from=US$&to=%E2%82%AC,rmb", want: convertQuery{From: "USD", To: "EUR,CNY", Amount: 1}},
+		{name: "amount defaults to one", query: "from=USD&to=EUR", want: convertQuery{From: "USD", To: "EUR", Amount: 1}},
+		{name: "every missing field", query: "", wantFields: []string{"from", "to"}},
+		{name: "invalid codes and amount", query: "from=US&to=EUR,EUROS&amount=-1", wantFields: []string{"from", "to", "amount"}},
+		{name: "unparsable amount with other errors", query: "from=USD1&to=EUR&amount=abc", wantFields: []string{"amount", "from"}},
+		{name: "infinite amount", query: "from=USD&to=EUR&amount=Inf", wantFields: []string{"amount"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/v1/convert?"+tt.query, nil)
+
+			var query convertQuery
+			err := bindParameters(c, &query)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("bindParameters() error = %v", err)
+				}
+				if query != tt.want {
+					t.Errorf("bindParameters() = %+v, want %+v", query, tt.want)
+				}
+				return
+			}
+
+			fields, ok := err.(fieldErrors)
+			if !ok {
+				t.Fatalf("bindParameters() error = %v, want field errors", err)
+			}
+			names := []string{}
+			for _, field := range fields {
+				names = append(names, field.Field)
+			}
+			if !reflect.DeepEqual(names, tt.wantFields) {
+				t.Errorf("bindParameters() invalid fields = %v, want %v", names, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestBindParameters_PathAndQuery(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/rates/usd/export?format=pdf&date=yesterday", nil)
+	c.Params = gin.Params{{Key: "base", Value: "usdollar"}}
+
+	var query exportQuery
+	err := bindParameters(c, &query)
+	want := fieldErrors{
+		{Field: "base", Message: "must be a three-letter ISO 4217 currency code"},
+		{Field: "format", Message: "must be csv or xlsx"},
+		{Field: "date", Message: "must be formatted as YYYY-MM-DD"},
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("bindParameters() error = %#v, want %#v", err, want)
+	}
+}
+
+func TestBindParameters_NormalizesPathAndPairs(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/rates/%C2%A3/timeseries?symbol=%E2%82%AC", nil)
+	c.Params = gin.Params{{Key: "base", Value: "£"}}
+
+	var parameters timeSeriesParameters
+	if err := bindParameters(c, &parameters); err != nil {
+		t.Fatalf("bindParameters() error = %v", err)
+	}
+	if parameters.Base != "GBP" || parameters.Symbol != "EUR" {
+		t.Errorf("bindParameters() base, symbol = %s, %s, want GBP, EUR", parameters.Base, parameters.Symbol)
+	}
+
+	c.Request = httptest.NewRequest("GET", "/api/v1/stream?pairs=%E2%82%AC/US$,gbp/usd", nil)
+	c.Params = nil
+	var query streamQuery
+	if err := bindParameters(c, &query); err != nil {
+		t.Fatalf("bindParameters() error = %v", err)
+	}
+	if query.Pairs != "EUR/USD,GBP/USD" {
+		t.Errorf("bindParameters() pairs = %s, want EUR/USD,GBP/USD", query.Pairs)
+	}
+}
+
+func TestHandlers_ValidationErrorResponse(t *testing.T) {
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(testutils.MockConfig(), logger),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/convert?from=USD1&amount=0", nil))
+	var errorResponse models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errorResponse); err != nil {
+		t.Fatalf("v1 response unmarshal error = %v", err)
+	}
+	if w.Code != http.StatusBadRequest || len(errorResponse.Fields) != 3 {
+		t.Errorf("v1 response = %d %+v, want 400 with 3 invalid fields", w.Code, errorResponse)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v2/rate?pair=USDEUR", nil))
+	var problem models.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("v2 response unmarshal error = %v", err)
+	}
+	want := []models.FieldError{{Field: "pair", Message: "must be formatted as FROM/TO"}}
+	if w.Code != http.StatusBadRequest || !reflect.DeepEqual(problem.Fields, want) {
+		t.Errorf("v2 response = %d %+v, want 400 with fields %+v", w.Code, problem, want)
+	}
+}
+
+func FuzzNormalizeCurrencies(f *testing.F) {
+	for _, seed := range []string{"usd", "€", " rmb ", "EUR,gbp, ,£", "€/US$", "gbp/usd,eur/jpy", "a/b/c", ",,", "Kč/ǆ", "\xff/\xfe"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		for _, tag := range currencyTags {
+			normalized := normalizeCurrencies(value, tag)
+			if again := normalizeCurrencies(normalized, tag); again != normalized {
+				t.Errorf("normalizeCurrencies(%q, %s) = %q, then %q, want it stable", value, tag, normalized, again)
+			}
+		}
+
+		// Codes stay valid codes, in upper case
+		if isCurrencyCode(value) {
+			if code := normalizeCurrencies(value, "currency"); !isCurrencyCode(code) || code != strings.ToUpper(code) {
+				t.Errorf("normalizeCurrencies(%q, currency) = %q, want an upper-case code", value, code)
+			}
+		}
+		if isCurrencyPair(value) {
+			if pair := normalizeCurrencies(value, "currency_pair"); !isCurrencyPair(pair) || pair != strings.ToUpper(pair) {
+				t.Errorf("normalizeCurrencies(%q, currency_pair) = %q, want an upper-case pair", value, pair)
+			}
+		}
+	})
+}
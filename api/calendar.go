@@ -0,0 +1,44 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/calendar"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// conventionFor returns the calendar convention a historical query chose, or the
+// configured default
+func (handlers *Handlers) conventionFor(parameter calendarParameter) string {
+	if parameter.Convention != "" {
+		return parameter.Convention
+	}
+	return handlers.calendar.Convention()
+}
+
+// applyCalendar marks the points on non-trading days, or leaves them out under the
+// previous convention, since their rates roll to the business day before
+func (handlers *Handlers) applyCalendar(points []models.TimeSeriesPoint, convention string) []models.TimeSeriesPoint {
+	applied := points[:0]
+	for _, point := range points {
+		point.NonTradingDay = !handlers.calendar.IsTradingDay(point.Time)
+		if point.NonTradingDay && convention == calendar.ConventionPrevious {
+			continue
+		}
+		applied = append(applied, point)
+	}
+	return applied
+}
+
+// setCalendarHeaders documents the calendar convention of a downloaded file, which has
+// no metadata of its own
+func setCalendarHeaders(context *gin.Context, convention models.CalendarConvention) {
+	context.Header("X-Calendar-Convention", convention.Convention)
+	context.Header("X-Requested-Date", convention.RequestedDate)
+	context.Header("X-Non-Trading-Day", strconv.FormatBool(convention.NonTradingDay))
+	if convention.RolledTo != "" {
+		context.Header("X-Rolled-To", convention.RolledTo)
+	}
+}
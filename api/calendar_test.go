@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/calendar"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_ApplyCalendar(t *testing.T) {
+	marketCalendar, err := calendar.New(config.MarketCalendarConfig{Weekend: []string{"saturday", "sunday"}, Convention: calendar.ConventionPrevious})
+	if err != nil {
+		t.Fatalf("calendar.New() error = %v", err)
+	}
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger(), Calendar: marketCalendar})
+	points := func() []models.TimeSeriesPoint {
+		return []models.TimeSeriesPoint{
+			{Time: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), Close: 0.92},
+			{Time: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), Close: 0.921},
+			{Time: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), Close: 0.95},
+		}
+	}
+
+	// The configured convention applies unless the query chooses another
+	if convention := handlers.conventionFor(calendarParameter{}); convention != calendar.ConventionPrevious {
+		t.Errorf("conventionFor() = %s, want the configured previous", convention)
+	}
+	if convention := handlers.conventionFor(calendarParameter{Convention: calendar.ConventionNone}); convention != calendar.ConventionNone {
+		t.Errorf("conventionFor(none) = %s, want none", convention)
+	}
+
+	marked := handlers.applyCalendar(points(), calendar.ConventionNone)
+	if len(marked) != 3 || marked[0].NonTradingDay || !marked[1].NonTradingDay || marked[2].NonTradingDay {
+		t.Errorf("applyCalendar(none) = %+v, want the Saturday marked", marked)
+	}
+
+	rolled := handlers.applyCalendar(points(), calendar.ConventionPrevious)
+	if len(rolled) != 2 || rolled[0].Close != 0.92 || rolled[1].Close != 0.95 {
+		t.Errorf("applyCalendar(previous) = %+v, want the Saturday left out", rolled)
+	}
+}
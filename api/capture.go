@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/capture"
+)
+
+// captureWriter keeps the start of a response body while writing it through
+type captureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write writes the data through, keeping what fits under the limit
+func (writer *captureWriter) Write(data []byte) (int, error) {
+	writer.keep(data)
+	return writer.ResponseWriter.Write(data)
+}
+
+// WriteString writes the string through, keeping what fits under the limit
+func (writer *captureWriter) WriteString(data string) (int, error) {
+	writer.keep([]byte(data))
+	return writer.ResponseWriter.WriteString(data)
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController reaches its deadlines
+func (writer *captureWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+// keep appends data to the kept body up to the limit
+func (writer *captureWriter) keep(data []byte) {
+	room := writer.limit - writer.body.Len()
+	if len(data) > room {
+		data = data[:max(room, 0)]
+		writer.truncated = true
+	}
+	writer.body.Write(data)
+}
+
+// captureMiddleware records a sample of requests and their responses in the debug
+// capture buffer. Admin calls, which carry admin keys and would capture the captures, the
+// operational endpoints polled by monitoring and rate streams, which would hold their
+// buffer until they close, are never captured.
+func (handlers *Handlers) captureMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		path := context.FullPath()
+		switch {
+		case strings.HasPrefix(context.Request.URL.Path, adminPathPrefix),
+			path == "/health", path == "/health/ready", path == "/stats", path == "/debug/runtime",
+			strings.HasPrefix(path, "/dashboard"), strings.HasSuffix(path, "/stream"),
+			!handlers.capture.Sampled():
+			context.Next()
+			return
+		}
+
+		limit := handlers.capture.MaxBodyBytes()
+		var requestBody []byte
+		if context.Request.Body != nil {
+			// Read one byte past the limit to tell a truncated body, then hand the handler
+			// the whole body again
+			requestBody, _ = io.ReadAll(io.LimitReader(context.Request.Body, int64(limit)+1))
+			context.Request.Body = readCloser{io.MultiReader(bytes.NewReader(requestBody), context.Request.Body), context.Request.Body}
+		}
+		requestTruncated := len(requestBody) > limit
+		if requestTruncated {
+			requestBody = requestBody[:limit]
+		}
+
+		writer := &captureWriter{ResponseWriter: context.Writer, limit: limit}
+		context.Writer = writer
+		start := time.Now()
+		context.Next()
+
+		handlers.capture.Record(capture.Exchange{
+			Request:           context.Request,
+			RequestID:         context.GetString("request_id"),
+			ClientIP:          context.ClientIP(),
+			KeyID:             context.GetString(keyIDContextKey),
+			At:                start.UTC(),
+			Duration:          time.Since(start),
+			RequestBody:       requestBody,
+			RequestTruncated:  requestTruncated,
+			Status:            writer.Status(),
+			ResponseHeader:    writer.Header(),
+			ResponseBody:      writer.body.Bytes(),
+			ResponseTruncated: writer.truncated,
+		})
+	}
+}
+
+// readCloser reads from a reader and closes the original request body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureQuery holds the parameters of the admin endpoint listing captured requests
+type captureQuery struct {
+	RequestID string `form:"request_id"`
+}
+
+// GetCaptures lists the captured requests and responses, newest first, or those of one
+// request ID
+func (handlers *Handlers) GetCaptures(context *gin.Context) {
+	var query captureQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	handlers.render(context, http.StatusOK, gin.H{"captures": handlers.capture.List(query.RequestID)})
+}
+
+// ClearCaptures drops the captured requests and responses
+func (handlers *Handlers) ClearCaptures(context *gin.Context) {
+	cleared := handlers.capture.Clear()
+	handlers.loggerFor(context).Infof("%d captured requests cleared via admin API", cleared)
+	handlers.render(context, http.StatusOK, gin.H{"cleared": cleared})
+}
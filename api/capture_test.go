@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/capture"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/stream"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_Captures(t *testing.T) {
+	recorder, err := capture.NewRecorder(config.CaptureConfig{Enabled: true, SampleRate: 1, MaxBodyBytes: 32, BufferSize: 10})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	router := NewHandlers(HandlerConfig{
+		Logger:      testutils.MockLogger(),
+		Tenants:     tenant.NewRegistry([]config.Tenant{{ID: "acme", APIKeys: []string{"acme-secret-key"}, RateLimitRequests: 100, RateLimitBurst: 10}}),
+		AdminAPIKey: "admin-secret",
+		AdminKeys:   []config.AdminKey{{Name: "oncall", Role: config.AdminRoleOperator, Keys: []string{"oncall-secret"}}},
+		Capture:     recorder,
+	}).SetupRoutes(RouterOptions{Routes: []RouteRegistrar{func(router *gin.Engine) {
+		router.POST("/echo", func(context *gin.Context) {
+			body, _ := io.ReadAll(context.Request.Body)
+			context.Data(http.StatusOK, "text/plain", body)
+		})
+	}}})
+	request := func(method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Handlers still read the whole body past the capture cap
+	body := `{"api_key": "acme-secret-key", "note": "` + strings.Repeat("x", 40) + `"}`
+	if w := request("POST", "/echo", body, nil); w.Body.String() != body {
+		t.Fatalf("POST /echo = %q, want the whole body echoed", w.Body.String())
+	}
+	w := request("GET", "/api/v1/currencies", "", map[string]string{"X-API-Key": "acme-secret-key"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/currencies status = %v, want %v", w.Code, http.StatusOK)
+	}
+	requestID := w.Header().Get("X-Request-ID")
+
+	// Only the admin role sees the captures, which exclude admin calls
+	if w := request("GET", "/admin/v1/captures", "", map[string]string{"X-Admin-Key": "oncall-secret"}); w.Code != http.StatusForbidden {
+		t.Errorf("GET /admin/v1/captures as operator status = %v, want %v", w.Code, http.StatusForbidden)
+	}
+	admin := map[string]string{"X-Admin-Key": "admin-secret"}
+	w = request("GET", "/admin/v1/captures", "", admin)
+	var response struct {
+		Captures []models.CapturedExchange `json:"captures"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Captures) != 2 {
+		t.Fatalf("GET /admin/v1/captures = %+v, want the two API calls", response.Captures)
+	}
+	currencies, echo := response.Captures[0], response.Captures[1]
+	if currencies.RequestID != requestID || currencies.KeyID == "" || currencies.RequestHeaders["X-Api-Key"] != config.Redacted {
+		t.Errorf("captured GET = %+v, want its request ID, key ID and the key redacted", currencies)
+	}
+	if !echo.RequestBodyTruncated || !echo.ResponseBodyTruncated || strings.Contains(echo.RequestBody, "acme-secret-key") {
+		t.Errorf("captured POST bodies = %q, %q, want them truncated and the key redacted", echo.RequestBody, echo.ResponseBody)
+	}
+
+	w = request("GET", "/admin/v1/captures?request_id="+requestID, "", admin)
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Captures) != 1 {
+		t.Errorf("GET /admin/v1/captures?request_id= = %s, want one capture", w.Body.String())
+	}
+	if w := request("DELETE", "/admin/v1/captures", "", admin); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cleared":2`) {
+		t.Errorf("DELETE /admin/v1/captures = %v %s, want 2 cleared", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlers_CapturesStream(t *testing.T) {
+	recorder, err := capture.NewRecorder(config.CaptureConfig{Enabled: true, SampleRate: 1, MaxBodyBytes: 32, BufferSize: 10})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	logger := testutils.MockLogger()
+	hub := stream.NewHub(2, 64, stream.PolicyCoalesce, logger)
+	hub.RatesCached(models.RatesResponse{Base: "USD", Provider: "erapi", Rates: models.RateTable{"EUR": 0.8}})
+	router := NewHandlers(HandlerConfig{
+		Logger:          logger,
+		Capture:         recorder,
+		Stream:          hub,
+		StreamHeartbeat: 50 * time.Millisecond,
+	}).SetupRoutes(RouterOptions{Routes: []RouteRegistrar{func(router *gin.Engine) {
+		router.GET("/deadline", func(context *gin.Context) {
+			if err := http.NewResponseController(context.Writer).SetWriteDeadline(time.Time{}); err != nil {
+				context.String(http.StatusInternalServerError, err.Error())
+				return
+			}
+			context.Status(http.StatusNoContent)
+		})
+	}}})
+	const writeTimeout = 200 * time.Millisecond
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = writeTimeout
+	server.Start()
+	defer server.Close()
+
+	// Captured handlers still reach the connection's write deadline
+	resp, err := http.Get(server.URL + "/deadline")
+	if err != nil {
+		t.Fatalf("GET /deadline error = %v", err)
+	}
+	message, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("GET /deadline = %v %s, want the write deadline cleared", resp.StatusCode, message)
+	}
+
+	// A stream outlives the server's write timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/stream?pairs=EUR/USD", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/v1/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if event, _ := readEvent(t, reader); event != "connected" {
+		t.Fatalf("first event = %s, want connected", event)
+	}
+	for deadline := time.Now().Add(3 * writeTimeout); time.Now().Before(deadline); {
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("reading stream after %v error = %v, want it kept open", writeTimeout, err)
+		}
+	}
+
+	// Only the deadline call was captured
+	if captures := recorder.List(""); len(captures) != 1 || captures[0].Path != "/deadline" {
+		t.Errorf("List() = %+v, want only GET /deadline", captures)
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// dashboardFileSystem returns the embedded dashboard assets rooted at the dashboard directory
+func dashboardFileSystem() http.FileSystem {
+	assets, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(assets)
+}
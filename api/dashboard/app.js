@@ -0,0 +1,170 @@
+// Dashboard for the currency exchange service. Everything shown here comes from
+// the public JSON endpoints, refreshed every few seconds.
+(function () {
+  "use strict";
+
+  var refreshInterval = 5000;
+  var baseInput = document.getElementById("base");
+  var apiKeyInput = document.getElementById("api-key");
+
+  apiKeyInput.value = window.localStorage.getItem("cx-api-key") || "";
+  apiKeyInput.addEventListener("change", function () {
+    window.localStorage.setItem("cx-api-key", apiKeyInput.value);
+    refresh();
+  });
+  baseInput.addEventListener("change", refresh);
+
+  function fetchJSON(path) {
+    var headers = { Accept: "application/json" };
+    if (apiKeyInput.value) {
+      headers["X-API-Key"] = apiKeyInput.value;
+    }
+    return fetch(path, { headers: headers }).then(function (response) {
+      return response.json().then(function (body) {
+        if (!response.ok) {
+          throw new Error(body.detail || body.message || body.error || response.statusText);
+        }
+        return body;
+      });
+    });
+  }
+
+  // fetchData loads an API v2 resource and unwraps its response envelope
+  function fetchData(path) {
+    return fetchJSON(path).then(function (body) {
+      return body.data;
+    });
+  }
+
+  function cell(text, className) {
+    var td = document.createElement("td");
+    td.textContent = text;
+    if (className) {
+      td.className = className;
+    }
+    return td;
+  }
+
+  function fillRows(tbody, rows) {
+    tbody.replaceChildren.apply(tbody, rows.map(function (cells) {
+      var tr = document.createElement("tr");
+      cells.forEach(function (td) { tr.appendChild(td); });
+      return tr;
+    }));
+  }
+
+  function fillList(dl, entries) {
+    dl.replaceChildren();
+    entries.forEach(function (entry) {
+      var dt = document.createElement("dt");
+      var dd = document.createElement("dd");
+      dt.textContent = entry[0];
+      dd.textContent = entry[1];
+      if (entry[2]) {
+        dd.className = entry[2];
+      }
+      dl.appendChild(dt);
+      dl.appendChild(dd);
+    });
+  }
+
+  function showError(target, error) {
+    if (target.tagName === "DL") {
+      fillList(target, [["error", error.message, "error"]]);
+    } else {
+      fillRows(target, [[cell(error.message, "error")]]);
+    }
+  }
+
+  function statusClass(status) {
+    if (status >= 500) {
+      return "error";
+    }
+    return status >= 400 ? "warn" : "ok";
+  }
+
+  function loadHealth() {
+    var target = document.getElementById("health");
+    return fetchJSON("/health").then(function (health) {
+      fillList(target, [
+        ["status", health.status, health.status === "healthy" ? "ok" : "error"],
+        ["version", health.version],
+        ["uptime", health.uptime]
+      ]);
+    }).catch(function (error) { showError(target, error); });
+  }
+
+  function loadStats() {
+    var cacheTarget = document.getElementById("cache");
+    var requestsTarget = document.getElementById("requests");
+    return fetchJSON("/stats").then(function (stats) {
+      var cache = stats.cache || {};
+      var lookups = (cache.hits || 0) + (cache.misses || 0);
+      fillList(cacheTarget, [
+        ["hits", cache.hits || 0],
+        ["misses", cache.misses || 0],
+        ["hit ratio", lookups ? (100 * cache.hits / lookups).toFixed(1) + "%" : "-"],
+        ["cached base", cache.base || "-"],
+        ["expires", cache.base ? new Date(cache.expires_at).toLocaleTimeString() : "-"],
+        ["ttl", cache.ttl || "-"]
+      ]);
+
+      var requests = stats.requests;
+      document.getElementById("requests-meta").textContent =
+        requests.total + " total, " + requests.errors + " errors, " +
+        requests.average_duration_ms.toFixed(1) + " ms avg";
+      fillRows(requestsTarget, requests.recent.map(function (request) {
+        return [
+          cell(new Date(request.time).toLocaleTimeString()),
+          cell(request.method),
+          cell(request.path),
+          cell(request.status, statusClass(request.status)),
+          cell(request.duration_ms.toFixed(1) + " ms")
+        ];
+      }));
+    }).catch(function (error) {
+      showError(cacheTarget, error);
+      showError(requestsTarget, error);
+    });
+  }
+
+  function loadProviders() {
+    var target = document.getElementById("providers");
+    return fetchData("/api/v2/providers").then(function (response) {
+      fillRows(target, response.providers.map(function (provider) {
+        return [
+          cell(provider.name),
+          cell(provider.enabled ? "yes" : "no", provider.enabled ? "ok" : "warn"),
+          cell(provider.priority),
+          cell(provider.effective_priority + (provider.demoted ? " (demoted)" : ""), provider.demoted ? "warn" : ""),
+          cell(provider.p95_ms ? provider.p95_ms.toFixed(0) + " ms" : "-")
+        ];
+      }));
+    }).catch(function (error) { showError(target, error); });
+  }
+
+  function loadRates() {
+    var target = document.getElementById("rates");
+    var base = baseInput.value.trim().toUpperCase();
+    var path = base ? "/api/v2/rates/" + encodeURIComponent(base) : "/api/v2/rates";
+    return fetchData(path).then(function (rates) {
+      document.getElementById("rates-meta").textContent =
+        rates.base + " from " + rates.provider + ", " + rates.age_seconds + "s old";
+      fillRows(target, Object.keys(rates.rates).sort().map(function (code) {
+        return [cell(code), cell(rates.rates[code])];
+      }));
+    }).catch(function (error) {
+      document.getElementById("rates-meta").textContent = "";
+      showError(target, error);
+    });
+  }
+
+  function refresh() {
+    Promise.all([loadHealth(), loadStats(), loadProviders(), loadRates()]).then(function () {
+      document.getElementById("updated").textContent = "Updated " + new Date().toLocaleTimeString();
+    });
+  }
+
+  refresh();
+  window.setInterval(refresh, refreshInterval);
+})();
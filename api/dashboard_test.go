@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/ratelimit"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/stream"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_Dashboard(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{path: "/dashboard/", contentType: "text/html", contains: "<title>Currency Exchange Service</title>"},
+		{path: "/dashboard/app.js", contentType: "javascript", contains: "/stats"},
+		{path: "/dashboard/style.css", contentType: "text/css", contains: "section"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s status = %v, want %v", tt.path, w.Code, http.StatusOK)
+			}
+			if !strings.Contains(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("GET %s Content-Type = %v, want %v", tt.path, w.Header().Get("Content-Type"), tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("GET %s body does not contain %q", tt.path, tt.contains)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetStats(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Stream:       stream.NewHub(5, 16, stream.PolicyDropOldest, logger),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/rates", nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/dashboard/", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stats", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetStats() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var response struct {
+		Cache    models.CacheStats   `json:"cache"`
+		Requests models.RequestStats `json:"requests"`
+		Streams  models.StreamStats  `json:"streams"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GetStats() response unmarshal error = %v", err)
+	}
+	if response.Cache.Hits != 1 || response.Cache.Misses != 1 {
+		t.Errorf("GetStats() cache hits/misses = %v/%v, want 1/1", response.Cache.Hits, response.Cache.Misses)
+	}
+	if response.Requests.Total != 2 || len(response.Requests.Recent) != 2 {
+		t.Errorf("GetStats() requests total = %v, recent = %v, want 2 and 2", response.Requests.Total, len(response.Requests.Recent))
+	}
+	if len(response.Requests.Recent) > 0 && response.Requests.Recent[0].Path != "/api/v1/rates" {
+		t.Errorf("GetStats() recent path = %v, want %v", response.Requests.Recent[0].Path, "/api/v1/rates")
+	}
+	if response.Streams.Policy != "drop-oldest" || response.Streams.BufferSize != 16 {
+		t.Errorf("GetStats() streams = %+v, want drop-oldest with a buffer of 16", response.Streams)
+	}
+}
+
+func TestHandlers_GetRuntimeStats(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	rateLimiter := ratelimit.NewLimiter(cfg, logger)
+	defer rateLimiter.Stop()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:      logger,
+		RateLimiter: rateLimiter,
+		Stream:      stream.NewHub(5, 16, stream.PolicyDropOldest, logger),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	for _, clientIP := range []string{"203.0.113.1", "203.0.113.2"} {
+		request := httptest.NewRequest("GET", "/health", nil)
+		request.Header.Set("X-Forwarded-For", clientIP)
+		router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	w := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "/debug/runtime", nil)
+	request.Header.Set("X-Forwarded-For", "203.0.113.1")
+	router.ServeHTTP(w, request)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetRuntimeStats() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var stats models.RuntimeStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("GetRuntimeStats() response unmarshal error = %v", err)
+	}
+	if stats.Goroutines == 0 || stats.HeapAllocBytes == 0 {
+		t.Errorf("GetRuntimeStats() goroutines = %v, heap = %v, want both above 0", stats.Goroutines, stats.HeapAllocBytes)
+	}
+	if stats.LimiterBuckets != 2 || stats.StreamConnections != 0 {
+		t.Errorf("GetRuntimeStats() limiter buckets = %v, streams = %v, want 2 and 0", stats.LimiterBuckets, stats.StreamConnections)
+	}
+	if handlers.metrics.snapshot().Total != 2 {
+		t.Errorf("GetRuntimeStats() was recorded in the request metrics")
+	}
+}
+
+func TestRequestMetrics_Snapshot(t *testing.T) {
+	metrics := &requestMetrics{}
+	for i := 0; i < recentRequestsLimit+5; i++ {
+		metrics.record(models.RequestRecord{Status: 200 + i}, time.Millisecond)
+	}
+	metrics.record(models.RequestRecord{Status: http.StatusBadGateway}, time.Millisecond)
+
+	stats := metrics.snapshot()
+
+	if stats.Total != recentRequestsLimit+6 {
+		t.Errorf("snapshot() Total = %v, want %v", stats.Total, recentRequestsLimit+6)
+	}
+	if len(stats.Recent) != recentRequestsLimit {
+		t.Fatalf("snapshot() Recent length = %v, want %v", len(stats.Recent), recentRequestsLimit)
+	}
+	if stats.Recent[0].Status != http.StatusBadGateway {
+		t.Errorf("snapshot() newest status = %v, want %v", stats.Recent[0].Status, http.StatusBadGateway)
+	}
+	if stats.Recent[len(stats.Recent)-1].Status != 206 {
+		t.Errorf("snapshot() oldest status = %v, want %v", stats.Recent[len(stats.Recent)-1].Status, 206)
+	}
+	if stats.AverageDurationMS != 1 {
+		t.Errorf("snapshot() AverageDurationMS = %v, want %v", stats.AverageDurationMS, 1)
+	}
+}
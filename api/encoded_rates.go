@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+)
+
+// ageField is age_seconds as encoded before it is known; responses are spliced around it
+var ageField = []byte(`"age_seconds":0`)
+
+// encodedRatesCache holds the JSON encoding of the rates tables served by the rates
+// endpoints, so hot requests write pre-encoded bytes instead of encoding the rates map.
+// A table is encoded again only once the rates cache serves a different table. Only
+// age_seconds changes between requests, and is written between the encoded halves.
+// The zero value is ready to use.
+type encodedRatesCache struct {
+	mutex  sync.RWMutex
+	tables map[string]*encodedRates // By tenant ID and base
+}
+
+// encodedRates is the JSON encoding of a rates table, split at its age_seconds value
+type encodedRates struct {
+	source models.RatesResponse // The encoded table, with AgeSeconds zeroed
+	head   []byte
+	tail   []byte
+}
+
+// lookup returns the encoding of the rates, encoding them when the cached encoding is of
+// another table
+func (cache *encodedRatesCache) lookup(key string, exchangeRates models.RatesResponse) (*encodedRates, error) {
+	cache.mutex.RLock()
+	encoded := cache.tables[key]
+	cache.mutex.RUnlock()
+	if encoded != nil && encoded.encodes(exchangeRates) {
+		return encoded, nil
+	}
+
+	source := exchangeRates
+	source.AgeSeconds = 0
+	body, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	split := bytes.LastIndex(body, ageField)
+	if split < 0 {
+		return nil, errors.New("age_seconds missing from encoded rates")
+	}
+	encoded = &encodedRates{
+		source: source,
+		head:   body[:split+len(ageField)-1],
+		tail:   body[split+len(ageField):],
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.tables == nil {
+		cache.tables = make(map[string]*encodedRates)
+	}
+	cache.tables[key] = encoded
+	return encoded, nil
+}
+
+// encodes reports whether the rates are the encoded table. Cached tables are never
+// modified, so the same rates map with the same metadata is the same table.
+func (encoded *encodedRates) encodes(exchangeRates models.RatesResponse) bool {
+	source := encoded.source
+	return source.Base == exchangeRates.Base &&
+		source.Timestamp == exchangeRates.Timestamp &&
+		source.Provider == exchangeRates.Provider &&
+		source.PublishedAt == exchangeRates.PublishedAt &&
+		source.FetchedAt == exchangeRates.FetchedAt &&
+		source.Rebased == exchangeRates.Rebased &&
+		source.SourceBase == exchangeRates.SourceBase &&
+		source.Degraded == exchangeRates.Degraded &&
+		reflect.ValueOf(source.Rates).UnsafePointer() == reflect.ValueOf(exchangeRates.Rates).UnsafePointer()
+}
+
+// write writes the encoded table with its age
+func (encoded *encodedRates) write(context *gin.Context, ageSeconds int64) {
+	var age [20]byte
+	context.Header("Content-Type", "application/json; charset=utf-8")
+	context.Status(http.StatusOK)
+	context.Writer.Write(encoded.head)
+	context.Writer.Write(strconv.AppendInt(age[:0], ageSeconds, 10))
+	context.Writer.Write(encoded.tail)
+}
+
+// renderRates renders a rates table. Complete tables requested as plain JSON from API v1
+// are written from their cached encoding; filtered tables, bid or ask sides, other
+// encodings, hypermedia and API v2 envelopes are encoded per request. So are tables with
+// stale currencies, whose stale list grows as they age.
+func (handlers *Handlers) renderRates(context *gin.Context, ratesService *service.RatesService, filtered bool, exchangeRates models.RatesResponse) {
+	handlers.signRates(context, exchangeRates)
+	if filtered || len(exchangeRates.Stale) > 0 || apiVersion(context) != 1 || wantsHypermedia(context) || context.NegotiateFormat(offeredFormats...) != binding.MIMEJSON {
+		handlers.renderResource(context, http.StatusOK, exchangeRates, ratesLinks(exchangeRates.Base))
+		return
+	}
+
+	// Tenants limited to some currencies get a freshly filtered table on every request
+	tenantID := ""
+	if tenant := ratesService.Tenant(); tenant != nil {
+		if len(tenant.AllowedCurrencies) > 0 {
+			handlers.renderResource(context, http.StatusOK, exchangeRates, ratesLinks(exchangeRates.Base))
+			return
+		}
+		tenantID = tenant.ID
+	}
+
+	encoded, err := handlers.encodedRates.lookup(tenantID+"/"+exchangeRates.Base, exchangeRates)
+	if err != nil {
+		handlers.loggerFor(context).Errorf("Rates encoding error: %v", err)
+		handlers.renderResource(context, http.StatusOK, exchangeRates, ratesLinks(exchangeRates.Base))
+		return
+	}
+	encoded.write(context, exchangeRates.AgeSeconds)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestEncodedRatesCache(t *testing.T) {
+	exchangeRates := models.RatesResponse{
+		Base:        "USD",
+		Timestamp:   1700000000,
+		Rates:       models.RateTable{"EUR": 0.85, "GBP": 0.75},
+		Provider:    "erapi",
+		PublishedAt: 1700000000,
+		FetchedAt:   1700000060,
+		AgeSeconds:  75,
+	}
+
+	var cache encodedRatesCache
+	encoded, err := cache.lookup("/USD", exchangeRates)
+	if err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+
+	// The spliced response is byte for byte the dynamic encoding
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	encoded.write(c, exchangeRates.AgeSeconds)
+	want, _ := json.Marshal(exchangeRates)
+	if w.Body.String() != string(want) {
+		t.Errorf("write() = %s, want %s", w.Body.String(), want)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+		t.Errorf("write() Content-Type = %s", contentType)
+	}
+
+	// The same table at another age reuses the encoding
+	older := exchangeRates
+	older.AgeSeconds = 90
+	if reused, _ := cache.lookup("/USD", older); reused != encoded {
+		t.Error("lookup() of the same table should reuse its encoding")
+	}
+
+	// A refreshed table, or the same table served degraded, is encoded again
+	refreshed := exchangeRates
+	refreshed.Rates = models.RateTable{"EUR": 0.86, "GBP": 0.75}
+	degraded := exchangeRates
+	degraded.Degraded = true
+	for name, changed := range map[string]models.RatesResponse{"refreshed": refreshed, "degraded": degraded} {
+		reencoded, err := cache.lookup("/USD", changed)
+		if err != nil {
+			t.Fatalf("lookup() %s error = %v", name, err)
+		}
+		if reencoded == encoded {
+			t.Errorf("lookup() of the %s table reused the previous encoding", name)
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		reencoded.write(c, changed.AgeSeconds)
+		if want, _ := json.Marshal(changed); w.Body.String() != string(want) {
+			t.Errorf("write() %s = %s, want %s", name, w.Body.String(), want)
+		}
+	}
+}
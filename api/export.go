@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/export"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// ExportRates streams the rates table for a base currency as a downloadable file
+func (handlers *Handlers) ExportRates(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	var query exportQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	format := export.Format(query.Format)
+	baseCurrency := strings.ToUpper(query.Base)
+	ratesService := handlers.ratesServiceFor(context)
+	requestContext := context.Request.Context()
+
+	var exchangeRates models.RatesResponse
+	var fetchError error
+	dateLabel := "latest"
+
+	if query.Date != "" {
+		date, _ := time.Parse("2006-01-02", query.Date)
+		date, convention := handlers.calendar.Apply(date, handlers.conventionFor(query.calendarParameter))
+		setCalendarHeaders(context, convention)
+		dateLabel = date.Format("2006-01-02")
+		exchangeRates, fetchError = ratesService.GetHistoricalRates(requestContext, baseCurrency, date)
+	} else {
+		exchangeRates, fetchError = ratesService.GetRates(requestContext, baseCurrency)
+	}
+
+	if fetchError != nil {
+		handlers.handleServiceError(context, fetchError)
+		return
+	}
+
+	fileName := fmt.Sprintf("rates-%s-%s.%s", baseCurrency, dateLabel, format)
+	context.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	context.Header("Content-Type", format.ContentType())
+	context.Status(http.StatusOK)
+
+	if writeError := export.Write(context.Writer, format, exchangeRates); writeError != nil {
+		handlers.loggerFor(context).Errorf("Export write error: %v", writeError)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlers_ExportRates(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	tests := []struct {
+		name            string
+		query           string
+		wantStatus      int
+		wantContentType string
+		wantFileName    string
+	}{
+		{name: "csv", query: "format=csv", wantStatus: http.StatusOK, wantContentType: "text/csv", wantFileName: "rates-USD-latest.csv"},
+		{name: "xlsx", query: "format=xlsx", wantStatus: http.StatusOK, wantContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", wantFileName: "rates-USD-latest.xlsx"},
+		{name: "unsupported format", query: "format=pdf", wantStatus: http.StatusBadRequest},
+		{name: "invalid date", query: "date=yesterday", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/rates/USD/export?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "base", Value: "USD"}}
+
+			handlers.ExportRates(c)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("ExportRates() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tt.wantContentType {
+				t.Errorf("ExportRates() Content-Type = %v, want %v", contentType, tt.wantContentType)
+			}
+			if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, tt.wantFileName) {
+				t.Errorf("ExportRates() Content-Disposition = %v, want filename %v", disposition, tt.wantFileName)
+			}
+			if w.Body.Len() == 0 {
+				t.Error("ExportRates() returned an empty body")
+			}
+		})
+	}
+}
@@ -1,25 +1,83 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/admission"
+	"github.com/dalfonso89/currency-exchange-service/alert"
+	"github.com/dalfonso89/currency-exchange-service/attestation"
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/calendar"
+	"github.com/dalfonso89/currency-exchange-service/capture"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/latency"
 	"github.com/dalfonso89/currency-exchange-service/logger"
 	"github.com/dalfonso89/currency-exchange-service/middleware"
 	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/quota"
 	"github.com/dalfonso89/currency-exchange-service/ratelimit"
 	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/store"
+	"github.com/dalfonso89/currency-exchange-service/stream"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/usage"
 )
 
+// tenantContextKey is the Gin context key holding the resolved tenant
+const tenantContextKey = "tenant"
+
+// serviceVersion is reported by /health and /version
+const serviceVersion = "1.0.0"
+
 // HandlerConfig contains all dependencies for the Handlers
 type HandlerConfig struct {
 	Logger       logger.Logger
 	RatesService *service.RatesService
 	RateLimiter  *ratelimit.Limiter
+	Tenants      *tenant.Registry
+	AdminAPIKey  string            // Key of the admin role named "admin" (empty = none)
+	AdminKeys    []config.AdminKey // Named admin keys and their roles
+	Readiness    *health.Checker
+	Store        *store.Store
+	IDGenerator  middleware.IDGenerator  // Request ID source (nil = UUIDv7)
+	Usage        *usage.Tracker          // API usage analytics (nil = disabled)
+	Quotas       *quota.Manager          // Daily and monthly quotas of tenant keys (nil = disabled)
+	JWT          *auth.Verifier          // Bearer-token authentication of tenants (nil = API keys only)
+	OAuth        *auth.Issuer            // Token issuance to machine clients (nil = disabled)
+	Signatures   *auth.SignatureVerifier // HMAC signatures required of some API keys (nil = none)
+	RatesSigner  *auth.ResponseSigner    // Signatures of served rates tables (nil = unsigned)
+	Attestations *attestation.Ledger     // Attestations of the conversions of regulated tenants (nil = disabled)
+	Alerts       *alert.Engine           // Threshold alerts on service metrics (nil = disabled)
+	RouteBudgets *latency.Budgets        // Latency budgets of routes (nil = none)
+	Admission    *admission.Scheduler    // Concurrency limit of API requests (nil = unlimited)
+	Calendar     *calendar.Calendar      // Trading days of historical queries (nil = every day)
+	Abuse        *abuse.Detector         // Bans of clients failing authentication repeatedly (nil = none)
+	Capture      *capture.Recorder       // Debug capture of sampled requests and responses (nil = disabled)
+	Config       *config.Config          // Configuration shown by the admin config dump (nil = not shown)
+
+	// Server-sent pair rate streams, the keep-alive interval of idle streams and the
+	// messages between snapshots of delta-encoded streams
+	Stream              *stream.Hub
+	StreamHeartbeat     time.Duration
+	StreamSnapshotEvery int
+
+	// Shared secrets of push-based rate sources and the allowed timestamp drift
+	WebhookSecrets   map[string]string
+	WebhookTolerance time.Duration
+
+	// API v1 lifecycle: announced deprecation and sunset, or removal in favor of v2
+	V1Lifecycle Lifecycle
+	DisableV1   bool
 }
 
 // Handlers contains all HTTP handlers
@@ -28,6 +86,44 @@ type Handlers struct {
 	startTime    time.Time
 	ratesService *service.RatesService
 	rateLimiter  *ratelimit.Limiter
+	tenants      *tenant.Registry
+	adminKeys    *auth.AdminKeyring
+	adminAudit   *adminAuditLog
+	readiness    *health.Checker
+	store        *store.Store
+	idGenerator  middleware.IDGenerator
+	usage        *usage.Tracker
+	quotas       *quota.Manager
+	jwt          *auth.Verifier
+	oauth        *auth.Issuer
+	signatures   *auth.SignatureVerifier
+	ratesSigner  *auth.ResponseSigner
+	attestations *attestation.Ledger
+	alerts       *alert.Engine
+	metrics      *requestMetrics
+	routeBudgets *latency.Budgets
+	admission    *admission.Scheduler
+	calendar     *calendar.Calendar
+	abuse        *abuse.Detector
+	capture      *capture.Recorder
+	config       *config.Config
+	encodedRates encodedRatesCache
+
+	stream              *stream.Hub
+	streamHeartbeat     time.Duration
+	streamSnapshotEvery int
+
+	webhookSecrets   map[string]string
+	webhookTolerance time.Duration
+
+	// Permission required by each admin route, keyed by method and route path
+	adminPermissions map[string]auth.AdminPermission
+
+	// Guards the secrets replaced when they rotate
+	secretsMutex sync.RWMutex
+
+	v1Lifecycle Lifecycle
+	disableV1   bool
 }
 
 // NewHandlers creates a new handlers instance with all dependencies
@@ -37,22 +133,68 @@ func NewHandlers(config HandlerConfig) *Handlers {
 		startTime:    time.Now(),
 		ratesService: config.RatesService,
 		rateLimiter:  config.RateLimiter,
+		tenants:      config.Tenants,
+		adminKeys:    auth.NewAdminKeyring(config.AdminAPIKey, config.AdminKeys),
+		adminAudit:   &adminAuditLog{},
+		readiness:    config.Readiness,
+		store:        config.Store,
+		idGenerator:  config.IDGenerator,
+		usage:        config.Usage,
+		quotas:       config.Quotas,
+		jwt:          config.JWT,
+		oauth:        config.OAuth,
+		signatures:   config.Signatures,
+		ratesSigner:  config.RatesSigner,
+		attestations: config.Attestations,
+		alerts:       config.Alerts,
+		metrics:      &requestMetrics{},
+		routeBudgets: config.RouteBudgets,
+		admission:    config.Admission,
+		calendar:     config.Calendar,
+		abuse:        config.Abuse,
+		capture:      config.Capture,
+		config:       config.Config,
+
+		stream:              config.Stream,
+		streamHeartbeat:     config.StreamHeartbeat,
+		streamSnapshotEvery: config.StreamSnapshotEvery,
+
+		webhookSecrets:   config.WebhookSecrets,
+		webhookTolerance: config.WebhookTolerance,
+
+		v1Lifecycle: config.V1Lifecycle,
+		disableV1:   config.DisableV1,
 	}
 }
 
-// SetupRoutes configures all the routes using Gin
-func (handlers *Handlers) SetupRoutes() *gin.Engine {
-	// Set Gin mode based on environment
-	gin.SetMode(gin.ReleaseMode)
+// RotateSecrets replaces the admin keys and the push sources' secrets with rotated values
+func (handlers *Handlers) RotateSecrets(adminAPIKey string, adminKeys []config.AdminKey, webhookSecrets map[string]string) {
+	handlers.adminKeys.Rotate(adminAPIKey, adminKeys)
 
-	router := gin.New()
+	handlers.secretsMutex.Lock()
+	defer handlers.secretsMutex.Unlock()
+	handlers.webhookSecrets = webhookSecrets
+}
+
+// SetupRoutes configures all the routes using Gin
+func (handlers *Handlers) SetupRoutes(options RouterOptions) *gin.Engine {
+	router := options.newEngine()
 
 	// Apply middleware
 	router.Use(middleware.RequestLogger(handlers.logger))
 	router.Use(gin.Recovery())
 	router.Use(middleware.SecurityHeaders())
-	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestID(handlers.idGenerator))
+	router.Use(middleware.TraceContext())
+	router.Use(options.Middleware...)
 	router.Use(handlers.corsMiddleware())
+	router.Use(handlers.metricsMiddleware())
+	if handlers.capture != nil {
+		router.Use(handlers.captureMiddleware())
+	}
+	if handlers.abuse != nil {
+		router.Use(handlers.abuseMiddleware(options.clientIPTrusted()))
+	}
 
 	// Add rate limiting middleware if enabled
 	if handlers.rateLimiter != nil {
@@ -61,28 +203,187 @@ func (handlers *Handlers) SetupRoutes() *gin.Engine {
 
 	// Health check endpoint
 	router.GET("/health", handlers.HealthCheck)
+	router.GET("/health/ready", handlers.ReadinessCheck)
+	router.GET("/version", handlers.GetVersion)
+
+	// Operational dashboard and the stats it renders
+	router.GET("/stats", handlers.GetStats)
+	router.StaticFS("/dashboard", dashboardFileSystem())
+	router.GET("/debug/runtime", handlers.GetRuntimeStats)
+
+	// API v1 routes, answered with 410 Gone once v1 is disabled
+	if handlers.disableV1 {
+		router.Any("/api/v1/*path", handlers.V1Removed)
+	} else {
+		apiV1 := router.Group("/api/v1")
+		apiV1.Use(handlers.apiMiddleware(1)...)
+		handlers.registerAPIRoutes(apiV1)
+	}
+
+	// API v2 routes: the v1 resources with enveloped responses and problem details errors
+	apiV2 := router.Group("/api/v2")
+	apiV2.Use(handlers.apiMiddleware(2)...)
+	handlers.registerAPIRoutes(apiV2)
+
+	// Push-based rate sources
+	router.POST("/webhooks/rates/:provider", handlers.ReceiveRates)
+
+	// Token issuance to machine clients
+	if handlers.oauth != nil {
+		router.POST("/oauth/token", handlers.IssueToken)
+		router.GET("/oauth/jwks.json", handlers.GetOAuthKeys)
+	}
+
+	// Key verifying the signatures of served rates tables
+	if _, published := handlers.ratesSigner.KeySet(); published {
+		router.GET("/.well-known/rates-signing-keys.json", handlers.GetRatesSigningKeys)
+	}
+
+	// Key verifying the attestations of conversions
+	if handlers.attestations != nil {
+		router.GET("/.well-known/attestation-keys.json", handlers.GetAttestationKeys)
+	}
 
-	// API v1 routes
-	apiV1 := router.Group("/api/v1")
+	// Admin routes, each with the permission it requires. The admin middleware also guards
+	// /admin routes registered later by embedders, which have no permission and are denied.
+	router.Use(handlers.adminAuthMiddleware())
+	adminV1 := router.Group("/admin/v1")
+	handlers.adminPermissions = make(map[string]auth.AdminPermission)
 	{
-		// Currency exchange routes
-		apiV1.GET("/rates", handlers.GetRates)
-		apiV1.GET("/rates/:base", handlers.GetRatesByBase)
+		handlers.adminRoute(adminV1, "DELETE", "/cache", auth.PermissionAdminCache, handlers.PurgeCache)
+		handlers.adminRoute(adminV1, "PUT", "/cache/ttl", auth.PermissionAdminCache, handlers.SetCacheTTL)
+		handlers.adminRoute(adminV1, "GET", "/config", auth.PermissionAdminConfig, handlers.GetConfig)
+		handlers.adminRoute(adminV1, "GET", "/audit", auth.PermissionAdminAudit, handlers.GetAdminAudit)
+		handlers.adminRoute(adminV1, "GET", "/usage", auth.PermissionAdminRead, handlers.GetUsage)
+		handlers.adminRoute(adminV1, "POST", "/providers/:name/disable", auth.PermissionAdminToggle, handlers.DisableProvider)
+		handlers.adminRoute(adminV1, "POST", "/providers/:name/enable", auth.PermissionAdminToggle, handlers.EnableProvider)
+		handlers.adminRoute(adminV1, "GET", "/providers/transitions", auth.PermissionAdminRead, handlers.GetProviderTransitions)
+		handlers.adminRoute(adminV1, "GET", "/attestations/:id", auth.PermissionAdminRead, handlers.GetAnyAttestation)
+		if handlers.alerts != nil {
+			handlers.adminRoute(adminV1, "GET", "/alerts", auth.PermissionAdminRead, handlers.GetAlerts)
+		}
+		if handlers.abuse != nil {
+			handlers.adminRoute(adminV1, "GET", "/bans", auth.PermissionAdminRead, handlers.GetBans)
+			handlers.adminRoute(adminV1, "DELETE", "/bans", auth.PermissionAdminBans, handlers.ClearBans)
+			handlers.adminRoute(adminV1, "DELETE", "/bans/:subject", auth.PermissionAdminBans, handlers.ClearBan)
+		}
+		if handlers.capture != nil {
+			handlers.adminRoute(adminV1, "GET", "/captures", auth.PermissionAdminDebug, handlers.GetCaptures)
+			handlers.adminRoute(adminV1, "DELETE", "/captures", auth.PermissionAdminDebug, handlers.ClearCaptures)
+		}
+	}
+
+	// Endpoints of applications embedding the service
+	for _, registerRoutes := range options.Routes {
+		registerRoutes(router)
 	}
 
 	return router
 }
 
-// HealthCheck handles health check requests
+// apiMiddleware returns the middleware of an API version's routes. Usage is recorded
+// before tenant resolution, so rejected requests are counted too; quotas are enforced
+// after it, per resolved key. Requests within their quotas then wait for a slot under
+// the concurrency limit.
+func (handlers *Handlers) apiMiddleware(version int) []gin.HandlerFunc {
+	middlewares := []gin.HandlerFunc{handlers.versionMiddleware(version)}
+	if handlers.usage != nil {
+		middlewares = append(middlewares, handlers.usageMiddleware())
+	}
+	middlewares = append(middlewares, handlers.tenantMiddleware())
+	if handlers.signatures != nil {
+		middlewares = append(middlewares, handlers.signatureMiddleware())
+	}
+	if handlers.quotas != nil {
+		middlewares = append(middlewares, handlers.quotaMiddleware())
+	}
+	if handlers.admission != nil {
+		middlewares = append(middlewares, handlers.admissionMiddleware())
+	}
+	return middlewares
+}
+
+// registerAPIRoutes registers the resources every API version serves
+func (handlers *Handlers) registerAPIRoutes(group *gin.RouterGroup) {
+	// Currency exchange routes
+	group.GET("/rates", handlers.GetRates)
+	group.GET("/rates/:base", handlers.GetRatesByBase)
+	group.GET("/rates/:base/export", handlers.ExportRates)
+	group.GET("/rates/:base/timeseries", handlers.GetTimeSeries)
+	group.GET("/convert", handlers.Convert)
+	group.GET("/rate", handlers.GetPairRate)
+	group.GET("/currencies", handlers.GetCurrencies)
+	group.GET("/providers", handlers.GetProviders)
+	group.GET("/meta/refresh", handlers.GetRefreshHints)
+	group.GET("/attestations/:id", handlers.GetAttestation)
+
+	// Server-sent rate streams and their pair subscriptions
+	group.GET("/stream", handlers.StreamRates)
+	group.GET("/stream/:id/subscriptions", handlers.GetStreamSubscriptions)
+	group.POST("/stream/:id/subscriptions", handlers.AddStreamSubscriptions)
+	group.DELETE("/stream/:id/subscriptions", handlers.RemoveStreamSubscriptions)
+	group.POST("/stream/:id/resync", handlers.ResyncStream)
+}
+
+// HealthCheck handles health check requests. With ?verbose=true it adds the readiness
+// status and dependency checks, answered from the cached readiness results; the service
+// is reported healthy whatever their status, since it is running.
 func (handlers *Handlers) HealthCheck(context *gin.Context) {
+	var query healthQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
 	healthCheckResponse := models.HealthCheck{
 		Status:    "healthy",
 		Timestamp: time.Now(),
-		Version:   "1.0.0",
+		Version:   serviceVersion,
 		Uptime:    time.Since(handlers.startTime).String(),
 	}
+	if query.Verbose {
+		healthCheckResponse.Readiness = health.StatusReady
+		if handlers.readiness != nil {
+			report := handlers.readiness.Report(context.Request.Context())
+			healthCheckResponse.Readiness = report.Status
+			healthCheckResponse.Dependencies = report.Checks
+		}
+	}
 
-	context.JSON(http.StatusOK, healthCheckResponse)
+	handlers.render(context, http.StatusOK, healthCheckResponse)
+}
+
+// ReadinessCheck reports the dependency check results; it answers 503 until the
+// checks have run and whenever a dependency group has no passing check
+func (handlers *Handlers) ReadinessCheck(context *gin.Context) {
+	if handlers.readiness == nil {
+		handlers.render(context, http.StatusOK, models.ReadinessReport{Status: health.StatusReady, Checks: []models.DependencyStatus{}})
+		return
+	}
+
+	report := handlers.readiness.Report(context.Request.Context())
+	statusCode := http.StatusOK
+	if report.Status == health.StatusNotReady || report.Status == health.StatusPending {
+		statusCode = http.StatusServiceUnavailable
+	}
+	handlers.render(context, statusCode, report)
+}
+
+// GetVersion reports the service version and, with persistence enabled, the applied
+// and latest database schema versions
+func (handlers *Handlers) GetVersion(context *gin.Context) {
+	versionInfo := models.VersionInfo{Version: serviceVersion}
+	if handlers.store != nil {
+		schemaVersion, err := handlers.store.SchemaVersion(context.Request.Context())
+		if err != nil {
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "database unavailable", err.Error())
+			return
+		}
+		versionInfo.SchemaVersion = schemaVersion
+		versionInfo.LatestSchemaVersion = store.LatestSchemaVersion()
+	}
+
+	handlers.render(context, http.StatusOK, versionInfo)
 }
 
 // GetRates returns latest rates for a base currency
@@ -92,19 +393,31 @@ func (handlers *Handlers) GetRates(context *gin.Context) {
 		return
 	}
 
-	baseCurrency := context.DefaultQuery("base", "USD")
+	var query ratesQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	ratesService := handlers.ratesServiceFor(context)
+	baseCurrency := strings.ToUpper(query.Base)
+	if baseCurrency == "" {
+		baseCurrency = ratesService.DefaultBaseCurrency()
+	}
 	requestContext := context.Request.Context()
 
-	exchangeRates, fetchError := handlers.ratesService.GetRates(requestContext, baseCurrency)
+	symbols := parseCurrencyList(query.Symbols)
+	exchangeRates, fetchError := ratesService.GetRatesForSymbols(requestContext, baseCurrency, symbols)
 	if fetchError != nil {
-		handlers.logger.Errorf("GetRates error: %v", fetchError)
+		handlers.loggerFor(context).Errorf("GetRates error: %v", fetchError)
 		handlers.handleServiceError(context, fetchError)
 		return
 	}
 
-	handlers.logger.Infof("Returning rates data: %+v", exchangeRates)
+	handlers.loggerFor(context).Infof("Returning rates data: %+v", exchangeRates)
 	// Return the actual exchange rates data
-	context.JSON(http.StatusOK, exchangeRates)
+	exchangeRates = exchangeRates.AtSide(query.Side)
+	handlers.renderRates(context, ratesService, len(symbols) > 0 || exchangeRates.Side != "", exchangeRates)
 }
 
 // GetRatesByBase returns rates for a specific base currency using path parameter
@@ -114,28 +427,204 @@ func (handlers *Handlers) GetRatesByBase(context *gin.Context) {
 		return
 	}
 
-	baseCurrency := strings.ToUpper(context.Param("base"))
+	var parameters ratesByBaseParameters
+	if bindError := bindParameters(context, &parameters); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	baseCurrency := strings.ToUpper(parameters.Base)
 	requestContext := context.Request.Context()
 
-	exchangeRates, fetchError := handlers.ratesService.GetRates(requestContext, baseCurrency)
+	ratesService := handlers.ratesServiceFor(context)
+	symbols := parseCurrencyList(parameters.Symbols)
+	exchangeRates, fetchError := ratesService.GetRatesForSymbols(requestContext, baseCurrency, symbols)
 	if fetchError != nil {
 		handlers.handleServiceError(context, fetchError)
 		return
 	}
 
 	// Return the actual exchange rates data
-	context.JSON(http.StatusOK, exchangeRates)
+	exchangeRates = exchangeRates.AtSide(parameters.Side)
+	handlers.renderRates(context, ratesService, len(symbols) > 0 || exchangeRates.Side != "", exchangeRates)
 }
 
-// writeErrorResponse writes an error response using Gin context
+// Convert converts an amount between two currencies
+func (handlers *Handlers) Convert(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	var query convertQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	fromCurrency := strings.ToUpper(query.From)
+	toCurrency := strings.ToUpper(query.To)
+	amount := query.Amount
+
+	// A comma-separated target list converts into every target from one rates fetch
+	if strings.Contains(toCurrency, ",") {
+		if query.Date != "" {
+			handlers.writeValidationError(context, fieldErrors{{Field: "date", Message: "date requires a single target currency"}})
+			return
+		}
+		conversions, convertError := handlers.ratesServiceFor(context).ConvertMany(context.Request.Context(), fromCurrency, parseCurrencyList(toCurrency), amount, query.Side)
+		if convertError != nil {
+			handlers.handleServiceError(context, convertError)
+			return
+		}
+		if !handlers.attestConversions(context, conversions.Conversions) {
+			return
+		}
+
+		handlers.renderResource(context, http.StatusOK, conversions, multiConversionLinks(conversions))
+		return
+	}
+
+	var conversion models.ConversionResponse
+	var convertError error
+	if query.Date != "" {
+		date, _ := time.Parse("2006-01-02", query.Date)
+		date, convention := handlers.calendar.Apply(date, handlers.conventionFor(query.calendarParameter))
+		conversion, convertError = handlers.ratesServiceFor(context).ConvertAt(context.Request.Context(), fromCurrency, toCurrency, amount, date, query.Side)
+		conversion.Calendar = &convention
+	} else {
+		conversion, convertError = handlers.ratesServiceFor(context).Convert(context.Request.Context(), fromCurrency, toCurrency, amount, query.Side)
+	}
+	if convertError != nil {
+		handlers.handleServiceError(context, convertError)
+		return
+	}
+	attested := []models.ConversionResponse{conversion}
+	if !handlers.attestConversions(context, attested) {
+		return
+	}
+	conversion = attested[0]
+
+	handlers.renderResource(context, http.StatusOK, conversion, conversionLinks(conversion))
+}
+
+// GetPairRate returns the rate of a single pair, given as ?pair=FROM/TO, and its inverse
+func (handlers *Handlers) GetPairRate(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	var query pairQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	fromCurrency, toCurrency, _ := strings.Cut(strings.ToUpper(query.Pair), "/")
+
+	pairRate, rateError := handlers.ratesServiceFor(context).GetPairRate(context.Request.Context(), fromCurrency, toCurrency)
+	if rateError != nil {
+		handlers.handleServiceError(context, rateError)
+		return
+	}
+
+	handlers.renderResource(context, http.StatusOK, pairRate, pairRateLinks(fromCurrency, toCurrency))
+}
+
+// parseCurrencyList splits a comma-separated currency list, dropping blanks and duplicates
+func parseCurrencyList(value string) []string {
+	currencies := []string{}
+	seen := make(map[string]bool)
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		currencies = append(currencies, code)
+	}
+	return currencies
+}
+
+// GetCurrencies returns the supported currencies, including precious metals
+func (handlers *Handlers) GetCurrencies(context *gin.Context) {
+	currencies := []currency.Currency{}
+	for _, supportedCurrency := range currency.All() {
+		if handlers.ratesService == nil || handlers.ratesServiceFor(context).IsCurrencyAllowed(supportedCurrency.Code) {
+			currencies = append(currencies, supportedCurrency)
+		}
+	}
+
+	handlers.render(context, http.StatusOK, gin.H{
+		"currencies": currencies,
+		"count":      len(currencies),
+	})
+}
+
+// GetProviders returns the status of the configured providers
+func (handlers *Handlers) GetProviders(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	providers := handlers.ratesServiceFor(context).GetProviderStatus()
+	handlers.render(context, http.StatusOK, gin.H{
+		"providers": providers,
+		"count":     len(providers),
+	})
+}
+
+// GetRefreshHints returns the cache TTL and the refresh schedule of each base, so clients
+// can align their polling with the refreshes
+func (handlers *Handlers) GetRefreshHints(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	handlers.render(context, http.StatusOK, handlers.ratesServiceFor(context).RefreshHints())
+}
+
+// ratesServiceFor returns the rates service scoped to the request's tenant, if any
+func (handlers *Handlers) ratesServiceFor(context *gin.Context) *service.RatesService {
+	if value, exists := context.Get(tenantContextKey); exists {
+		return handlers.ratesService.ForTenant(value.(*config.Tenant))
+	}
+	return handlers.ratesService
+}
+
+// writeErrorResponse writes an error response using Gin context, as problem details on
+// API v2 routes
 func (handlers *Handlers) writeErrorResponse(context *gin.Context, statusCode int, errorMessage, errorDetails string) {
+	handlers.writeError(context, statusCode, errorMessage, errorDetails, nil)
+}
+
+// writeValidationError writes a 400 response listing every invalid request parameter
+func (handlers *Handlers) writeValidationError(context *gin.Context, err error) {
+	var fields fieldErrors
+	if !errors.As(err, &fields) {
+		fields = fieldErrors{{Message: err.Error()}}
+	}
+	handlers.writeError(context, http.StatusBadRequest, "invalid request", fields.Error(), fields)
+}
+
+// writeError writes an error response with the invalid fields, if any
+func (handlers *Handlers) writeError(context *gin.Context, statusCode int, errorMessage, errorDetails string, fields []models.FieldError) {
+	if apiVersion(context) >= 2 {
+		handlers.renderProblem(context, statusCode, errorMessage, errorDetails, fields)
+		return
+	}
+
 	errorResponse := models.ErrorResponse{
 		Error:   errorMessage,
 		Message: errorDetails,
 		Code:    statusCode,
+		Fields:  fields,
 	}
 
-	context.JSON(statusCode, errorResponse)
+	handlers.render(context, statusCode, errorResponse)
 }
 
 // handleServiceError handles service errors using type switches
@@ -152,6 +641,22 @@ func (handlers *Handlers) handleServiceError(context *gin.Context, err error) {
 			handlers.writeErrorResponse(context, http.StatusBadGateway, "network error", e.Error())
 		case service.ErrorTypeInvalidResponse:
 			handlers.writeErrorResponse(context, http.StatusBadGateway, "invalid response", e.Error())
+		case service.ErrorTypeInvalidRequest:
+			handlers.writeErrorResponse(context, http.StatusBadRequest, "invalid request", e.Error())
+		case service.ErrorTypeUnsupportedBase:
+			handlers.writeErrorResponse(context, http.StatusBadRequest, "unsupported base currency", e.Error())
+		case service.ErrorTypeProviderUnavailable:
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "no provider available", e.Error())
+		case service.ErrorTypeProviderFailed:
+			handlers.writeErrorResponse(context, http.StatusBadGateway, "provider error", e.Error())
+		case service.ErrorTypeBudgetExhausted:
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "provider call budget exhausted", e.Error())
+		case service.ErrorTypeSourceNotPermitted:
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "no permitted provider", e.Error())
+		case service.ErrorTypeHistoryUnavailable:
+			handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "history unavailable", e.Error())
+		case service.ErrorTypeRateNotFound:
+			handlers.writeErrorResponse(context, http.StatusNotFound, "rate not found", e.Error())
 		default:
 			handlers.writeErrorResponse(context, http.StatusInternalServerError, "service error", e.Error())
 		}
@@ -166,7 +671,7 @@ func (handlers *Handlers) corsMiddleware() gin.HandlerFunc {
 	return func(context *gin.Context) {
 		context.Header("Access-Control-Allow-Origin", "*")
 		context.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		context.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		context.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Admin-Key, X-Signature, X-Signature-Timestamp, X-Signature-Nonce, X-Webhook-Timestamp, X-Webhook-Signature, traceparent, tracestate")
 
 		// Handle HTTP method using type switch
 		switch context.Request.Method {
@@ -188,13 +693,32 @@ func (handlers *Handlers) corsMiddleware() gin.HandlerFunc {
 func (handlers *Handlers) rateLimitMiddleware() gin.HandlerFunc {
 	return func(context *gin.Context) {
 		clientIP := handlers.rateLimiter.GetClientIP(context.Request)
+		limitKey := clientIP
+		limitRequests := handlers.rateLimiter.Configuration.RateLimitRequests
+		limitBurst := handlers.rateLimiter.Configuration.RateLimitBurst
+
+		// Tenants are limited per tenant rather than per IP, and tokens of a configured
+		// tier with the tier's limits
+		if resolvedCaller, err := handlers.authenticate(context); err == nil {
+			limitKey = "tenant:" + resolvedCaller.tenant.ID
+			limitRequests = resolvedCaller.tenant.RateLimitRequests
+			limitBurst = resolvedCaller.tenant.RateLimitBurst
+			if tier, found := handlers.rateLimiter.Configuration.RateLimitTiers[resolvedCaller.tier]; found && resolvedCaller.tier != "" {
+				limitKey += "/tier:" + resolvedCaller.tier
+				limitRequests, limitBurst = tier.Requests, tier.Burst
+			}
+		}
 
-		if !handlers.rateLimiter.Allow(clientIP) {
-			handlers.logger.Warnf("Rate limit exceeded for IP: %s", clientIP)
-			context.Header("X-RateLimit-Limit", strconv.Itoa(handlers.rateLimiter.Configuration.RateLimitRequests))
+		allowed, shadowBlocked := handlers.rateLimiter.Check(limitKey, limitRequests, limitBurst)
+		if shadowBlocked {
+			context.Header(ratelimit.ShadowHeader, "would-block")
+		}
+		if !allowed {
+			handlers.loggerFor(context).Warnf("Rate limit exceeded for: %s", limitKey)
+			context.Header("X-RateLimit-Limit", strconv.Itoa(limitRequests))
 			context.Header("X-RateLimit-Remaining", "0")
 			context.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(handlers.rateLimiter.Configuration.RateLimitWindow).Unix(), 10))
-			context.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			handlers.render(context, http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
 			context.Abort()
 			return
 		}
@@ -202,3 +726,8 @@ func (handlers *Handlers) rateLimitMiddleware() gin.HandlerFunc {
 		context.Next()
 	}
 }
+
+// loggerFor returns the logger of a request, adding its request_id and trace_id
+func (handlers *Handlers) loggerFor(context *gin.Context) logger.Logger {
+	return handlers.logger.WithContext(context.Request.Context())
+}
@@ -3,12 +3,22 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dalfonso89/currency-exchange-service/alert"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/health"
 	"github.com/dalfonso89/currency-exchange-service/models"
 	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
 	"github.com/dalfonso89/currency-exchange-service/testutils"
 
 	"github.com/gin-gonic/gin"
@@ -105,9 +115,112 @@ func TestHandlers_HealthCheck(t *testing.T) {
 	}
 }
 
+func TestHandlers_HealthCheckVerbose(t *testing.T) {
+	logger := testutils.MockLogger()
+	readiness := health.NewChecker(health.ModeWarn, time.Second, logger)
+	readiness.Register(health.Check{Name: "provider:typo", Group: "providers", Run: func(ctx context.Context) error {
+		return errors.New("no such host")
+	}})
+	readiness.Startup(context.Background())
+	router := NewHandlers(HandlerConfig{Logger: logger, Readiness: readiness}).SetupRoutes(RouterOptions{})
+
+	tests := []struct {
+		target           string
+		wantReadiness    string
+		wantDependencies int
+	}{
+		{target: "/health", wantReadiness: "", wantDependencies: 0},
+		{target: "/health?verbose=true", wantReadiness: health.StatusNotReady, wantDependencies: 1},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+		// Failing dependencies never make the liveness probe fail
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s status = %v, want %v", tt.target, w.Code, http.StatusOK)
+		}
+		var response models.HealthCheck
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("GET %s response unmarshal error = %v", tt.target, err)
+		}
+		if response.Status != "healthy" || response.Readiness != tt.wantReadiness || len(response.Dependencies) != tt.wantDependencies {
+			t.Errorf("GET %s = %s, want healthy with readiness %q and %d dependencies", tt.target, w.Body.String(), tt.wantReadiness, tt.wantDependencies)
+		}
+	}
+}
+
+func TestHandlers_ReadinessCheck(t *testing.T) {
+	failingCheck := health.Check{Name: "provider:typo", Group: "providers", Run: func(ctx context.Context) error {
+		return errors.New("no such host")
+	}}
+	passingCheck := health.Check{Name: "provider:ok", Group: "providers", Run: func(ctx context.Context) error { return nil }}
+
+	tests := []struct {
+		name       string
+		mode       health.Mode
+		checks     []health.Check
+		runChecks  bool
+		wantStatus int
+		wantReport string
+	}{
+		{name: "degraded", mode: health.ModeWarn, checks: []health.Check{passingCheck, failingCheck}, runChecks: true, wantStatus: http.StatusOK, wantReport: health.StatusDegraded},
+		{name: "not ready", mode: health.ModeWarn, checks: []health.Check{failingCheck}, runChecks: true, wantStatus: http.StatusServiceUnavailable, wantReport: health.StatusNotReady},
+		{name: "lazy runs on probe", mode: health.ModeLazy, checks: []health.Check{passingCheck}, wantStatus: http.StatusOK, wantReport: health.StatusReady},
+		{name: "pending before startup", mode: health.ModeWarn, checks: []health.Check{passingCheck}, wantStatus: http.StatusServiceUnavailable, wantReport: health.StatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := testutils.MockLogger()
+			readiness := health.NewChecker(tt.mode, time.Second, logger)
+			readiness.Register(tt.checks...)
+			if tt.runChecks {
+				readiness.Startup(context.Background())
+			}
+			handlers := NewHandlers(HandlerConfig{Logger: logger, Readiness: readiness})
+
+			req := httptest.NewRequest("GET", "/health/ready", nil)
+			w := httptest.NewRecorder()
+			handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GET /health/ready status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			var report models.ReadinessReport
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("GET /health/ready response unmarshal error = %v", err)
+			}
+			if report.Status != tt.wantReport {
+				t.Errorf("GET /health/ready report status = %v, want %v", report.Status, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetVersion(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+
+	req := httptest.NewRequest("GET", "/version", nil)
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /version status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var versionInfo models.VersionInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &versionInfo); err != nil {
+		t.Fatalf("GET /version response unmarshal error = %v", err)
+	}
+	if versionInfo.Version != serviceVersion || versionInfo.LatestSchemaVersion != 0 {
+		t.Errorf("GET /version = %+v, want version %s without schema", versionInfo, serviceVersion)
+	}
+}
+
 func TestHandlers_GetRates(t *testing.T) {
 	// Create mock servers
-	mockExchangeRateServer := testutils.NewMockExchangeRateServer()
+	mockExchangeRateServer := testsupport.NewProviderServer()
 	defer mockExchangeRateServer.Close()
 	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
 	defer mockJSONPlaceholderServer.Close()
@@ -139,9 +252,55 @@ func TestHandlers_GetRates(t *testing.T) {
 	}
 }
 
+func TestHandlers_GetRates_BaseConfiguration(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.DefaultBaseCurrency = "EUR"
+	cfg.AllowedBaseCurrencies = []string{"EUR"}
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{Logger: logger, RatesService: service.NewRatesService(cfg, logger)})
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantBase   string
+	}{
+		{name: "default base", url: "/api/v1/rates", wantStatus: http.StatusOK, wantBase: "EUR"},
+		{name: "disallowed base", url: "/api/v1/rates?base=USD", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", tt.url, nil)
+
+			handlers.GetRates(c)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GetRates() status code = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantBase == "" {
+				return
+			}
+			var response models.RatesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GetRates() response: %v", err)
+			}
+			if response.Base != tt.wantBase {
+				t.Errorf("GetRates() base = %s, want %s", response.Base, tt.wantBase)
+			}
+		})
+	}
+}
+
 func TestHandlers_GetRatesByBase(t *testing.T) {
 	// Create mock servers
-	mockExchangeRateServer := testutils.NewMockExchangeRateServer()
+	mockExchangeRateServer := testsupport.NewProviderServer()
 	defer mockExchangeRateServer.Close()
 	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
 	defer mockJSONPlaceholderServer.Close()
@@ -173,3 +332,783 @@ func TestHandlers_GetRatesByBase(t *testing.T) {
 		t.Errorf("GetRatesByBase() status code = %v, want %v", w.Code, http.StatusOK)
 	}
 }
+
+func TestHandlers_GetRatesByBase_Symbols(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{Logger: logger, RatesService: service.NewRatesService(cfg, logger)})
+
+	tests := []struct {
+		name       string
+		symbols    string
+		wantStatus int
+		wantRates  []string
+	}{
+		{name: "filtered", symbols: "gbp,€", wantStatus: http.StatusOK, wantRates: []string{"EUR", "GBP"}},
+		{name: "invalid symbol", symbols: "EUR,E1", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/rates/USD?symbols="+url.QueryEscape(tt.symbols), nil)
+			c.Params = gin.Params{{Key: "base", Value: "USD"}}
+
+			handlers.GetRatesByBase(c)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GetRatesByBase() status code = %v, want %v: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantRates == nil {
+				return
+			}
+			var response models.RatesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GetRatesByBase() response: %v", err)
+			}
+			if len(response.Rates) != len(tt.wantRates) {
+				t.Errorf("GetRatesByBase() rates = %v, want %v", response.Rates, tt.wantRates)
+			}
+			for _, symbol := range tt.wantRates {
+				if _, found := response.Rates[symbol]; !found {
+					t.Errorf("GetRatesByBase() rates = %v, missing %s", response.Rates, symbol)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlers_Convert(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Markup.GlobalBPS = 50
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "valid conversion", query: "from=USD&to=EUR&amount=100", wantStatus: http.StatusOK},
+		{name: "missing target", query: "from=USD&amount=100", wantStatus: http.StatusBadRequest},
+		{name: "invalid amount", query: "from=USD&to=EUR&amount=abc", wantStatus: http.StatusBadRequest},
+		{name: "infinite amount", query: "from=USD&to=EUR&amount=Inf", wantStatus: http.StatusBadRequest},
+		{name: "unsupported currency", query: "from=USD&to=XYZ&amount=100", wantStatus: http.StatusBadRequest},
+		{name: "unsupported currency among targets", query: "from=USD&to=EUR,XYZ&amount=100", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/convert?"+tt.query, nil)
+
+			handlers.Convert(c)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Convert() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response models.ConversionResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Convert() response unmarshal error = %v", err)
+			}
+			if response.MidRate != 0.85 {
+				t.Errorf("Convert() MidRate = %v, want %v", response.MidRate, 0.85)
+			}
+			if response.Rate >= response.MidRate {
+				t.Errorf("Convert() Rate = %v, want less than mid rate %v", response.Rate, response.MidRate)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetPairRate(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "valid pair", query: "pair=usd/eur", wantStatus: http.StatusOK},
+		{name: "missing pair", query: "", wantStatus: http.StatusBadRequest},
+		{name: "malformed pair", query: "pair=USDEUR", wantStatus: http.StatusBadRequest},
+		{name: "unsupported pair", query: "pair=USD/XYZ", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/rate?"+tt.query, nil)
+
+			handlers.GetPairRate(c)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GetPairRate() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response models.PairRateResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GetPairRate() response unmarshal error = %v", err)
+			}
+			if response.Pair != "USD/EUR" || response.Rate != 0.85 || response.Inverse != 1/0.85 {
+				t.Errorf("GetPairRate() = %+v, want USD/EUR 0.85 and its inverse", response)
+			}
+		})
+	}
+}
+
+func TestHandlers_Convert_MultipleTargets(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/convert?from=USD&to=eur,%20GBP,EUR&amount=100", nil)
+
+	handlers.Convert(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Convert() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var response models.MultiConversionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Convert() response unmarshal error = %v", err)
+	}
+	if response.From != "USD" || len(response.Conversions) != 2 {
+		t.Fatalf("Convert() = %+v, want EUR and GBP conversions", response)
+	}
+	if response.Conversions[0].To != "EUR" || response.Conversions[0].MidRate != 0.85 || response.Conversions[1].To != "GBP" {
+		t.Errorf("Convert() conversions = %+v", response.Conversions)
+	}
+}
+
+func TestHandlers_Convert_Date(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	tests := []struct {
+		query      string
+		wantStatus int
+	}{
+		{"from=USD&to=EUR&date=2024-03-08", http.StatusServiceUnavailable},
+		{"from=USD&to=EUR,GBP&date=2024-03-08", http.StatusBadRequest},
+		{"from=USD&to=EUR&date=08/03/2024", http.StatusBadRequest},
+		{"from=USD&to=EUR&date=" + time.Now().UTC().Format("2006-01-02"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/v1/convert?"+tt.query, nil)
+
+		handlers.Convert(c)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("Convert(%s) status = %v, want %v: %s", tt.query, w.Code, tt.wantStatus, w.Body.String())
+		}
+	}
+}
+
+func TestHandlers_GetRates_Side(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	router := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	}).SetupRoutes(RouterOptions{})
+
+	// The mock provider quotes no spreads, so the bid side falls back to mid
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/rates/USD?side=bid", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET rates?side=bid status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var response models.RatesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response unmarshal error = %v", err)
+	}
+	if response.Side != models.SideBid || !response.MidFallback || response.Rates["EUR"] != 0.85 {
+		t.Errorf("GET rates?side=bid = %+v, want mid rates flagged", response)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/convert?from=USD&to=EUR&side=offer", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET convert?side=offer status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandlers_TenantAuthentication(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{
+		{ID: "acme", APIKeys: []string{"acme-key"}, AllowedCurrencies: []string{"USD", "EUR"}, RateLimitRequests: 100, RateLimitBurst: 10},
+	}
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	tests := []struct {
+		name       string
+		path       string
+		apiKey     string
+		wantStatus int
+	}{
+		{name: "health without key", path: "/health", wantStatus: http.StatusOK},
+		{name: "rates without key", path: "/api/v1/rates", wantStatus: http.StatusUnauthorized},
+		{name: "rates with unknown key", path: "/api/v1/rates", apiKey: "other", wantStatus: http.StatusUnauthorized},
+		{name: "rates with tenant key", path: "/api/v1/rates", apiKey: "acme-key", wantStatus: http.StatusOK},
+		{name: "disallowed base", path: "/api/v1/rates/GBP", apiKey: "acme-key", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s status = %v, want %v", tt.path, w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetCurrencies(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/v1/currencies", nil)
+
+	handlers.GetCurrencies(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetCurrencies() status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Currencies []currency.Currency `json:"currencies"`
+		Count      int                 `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GetCurrencies() response unmarshal error = %v", err)
+	}
+	if response.Count != len(response.Currencies) || response.Count == 0 {
+		t.Errorf("GetCurrencies() count = %v, currencies = %v", response.Count, len(response.Currencies))
+	}
+
+	foundGold := false
+	for _, supported := range response.Currencies {
+		if supported.Code == "XAU" && supported.Type == currency.TypeMetal {
+			foundGold = true
+		}
+	}
+	if !foundGold {
+		t.Error("GetCurrencies() missing XAU metal entry")
+	}
+}
+
+func TestHandlers_ContentNegotiation(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "default json", accept: "", wantContentType: "application/json", wantBody: `"rates":{`},
+		{name: "explicit json", accept: "application/json", wantContentType: "application/json", wantBody: `"rates":{`},
+		{name: "xml", accept: "application/xml", wantContentType: "application/xml", wantBody: `<rate currency="EUR">0.85</rate>`},
+		{name: "msgpack", accept: "application/msgpack", wantContentType: "application/msgpack", wantBody: "rates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/rates", nil)
+			if tt.accept != "" {
+				c.Request.Header.Set("Accept", tt.accept)
+			}
+
+			handlers.GetRates(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetRates() status = %v, want %v", w.Code, http.StatusOK)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.wantContentType) {
+				t.Errorf("GetRates() Content-Type = %v, want %v", contentType, tt.wantContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("GetRates() body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetProviders(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{Logger: logger, RatesService: service.NewRatesService(cfg, logger)})
+
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/providers", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetProviders() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var response struct {
+		Providers []models.ProviderStatus `json:"providers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("GetProviders() response unmarshal error = %v", err)
+	}
+	if len(response.Providers) == 0 {
+		t.Error("GetProviders() returned no providers")
+	}
+}
+
+func TestHandlers_AdminAuthentication(t *testing.T) {
+	tests := []struct {
+		name        string
+		adminAPIKey string
+		header      string
+		wantStatus  int
+	}{
+		{name: "admin API disabled", header: "anything", wantStatus: http.StatusForbidden},
+		{name: "missing key", adminAPIKey: "admin-secret", wantStatus: http.StatusUnauthorized},
+		{name: "wrong key", adminAPIKey: "admin-secret", header: "wrong", wantStatus: http.StatusUnauthorized},
+		{name: "valid key", adminAPIKey: "admin-secret", header: "admin-secret", wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutils.MockConfig()
+			logger := testutils.MockLogger()
+			handlers := NewHandlers(HandlerConfig{
+				Logger:       logger,
+				RatesService: service.NewRatesService(cfg, logger),
+				AdminAPIKey:  tt.adminAPIKey,
+			})
+
+			req := httptest.NewRequest("DELETE", "/admin/v1/cache", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Admin-Key", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("DELETE /admin/v1/cache status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandlers_AdminRoles(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	router := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		AdminKeys: []config.AdminKey{
+			{Name: "dashboard", Role: config.AdminRoleViewer, Keys: []string{"viewer-key"}},
+			{Name: "oncall", Role: config.AdminRoleOperator, Keys: []string{"operator-key"}},
+		},
+	}).SetupRoutes(RouterOptions{})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		adminKey   string
+		wantStatus int
+	}{
+		{name: "viewer reads transitions", method: "GET", target: "/admin/v1/providers/transitions", adminKey: "viewer-key", wantStatus: http.StatusOK},
+		{name: "viewer cannot purge", method: "DELETE", target: "/admin/v1/cache", adminKey: "viewer-key", wantStatus: http.StatusForbidden},
+		{name: "operator purges", method: "DELETE", target: "/admin/v1/cache", adminKey: "operator-key", wantStatus: http.StatusNoContent},
+		{name: "operator cannot dump the configuration", method: "GET", target: "/admin/v1/config", adminKey: "operator-key", wantStatus: http.StatusForbidden},
+		{name: "operator cannot read the audit log", method: "GET", target: "/admin/v1/audit", adminKey: "operator-key", wantStatus: http.StatusForbidden},
+		{name: "unknown key", method: "GET", target: "/admin/v1/providers/transitions", adminKey: "tenant-key", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("X-Admin-Key", tt.adminKey)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %v, want %v", tt.method, tt.target, w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandlers_AdminDenyByDefault(t *testing.T) {
+	router := NewHandlers(HandlerConfig{Logger: testutils.MockLogger(), AdminAPIKey: "admin-secret"}).SetupRoutes(RouterOptions{
+		Routes: []RouteRegistrar{func(router *gin.Engine) {
+			// An admin route registered without a permission, e.g. by an embedder
+			router.GET("/admin/v1/undeclared", func(context *gin.Context) {
+				context.Status(http.StatusOK)
+			})
+		}},
+	})
+
+	req := httptest.NewRequest("GET", "/admin/v1/undeclared", nil)
+	req.Header.Set("X-Admin-Key", "admin-secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("GET /admin/v1/undeclared status = %v, want %v", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandlers_GetAdminAudit(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	router := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		AdminAPIKey:  "admin-secret",
+		AdminKeys:    []config.AdminKey{{Name: "dashboard", Role: config.AdminRoleViewer, Keys: []string{"viewer-key"}}},
+	}).SetupRoutes(RouterOptions{})
+	call := func(method, target, adminKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("X-Admin-Key", adminKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	call("DELETE", "/admin/v1/cache", "admin-secret")
+	call("DELETE", "/admin/v1/cache", "viewer-key")
+	call("GET", "/admin/v1/providers/transitions", "viewer-key") // Reads are not audited
+
+	w := call("GET", "/admin/v1/audit", "admin-secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /admin/v1/audit status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var response struct {
+		Entries []models.AdminAuditEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Entries) != 2 {
+		t.Fatalf("audit entries = %+v, want the purge and the denied purge", response.Entries)
+	}
+	denied, purged := response.Entries[0], response.Entries[1]
+	if denied.Admin != "dashboard" || denied.Status != http.StatusForbidden || denied.Method != "DELETE" {
+		t.Errorf("newest audit entry = %+v, want the viewer's denied purge", denied)
+	}
+	if purged.Admin != "admin" || purged.Role != config.AdminRoleAdmin || purged.Status != http.StatusNoContent || purged.Path != "/admin/v1/cache" || purged.RequestID == "" {
+		t.Errorf("oldest audit entry = %+v, want the admin's purge", purged)
+	}
+}
+
+func TestHandlers_SetCacheTTL(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	ratesService := service.NewRatesService(cfg, logger)
+	router := NewHandlers(HandlerConfig{Logger: logger, RatesService: ratesService, AdminAPIKey: "admin-secret"}).SetupRoutes(RouterOptions{})
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantTTL    time.Duration
+	}{
+		{name: "missing seconds", target: "/admin/v1/cache/ttl", wantStatus: http.StatusBadRequest, wantTTL: cfg.RatesCacheTTL},
+		{name: "negative seconds", target: "/admin/v1/cache/ttl?seconds=-1", wantStatus: http.StatusBadRequest, wantTTL: cfg.RatesCacheTTL},
+		{name: "new TTL", target: "/admin/v1/cache/ttl?seconds=30", wantStatus: http.StatusOK, wantTTL: 30 * time.Second},
+		{name: "no caching", target: "/admin/v1/cache/ttl?seconds=0", wantStatus: http.StatusOK, wantTTL: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.target, nil)
+			req.Header.Set("X-Admin-Key", "admin-secret")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("PUT %s status = %v, want %v", tt.target, w.Code, tt.wantStatus)
+			}
+			if ttl := ratesService.CacheTTL(); ttl != tt.wantTTL {
+				t.Errorf("PUT %s CacheTTL() = %v, want %v", tt.target, ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetConfig(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.AdminAPIKey = "admin-secret"
+	logger := testutils.MockLogger()
+	ratesService := service.NewRatesService(cfg, logger)
+	ratesService.SetCacheTTL(context.Background(), 90*time.Second)
+	router := NewHandlers(HandlerConfig{Logger: logger, RatesService: ratesService, AdminAPIKey: cfg.AdminAPIKey, Config: cfg}).SetupRoutes(RouterOptions{})
+
+	req := httptest.NewRequest("GET", "/admin/v1/config", nil)
+	req.Header.Set("X-Admin-Key", "admin-secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /admin/v1/config status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if strings.Contains(w.Body.String(), "admin-secret") {
+		t.Errorf("GET /admin/v1/config leaks the admin key: %s", w.Body.String())
+	}
+	var dump models.ConfigDump
+	if err := json.Unmarshal(w.Body.Bytes(), &dump); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if dump.Config["AdminAPIKey"] != config.Redacted || dump.Config["RatesCacheTTL"] != "1m30s" {
+		t.Errorf("GET /admin/v1/config = %v, want the admin key redacted and the runtime cache TTL", dump.Config)
+	}
+}
+
+func TestHandlers_GetAlerts(t *testing.T) {
+	logger := testutils.MockLogger()
+	engine, err := alert.NewEngine(config.AlertingConfig{
+		EvaluationInterval: time.Minute,
+		Rules:              []config.AlertRule{{Name: "api_errors", Metric: alert.RequestErrorRate, Threshold: 0.5, Severity: "critical"}},
+	}, alert.Sources{}, logger)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	handlers := NewHandlers(HandlerConfig{Logger: logger, AdminAPIKey: "admin-secret", Alerts: engine})
+	engine.SetRequestCounter(handlers)
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	// A failing request drives the request error rate above the threshold
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/rates/USD", nil))
+	engine.Evaluate(context.Background())
+
+	req := httptest.NewRequest("GET", "/admin/v1/alerts", nil)
+	req.Header.Set("X-Admin-Key", "admin-secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response struct {
+		Alerts []models.Alert `json:"alerts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Alerts) != 1 || response.Alerts[0].Rule != "api_errors" {
+		t.Errorf("GET /admin/v1/alerts = %d %s, want the api_errors alert", w.Code, w.Body.String())
+	}
+
+	// Without alerting the route does not exist
+	router = NewHandlers(HandlerConfig{Logger: logger, AdminAPIKey: "admin-secret"}).SetupRoutes(RouterOptions{})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET /admin/v1/alerts without alerting status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandlers_ProviderStateAdmin(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		AdminAPIKey:  "admin-secret",
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	request := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("X-Admin-Key", "admin-secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("POST", "/admin/v1/providers/erapi/disable?standby=true&reason=incident")
+	var status models.ProviderStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("disable status = %v, body = %s", w.Code, w.Body.String())
+	}
+	if !status.Disabled || !status.Standby || status.DisabledBy != "operator" {
+		t.Errorf("disable response = %+v, want disabled in standby by the operator", status)
+	}
+
+	if w := request("POST", "/admin/v1/providers/unknown/disable"); w.Code != http.StatusNotFound {
+		t.Errorf("disable of an unknown provider status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+
+	if w := request("POST", "/admin/v1/providers/erapi/enable"); w.Code != http.StatusOK {
+		t.Errorf("enable status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	w = request("GET", "/admin/v1/providers/transitions")
+	var response struct {
+		Transitions []models.ProviderTransition `json:"transitions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Transitions) != 2 {
+		t.Fatalf("transitions = %s, want 2 transitions", w.Body.String())
+	}
+	if newest := response.Transitions[0]; newest.Provider != "erapi" || newest.From != "standby" || newest.To != "enabled" || newest.By != "operator" {
+		t.Errorf("newest transition = %+v, want erapi standby -> enabled by the operator", newest)
+	}
+	if oldest := response.Transitions[1]; oldest.Reason != "incident" {
+		t.Errorf("oldest transition reason = %q, want incident", oldest.Reason)
+	}
+}
+
+func TestHandlers_RotateSecrets(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		AdminAPIKey:  "old-admin-key",
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+	purge := func(adminKey string) int {
+		req := httptest.NewRequest("DELETE", "/admin/v1/cache", nil)
+		req.Header.Set("X-Admin-Key", adminKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	handlers.RotateSecrets("new-admin-key", nil, nil)
+
+	if code := purge("new-admin-key"); code != http.StatusNoContent {
+		t.Errorf("DELETE /admin/v1/cache with the rotated key status = %v, want %v", code, http.StatusNoContent)
+	}
+	if code := purge("old-admin-key"); code != http.StatusUnauthorized {
+		t.Errorf("DELETE /admin/v1/cache with the old key status = %v, want %v", code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandlers_handleServiceError(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+
+	tests := []struct {
+		name       string
+		errorType  service.ErrorType
+		wantStatus int
+	}{
+		{name: "unsupported base", errorType: service.ErrorTypeUnsupportedBase, wantStatus: http.StatusBadRequest},
+		{name: "providers unavailable", errorType: service.ErrorTypeProviderUnavailable, wantStatus: http.StatusServiceUnavailable},
+		{name: "upstream failure", errorType: service.ErrorTypeProviderFailed, wantStatus: http.StatusBadGateway},
+		{name: "budget exhausted", errorType: service.ErrorTypeBudgetExhausted, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/v1/rates/USD", nil)
+
+			handlers.handleServiceError(c, &service.ServiceError{Type: tt.errorType, Message: "failed"})
+			if w.Code != tt.wantStatus {
+				t.Errorf("handleServiceError() status = %v, want %v", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetRefreshHints(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{Logger: logger, RatesService: service.NewRatesService(cfg, logger)})
+
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/meta/refresh", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetRefreshHints() status = %v, want %v", w.Code, http.StatusOK)
+	}
+	var hints models.RefreshHints
+	if err := json.Unmarshal(w.Body.Bytes(), &hints); err != nil {
+		t.Fatalf("GetRefreshHints() response unmarshal error = %v", err)
+	}
+	if hints.CacheTTLSeconds != int64(cfg.RatesCacheTTL/time.Second) || len(hints.Bases) != 1 || hints.Bases[0].Base != "USD" {
+		t.Errorf("GetRefreshHints() = %+v, want the TTL and the default base", hints)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// mimeHAL is the media type clients send to opt into hypermedia responses
+const mimeHAL = "application/hal+json"
+
+// hypermediaFormats lists HAL after the plain formats so that wildcards keep plain JSON
+var hypermediaFormats = append(append([]string{}, offeredFormats...), mimeHAL)
+
+// halResource renders a response with a HAL "_links" object merged into its fields
+type halResource struct {
+	data  interface{}
+	links models.Links
+}
+
+// MarshalJSON merges the links into the JSON object of the wrapped data
+func (resource halResource) MarshalJSON() ([]byte, error) {
+	encoded, err := json.Marshal(resource.data)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+
+	encodedLinks, err := json.Marshal(resource.links)
+	if err != nil {
+		return nil, err
+	}
+	fields["_links"] = encodedLinks
+
+	return json.Marshal(fields)
+}
+
+// wantsHypermedia reports whether the client opted into hypermedia responses
+func wantsHypermedia(context *gin.Context) bool {
+	if hypermedia, err := strconv.ParseBool(context.Query("hypermedia")); err == nil {
+		return hypermedia
+	}
+	if context.GetHeader("Accept") == "" {
+		return false
+	}
+	return context.NegotiateFormat(hypermediaFormats...) == mimeHAL
+}
+
+// renderResource renders data, adding links when the client asked for hypermedia
+func (handlers *Handlers) renderResource(context *gin.Context, statusCode int, data interface{}, links models.Links) {
+	if !wantsHypermedia(context) {
+		handlers.render(context, statusCode, data)
+		return
+	}
+
+	var resource interface{} = halResource{data: data, links: versionLinks(context, links)}
+	if apiVersion(context) >= 2 {
+		resource = envelope(context, resource)
+	}
+
+	encoded, err := json.Marshal(resource)
+	if err != nil {
+		handlers.loggerFor(context).Errorf("Hypermedia encoding error: %v", err)
+		handlers.writeErrorResponse(context, http.StatusInternalServerError, "encoding error", err.Error())
+		return
+	}
+	context.Data(statusCode, mimeHAL+"; charset=utf-8", encoded)
+}
+
+// ratesLinks returns the navigation links for a rates resource
+func ratesLinks(baseCurrency string) models.Links {
+	escapedBase := url.PathEscape(baseCurrency)
+	return models.Links{
+		"self":       {Href: "/api/v1/rates/" + escapedBase},
+		"history":    {Href: "/api/v1/rates/" + escapedBase + "/export{?format,date}", Templated: true},
+		"convert":    {Href: "/api/v1/convert?from=" + url.QueryEscape(baseCurrency) + "{&to,amount,date}", Templated: true},
+		"currencies": {Href: "/api/v1/currencies"},
+	}
+}
+
+// conversionLinks returns the navigation links for a conversion resource
+func conversionLinks(conversion models.ConversionResponse) models.Links {
+	query := func(from, to string) string {
+		values := url.Values{
+			"from":   {from},
+			"to":     {to},
+			"amount": {strconv.FormatFloat(conversion.Amount, 'f', -1, 64)},
+		}
+		if conversion.Date != "" {
+			values.Set("date", conversion.Date)
+		}
+		return values.Encode()
+	}
+	links := models.Links{
+		"self":    {Href: "/api/v1/convert?" + query(conversion.From, conversion.To)},
+		"inverse": {Href: "/api/v1/convert?" + query(conversion.To, conversion.From)},
+		"rates":   {Href: "/api/v1/rates/" + url.PathEscape(conversion.From)},
+	}
+	if conversion.AttestationID != "" {
+		links["attestation"] = models.Link{Href: "/api/v1/attestations/" + url.PathEscape(conversion.AttestationID)}
+	}
+	return links
+}
+
+// pairRateLinks returns the navigation links for a pair rate resource
+func pairRateLinks(fromCurrency, toCurrency string) models.Links {
+	return models.Links{
+		"self":    {Href: "/api/v1/rate?pair=" + url.QueryEscape(fromCurrency+"/"+toCurrency)},
+		"inverse": {Href: "/api/v1/rate?pair=" + url.QueryEscape(toCurrency+"/"+fromCurrency)},
+		"convert": {Href: "/api/v1/convert?" + url.Values{"from": {fromCurrency}, "to": {toCurrency}}.Encode() + "{&amount}", Templated: true},
+	}
+}
+
+// multiConversionLinks returns the navigation links for a multi-target conversion resource
+func multiConversionLinks(conversions models.MultiConversionResponse) models.Links {
+	targets := make([]string, 0, len(conversions.Conversions))
+	for _, conversion := range conversions.Conversions {
+		targets = append(targets, conversion.To)
+	}
+	query := url.Values{
+		"from":   {conversions.From},
+		"to":     {strings.Join(targets, ",")},
+		"amount": {strconv.FormatFloat(conversions.Amount, 'f', -1, 64)},
+	}
+	return models.Links{
+		"self":  {Href: "/api/v1/convert?" + query.Encode()},
+		"rates": {Href: "/api/v1/rates/" + url.PathEscape(conversions.From)},
+	}
+}
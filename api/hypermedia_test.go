@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandlers_Hypermedia(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+	})
+
+	tests := []struct {
+		name      string
+		target    string
+		accept    string
+		wantLinks bool
+	}{
+		{name: "plain json", target: "/api/v1/rates", wantLinks: false},
+		{name: "hal accept header", target: "/api/v1/rates", accept: "application/hal+json", wantLinks: true},
+		{name: "hypermedia query", target: "/api/v1/rates?hypermedia=true", wantLinks: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", tt.target, nil)
+			if tt.accept != "" {
+				c.Request.Header.Set("Accept", tt.accept)
+			}
+
+			handlers.GetRates(c)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetRates() status = %v, want %v", w.Code, http.StatusOK)
+			}
+
+			var response struct {
+				models.RatesResponse
+				Links models.Links `json:"_links"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("GetRates() response unmarshal error = %v", err)
+			}
+			if response.Base != "USD" || len(response.Rates) == 0 {
+				t.Errorf("GetRates() lost resource fields: %+v", response.RatesResponse)
+			}
+
+			if !tt.wantLinks {
+				if response.Links != nil {
+					t.Errorf("GetRates() unexpected links %v", response.Links)
+				}
+				return
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/hal+json") {
+				t.Errorf("GetRates() Content-Type = %v, want application/hal+json", w.Header().Get("Content-Type"))
+			}
+			if response.Links["self"].Href != "/api/v1/rates/USD" {
+				t.Errorf("GetRates() self link = %v", response.Links["self"])
+			}
+			if !response.Links["convert"].Templated || !response.Links["history"].Templated {
+				t.Errorf("GetRates() convert/history links should be templated: %v", response.Links)
+			}
+		})
+	}
+}
+
+func TestConversionLinks(t *testing.T) {
+	links := conversionLinks(models.ConversionResponse{From: "USD", To: "EUR", Amount: 100})
+
+	if links["self"].Href != "/api/v1/convert?amount=100&from=USD&to=EUR" {
+		t.Errorf("conversionLinks() self = %v", links["self"].Href)
+	}
+	if links["inverse"].Href != "/api/v1/convert?amount=100&from=EUR&to=USD" {
+		t.Errorf("conversionLinks() inverse = %v", links["inverse"].Href)
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// recentRequestsLimit is the number of requests kept for the dashboard
+const recentRequestsLimit = 50
+
+// requestMetrics keeps request counters and a ring buffer of recent requests
+type requestMetrics struct {
+	mutex         sync.Mutex
+	total         int64
+	errors        int64
+	totalDuration time.Duration
+	recent        []models.RequestRecord
+	next          int
+}
+
+// record adds a completed request to the metrics
+func (metrics *requestMetrics) record(record models.RequestRecord, duration time.Duration) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	metrics.total++
+	if record.Status >= http.StatusInternalServerError {
+		metrics.errors++
+	}
+	metrics.totalDuration += duration
+
+	if len(metrics.recent) < recentRequestsLimit {
+		metrics.recent = append(metrics.recent, record)
+		return
+	}
+	metrics.recent[metrics.next] = record
+	metrics.next = (metrics.next + 1) % recentRequestsLimit
+}
+
+// snapshot returns the counters and recent requests, newest first
+func (metrics *requestMetrics) snapshot() models.RequestStats {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	stats := models.RequestStats{
+		Total:  metrics.total,
+		Errors: metrics.errors,
+		Recent: make([]models.RequestRecord, 0, len(metrics.recent)),
+	}
+	if metrics.total > 0 {
+		stats.AverageDurationMS = float64(metrics.totalDuration.Microseconds()) / 1000 / float64(metrics.total)
+	}
+	for i := len(metrics.recent) - 1; i >= 0; i-- {
+		stats.Recent = append(stats.Recent, metrics.recent[(metrics.next+i)%len(metrics.recent)])
+	}
+	return stats
+}
+
+// RequestCounts returns the API requests served since startup and how many of them failed
+// with a server error, for alerting on the request error rate
+func (handlers *Handlers) RequestCounts() (total, errors int64) {
+	handlers.metrics.mutex.Lock()
+	defer handlers.metrics.mutex.Unlock()
+	return handlers.metrics.total, handlers.metrics.errors
+}
+
+// metricsMiddleware records every API request except the dashboard, stats and runtime
+// samples themselves
+func (handlers *Handlers) metricsMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		start := time.Now()
+		context.Next()
+
+		path := context.FullPath()
+		if path == "" || path == "/stats" || path == "/debug/runtime" || path == "/dashboard/*filepath" {
+			return
+		}
+		duration := time.Since(start)
+		handlers.routeBudgets.Observe(path, duration)
+		handlers.metrics.record(models.RequestRecord{
+			Method:     context.Request.Method,
+			Path:       path,
+			Status:     context.Writer.Status(),
+			DurationMS: float64(duration.Microseconds()) / 1000,
+			Time:       start,
+		}, duration)
+	}
+}
+
+// GetStats returns cache statistics and request metrics for the dashboard
+func (handlers *Handlers) GetStats(context *gin.Context) {
+	response := gin.H{
+		"uptime":   time.Since(handlers.startTime).String(),
+		"requests": handlers.metrics.snapshot(),
+	}
+	if handlers.ratesService != nil {
+		response["cache"] = handlers.ratesService.CacheStats()
+		response["provider_order"] = handlers.ratesService.EffectiveProviderOrder()
+		if budget := handlers.ratesService.BudgetStats(); budget != nil {
+			response["provider_budget"] = budget
+		}
+	}
+	var providerBudgets []models.LatencyBudget
+	if handlers.ratesService != nil {
+		providerBudgets = handlers.ratesService.LatencyBudgetStats()
+	}
+	if routeBudgets := handlers.routeBudgets.Stats(); routeBudgets != nil || providerBudgets != nil {
+		response["latency_budgets"] = gin.H{"routes": routeBudgets, "providers": providerBudgets}
+	}
+	if admissionStats := handlers.admission.Stats(); admissionStats != nil {
+		response["admission"] = admissionStats
+	}
+	if handlers.rateLimiter != nil {
+		response["rate_limiter"] = handlers.rateLimiter.Stats()
+	}
+	if abuseStats := handlers.abuse.Stats(); abuseStats != nil {
+		response["abuse"] = abuseStats
+	}
+	if handlers.store != nil {
+		response["history"] = handlers.store.CompactionStats()
+	}
+	if handlers.stream != nil {
+		response["streams"] = handlers.stream.Stats()
+	}
+
+	handlers.render(context, http.StatusOK, response)
+}
+
+// GetRuntimeStats reports goroutines, heap and the client-driven subsystem sizes
+// for soak tests to sample
+func (handlers *Handlers) GetRuntimeStats(context *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := models.RuntimeStats{
+		SampledAt:      time.Now(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: memStats.HeapAlloc,
+		HeapObjects:    memStats.HeapObjects,
+		GCCycles:       memStats.NumGC,
+	}
+	if handlers.rateLimiter != nil {
+		stats.LimiterBuckets = handlers.rateLimiter.Stats().Buckets
+	}
+	if handlers.stream != nil {
+		stats.StreamConnections = handlers.stream.Stats().Connections
+	}
+
+	handlers.render(context, http.StatusOK, stats)
+}
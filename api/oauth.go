@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+)
+
+// IssueToken implements the OAuth2 client-credentials grant (RFC 6749 section 4.4):
+// configured machine clients exchange their credentials, sent with HTTP Basic
+// authentication or as client_id and client_secret form parameters, for a short-lived
+// JWT accepted as a bearer token
+func (handlers *Handlers) IssueToken(context *gin.Context) {
+	switch grantType := context.PostForm("grant_type"); grantType {
+	case "client_credentials":
+	case "":
+		handlers.writeOAuthError(context, http.StatusBadRequest, "invalid_request", "grant_type is required")
+		return
+	default:
+		handlers.writeOAuthError(context, http.StatusBadRequest, "unsupported_grant_type", "only the client_credentials grant is supported")
+		return
+	}
+
+	clientID, secret, basic := context.Request.BasicAuth()
+	if !basic {
+		clientID, secret = context.PostForm("client_id"), context.PostForm("client_secret")
+	}
+	client, authenticated := handlers.oauth.Authenticate(clientID, secret)
+	if !authenticated {
+		handlers.loggerFor(context).Warnf("OAuth client authentication failed for client %q", clientID)
+		if basic && clientID != "" {
+			failCredential(context, abuse.ClientSubject(clientID))
+		}
+		handlers.writeOAuthError(context, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	token, lifetime, err := handlers.oauth.Issue(client)
+	if err != nil {
+		handlers.loggerFor(context).Errorf("Failed to issue token for client %s: %v", client.ID, err)
+		handlers.writeOAuthError(context, http.StatusInternalServerError, "server_error", "failed to issue token")
+		return
+	}
+
+	context.Header("Cache-Control", "no-store")
+	context.Header("Pragma", "no-cache")
+	context.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(lifetime.Seconds()),
+	})
+}
+
+// GetOAuthKeys publishes the key set verifying the issued tokens, for services that
+// verify them themselves
+func (handlers *Handlers) GetOAuthKeys(context *gin.Context) {
+	context.JSON(http.StatusOK, handlers.oauth.KeySet())
+}
+
+// writeOAuthError writes an OAuth2 error response (RFC 6749 section 5.2)
+func (handlers *Handlers) writeOAuthError(context *gin.Context, statusCode int, errorCode, description string) {
+	context.Header("Cache-Control", "no-store")
+	context.Header("Pragma", "no-cache")
+	if statusCode == http.StatusUnauthorized {
+		context.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	context.JSON(statusCode, gin.H{"error": errorCode, "error_description": description})
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_IssueToken(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{{ID: "acme", RateLimitRequests: 100, RateLimitBurst: 10}}
+	issuer, err := auth.NewIssuer(config.OAuthConfig{
+		Clients:  []config.OAuthClient{{ID: "billing", Secrets: []string{"s3cret"}, Tenant: "acme"}},
+		Issuer:   "currency-exchange-service",
+		TokenTTL: 5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+		JWT:          auth.NewVerifier(config.JWTConfig{}, issuer, nil),
+		OAuth:        issuer,
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	requestToken := func(form url.Values, clientID, secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if clientID != "" {
+			req.SetBasicAuth(clientID, secret)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := requestToken(url.Values{"grant_type": {"client_credentials"}}, "billing", "s3cret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /oauth/token status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.TokenType != "Bearer" || response.ExpiresIn != 300 {
+		t.Fatalf("token response = %+v (%v), want a Bearer token valid for 300s", response, err)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("token response Cache-Control = %q, want no-store", w.Header().Get("Cache-Control"))
+	}
+
+	// The issued token authenticates as the client's tenant
+	req := httptest.NewRequest("GET", "/api/v1/rates/EUR", nil)
+	req.Header.Set("Authorization", "Bearer "+response.AccessToken)
+	apiResponse := httptest.NewRecorder()
+	router.ServeHTTP(apiResponse, req)
+	if apiResponse.Code != http.StatusOK {
+		t.Errorf("request with the issued token status = %v, want %v: %s", apiResponse.Code, http.StatusOK, apiResponse.Body.String())
+	}
+
+	// Credentials may also be sent as form parameters
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {"billing"}, "client_secret": {"s3cret"}}
+	if w := requestToken(form, "", ""); w.Code != http.StatusOK {
+		t.Errorf("POST /oauth/token with form credentials status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	tests := []struct {
+		name      string
+		grantType string
+		secret    string
+		wantCode  int
+		wantError string
+	}{
+		{"wrong secret", "client_credentials", "guess", http.StatusUnauthorized, "invalid_client"},
+		{"unsupported grant", "password", "s3cret", http.StatusBadRequest, "unsupported_grant_type"},
+		{"missing grant", "", "s3cret", http.StatusBadRequest, "invalid_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := requestToken(url.Values{"grant_type": {tt.grantType}}, "billing", tt.secret)
+			var oauthError struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &oauthError)
+			if w.Code != tt.wantCode || oauthError.Error != tt.wantError {
+				t.Errorf("POST /oauth/token = %v %q, want %v %q", w.Code, oauthError.Error, tt.wantCode, tt.wantError)
+			}
+		})
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/oauth/jwks.json", nil))
+	var keySet auth.JSONWebKeySet
+	if err := json.Unmarshal(w.Body.Bytes(), &keySet); err != nil || len(keySet.Keys) != 1 {
+		t.Errorf("GET /oauth/jwks.json = %s, want the signing key", w.Body.String())
+	}
+}
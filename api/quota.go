@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/quota"
+)
+
+// quotaHeaderPrefixes names the response headers of each quota period
+var quotaHeaderPrefixes = map[string]string{
+	quota.Daily:   "X-Quota-Daily-",
+	quota.Monthly: "X-Quota-Monthly-",
+}
+
+// quotaMiddleware counts requests against the daily and monthly quotas of their API key
+// and rejects them with 429 once a quota is exhausted. It runs after tenant resolution.
+func (handlers *Handlers) quotaMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		value, exists := context.Get(tenantContextKey)
+		if !exists {
+			context.Next()
+			return
+		}
+		resolvedTenant := value.(*config.Tenant)
+		if !resolvedTenant.HasQuota() {
+			context.Next()
+			return
+		}
+		limits := quota.Limits{Daily: resolvedTenant.DailyQuota, Monthly: resolvedTenant.MonthlyQuota}
+
+		now := time.Now()
+		statuses, allowed := handlers.quotas.Allow(context.GetString(keyIDContextKey), limits, now)
+		for _, status := range statuses {
+			prefix := quotaHeaderPrefixes[status.Period]
+			context.Header(prefix+"Limit", strconv.Itoa(status.Limit))
+			context.Header(prefix+"Remaining", strconv.Itoa(status.Remaining))
+			context.Header(prefix+"Reset", strconv.FormatInt(status.Reset.Unix(), 10))
+		}
+		if allowed {
+			context.Next()
+			return
+		}
+
+		// Retry once every exhausted quota has been reset
+		var exhausted quota.Status
+		for _, status := range statuses {
+			if status.Remaining == 0 && status.Reset.After(exhausted.Reset) {
+				exhausted = status
+			}
+		}
+		handlers.loggerFor(context).Warnf("Request quota exceeded for tenant %s: %s limit of %d", resolvedTenant.ID, exhausted.Period, exhausted.Limit)
+		context.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(exhausted.Reset.Sub(now).Seconds())), 10))
+		handlers.writeErrorResponse(context, http.StatusTooManyRequests, "quota exceeded",
+			fmt.Sprintf("%s quota of %d requests exhausted until %s", exhausted.Period, exhausted.Limit, exhausted.Reset.Format(time.RFC3339)))
+		context.Abort()
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/quota"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_QuotaMiddleware(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{
+		{ID: "acme", APIKeys: []string{"acme-key", "acme-other-key"}, RateLimitRequests: 100, RateLimitBurst: 10, DailyQuota: 2, MonthlyQuota: 100},
+		{ID: "unlimited", APIKeys: []string{"unlimited-key"}, RateLimitRequests: 100, RateLimitBurst: 10},
+	}
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+		Quotas:       quota.NewManager(nil, logger),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	request := func(path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, want := range []string{"1", "0"} {
+		w := request("/api/v1/rates/EUR", "acme-key")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %v, want %v", i+1, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("X-Quota-Daily-Remaining"); got != want {
+			t.Errorf("request %d X-Quota-Daily-Remaining = %q, want %q", i+1, got, want)
+		}
+		if w.Header().Get("X-Quota-Daily-Limit") != "2" || w.Header().Get("X-Quota-Monthly-Limit") != "100" || w.Header().Get("X-Quota-Monthly-Reset") == "" {
+			t.Errorf("request %d quota headers = %v", i+1, w.Header())
+		}
+	}
+
+	w := request("/api/v2/rates/EUR", "acme-key")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request beyond the daily quota status = %v, want %v", w.Code, http.StatusTooManyRequests)
+	}
+	if retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retryAfter <= 0 || retryAfter > 86400 {
+		t.Errorf("Retry-After = %q, want the seconds until the next UTC day", w.Header().Get("Retry-After"))
+	}
+	var problem models.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil || problem.Status != http.StatusTooManyRequests {
+		t.Errorf("quota problem = %+v (%v), want status 429", problem, err)
+	}
+
+	// Quotas are counted per key; tenants without quotas send no quota headers
+	if w := request("/api/v1/rates/EUR", "acme-other-key"); w.Code != http.StatusOK {
+		t.Errorf("another key of the tenant status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if w := request("/api/v1/rates/EUR", "unlimited-key"); w.Code != http.StatusOK || w.Header().Get("X-Quota-Daily-Limit") != "" {
+		t.Errorf("unlimited tenant status = %v, headers = %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+)
+
+// offeredFormats lists the response encodings supported by every handler; JSON is the default
+var offeredFormats = []string{
+	binding.MIMEJSON,
+	binding.MIMEXML,
+	binding.MIMEXML2,
+	binding.MIMEMSGPACK,
+	binding.MIMEMSGPACK2,
+}
+
+// render writes data in the encoding negotiated from the Accept header, wrapped in the
+// response envelope on API v2 routes
+func (handlers *Handlers) render(context *gin.Context, statusCode int, data interface{}) {
+	if apiVersion(context) >= 2 {
+		data = envelope(context, data)
+	}
+
+	switch context.NegotiateFormat(offeredFormats...) {
+	case binding.MIMEXML, binding.MIMEXML2:
+		context.XML(statusCode, data)
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		context.Render(statusCode, render.MsgPack{Data: data})
+	default:
+		context.JSON(statusCode, data)
+	}
+}
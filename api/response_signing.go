@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// signRates sets the signature headers of a served rates table, when responses are signed
+func (handlers *Handlers) signRates(context *gin.Context, exchangeRates models.RatesResponse) {
+	if handlers.ratesSigner == nil {
+		return
+	}
+	context.Header(auth.RatesSignatureHeader, handlers.ratesSigner.SignRates(exchangeRates))
+	if keyID := handlers.ratesSigner.KeyID(); keyID != "" {
+		context.Header(auth.RatesSignatureKeyIDHeader, keyID)
+	}
+}
+
+// GetRatesSigningKeys publishes the key set verifying Ed25519 rates signatures, for
+// downstream services that cache our responses
+func (handlers *Handlers) GetRatesSigningKeys(context *gin.Context) {
+	keySet, _ := handlers.ratesSigner.KeySet()
+	context.JSON(http.StatusOK, keySet)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_SignedRates(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	signer, err := auth.NewResponseSigner(config.ResponseSigningConfig{Algorithm: auth.ResponseSigningEd25519})
+	if err != nil {
+		t.Fatalf("NewResponseSigner() error = %v", err)
+	}
+	logger := testutils.MockLogger()
+	router := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		RatesSigner:  signer,
+	}).SetupRoutes(RouterOptions{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/.well-known/rates-signing-keys.json", nil))
+	var keySet auth.JSONWebKeySet
+	if err := json.Unmarshal(w.Body.Bytes(), &keySet); err != nil || len(keySet.Keys) != 1 {
+		t.Fatalf("GET /.well-known/rates-signing-keys.json = %d %s", w.Code, w.Body.String())
+	}
+	publicKey, _ := base64.RawURLEncoding.DecodeString(keySet.Keys[0].X)
+
+	// Both the pre-encoded and the per-request encoded tables are signed
+	for _, path := range []string{"/api/v1/rates/USD", "/api/v1/rates/USD?symbols=EUR,GBP"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d: %s", path, w.Code, w.Body.String())
+		}
+		if keyID := w.Header().Get(auth.RatesSignatureKeyIDHeader); keyID != keySet.Keys[0].KeyID {
+			t.Errorf("GET %s key ID = %q, want %q", path, keyID, keySet.Keys[0].KeyID)
+		}
+
+		var exchangeRates models.RatesResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &exchangeRates); err != nil {
+			t.Fatalf("decoding GET %s: %v", path, err)
+		}
+		if err := auth.VerifyRatesSignature(w.Header().Get(auth.RatesSignatureHeader), exchangeRates, publicKey); err != nil {
+			t.Errorf("GET %s signature: %v", path, err)
+		}
+	}
+}
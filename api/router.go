@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// trustedPlatforms maps the TRUSTED_PLATFORM names of hosting platforms to the header
+// they put the client IP in
+var trustedPlatforms = map[string]string{
+	"cloudflare": gin.PlatformCloudflare,
+	"appengine":  gin.PlatformGoogleAppEngine,
+}
+
+// RouteRegistrar mounts an embedding application's endpoints and middleware on the engine
+// SetupRoutes builds
+type RouteRegistrar func(router *gin.Engine)
+
+// RouterOptions customizes the Gin engine SetupRoutes builds, for the service itself and
+// for applications embedding its routes
+type RouterOptions struct {
+	GinMode            string   // debug, release or test ("" = release)
+	TrustedPlatform    string   // Header the hosting platform puts the client IP in, e.g. CF-Connecting-IP ("" = none)
+	TrustedProxies     []string // IPs or CIDRs of the proxies whose X-Forwarded-For is trusted (none = Gin's default, all)
+	MaxMultipartMemory int64    // Bytes of a multipart form held in memory (0 = Gin's default)
+
+	// Middleware run on every request after request IDs and trace context are assigned,
+	// and before CORS, rate limiting and the routes
+	Middleware []gin.HandlerFunc
+
+	// Registrars called in order once the service's routes are registered. Their routes
+	// pass through the service's middleware. Registering a path the service serves panics,
+	// and routes under /admin/ are denied, as they have no admin permission.
+	Routes []RouteRegistrar
+}
+
+// NewRouterOptions returns the router options of the configuration. TrustedPlatform is
+// resolved from a platform name to its header; other values are taken as the header.
+func NewRouterOptions(configuration config.RouterConfig) (RouterOptions, error) {
+	if configuration.MaxMultipartMemory < 0 {
+		return RouterOptions{}, errors.New("MAX_MULTIPART_MEMORY_BYTES must not be negative")
+	}
+
+	for _, proxy := range configuration.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return RouterOptions{}, fmt.Errorf("invalid TRUSTED_PROXIES entry %q", proxy)
+			}
+		}
+	}
+
+	trustedPlatform := configuration.TrustedPlatform
+	if header, found := trustedPlatforms[trustedPlatform]; found {
+		trustedPlatform = header
+	}
+	return RouterOptions{
+		GinMode:            configuration.GinMode,
+		TrustedPlatform:    trustedPlatform,
+		TrustedProxies:     configuration.TrustedProxies,
+		MaxMultipartMemory: configuration.MaxMultipartMemory,
+	}, nil
+}
+
+// newEngine creates a Gin engine without middleware with the options' settings
+func (options RouterOptions) newEngine() *gin.Engine {
+	if options.GinMode != "" {
+		gin.SetMode(options.GinMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.TrustedPlatform = options.TrustedPlatform
+	if len(options.TrustedProxies) > 0 {
+		if err := router.SetTrustedProxies(options.TrustedProxies); err != nil {
+			panic(fmt.Sprintf("invalid trusted proxies: %v", err))
+		}
+	}
+	if options.MaxMultipartMemory > 0 {
+		router.MaxMultipartMemory = options.MaxMultipartMemory
+	}
+	return router
+}
+
+// clientIPTrusted reports whether the client IPs Gin resolves can be trusted: a platform
+// header or the proxies whose X-Forwarded-For is honored are configured. Otherwise any
+// client can claim another address in the header.
+func (options RouterOptions) clientIPTrusted() bool {
+	return options.TrustedPlatform != "" || len(options.TrustedProxies) > 0
+}
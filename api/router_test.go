@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestNewRouterOptions(t *testing.T) {
+	tests := []struct {
+		name                string
+		configuration       config.RouterConfig
+		wantTrustedPlatform string
+		wantErr             bool
+	}{
+		{name: "no platform", configuration: config.RouterConfig{GinMode: "release"}, wantTrustedPlatform: ""},
+		{name: "cloudflare", configuration: config.RouterConfig{TrustedPlatform: "cloudflare"}, wantTrustedPlatform: "CF-Connecting-IP"},
+		{name: "appengine", configuration: config.RouterConfig{TrustedPlatform: "appengine"}, wantTrustedPlatform: "X-Appengine-Remote-Addr"},
+		{name: "custom header", configuration: config.RouterConfig{TrustedPlatform: "Fly-Client-IP"}, wantTrustedPlatform: "Fly-Client-IP"},
+		{name: "negative multipart memory", configuration: config.RouterConfig{MaxMultipartMemory: -1}, wantErr: true},
+		{name: "trusted proxies", configuration: config.RouterConfig{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.10"}}},
+		{name: "invalid trusted proxy", configuration: config.RouterConfig{TrustedProxies: []string{"proxy.internal"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := NewRouterOptions(tt.configuration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRouterOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if options.TrustedPlatform != tt.wantTrustedPlatform {
+				t.Errorf("NewRouterOptions() TrustedPlatform = %q, want %q", options.TrustedPlatform, tt.wantTrustedPlatform)
+			}
+		})
+	}
+}
+
+func TestHandlers_SetupRoutesOptions(t *testing.T) {
+	var clientIP, requestID string
+	options := RouterOptions{
+		GinMode:            gin.TestMode,
+		TrustedPlatform:    gin.PlatformCloudflare,
+		MaxMultipartMemory: 1 << 20,
+		Middleware: []gin.HandlerFunc{func(context *gin.Context) {
+			clientIP = context.ClientIP()
+			requestID = context.GetString("request_id")
+		}},
+	}
+	router := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()}).SetupRoutes(options)
+
+	if router.MaxMultipartMemory != 1<<20 {
+		t.Errorf("SetupRoutes() MaxMultipartMemory = %d, want %d", router.MaxMultipartMemory, 1<<20)
+	}
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("CF-Connecting-IP", "203.0.113.7")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /health status = %v, want %v", w.Code, http.StatusOK)
+	}
+	if clientIP != "203.0.113.7" {
+		t.Errorf("middleware ClientIP() = %q, want the CF-Connecting-IP address", clientIP)
+	}
+	if requestID == "" || requestID != w.Header().Get("X-Request-ID") {
+		t.Errorf("middleware request_id = %q, want the response's X-Request-ID %q", requestID, w.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestHandlers_SetupRoutesRegistrars(t *testing.T) {
+	registerPing := func(router *gin.Engine) {
+		router.GET("/embedder/ping", func(context *gin.Context) {
+			context.String(http.StatusOK, "pong")
+		})
+	}
+	registerInternal := func(router *gin.Engine) {
+		internal := router.Group("/embedder/internal")
+		internal.Use(func(context *gin.Context) {
+			context.AbortWithStatus(http.StatusForbidden)
+		})
+		internal.GET("/state", func(context *gin.Context) {
+			context.String(http.StatusOK, "state")
+		})
+	}
+	router := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()}).SetupRoutes(RouterOptions{
+		Routes: []RouteRegistrar{registerPing, registerInternal},
+	})
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/embedder/ping", wantStatus: http.StatusOK},
+		{target: "/embedder/internal/state", wantStatus: http.StatusForbidden},
+		{target: "/health", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %v, want %v", tt.target, w.Code, tt.wantStatus)
+			}
+			// Embedded routes pass through the service's middleware
+			if w.Header().Get("X-Request-ID") == "" {
+				t.Errorf("GET %s has no X-Request-ID header", tt.target)
+			}
+		})
+	}
+}
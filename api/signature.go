@@ -0,0 +1,52 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+)
+
+// maxSignedBodyBytes bounds the request body read to verify its signature
+const maxSignedBodyBytes = 1 << 20
+
+// signatureMiddleware rejects requests made with an API key that requires signatures
+// unless they carry a valid, unused signature. It runs after the tenant middleware, so
+// the caller is known.
+func (handlers *Handlers) signatureMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		resolvedCaller, err := handlers.authenticate(context)
+		if err != nil || resolvedCaller.apiKey == "" || !handlers.signatures.Requires(resolvedCaller.apiKey) {
+			context.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(context.Writer, context.Request.Body, maxSignedBodyBytes))
+		if err != nil {
+			handlers.writeErrorResponse(context, http.StatusRequestEntityTooLarge, "invalid request", "body too large")
+			context.Abort()
+			return
+		}
+		// Handlers read the body again after it was verified
+		context.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = handlers.signatures.Verify(resolvedCaller.apiKey, auth.SignedRequest{
+			Method:     context.Request.Method,
+			RequestURI: context.Request.URL.RequestURI(),
+			Body:       body,
+			Timestamp:  context.GetHeader("X-Signature-Timestamp"),
+			Nonce:      context.GetHeader("X-Signature-Nonce"),
+			Signature:  context.GetHeader("X-Signature"),
+		})
+		if err != nil {
+			handlers.loggerFor(context).Warnf("Rejected signed request of tenant %s: %v", resolvedCaller.tenant.ID, err)
+			handlers.writeErrorResponse(context, http.StatusUnauthorized, "unauthorized", err.Error())
+			context.Abort()
+			return
+		}
+		context.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHandlers_SignedRequests(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{{ID: "acme", APIKeys: []string{"partner-key", "plain-key"}, RateLimitRequests: 100, RateLimitBurst: 10}}
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+		Signatures: auth.NewSignatureVerifier(config.RequestSignatureConfig{
+			Secrets:   map[string]string{"partner-key": "shared-secret"},
+			Tolerance: 5 * time.Minute,
+		}),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	request := func(apiKey, nonce, secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/rates/EUR?symbols=USD", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		if nonce != "" {
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(timestamp + "\n" + nonce + "\nGET\n/api/v1/rates/EUR?symbols=USD\n"))
+			req.Header.Set("X-Signature-Timestamp", timestamp)
+			req.Header.Set("X-Signature-Nonce", nonce)
+			req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request("partner-key", "nonce-1", "shared-secret"); w.Code != http.StatusOK {
+		t.Fatalf("signed request status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if w := request("partner-key", "nonce-1", "shared-secret"); w.Code != http.StatusUnauthorized {
+		t.Errorf("replayed request status = %v, want %v", w.Code, http.StatusUnauthorized)
+	}
+	if w := request("partner-key", "nonce-2", "guess"); w.Code != http.StatusUnauthorized {
+		t.Errorf("badly signed request status = %v, want %v", w.Code, http.StatusUnauthorized)
+	}
+	if w := request("partner-key", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request status = %v, want %v", w.Code, http.StatusUnauthorized)
+	}
+
+	// Keys without a signing secret need no signature
+	if w := request("plain-key", "", ""); w.Code != http.StatusOK {
+		t.Errorf("unsigned request of another key status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandlers_SignedRequestsPreflight(t *testing.T) {
+	router := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()}).SetupRoutes(RouterOptions{})
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/rates", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Headers", "x-api-key, x-signature, x-signature-timestamp, x-signature-nonce")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	allowed := w.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"X-Signature", "X-Signature-Timestamp", "X-Signature-Nonce"} {
+		if !strings.Contains(allowed, header+",") {
+			t.Errorf("Access-Control-Allow-Headers = %q, want %s for browser clients signing requests", allowed, header)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/stream"
+)
+
+// defaultStreamHeartbeat is used when no heartbeat interval is configured
+const defaultStreamHeartbeat = 15 * time.Second
+
+// StreamRates opens a server-sent event stream of rate updates for the pairs in ?pairs=.
+// The first "connected" event carries the connection ID used to change the subscribed
+// pairs; each "rate" event carries one pair's new rate. With ?encoding=delta, each refresh
+// is sent as one "delta" event of the changed pairs instead, with periodic "snapshot"
+// events of every subscribed pair.
+func (handlers *Handlers) StreamRates(context *gin.Context) {
+	if handlers.stream == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "streaming unavailable", "not configured")
+		return
+	}
+
+	var query streamQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	pairs := streamPairs(query.Pairs)
+	if !handlers.pairsAllowed(context, pairs) {
+		return
+	}
+	connection, connectError := handlers.stream.Connect(streamOwner(context), pairs)
+	if connectError != nil {
+		handlers.writeStreamError(context, connectError)
+		return
+	}
+	defer handlers.stream.Disconnect(connection)
+
+	// Streams outlive the server's write timeout
+	http.NewResponseController(context.Writer).SetWriteDeadline(time.Time{})
+
+	context.Header("Content-Type", "text/event-stream")
+	context.Header("Cache-Control", "no-cache")
+	context.Header("X-Accel-Buffering", "no")
+	context.Status(http.StatusOK)
+
+	subscriptions, _ := handlers.stream.Subscriptions(connection.Owner, connection.ID)
+	context.SSEvent("connected", models.StreamSubscriptions{ConnectionID: connection.ID, Subscriptions: subscriptions})
+	context.Writer.Flush()
+
+	heartbeatInterval := handlers.streamHeartbeat
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = defaultStreamHeartbeat
+	}
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	var deltas *stream.DeltaEncoder
+	if query.Encoding == "delta" {
+		deltas = stream.NewDeltaEncoder(handlers.streamSnapshotEvery)
+	}
+
+	for {
+		select {
+		case <-context.Request.Context().Done():
+			return
+		case <-connection.Ready:
+			updates, open := connection.Take()
+			if !open {
+				if dropped := connection.Dropped(); dropped > 0 {
+					handlers.loggerFor(context).Infof("Stream %s closed after dropping %d updates", connection.ID, dropped)
+				}
+				return
+			}
+			if deltas == nil {
+				for _, update := range updates {
+					context.SSEvent("rate", update)
+				}
+			} else if event, message, encoded := deltas.Encode(updates, connection.Dropped(), connection.ResyncRequested(), func() []models.PairRateResponse {
+				return handlers.stream.Snapshot(connection)
+			}); encoded {
+				context.SSEvent(event, message)
+			}
+		case <-heartbeat.C:
+			context.Writer.WriteString(": keepalive\n\n")
+		}
+		context.Writer.Flush()
+	}
+}
+
+// GetStreamSubscriptions lists the pairs an open stream is subscribed to
+func (handlers *Handlers) GetStreamSubscriptions(context *gin.Context) {
+	handlers.updateStreamSubscriptions(context, func(owner, id string, _ []string) ([]string, error) {
+		return handlers.stream.Subscriptions(owner, id)
+	})
+}
+
+// AddStreamSubscriptions subscribes an open stream to the pairs in ?pairs=
+func (handlers *Handlers) AddStreamSubscriptions(context *gin.Context) {
+	handlers.updateStreamSubscriptions(context, func(owner, id string, pairs []string) ([]string, error) {
+		return handlers.stream.Subscribe(owner, id, pairs)
+	})
+}
+
+// RemoveStreamSubscriptions unsubscribes an open stream from the pairs in ?pairs=
+func (handlers *Handlers) RemoveStreamSubscriptions(context *gin.Context) {
+	handlers.updateStreamSubscriptions(context, func(owner, id string, pairs []string) ([]string, error) {
+		return handlers.stream.Unsubscribe(owner, id, pairs)
+	})
+}
+
+// ResyncStream asks for a snapshot of every subscribed pair on an open delta-encoded
+// stream, for clients that noticed a gap in the sequence numbers
+func (handlers *Handlers) ResyncStream(context *gin.Context) {
+	handlers.updateStreamSubscriptions(context, func(owner, id string, _ []string) ([]string, error) {
+		return handlers.stream.Resync(owner, id)
+	})
+}
+
+// updateStreamSubscriptions applies a subscription change and renders the resulting pairs
+func (handlers *Handlers) updateStreamSubscriptions(context *gin.Context, update func(owner, id string, pairs []string) ([]string, error)) {
+	if handlers.stream == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "streaming unavailable", "not configured")
+		return
+	}
+
+	var query streamQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	pairs := streamPairs(query.Pairs)
+	if !handlers.pairsAllowed(context, pairs) {
+		return
+	}
+
+	connectionID := context.Param("id")
+	subscriptions, updateError := update(streamOwner(context), connectionID, pairs)
+	if updateError != nil {
+		handlers.writeStreamError(context, updateError)
+		return
+	}
+
+	handlers.render(context, http.StatusOK, models.StreamSubscriptions{ConnectionID: connectionID, Subscriptions: subscriptions})
+}
+
+// pairsAllowed rejects pairs with currencies the tenant may not use, writing the error
+func (handlers *Handlers) pairsAllowed(context *gin.Context, pairs []string) bool {
+	if handlers.ratesService == nil {
+		return true
+	}
+
+	ratesService := handlers.ratesServiceFor(context)
+	for _, pair := range pairs {
+		from, to, _ := strings.Cut(strings.ToUpper(pair), "/")
+		for _, code := range []string{from, to} {
+			if code != "" && !ratesService.IsCurrencyAllowed(code) {
+				handlers.writeErrorResponse(context, http.StatusBadRequest, "invalid request", "currency not allowed: "+code)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writeStreamError maps stream hub errors to HTTP responses
+func (handlers *Handlers) writeStreamError(context *gin.Context, err error) {
+	switch {
+	case errors.Is(err, stream.ErrConnectionNotFound):
+		handlers.writeErrorResponse(context, http.StatusNotFound, "stream not found", err.Error())
+	case errors.Is(err, stream.ErrTooManySubscriptions):
+		handlers.writeErrorResponse(context, http.StatusConflict, "subscription limit reached", err.Error())
+	default:
+		handlers.writeErrorResponse(context, http.StatusBadRequest, "invalid request", err.Error())
+	}
+}
+
+// streamPairs splits the comma-separated pairs of the ?pairs= parameter
+func streamPairs(value string) []string {
+	pairs := []string{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair != "" {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// streamOwner returns the tenant owning streams opened by the request ("" without tenants)
+func streamOwner(context *gin.Context) string {
+	if value, exists := context.Get(tenantContextKey); exists {
+		return value.(*config.Tenant).ID
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/stream"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// readEvent reads the next server-sent event, skipping keep-alive comments
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream error = %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "" && event != "":
+			return event, data
+		}
+	}
+}
+
+func TestHandlers_StreamRates(t *testing.T) {
+	logger := testutils.MockLogger()
+	hub := stream.NewHub(2, 64, stream.PolicyCoalesce, logger)
+	hub.RatesCached(models.RatesResponse{Base: "USD", Provider: "erapi", Rates: models.RateTable{"EUR": 0.8, "GBP": 0.5}})
+	handlers := NewHandlers(HandlerConfig{Logger: logger, Stream: hub, StreamHeartbeat: 50 * time.Millisecond})
+	server := httptest.NewServer(handlers.SetupRoutes(RouterOptions{}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/stream?pairs=EUR/USD", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/v1/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		t.Fatalf("stream Content-Type = %v, want text/event-stream", contentType)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	event, data := readEvent(t, reader)
+	var connected models.StreamSubscriptions
+	if event != "connected" || json.Unmarshal([]byte(data), &connected) != nil || connected.ConnectionID == "" {
+		t.Fatalf("first event = %s %s, want connected with a connection ID", event, data)
+	}
+
+	event, data = readEvent(t, reader)
+	var update models.PairRateResponse
+	if event != "rate" || json.Unmarshal([]byte(data), &update) != nil || update.Pair != "EUR/USD" || update.Rate != 1.25 {
+		t.Fatalf("second event = %s %s, want the EUR/USD rate", event, data)
+	}
+
+	// Add a pair mid-connection
+	subscriptionsURL := server.URL + "/api/v1/stream/" + connected.ConnectionID + "/subscriptions"
+	addResp, err := http.Post(subscriptionsURL+"?pairs=GBP/USD", "", nil)
+	if err != nil {
+		t.Fatalf("POST subscriptions error = %v", err)
+	}
+	var subscriptions models.StreamSubscriptions
+	json.NewDecoder(addResp.Body).Decode(&subscriptions)
+	addResp.Body.Close()
+	if addResp.StatusCode != http.StatusOK || len(subscriptions.Subscriptions) != 2 {
+		t.Fatalf("POST subscriptions = %v %+v, want 2 subscriptions", addResp.StatusCode, subscriptions)
+	}
+
+	event, data = readEvent(t, reader)
+	if event != "rate" || json.Unmarshal([]byte(data), &update) != nil || update.Pair != "GBP/USD" || update.Rate != 2 {
+		t.Fatalf("event after subscribe = %s %s, want the GBP/USD rate", event, data)
+	}
+
+	// The cap applies to the connection
+	capResp, err := http.Post(subscriptionsURL+"?pairs=JPY/USD", "", nil)
+	if err != nil {
+		t.Fatalf("POST subscriptions error = %v", err)
+	}
+	capResp.Body.Close()
+	if capResp.StatusCode != http.StatusConflict {
+		t.Errorf("POST over the cap status = %v, want %v", capResp.StatusCode, http.StatusConflict)
+	}
+
+	// Remove a pair
+	removeReq, _ := http.NewRequest("DELETE", subscriptionsURL+"?pairs=EUR/USD", nil)
+	removeResp, err := http.DefaultClient.Do(removeReq)
+	if err != nil {
+		t.Fatalf("DELETE subscriptions error = %v", err)
+	}
+	json.NewDecoder(removeResp.Body).Decode(&subscriptions)
+	removeResp.Body.Close()
+	if len(subscriptions.Subscriptions) != 1 || subscriptions.Subscriptions[0] != "GBP/USD" {
+		t.Errorf("DELETE subscriptions = %+v, want GBP/USD only", subscriptions)
+	}
+}
+
+func TestHandlers_StreamSubscriptions_UnknownConnection(t *testing.T) {
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{Logger: logger, Stream: stream.NewHub(5, 64, stream.PolicyCoalesce, logger)})
+
+	req := httptest.NewRequest("POST", "/api/v1/stream/unknown/subscriptions?pairs=EUR/USD", nil)
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("POST subscriptions for an unknown stream status = %v, want %v", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandlers_StreamRates_Delta(t *testing.T) {
+	logger := testutils.MockLogger()
+	hub := stream.NewHub(5, 64, stream.PolicyCoalesce, logger)
+	hub.RatesCached(models.RatesResponse{Base: "USD", Provider: "erapi", Rates: models.RateTable{"EUR": 0.8, "GBP": 0.5}})
+	handlers := NewHandlers(HandlerConfig{Logger: logger, Stream: hub, StreamHeartbeat: 50 * time.Millisecond})
+	server := httptest.NewServer(handlers.SetupRoutes(RouterOptions{}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/stream?pairs=EUR/USD,GBP/USD&encoding=delta", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/v1/stream error = %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	event, data := readEvent(t, reader)
+	var connected models.StreamSubscriptions
+	if event != "connected" || json.Unmarshal([]byte(data), &connected) != nil {
+		t.Fatalf("first event = %s %s, want connected", event, data)
+	}
+
+	// The first message is a snapshot of every subscribed pair
+	var message models.StreamDelta
+	event, data = readEvent(t, reader)
+	if event != stream.EventSnapshot || json.Unmarshal([]byte(data), &message) != nil || message.Sequence != 1 || len(message.Rates) != 2 {
+		t.Fatalf("second event = %s %s, want a snapshot of both pairs", event, data)
+	}
+
+	// A refresh sends only the changed pair
+	hub.RatesCached(models.RatesResponse{Base: "USD", Provider: "erapi", Rates: models.RateTable{"EUR": 0.8, "GBP": 0.4}})
+	event, data = readEvent(t, reader)
+	message = models.StreamDelta{}
+	if event != stream.EventDelta || json.Unmarshal([]byte(data), &message) != nil || message.Sequence != 2 || len(message.Rates) != 1 || message.Rates["GBP/USD"] != 2.5 {
+		t.Fatalf("event after a refresh = %s %s, want a delta of GBP/USD", event, data)
+	}
+
+	// A resync sends a new snapshot
+	resyncResp, err := http.Post(server.URL+"/api/v1/stream/"+connected.ConnectionID+"/resync", "", nil)
+	if err != nil {
+		t.Fatalf("POST resync error = %v", err)
+	}
+	resyncResp.Body.Close()
+	if resyncResp.StatusCode != http.StatusOK {
+		t.Fatalf("POST resync status = %v, want %v", resyncResp.StatusCode, http.StatusOK)
+	}
+	event, data = readEvent(t, reader)
+	message = models.StreamDelta{}
+	if event != stream.EventSnapshot || json.Unmarshal([]byte(data), &message) != nil || message.Sequence != 3 || len(message.Rates) != 2 {
+		t.Fatalf("event after a resync = %s %s, want a snapshot", event, data)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTimeSeriesPoints caps how many buckets one timeseries request may span
+const maxTimeSeriesPoints = 2000
+
+// defaultTimeSeriesRange is the span returned when from is omitted
+const defaultTimeSeriesRange = 30 * 24 * time.Hour
+
+// timeSeriesIntervals maps the supported interval parameters to bucket sizes
+var timeSeriesIntervals = map[string]time.Duration{
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// timeSeriesQuery is a validated timeseries request
+type timeSeriesQuery struct {
+	Base       string
+	Symbol     string
+	Interval   string
+	From       time.Time
+	To         time.Time
+	Convention string // Calendar convention, empty for the configured default
+}
+
+// GetTimeSeries returns downsampled OHLC points for a currency pair from stored history
+func (handlers *Handlers) GetTimeSeries(context *gin.Context) {
+	if handlers.store == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "history unavailable", "persistence is not configured")
+		return
+	}
+
+	query, queryError := parseTimeSeriesQuery(context, time.Now())
+	if queryError != nil {
+		handlers.writeValidationError(context, queryError)
+		return
+	}
+	if handlers.ratesService != nil {
+		ratesService := handlers.ratesServiceFor(context)
+		for _, code := range []string{query.Base, query.Symbol} {
+			if !ratesService.IsCurrencyAllowed(code) {
+				handlers.writeErrorResponse(context, http.StatusBadRequest, "invalid request", "currency not allowed: "+code)
+				return
+			}
+		}
+	}
+
+	points, fetchError := handlers.store.TimeSeries(context.Request.Context(), query.Base, query.Symbol, query.From, query.To, timeSeriesIntervals[query.Interval])
+	if fetchError != nil {
+		handlers.loggerFor(context).Errorf("Timeseries query failed: %v", fetchError)
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "history unavailable", "failed to read rate history")
+		return
+	}
+
+	convention := handlers.conventionFor(calendarParameter{Convention: query.Convention})
+	handlers.render(context, http.StatusOK, models.TimeSeriesResponse{
+		Base:     query.Base,
+		Symbol:   query.Symbol,
+		Interval: query.Interval,
+		From:     query.From,
+		To:       query.To,
+		Points:   handlers.applyCalendar(points, convention),
+		Calendar: &models.CalendarConvention{Convention: convention},
+	})
+}
+
+// parseTimeSeriesQuery validates the timeseries parameters. from and to accept RFC 3339
+// times or YYYY-MM-DD dates, where a to date includes the whole day; the range is
+// aligned to whole buckets.
+func parseTimeSeriesQuery(context *gin.Context, now time.Time) (timeSeriesQuery, error) {
+	var parameters timeSeriesParameters
+	if err := bindParameters(context, &parameters); err != nil {
+		return timeSeriesQuery{}, err
+	}
+
+	query := timeSeriesQuery{
+		Base:       strings.ToUpper(parameters.Base),
+		Symbol:     strings.ToUpper(parameters.Symbol),
+		Interval:   parameters.Interval,
+		Convention: parameters.Convention,
+	}
+	interval := timeSeriesIntervals[query.Interval]
+
+	query.To = now.UTC()
+	if parameters.To != "" {
+		var isDate bool
+		query.To, isDate, _ = parseTimeParameter(parameters.To)
+		if isDate {
+			query.To = query.To.AddDate(0, 0, 1)
+		}
+	}
+	query.From = query.To.Add(-defaultTimeSeriesRange)
+	if parameters.From != "" {
+		query.From, _, _ = parseTimeParameter(parameters.From)
+	}
+
+	// Include the partial bucket at each end so the first and last points are complete buckets
+	query.From = query.From.Truncate(interval)
+	if truncated := query.To.Truncate(interval); !truncated.Equal(query.To) {
+		query.To = truncated.Add(interval)
+	}
+	if !query.From.Before(query.To) {
+		return query, fieldErrors{{Field: "from", Message: "must be before to"}}
+	}
+	if buckets := query.To.Sub(query.From) / interval; buckets > maxTimeSeriesPoints {
+		return query, fieldErrors{{Field: "from", Message: fmt.Sprintf("starts a range of %d %s buckets; the maximum is %d", buckets, query.Interval, maxTimeSeriesPoints)}}
+	}
+	return query, nil
+}
+
+// parseTimeParameter parses an RFC 3339 time or a YYYY-MM-DD date (midnight UTC),
+// reporting which form was given
+func parseTimeParameter(value string) (time.Time, bool, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), false, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not an RFC 3339 time or YYYY-MM-DD date", value)
+}
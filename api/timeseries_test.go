@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseTimeSeriesQuery(t *testing.T) {
+	now := time.Date(2024, 3, 10, 14, 25, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		query        string
+		wantError    bool
+		wantInterval string
+		wantFrom     time.Time
+		wantTo       time.Time
+	}{
+		{
+			name:         "defaults to the last 30 days of daily points",
+			query:        "symbol=eur",
+			wantInterval: "1d",
+			wantFrom:     time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC),
+			wantTo:       time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "dates include the whole to day",
+			query:        "symbol=EUR&from=2024-03-01&to=2024-03-02&interval=1h",
+			wantInterval: "1h",
+			wantFrom:     time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:       time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "times are aligned to whole buckets",
+			query:        "symbol=EUR&from=2024-03-01T10:30:00Z&to=2024-03-01T12:10:00%2B01:00&interval=1h",
+			wantInterval: "1h",
+			wantFrom:     time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			wantTo:       time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{name: "missing symbol", query: "interval=1d", wantError: true},
+		{name: "unsupported interval", query: "symbol=EUR&interval=5m", wantError: true},
+		{name: "invalid from", query: "symbol=EUR&from=yesterday", wantError: true},
+		{name: "reversed range", query: "symbol=EUR&from=2024-03-05&to=2024-03-01", wantError: true},
+		{name: "too many points", query: "symbol=EUR&from=2023-01-01&to=2024-03-01&interval=1h", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/api/v1/rates/usd/timeseries?"+tt.query, nil)
+			c.Params = gin.Params{{Key: "base", Value: "usd"}}
+
+			query, err := parseTimeSeriesQuery(c, now)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("parseTimeSeriesQuery() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			if query.Base != "USD" || query.Symbol != "EUR" || query.Interval != tt.wantInterval {
+				t.Errorf("parseTimeSeriesQuery() = %+v, want USD/EUR at %s", query, tt.wantInterval)
+			}
+			if !query.From.Equal(tt.wantFrom) || !query.To.Equal(tt.wantTo) {
+				t.Errorf("parseTimeSeriesQuery() range = %v - %v, want %v - %v", query.From, query.To, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestHandlers_GetTimeSeries_WithoutStore(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+
+	req := httptest.NewRequest("GET", "/api/v1/rates/USD/timeseries?symbol=EUR", nil)
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET timeseries without persistence status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// usageQuery holds the parameters of GET /admin/v1/usage
+type usageQuery struct {
+	From string `form:"from" binding:"omitempty,timestamp"`
+	To   string `form:"to" binding:"omitempty,timestamp"`
+}
+
+// usageMiddleware records every API request in the usage of its key and endpoint.
+// Requests without a resolved tenant are counted without a key.
+func (handlers *Handlers) usageMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		start := time.Now()
+		context.Next()
+
+		path := context.FullPath()
+		if path == "" {
+			return
+		}
+		tenantID, keyID := "", ""
+		if value, exists := context.Get(tenantContextKey); exists {
+			tenantID = value.(*config.Tenant).ID
+			keyID = context.GetString(keyIDContextKey)
+		}
+		handlers.usage.Record(tenantID, keyID, context.Request.Method+" "+path, context.Writer.Status(),
+			int64(context.Writer.Size()), time.Since(start), start)
+	}
+}
+
+// GetUsage reports API usage per key and endpoint. from and to accept RFC 3339 times
+// or YYYY-MM-DD dates, where a to date includes the whole day; the range defaults to
+// the current calendar month.
+func (handlers *Handlers) GetUsage(context *gin.Context) {
+	if handlers.usage == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "usage unavailable", "usage tracking is disabled")
+		return
+	}
+
+	var query usageQuery
+	if bindError := bindParameters(context, &query); bindError != nil {
+		handlers.writeValidationError(context, bindError)
+		return
+	}
+
+	now := time.Now().UTC()
+	to := now
+	if query.To != "" {
+		var isDate bool
+		to, isDate, _ = parseTimeParameter(query.To)
+		if isDate {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if query.From != "" {
+		from, _, _ = parseTimeParameter(query.From)
+	}
+	if !from.Before(to) {
+		handlers.writeValidationError(context, fieldErrors{{Field: "from", Message: "must be before to"}})
+		return
+	}
+
+	report, reportError := handlers.usage.Report(context.Request.Context(), from, to)
+	if reportError != nil {
+		handlers.loggerFor(context).Errorf("Usage report failed: %v", reportError)
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "usage unavailable", "failed to read API usage")
+		return
+	}
+	handlers.render(context, http.StatusOK, report)
+}
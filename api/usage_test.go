@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+	"github.com/dalfonso89/currency-exchange-service/usage"
+)
+
+func TestHandlers_GetUsage(t *testing.T) {
+	mockExchangeRateServer := testsupport.NewProviderServer()
+	defer mockExchangeRateServer.Close()
+	mockJSONPlaceholderServer := testutils.NewMockJSONPlaceholderServer()
+	defer mockJSONPlaceholderServer.Close()
+
+	cfg := testutils.MockConfigWithMocks(mockExchangeRateServer.URL(), mockJSONPlaceholderServer.URL())
+	cfg.Tenants = []config.Tenant{{ID: "acme", APIKeys: []string{"acme-key"}, RateLimitRequests: 100, RateLimitBurst: 10}}
+	logger := testutils.MockLogger()
+	handlers := NewHandlers(HandlerConfig{
+		Logger:       logger,
+		RatesService: service.NewRatesService(cfg, logger),
+		Tenants:      tenant.NewRegistry(cfg.Tenants),
+		AdminAPIKey:  "admin-key",
+		Usage:        usage.NewTracker(nil, logger),
+	})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	for _, apiKey := range []string{"acme-key", "acme-key", ""} {
+		req := httptest.NewRequest("GET", "/api/v1/rates/EUR", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest("GET", "/admin/v1/usage", nil)
+	req.Header.Set("X-Admin-Key", "admin-key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /admin/v1/usage status = %v, want %v: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var report models.UsageReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("usage report unmarshal error = %v", err)
+	}
+	if len(report.Entries) != 2 {
+		t.Fatalf("usage entries = %+v, want the anonymous and the acme usage", report.Entries)
+	}
+	anonymous, acme := report.Entries[0], report.Entries[1]
+	if anonymous.Tenant != "" || anonymous.Requests != 1 || anonymous.Errors != 1 {
+		t.Errorf("anonymous usage = %+v, want one rejected request", anonymous)
+	}
+	if acme.Tenant != "acme" || acme.KeyID != usage.KeyID("acme-key") || acme.Endpoint != "GET /api/v1/rates/:base" || acme.Requests != 2 || acme.Bytes == 0 {
+		t.Errorf("acme usage = %+v, want two requests to GET /api/v1/rates/:base", acme)
+	}
+
+	req = httptest.NewRequest("GET", "/admin/v1/usage?from=2024-03-05&to=2024-03-01", nil)
+	req.Header.Set("X-Admin-Key", "admin-key")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET /admin/v1/usage with a reversed range status = %v, want %v", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandlers_GetUsage_Disabled(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger(), AdminAPIKey: "admin-key"})
+
+	req := httptest.NewRequest("GET", "/admin/v1/usage", nil)
+	req.Header.Set("X-Admin-Key", "admin-key")
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /admin/v1/usage without tracking status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// apiVersionContextKey is the Gin context key holding the API version of the route
+const apiVersionContextKey = "api_version"
+
+// Problem details media types of API v2 error responses
+const (
+	mimeProblemJSON = "application/problem+json"
+	mimeProblemXML  = "application/problem+xml"
+)
+
+// Lifecycle holds the announced deprecation and sunset times of an API version;
+// zero values are not announced
+type Lifecycle struct {
+	Deprecation time.Time
+	Sunset      time.Time
+}
+
+// ParseLifecycle validates configured deprecation and sunset dates (YYYY-MM-DD, empty
+// when not announced)
+func ParseLifecycle(deprecation, sunset string) (Lifecycle, error) {
+	var lifecycle Lifecycle
+	var err error
+	if deprecation != "" {
+		if lifecycle.Deprecation, err = time.Parse("2006-01-02", deprecation); err != nil {
+			return Lifecycle{}, fmt.Errorf("invalid deprecation date %q: use YYYY-MM-DD", deprecation)
+		}
+	}
+	if sunset != "" {
+		if lifecycle.Sunset, err = time.Parse("2006-01-02", sunset); err != nil {
+			return Lifecycle{}, fmt.Errorf("invalid sunset date %q: use YYYY-MM-DD", sunset)
+		}
+	}
+	if !lifecycle.Deprecation.IsZero() && !lifecycle.Sunset.IsZero() && lifecycle.Sunset.Before(lifecycle.Deprecation) {
+		return Lifecycle{}, fmt.Errorf("sunset date %s is before deprecation date %s", sunset, deprecation)
+	}
+	return lifecycle, nil
+}
+
+// versionMiddleware tags requests with the API version of their route group. Version 1
+// responses announce its deprecation (RFC 9745) and sunset (RFC 8594), pointing to the
+// same resource in version 2.
+func (handlers *Handlers) versionMiddleware(version int) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Set(apiVersionContextKey, version)
+
+		if version == 1 && (!handlers.v1Lifecycle.Deprecation.IsZero() || !handlers.v1Lifecycle.Sunset.IsZero()) {
+			if !handlers.v1Lifecycle.Deprecation.IsZero() {
+				context.Header("Deprecation", "@"+strconv.FormatInt(handlers.v1Lifecycle.Deprecation.Unix(), 10))
+			}
+			if !handlers.v1Lifecycle.Sunset.IsZero() {
+				context.Header("Sunset", handlers.v1Lifecycle.Sunset.UTC().Format(http.TimeFormat))
+			}
+			context.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successorPath(context.Request.URL.Path)))
+		}
+		context.Next()
+	}
+}
+
+// V1Removed answers requests to API v1 once it has been disabled
+func (handlers *Handlers) V1Removed(context *gin.Context) {
+	context.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successorPath(context.Request.URL.Path)))
+	handlers.writeErrorResponse(context, http.StatusGone, "api version removed", "API v1 has been removed; use /api/v2")
+}
+
+// apiVersion returns the API version of the request's route, 1 outside versioned groups
+func apiVersion(context *gin.Context) int {
+	if version, exists := context.Get(apiVersionContextKey); exists {
+		return version.(int)
+	}
+	return 1
+}
+
+// successorPath maps an API v1 path to the same resource in API v2
+func successorPath(path string) string {
+	return "/api/v2" + strings.TrimPrefix(path, "/api/v1")
+}
+
+// versionLinks points links to the API version of the request
+func versionLinks(context *gin.Context, links models.Links) models.Links {
+	if apiVersion(context) == 1 {
+		return links
+	}
+
+	versioned := make(models.Links, len(links))
+	for relation, link := range links {
+		if strings.HasPrefix(link.Href, "/api/v1/") {
+			link.Href = successorPath(link.Href)
+		}
+		versioned[relation] = link
+	}
+	return versioned
+}
+
+// envelope wraps data in the API v2 response envelope
+func envelope(context *gin.Context, data interface{}) models.Envelope {
+	return models.Envelope{
+		Data: data,
+		Meta: models.EnvelopeMeta{
+			APIVersion: strconv.Itoa(apiVersion(context)),
+			RequestID:  context.GetString("request_id"),
+			Timestamp:  time.Now().UTC(),
+		},
+	}
+}
+
+// renderProblem writes an API v2 error as problem details in the negotiated encoding
+func (handlers *Handlers) renderProblem(context *gin.Context, statusCode int, errorMessage, errorDetails string, fields []models.FieldError) {
+	problem := models.Problem{
+		Type:      "/problems/" + strings.ReplaceAll(errorMessage, " ", "-"),
+		Title:     errorMessage,
+		Status:    statusCode,
+		Detail:    errorDetails,
+		Instance:  context.Request.URL.Path,
+		RequestID: context.GetString("request_id"),
+		Fields:    fields,
+	}
+
+	switch context.NegotiateFormat(offeredFormats...) {
+	case binding.MIMEXML, binding.MIMEXML2:
+		context.Header("Content-Type", mimeProblemXML+"; charset=utf-8")
+		context.Render(statusCode, render.XML{Data: problem})
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		context.Render(statusCode, render.MsgPack{Data: problem})
+	default:
+		context.Header("Content-Type", mimeProblemJSON+"; charset=utf-8")
+		context.Render(statusCode, render.JSON{Data: problem})
+	}
+}
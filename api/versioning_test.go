@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestParseLifecycle(t *testing.T) {
+	tests := []struct {
+		name        string
+		deprecation string
+		sunset      string
+		wantErr     bool
+	}{
+		{name: "not announced"},
+		{name: "deprecated with sunset", deprecation: "2025-01-01", sunset: "2025-07-01"},
+		{name: "sunset only", sunset: "2025-07-01"},
+		{name: "malformed date", deprecation: "01/01/2025", wantErr: true},
+		{name: "sunset before deprecation", deprecation: "2025-07-01", sunset: "2025-01-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLifecycle(tt.deprecation, tt.sunset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLifecycle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandlers_V2Envelope(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+
+	req := httptest.NewRequest("GET", "/api/v2/currencies", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v2/currencies status = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Data struct {
+			Count int `json:"count"`
+		} `json:"data"`
+		Meta models.EnvelopeMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode envelope: %v", err)
+	}
+	if response.Data.Count == 0 || response.Meta.APIVersion != "2" || response.Meta.RequestID != "req-123" {
+		t.Errorf("GET /api/v2/currencies = %+v, want enveloped currencies for req-123", response)
+	}
+}
+
+func TestHandlers_V2ProblemDetails(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger()})
+
+	req := httptest.NewRequest("GET", "/api/v2/providers", nil)
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /api/v2/providers status = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, mimeProblemJSON) {
+		t.Errorf("Content-Type = %q, want %q", contentType, mimeProblemJSON)
+	}
+
+	var problem models.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	if problem.Status != http.StatusServiceUnavailable || problem.Type != "/problems/rates-service-unavailable" || problem.Instance != "/api/v2/providers" {
+		t.Errorf("problem = %+v", problem)
+	}
+}
+
+func TestHandlers_V1Lifecycle(t *testing.T) {
+	lifecycle := Lifecycle{
+		Deprecation: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger(), V1Lifecycle: lifecycle})
+
+	req := httptest.NewRequest("GET", "/api/v1/currencies", nil)
+	w := httptest.NewRecorder()
+	handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /api/v1/currencies status = %v, want %v", w.Code, http.StatusOK)
+	}
+	wantHeaders := map[string]string{
+		"Deprecation": "@1735689600",
+		"Sunset":      "Tue, 01 Jul 2025 00:00:00 GMT",
+		"Link":        `</api/v2/currencies>; rel="successor-version"`,
+	}
+	for header, want := range wantHeaders {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s header = %q, want %q", header, got, want)
+		}
+	}
+	if strings.Contains(w.Body.String(), `"meta"`) {
+		t.Error("API v1 responses should not be enveloped")
+	}
+}
+
+func TestHandlers_V1Disabled(t *testing.T) {
+	handlers := NewHandlers(HandlerConfig{Logger: testutils.MockLogger(), DisableV1: true})
+	router := handlers.SetupRoutes(RouterOptions{})
+
+	req := httptest.NewRequest("GET", "/api/v1/rates/USD", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Errorf("GET /api/v1/rates/USD status = %v, want %v", w.Code, http.StatusGone)
+	}
+	if link := w.Header().Get("Link"); link != `</api/v2/rates/USD>; rel="successor-version"` {
+		t.Errorf("Link header = %q", link)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v2/currencies", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /api/v2/currencies status = %v, want %v", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// maxWebhookBodyBytes bounds the size of a pushed rates payload
+const maxWebhookBodyBytes = 1 << 20
+
+// webhookRatesPayload is the body a push source delivers to the webhook receiver
+type webhookRatesPayload struct {
+	Base        string           `json:"base"`
+	Rates       models.RateTable `json:"rates"`
+	Bid         models.RateTable `json:"bid"`
+	Ask         models.RateTable `json:"ask"`
+	PublishedAt int64            `json:"published_at"`
+}
+
+// ReceiveRates accepts rates pushed by an upstream source. The request must carry the
+// Unix time in X-Webhook-Timestamp and a hex HMAC-SHA256 of "<timestamp>\n<body>",
+// keyed with the source's shared secret, in X-Webhook-Signature.
+func (handlers *Handlers) ReceiveRates(context *gin.Context) {
+	if handlers.ratesService == nil {
+		handlers.writeErrorResponse(context, http.StatusServiceUnavailable, "rates service unavailable", "not configured")
+		return
+	}
+
+	source := context.Param("provider")
+	handlers.secretsMutex.RLock()
+	secret, configured := handlers.webhookSecrets[source]
+	handlers.secretsMutex.RUnlock()
+	if !configured {
+		handlers.writeErrorResponse(context, http.StatusNotFound, "unknown source", "no webhook configured for "+source)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(context.Writer, context.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		handlers.writeErrorResponse(context, http.StatusRequestEntityTooLarge, "invalid request", "payload too large")
+		return
+	}
+
+	timestamp := context.GetHeader("X-Webhook-Timestamp")
+	if !handlers.validWebhookTimestamp(timestamp, time.Now()) {
+		handlers.writeErrorResponse(context, http.StatusUnauthorized, "unauthorized", "missing or expired webhook timestamp")
+		return
+	}
+	if !validWebhookSignature(secret, timestamp, body, context.GetHeader("X-Webhook-Signature")) {
+		handlers.loggerFor(context).Warnf("Rejected webhook from %s: invalid signature", source)
+		handlers.writeErrorResponse(context, http.StatusUnauthorized, "unauthorized", "invalid webhook signature")
+		return
+	}
+
+	var payload webhookRatesPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		handlers.writeErrorResponse(context, http.StatusBadRequest, "invalid request", "payload must be a JSON rates object")
+		return
+	}
+
+	exchangeRates, err := handlers.ratesService.PushRates(context.Request.Context(), source, models.RatesResponse{
+		Base:        payload.Base,
+		Rates:       payload.Rates,
+		Bid:         payload.Bid,
+		Ask:         payload.Ask,
+		PublishedAt: payload.PublishedAt,
+	})
+	if err != nil {
+		handlers.handleServiceError(context, err)
+		return
+	}
+
+	handlers.render(context, http.StatusAccepted, exchangeRates)
+}
+
+// validWebhookTimestamp reports whether the signed timestamp is within the tolerance of now
+func (handlers *Handlers) validWebhookTimestamp(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	drift := now.Sub(time.Unix(seconds, 0))
+	return drift.Abs() <= handlers.webhookTolerance
+}
+
+// validWebhookSignature checks the hex HMAC-SHA256 signature, optionally prefixed "sha256="
+func validWebhookSignature(secret, timestamp string, body []byte, signature string) bool {
+	provided, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || len(provided) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n"))
+	mac.Write(body)
+	return hmac.Equal(provided, mac.Sum(nil))
+}
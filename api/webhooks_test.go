@@ -0,0 +1,68 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func signWebhook(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandlers_ReceiveRates(t *testing.T) {
+	body := `{"base": "USD", "rates": {"EUR": 0.91, "GBP": 0.78}}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name       string
+		source     string
+		timestamp  string
+		signature  string
+		body       string
+		wantStatus int
+	}{
+		{name: "valid push", source: "pricing-engine", timestamp: now, signature: signWebhook("push-secret", now, body), body: body, wantStatus: http.StatusAccepted},
+		{name: "prefixed signature", source: "pricing-engine", timestamp: now, signature: "sha256=" + signWebhook("push-secret", now, body), body: body, wantStatus: http.StatusAccepted},
+		{name: "unknown source", source: "other", timestamp: now, signature: signWebhook("push-secret", now, body), body: body, wantStatus: http.StatusNotFound},
+		{name: "wrong secret", source: "pricing-engine", timestamp: now, signature: signWebhook("wrong", now, body), body: body, wantStatus: http.StatusUnauthorized},
+		{name: "expired timestamp", source: "pricing-engine", timestamp: stale, signature: signWebhook("push-secret", stale, body), body: body, wantStatus: http.StatusUnauthorized},
+		{name: "invalid payload", source: "pricing-engine", timestamp: now, signature: signWebhook("push-secret", now, `{"base": "USD"}`), body: `{"base": "USD"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutils.MockConfig()
+			logger := testutils.MockLogger()
+			handlers := NewHandlers(HandlerConfig{
+				Logger:           logger,
+				RatesService:     service.NewRatesService(cfg, logger),
+				WebhookSecrets:   map[string]string{"pricing-engine": "push-secret"},
+				WebhookTolerance: 5 * time.Minute,
+			})
+
+			req := httptest.NewRequest("POST", "/webhooks/rates/"+tt.source, strings.NewReader(tt.body))
+			req.Header.Set("X-Webhook-Timestamp", tt.timestamp)
+			req.Header.Set("X-Webhook-Signature", tt.signature)
+			w := httptest.NewRecorder()
+
+			handlers.SetupRoutes(RouterOptions{}).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("POST /webhooks/rates/%s status = %v, want %v (body %s)", tt.source, w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
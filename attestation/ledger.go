@@ -0,0 +1,99 @@
+// Package attestation issues signed attestations of the rates applied to conversions and
+// keeps them, so auditors of regulated tenants can later prove which rate was applied.
+package attestation
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/clock"
+	"github.com/dalfonso89/currency-exchange-service/middleware"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// ErrNotFound reports an attestation ID that was never issued
+var ErrNotFound = errors.New("attestation not found")
+
+// Store keeps issued attestations
+type Store interface {
+	RecordAttestation(ctx context.Context, attestation models.Attestation) error
+	Attestation(ctx context.Context, id string) (models.Attestation, bool, error)
+}
+
+// Ledger signs the claims of conversions and records them before they are served, so a
+// conversion is never answered with an attestation ID that cannot be retrieved
+type Ledger struct {
+	signer      *auth.AttestationSigner
+	store       Store
+	idGenerator middleware.IDGenerator
+	clock       clock.Clock
+}
+
+// NewLedger creates a ledger signing with the signer and recording into the store
+func NewLedger(signer *auth.AttestationSigner, store Store) *Ledger {
+	return &Ledger{signer: signer, store: store, idGenerator: middleware.UUIDv7{}, clock: clock.System}
+}
+
+// KeySet returns the key set verifying the ledger's attestations
+func (ledger *Ledger) KeySet() auth.JSONWebKeySet {
+	return ledger.signer.KeySet()
+}
+
+// Attest signs and records the claims of a tenant's conversion
+func (ledger *Ledger) Attest(ctx context.Context, tenantID string, conversion models.ConversionResponse) (models.Attestation, error) {
+	issuedAt := ledger.clock.Now().UTC()
+	claims := models.AttestationClaims{
+		ID:            ledger.idGenerator.NewID(),
+		Tenant:        tenantID,
+		Pair:          conversion.From + "/" + conversion.To,
+		Amount:        conversion.Amount,
+		MidRate:       conversion.MidRate,
+		Rate:          conversion.Rate,
+		Converted:     conversion.Converted,
+		Provider:      conversion.Provider,
+		RateTimestamp: conversion.Timestamp,
+		IssuedAt:      issuedAt.Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return models.Attestation{}, fmt.Errorf("failed to encode attestation claims: %w", err)
+	}
+
+	attestation := models.Attestation{
+		ID:       claims.ID,
+		Tenant:   tenantID,
+		Claims:   claims,
+		Payload:  base64.RawURLEncoding.EncodeToString(payload),
+		JWS:      ledger.signer.Sign(payload),
+		KeyID:    ledger.signer.KeyID(),
+		IssuedAt: issuedAt,
+	}
+	if err := ledger.store.RecordAttestation(ctx, attestation); err != nil {
+		return models.Attestation{}, err
+	}
+	return attestation, nil
+}
+
+// Get returns the attestation with the ID, its claims decoded from the signed payload
+func (ledger *Ledger) Get(ctx context.Context, id string) (models.Attestation, error) {
+	attestation, found, err := ledger.store.Attestation(ctx, id)
+	if err != nil {
+		return models.Attestation{}, err
+	}
+	if !found {
+		return models.Attestation{}, ErrNotFound
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(attestation.Payload)
+	if err != nil {
+		return models.Attestation{}, fmt.Errorf("stored attestation %s has a malformed payload: %w", id, err)
+	}
+	if err := json.Unmarshal(payload, &attestation.Claims); err != nil {
+		return models.Attestation{}, fmt.Errorf("stored attestation %s has malformed claims: %w", id, err)
+	}
+	return attestation, nil
+}
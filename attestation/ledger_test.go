@@ -0,0 +1,112 @@
+package attestation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/middleware"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// memoryStore keeps attestations in a map
+type memoryStore struct {
+	attestations map[string]models.Attestation
+	err          error
+}
+
+func (store *memoryStore) RecordAttestation(ctx context.Context, attestation models.Attestation) error {
+	if store.err != nil {
+		return store.err
+	}
+	attestation.Claims = models.AttestationClaims{}
+	store.attestations[attestation.ID] = attestation
+	return nil
+}
+
+func (store *memoryStore) Attestation(ctx context.Context, id string) (models.Attestation, bool, error) {
+	attestation, found := store.attestations[id]
+	return attestation, found, nil
+}
+
+type fixedClock struct{ now time.Time }
+
+func (clock fixedClock) Now() time.Time { return clock.now }
+
+// newTestSigner returns a signer of a fresh key and the key's public half
+func newTestSigner(t *testing.T) (*auth.AttestationSigner, ed25519.PublicKey) {
+	t.Helper()
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "attestations.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := auth.NewAttestationSigner(config.AttestationConfig{SigningKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+	return signer, publicKey
+}
+
+func TestLedger_AttestAndGet(t *testing.T) {
+	signer, publicKey := newTestSigner(t)
+	store := &memoryStore{attestations: map[string]models.Attestation{}}
+	ledger := NewLedger(signer, store)
+	ledger.idGenerator = middleware.IDGeneratorFunc(func() string { return "att-1" })
+	ledger.clock = fixedClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	conversion := models.ConversionResponse{
+		From: "USD", To: "EUR", Amount: 100, MidRate: 0.92, Rate: 0.9154, Converted: 91.54,
+		Timestamp: 1709294400, Provider: "erapi",
+	}
+	issued, err := ledger.Attest(context.Background(), "acme", conversion)
+	if err != nil {
+		t.Fatalf("Attest() error = %v", err)
+	}
+	wantClaims := models.AttestationClaims{
+		ID: "att-1", Tenant: "acme", Pair: "USD/EUR", Amount: 100, MidRate: 0.92, Rate: 0.9154,
+		Converted: 91.54, Provider: "erapi", RateTimestamp: 1709294400, IssuedAt: 1709294400,
+	}
+	if issued.ID != "att-1" || issued.Claims != wantClaims || issued.KeyID != signer.KeyID() {
+		t.Errorf("Attest() = %+v", issued)
+	}
+
+	retrieved, err := ledger.Get(context.Background(), "att-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if retrieved != issued {
+		t.Errorf("Get() = %+v, want %+v", retrieved, issued)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(retrieved.Payload)
+	if err := auth.VerifyAttestation(retrieved.JWS, payload, publicKey); err != nil {
+		t.Errorf("VerifyAttestation() of the retrieved attestation error = %v", err)
+	}
+
+	if _, err := ledger.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a missing ID error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestLedger_Attest_StoreError(t *testing.T) {
+	signer, _ := newTestSigner(t)
+	ledger := NewLedger(signer, &memoryStore{err: errors.New("database down")})
+
+	if _, err := ledger.Attest(context.Background(), "acme", models.ConversionResponse{From: "USD", To: "EUR"}); err == nil {
+		t.Error("Attest() should fail when the attestation cannot be recorded")
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// AdminPermission is the right to call a group of admin endpoints
+type AdminPermission string
+
+// Permissions of the admin endpoints
+const (
+	PermissionAdminRead   AdminPermission = "read"   // Provider state, usage, alerts, bans and attestations
+	PermissionAdminCache  AdminPermission = "cache"  // Purging the cache and changing its TTL
+	PermissionAdminToggle AdminPermission = "toggle" // Enabling and disabling providers
+	PermissionAdminBans   AdminPermission = "bans"   // Lifting the bans of clients that failed authentication
+	PermissionAdminConfig AdminPermission = "config" // The configuration dump
+	PermissionAdminAudit  AdminPermission = "audit"  // The audit log of admin calls
+	PermissionAdminDebug  AdminPermission = "debug"  // Captured request and response bodies
+)
+
+// rolePermissions holds the permissions each admin role grants
+var rolePermissions = map[string][]AdminPermission{
+	config.AdminRoleViewer:   {PermissionAdminRead},
+	config.AdminRoleOperator: {PermissionAdminRead, PermissionAdminCache, PermissionAdminToggle, PermissionAdminBans},
+	config.AdminRoleAdmin: {
+		PermissionAdminRead, PermissionAdminCache, PermissionAdminToggle, PermissionAdminBans,
+		PermissionAdminConfig, PermissionAdminAudit, PermissionAdminDebug,
+	},
+}
+
+// AdminPrincipal is the holder of an admin key
+type AdminPrincipal struct {
+	Name string
+	Role string
+}
+
+// Can reports whether the principal's role grants the permission. Unknown roles grant
+// nothing.
+func (principal AdminPrincipal) Can(permission AdminPermission) bool {
+	for _, granted := range rolePermissions[principal.Role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// adminCredential is an accepted admin key and its holder
+type adminCredential struct {
+	principal AdminPrincipal
+	keySum    [sha256.Size]byte
+}
+
+// AdminKeyring authenticates the keys of the admin API
+type AdminKeyring struct {
+	mutex       sync.RWMutex
+	credentials []adminCredential
+}
+
+// NewAdminKeyring creates a keyring of the named admin keys. A legacy ADMIN_API_KEY is
+// accepted as a key of the admin role named "admin".
+func NewAdminKeyring(adminAPIKey string, adminKeys []config.AdminKey) *AdminKeyring {
+	keyring := &AdminKeyring{}
+	keyring.Rotate(adminAPIKey, adminKeys)
+	return keyring
+}
+
+// Rotate replaces the accepted keys with rotated values
+func (keyring *AdminKeyring) Rotate(adminAPIKey string, adminKeys []config.AdminKey) {
+	credentials := []adminCredential{}
+	if adminAPIKey != "" {
+		credentials = append(credentials, adminCredential{
+			principal: AdminPrincipal{Name: "admin", Role: config.AdminRoleAdmin},
+			keySum:    sha256.Sum256([]byte(adminAPIKey)),
+		})
+	}
+	for _, adminKey := range adminKeys {
+		for _, key := range adminKey.Keys {
+			credentials = append(credentials, adminCredential{
+				principal: AdminPrincipal{Name: adminKey.Name, Role: adminKey.Role},
+				keySum:    sha256.Sum256([]byte(key)),
+			})
+		}
+	}
+
+	keyring.mutex.Lock()
+	defer keyring.mutex.Unlock()
+	keyring.credentials = credentials
+}
+
+// Empty reports whether the keyring accepts no key, which disables the admin API
+func (keyring *AdminKeyring) Empty() bool {
+	keyring.mutex.RLock()
+	defer keyring.mutex.RUnlock()
+	return len(keyring.credentials) == 0
+}
+
+// Authenticate returns the holder of the key
+func (keyring *AdminKeyring) Authenticate(key string) (AdminPrincipal, bool) {
+	keyring.mutex.RLock()
+	defer keyring.mutex.RUnlock()
+
+	var principal AdminPrincipal
+	matched := false
+	// Every key is compared, in constant time, so timing reveals nothing about them
+	keySum := sha256.Sum256([]byte(key))
+	for _, credential := range keyring.credentials {
+		if subtle.ConstantTimeCompare(keySum[:], credential.keySum[:]) == 1 {
+			principal, matched = credential.principal, true
+		}
+	}
+	return principal, matched && key != ""
+}
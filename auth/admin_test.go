@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func TestAdminKeyring_Authenticate(t *testing.T) {
+	keyring := NewAdminKeyring("legacy-key", []config.AdminKey{
+		{Name: "oncall", Role: config.AdminRoleOperator, Keys: []string{"new-key", "old-key"}},
+		{Name: "dashboard", Role: config.AdminRoleViewer, Keys: []string{"dashboard-key"}},
+	})
+
+	tests := []struct {
+		key       string
+		want      AdminPrincipal
+		wantFound bool
+	}{
+		{key: "legacy-key", want: AdminPrincipal{Name: "admin", Role: config.AdminRoleAdmin}, wantFound: true},
+		{key: "old-key", want: AdminPrincipal{Name: "oncall", Role: config.AdminRoleOperator}, wantFound: true},
+		{key: "dashboard-key", want: AdminPrincipal{Name: "dashboard", Role: config.AdminRoleViewer}, wantFound: true},
+		{key: "wrong-key"},
+		{key: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			principal, found := keyring.Authenticate(tt.key)
+			if found != tt.wantFound || principal != tt.want {
+				t.Errorf("Authenticate(%q) = %+v, %v, want %+v, %v", tt.key, principal, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestAdminKeyring_Rotate(t *testing.T) {
+	keyring := NewAdminKeyring("", nil)
+	if !keyring.Empty() {
+		t.Fatal("Empty() = false, want true without keys")
+	}
+
+	keyring.Rotate("", []config.AdminKey{{Name: "oncall", Role: config.AdminRoleViewer, Keys: []string{"rotated-key"}}})
+	if keyring.Empty() {
+		t.Error("Empty() = true after rotating in a key")
+	}
+	if _, found := keyring.Authenticate("rotated-key"); !found {
+		t.Error("Authenticate() rejected the rotated key")
+	}
+}
+
+func TestAdminPrincipal_Can(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission AdminPermission
+		want       bool
+	}{
+		{role: config.AdminRoleViewer, permission: PermissionAdminRead, want: true},
+		{role: config.AdminRoleViewer, permission: PermissionAdminCache, want: false},
+		{role: config.AdminRoleOperator, permission: PermissionAdminToggle, want: true},
+		{role: config.AdminRoleOperator, permission: PermissionAdminConfig, want: false},
+		{role: config.AdminRoleAdmin, permission: PermissionAdminAudit, want: true},
+		{role: config.AdminRoleOperator, permission: PermissionAdminDebug, want: false},
+		{role: config.AdminRoleAdmin, permission: PermissionAdminDebug, want: true},
+		{role: "root", permission: PermissionAdminRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+"/"+string(tt.permission), func(t *testing.T) {
+			if got := (AdminPrincipal{Name: "someone", Role: tt.role}).Can(tt.permission); got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// ErrInvalidAttestation reports an attestation JWS that is malformed or does not verify
+var ErrInvalidAttestation = errors.New("invalid attestation")
+
+// attestationHeader is the protected JWS header of attestations
+type attestationHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// AttestationSigner signs attestations as compact JWS with a detached payload (RFC 7515
+// appendix F), with an Ed25519 key whose public half is published as a key set
+type AttestationSigner struct {
+	privateKey ed25519.PrivateKey
+	publicKey  JSONWebKey
+	header     string // Encoded protected header, the same for every attestation
+}
+
+// NewAttestationSigner loads the key of attestations. Unlike response signing keys it is
+// never generated: auditors verify attestations long after the instance issuing them is gone.
+func NewAttestationSigner(configuration config.AttestationConfig) (*AttestationSigner, error) {
+	if configuration.SigningKeyFile == "" {
+		return nil, errors.New("ATTESTATION_SIGNING_KEY_FILE is required when a tenant enables attestations")
+	}
+	privateKey, err := loadEd25519Key(configuration.SigningKeyFile, "attestation signing")
+	if err != nil {
+		return nil, err
+	}
+	return newAttestationSigner(privateKey)
+}
+
+// newAttestationSigner creates a signer of the key
+func newAttestationSigner(privateKey ed25519.PrivateKey) (*AttestationSigner, error) {
+	publicKey, err := ed25519JSONWebKey(privateKey, "attestation signing")
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(attestationHeader{Algorithm: "EdDSA", KeyID: publicKey.KeyID})
+	if err != nil {
+		return nil, err
+	}
+	return &AttestationSigner{
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		header:     base64.RawURLEncoding.EncodeToString(header),
+	}, nil
+}
+
+// KeyID returns the ID of the signing key, named by the kid header of its signatures
+func (signer *AttestationSigner) KeyID() string {
+	return signer.publicKey.KeyID
+}
+
+// KeySet returns the key set verifying attestations
+func (signer *AttestationSigner) KeySet() JSONWebKeySet {
+	return JSONWebKeySet{Keys: []JSONWebKey{signer.publicKey}}
+}
+
+// Sign returns the detached JWS of the payload: "<header>..<signature>", the payload left
+// out between the dots
+func (signer *AttestationSigner) Sign(payload []byte) string {
+	signingInput := signer.header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(signer.privateKey, []byte(signingInput))
+	return signer.header + ".." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// VerifyAttestation checks a detached JWS against its payload with an Ed25519 public key
+func VerifyAttestation(jws string, payload []byte, publicKey ed25519.PublicKey) error {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return fmt.Errorf("%w: expected <header>..<signature>", ErrInvalidAttestation)
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: header is not base64url", ErrInvalidAttestation)
+	}
+	var header attestationHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Algorithm != "EdDSA" {
+		return fmt.Errorf("%w: expected an EdDSA header", ErrInvalidAttestation)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64url", ErrInvalidAttestation)
+	}
+
+	signingInput := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload)
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, []byte(signingInput), signature) {
+		return ErrInvalidAttestation
+	}
+	return nil
+}
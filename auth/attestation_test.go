@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func TestAttestationSigner(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "attestations.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewAttestationSigner(config.AttestationConfig{SigningKeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("NewAttestationSigner() error = %v", err)
+	}
+	keySet := signer.KeySet()
+	if len(keySet.Keys) != 1 || keySet.Keys[0].KeyID != signer.KeyID() || keySet.Keys[0].Algorithm != "EdDSA" {
+		t.Fatalf("KeySet() = %+v", keySet)
+	}
+	publishedKey, _ := base64.RawURLEncoding.DecodeString(keySet.Keys[0].X)
+	if !publicKey.Equal(ed25519.PublicKey(publishedKey)) {
+		t.Error("KeySet() publishes another key than the key file's")
+	}
+
+	payload := []byte(`{"jti":"a1","pair":"USD/EUR","rate":0.92}`)
+	jws := signer.Sign(payload)
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("Sign() = %q, want a detached JWS", jws)
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if want := `{"alg":"EdDSA","kid":"` + signer.KeyID() + `"}`; string(header) != want {
+		t.Errorf("Sign() header = %s, want %s", header, want)
+	}
+
+	if err := VerifyAttestation(jws, payload, publicKey); err != nil {
+		t.Errorf("VerifyAttestation() error = %v", err)
+	}
+	tampered := []byte(`{"jti":"a1","pair":"USD/EUR","rate":0.93}`)
+	if err := VerifyAttestation(jws, tampered, publicKey); !errors.Is(err, ErrInvalidAttestation) {
+		t.Errorf("VerifyAttestation() of a tampered payload error = %v, want %v", err, ErrInvalidAttestation)
+	}
+	attached := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	if err := VerifyAttestation(attached, payload, publicKey); !errors.Is(err, ErrInvalidAttestation) {
+		t.Errorf("VerifyAttestation() of an attached payload error = %v, want %v", err, ErrInvalidAttestation)
+	}
+}
+
+func TestNewAttestationSigner_RequiresKeyFile(t *testing.T) {
+	if _, err := NewAttestationSigner(config.AttestationConfig{}); err == nil {
+		t.Error("NewAttestationSigner() should require a key file")
+	}
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// Claims holding the tenant and tier of the tokens the service issues
+const (
+	issuedTenantClaim = "tenant"
+	issuedTierClaim   = "tier"
+)
+
+// Issuer issues short-lived JWTs to the configured machine clients in exchange for their
+// credentials (the OAuth2 client-credentials grant), and publishes the key verifying them
+type Issuer struct {
+	name      string
+	ttl       time.Duration
+	signer    crypto.Signer
+	algorithm string
+	hash      crypto.Hash
+	keyID     string
+	publicKey JSONWebKey
+	now       func() time.Time
+
+	clientsMutex sync.RWMutex
+	clients      map[string]config.OAuthClient
+}
+
+// NewIssuer creates an issuer for the OAuth configuration, or returns nil when no
+// clients are configured. Without a signing key file, a key is generated, so tokens do
+// not survive a restart and are only accepted by the instance that issued them.
+func NewIssuer(configuration config.OAuthConfig) (*Issuer, error) {
+	if len(configuration.Clients) == 0 {
+		return nil, nil
+	}
+
+	var signer crypto.Signer
+	var err error
+	if configuration.SigningKeyFile != "" {
+		signer, err = loadSigningKey(configuration.SigningKeyFile)
+	} else {
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := &Issuer{
+		name:    configuration.Issuer,
+		ttl:     configuration.TokenTTL,
+		clients: make(map[string]config.OAuthClient, len(configuration.Clients)),
+		signer:  signer,
+		now:     time.Now,
+	}
+	for _, client := range configuration.Clients {
+		issuer.clients[client.ID] = client
+	}
+	if err := issuer.describeKey(); err != nil {
+		return nil, err
+	}
+	return issuer, nil
+}
+
+// Authenticate returns the client with the ID when secret is one of its secrets
+func (issuer *Issuer) Authenticate(clientID, secret string) (config.OAuthClient, bool) {
+	issuer.clientsMutex.RLock()
+	client, found := issuer.clients[clientID]
+	issuer.clientsMutex.RUnlock()
+
+	matched := false
+	// Every secret is compared, in constant time, so timing reveals nothing about them
+	secretSum := sha256.Sum256([]byte(secret))
+	for _, candidate := range client.Secrets {
+		candidateSum := sha256.Sum256([]byte(candidate))
+		if subtle.ConstantTimeCompare(secretSum[:], candidateSum[:]) == 1 {
+			matched = true
+		}
+	}
+	return client, found && matched
+}
+
+// RotateClientSecrets replaces the secrets of the configured clients with rotated values.
+// Clients added since startup are ignored, as their tenants were not validated.
+func (issuer *Issuer) RotateClientSecrets(clients []config.OAuthClient) {
+	issuer.clientsMutex.Lock()
+	defer issuer.clientsMutex.Unlock()
+
+	rotated := make(map[string]config.OAuthClient, len(issuer.clients))
+	for _, client := range clients {
+		if registered, found := issuer.clients[client.ID]; found {
+			registered.Secrets = client.Secrets
+			rotated[client.ID] = registered
+		}
+	}
+	issuer.clients = rotated
+}
+
+// Issue signs a token for the client, returning it with its lifetime
+func (issuer *Issuer) Issue(client config.OAuthClient) (string, time.Duration, error) {
+	now := issuer.now()
+	claims := map[string]interface{}{
+		"iss":             issuer.name,
+		"aud":             issuer.name,
+		"sub":             client.ID,
+		"iat":             now.Unix(),
+		"exp":             now.Add(issuer.ttl).Unix(),
+		issuedTenantClaim: client.Tenant,
+	}
+	if client.Tier != "" {
+		claims[issuedTierClaim] = client.Tier
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": issuer.algorithm, "typ": "JWT", "kid": issuer.keyID})
+	if err != nil {
+		return "", 0, err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", 0, err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hasher := issuer.hash.New()
+	hasher.Write([]byte(signingInput))
+	signature, err := issuer.sign(hasher.Sum(nil))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), issuer.ttl, nil
+}
+
+// KeySet returns the key set verifying the issued tokens
+func (issuer *Issuer) KeySet() JSONWebKeySet {
+	return JSONWebKeySet{Keys: []JSONWebKey{issuer.publicKey}}
+}
+
+// key returns the signing key to the verifier
+func (issuer *Issuer) key(ctx context.Context, keyID string) (signingKey, error) {
+	if keyID != issuer.keyID {
+		return signingKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, keyID)
+	}
+	return signingKey{publicKey: issuer.signer.Public(), algorithm: issuer.algorithm}, nil
+}
+
+// sign signs a digest, encoding ECDSA signatures as the fixed-size r and s JWS expects
+func (issuer *Issuer) sign(digest []byte) ([]byte, error) {
+	switch key := issuer.signer.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, key, issuer.hash, digest)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...), nil
+	default:
+		return nil, errors.New("unsupported signing key")
+	}
+}
+
+// describeKey derives the algorithm, key ID and published key of the signing key
+func (issuer *Issuer) describeKey() error {
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(issuer.signer.Public())
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	sum := sha256.Sum256(publicKeyDER)
+	issuer.keyID = hex.EncodeToString(sum[:8])
+
+	switch key := issuer.signer.Public().(type) {
+	case *rsa.PublicKey:
+		issuer.algorithm, issuer.hash = "RS256", crypto.SHA256
+		issuer.publicKey = JSONWebKey{
+			KeyType: "RSA",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		algorithms := map[string]crypto.Hash{"P-256": crypto.SHA256, "P-384": crypto.SHA384, "P-521": crypto.SHA512}
+		curve := key.Curve.Params().Name
+		hash, supported := algorithms[curve]
+		if !supported {
+			return fmt.Errorf("unsupported signing key curve %s", curve)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		issuer.algorithm, issuer.hash = fmt.Sprintf("ES%d", hash.Size()*8), hash
+		issuer.publicKey = JSONWebKey{
+			KeyType: "EC",
+			Curve:   curve,
+			X:       base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:       base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}
+	default:
+		return errors.New("signing key must be RSA or ECDSA")
+	}
+	issuer.publicKey.KeyID, issuer.publicKey.Use, issuer.publicKey.Algorithm = issuer.keyID, "sig", issuer.algorithm
+	return nil
+}
+
+// loadSigningKey reads a PEM-encoded PKCS #8, PKCS #1 or SEC 1 private key
+func loadSigningKey(path string) (crypto.Signer, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM-encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("signing key %s is not an RSA or ECDSA private key", path)
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func testOAuthConfig() config.OAuthConfig {
+	return config.OAuthConfig{
+		Clients: []config.OAuthClient{
+			{ID: "billing", Secrets: []string{"new-secret", "old-secret"}, Tenant: "acme", Tier: "pro"},
+		},
+		Issuer:   "currency-exchange-service",
+		TokenTTL: 15 * time.Minute,
+	}
+}
+
+func TestIssuer_Authenticate(t *testing.T) {
+	issuer, err := NewIssuer(testOAuthConfig())
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+
+	tests := []struct {
+		clientID, secret string
+		want             bool
+	}{
+		{"billing", "new-secret", true},
+		{"billing", "old-secret", true},
+		{"billing", "wrong-secret", false},
+		{"billing", "", false},
+		{"unknown", "new-secret", false},
+	}
+	for _, tt := range tests {
+		if client, ok := issuer.Authenticate(tt.clientID, tt.secret); ok != tt.want || (ok && client.Tenant != "acme") {
+			t.Errorf("Authenticate(%q, %q) = %+v, %v, want %v", tt.clientID, tt.secret, client, ok, tt.want)
+		}
+	}
+}
+
+func TestIssuer_RotateClientSecrets(t *testing.T) {
+	issuer, err := NewIssuer(testOAuthConfig())
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+
+	issuer.RotateClientSecrets([]config.OAuthClient{
+		{ID: "billing", Secrets: []string{"rotated-secret"}, Tenant: "acme"},
+		{ID: "added-later", Secrets: []string{"secret"}, Tenant: "acme"},
+	})
+
+	if client, ok := issuer.Authenticate("billing", "rotated-secret"); !ok || client.Tier != "pro" {
+		t.Errorf("Authenticate() with the rotated secret = %+v, %v, want the client with its tier", client, ok)
+	}
+	if _, ok := issuer.Authenticate("billing", "new-secret"); ok {
+		t.Error("Authenticate() accepted a secret removed by the rotation")
+	}
+	if _, ok := issuer.Authenticate("added-later", "secret"); ok {
+		t.Error("Authenticate() accepted a client added after startup")
+	}
+}
+
+func TestIssuer_IssueAndVerify(t *testing.T) {
+	issuer, err := NewIssuer(testOAuthConfig())
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	remote := testutils.NewMockJWTIssuer()
+	defer remote.Close()
+	verifier := NewVerifier(config.JWTConfig{JWKSURL: remote.URL(), Issuer: "https://issuer.example.com", TenantClaim: "org"}, issuer, nil)
+
+	client, _ := issuer.Authenticate("billing", "new-secret")
+	token, ttl, err := issuer.Issue(client)
+	if err != nil || ttl != 15*time.Minute {
+		t.Fatalf("Issue() = %v, %v, want a token valid for 15m", ttl, err)
+	}
+	principal, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if want := (Principal{Subject: "billing", Tenant: "acme", Tier: "pro"}); principal != want {
+		t.Errorf("Verify() = %+v, want %+v", principal, want)
+	}
+	if remote.Fetches() != 0 {
+		t.Errorf("key set fetches = %d, want issued tokens verified without the remote issuer", remote.Fetches())
+	}
+
+	// Issued tokens expire after their lifetime
+	verifier.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() of an expired issued token error = %v, want ErrInvalidToken", err)
+	}
+
+	keySet := issuer.KeySet()
+	if len(keySet.Keys) != 1 || keySet.Keys[0].KeyType != "EC" || keySet.Keys[0].Algorithm != "ES256" || keySet.Keys[0].KeyID == "" {
+		t.Errorf("KeySet() = %+v, want the generated P-256 key", keySet)
+	}
+}
+
+func TestNewIssuer_SigningKeyFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, encoded, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	configuration := testOAuthConfig()
+	configuration.SigningKeyFile = path
+	issuer, err := NewIssuer(configuration)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	token, _, err := issuer.Issue(configuration.Clients[0])
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if _, err := NewVerifier(config.JWTConfig{}, issuer, nil).Verify(context.Background(), token); err != nil {
+		t.Errorf("Verify() of an RS256 issued token error = %v", err)
+	}
+
+	configuration.SigningKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := NewIssuer(configuration); err == nil {
+		t.Error("NewIssuer() with a missing key file expected error")
+	}
+	if issuer, err := NewIssuer(config.OAuthConfig{}); issuer != nil || err != nil {
+		t.Errorf("NewIssuer() without clients = %v, %v, want nil", issuer, err)
+	}
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// refreshCooldown is the minimum time between key set fetches, so tokens with made-up
+// key IDs cannot flood the issuer
+const refreshCooldown = 30 * time.Second
+
+// maxKeySetSize bounds the key set document read from the issuer
+const maxKeySetSize = 1 << 20
+
+// ErrKeysUnavailable reports that the key set could not be fetched and no keys are cached
+var ErrKeysUnavailable = errors.New("signing keys unavailable")
+
+// JSONWebKeySet is a JSON Web Key Set document (RFC 7517)
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JSONWebKey is a public RSA or elliptic curve key of a key set
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// signingKey is a parsed key and the algorithm it is restricted to ("" = any matching its type)
+type signingKey struct {
+	publicKey crypto.PublicKey
+	algorithm string
+}
+
+// keySet fetches and caches the signing keys of a token issuer. Keys are refetched once
+// the cache TTL passes, and earlier when a token names an unknown key, so rotated keys
+// are picked up without a restart. When a refetch fails, the cached keys stay in use.
+type keySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	fetchMutex sync.Mutex // Serializes fetches
+
+	mutex       sync.RWMutex
+	keys        map[string]signingKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	lastError   error
+}
+
+// newKeySet creates a key set for the JWKS URL, caching fetched keys for ttl
+func newKeySet(url string, ttl time.Duration, client *http.Client) *keySet {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &keySet{url: url, ttl: ttl, client: client}
+}
+
+// key returns the key with the key ID. An empty key ID matches the only key of a set
+// holding a single key.
+func (set *keySet) key(ctx context.Context, keyID string) (signingKey, error) {
+	key, found, stale := set.cached(keyID)
+	if found && !stale {
+		return key, nil
+	}
+
+	if err := set.refresh(ctx); err != nil && !found && !set.hasKeys() {
+		return signingKey{}, fmt.Errorf("%w: %v", ErrKeysUnavailable, err)
+	}
+	// A key that failed to refresh stays in use while the issuer is unreachable
+	if key, found, _ = set.cached(keyID); !found {
+		return signingKey{}, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, keyID)
+	}
+	return key, nil
+}
+
+// cached looks up a key, reporting whether the cached keys have expired
+func (set *keySet) cached(keyID string) (key signingKey, found, stale bool) {
+	set.mutex.RLock()
+	defer set.mutex.RUnlock()
+
+	if keyID == "" && len(set.keys) == 1 {
+		for _, onlyKey := range set.keys {
+			key, found = onlyKey, true
+		}
+	} else {
+		key, found = set.keys[keyID]
+	}
+	stale = set.fetchedAt.IsZero() || (set.ttl > 0 && time.Since(set.fetchedAt) >= set.ttl)
+	return key, found, stale
+}
+
+// hasKeys reports whether any keys are cached
+func (set *keySet) hasKeys() bool {
+	set.mutex.RLock()
+	defer set.mutex.RUnlock()
+	return len(set.keys) > 0
+}
+
+// refresh fetches the key set unless it was attempted within the cooldown, by this or a
+// concurrent caller, in which case the outcome of that attempt is returned
+func (set *keySet) refresh(ctx context.Context) error {
+	set.fetchMutex.Lock()
+	defer set.fetchMutex.Unlock()
+
+	set.mutex.RLock()
+	attemptedAt, lastError := set.attemptedAt, set.lastError
+	set.mutex.RUnlock()
+	if !attemptedAt.IsZero() && time.Since(attemptedAt) < refreshCooldown {
+		return lastError
+	}
+
+	keys, err := set.fetch(ctx)
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	set.attemptedAt, set.lastError = time.Now(), err
+	if err != nil {
+		return err
+	}
+	set.keys = keys
+	set.fetchedAt = set.attemptedAt
+	return nil
+}
+
+// fetch downloads and parses the key set, skipping keys that cannot verify signatures
+func (set *keySet) fetch(ctx context.Context) (map[string]signingKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, set.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key set request: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := set.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch key set: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch key set: status %d", response.StatusCode)
+	}
+
+	var document JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxKeySetSize)).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := make(map[string]signingKey, len(document.Keys))
+	for _, webKey := range document.Keys {
+		if webKey.Use != "" && webKey.Use != "sig" {
+			continue
+		}
+		publicKey, err := webKey.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[webKey.KeyID] = signingKey{publicKey: publicKey, algorithm: webKey.Algorithm}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("key set has no usable signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey parses an RSA or elliptic curve key
+func (webKey JSONWebKey) publicKey() (crypto.PublicKey, error) {
+	switch webKey.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(webKey.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(webKey.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, known := curves[webKey.Curve]
+		if !known {
+			return nil, fmt.Errorf("unsupported curve %q", webKey.Curve)
+		}
+		x, err := decodeBigInt(webKey.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(webKey.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", webKey.KeyType)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestKeySet_Rotation(t *testing.T) {
+	issuer := testutils.NewMockJWTIssuer()
+	defer issuer.Close()
+	verifier := newTestVerifier(issuer.URL())
+
+	if _, err := verifier.Verify(context.Background(), issuer.Token(validClaims(nil))); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), issuer.Token(validClaims(nil))); err != nil || issuer.Fetches() != 1 {
+		t.Fatalf("Verify() with cached keys error = %v, fetches = %d, want 1", err, issuer.Fetches())
+	}
+
+	// A token signed with a rotated key refetches the key set once the cooldown passed
+	issuer.RotateKey()
+	rotated := issuer.Token(validClaims(nil))
+	if _, err := verifier.Verify(context.Background(), rotated); !errors.Is(err, ErrInvalidToken) || issuer.Fetches() != 1 {
+		t.Errorf("Verify() within the cooldown error = %v, fetches = %d, want an unknown key without fetching", err, issuer.Fetches())
+	}
+	remoteKeys(verifier).attemptedAt = time.Now().Add(-refreshCooldown)
+	if _, err := verifier.Verify(context.Background(), rotated); err != nil || issuer.Fetches() != 2 {
+		t.Errorf("Verify() with a rotated key error = %v, fetches = %d, want 2", err, issuer.Fetches())
+	}
+}
+
+func TestKeySet_IssuerUnavailable(t *testing.T) {
+	issuer := testutils.NewMockJWTIssuer()
+	verifier := newTestVerifier(issuer.URL())
+	token := issuer.Token(validClaims(nil))
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	issuer.Close()
+
+	// Expired keys stay in use while the issuer is down
+	remoteKeys(verifier).fetchedAt = time.Now().Add(-2 * time.Hour)
+	remoteKeys(verifier).attemptedAt = remoteKeys(verifier).fetchedAt
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Errorf("Verify() with the issuer down error = %v, want the cached key used", err)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	if _, err := newTestVerifier(down.URL).Verify(context.Background(), token); !errors.Is(err, ErrKeysUnavailable) {
+		t.Errorf("Verify() without keys error = %v, want ErrKeysUnavailable", err)
+	}
+}
+
+// remoteKeys returns the key set of the verifier's configured issuer
+func remoteKeys(verifier *Verifier) *keySet {
+	return verifier.issuers[len(verifier.issuers)-1].keys.(*keySet)
+}
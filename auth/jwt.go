@@ -0,0 +1,259 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// ErrInvalidToken reports a token that is malformed, badly signed, expired or not meant
+// for this service
+var ErrInvalidToken = errors.New("invalid token")
+
+// algorithms lists the supported signature algorithms and their hash. Symmetric and
+// unsigned tokens are rejected.
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ecdsaCurves binds each ES algorithm to its curve, so a key on another curve cannot
+// verify tokens naming the algorithm
+var ecdsaCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+// Principal is the caller a token was issued to
+type Principal struct {
+	Subject string
+	Tenant  string // Tenant ID from the tenant claim
+	Tier    string // Rate limit tier from the tier claim ("" = none)
+}
+
+// keyProvider supplies the keys verifying an issuer's signatures
+type keyProvider interface {
+	key(ctx context.Context, keyID string) (signingKey, error)
+}
+
+// trustedIssuer is an issuer whose tokens are accepted, and how its claims are checked
+// and mapped
+type trustedIssuer struct {
+	keys        keyProvider
+	issuer      string // Required iss claim ("" = any)
+	audience    string // Value the aud claim must contain ("" = not checked)
+	tenantClaim string
+	tierClaim   string
+}
+
+// Verifier validates JWT bearer tokens of the trusted issuers and maps their claims to
+// a principal
+type Verifier struct {
+	issuers   []trustedIssuer
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewVerifier creates a verifier accepting the tokens of the issuer configured by the JWT
+// configuration and of the service's own issuer, either of which may be absent. It
+// returns nil when neither is.
+func NewVerifier(configuration config.JWTConfig, localIssuer *Issuer, client *http.Client) *Verifier {
+	verifier := &Verifier{clockSkew: configuration.ClockSkew, now: time.Now}
+	// The service's own tokens are matched first, by their issuer
+	if localIssuer != nil {
+		verifier.issuers = append(verifier.issuers, trustedIssuer{
+			keys:        localIssuer,
+			issuer:      localIssuer.name,
+			audience:    localIssuer.name,
+			tenantClaim: issuedTenantClaim,
+			tierClaim:   issuedTierClaim,
+		})
+	}
+	if configuration.JWKSURL != "" {
+		verifier.issuers = append(verifier.issuers, trustedIssuer{
+			keys:        newKeySet(configuration.JWKSURL, configuration.JWKSCacheTTL, client),
+			issuer:      configuration.Issuer,
+			audience:    configuration.Audience,
+			tenantClaim: configuration.TenantClaim,
+			tierClaim:   configuration.TierClaim,
+		})
+	}
+	if len(verifier.issuers) == 0 {
+		return nil
+	}
+	return verifier
+}
+
+// Verify validates a compact JWS token: its signature, expiry, not-before and issued-at
+// times, issuer and audience. Errors wrap ErrInvalidToken, or ErrKeysUnavailable when the
+// issuer's keys cannot be fetched.
+func (verifier *Verifier) Verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	hash, supported := algorithms[header.Algorithm]
+	if !supported {
+		return Principal{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	// The issuer is chosen by the unverified iss claim; its keys then verify the token
+	trusted, found := verifier.trustedIssuer(stringClaim(claims, "iss"))
+	if !found {
+		return Principal{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	key, err := trusted.keys.key(ctx, header.KeyID)
+	if err != nil {
+		return Principal{}, err
+	}
+	if key.algorithm != "" && key.algorithm != header.Algorithm {
+		return Principal{}, fmt.Errorf("%w: key %q does not sign with %s", ErrInvalidToken, header.KeyID, header.Algorithm)
+	}
+	if err := verifySignature(key.publicKey, header.Algorithm, hash, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+	if err := verifier.validateClaims(claims, trusted); err != nil {
+		return Principal{}, err
+	}
+
+	principal := Principal{
+		Subject: stringClaim(claims, "sub"),
+		Tenant:  stringClaim(claims, trusted.tenantClaim),
+		Tier:    stringClaim(claims, trusted.tierClaim),
+	}
+	if principal.Tenant == "" {
+		return Principal{}, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, trusted.tenantClaim)
+	}
+	return principal, nil
+}
+
+// trustedIssuer returns the issuer named by an iss claim, or the issuer accepting any
+func (verifier *Verifier) trustedIssuer(iss string) (trustedIssuer, bool) {
+	for _, trusted := range verifier.issuers {
+		if trusted.issuer == iss || trusted.issuer == "" {
+			return trusted, true
+		}
+	}
+	return trustedIssuer{}, false
+}
+
+// validateClaims checks the registered time and audience claims
+func (verifier *Verifier) validateClaims(claims map[string]interface{}, trusted trustedIssuer) error {
+	now := verifier.now()
+
+	expiresAt, found := timeClaim(claims, "exp")
+	if !found {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if !now.Before(expiresAt.Add(verifier.clockSkew)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if notBefore, found := timeClaim(claims, "nbf"); found && now.Add(verifier.clockSkew).Before(notBefore) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if issuedAt, found := timeClaim(claims, "iat"); found && now.Add(verifier.clockSkew).Before(issuedAt) {
+		return fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	}
+
+	if trusted.audience != "" && !hasAudience(claims["aud"], trusted.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// verifySignature checks an RSA PKCS #1 v1.5 or ECDSA signature of the signing input
+func verifySignature(publicKey crypto.PublicKey, algorithm string, hash crypto.Hash, signingInput string, signature []byte) error {
+	hasher := hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(algorithm, "RS") && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		// ES signatures are the fixed-size big-endian r and s concatenated
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if ecdsaCurves[algorithm] == key.Curve && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+}
+
+// decodeSegment decodes a base64url-encoded JSON token segment
+func decodeSegment(segment string, target interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, target)
+}
+
+// stringClaim returns a string claim, or "" when it is missing or not a string
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// timeClaim returns a NumericDate claim (seconds since the epoch)
+func timeClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	seconds, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// hasAudience reports whether an aud claim, a string or an array of strings, contains audience
+func hasAudience(claim interface{}, audience string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, entry := range value {
+			if entry == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
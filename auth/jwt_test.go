@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// testNow is the verification time of the tests
+var testNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestVerifier(jwksURL string) *Verifier {
+	verifier := NewVerifier(config.JWTConfig{
+		JWKSURL:      jwksURL,
+		Issuer:       "https://issuer.example.com",
+		Audience:     "currency-api",
+		JWKSCacheTTL: time.Hour,
+		ClockSkew:    time.Minute,
+		TenantClaim:  "tenant",
+		TierClaim:    "tier",
+	}, nil, nil)
+	verifier.now = func() time.Time { return testNow }
+	return verifier
+}
+
+// validClaims returns claims that pass every check, with overrides applied
+func validClaims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":    "https://issuer.example.com",
+		"aud":    []string{"other-api", "currency-api"},
+		"sub":    "user-42",
+		"tenant": "acme",
+		"tier":   "pro",
+		"iat":    testNow.Add(-time.Minute).Unix(),
+		"exp":    testNow.Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	issuer := testutils.NewMockJWTIssuer()
+	defer issuer.Close()
+	verifier := newTestVerifier(issuer.URL())
+
+	principal, err := verifier.Verify(context.Background(), issuer.Token(validClaims(nil)))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if want := (Principal{Subject: "user-42", Tenant: "acme", Tier: "pro"}); principal != want {
+		t.Errorf("Verify() = %+v, want %+v", principal, want)
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+	}{
+		{"expired", validClaims(map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()})},
+		{"missing exp", validClaims(map[string]interface{}{"exp": nil})},
+		{"not valid yet", validClaims(map[string]interface{}{"nbf": testNow.Add(time.Hour).Unix()})},
+		{"wrong issuer", validClaims(map[string]interface{}{"iss": "https://evil.example.com"})},
+		{"wrong audience", validClaims(map[string]interface{}{"aud": "other-api"})},
+		{"missing tenant", validClaims(map[string]interface{}{"tenant": nil})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(context.Background(), issuer.Token(tt.claims)); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+
+	// Expiry within the clock skew is tolerated
+	if _, err := verifier.Verify(context.Background(), issuer.Token(validClaims(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()}))); err != nil {
+		t.Errorf("Verify() within the clock skew error = %v", err)
+	}
+}
+
+func TestVerifier_Verify_RejectsForgedTokens(t *testing.T) {
+	issuer := testutils.NewMockJWTIssuer()
+	defer issuer.Close()
+	verifier := newTestVerifier(issuer.URL())
+
+	token := issuer.Token(validClaims(nil))
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + segment(validClaims(map[string]interface{}{"tenant": "other"})) + "." + parts[2]
+	unsigned := segment(map[string]string{"alg": "none"}) + "." + parts[1] + "."
+	symmetric := segment(map[string]string{"alg": "HS256", "kid": "key-1"}) + "." + parts[1] + "." + parts[2]
+
+	for name, forged := range map[string]string{"tampered": tampered, "unsigned": unsigned, "symmetric": symmetric, "malformed": "not-a-token"} {
+		if _, err := verifier.Verify(context.Background(), forged); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%s) error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestVerifier_Verify_ECDSA(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "EC", "crv": "P-256", "kid": "ec-1",
+			"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	defer server.Close()
+
+	signingInput := segment(map[string]string{"alg": "ES256", "kid": "ec-1"}) + "." + segment(validClaims(nil))
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	verifier := newTestVerifier(server.URL)
+	if _, err := verifier.Verify(context.Background(), signingInput+"."+base64.RawURLEncoding.EncodeToString(signature)); err != nil {
+		t.Errorf("Verify() ES256 error = %v", err)
+	}
+
+	// A P-256 key does not verify tokens naming another ES algorithm
+	mismatchedInput := segment(map[string]string{"alg": "ES384", "kid": "ec-1"}) + "." + segment(validClaims(nil))
+	mismatchedDigest := sha512.Sum384([]byte(mismatchedInput))
+	r, s, err = ecdsa.Sign(rand.Reader, key, mismatchedDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	if _, err := verifier.Verify(context.Background(), mismatchedInput+"."+base64.RawURLEncoding.EncodeToString(signature)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() ES384 with a P-256 key error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestNewVerifier_Disabled(t *testing.T) {
+	if verifier := NewVerifier(config.JWTConfig{}, nil, nil); verifier != nil {
+		t.Errorf("NewVerifier() without a JWKS URL = %v, want nil", verifier)
+	}
+}
+
+// segment encodes a value as a base64url JSON token segment
+func segment(value interface{}) string {
+	encoded, _ := json.Marshal(value)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Algorithms of rates signatures
+const (
+	ResponseSigningHMAC    = "hmac-sha256"
+	ResponseSigningEd25519 = "ed25519"
+)
+
+// Headers carrying the signature of a rates table and the ID of the Ed25519 key it was
+// made with
+const (
+	RatesSignatureHeader      = "X-Rates-Signature"
+	RatesSignatureKeyIDHeader = "X-Rates-Signature-Key-ID"
+)
+
+// ratesCanonicalVersion is the first line of canonical rates, naming their format
+const ratesCanonicalVersion = "rates-v1"
+
+// ErrInvalidRatesSignature reports a rates signature that is missing, malformed or wrong
+var ErrInvalidRatesSignature = errors.New("invalid rates signature")
+
+// ResponseSigner signs the rates tables the service serves, over their canonical form, so
+// the signature holds whatever encoding the table is served in
+type ResponseSigner struct {
+	algorithm  string
+	hmacKey    []byte
+	privateKey ed25519.PrivateKey
+	keyID      string
+	publicKey  JSONWebKey
+}
+
+// NewResponseSigner creates a signer for the configuration, or returns nil when responses
+// are not signed. Without an Ed25519 key file, a key is generated, so its signatures only
+// verify with the key published by this instance.
+func NewResponseSigner(configuration config.ResponseSigningConfig) (*ResponseSigner, error) {
+	switch configuration.Algorithm {
+	case "":
+		return nil, nil
+	case ResponseSigningHMAC:
+		if configuration.Key == "" {
+			return nil, errors.New("RESPONSE_SIGNING_KEY is required for hmac-sha256 response signatures")
+		}
+		return &ResponseSigner{algorithm: ResponseSigningHMAC, hmacKey: []byte(configuration.Key)}, nil
+	case ResponseSigningEd25519:
+		var privateKey ed25519.PrivateKey
+		if configuration.KeyFile != "" {
+			key, err := loadEd25519Key(configuration.KeyFile, "response signing")
+			if err != nil {
+				return nil, err
+			}
+			privateKey = key
+		} else {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, err
+			}
+			privateKey = key
+		}
+		publicKey, err := ed25519JSONWebKey(privateKey, "response signing")
+		if err != nil {
+			return nil, err
+		}
+		return &ResponseSigner{
+			algorithm:  ResponseSigningEd25519,
+			privateKey: privateKey,
+			keyID:      publicKey.KeyID,
+			publicKey:  publicKey,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown response signing algorithm %q: use hmac-sha256 or ed25519", configuration.Algorithm)
+	}
+}
+
+// loadEd25519Key loads a PEM Ed25519 private key, named by its purpose in errors
+func loadEd25519Key(path, purpose string) (ed25519.PrivateKey, error) {
+	signer, err := loadSigningKey(path)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, isEd25519 := signer.(ed25519.PrivateKey)
+	if !isEd25519 {
+		return nil, fmt.Errorf("%s key %s is not an Ed25519 private key", purpose, path)
+	}
+	return privateKey, nil
+}
+
+// ed25519JSONWebKey describes the public half of an Ed25519 key, identified by the
+// SHA-256 of its PKIX encoding
+func ed25519JSONWebKey(privateKey ed25519.PrivateKey, purpose string) (JSONWebKey, error) {
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return JSONWebKey{}, fmt.Errorf("failed to encode %s key: %w", purpose, err)
+	}
+	sum := sha256.Sum256(publicKeyDER)
+
+	return JSONWebKey{
+		KeyType:   "OKP",
+		KeyID:     hex.EncodeToString(sum[:8]),
+		Use:       "sig",
+		Algorithm: "EdDSA",
+		Curve:     "Ed25519",
+		X:         base64.RawURLEncoding.EncodeToString(publicKey),
+	}, nil
+}
+
+// KeyID returns the ID of the Ed25519 key ("" for HMAC signatures)
+func (signer *ResponseSigner) KeyID() string {
+	return signer.keyID
+}
+
+// KeySet returns the key set verifying Ed25519 signatures, and false for HMAC signatures,
+// whose key is shared out of band, and without a signer
+func (signer *ResponseSigner) KeySet() (JSONWebKeySet, bool) {
+	if signer == nil || signer.algorithm != ResponseSigningEd25519 {
+		return JSONWebKeySet{}, false
+	}
+	return JSONWebKeySet{Keys: []JSONWebKey{signer.publicKey}}, true
+}
+
+// SignRates returns the signature header value of the rates table, e.g. "ed25519=<sig>",
+// the signature encoded as unpadded base64url
+func (signer *ResponseSigner) SignRates(exchangeRates models.RatesResponse) string {
+	canonical := CanonicalRates(exchangeRates)
+	var signature []byte
+	if signer.algorithm == ResponseSigningEd25519 {
+		signature = ed25519.Sign(signer.privateKey, canonical)
+	} else {
+		mac := hmac.New(sha256.New, signer.hmacKey)
+		mac.Write(canonical)
+		signature = mac.Sum(nil)
+	}
+	return signer.algorithm + "=" + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// CanonicalRates returns the signed form of a rates table: lines of the format version,
+// base, provider and timestamp, then one CODE=rate line per currency sorted by code. Rates
+// are written in the shortest decimal notation that reads back as the same number,
+// without exponent. Lines end with a newline.
+func CanonicalRates(exchangeRates models.RatesResponse) []byte {
+	codes := make([]string, 0, len(exchangeRates.Rates))
+	for code := range exchangeRates.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var canonical bytes.Buffer
+	canonical.WriteString(ratesCanonicalVersion + "\n")
+	canonical.WriteString("base=" + exchangeRates.Base + "\n")
+	canonical.WriteString("provider=" + exchangeRates.Provider + "\n")
+	canonical.WriteString("timestamp=" + strconv.FormatInt(exchangeRates.Timestamp, 10) + "\n")
+	for _, code := range codes {
+		canonical.WriteString(code + "=" + strconv.FormatFloat(exchangeRates.Rates[code], 'f', -1, 64) + "\n")
+	}
+	return canonical.Bytes()
+}
+
+// VerifyRatesSignature checks a signature header value against a rates table, with the
+// shared HMAC secret or the Ed25519 public key, as the header's algorithm requires
+func VerifyRatesSignature(header string, exchangeRates models.RatesResponse, key []byte) error {
+	algorithm, encoded, found := strings.Cut(header, "=")
+	if !found {
+		return fmt.Errorf("%w: expected <algorithm>=<signature>", ErrInvalidRatesSignature)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64url", ErrInvalidRatesSignature)
+	}
+
+	canonical := CanonicalRates(exchangeRates)
+	switch algorithm {
+	case ResponseSigningHMAC:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(canonical)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrInvalidRatesSignature
+		}
+	case ResponseSigningEd25519:
+		if len(key) != ed25519.PublicKeySize || !ed25519.Verify(ed25519.PublicKey(key), canonical, signature) {
+			return ErrInvalidRatesSignature
+		}
+	default:
+		return fmt.Errorf("%w: unknown algorithm %q", ErrInvalidRatesSignature, algorithm)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestCanonicalRates(t *testing.T) {
+	canonical := CanonicalRates(models.RatesResponse{
+		Base:       "USD",
+		Provider:   "erapi",
+		Timestamp:  1704067200,
+		FetchedAt:  1704067205,
+		AgeSeconds: 12,
+		Rates:      models.RateTable{"JPY": 150, "EUR": 0.92, "BTC": 0.0000156, "IDR": 15400.5},
+	})
+
+	want := "rates-v1\nbase=USD\nprovider=erapi\ntimestamp=1704067200\nBTC=0.0000156\nEUR=0.92\nIDR=15400.5\nJPY=150\n"
+	if string(canonical) != want {
+		t.Errorf("CanonicalRates() =\n%s\nwant\n%s", canonical, want)
+	}
+}
+
+func TestResponseSigner(t *testing.T) {
+	exchangeRates := models.RatesResponse{Base: "USD", Provider: "erapi", Timestamp: 1704067200, Rates: models.RateTable{"EUR": 0.92, "GBP": 0.78}}
+	tampered := exchangeRates
+	tampered.Rates = models.RateTable{"EUR": 0.93, "GBP": 0.78}
+
+	t.Run("hmac-sha256", func(t *testing.T) {
+		signer, err := NewResponseSigner(config.ResponseSigningConfig{Algorithm: ResponseSigningHMAC, Key: "shared"})
+		if err != nil {
+			t.Fatalf("NewResponseSigner() error = %v", err)
+		}
+		if _, published := signer.KeySet(); published {
+			t.Error("KeySet() should not publish a shared HMAC key")
+		}
+
+		signature := signer.SignRates(exchangeRates)
+		if err := VerifyRatesSignature(signature, exchangeRates, []byte("shared")); err != nil {
+			t.Errorf("VerifyRatesSignature() error = %v", err)
+		}
+		if err := VerifyRatesSignature(signature, tampered, []byte("shared")); !errors.Is(err, ErrInvalidRatesSignature) {
+			t.Errorf("VerifyRatesSignature() of tampered rates error = %v, want %v", err, ErrInvalidRatesSignature)
+		}
+		if err := VerifyRatesSignature(signature, exchangeRates, []byte("other")); !errors.Is(err, ErrInvalidRatesSignature) {
+			t.Errorf("VerifyRatesSignature() with another key error = %v, want %v", err, ErrInvalidRatesSignature)
+		}
+	})
+
+	t.Run("ed25519 from key file", func(t *testing.T) {
+		publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+		}
+		keyFile := filepath.Join(t.TempDir(), "rates.pem")
+		if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		signer, err := NewResponseSigner(config.ResponseSigningConfig{Algorithm: ResponseSigningEd25519, KeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("NewResponseSigner() error = %v", err)
+		}
+		keySet, published := signer.KeySet()
+		if !published || len(keySet.Keys) != 1 || keySet.Keys[0].KeyID != signer.KeyID() || keySet.Keys[0].Curve != "Ed25519" {
+			t.Fatalf("KeySet() = %+v, %v", keySet, published)
+		}
+		publishedKey, _ := base64.RawURLEncoding.DecodeString(keySet.Keys[0].X)
+		if !publicKey.Equal(ed25519.PublicKey(publishedKey)) {
+			t.Error("KeySet() publishes another key than the key file's")
+		}
+
+		signature := signer.SignRates(exchangeRates)
+		if err := VerifyRatesSignature(signature, exchangeRates, publishedKey); err != nil {
+			t.Errorf("VerifyRatesSignature() error = %v", err)
+		}
+		if err := VerifyRatesSignature(signature, tampered, publishedKey); !errors.Is(err, ErrInvalidRatesSignature) {
+			t.Errorf("VerifyRatesSignature() of tampered rates error = %v, want %v", err, ErrInvalidRatesSignature)
+		}
+	})
+}
+
+func TestNewResponseSigner_Configuration(t *testing.T) {
+	if signer, err := NewResponseSigner(config.ResponseSigningConfig{}); signer != nil || err != nil {
+		t.Errorf("NewResponseSigner() without an algorithm = %v, %v, want nil", signer, err)
+	}
+	if _, err := NewResponseSigner(config.ResponseSigningConfig{Algorithm: ResponseSigningHMAC}); err == nil {
+		t.Error("NewResponseSigner() should require an HMAC key")
+	}
+	if _, err := NewResponseSigner(config.ResponseSigningConfig{Algorithm: "rsa"}); err == nil {
+		t.Error("NewResponseSigner() should reject an unknown algorithm")
+	}
+	if signer, err := NewResponseSigner(config.ResponseSigningConfig{Algorithm: ResponseSigningEd25519}); err != nil || signer.KeyID() == "" {
+		t.Errorf("NewResponseSigner() without a key file = %v, %v, want a generated key", signer, err)
+	}
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// maxNonceLength bounds the nonces remembered for replay protection
+const maxNonceLength = 128
+
+// ErrInvalidSignature reports a request signature that is missing, stale, replayed or wrong
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// SignedRequest is the signed content of a request and its signature headers
+type SignedRequest struct {
+	Method     string
+	RequestURI string // Path and query
+	Body       []byte
+	Timestamp  string // Unix time in seconds
+	Nonce      string // Unique value of each request, which cannot be replayed
+	Signature  string // Hex HMAC-SHA256, optionally prefixed "sha256="
+}
+
+// SignatureVerifier checks the HMAC signatures required of requests made with certain API
+// keys. Each nonce is accepted once while its timestamp is within the tolerance, so a
+// captured request cannot be replayed.
+type SignatureVerifier struct {
+	tolerance time.Duration
+	now       func() time.Time
+
+	secretsMutex sync.RWMutex
+	secrets      map[string]string
+
+	mutex     sync.Mutex
+	nonces    map[string]time.Time // Seen nonces per API key and when they can be forgotten
+	nextPrune time.Time
+}
+
+// NewSignatureVerifier creates a verifier for the configured signing secrets, or returns
+// nil when no API key requires signatures
+func NewSignatureVerifier(configuration config.RequestSignatureConfig) *SignatureVerifier {
+	if len(configuration.Secrets) == 0 {
+		return nil
+	}
+	return &SignatureVerifier{
+		secrets:   configuration.Secrets,
+		tolerance: configuration.Tolerance,
+		now:       time.Now,
+		nonces:    make(map[string]time.Time),
+	}
+}
+
+// Requires reports whether requests made with the API key must be signed
+func (verifier *SignatureVerifier) Requires(apiKey string) bool {
+	_, required := verifier.secret(apiKey)
+	return required
+}
+
+// RotateSecrets replaces the signing secrets with rotated values
+func (verifier *SignatureVerifier) RotateSecrets(secrets map[string]string) {
+	verifier.secretsMutex.Lock()
+	defer verifier.secretsMutex.Unlock()
+	verifier.secrets = secrets
+}
+
+// secret returns the signing secret of the API key
+func (verifier *SignatureVerifier) secret(apiKey string) (string, bool) {
+	verifier.secretsMutex.RLock()
+	defer verifier.secretsMutex.RUnlock()
+	secret, found := verifier.secrets[apiKey]
+	return secret, found
+}
+
+// Verify checks the signature over "<timestamp>\n<nonce>\n<method>\n<path?query>\n<body>",
+// keyed with the API key's secret. Errors wrap ErrInvalidSignature.
+func (verifier *SignatureVerifier) Verify(apiKey string, request SignedRequest) error {
+	secret, required := verifier.secret(apiKey)
+	if !required {
+		return nil
+	}
+	if request.Timestamp == "" || request.Nonce == "" || request.Signature == "" {
+		return fmt.Errorf("%w: missing signature headers", ErrInvalidSignature)
+	}
+	if len(request.Nonce) > maxNonceLength {
+		return fmt.Errorf("%w: nonce longer than %d characters", ErrInvalidSignature, maxNonceLength)
+	}
+
+	now := verifier.now()
+	seconds, err := strconv.ParseInt(request.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	signedAt := time.Unix(seconds, 0)
+	if now.Sub(signedAt).Abs() > verifier.tolerance {
+		return fmt.Errorf("%w: timestamp outside the allowed window", ErrInvalidSignature)
+	}
+
+	provided, err := hex.DecodeString(strings.TrimPrefix(request.Signature, "sha256="))
+	if err != nil || len(provided) == 0 {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(request.Timestamp + "\n" + request.Nonce + "\n" + request.Method + "\n" + request.RequestURI + "\n"))
+	mac.Write(request.Body)
+	if !hmac.Equal(provided, mac.Sum(nil)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+
+	// Only correctly signed requests use up their nonce
+	if !verifier.useNonce(apiKey, request.Nonce, signedAt.Add(verifier.tolerance), now) {
+		return fmt.Errorf("%w: nonce already used", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// useNonce records a nonce until it expires, reporting false when it was already seen.
+// Once the signed timestamp leaves the window, the request is rejected anyway, so the
+// nonce is forgotten then.
+func (verifier *SignatureVerifier) useNonce(apiKey, nonce string, expiresAt, now time.Time) bool {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	if now.After(verifier.nextPrune) {
+		for seen, seenExpiry := range verifier.nonces {
+			if now.After(seenExpiry) {
+				delete(verifier.nonces, seen)
+			}
+		}
+		verifier.nextPrune = now.Add(verifier.tolerance)
+	}
+
+	key := apiKey + "\n" + nonce
+	if seenExpiry, seen := verifier.nonces[key]; seen && !now.After(seenExpiry) {
+		return false
+	}
+	verifier.nonces[key] = expiresAt
+	return true
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// signedRequest signs a request the way a partner would
+func signedRequest(secret string, signedAt time.Time, nonce, method, requestURI, body string) SignedRequest {
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n" + method + "\n" + requestURI + "\n" + body))
+	return SignedRequest{
+		Method:     method,
+		RequestURI: requestURI,
+		Body:       []byte(body),
+		Timestamp:  timestamp,
+		Nonce:      nonce,
+		Signature:  hex.EncodeToString(mac.Sum(nil)),
+	}
+}
+
+func TestNewSignatureVerifier_Disabled(t *testing.T) {
+	if verifier := NewSignatureVerifier(config.RequestSignatureConfig{Tolerance: time.Minute}); verifier != nil {
+		t.Error("NewSignatureVerifier() without secrets should return nil")
+	}
+}
+
+func TestSignatureVerifier_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewSignatureVerifier(config.RequestSignatureConfig{
+		Secrets:   map[string]string{"partner-key": "shared-secret"},
+		Tolerance: 5 * time.Minute,
+	})
+	verifier.now = func() time.Time { return now }
+
+	if !verifier.Requires("partner-key") || verifier.Requires("other-key") {
+		t.Fatal("Requires() should only hold for keys with a secret")
+	}
+	if err := verifier.Verify("other-key", SignedRequest{}); err != nil {
+		t.Errorf("Verify() of a key without a secret error = %v, want nil", err)
+	}
+
+	valid := signedRequest("shared-secret", now.Add(-time.Minute), "n-1", "GET", "/api/v1/rates/EUR?symbols=USD", "")
+	if err := verifier.Verify("partner-key", valid); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	prefixed := signedRequest("shared-secret", now, "n-2", "POST", "/api/v1/convert", `{"amount":1}`)
+	prefixed.Signature = "sha256=" + prefixed.Signature
+	if err := verifier.Verify("partner-key", prefixed); err != nil {
+		t.Errorf("Verify() with a sha256= prefix error = %v", err)
+	}
+
+	tampered := signedRequest("shared-secret", now, "n-3", "GET", "/api/v1/rates/EUR", "")
+	tampered.RequestURI = "/api/v1/rates/GBP"
+	wrongSecret := signedRequest("guess", now, "n-4", "GET", "/api/v1/rates/EUR", "")
+	stale := signedRequest("shared-secret", now.Add(-6*time.Minute), "n-5", "GET", "/api/v1/rates/EUR", "")
+	unsigned := signedRequest("shared-secret", now, "n-6", "GET", "/api/v1/rates/EUR", "")
+	unsigned.Signature = ""
+
+	tests := []struct {
+		name    string
+		request SignedRequest
+	}{
+		{"replayed nonce", valid},
+		{"tampered path", tampered},
+		{"wrong secret", wrongSecret},
+		{"stale timestamp", stale},
+		{"missing signature", unsigned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifier.Verify("partner-key", tt.request); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	// A rejected request does not use up its nonce
+	retried := signedRequest("shared-secret", now, "n-3", "GET", "/api/v1/rates/GBP", "")
+	if err := verifier.Verify("partner-key", retried); err != nil {
+		t.Errorf("Verify() of a correctly signed retry error = %v", err)
+	}
+}
+
+func TestSignatureVerifier_ForgetsExpiredNonces(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewSignatureVerifier(config.RequestSignatureConfig{
+		Secrets:   map[string]string{"partner-key": "shared-secret"},
+		Tolerance: time.Minute,
+	})
+	verifier.now = func() time.Time { return now }
+
+	if err := verifier.Verify("partner-key", signedRequest("shared-secret", now, "n-1", "GET", "/", "")); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := verifier.Verify("partner-key", signedRequest("shared-secret", now, "n-2", "GET", "/", "")); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(verifier.nonces) != 1 {
+		t.Errorf("remembered nonces = %d, want the expired nonce pruned", len(verifier.nonces))
+	}
+}
+
+func TestSignatureVerifier_RotateSecrets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewSignatureVerifier(config.RequestSignatureConfig{
+		Secrets:   map[string]string{"partner-key": "old-secret"},
+		Tolerance: time.Minute,
+	})
+	verifier.now = func() time.Time { return now }
+
+	verifier.RotateSecrets(map[string]string{"partner-key": "new-secret"})
+
+	if err := verifier.Verify("partner-key", signedRequest("old-secret", now, "n-1", "GET", "/", "")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() with the old secret error = %v, want ErrInvalidSignature", err)
+	}
+	if err := verifier.Verify("partner-key", signedRequest("new-secret", now, "n-2", "GET", "/", "")); err != nil {
+		t.Errorf("Verify() with the rotated secret error = %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package calendar knows the trading days of the currency market, so historical queries
+// can mark weekends and holidays and optionally roll them to the previous business day.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Conventions of historical queries for dates that are not trading days
+const (
+	ConventionNone     = "none"     // Serve the date as is, marking it as a non-trading day
+	ConventionPrevious = "previous" // Roll to the previous business day
+)
+
+// dateLayout is the format of holidays and of the dates in response metadata
+const dateLayout = "2006-01-02"
+
+// maxRoll bounds the search for a previous business day, so a calendar without any
+// trading day cannot loop forever
+const maxRoll = 366
+
+// Calendar holds the weekend days and holidays of the market and the default convention.
+// A nil calendar treats every day as a trading day.
+type Calendar struct {
+	weekend    map[time.Weekday]bool
+	holidays   map[string]bool
+	convention string
+}
+
+// New creates the calendar of the configured weekend days and holidays
+func New(configuration config.MarketCalendarConfig) (*Calendar, error) {
+	convention, err := ParseConvention(configuration.Convention)
+	if err != nil {
+		return nil, fmt.Errorf("MARKET_DATE_CONVENTION %w", err)
+	}
+
+	calendar := &Calendar{
+		weekend:    make(map[time.Weekday]bool),
+		holidays:   make(map[string]bool),
+		convention: convention,
+	}
+	for _, name := range configuration.Weekend {
+		weekday, found := weekdays[strings.ToLower(name)]
+		if !found {
+			return nil, fmt.Errorf("MARKET_WEEKEND has unknown weekday %q", name)
+		}
+		calendar.weekend[weekday] = true
+	}
+	if len(calendar.weekend) == 7 {
+		return nil, fmt.Errorf("MARKET_WEEKEND leaves no trading day")
+	}
+	for _, holiday := range configuration.Holidays {
+		if _, err := time.Parse(dateLayout, holiday); err != nil {
+			return nil, fmt.Errorf("MARKET_HOLIDAYS date %q must be YYYY-MM-DD", holiday)
+		}
+		calendar.holidays[holiday] = true
+	}
+	return calendar, nil
+}
+
+// weekdays maps lowercase weekday names to weekdays
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ParseConvention validates a convention, defaulting an empty one to none
+func ParseConvention(convention string) (string, error) {
+	switch convention {
+	case "":
+		return ConventionNone, nil
+	case ConventionNone, ConventionPrevious:
+		return convention, nil
+	default:
+		return "", fmt.Errorf("must be none or previous, got %q", convention)
+	}
+}
+
+// Convention returns the convention applied when a query does not choose one
+func (calendar *Calendar) Convention() string {
+	if calendar == nil {
+		return ConventionNone
+	}
+	return calendar.convention
+}
+
+// IsTradingDay reports whether the UTC day of the time is a trading day
+func (calendar *Calendar) IsTradingDay(date time.Time) bool {
+	if calendar == nil {
+		return true
+	}
+	date = date.UTC()
+	return !calendar.weekend[date.Weekday()] && !calendar.holidays[date.Format(dateLayout)]
+}
+
+// Apply resolves the UTC day a single-date query serves under the convention, and
+// documents it in the response metadata
+func (calendar *Calendar) Apply(date time.Time, convention string) (time.Time, models.CalendarConvention) {
+	day := date.UTC().Truncate(24 * time.Hour)
+	metadata := models.CalendarConvention{
+		Convention:    convention,
+		RequestedDate: day.Format(dateLayout),
+		NonTradingDay: !calendar.IsTradingDay(day),
+	}
+	if !metadata.NonTradingDay || convention != ConventionPrevious {
+		return day, metadata
+	}
+
+	for rolls := 0; rolls < maxRoll && !calendar.IsTradingDay(day); rolls++ {
+		day = day.AddDate(0, 0, -1)
+	}
+	metadata.RolledTo = day.Format(dateLayout)
+	return day, metadata
+}
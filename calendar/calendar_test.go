@@ -0,0 +1,85 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func testCalendar(t *testing.T) *Calendar {
+	t.Helper()
+	calendar, err := New(config.MarketCalendarConfig{
+		Weekend:  []string{"saturday", "Sunday"},
+		Holidays: []string{"2024-12-25", "2024-12-26"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return calendar
+}
+
+func TestCalendar_IsTradingDay(t *testing.T) {
+	calendar := testCalendar(t)
+
+	tests := []struct {
+		date time.Time
+		want bool
+	}{
+		{time.Date(2024, 12, 20, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 12, 25, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2024, 12, 27, 0, 0, 0, 0, time.UTC), true},
+		// The UTC day counts: Friday evening in New York is Saturday in UTC
+		{time.Date(2024, 12, 20, 20, 0, 0, 0, time.FixedZone("EST", -5*3600)), false},
+	}
+	for _, test := range tests {
+		if got := calendar.IsTradingDay(test.date); got != test.want {
+			t.Errorf("IsTradingDay(%s) = %v, want %v", test.date, got, test.want)
+		}
+	}
+
+	var unconfigured *Calendar
+	if !unconfigured.IsTradingDay(time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC)) || unconfigured.Convention() != ConventionNone {
+		t.Error("a nil calendar should treat every day as a trading day")
+	}
+}
+
+func TestCalendar_Apply(t *testing.T) {
+	calendar := testCalendar(t)
+
+	// Boxing Day 2024 is a Thursday after the Christmas holiday, so it rolls back two days
+	day, metadata := calendar.Apply(time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC), ConventionPrevious)
+	if !day.Equal(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)) || metadata.RolledTo != "2024-12-24" || metadata.RequestedDate != "2024-12-26" || !metadata.NonTradingDay {
+		t.Errorf("Apply(previous) = %s, %+v", day, metadata)
+	}
+
+	day, metadata = calendar.Apply(time.Date(2024, 12, 22, 0, 0, 0, 0, time.UTC), ConventionPrevious)
+	if metadata.RolledTo != "2024-12-20" {
+		t.Errorf("Apply(previous) of a Sunday = %s, %+v, want Friday", day, metadata)
+	}
+
+	day, metadata = calendar.Apply(time.Date(2024, 12, 22, 0, 0, 0, 0, time.UTC), ConventionNone)
+	if !day.Equal(time.Date(2024, 12, 22, 0, 0, 0, 0, time.UTC)) || !metadata.NonTradingDay || metadata.RolledTo != "" || metadata.Convention != ConventionNone {
+		t.Errorf("Apply(none) = %s, %+v, want the date marked", day, metadata)
+	}
+
+	day, metadata = calendar.Apply(time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC), ConventionPrevious)
+	if !day.Equal(time.Date(2024, 12, 23, 0, 0, 0, 0, time.UTC)) || metadata.NonTradingDay || metadata.RolledTo != "" {
+		t.Errorf("Apply(previous) of a trading day = %s, %+v, want it unchanged", day, metadata)
+	}
+}
+
+func TestNew(t *testing.T) {
+	invalid := []config.MarketCalendarConfig{
+		{Weekend: []string{"caturday"}},
+		{Holidays: []string{"25/12/2024"}},
+		{Convention: "following"},
+		{Weekend: []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}},
+	}
+	for _, configuration := range invalid {
+		if _, err := New(configuration); err == nil {
+			t.Errorf("New(%+v) should fail", configuration)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package capture records a sample of API requests and their responses, bodies included,
+// for diagnosing reports of unexpected responses without packet captures. Bodies are cut
+// at a size cap and credentials are redacted before anything is kept.
+package capture
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// redactedHeaders are the headers carrying credentials
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Admin-Key":         true,
+}
+
+// secretNames are the JSON fields and form or query parameters holding credentials
+const secretNames = `api_?key|admin_?key|client_secret|secret|password|access_token|refresh_token|token`
+
+var (
+	jsonSecretPattern = regexp.MustCompile(`(?i)"(` + secretNames + `)"(\s*):(\s*)"(?:[^"\\]|\\.)*"?`)
+	formSecretPattern = regexp.MustCompile(`(?i)(^|[&?])(` + secretNames + `)=[^&]*`)
+)
+
+// minSecretLength is the length below which credential values are not searched for in
+// bodies, as short values would mangle unrelated text
+const minSecretLength = 8
+
+// Exchange is a request and its response as the middleware saw them, before redaction
+type Exchange struct {
+	Request           *http.Request
+	RequestID         string
+	ClientIP          string
+	KeyID             string
+	At                time.Time
+	Duration          time.Duration
+	RequestBody       []byte
+	RequestTruncated  bool
+	Status            int
+	ResponseHeader    http.Header
+	ResponseBody      []byte
+	ResponseTruncated bool
+}
+
+// Recorder keeps the latest captured exchanges. A nil recorder is valid and captures
+// nothing.
+type Recorder struct {
+	sampleRate   float64
+	maxBodyBytes int
+	bufferSize   int
+	random       func() float64
+
+	mutex     sync.Mutex
+	exchanges []models.CapturedExchange // Oldest first
+}
+
+// NewRecorder creates a recorder with the configured sample rate and limits, or returns
+// nil when capture is disabled
+func NewRecorder(configuration config.CaptureConfig) (*Recorder, error) {
+	if !configuration.Enabled {
+		return nil, nil
+	}
+	if configuration.SampleRate <= 0 || configuration.SampleRate > 1 {
+		return nil, fmt.Errorf("DEBUG_CAPTURE_SAMPLE_RATE must be above 0 and at most 1")
+	}
+	if configuration.MaxBodyBytes <= 0 || configuration.BufferSize <= 0 {
+		return nil, fmt.Errorf("DEBUG_CAPTURE_MAX_BODY_BYTES and DEBUG_CAPTURE_BUFFER_SIZE must be positive")
+	}
+
+	return &Recorder{
+		sampleRate:   configuration.SampleRate,
+		maxBodyBytes: configuration.MaxBodyBytes,
+		bufferSize:   configuration.BufferSize,
+		random:       rand.Float64,
+	}, nil
+}
+
+// Sampled decides whether to capture a request
+func (recorder *Recorder) Sampled() bool {
+	return recorder != nil && recorder.random() < recorder.sampleRate
+}
+
+// MaxBodyBytes returns how many bytes of each body are kept
+func (recorder *Recorder) MaxBodyBytes() int {
+	if recorder == nil {
+		return 0
+	}
+	return recorder.maxBodyBytes
+}
+
+// Record redacts an exchange and keeps it, dropping the oldest beyond the buffer size
+func (recorder *Recorder) Record(exchange Exchange) {
+	if recorder == nil {
+		return
+	}
+	secrets := credentials(exchange.Request)
+	captured := models.CapturedExchange{
+		RequestID:             exchange.RequestID,
+		At:                    exchange.At,
+		Method:                exchange.Request.Method,
+		Path:                  redactText(formSecretPattern.ReplaceAllString(exchange.Request.URL.RequestURI(), "$1$2="+config.Redacted), secrets),
+		ClientIP:              exchange.ClientIP,
+		KeyID:                 exchange.KeyID,
+		Status:                exchange.Status,
+		DurationMS:            float64(exchange.Duration.Microseconds()) / 1000,
+		RequestHeaders:        redactHeaders(exchange.Request.Header),
+		RequestBody:           redactBody(exchange.RequestBody, exchange.RequestTruncated, secrets),
+		RequestBodyTruncated:  exchange.RequestTruncated,
+		ResponseHeaders:       redactHeaders(exchange.ResponseHeader),
+		ResponseBody:          redactBody(exchange.ResponseBody, exchange.ResponseTruncated, secrets),
+		ResponseBodyTruncated: exchange.ResponseTruncated,
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.exchanges = append(recorder.exchanges, captured)
+	if len(recorder.exchanges) > recorder.bufferSize {
+		recorder.exchanges = recorder.exchanges[len(recorder.exchanges)-recorder.bufferSize:]
+	}
+}
+
+// List returns the captured exchanges, newest first, or those of one request ID
+func (recorder *Recorder) List(requestID string) []models.CapturedExchange {
+	exchanges := []models.CapturedExchange{}
+	if recorder == nil {
+		return exchanges
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	for i := len(recorder.exchanges) - 1; i >= 0; i-- {
+		if requestID == "" || recorder.exchanges[i].RequestID == requestID {
+			exchanges = append(exchanges, recorder.exchanges[i])
+		}
+	}
+	return exchanges
+}
+
+// Clear drops the captured exchanges, returning how many there were
+func (recorder *Recorder) Clear() int {
+	if recorder == nil {
+		return 0
+	}
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	cleared := len(recorder.exchanges)
+	recorder.exchanges = nil
+	return cleared
+}
+
+// credentials returns the credential values a request presents, to redact wherever they
+// are echoed
+func credentials(request *http.Request) []string {
+	var secrets []string
+	for _, header := range []string{"X-API-Key", "X-Admin-Key"} {
+		if value := request.Header.Get(header); len(value) >= minSecretLength {
+			secrets = append(secrets, value)
+		}
+	}
+	if _, token, found := strings.Cut(request.Header.Get("Authorization"), " "); found && len(token) >= minSecretLength {
+		secrets = append(secrets, token)
+	}
+	if _, password, basic := request.BasicAuth(); basic && len(password) >= minSecretLength {
+		secrets = append(secrets, password)
+	}
+	return secrets
+}
+
+// redactHeaders flattens headers, replacing the values of those carrying credentials
+func redactHeaders(header http.Header) map[string]string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	flattened := make(map[string]string, len(header))
+	for _, name := range names {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			flattened[name] = config.Redacted
+			continue
+		}
+		flattened[name] = strings.Join(header[name], ", ")
+	}
+	return flattened
+}
+
+// redactBody returns a body as text with credentials replaced. A body cut at the size cap
+// loses its last partial character; other bodies that are not UTF-8 text are summarized.
+func redactBody(body []byte, truncated bool, secrets []string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if truncated {
+		for trimmed := 0; trimmed < utf8.UTFMax && len(body) > 0 && !utf8.Valid(body); trimmed++ {
+			body = body[:len(body)-1]
+		}
+	}
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("[%d bytes of binary data]", len(body))
+	}
+
+	text := jsonSecretPattern.ReplaceAllString(string(body), `"$1"$2:$3"`+config.Redacted+`"`)
+	text = formSecretPattern.ReplaceAllString(text, "$1$2="+config.Redacted)
+	return redactText(text, secrets)
+}
+
+// redactText replaces every occurrence of the credential values
+func redactText(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, config.Redacted)
+	}
+	return text
+}
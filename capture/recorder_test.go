@@ -0,0 +1,145 @@
+package capture
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func testRecorder(t *testing.T, bufferSize int) *Recorder {
+	t.Helper()
+	recorder, err := NewRecorder(config.CaptureConfig{Enabled: true, SampleRate: 0.5, MaxBodyBytes: 16, BufferSize: bufferSize})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	return recorder
+}
+
+func TestRecorder_Redaction(t *testing.T) {
+	recorder := testRecorder(t, 10)
+	request := httptest.NewRequest("POST", "/oauth/token?api_key=acme-secret-key&amount=10", nil)
+	request.Header.Set("X-API-Key", "acme-secret-key")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth("billing", "client-password")
+
+	recorder.Record(Exchange{
+		Request:        request,
+		RequestID:      "req-1",
+		RequestBody:    []byte("grant_type=client_credentials&client_secret=s3cr3t"),
+		Status:         http.StatusOK,
+		ResponseHeader: http.Header{"Set-Cookie": {"session=abc"}, "Content-Type": {"application/json"}},
+		ResponseBody:   []byte(`{"access_token": "eyJhbGciOi", "echo": "acme-secret-key"}`),
+	})
+
+	captured := recorder.List("")[0]
+	if captured.Path != "/oauth/token?api_key="+config.Redacted+"&amount=10" {
+		t.Errorf("Path = %q, want the key redacted", captured.Path)
+	}
+	if captured.RequestHeaders["X-Api-Key"] != config.Redacted || captured.RequestHeaders["Authorization"] != config.Redacted {
+		t.Errorf("RequestHeaders = %v, want the credentials redacted", captured.RequestHeaders)
+	}
+	if captured.RequestHeaders["Accept"] != "application/json" || captured.ResponseHeaders["Set-Cookie"] != config.Redacted {
+		t.Errorf("headers = %v, %v, want only the credentials redacted", captured.RequestHeaders, captured.ResponseHeaders)
+	}
+	if captured.RequestBody != "grant_type=client_credentials&client_secret="+config.Redacted {
+		t.Errorf("RequestBody = %q, want the client secret redacted", captured.RequestBody)
+	}
+	if want := `{"access_token": "` + config.Redacted + `", "echo": "` + config.Redacted + `"}`; captured.ResponseBody != want {
+		t.Errorf("ResponseBody = %q, want %q", captured.ResponseBody, want)
+	}
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      []byte
+		truncated bool
+		want      string
+	}{
+		{name: "empty", body: nil, want: ""},
+		{name: "text", body: []byte(`{"amount": 10}`), want: `{"amount": 10}`},
+		{name: "cut secret", body: []byte(`{"token": "abc`), truncated: true, want: `{"token": "` + config.Redacted + `"`},
+		{name: "cut character", body: []byte("price €")[:7], truncated: true, want: "price "},
+		{name: "binary", body: []byte{0xff, 0xfe, 0x00}, want: "[3 bytes of binary data]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody(tt.body, tt.truncated, nil); got != tt.want {
+				t.Errorf("redactBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorder_Buffer(t *testing.T) {
+	recorder := testRecorder(t, 2)
+	for _, requestID := range []string{"req-1", "req-2", "req-3"} {
+		recorder.Record(Exchange{Request: httptest.NewRequest("GET", "/api/v1/rates", nil), RequestID: requestID, At: time.Now()})
+	}
+
+	captured := recorder.List("")
+	if len(captured) != 2 || captured[0].RequestID != "req-3" || captured[1].RequestID != "req-2" {
+		t.Fatalf("List() = %+v, want the latest two, newest first", captured)
+	}
+	if filtered := recorder.List("req-2"); len(filtered) != 1 || filtered[0].RequestID != "req-2" {
+		t.Errorf("List(req-2) = %+v, want one exchange", filtered)
+	}
+	if cleared := recorder.Clear(); cleared != 2 || len(recorder.List("")) != 0 {
+		t.Errorf("Clear() = %d, want 2 and an empty buffer", cleared)
+	}
+}
+
+func TestRecorder_Sampled(t *testing.T) {
+	recorder := testRecorder(t, 10)
+	for _, tt := range []struct {
+		random float64
+		want   bool
+	}{{0.1, true}, {0.5, false}, {0.9, false}} {
+		recorder.random = func() float64 { return tt.random }
+		if got := recorder.Sampled(); got != tt.want {
+			t.Errorf("Sampled() at %v = %v, want %v", tt.random, got, tt.want)
+		}
+	}
+}
+
+func TestNewRecorder(t *testing.T) {
+	valid := config.CaptureConfig{Enabled: true, SampleRate: 0.01, MaxBodyBytes: 4096, BufferSize: 200}
+	tests := []struct {
+		name    string
+		modify  func(configuration *config.CaptureConfig)
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "valid", modify: func(*config.CaptureConfig) {}},
+		{name: "disabled", modify: func(configuration *config.CaptureConfig) { configuration.Enabled = false }, wantNil: true},
+		{name: "zero sample rate", modify: func(configuration *config.CaptureConfig) { configuration.SampleRate = 0 }, wantNil: true, wantErr: true},
+		{name: "sample rate above 1", modify: func(configuration *config.CaptureConfig) { configuration.SampleRate = 1.5 }, wantNil: true, wantErr: true},
+		{name: "zero buffer", modify: func(configuration *config.CaptureConfig) { configuration.BufferSize = 0 }, wantNil: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configuration := valid
+			tt.modify(&configuration)
+			recorder, err := NewRecorder(configuration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRecorder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (recorder == nil) != tt.wantNil {
+				t.Errorf("NewRecorder() = %v, wantNil %v", recorder, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var recorder *Recorder
+	recorder.Record(Exchange{Request: httptest.NewRequest("GET", "/", nil)})
+	if recorder.Sampled() || len(recorder.List("")) != 0 || recorder.Clear() != 0 {
+		t.Error("nil Recorder sampled or captured requests")
+	}
+}
@@ -0,0 +1,320 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Config holds the settings for a Client
+type Config struct {
+	BaseURL    string        // Service URL, e.g. http://localhost:8081
+	APIKey     string        // Sent as X-API-Key when set
+	AdminKey   string        // Sent as X-Admin-Key on admin requests
+	HTTPClient *http.Client  // Defaults to a client with a 10s timeout
+	MaxRetries int           // Retries after the first attempt for 429, 5xx and network errors
+	RetryDelay time.Duration // Initial backoff, doubled on each retry
+	MaxBackoff time.Duration // Upper bound for any single wait, including Retry-After
+}
+
+// Client is a typed client for the currency exchange service
+type Client struct {
+	configuration Config
+}
+
+// APIError is returned when the service responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Response   models.ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	if e.Response.Message != "" {
+		return fmt.Sprintf("currency exchange service returned %d: %s: %s", e.StatusCode, e.Response.Error, e.Response.Message)
+	}
+	return fmt.Sprintf("currency exchange service returned %d", e.StatusCode)
+}
+
+// New creates a new client, filling in defaults for unset settings
+func New(configuration Config) *Client {
+	if configuration.HTTPClient == nil {
+		configuration.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if configuration.RetryDelay <= 0 {
+		configuration.RetryDelay = 200 * time.Millisecond
+	}
+	if configuration.MaxBackoff <= 0 {
+		configuration.MaxBackoff = 30 * time.Second
+	}
+	if configuration.MaxRetries < 0 {
+		configuration.MaxRetries = 0
+	}
+
+	return &Client{configuration: configuration}
+}
+
+// GetRates returns the latest rates for a base currency
+func (client *Client) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
+	var exchangeRates models.RatesResponse
+	err := client.get(ctx, "/api/v1/rates/"+url.PathEscape(baseCurrency), nil, &exchangeRates)
+	return exchangeRates, err
+}
+
+// Convert converts an amount between two currencies
+func (client *Client) Convert(ctx context.Context, fromCurrency, toCurrency string, amount float64) (models.ConversionResponse, error) {
+	query := url.Values{
+		"from":   {fromCurrency},
+		"to":     {toCurrency},
+		"amount": {strconv.FormatFloat(amount, 'f', -1, 64)},
+	}
+
+	var conversion models.ConversionResponse
+	err := client.get(ctx, "/api/v1/convert", query, &conversion)
+	return conversion, err
+}
+
+// ConvertMany converts an amount into several target currencies in one request
+func (client *Client) ConvertMany(ctx context.Context, fromCurrency string, toCurrencies []string, amount float64) (models.MultiConversionResponse, error) {
+	// The service answers a single target with a plain conversion
+	if len(toCurrencies) == 1 {
+		conversion, err := client.Convert(ctx, fromCurrency, toCurrencies[0], amount)
+		if err != nil {
+			return models.MultiConversionResponse{}, err
+		}
+		return models.MultiConversionResponse{
+			From:        conversion.From,
+			Amount:      conversion.Amount,
+			Timestamp:   conversion.Timestamp,
+			Provider:    conversion.Provider,
+			Conversions: []models.ConversionResponse{conversion},
+		}, nil
+	}
+
+	query := url.Values{
+		"from":   {fromCurrency},
+		"to":     {strings.Join(toCurrencies, ",")},
+		"amount": {strconv.FormatFloat(amount, 'f', -1, 64)},
+	}
+
+	var conversions models.MultiConversionResponse
+	err := client.get(ctx, "/api/v1/convert", query, &conversions)
+	return conversions, err
+}
+
+// GetPairRate returns the rate of a single currency pair and its inverse
+func (client *Client) GetPairRate(ctx context.Context, fromCurrency, toCurrency string) (models.PairRateResponse, error) {
+	var pairRate models.PairRateResponse
+	err := client.get(ctx, "/api/v1/rate", url.Values{"pair": {fromCurrency + "/" + toCurrency}}, &pairRate)
+	return pairRate, err
+}
+
+// GetCurrencies returns the currencies supported by the service
+func (client *Client) GetCurrencies(ctx context.Context) ([]currency.Currency, error) {
+	var response struct {
+		Currencies []currency.Currency `json:"currencies"`
+	}
+	err := client.get(ctx, "/api/v1/currencies", nil, &response)
+	return response.Currencies, err
+}
+
+// GetProviders returns the status of the providers configured on the service
+func (client *Client) GetProviders(ctx context.Context) ([]models.ProviderStatus, error) {
+	var response struct {
+		Providers []models.ProviderStatus `json:"providers"`
+	}
+	err := client.get(ctx, "/api/v1/providers", nil, &response)
+	return response.Providers, err
+}
+
+// GetRefreshHints returns the service's cache TTL and when the rates of each base refresh
+// next, for aligning polling with the refreshes
+func (client *Client) GetRefreshHints(ctx context.Context) (models.RefreshHints, error) {
+	var hints models.RefreshHints
+	err := client.get(ctx, "/api/v1/meta/refresh", nil, &hints)
+	return hints, err
+}
+
+// PurgeCache drops the service's cached rates (requires AdminKey)
+func (client *Client) PurgeCache(ctx context.Context) error {
+	return client.request(ctx, http.MethodDelete, "/admin/v1/cache", nil, nil)
+}
+
+// ListAlerts returns the currently firing alerts (requires AdminKey)
+func (client *Client) ListAlerts(ctx context.Context) ([]models.Alert, error) {
+	var response struct {
+		Alerts []models.Alert `json:"alerts"`
+	}
+	err := client.get(ctx, "/admin/v1/alerts", nil, &response)
+	return response.Alerts, err
+}
+
+// StreamRates polls the rates for a base currency every interval and delivers each
+// response whose rates changed. Both channels are closed when ctx is cancelled; polling
+// errors are delivered without stopping the stream.
+func (client *Client) StreamRates(ctx context.Context, baseCurrency string, interval time.Duration) (<-chan models.RatesResponse, <-chan error) {
+	updates := make(chan models.RatesResponse)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last models.RatesResponse
+		for {
+			exchangeRates, err := client.GetRates(ctx, baseCurrency)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				select {
+				case errs <- err:
+				default: // drop if the consumer is not reading errors
+				}
+			case err == nil && !sameRates(last, exchangeRates):
+				last = exchangeRates
+				select {
+				case updates <- exchangeRates:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+// get performs a GET request with retries and decodes the JSON response into target
+func (client *Client) get(ctx context.Context, path string, query url.Values, target interface{}) error {
+	return client.request(ctx, http.MethodGet, path, query, target)
+}
+
+// request performs a request with retries and decodes the JSON response into target,
+// which may be nil for responses without a body
+func (client *Client) request(ctx context.Context, method, path string, query url.Values, target interface{}) error {
+	requestURL := client.configuration.BaseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	backoff := client.configuration.RetryDelay
+	for attempt := 0; ; attempt++ {
+		wait, err := client.do(ctx, method, requestURL, target)
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || attempt >= client.configuration.MaxRetries {
+			return err
+		}
+
+		if wait == 0 {
+			wait = backoff
+			backoff *= 2
+		}
+		if wait > client.configuration.MaxBackoff {
+			wait = client.configuration.MaxBackoff
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// do performs a single attempt. The returned wait is negative when the error is not
+// retryable, zero to use exponential backoff, or the server-requested delay for 429s.
+func (client *Client) do(ctx context.Context, method, requestURL string, target interface{}) (time.Duration, error) {
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+	if client.configuration.APIKey != "" {
+		request.Header.Set("X-API-Key", client.configuration.APIKey)
+	}
+	if client.configuration.AdminKey != "" {
+		request.Header.Set("X-Admin-Key", client.configuration.AdminKey)
+	}
+
+	response, err := client.configuration.HTTPClient.Do(request)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		apiError := &APIError{StatusCode: response.StatusCode}
+		_ = json.Unmarshal(body, &apiError.Response)
+
+		switch {
+		case response.StatusCode == http.StatusTooManyRequests:
+			return rateLimitWait(response.Header, time.Now()), apiError
+		case response.StatusCode >= 500:
+			return 0, apiError
+		default:
+			return -1, apiError
+		}
+	}
+
+	if target == nil {
+		return 0, nil
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return -1, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return 0, nil
+}
+
+// rateLimitWait derives how long to wait from Retry-After or X-RateLimit-Reset headers
+func rateLimitWait(header http.Header, now time.Time) time.Duration {
+	if retryAfter, err := strconv.Atoi(header.Get("Retry-After")); err == nil && retryAfter >= 0 {
+		return time.Duration(retryAfter) * time.Second
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if wait := time.Unix(reset, 0).Sub(now); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// sameRates reports whether two responses carry identical rates
+func sameRates(previous, current models.RatesResponse) bool {
+	if previous.Base != current.Base || len(previous.Rates) != len(current.Rates) {
+		return false
+	}
+	for currencyCode, rate := range current.Rates {
+		if previousRate, found := previous.Rates[currencyCode]; !found || previousRate != rate {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_GetRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rates/EUR" {
+			t.Errorf("GetRates() path = %v, want %v", r.URL.Path, "/api/v1/rates/EUR")
+		}
+		if r.Header.Get("X-API-Key") != "secret" {
+			t.Errorf("GetRates() X-API-Key = %v, want %v", r.Header.Get("X-API-Key"), "secret")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "EUR", "timestamp": 1640995200, "rates": {"USD": 1.18}, "provider": "test"}`))
+	}))
+	defer server.Close()
+
+	client := New(Config{BaseURL: server.URL, APIKey: "secret"})
+
+	result, err := client.GetRates(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if result.Base != "EUR" || result.Rates["USD"] != 1.18 {
+		t.Errorf("GetRates() = %+v", result)
+	}
+}
+
+func TestClient_Convert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("from") != "USD" || query.Get("to") != "EUR" || query.Get("amount") != "12.5" {
+			t.Errorf("Convert() query = %v", query)
+		}
+		w.Write([]byte(`{"from": "USD", "to": "EUR", "amount": 12.5, "mid_rate": 0.8, "rate": 0.8, "converted": 10}`))
+	}))
+	defer server.Close()
+
+	result, err := New(Config{BaseURL: server.URL}).Convert(context.Background(), "USD", "EUR", 12.5)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if result.Converted != 10 {
+		t.Errorf("Convert() Converted = %v, want %v", result.Converted, 10)
+	}
+}
+
+func TestClient_ConvertMany(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if to := r.URL.Query().Get("to"); to != "EUR,GBP" {
+			t.Errorf("ConvertMany() to = %v, want EUR,GBP", to)
+		}
+		w.Write([]byte(`{"from": "USD", "amount": 10, "conversions": [{"to": "EUR", "converted": 8}, {"to": "GBP", "converted": 7}]}`))
+	}))
+	defer server.Close()
+
+	result, err := New(Config{BaseURL: server.URL}).ConvertMany(context.Background(), "USD", []string{"EUR", "GBP"}, 10)
+	if err != nil {
+		t.Fatalf("ConvertMany() error = %v", err)
+	}
+	if len(result.Conversions) != 2 || result.Conversions[1].Converted != 7 {
+		t.Errorf("ConvertMany() = %+v", result)
+	}
+}
+
+func TestClient_GetPairRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/rate" || r.URL.Query().Get("pair") != "EUR/USD" {
+			t.Errorf("GetPairRate() request = %v", r.URL)
+		}
+		w.Write([]byte(`{"pair": "EUR/USD", "rate": 1.25, "inverse": 0.8, "provider": "erapi"}`))
+	}))
+	defer server.Close()
+
+	result, err := New(Config{BaseURL: server.URL}).GetPairRate(context.Background(), "EUR", "USD")
+	if err != nil {
+		t.Fatalf("GetPairRate() error = %v", err)
+	}
+	if result.Rate != 1.25 || result.Inverse != 0.8 {
+		t.Errorf("GetPairRate() = %+v", result)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxRetries   int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{name: "retries server errors", statuses: []int{503, 502, 200}, maxRetries: 3, wantAttempts: 3},
+		{name: "retries rate limits", statuses: []int{429, 200}, maxRetries: 3, wantAttempts: 2},
+		{name: "gives up after max retries", statuses: []int{503, 503, 503}, maxRetries: 1, wantAttempts: 2, wantErr: true},
+		{name: "does not retry client errors", statuses: []int{400, 200}, maxRetries: 3, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := atomic.AddInt32(&attempts, 1)
+				status := tt.statuses[attempt-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.85}}`))
+					return
+				}
+				w.Write([]byte(`{"error": "failure", "message": "details", "code": 500}`))
+			}))
+			defer server.Close()
+
+			client := New(Config{BaseURL: server.URL, MaxRetries: tt.maxRetries, RetryDelay: time.Millisecond})
+
+			_, err := client.GetRates(context.Background(), "USD")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetRates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("GetRates() attempts = %v, want %v", attempts, tt.wantAttempts)
+			}
+
+			var apiError *APIError
+			if tt.wantErr && !errors.As(err, &apiError) {
+				t.Errorf("GetRates() error type = %T, want *APIError", err)
+			}
+		})
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "retry after", header: http.Header{"Retry-After": {"3"}}, want: 3 * time.Second},
+		{name: "reset time", header: http.Header{"X-Ratelimit-Reset": {"1005"}}, want: 5 * time.Second},
+		{name: "reset in past", header: http.Header{"X-Ratelimit-Reset": {"900"}}, want: 0},
+		{name: "no headers", header: http.Header{}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateLimitWait(tt.header, now); got != tt.want {
+				t.Errorf("rateLimitWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_StreamRates(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The rate changes only on the third poll
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.85}}`))
+			return
+		}
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.86}}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	updates, _ := New(Config{BaseURL: server.URL}).StreamRates(ctx, "USD", 5*time.Millisecond)
+
+	first := <-updates
+	second := <-updates
+	if first.Rates["EUR"] != 0.85 || second.Rates["EUR"] != 0.86 {
+		t.Errorf("StreamRates() updates = %v, %v; want 0.85 then 0.86", first.Rates, second.Rates)
+	}
+	if polled := atomic.LoadInt32(&calls); polled < 3 {
+		t.Errorf("StreamRates() polled %v times, want at least 3", polled)
+	}
+
+	cancel()
+	for range updates {
+	}
+}
+
+func TestClient_AdminRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Admin-Key") != "admin" {
+			t.Errorf("%s X-Admin-Key = %v, want %v", r.URL.Path, r.Header.Get("X-Admin-Key"), "admin")
+		}
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/admin/v1/cache":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/admin/v1/alerts":
+			w.Write([]byte(`{"alerts": [{"rule": "stale_rates", "severity": "warning", "value": 900, "threshold": 600}]}`))
+		case r.URL.Path == "/api/v1/providers":
+			w.Write([]byte(`{"providers": [{"name": "frankfurter", "enabled": true, "priority": 3}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New(Config{BaseURL: server.URL, AdminKey: "admin"})
+	ctx := context.Background()
+
+	if err := client.PurgeCache(ctx); err != nil {
+		t.Errorf("PurgeCache() error = %v", err)
+	}
+
+	alerts, err := client.ListAlerts(ctx)
+	if err != nil {
+		t.Fatalf("ListAlerts() error = %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Rule != "stale_rates" || alerts[0].Threshold != 600 {
+		t.Errorf("ListAlerts() = %+v", alerts)
+	}
+
+	providers, err := client.GetProviders(ctx)
+	if err != nil {
+		t.Fatalf("GetProviders() error = %v", err)
+	}
+	if len(providers) != 1 || providers[0].Name != "frankfurter" || !providers[0].Enabled {
+		t.Errorf("GetProviders() = %+v", providers)
+	}
+}
+
+func TestClient_GetRefreshHints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/meta/refresh" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"cache_ttl_seconds": 60, "bases": [{"base": "USD", "fetched_at": 1704067200, "next_refresh_at": 1704067260, "poll_interval_seconds": 60}]}`))
+	}))
+	defer server.Close()
+
+	hints, err := New(Config{BaseURL: server.URL}).GetRefreshHints(context.Background())
+	if err != nil {
+		t.Fatalf("GetRefreshHints() error = %v", err)
+	}
+	if hints.CacheTTLSeconds != 60 || len(hints.Bases) != 1 || hints.Bases[0].NextRefreshAt != 1704067260 {
+		t.Errorf("GetRefreshHints() = %+v", hints)
+	}
+}
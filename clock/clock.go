@@ -0,0 +1,29 @@
+// Package clock abstracts reading the current time, so cache expiry and token refill can
+// be tested against a fake clock instead of sleeping.
+package clock
+
+import "time"
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the wall clock
+var System Clock = systemClock{}
+
+// systemClock reads the time from the operating system
+type systemClock struct{}
+
+// Now returns the current wall clock time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Or returns the clock, or the system clock when it is nil
+func Or(clock Clock) Clock {
+	if clock == nil {
+		return System
+	}
+	return clock
+}
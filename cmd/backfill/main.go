@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/store"
+)
+
+const usage = `Usage: backfill -from YYYY-MM-DD [-to YYYY-MM-DD] [-bases USD,EUR] [-delay 250ms]
+
+Imports daily historical rates from the configured history-capable providers into the
+rate history database (DATABASE_URL). Days that are already stored are skipped, so an
+interrupted backfill resumes where it stopped when run again.
+
+Flags:
+`
+
+// BackfillConfig holds the command-line settings
+type BackfillConfig struct {
+	From  time.Time
+	To    time.Time
+	Bases []string
+	Delay time.Duration
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	backfillConfig, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.DatabaseURL == "" {
+		return errors.New("DATABASE_URL is required")
+	}
+	loggerInstance := logger.New(cfg.LogLevel)
+
+	database, err := store.Open(cfg.DatabaseURL, loggerInstance)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if cfg.DatabaseAutoMigrate {
+		if _, err := database.Migrate(ctx); err != nil {
+			return fmt.Errorf("database migration failed: %w", err)
+		}
+	}
+
+	// Roll recorded history up first so imported days never take the place of days
+	// the service recorded but had not compacted yet
+	if _, err := database.Compact(ctx, cfg.History, time.Now()); err != nil {
+		return fmt.Errorf("history compaction failed: %w", err)
+	}
+
+	ratesService := service.NewRatesService(cfg, loggerInstance)
+	defer ratesService.Close()
+
+	return backfill(ctx, ratesService, database, backfillConfig, out)
+}
+
+// parseFlags validates the command line
+func parseFlags(args []string) (BackfillConfig, error) {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	from := flags.String("from", "", "First day to import (YYYY-MM-DD)")
+	to := flags.String("to", time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02"), "Last day to import (YYYY-MM-DD)")
+	bases := flags.String("bases", "USD", "Comma-separated base currencies")
+	delay := flags.Duration("delay", 250*time.Millisecond, "Pause between provider requests")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return BackfillConfig{}, err
+	}
+
+	backfillConfig := BackfillConfig{Delay: *delay}
+	var err error
+	if backfillConfig.From, err = time.Parse("2006-01-02", *from); err != nil {
+		return BackfillConfig{}, fmt.Errorf("invalid -from %q: use YYYY-MM-DD", *from)
+	}
+	if backfillConfig.To, err = time.Parse("2006-01-02", *to); err != nil {
+		return BackfillConfig{}, fmt.Errorf("invalid -to %q: use YYYY-MM-DD", *to)
+	}
+	if backfillConfig.To.Before(backfillConfig.From) {
+		return BackfillConfig{}, errors.New("-to must not be before -from")
+	}
+	for _, base := range strings.Split(*bases, ",") {
+		if base = strings.ToUpper(strings.TrimSpace(base)); base != "" {
+			backfillConfig.Bases = append(backfillConfig.Bases, base)
+		}
+	}
+	if len(backfillConfig.Bases) == 0 {
+		return BackfillConfig{}, errors.New("no base currencies given")
+	}
+	return backfillConfig, nil
+}
+
+// backfill imports every missing day of every base, reporting progress as it goes.
+// Failed days are reported and left missing so a later run retries them.
+func backfill(ctx context.Context, ratesService *service.RatesService, database *store.Store, backfillConfig BackfillConfig, out io.Writer) error {
+	days := int(backfillConfig.To.Sub(backfillConfig.From).Hours()/24) + 1
+	total := days * len(backfillConfig.Bases)
+	done, imported, skipped, failed := 0, 0, 0, 0
+
+	for _, base := range backfillConfig.Bases {
+		stored, err := database.StoredDays(ctx, base, backfillConfig.From, backfillConfig.To)
+		if err != nil {
+			return err
+		}
+
+		for day := backfillConfig.From; !day.After(backfillConfig.To); day = day.AddDate(0, 0, 1) {
+			done++
+			date := day.Format("2006-01-02")
+			if stored[date] {
+				skipped++
+				continue
+			}
+
+			exchangeRates, err := ratesService.GetHistoricalRates(ctx, base, day)
+			if err == nil {
+				_, err = database.RecordDailyRates(ctx, exchangeRates, day)
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("interrupted after %d of %d days; run again to resume", done-1, total)
+			}
+			if err != nil {
+				failed++
+				fmt.Fprintf(out, "[%d/%d] %s %s: failed: %v\n", done, total, base, date, err)
+			} else {
+				imported++
+				fmt.Fprintf(out, "[%d/%d] %s %s: %d rates from %s\n", done, total, base, date, len(exchangeRates.Rates), exchangeRates.Provider)
+			}
+
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("interrupted after %d of %d days; run again to resume", done, total)
+			case <-time.After(backfillConfig.Delay):
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "Imported %d days, skipped %d already stored, %d failed\n", imported, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d days failed; run again to retry them", failed)
+	}
+	return nil
+}
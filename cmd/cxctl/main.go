@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/client"
+)
+
+const usage = `Usage: cxctl [global flags] <command> [arguments]
+
+Commands:
+  rates [base]                       Show the latest rates for a base currency (default USD)
+  rate FROM/TO                       Show the rate of one pair and its inverse
+  convert -from X -to Y -amount N    Convert an amount between currencies (-to Y,Z for several)
+  providers                          List the configured rate providers
+  cache purge                        Drop the service's cached rates (admin)
+  alerts list                        List firing alerts (admin)
+
+Global flags:
+`
+
+// CLIConfig holds the global settings shared by all commands
+type CLIConfig struct {
+	URL      string
+	APIKey   string
+	AdminKey string
+	Output   string
+	Timeout  time.Duration
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "cxctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	var config CLIConfig
+
+	flags := flag.NewFlagSet("cxctl", flag.ContinueOnError)
+	flags.StringVar(&config.URL, "url", getEnv("CXCTL_URL", "http://localhost:8081"), "Service URL")
+	flags.StringVar(&config.APIKey, "api-key", os.Getenv("CXCTL_API_KEY"), "Tenant API key sent as X-API-Key")
+	flags.StringVar(&config.AdminKey, "admin-key", os.Getenv("CXCTL_ADMIN_KEY"), "Admin key sent as X-Admin-Key")
+	flags.StringVar(&config.Output, "output", getEnv("CXCTL_OUTPUT", "table"), "Output format: table or json")
+	flags.DurationVar(&config.Timeout, "timeout", 10*time.Second, "Request timeout")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if config.Output != "table" && config.Output != "json" {
+		return fmt.Errorf("unknown output format %q (want table or json)", config.Output)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no command given")
+	}
+
+	api := client.New(client.Config{
+		BaseURL:    config.URL,
+		APIKey:     config.APIKey,
+		AdminKey:   config.AdminKey,
+		HTTPClient: &http.Client{Timeout: config.Timeout},
+		MaxRetries: 2,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "rates":
+		return runRates(ctx, api, config, rest, out)
+	case "rate":
+		if len(rest) != 1 {
+			return errors.New("usage: cxctl rate FROM/TO")
+		}
+		return runRate(ctx, api, config, rest[0], out)
+	case "convert":
+		return runConvert(ctx, api, config, rest, out)
+	case "providers":
+		return runProviders(ctx, api, config, out)
+	case "cache":
+		if len(rest) != 1 || rest[0] != "purge" {
+			return errors.New("usage: cxctl cache purge")
+		}
+		return runCachePurge(ctx, api, out)
+	case "alerts":
+		if len(rest) != 1 || rest[0] != "list" {
+			return errors.New("usage: cxctl alerts list")
+		}
+		return runAlertsList(ctx, api, config, out)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func runRates(ctx context.Context, api *client.Client, config CLIConfig, args []string, out io.Writer) error {
+	base := "USD"
+	if len(args) > 0 {
+		base = strings.ToUpper(args[0])
+	}
+
+	rates, err := api.GetRates(ctx, base)
+	if err != nil {
+		return err
+	}
+	if config.Output == "json" {
+		return printJSON(out, rates)
+	}
+
+	currencies := make([]string, 0, len(rates.Rates))
+	for code := range rates.Rates {
+		currencies = append(currencies, code)
+	}
+	sort.Strings(currencies)
+
+	fmt.Fprintf(out, "Base: %s  Provider: %s  Age: %ds\n\n", rates.Base, rates.Provider, rates.AgeSeconds)
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CURRENCY\tRATE")
+	for _, code := range currencies {
+		fmt.Fprintf(writer, "%s\t%g\n", code, rates.Rates[code])
+	}
+	return writer.Flush()
+}
+
+func runRate(ctx context.Context, api *client.Client, config CLIConfig, pair string, out io.Writer) error {
+	from, to, found := strings.Cut(strings.ToUpper(pair), "/")
+	if !found {
+		return errors.New("usage: cxctl rate FROM/TO")
+	}
+
+	pairRate, err := api.GetPairRate(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	if config.Output == "json" {
+		return printJSON(out, pairRate)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PAIR\tRATE\tINVERSE\tPROVIDER\tAGE")
+	fmt.Fprintf(writer, "%s\t%g\t%g\t%s\t%ds\n", pairRate.Pair, pairRate.Rate, pairRate.Inverse, pairRate.Provider, pairRate.AgeSeconds)
+	return writer.Flush()
+}
+
+func runConvert(ctx context.Context, api *client.Client, config CLIConfig, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	from := flags.String("from", "", "Source currency")
+	to := flags.String("to", "", "Target currency, or a comma-separated list of targets")
+	amount := flags.Float64("amount", 1, "Amount to convert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("usage: cxctl convert -from X -to Y[,Z...] [-amount N]")
+	}
+
+	conversions, err := api.ConvertMany(ctx, strings.ToUpper(*from), strings.Split(strings.ToUpper(*to), ","), *amount)
+	if err != nil {
+		return err
+	}
+	if config.Output == "json" {
+		if len(conversions.Conversions) == 1 {
+			return printJSON(out, conversions.Conversions[0])
+		}
+		return printJSON(out, conversions)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "FROM\tTO\tAMOUNT\tRATE\tFEE\tCONVERTED\tPROVIDER")
+	for _, conversion := range conversions.Conversions {
+		fmt.Fprintf(writer, "%s\t%s\t%g\t%g\t%g\t%g\t%s\n",
+			conversion.From, conversion.To, conversion.Amount, conversion.Rate, conversion.Fee, conversion.Converted, conversion.Provider)
+	}
+	return writer.Flush()
+}
+
+func runProviders(ctx context.Context, api *client.Client, config CLIConfig, out io.Writer) error {
+	providers, err := api.GetProviders(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Output == "json" {
+		return printJSON(out, providers)
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tENABLED\tPRIORITY")
+	for _, provider := range providers {
+		fmt.Fprintf(writer, "%s\t%t\t%d\n", provider.Name, provider.Enabled, provider.Priority)
+	}
+	return writer.Flush()
+}
+
+func runCachePurge(ctx context.Context, api *client.Client, out io.Writer) error {
+	if err := api.PurgeCache(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Cache purged")
+	return nil
+}
+
+func runAlertsList(ctx context.Context, api *client.Client, config CLIConfig, out io.Writer) error {
+	alerts, err := api.ListAlerts(ctx)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return errors.New("alerting is not available on this service")
+	}
+	if err != nil {
+		return err
+	}
+	if config.Output == "json" {
+		return printJSON(out, alerts)
+	}
+	if len(alerts) == 0 {
+		fmt.Fprintln(out, "No alerts firing")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "RULE\tSEVERITY\tVALUE\tTHRESHOLD\tFIRED AT\tMESSAGE")
+	for _, alert := range alerts {
+		fmt.Fprintf(writer, "%s\t%s\t%g\t%g\t%s\t%s\n",
+			alert.Rule, alert.Severity, alert.Value, alert.Threshold, alert.FiredAt.Format(time.RFC3339), alert.Message)
+	}
+	return writer.Flush()
+}
+
+func printJSON(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,6 +23,17 @@ type LoadTestConfig struct {
 	TestDuration    time.Duration
 	RampUpDuration  time.Duration
 	ThinkTime       time.Duration
+
+	// Cache TTLs to compare, each set through the admin API before running the scenario
+	CacheTTLs   []time.Duration
+	AdminAPIKey string
+}
+
+// TTLComparison holds the result of running the scenario at one cache TTL
+type TTLComparison struct {
+	CacheTTL      time.Duration
+	Summary       LoadTestSummary
+	UpstreamCalls int64
 }
 
 // LoadTestResult holds the result of a single request
@@ -56,8 +72,19 @@ func main() {
 	flag.DurationVar(&config.TestDuration, "duration", 0, "Test duration (0 = run until all requests complete)")
 	flag.DurationVar(&config.RampUpDuration, "rampup", 5*time.Second, "Ramp-up duration")
 	flag.DurationVar(&config.ThinkTime, "think", 100*time.Millisecond, "Think time between requests")
+	ttls := flag.String("ttls", "", "Comma-separated cache TTLs to compare (e.g. 0s,10s,1m,5m); needs -admin-key")
+	flag.StringVar(&config.AdminAPIKey, "admin-key", os.Getenv("ADMIN_API_KEY"), "Admin API key used to set the cache TTL")
 	flag.Parse()
 
+	if *ttls != "" {
+		cacheTTLs, err := parseTTLs(*ttls)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loadtest: %v\n", err)
+			os.Exit(1)
+		}
+		config.CacheTTLs = cacheTTLs
+	}
+
 	fmt.Printf("Starting load test...\n")
 	fmt.Printf("URL: %s\n", config.URL)
 	fmt.Printf("Concurrent Users: %d\n", config.ConcurrentUsers)
@@ -68,6 +95,16 @@ func main() {
 	fmt.Printf("Test Duration: %v\n", config.TestDuration)
 	fmt.Println()
 
+	if len(config.CacheTTLs) > 0 {
+		comparisons, err := compareCacheTTLs(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "loadtest: %v\n", err)
+			os.Exit(1)
+		}
+		printComparison(comparisons)
+		return
+	}
+
 	// Run load test
 	summary := runLoadTest(config)
 
@@ -276,3 +313,157 @@ func printSummary(summary LoadTestSummary) {
 		fmt.Printf("✅ Throughput: %.2f req/s (good)\n", summary.RequestsPerSecond)
 	}
 }
+
+// parseTTLs parses a comma-separated list of cache TTLs
+func parseTTLs(value string) ([]time.Duration, error) {
+	var ttls []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(part)
+		if err != nil || ttl < 0 || ttl%time.Second != 0 {
+			return nil, fmt.Errorf("invalid cache TTL %q: want whole seconds such as 30s or 5m", part)
+		}
+		ttls = append(ttls, ttl)
+	}
+	return ttls, nil
+}
+
+// compareCacheTTLs runs the scenario once per cache TTL. Before each run the TTL is set
+// and the cache purged through the admin API, so every run starts cold; upstream calls
+// are the growth of the providers' call counters over the run. The original TTL is
+// restored afterwards.
+func compareCacheTTLs(config LoadTestConfig) ([]TTLComparison, error) {
+	if config.AdminAPIKey == "" {
+		return nil, fmt.Errorf("comparing cache TTLs needs -admin-key or ADMIN_API_KEY")
+	}
+	target, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	baseURL := target.Scheme + "://" + target.Host
+	client := &http.Client{Timeout: config.Timeout}
+
+	originalTTL, err := currentCacheTTL(client, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := adminRequest(client, baseURL, config.AdminAPIKey, http.MethodPut, ttlPath(originalTTL)); err != nil {
+			fmt.Fprintf(os.Stderr, "loadtest: failed to restore the cache TTL of %v: %v\n", originalTTL, err)
+		}
+	}()
+
+	comparisons := make([]TTLComparison, 0, len(config.CacheTTLs))
+	for _, cacheTTL := range config.CacheTTLs {
+		fmt.Printf("Running with a cache TTL of %v...\n", cacheTTL)
+		if err := adminRequest(client, baseURL, config.AdminAPIKey, http.MethodPut, ttlPath(cacheTTL)); err != nil {
+			return nil, fmt.Errorf("failed to set the cache TTL: %w", err)
+		}
+		if err := adminRequest(client, baseURL, config.AdminAPIKey, http.MethodDelete, "/admin/v1/cache"); err != nil {
+			return nil, fmt.Errorf("failed to purge the cache: %w", err)
+		}
+
+		callsBefore, err := upstreamCalls(client, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		summary := runLoadTest(config)
+		callsAfter, err := upstreamCalls(client, baseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		comparisons = append(comparisons, TTLComparison{
+			CacheTTL:      cacheTTL,
+			Summary:       summary,
+			UpstreamCalls: callsAfter - callsBefore,
+		})
+	}
+	return comparisons, nil
+}
+
+// ttlPath returns the admin path setting the cache TTL
+func ttlPath(cacheTTL time.Duration) string {
+	return "/admin/v1/cache/ttl?seconds=" + strconv.Itoa(int(cacheTTL/time.Second))
+}
+
+// adminRequest sends an admin API request, failing on any answer but success
+func adminRequest(client *http.Client, baseURL, adminAPIKey, method, path string) error {
+	request, err := http.NewRequest(method, baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Admin-Key", adminAPIKey)
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s answered %d", method, path, resp.StatusCode)
+	}
+	return nil
+}
+
+// currentCacheTTL reads the cache TTL the service runs with from GET /stats
+func currentCacheTTL(client *http.Client, baseURL string) (time.Duration, error) {
+	var stats struct {
+		Cache struct {
+			TTL string `json:"ttl"`
+		} `json:"cache"`
+	}
+	if err := getJSON(client, baseURL+"/stats", &stats); err != nil {
+		return 0, fmt.Errorf("failed to read the cache TTL: %w", err)
+	}
+	return time.ParseDuration(stats.Cache.TTL)
+}
+
+// upstreamCalls returns the provider calls the service has made since startup
+func upstreamCalls(client *http.Client, baseURL string) (int64, error) {
+	var response struct {
+		Providers []struct {
+			Calls int64 `json:"calls"`
+		} `json:"providers"`
+	}
+	if err := getJSON(client, baseURL+"/api/v1/providers", &response); err != nil {
+		return 0, fmt.Errorf("failed to read provider calls: %w", err)
+	}
+	var calls int64
+	for _, provider := range response.Providers {
+		calls += provider.Calls
+	}
+	return calls, nil
+}
+
+// getJSON decodes the JSON answer of a GET request
+func getJSON(client *http.Client, target string, value interface{}) error {
+	resp, err := client.Get(target)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s answered %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
+
+func printComparison(comparisons []TTLComparison) {
+	fmt.Println("\n=== Cache TTL Comparison ===")
+	fmt.Printf("%-10s %10s %10s %14s %12s %12s %12s %10s\n",
+		"TTL", "Requests", "Upstream", "Upstream/1k", "Avg", "95th", "99th", "Errors")
+	for _, comparison := range comparisons {
+		summary := comparison.Summary
+		var perThousand float64
+		if summary.TotalRequests > 0 {
+			perThousand = float64(comparison.UpstreamCalls) / float64(summary.TotalRequests) * 1000
+		}
+		fmt.Printf("%-10v %10d %10d %14.1f %12v %12v %12v %9.2f%%\n",
+			comparison.CacheTTL, summary.TotalRequests, comparison.UpstreamCalls, perThousand,
+			summary.AverageResponseTime.Round(time.Microsecond), summary.ResponseTime95th.Round(time.Microsecond),
+			summary.ResponseTime99th.Round(time.Microsecond), summary.ErrorRate)
+	}
+}
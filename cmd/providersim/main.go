@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: providersim [-addr :9090] [-latency 50ms] [-jitter 50ms] [-volatility 0.005]
+
+Emulates the four supported exchange rate providers so the service can run offline.
+Rates drift in a random walk, each provider answers in its own format under its own
+path, and latency and failures can be changed per provider through the admin API.
+
+Point the service at it with PROFILE=dev, or with:
+
+  EXCHANGE_RATE_API_BASE_URL=http://localhost:9090/erapi/v6/latest
+  OPEN_EXCHANGE_RATES_BASE_URL=http://localhost:9090/openexchangerates/api/latest.json
+  FRANKFURTER_API_BASE_URL=http://localhost:9090/frankfurter/latest
+  EXCHANGE_RATE_HOST_BASE_URL=http://localhost:9090/exchangeratehost/latest
+
+Flags:
+`
+
+// SimulatorConfig holds the command-line settings
+type SimulatorConfig struct {
+	Address    string
+	Latency    time.Duration
+	Jitter     time.Duration
+	Volatility float64
+	Tick       time.Duration
+	Status     int
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "providersim: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	simulatorConfig, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	simulator := NewSimulator(simulatorConfig.Volatility, ProviderState{
+		LatencyMS: int(simulatorConfig.Latency / time.Millisecond),
+		JitterMS:  int(simulatorConfig.Jitter / time.Millisecond),
+		Status:    simulatorConfig.Status,
+	})
+	go simulator.Run(simulatorConfig.Tick, ctx.Done())
+
+	server := &http.Server{
+		Addr:              simulatorConfig.Address,
+		Handler:           simulator,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	fmt.Fprintf(out, "Simulating providers on %s:\n", simulatorConfig.Address)
+	names := make([]string, 0, len(providerRoutes))
+	for name := range providerRoutes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-18s /%s/\n", name, name)
+	}
+	fmt.Fprintln(out, "Admin API at /admin/providers and /admin/reset")
+
+	select {
+	case err := <-serverErrors:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func parseFlags(args []string) (SimulatorConfig, error) {
+	var simulatorConfig SimulatorConfig
+
+	flags := flag.NewFlagSet("providersim", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&simulatorConfig.Address, "addr", ":9090", "Listen address")
+	flags.DurationVar(&simulatorConfig.Latency, "latency", 50*time.Millisecond, "Latency of every answer")
+	flags.DurationVar(&simulatorConfig.Jitter, "jitter", 50*time.Millisecond, "Random extra latency of up to this much")
+	flags.Float64Var(&simulatorConfig.Volatility, "volatility", 0.005, "Standard deviation of the daily relative rate change")
+	flags.DurationVar(&simulatorConfig.Tick, "tick", time.Second, "Interval between rate moves")
+	flags.IntVar(&simulatorConfig.Status, "status", http.StatusServiceUnavailable, "Status code of simulated failures")
+	if err := flags.Parse(args); err != nil {
+		return simulatorConfig, err
+	}
+
+	switch {
+	case simulatorConfig.Latency < 0 || simulatorConfig.Jitter < 0:
+		return simulatorConfig, errors.New("-latency and -jitter must not be negative")
+	case simulatorConfig.Volatility < 0:
+		return simulatorConfig, errors.New("-volatility must not be negative")
+	case simulatorConfig.Tick <= 0:
+		return simulatorConfig, errors.New("-tick must be positive")
+	case simulatorConfig.Status < 400 || simulatorConfig.Status > 599:
+		return simulatorConfig, errors.New("-status must be an error status between 400 and 599")
+	}
+	return simulatorConfig, nil
+}
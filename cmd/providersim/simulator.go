@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+)
+
+// referenceRates are the units of each currency per US dollar the random walk starts from
+var referenceRates = map[string]float64{
+	"USD": 1, "EUR": 0.92, "GBP": 0.79, "JPY": 149.5, "CHF": 0.88, "CAD": 1.36,
+	"AUD": 1.52, "NZD": 1.64, "CNY": 7.24, "HKD": 7.82, "SGD": 1.34, "SEK": 10.6,
+	"NOK": 10.7, "DKK": 6.87, "PLN": 4.02, "CZK": 22.9, "HUF": 356, "TRY": 30.2,
+	"INR": 83.2, "KRW": 1320, "MXN": 17.1, "BRL": 4.95, "ZAR": 18.7, "ILS": 3.68,
+}
+
+// historyAmplitude is how far historical rates swing around the reference rates
+const historyAmplitude = 0.05
+
+// providerRoutes maps the path prefix of each simulated provider to its response format
+var providerRoutes = map[string]testsupport.Format{
+	"erapi":             testsupport.FormatERAPI,
+	"openexchangerates": testsupport.FormatOpenExchangeRates,
+	"frankfurter":       testsupport.FormatFrankfurter,
+	"exchangeratehost":  testsupport.FormatExchangeRateHost,
+}
+
+// ProviderState is the behaviour of one simulated provider and its request counters
+type ProviderState struct {
+	Name      string  `json:"name"`
+	LatencyMS int     `json:"latency_ms"` // Added to every answer
+	JitterMS  int     `json:"jitter_ms"`  // Random extra latency of up to this much
+	Down      bool    `json:"down"`       // Fail every request
+	ErrorRate float64 `json:"error_rate"` // Fraction of requests failed at random
+	Status    int     `json:"status"`     // Status of failed requests
+	Malformed bool    `json:"malformed"`  // Answer with truncated JSON
+	Requests  int64   `json:"requests"`   // Requests since startup
+	Failures  int64   `json:"failures"`   // Requests answered with a failure since startup
+}
+
+// providerUpdate changes the fields of a provider state that are set
+type providerUpdate struct {
+	LatencyMS *int     `json:"latency_ms"`
+	JitterMS  *int     `json:"jitter_ms"`
+	Down      *bool    `json:"down"`
+	ErrorRate *float64 `json:"error_rate"`
+	Status    *int     `json:"status"`
+	Malformed *bool    `json:"malformed"`
+}
+
+// Simulator serves drifting rates in the formats of the supported providers, with
+// latency and failures set per provider through its admin API
+type Simulator struct {
+	volatility float64 // Standard deviation of the daily relative rate change
+	defaults   ProviderState
+
+	mutex     sync.Mutex
+	random    *rand.Rand
+	rates     map[string]float64 // Units per US dollar
+	updated   time.Time
+	providers map[string]*ProviderState
+}
+
+// NewSimulator returns a simulator at the reference rates, with every provider answering
+// with the default latency
+func NewSimulator(volatility float64, defaults ProviderState) *Simulator {
+	simulator := &Simulator{
+		volatility: volatility,
+		defaults:   defaults,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		rates:      make(map[string]float64, len(referenceRates)),
+		updated:    time.Now(),
+		providers:  make(map[string]*ProviderState, len(providerRoutes)),
+	}
+	for code, rate := range referenceRates {
+		simulator.rates[code] = rate
+	}
+	simulator.Reset()
+	return simulator
+}
+
+// Reset puts every provider back to the default behaviour, keeping the counters
+func (simulator *Simulator) Reset() {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	for name := range providerRoutes {
+		state := simulator.defaults
+		state.Name = name
+		if existing, found := simulator.providers[name]; found {
+			state.Requests, state.Failures = existing.Requests, existing.Failures
+		}
+		simulator.providers[name] = &state
+	}
+}
+
+// Step moves every rate by a random step scaled to the time since the last step, so the
+// rates follow a random walk with the configured daily volatility
+func (simulator *Simulator) Step(now time.Time) {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	elapsed := now.Sub(simulator.updated)
+	if elapsed <= 0 {
+		return
+	}
+	deviation := simulator.volatility * math.Sqrt(elapsed.Hours()/24)
+	for code, rate := range simulator.rates {
+		if code != "USD" {
+			simulator.rates[code] = rate * math.Exp(deviation*simulator.random.NormFloat64())
+		}
+	}
+	simulator.updated = now
+}
+
+// Run steps the rates every interval until stop is closed
+func (simulator *Simulator) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			simulator.Step(now)
+		}
+	}
+}
+
+// quote returns the current rates of the base, or false for an unknown base
+func (simulator *Simulator) quote(base string) (testsupport.Quote, bool) {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	baseRate, found := simulator.rates[base]
+	if !found {
+		return testsupport.Quote{}, false
+	}
+	rates := make(map[string]float64, len(simulator.rates))
+	for code, rate := range simulator.rates {
+		rates[code] = rate / baseRate
+	}
+	return testsupport.Quote{Base: base, Rates: rates, Time: simulator.updated}, true
+}
+
+// historicalQuote returns the rates of the base on a past day. They swing around the
+// reference rates by a fixed amount per day and currency, so a day always answers the
+// same rates.
+func historicalQuote(base string, day time.Time) (testsupport.Quote, bool) {
+	if _, found := referenceRates[base]; !found {
+		return testsupport.Quote{}, false
+	}
+
+	dayNumber := float64(day.Unix() / 86400)
+	swung := make(map[string]float64, len(referenceRates))
+	for code, rate := range referenceRates {
+		if code == "USD" {
+			swung[code] = rate
+			continue
+		}
+		hash := fnv.New32a()
+		hash.Write([]byte(code))
+		phase := float64(hash.Sum32()%360) * math.Pi / 180
+		swung[code] = rate * (1 + historyAmplitude*math.Sin(dayNumber/30+phase))
+	}
+
+	rates := make(map[string]float64, len(swung))
+	for code, rate := range swung {
+		rates[code] = rate / swung[base]
+	}
+	return testsupport.Quote{Base: base, Rates: rates, Time: day.Add(16 * time.Hour)}, true
+}
+
+// ServeHTTP answers the providers under their path prefix and the admin API under /admin/
+func (simulator *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if name == "admin" {
+		simulator.serveAdmin(w, r, rest)
+		return
+	}
+
+	format, found := providerRoutes[name]
+	if !found {
+		writeError(w, http.StatusNotFound, "unknown provider "+name)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	simulator.serveProvider(w, r, name, format, rest)
+}
+
+// serveProvider answers a rates request in the provider's format after its latency,
+// unless the provider is set to fail it
+func (simulator *Simulator) serveProvider(w http.ResponseWriter, r *http.Request, name string, format testsupport.Format, path string) {
+	state, fail := simulator.admit(name)
+
+	delay := time.Duration(state.LatencyMS) * time.Millisecond
+	if state.JitterMS > 0 {
+		simulator.mutex.Lock()
+		delay += time.Duration(simulator.random.Intn(state.JitterMS+1)) * time.Millisecond
+		simulator.mutex.Unlock()
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fail {
+		writeError(w, state.Status, "simulated provider failure")
+		return
+	}
+	if state.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.9`))
+		return
+	}
+
+	base, day, ok := parseRatesRequest(format, path, r)
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+		return
+	}
+
+	var quote testsupport.Quote
+	var found bool
+	if day.IsZero() {
+		quote, found = simulator.quote(base)
+	} else if day.After(time.Now()) {
+		writeError(w, http.StatusBadRequest, "date is in the future")
+		return
+	} else {
+		quote, found = historicalQuote(base, day)
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "unsupported base currency "+base)
+		return
+	}
+	if format == testsupport.FormatFrankfurter {
+		delete(quote.Rates, base)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(format.Body(quote))
+}
+
+// admit counts the request and decides whether it fails, returning the provider's state
+func (simulator *Simulator) admit(name string) (ProviderState, bool) {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	state := simulator.providers[name]
+	state.Requests++
+	fail := state.Down || (state.ErrorRate > 0 && simulator.random.Float64() < state.ErrorRate)
+	if fail {
+		state.Failures++
+	}
+	return *state, fail
+}
+
+// parseRatesRequest returns the base and, for historical requests, the day a request asks
+// for, using the URL layout of the provider:
+//
+//	erapi:             /erapi/v6/latest/USD
+//	openexchangerates: /openexchangerates/api/latest.json?base=USD
+//	                   /openexchangerates/api/historical/2024-01-31.json?base=USD
+//	frankfurter:       /frankfurter/latest?from=USD, /frankfurter/2024-01-31?from=USD
+//	exchangeratehost:  /exchangeratehost/latest?base=USD, /exchangeratehost/2024-01-31?base=USD
+func parseRatesRequest(format testsupport.Format, path string, r *http.Request) (string, time.Time, bool) {
+	query := r.URL.Query()
+	base := strings.ToUpper(query.Get("base"))
+	if base == "" {
+		base = "USD"
+	}
+
+	var dayValue string
+	switch format {
+	case testsupport.FormatERAPI:
+		code, found := strings.CutPrefix(path, "v6/latest/")
+		if !found || code == "" {
+			return "", time.Time{}, false
+		}
+		return strings.ToUpper(code), time.Time{}, true
+	case testsupport.FormatOpenExchangeRates:
+		if path == "api/latest.json" {
+			return base, time.Time{}, true
+		}
+		historical, found := strings.CutPrefix(path, "api/historical/")
+		if !found || !strings.HasSuffix(historical, ".json") {
+			return "", time.Time{}, false
+		}
+		dayValue = strings.TrimSuffix(historical, ".json")
+	case testsupport.FormatFrankfurter:
+		if from := query.Get("from"); from != "" {
+			base = strings.ToUpper(from)
+		}
+		if path == "latest" {
+			return base, time.Time{}, true
+		}
+		dayValue = path
+	default:
+		if path == "latest" {
+			return base, time.Time{}, true
+		}
+		dayValue = path
+	}
+
+	day, err := time.Parse("2006-01-02", dayValue)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return base, day, true
+}
+
+// serveAdmin answers the admin API:
+//
+//	GET  /admin/providers        the state of every provider
+//	GET  /admin/providers/NAME   the state of one provider
+//	PUT  /admin/providers/NAME   change latency and failures with a JSON body of the fields to set
+//	POST /admin/reset            put every provider back to the defaults
+func (simulator *Simulator) serveAdmin(w http.ResponseWriter, r *http.Request, path string) {
+	switch name, hasName := strings.CutPrefix(path, "providers/"); {
+	case path == "providers" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"providers": simulator.States()})
+	case hasName && r.Method == http.MethodGet:
+		if state, found := simulator.State(name); found {
+			writeJSON(w, http.StatusOK, state)
+		} else {
+			writeError(w, http.StatusNotFound, "unknown provider "+name)
+		}
+	case hasName && r.Method == http.MethodPut:
+		var update providerUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+			return
+		}
+		state, err := simulator.Update(name, update)
+		switch {
+		case errors.Is(err, errUnknownProvider):
+			writeError(w, http.StatusNotFound, "unknown provider "+name)
+		case err != nil:
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeJSON(w, http.StatusOK, state)
+		}
+	case path == "reset" && r.Method == http.MethodPost:
+		simulator.Reset()
+		writeJSON(w, http.StatusOK, map[string]interface{}{"providers": simulator.States()})
+	default:
+		writeError(w, http.StatusNotFound, "unknown admin endpoint")
+	}
+}
+
+// errUnknownProvider is returned for updates of providers the simulator does not serve
+var errUnknownProvider = errors.New("unknown provider")
+
+// States returns the state of every provider, by name
+func (simulator *Simulator) States() []ProviderState {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	states := make([]ProviderState, 0, len(simulator.providers))
+	for _, state := range simulator.providers {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// State returns the state of one provider
+func (simulator *Simulator) State(name string) (ProviderState, bool) {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	state, found := simulator.providers[name]
+	if !found {
+		return ProviderState{}, false
+	}
+	return *state, true
+}
+
+// Update applies the set fields of the update to a provider
+func (simulator *Simulator) Update(name string, update providerUpdate) (ProviderState, error) {
+	simulator.mutex.Lock()
+	defer simulator.mutex.Unlock()
+
+	state, found := simulator.providers[name]
+	if !found {
+		return ProviderState{}, errUnknownProvider
+	}
+	changed := *state
+	if update.LatencyMS != nil {
+		changed.LatencyMS = *update.LatencyMS
+	}
+	if update.JitterMS != nil {
+		changed.JitterMS = *update.JitterMS
+	}
+	if update.Down != nil {
+		changed.Down = *update.Down
+	}
+	if update.ErrorRate != nil {
+		changed.ErrorRate = *update.ErrorRate
+	}
+	if update.Status != nil {
+		changed.Status = *update.Status
+	}
+	if update.Malformed != nil {
+		changed.Malformed = *update.Malformed
+	}
+
+	switch {
+	case changed.LatencyMS < 0 || changed.JitterMS < 0:
+		return ProviderState{}, errors.New("latency_ms and jitter_ms must not be negative")
+	case changed.ErrorRate < 0 || changed.ErrorRate > 1:
+		return ProviderState{}, errors.New("error_rate must be between 0 and 1")
+	case changed.Status < 400 || changed.Status > 599:
+		return ProviderState{}, errors.New("status must be an error status between 400 and 599")
+	}
+	*state = changed
+	return changed, nil
+}
+
+// writeJSON writes the value as a JSON answer
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a provider-style JSON error
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"error": true, "status": status, "message": message})
+}
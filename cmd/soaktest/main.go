@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+const usage = `Usage: soaktest [-url http://localhost:8081] [-duration 2h] [-users 20] [-streams 5]
+
+Runs sustained load against the service while sampling GET /debug/runtime, and fails
+when goroutines or heap trend upward beyond the allowed growth. Requests come from a
+rotating set of client IPs and streams are opened and closed continuously, so the rate
+limiter buckets and stream connections churn the whole time.
+
+Flags:
+`
+
+// SoakTestConfig holds the command-line settings
+type SoakTestConfig struct {
+	URL                string
+	Paths              []string
+	Duration           time.Duration
+	Warmup             time.Duration
+	SampleInterval     time.Duration
+	Users              int
+	Clients            int
+	Streams            int
+	StreamHold         time.Duration
+	ThinkTime          time.Duration
+	Timeout            time.Duration
+	MaxGoroutineGrowth int
+	MaxHeapGrowth      float64
+}
+
+// Sample is one reading of the target's runtime, relative to the start of the test
+type Sample struct {
+	Elapsed time.Duration
+	models.RuntimeStats
+}
+
+// LoadCounters counts the load generated so far
+type LoadCounters struct {
+	requests int64
+	failures int64
+	streams  int64
+}
+
+// Trend is the least-squares growth of a sampled value over the measured window
+type Trend struct {
+	Baseline float64
+	Growth   float64
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "soaktest: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	soakConfig, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Soaking %s for %v with %d users over %d client IPs and %d streams\n",
+		soakConfig.URL, soakConfig.Duration, soakConfig.Users, soakConfig.Clients, soakConfig.Streams)
+
+	ctx, cancel := context.WithTimeout(ctx, soakConfig.Duration)
+	defer cancel()
+
+	httpClient := &http.Client{Timeout: soakConfig.Timeout}
+	counters := &LoadCounters{}
+
+	var wg sync.WaitGroup
+	for userID := 0; userID < soakConfig.Users; userID++ {
+		wg.Add(1)
+		go func(uid int) {
+			defer wg.Done()
+			generateRequests(ctx, httpClient, soakConfig, uid, counters)
+		}(userID)
+	}
+	for streamID := 0; streamID < soakConfig.Streams; streamID++ {
+		wg.Add(1)
+		go func(sid int) {
+			defer wg.Done()
+			churnStreams(ctx, soakConfig, sid, counters)
+		}(streamID)
+	}
+
+	samples := sampleRuntime(ctx, httpClient, soakConfig, counters, out)
+	wg.Wait()
+
+	return evaluate(samples, soakConfig, counters, out)
+}
+
+func parseFlags(args []string) (SoakTestConfig, error) {
+	var soakConfig SoakTestConfig
+	var paths string
+
+	flags := flag.NewFlagSet("soaktest", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&soakConfig.URL, "url", "http://localhost:8081", "Base URL of the service")
+	flags.StringVar(&paths, "paths", "/api/v1/rates,/api/v1/rates/EUR,/api/v1/convert?from=USD&to=EUR&amount=100", "Comma-separated request paths, used in turn")
+	flags.DurationVar(&soakConfig.Duration, "duration", 2*time.Hour, "Test duration")
+	flags.DurationVar(&soakConfig.Warmup, "warmup", 5*time.Minute, "Time before samples count toward the trend")
+	flags.DurationVar(&soakConfig.SampleInterval, "sample", 30*time.Second, "Interval between runtime samples")
+	flags.IntVar(&soakConfig.Users, "users", 20, "Number of concurrent request loops")
+	flags.IntVar(&soakConfig.Clients, "clients", 1000, "Number of client IPs requests rotate through")
+	flags.IntVar(&soakConfig.Streams, "streams", 5, "Number of concurrent stream loops")
+	flags.DurationVar(&soakConfig.StreamHold, "stream-hold", 10*time.Second, "How long each stream stays open")
+	flags.DurationVar(&soakConfig.ThinkTime, "think", 50*time.Millisecond, "Think time between requests")
+	flags.DurationVar(&soakConfig.Timeout, "timeout", 30*time.Second, "Request timeout")
+	flags.IntVar(&soakConfig.MaxGoroutineGrowth, "max-goroutine-growth", 50, "Goroutines the trend may add over the measured window")
+	flags.Float64Var(&soakConfig.MaxHeapGrowth, "max-heap-growth", 0.5, "Heap growth the trend may add over the measured window, as a fraction of the baseline")
+	if err := flags.Parse(args); err != nil {
+		return soakConfig, err
+	}
+
+	soakConfig.URL = strings.TrimRight(soakConfig.URL, "/")
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			soakConfig.Paths = append(soakConfig.Paths, path)
+		}
+	}
+
+	switch {
+	case len(soakConfig.Paths) == 0:
+		return soakConfig, errors.New("-paths needs at least one path")
+	case soakConfig.Users < 1 || soakConfig.Clients < 1 || soakConfig.Streams < 0:
+		return soakConfig, errors.New("-users and -clients must be positive and -streams not negative")
+	case soakConfig.SampleInterval <= 0:
+		return soakConfig, errors.New("-sample must be positive")
+	case soakConfig.Warmup >= soakConfig.Duration:
+		return soakConfig, errors.New("-warmup must be shorter than -duration")
+	}
+	return soakConfig, nil
+}
+
+// clientIP returns the n-th of the rotating client IPs, in 10.0.0.0/8
+func clientIP(n int) string {
+	return fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff)
+}
+
+// generateRequests sends requests until the test ends, moving to the next client IP
+// and path with every request
+func generateRequests(ctx context.Context, httpClient *http.Client, soakConfig SoakTestConfig, userID int, counters *LoadCounters) {
+	for n := userID; ctx.Err() == nil; n += soakConfig.Users {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, soakConfig.URL+soakConfig.Paths[n%len(soakConfig.Paths)], nil)
+		if err != nil {
+			return
+		}
+		request.Header.Set("X-Forwarded-For", clientIP(n%soakConfig.Clients))
+
+		response, err := httpClient.Do(request)
+		if err == nil {
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		atomic.AddInt64(&counters.requests, 1)
+		if err != nil || response.StatusCode >= http.StatusInternalServerError {
+			atomic.AddInt64(&counters.failures, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(soakConfig.ThinkTime):
+		}
+	}
+}
+
+// churnStreams opens a rate stream, reads it for the hold time and drops it, over and over
+func churnStreams(ctx context.Context, soakConfig SoakTestConfig, streamID int, counters *LoadCounters) {
+	for n := streamID; ctx.Err() == nil; n += soakConfig.Streams {
+		holdCtx, cancel := context.WithTimeout(ctx, soakConfig.StreamHold)
+		request, err := http.NewRequestWithContext(holdCtx, http.MethodGet, soakConfig.URL+"/api/v1/stream?pairs=EUR/USD,GBP/USD", nil)
+		if err != nil {
+			cancel()
+			return
+		}
+		request.Header.Set("X-Forwarded-For", clientIP(n%soakConfig.Clients))
+
+		// Streams have no client timeout; the hold context closes them
+		if response, err := http.DefaultClient.Do(request); err == nil {
+			atomic.AddInt64(&counters.streams, 1)
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		<-holdCtx.Done()
+		cancel()
+	}
+}
+
+// sampleRuntime reads the target's runtime every sample interval until the test ends
+func sampleRuntime(ctx context.Context, httpClient *http.Client, soakConfig SoakTestConfig, counters *LoadCounters, out io.Writer) []Sample {
+	var samples []Sample
+	start := time.Now()
+	ticker := time.NewTicker(soakConfig.SampleInterval)
+	defer ticker.Stop()
+
+	fmt.Fprintf(out, "%10s %10s %12s %12s %10s %8s %10s %9s\n",
+		"elapsed", "goroutines", "heap_mb", "heap_objects", "buckets", "streams", "requests", "failures")
+	for {
+		stats, err := fetchRuntime(httpClient, soakConfig.URL)
+		if err != nil {
+			fmt.Fprintf(out, "%10v sample failed: %v\n", time.Since(start).Round(time.Second), err)
+		} else {
+			sample := Sample{Elapsed: time.Since(start), RuntimeStats: stats}
+			samples = append(samples, sample)
+			fmt.Fprintf(out, "%10v %10d %12.1f %12d %10d %8d %10d %9d\n",
+				sample.Elapsed.Round(time.Second), stats.Goroutines, float64(stats.HeapAllocBytes)/(1<<20),
+				stats.HeapObjects, stats.LimiterBuckets, stats.StreamConnections,
+				atomic.LoadInt64(&counters.requests), atomic.LoadInt64(&counters.failures))
+		}
+
+		select {
+		case <-ctx.Done():
+			return samples
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchRuntime reads GET /debug/runtime
+func fetchRuntime(httpClient *http.Client, baseURL string) (models.RuntimeStats, error) {
+	var stats models.RuntimeStats
+
+	response, err := httpClient.Get(baseURL + "/debug/runtime")
+	if err != nil {
+		return stats, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return stats, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		return stats, fmt.Errorf("failed to decode runtime stats: %w", err)
+	}
+	return stats, nil
+}
+
+// evaluate fits a trend to the samples taken after the warmup and fails when goroutines
+// or heap grow beyond their allowances over that window
+func evaluate(samples []Sample, soakConfig SoakTestConfig, counters *LoadCounters, out io.Writer) error {
+	var measured []Sample
+	for _, sample := range samples {
+		if sample.Elapsed >= soakConfig.Warmup {
+			measured = append(measured, sample)
+		}
+	}
+
+	fmt.Fprintln(out, "\n=== Soak Test Results ===")
+	fmt.Fprintf(out, "Requests: %d (%d failed), streams opened: %d\n",
+		atomic.LoadInt64(&counters.requests), atomic.LoadInt64(&counters.failures), atomic.LoadInt64(&counters.streams))
+	if len(measured) < 3 {
+		return fmt.Errorf("only %d samples after the warmup, need at least 3", len(measured))
+	}
+
+	goroutines := fitTrend(measured, func(sample Sample) float64 { return float64(sample.Goroutines) })
+	heap := fitTrend(measured, func(sample Sample) float64 { return float64(sample.HeapAllocBytes) })
+	buckets := fitTrend(measured, func(sample Sample) float64 { return float64(sample.LimiterBuckets) })
+	streams := fitTrend(measured, func(sample Sample) float64 { return float64(sample.StreamConnections) })
+
+	fmt.Fprintf(out, "Goroutines: %.0f at baseline, trend %+.1f\n", goroutines.Baseline, goroutines.Growth)
+	fmt.Fprintf(out, "Heap: %.1f MB at baseline, trend %+.1f MB\n", heap.Baseline/(1<<20), heap.Growth/(1<<20))
+	fmt.Fprintf(out, "Limiter buckets: %.0f at baseline, trend %+.1f\n", buckets.Baseline, buckets.Growth)
+	fmt.Fprintf(out, "Stream connections: %.0f at baseline, trend %+.1f\n", streams.Baseline, streams.Growth)
+
+	var failures []string
+	if goroutines.Growth > float64(soakConfig.MaxGoroutineGrowth) {
+		failures = append(failures, fmt.Sprintf("goroutines grew by %.0f, over the allowed %d", goroutines.Growth, soakConfig.MaxGoroutineGrowth))
+	}
+	if heap.Baseline > 0 && heap.Growth > heap.Baseline*soakConfig.MaxHeapGrowth {
+		failures = append(failures, fmt.Sprintf("heap grew by %.0f%%, over the allowed %.0f%%", heap.Growth/heap.Baseline*100, soakConfig.MaxHeapGrowth*100))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("possible leak: %s", strings.Join(failures, "; "))
+	}
+
+	fmt.Fprintln(out, "✅ No upward trend beyond the allowed growth")
+	return nil
+}
+
+// fitTrend fits a least-squares line to a sampled value. Baseline is the line's value
+// at the first sample and Growth its rise up to the last, so a single spike or GC cycle
+// moves the result much less than comparing the first and last samples would.
+func fitTrend(samples []Sample, value func(Sample) float64) Trend {
+	count := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x, y := sample.Elapsed.Seconds(), value(sample)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	first, last := samples[0].Elapsed.Seconds(), samples[len(samples)-1].Elapsed.Seconds()
+	denominator := count*sumXX - sumX*sumX
+	if denominator == 0 {
+		return Trend{Baseline: sumY / count}
+	}
+	slope := (count*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / count
+	return Trend{
+		Baseline: intercept + slope*first,
+		Growth:   slope * (last - first),
+	}
+}
@@ -4,38 +4,564 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Provider types
+const (
+	ProviderTypeHTTP      = "http"      // An exchange rate API
+	ProviderTypeComposite = "composite" // Combines the rates of other providers
+)
+
 // ExchangeRateProvider represents a single exchange rate API provider
 type ExchangeRateProvider struct {
 	Name       string
+	Type       string // ProviderTypeHTTP or ProviderTypeComposite
 	BaseURL    string
-	APIKey     string
+	MirrorURLs []string // Regional mirrors of BaseURL tried in order when it fails
+	APIKey     string   `secret:"true"`
 	Enabled    bool
 	Priority   int // Lower number = higher priority
 	Timeout    time.Duration
 	RetryCount int
 	RetryDelay time.Duration
+
+	// MaxConcurrent caps the provider's in-flight requests; further calls queue for a slot
+	MaxConcurrent int
+
+	// RateLimit is the provider's published request limit, like "1000/month" ("" =
+	// unlimited), and RateLimitBurst the calls that may be made at once within it
+	RateLimit      string
+	RateLimitBurst int
+
+	// InvertedSymbols lists codes the provider quotes as base-per-unit (e.g. USD per ounce
+	// of XAU) rather than units-per-base; their rates are inverted after parsing
+	InvertedSymbols []string
+
+	// FixedBase is the only base currency the provider supports (e.g. USD on the free
+	// openexchangerates tier); other bases are derived from it via cross rates
+	FixedBase string
+
+	// Signing configures HMAC signing of outbound requests (disabled when Key is empty)
+	Signing RequestSigningConfig
+
+	// ForwardRequestID sends the API request's ID as an X-Request-ID header, and passes
+	// through its trace context headers; disable it for providers that reject unknown
+	// headers
+	ForwardRequestID bool
+
+	// Composite providers query Members and combine their rates per currency with Combine:
+	// "mean", "median" or "trimmed_mean", which drops TrimPercent of the rates at each end.
+	// At least MinMembers must answer, and quote a currency, for it to be served.
+	Members     []string
+	Combine     string
+	TrimPercent float64
+	MinMembers  int
+}
+
+// RequestSigningConfig holds the HMAC signing applied to a provider's outbound requests
+type RequestSigningConfig struct {
+	Algorithm       string // hmac-sha256 or hmac-sha512
+	Key             string `secret:"true"` // Shared secret (empty = signing disabled)
+	SignatureHeader string // Header carrying the hex-encoded signature
+	TimestampHeader string // Header carrying the Unix timestamp that was signed
+}
+
+// MarkupConfig holds the markup rules applied to conversions
+type MarkupConfig struct {
+	GlobalBPS float64            // Markup in basis points applied to every pair
+	PairBPS   map[string]float64 // Per-pair overrides keyed by "FROM/TO"
+	FixedFee  float64            // Flat fee deducted before conversion, in units of whichever currency is converted from
+}
+
+// LatencySLOConfig holds the provider latency objective used to demote slow providers
+type LatencySLOConfig struct {
+	P95Threshold     time.Duration // Rolling p95 latency a provider must stay under (0 = disabled)
+	Window           time.Duration // Window the rolling p95 is computed over
+	BreachDuration   time.Duration // How long the SLO must be breached before demotion
+	RecoveryDuration time.Duration // How long the SLO must be met again before restoring
+}
+
+// ProviderStandbyConfig controls how providers in standby are probed and re-enabled
+type ProviderStandbyConfig struct {
+	ProbeInterval time.Duration // How often providers in standby are probed (0 = readiness checks only)
+	Successes     int           // Consecutive successes that re-enable a provider
+}
+
+// LatencyBudgetConfig holds the target p95 latencies that breaches are reported against
+type LatencyBudgetConfig struct {
+	Routes     map[string]time.Duration // Target p95 per route, like "/api/v1/rates/:base"
+	Providers  map[string]time.Duration // Target p95 per provider name
+	Window     time.Duration            // Window the rolling p95 is computed over
+	MinSamples int                      // Calls needed in the window before a budget can be exceeded
+}
+
+// CallBudgetConfig caps outbound provider calls to protect API key quotas
+type CallBudgetConfig struct {
+	Calls  int           // Provider calls allowed per window (0 = unlimited)
+	Window time.Duration // Window the budget is counted over
+}
+
+// DNSConfig controls how provider hostnames are resolved
+type DNSConfig struct {
+	CacheTTL          time.Duration // How long resolved addresses are reused (0 = no caching)
+	ResolveTimeout    time.Duration // Time budget for each resolver attempt
+	FallbackResolvers []string      // host:port resolvers tried in order when the system resolver fails
+}
+
+// EventsConfig controls publishing of rate update events to a message broker
+type EventsConfig struct {
+	Broker               string  // nats, kafka-rest (a Kafka REST proxy), or empty to disable publishing
+	URL                  string  // nats:// or tls:// server URL, or the Kafka REST proxy base URL
+	Topic                string  // Subject or topic events are published to
+	Format               string  // Payload encoding: json or avro
+	MoveThresholdPercent float64 // Publish a move event when a rate changes by at least this much (0 = off)
+}
+
+// MQTTConfig controls publishing of selected pairs to an MQTT broker
+type MQTTConfig struct {
+	URL           string // mqtt://host:port (empty = disabled)
+	ClientID      string
+	Username      string
+	Password      string   `secret:"true"`
+	Pairs         []string // Pairs to publish, e.g. USD/EUR
+	TopicTemplate string   // Topic per pair; {from} and {to} are replaced with the codes
+	QoS           int      // 0 (at most once) or 1 (at least once)
+	Retain        bool     // Retain the last rate so new subscribers get it immediately
+}
+
+// HistoryConfig controls how long stored rate history is kept at each resolution.
+// A zero retention keeps that resolution forever.
+type HistoryConfig struct {
+	RawRetention    time.Duration // Raw snapshots, one row per fetched rate
+	HourlyRetention time.Duration // Hourly OHLC rollups
+	DailyRetention  time.Duration // Daily OHLC rollups
+	CompactInterval time.Duration // How often the compactor rolls up and prunes (0 = disabled)
+}
+
+// DatedRatesConfig controls conversions at past dates the rate history holds no close
+// for, such as weekends and holidays, and at future dates
+type DatedRatesConfig struct {
+	Interpolation string             // none, nearest or linear
+	MaxGap        time.Duration      // Furthest a stored close may be from the requested date
+	ForwardPoints map[string]float64 // Annualised forward points in pips keyed by "FROM/TO"
+}
+
+// StalenessConfig holds how old the rates of a currency may be before they are marked
+// stale, so fast-moving currencies can go stale long before daily fixings do
+type StalenessConfig struct {
+	MaxAge  map[string]time.Duration // Keyed by currency code, or by class (FIAT, METAL, DEFAULT)
+	Refresh bool                     // Refetch cached tables whose requested currencies are stale
+}
+
+// MarketCalendarConfig holds the non-trading days of historical queries
+type MarketCalendarConfig struct {
+	Weekend    []string // Weekday names that are never trading days
+	Holidays   []string // YYYY-MM-DD dates that are not trading days
+	Convention string   // Default treatment of non-trading dates: none or previous
+}
+
+// APIVersionsConfig controls the lifecycle of API v1 now that v2 exists
+type APIVersionsConfig struct {
+	V1Enabled         bool   // Serve /api/v1; when false it answers 410 Gone
+	V1DeprecationDate string // YYYY-MM-DD announced in the Deprecation header (empty = not deprecated)
+	V1SunsetDate      string // YYYY-MM-DD announced in the Sunset header (empty = none)
+}
+
+// UsageConfig controls the per-key and per-endpoint API usage analytics
+type UsageConfig struct {
+	Enabled       bool
+	FlushInterval time.Duration // How often usage is flushed to the database
+}
+
+// QuotaConfig controls the daily and monthly request quotas of tenant API keys
+type QuotaConfig struct {
+	DailyRequests   int           // Default daily quota of tenant keys (0 = unlimited)
+	MonthlyRequests int           // Default monthly quota of tenant keys (0 = unlimited)
+	FlushInterval   time.Duration // How often quota counts are flushed to the database
+}
+
+// AlertingConfig holds the internal alert rules, evaluated every EvaluationInterval, the
+// sinks they notify and the chat webhook notified of provider outages
+type AlertingConfig struct {
+	EvaluationInterval  time.Duration
+	Rules               []AlertRule
+	Sinks               []AlertSink
+	OutageWebhookURL    string `secret:"true"` // Slack or Teams incoming webhook (empty = disabled)
+	OutageWebhookFormat string // slack or teams
+}
+
+// AlertRule fires when a metric stays above its threshold for a duration
+type AlertRule struct {
+	Name      string
+	Metric    string // provider_error_rate, quota_consumption, cache_staleness or request_error_rate
+	Threshold float64
+	For       time.Duration // How long the metric must stay above the threshold (0 = fire at once)
+	Severity  string
+	Sinks     []string // Names of the sinks notified (empty = log)
+}
+
+// AlertSink is a destination of alert notifications
+type AlertSink struct {
+	Name string
+	Type string // log, webhook or slack
+	URL  string `secret:"true"` // Endpoint of webhook and slack sinks
+}
+
+// DigestConfig schedules the daily rates digest and its deliveries
+type DigestConfig struct {
+	Pairs        []string // Pairs summarised, e.g. EUR/USD (empty = disabled)
+	SendAt       string   // UTC time of day the digest is sent, as HH:MM
+	WebhookURL   string   `secret:"true"` // Endpoint the digest is POSTed to as JSON
+	SMTPAddr     string   // host:port of the mail server the digest is emailed through
+	SMTPUsername string
+	SMTPPassword string `secret:"true"`
+	EmailFrom    string
+	EmailTo      []string
+}
+
+// AdmissionConfig caps the API requests served at once, queueing requests beyond the cap
+// per client
+type AdmissionConfig struct {
+	MaxInFlight    int            // Requests served at once (0 = unlimited)
+	QueuePerClient int            // Requests of one client that may wait for a slot
+	QueueSize      int            // Requests of all clients that may wait for a slot
+	QueueTimeout   time.Duration  // How long a request waits for a slot before it is rejected
+	TierWeights    map[string]int // Share of the freed slots per JWT tier claim (default 1)
+}
+
+// AbuseConfig controls the bans of clients and credentials that fail authentication
+// repeatedly, such as key-guessing attacks
+type AbuseConfig struct {
+	Enabled     bool
+	Threshold   int           // Failures within Window that ban a client IP or credential
+	Window      time.Duration // Period failures are counted over
+	BanDuration time.Duration // Length of the first ban; each repeated ban doubles it
+	MaxBan      time.Duration // Longest ban, and how long a subject must stay clean to be forgiven
+	MaxSubjects int           // Client IPs and credentials tracked at once (0 = unlimited)
+	ExemptCIDRs []string      // Client networks never banned, such as health checkers
+}
+
+// CaptureConfig controls the opt-in capture of sampled API requests and responses, bodies
+// included, for debugging
+type CaptureConfig struct {
+	Enabled      bool
+	SampleRate   float64 // Fraction of API requests captured, from 0 to 1
+	MaxBodyBytes int     // Bytes kept of each request and response body
+	BufferSize   int     // Captured requests kept; the oldest is dropped beyond it
+}
+
+// JWTConfig controls bearer-token authentication, an alternative to tenant API keys
+type JWTConfig struct {
+	JWKSURL      string        // JSON Web Key Set of the token issuer (empty = JWTs not accepted)
+	Issuer       string        // Required iss claim (empty = not checked)
+	Audience     string        // Value the aud claim must contain (empty = not checked)
+	JWKSCacheTTL time.Duration // How long fetched keys are used before the key set is refetched
+	ClockSkew    time.Duration // Tolerance of the exp, nbf and iat checks
+	TenantClaim  string        // Claim holding the tenant ID
+	TierClaim    string        // Claim holding the rate limit tier
+}
+
+// OAuthConfig controls the OAuth2 client-credentials token endpoint for machine clients
+type OAuthConfig struct {
+	Clients        []OAuthClient // Clients allowed to request tokens (empty = endpoint disabled)
+	Issuer         string        // iss and aud claims of the issued tokens
+	TokenTTL       time.Duration // Lifetime of the issued tokens
+	SigningKeyFile string        // PEM private key signing the tokens (empty = generated at startup)
+}
+
+// OAuthClient is a machine client that exchanges its credentials for tokens of a tenant
+type OAuthClient struct {
+	ID      string
+	Secrets []string `secret:"true"` // Accepted secrets; several allow rotating them without downtime
+	Tenant  string   // Tenant the client's tokens authenticate as
+	Tier    string   // Rate limit tier of the client's tokens ("" = the tenant's limits)
+}
+
+// Roles of admin API keys, from least to most privileged
+const (
+	AdminRoleViewer   = "viewer"   // Reads provider state, usage, alerts, bans and attestations
+	AdminRoleOperator = "operator" // Viewer, plus purging the cache, toggling providers and lifting bans
+	AdminRoleAdmin    = "admin"    // Operator, plus the configuration dump, the audit log and captured requests
+)
+
+// AdminKey is a credential of the admin API. Admin keys are distinct from the keys and
+// secrets of the public API.
+type AdminKey struct {
+	Name string   // Holder of the key, recorded in the audit log
+	Role string   // viewer, operator or admin
+	Keys []string `secret:"true"` // Accepted keys; several allow rotating them without downtime
+}
+
+// RequestSignatureConfig controls HMAC signatures required of requests made with
+// certain tenant API keys, for partners that cannot use TLS client certificates
+type RequestSignatureConfig struct {
+	Secrets   map[string]string `secret:"true"` // Signing secret per tenant API key (empty = signatures not required)
+	Tolerance time.Duration     // How far a signed timestamp may drift from now
+}
+
+// ResponseSigningConfig controls the signatures of served rates tables, which let
+// downstream caches verify that the rates were not tampered with
+type ResponseSigningConfig struct {
+	Algorithm string // hmac-sha256 or ed25519 (empty = responses not signed)
+	Key       string `secret:"true"` // Shared HMAC secret
+	KeyFile   string // PEM Ed25519 private key (empty = generated at startup)
+}
+
+// AttestationConfig controls the signed attestations of the conversions of regulated
+// tenants
+type AttestationConfig struct {
+	SigningKeyFile string // PEM Ed25519 private key, required when a tenant enables attestations
+}
+
+// RateLimitTier replaces a tenant's rate limits for tokens of the tier
+type RateLimitTier struct {
+	Requests int
+	Burst    int
+}
+
+// StreamConfig controls the server-sent rate streams
+type StreamConfig struct {
+	MaxSubscriptions int           // Pairs one connection may subscribe to
+	Base             string        // Base refreshed while streams are open; pairs are crossed through it
+	Heartbeat        time.Duration // Interval of keep-alive comments on idle streams
+	BufferSize       int           // Updates buffered per connection for slow clients
+	Backpressure     string        // drop-oldest, coalesce or disconnect when a buffer is full
+	SnapshotEvery    int           // Messages between full snapshots of delta-encoded streams (0 = only when needed)
+}
+
+// StartupChecksConfig controls the dependency checks run at boot and for readiness probes
+type StartupChecksConfig struct {
+	Mode         string        // strict (fail startup), warn (log and continue) or lazy (check on first readiness probe)
+	Timeout      time.Duration // Time budget for all checks at boot
+	CacheTTL     time.Duration // Age after which readiness probes refresh the results (0 = never)
+	ProbeTimeout time.Duration // Time budget for checks refreshed for readiness probes
+}
+
+// Tenant represents an API consumer with its own providers, markup, limits and currencies
+type Tenant struct {
+	ID                string
+	APIKeys           []string `secret:"true"`
+	Providers         []string // Provider names the tenant may use (empty = all)
+	Markup            MarkupConfig
+	RateLimitRequests int
+	RateLimitBurst    int
+	AllowedCurrencies []string            // Currencies the tenant may query (empty = all)
+	SourcePolicy      map[string][]string // Providers permitted per currency, replacing the global entries
+	DailyQuota        int                 // Requests per API key per UTC day (0 = unlimited)
+	MonthlyQuota      int                 // Requests per API key per UTC calendar month (0 = unlimited)
+	Attestations      bool                // Issue a signed attestation of every conversion, for auditors
+}
+
+// HasQuota reports whether the tenant's keys have a daily or monthly request quota
+func (tenant Tenant) HasQuota() bool {
+	return tenant.DailyQuota > 0 || tenant.MonthlyQuota > 0
+}
+
+// LoggingConfig controls the log format, where records are written and the fields
+// added to every record
+type LoggingConfig struct {
+	Backend         string            // logrus or slog
+	Format          string            // json, text or console
+	Outputs         []string          // stdout, stderr, file and/or syslog
+	TimestampFormat string            // rfc3339, rfc3339nano, or a Go time layout
+	Fields          map[string]string // Static fields added to every record (e.g. service, env, region)
+
+	File           string // Log file path for the file output
+	FileMaxSizeMB  int    // Size at which the log file is rotated (0 = never)
+	FileMaxBackups int    // Rotated log files kept
+
+	SyslogAddress string // network://host:port of a remote syslog server (empty = local syslog)
+}
+
+// ListenConfig selects where the HTTP server accepts connections
+type ListenConfig struct {
+	TCP             bool   // Accept connections on Port
+	UnixSocket      string // Path of a Unix domain socket to accept connections on (empty = none)
+	UnixSocketMode  string // Octal permissions of the socket file, e.g. 0660
+	UnixSocketGroup string // Group name or ID owning the socket file (empty = the process's group)
+}
+
+// RouterConfig holds the settings of the Gin engine
+type RouterConfig struct {
+	GinMode            string   // debug, release or test
+	TrustedPlatform    string   // cloudflare, appengine or the header the hosting platform puts the client IP in (empty = none)
+	TrustedProxies     []string // IPs or CIDRs of the proxies whose X-Forwarded-For is trusted (empty = Gin's default, all)
+	MaxMultipartMemory int64    // Bytes of a multipart form held in memory; the rest goes to temporary files
 }
 
 // Config holds all configuration for the application
 type Config struct {
+	Profile  string // dev, staging or prod ("" = built-in defaults only)
 	Port     string
+	Listen   ListenConfig
 	LogLevel string
+	Logging  LoggingConfig
+
+	// Settings of the Gin engine serving the API
+	Router RouterConfig
+
+	// API version lifecycle
+	APIVersions APIVersionsConfig
+
+	// API usage analytics for billing and capacity reports
+	Usage UsageConfig
+
+	// Request quotas of tenant API keys
+	Quota QuotaConfig
+
+	// Threshold alerts on provider errors, quota consumption, cache staleness and request errors
+	Alerting AlertingConfig
+
+	// Daily digest of closing rates and provider availability, by email or webhook
+	Digest DigestConfig
+
+	// Concurrency limit and fair queueing of API requests
+	Admission AdmissionConfig
+
+	// Bans of clients and credentials that fail authentication repeatedly
+	Abuse AbuseConfig
+
+	// Capture of sampled requests and responses for debugging
+	Capture CaptureConfig
+
+	// AdminAPIKey is a key of the admin role named "admin" (empty = none)
+	AdminAPIKey string `secret:"true"`
+
+	// Named admin keys and their roles. The admin API is disabled when neither these nor
+	// AdminAPIKey are set.
+	AdminKeys []AdminKey
+
+	// Aliases accepted for currency codes in request parameters, added to the built-in
+	// table (e.g. "RMB" for CNY)
+	CurrencyAliases map[string]string
+
+	// Base currency of requests that name none, and the bases that may be requested from
+	// providers (empty = any)
+	DefaultBaseCurrency   string
+	AllowedBaseCurrencies []string
+
+	// Providers permitted to serve rates involving a currency, as the base or a quoted
+	// symbol (currencies without an entry may be served by any provider)
+	SourcePolicy map[string][]string
 
 	// Exchange rate providers (dynamic list)
 	ExchangeRateProviders []ExchangeRateProvider
 	RatesCacheTTL         time.Duration
-	MaxConcurrentRequests int
+	MaxConcurrentRequests int // Default per-provider concurrency cap
+	ProviderQueueSize     int // Calls that may wait for a provider slot before failing fast
+
+	// Age after which the rates of a currency are marked stale
+	Staleness StalenessConfig
+
+	// ConditionalPolling sends ETag/Last-Modified validators so unchanged rates cost a 304
+	ConditionalPolling bool
+
+	// Largest provider response body accepted; larger responses fail as undecodable
+	ProviderMaxResponseBytes int64
+
+	// Provider latency SLO used for automatic deprioritization
+	ProviderSLO LatencySLOConfig
+
+	// Global budget of outbound provider calls
+	ProviderBudget CallBudgetConfig
+
+	// Probing and re-enabling of disabled providers in standby
+	ProviderStandby ProviderStandbyConfig
+
+	// Latency budgets of routes and providers, reported when breached
+	LatencyBudgets LatencyBudgetConfig
+
+	// PostgreSQL persistence for rate history (empty URL = disabled)
+	DatabaseURL         string `secret:"true"`
+	DatabaseAutoMigrate bool
+	History             HistoryConfig
+
+	// Conversions at dates without a stored close
+	DatedRates DatedRatesConfig
+
+	// Trading days of historical queries
+	MarketCalendar MarketCalendarConfig
+
+	// Boot-time dependency checks
+	StartupChecks StartupChecksConfig
+
+	// DNS resolution for provider calls
+	DNS DNSConfig
+
+	// Rate update events
+	Events EventsConfig
+
+	// Pair rates for MQTT subscribers such as displays and kiosks
+	MQTT MQTTConfig
+
+	// Server-sent pair rate streams
+	Stream StreamConfig
+
+	// Push-based rate sources: shared secret per source name, and how far a
+	// webhook's signed timestamp may drift from now before it is rejected
+	WebhookSecrets   map[string]string `secret:"true"`
+	WebhookTolerance time.Duration
 
 	// Rate limiting
 	RateLimitEnabled  bool
 	RateLimitRequests int
 	RateLimitWindow   time.Duration
 	RateLimitBurst    int
+	RateLimitTiers    map[string]RateLimitTier // Limits per JWT tier claim
+	RateLimitShadow   bool                     // Log and count requests over the limits instead of rejecting them
+
+	// Client buckets kept by the rate limiter (0 = unlimited), and how often idle
+	// buckets are removed
+	RateLimitMaxClients      int
+	RateLimitCleanupInterval time.Duration
+
+	// Conversion markup
+	Markup MarkupConfig
+
+	// Tenants (empty = single-tenant mode without API keys)
+	Tenants []Tenant
+
+	// JWT bearer-token authentication of tenants
+	JWT JWTConfig
+
+	// Tokens issued to machine clients
+	OAuth OAuthConfig
+
+	// HMAC-signed requests of tenant API keys
+	RequestSignatures RequestSignatureConfig
+
+	// Signatures of served rates tables
+	ResponseSigning ResponseSigningConfig
+
+	// Attestations of the conversions of regulated tenants
+	Attestation AttestationConfig
+
+	// Secret manager and rotation of secrets read from files or the secret manager
+	Secrets SecretsConfig
+
+	// Where each variable read came from, for the configuration dump
+	sources map[string]string
+}
+
+// LoadListen returns the port and listen settings, read as Load reads them but without
+// loading the rest of the configuration or any secrets, for the -healthcheck probe
+func LoadListen() (string, ListenConfig) {
+	_ = godotenv.Load()
+	return getEnv("PORT", "8081"), loadListenConfig()
+}
+
+// loadListenConfig reads where the HTTP server accepts connections
+func loadListenConfig() ListenConfig {
+	return ListenConfig{
+		TCP:             getEnv("LISTEN_TCP", "true") == "true",
+		UnixSocket:      getEnv("LISTEN_UNIX_SOCKET", ""),
+		UnixSocketMode:  getEnv("LISTEN_UNIX_SOCKET_MODE", "0660"),
+		UnixSocketGroup: getEnv("LISTEN_UNIX_SOCKET_GROUP", ""),
+	}
 }
 
 // Load loads configuration from environment variables
@@ -43,26 +569,297 @@ func Load() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
+	// Profile defaults apply to the variables left unset
+	profile, err := checkProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	// Secrets can come from files and the secret manager
+	loader := &secretLoader{}
+	secretsConfig := loadSecretsConfig(loader)
+	secretProvider, err := newSecretProvider(secretsConfig)
+	if err != nil {
+		return nil, err
+	}
+	loader.provider = secretProvider
+
 	// Load exchange rate providers
-	providers := loadExchangeRateProviders()
+	providers := loadExchangeRateProviders(loader)
+
+	defaultBase := strings.ToUpper(getEnv("DEFAULT_BASE_CURRENCY", "USD"))
+	rateLimitRequests := mustAtoi(getEnv("RATE_LIMIT_REQUESTS", "100"))
+	rateLimitBurst := mustAtoi(getEnv("RATE_LIMIT_BURST", "10"))
+	quota := QuotaConfig{
+		DailyRequests:   mustAtoi(getEnv("QUOTA_DAILY_REQUESTS", "0")),
+		MonthlyRequests: mustAtoi(getEnv("QUOTA_MONTHLY_REQUESTS", "0")),
+		FlushInterval:   time.Duration(mustAtoi(getEnv("QUOTA_FLUSH_INTERVAL_SECONDS", "30"))) * time.Second,
+	}
+	jwt := JWTConfig{
+		JWKSURL:      getEnv("JWT_JWKS_URL", ""),
+		Issuer:       getEnv("JWT_ISSUER", ""),
+		Audience:     getEnv("JWT_AUDIENCE", ""),
+		JWKSCacheTTL: time.Duration(mustAtoi(getEnv("JWT_JWKS_CACHE_TTL_SECONDS", "3600"))) * time.Second,
+		ClockSkew:    time.Duration(mustAtoi(getEnv("JWT_CLOCK_SKEW_SECONDS", "60"))) * time.Second,
+		TenantClaim:  getEnv("JWT_TENANT_CLAIM", "tenant"),
+		TierClaim:    getEnv("JWT_TIER_CLAIM", "tier"),
+	}
+	oauth := OAuthConfig{
+		Clients:        loadOAuthClients(loader),
+		Issuer:         getEnv("OAUTH_ISSUER", "currency-exchange-service"),
+		TokenTTL:       time.Duration(mustAtoi(getEnv("OAUTH_TOKEN_TTL_SECONDS", "900"))) * time.Second,
+		SigningKeyFile: getEnv("OAUTH_SIGNING_KEY_FILE", ""),
+	}
+	markup := MarkupConfig{
+		GlobalBPS: mustParseFloat(getEnv("MARKUP_GLOBAL_BPS", "0")),
+		PairBPS:   parsePairValues(getEnv("MARKUP_PAIR_BPS", "")),
+		FixedFee:  mustParseFloat(getEnv("MARKUP_FIXED_FEE", "0")),
+	}
 
-	return &Config{
+	configuration := &Config{
+		Profile:  profile,
 		Port:     getEnv("PORT", "8081"),
+		Listen:   loadListenConfig(),
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+		Logging: LoggingConfig{
+			Backend:         strings.ToLower(getEnv("LOG_BACKEND", "logrus")),
+			Format:          strings.ToLower(getEnv("LOG_FORMAT", "json")),
+			Outputs:         parseList(strings.ToLower(getEnv("LOG_OUTPUT", "stdout"))),
+			TimestampFormat: getEnv("LOG_TIMESTAMP_FORMAT", "rfc3339"),
+			Fields:          logFields(getEnv("LOG_SERVICE_NAME", "currency-exchange-service"), getEnv("LOG_FIELDS", "")),
+			File:            getEnv("LOG_FILE", "currency-exchange-service.log"),
+			FileMaxSizeMB:   mustAtoi(getEnv("LOG_FILE_MAX_SIZE_MB", "100")),
+			FileMaxBackups:  mustAtoi(getEnv("LOG_FILE_MAX_BACKUPS", "5")),
+			SyslogAddress:   getEnv("LOG_SYSLOG_ADDRESS", ""),
+		},
+		Router: RouterConfig{
+			GinMode:            strings.ToLower(getEnv("GIN_MODE", "release")),
+			TrustedPlatform:    getEnv("TRUSTED_PLATFORM", ""),
+			TrustedProxies:     parseList(getEnv("TRUSTED_PROXIES", "")),
+			MaxMultipartMemory: int64(mustAtoi(getEnv("MAX_MULTIPART_MEMORY_BYTES", "33554432"))),
+		},
+
+		APIVersions: APIVersionsConfig{
+			V1Enabled:         getEnv("API_V1_ENABLED", "true") == "true",
+			V1DeprecationDate: getEnv("API_V1_DEPRECATION_DATE", ""),
+			V1SunsetDate:      getEnv("API_V1_SUNSET_DATE", ""),
+		},
+
+		Usage: UsageConfig{
+			Enabled:       getEnv("USAGE_TRACKING_ENABLED", "true") == "true",
+			FlushInterval: time.Duration(mustAtoi(getEnv("USAGE_FLUSH_INTERVAL_SECONDS", "60"))) * time.Second,
+		},
+
+		Quota: quota,
+
+		Alerting: AlertingConfig{
+			EvaluationInterval:  time.Duration(mustAtoi(getEnv("ALERT_EVALUATION_INTERVAL_SECONDS", "60"))) * time.Second,
+			Rules:               loadAlertRules(),
+			Sinks:               loadAlertSinks(loader),
+			OutageWebhookURL:    loader.get("PROVIDER_OUTAGE_WEBHOOK_URL", ""),
+			OutageWebhookFormat: strings.ToLower(getEnv("PROVIDER_OUTAGE_WEBHOOK_FORMAT", "slack")),
+		},
+
+		Digest: DigestConfig{
+			Pairs:        parseList(strings.ToUpper(getEnv("DIGEST_PAIRS", ""))),
+			SendAt:       getEnv("DIGEST_TIME", "07:00"),
+			WebhookURL:   loader.get("DIGEST_WEBHOOK_URL", ""),
+			SMTPAddr:     getEnv("DIGEST_SMTP_ADDR", ""),
+			SMTPUsername: getEnv("DIGEST_SMTP_USERNAME", ""),
+			SMTPPassword: loader.get("DIGEST_SMTP_PASSWORD", ""),
+			EmailFrom:    getEnv("DIGEST_EMAIL_FROM", ""),
+			EmailTo:      parseList(getEnv("DIGEST_EMAIL_TO", "")),
+		},
+
+		Admission: AdmissionConfig{
+			MaxInFlight:    mustAtoi(getEnv("MAX_INFLIGHT_REQUESTS", "0")),
+			QueuePerClient: mustAtoi(getEnv("REQUEST_QUEUE_PER_CLIENT", "8")),
+			QueueSize:      mustAtoi(getEnv("REQUEST_QUEUE_SIZE", "256")),
+			QueueTimeout:   time.Duration(mustAtoi(getEnv("REQUEST_QUEUE_TIMEOUT_MS", "1000"))) * time.Millisecond,
+			TierWeights:    parseTierWeights(getEnv("REQUEST_QUEUE_TIER_WEIGHTS", "")),
+		},
+
+		Abuse: AbuseConfig{
+			Enabled:     getEnv("ABUSE_DETECTION_ENABLED", "true") == "true",
+			Threshold:   mustAtoi(getEnv("ABUSE_FAILURE_THRESHOLD", "10")),
+			Window:      time.Duration(mustAtoi(getEnv("ABUSE_FAILURE_WINDOW_SECONDS", "300"))) * time.Second,
+			BanDuration: time.Duration(mustAtoi(getEnv("ABUSE_BAN_SECONDS", "60"))) * time.Second,
+			MaxBan:      time.Duration(mustAtoi(getEnv("ABUSE_MAX_BAN_SECONDS", "86400"))) * time.Second,
+			MaxSubjects: mustAtoi(getEnv("ABUSE_MAX_SUBJECTS", "100000")),
+			ExemptCIDRs: parseList(getEnv("ABUSE_EXEMPT_CIDRS", "")),
+		},
+
+		Capture: CaptureConfig{
+			Enabled:      getEnv("DEBUG_CAPTURE_ENABLED", "false") == "true",
+			SampleRate:   mustParseFloat(getEnv("DEBUG_CAPTURE_SAMPLE_RATE", "0.01")),
+			MaxBodyBytes: mustAtoi(getEnv("DEBUG_CAPTURE_MAX_BODY_BYTES", "4096")),
+			BufferSize:   mustAtoi(getEnv("DEBUG_CAPTURE_BUFFER_SIZE", "200")),
+		},
+
+		AdminAPIKey: loader.get("ADMIN_API_KEY", ""),
+		AdminKeys:   loadAdminKeys(loader),
+
+		CurrencyAliases: parseCurrencyAliases(getEnv("CURRENCY_ALIASES", "")),
+
+		DefaultBaseCurrency:   defaultBase,
+		AllowedBaseCurrencies: parseList(strings.ToUpper(getEnv("ALLOWED_BASE_CURRENCIES", ""))),
+		SourcePolicy:          parseSourcePolicy(getEnv("RATE_SOURCE_POLICY", "")),
 
 		ExchangeRateProviders: providers,
 		RatesCacheTTL:         time.Duration(mustAtoi(getEnv("RATES_CACHE_TTL_SECONDS", "60"))) * time.Second,
 		MaxConcurrentRequests: mustAtoi(getEnv("MAX_CONCURRENT_REQUESTS", "4")),
+		ProviderQueueSize:     mustAtoi(getEnv("PROVIDER_QUEUE_SIZE", "16")),
+		ConditionalPolling:    getEnv("PROVIDER_CONDITIONAL_REQUESTS", "true") == "true",
+
+		Staleness: StalenessConfig{
+			MaxAge:  parseMaxAges(getEnv("RATE_MAX_AGE_SECONDS", "")),
+			Refresh: getEnv("RATE_STALE_REFRESH", "false") == "true",
+		},
+
+		ProviderMaxResponseBytes: int64(mustAtoi(getEnv("PROVIDER_MAX_RESPONSE_BYTES", "1048576"))),
+
+		LatencyBudgets: LatencyBudgetConfig{
+			Routes:     parseLatencyBudgets(getEnv("ROUTE_LATENCY_BUDGETS_MS", "")),
+			Providers:  parseLatencyBudgets(getEnv("PROVIDER_LATENCY_BUDGETS_MS", "")),
+			Window:     time.Duration(mustAtoi(getEnv("LATENCY_BUDGET_WINDOW_SECONDS", "300"))) * time.Second,
+			MinSamples: mustAtoi(getEnv("LATENCY_BUDGET_MIN_SAMPLES", "20")),
+		},
+
+		ProviderSLO: LatencySLOConfig{
+			P95Threshold:     time.Duration(mustAtoi(getEnv("PROVIDER_SLO_P95_MS", "0"))) * time.Millisecond,
+			Window:           time.Duration(mustAtoi(getEnv("PROVIDER_SLO_WINDOW_SECONDS", "60"))) * time.Second,
+			BreachDuration:   time.Duration(mustAtoi(getEnv("PROVIDER_SLO_BREACH_SECONDS", "300"))) * time.Second,
+			RecoveryDuration: time.Duration(mustAtoi(getEnv("PROVIDER_SLO_RECOVERY_SECONDS", "300"))) * time.Second,
+		},
+
+		ProviderBudget: CallBudgetConfig{
+			Calls:  mustAtoi(getEnv("PROVIDER_CALL_BUDGET", "0")),
+			Window: time.Duration(mustAtoi(getEnv("PROVIDER_CALL_BUDGET_WINDOW_SECONDS", "3600"))) * time.Second,
+		},
+
+		ProviderStandby: ProviderStandbyConfig{
+			ProbeInterval: time.Duration(mustAtoi(getEnv("PROVIDER_STANDBY_PROBE_INTERVAL_SECONDS", "60"))) * time.Second,
+			Successes:     mustAtoi(getEnv("PROVIDER_STANDBY_SUCCESSES", "3")),
+		},
+
+		DatabaseURL:         loader.get("DATABASE_URL", ""),
+		DatabaseAutoMigrate: getEnv("DATABASE_AUTO_MIGRATE", "true") == "true",
+		History: HistoryConfig{
+			RawRetention:    time.Duration(mustAtoi(getEnv("HISTORY_RAW_RETENTION_DAYS", "30"))) * 24 * time.Hour,
+			HourlyRetention: time.Duration(mustAtoi(getEnv("HISTORY_HOURLY_RETENTION_DAYS", "365"))) * 24 * time.Hour,
+			DailyRetention:  time.Duration(mustAtoi(getEnv("HISTORY_DAILY_RETENTION_DAYS", "0"))) * 24 * time.Hour,
+			CompactInterval: time.Duration(mustAtoi(getEnv("HISTORY_COMPACT_INTERVAL_MINUTES", "60"))) * time.Minute,
+		},
+		DatedRates: DatedRatesConfig{
+			Interpolation: strings.ToLower(getEnv("HISTORY_INTERPOLATION", "none")),
+			MaxGap:        time.Duration(mustAtoi(getEnv("HISTORY_INTERPOLATION_MAX_GAP_DAYS", "5"))) * 24 * time.Hour,
+			ForwardPoints: parsePairValues(getEnv("FORWARD_POINTS", "")),
+		},
+		MarketCalendar: MarketCalendarConfig{
+			Weekend:    parseList(strings.ToLower(getEnv("MARKET_WEEKEND", "saturday,sunday"))),
+			Holidays:   parseList(getEnv("MARKET_HOLIDAYS", "")),
+			Convention: strings.ToLower(getEnv("MARKET_DATE_CONVENTION", "none")),
+		},
+
+		StartupChecks: StartupChecksConfig{
+			Mode:         strings.ToLower(getEnv("STARTUP_CHECK_MODE", "warn")),
+			Timeout:      time.Duration(mustAtoi(getEnv("STARTUP_CHECK_TIMEOUT_SECONDS", "10"))) * time.Second,
+			CacheTTL:     time.Duration(mustAtoi(getEnv("READINESS_CACHE_TTL_SECONDS", "10"))) * time.Second,
+			ProbeTimeout: time.Duration(mustAtoi(getEnv("READINESS_CHECK_TIMEOUT_SECONDS", "2"))) * time.Second,
+		},
+
+		DNS: DNSConfig{
+			CacheTTL:          time.Duration(mustAtoi(getEnv("DNS_CACHE_TTL_SECONDS", "60"))) * time.Second,
+			ResolveTimeout:    time.Duration(mustAtoi(getEnv("DNS_RESOLVE_TIMEOUT_MS", "2000"))) * time.Millisecond,
+			FallbackResolvers: parseList(getEnv("DNS_FALLBACK_RESOLVERS", "")),
+		},
+
+		Events: EventsConfig{
+			Broker:               strings.ToLower(getEnv("EVENTS_BROKER", "")),
+			URL:                  getEnv("EVENTS_URL", ""),
+			Topic:                getEnv("EVENTS_TOPIC", "rates"),
+			Format:               strings.ToLower(getEnv("EVENTS_FORMAT", "json")),
+			MoveThresholdPercent: mustParseFloat(getEnv("EVENTS_MOVE_THRESHOLD_PERCENT", "0")),
+		},
+
+		Stream: StreamConfig{
+			MaxSubscriptions: mustAtoi(getEnv("STREAM_MAX_SUBSCRIPTIONS", "20")),
+			Base:             strings.ToUpper(getEnv("STREAM_BASE", defaultBase)),
+			Heartbeat:        time.Duration(mustAtoi(getEnv("STREAM_HEARTBEAT_SECONDS", "15"))) * time.Second,
+			BufferSize:       mustAtoi(getEnv("STREAM_BUFFER_SIZE", "64")),
+			Backpressure:     strings.ToLower(getEnv("STREAM_BACKPRESSURE_POLICY", "coalesce")),
+			SnapshotEvery:    mustAtoi(getEnv("STREAM_SNAPSHOT_EVERY", "60")),
+		},
+
+		MQTT: MQTTConfig{
+			URL:           getEnv("MQTT_URL", ""),
+			ClientID:      getEnv("MQTT_CLIENT_ID", "currency-exchange-service"),
+			Username:      getEnv("MQTT_USERNAME", ""),
+			Password:      loader.get("MQTT_PASSWORD", ""),
+			Pairs:         parseList(strings.ToUpper(getEnv("MQTT_PAIRS", ""))),
+			TopicTemplate: getEnv("MQTT_TOPIC_TEMPLATE", "rates/{from}/{to}"),
+			QoS:           mustAtoi(getEnv("MQTT_QOS", "0")),
+			Retain:        getEnv("MQTT_RETAIN", "true") == "true",
+		},
+
+		WebhookSecrets:   loadWebhookSecrets(loader),
+		WebhookTolerance: time.Duration(mustAtoi(getEnv("WEBHOOK_TOLERANCE_SECONDS", "300"))) * time.Second,
 
 		RateLimitEnabled:  getEnv("RATE_LIMIT_ENABLED", "true") == "true",
-		RateLimitRequests: mustAtoi(getEnv("RATE_LIMIT_REQUESTS", "100")),
+		RateLimitRequests: rateLimitRequests,
 		RateLimitWindow:   time.Duration(mustAtoi(getEnv("RATE_LIMIT_WINDOW_SECONDS", "60"))) * time.Second,
-		RateLimitBurst:    mustAtoi(getEnv("RATE_LIMIT_BURST", "10")),
-	}, nil
+		RateLimitBurst:    rateLimitBurst,
+		RateLimitTiers:    parseRateLimitTiers(getEnv("RATE_LIMIT_TIERS", ""), rateLimitBurst),
+		RateLimitShadow:   getEnv("RATE_LIMIT_SHADOW", "false") == "true",
+
+		RateLimitMaxClients:      mustAtoi(getEnv("RATE_LIMIT_MAX_CLIENTS", "100000")),
+		RateLimitCleanupInterval: time.Duration(mustAtoi(getEnv("RATE_LIMIT_CLEANUP_INTERVAL_SECONDS", "120"))) * time.Second,
+
+		Markup: markup,
+
+		Tenants: loadTenants(markup, rateLimitRequests, rateLimitBurst, quota, jwt.JWKSURL != "" || len(oauth.Clients) > 0, loader),
+		JWT:     jwt,
+		OAuth:   oauth,
+
+		RequestSignatures: RequestSignatureConfig{
+			Secrets:   loadSignatureSecrets(loader),
+			Tolerance: time.Duration(mustAtoi(getEnv("SIGNATURE_TOLERANCE_SECONDS", "300"))) * time.Second,
+		},
+		ResponseSigning: ResponseSigningConfig{
+			Algorithm: strings.ToLower(getEnv("RESPONSE_SIGNING_ALGORITHM", "")),
+			Key:       loader.get("RESPONSE_SIGNING_KEY", ""),
+			KeyFile:   getEnv("RESPONSE_SIGNING_KEY_FILE", ""),
+		},
+		Attestation: AttestationConfig{
+			SigningKeyFile: getEnv("ATTESTATION_SIGNING_KEY_FILE", ""),
+		},
+
+		Secrets: secretsConfig,
+	}
+
+	if loader.err != nil {
+		return nil, loader.err
+	}
+	switch configuration.Router.GinMode {
+	case "debug", "release", "test":
+	default:
+		return nil, fmt.Errorf("invalid GIN_MODE %q (expected debug, release or test)", configuration.Router.GinMode)
+	}
+	if err := validateAdminKeys(configuration); err != nil {
+		return nil, err
+	}
+	// Without both, a token signed by any key in the set is accepted, whatever issued it
+	// and whoever it was meant for
+	if jwt.JWKSURL != "" && (jwt.Issuer == "" || jwt.Audience == "") {
+		return nil, fmt.Errorf("JWT_JWKS_URL requires JWT_ISSUER and JWT_AUDIENCE")
+	}
+	configuration.Secrets.External = loader.external
+	configuration.sources = variableSources()
+	return configuration, nil
 }
 
 // loadExchangeRateProviders loads exchange rate providers from environment variables
-func loadExchangeRateProviders() []ExchangeRateProvider {
+func loadExchangeRateProviders(loader *secretLoader) []ExchangeRateProvider {
 	providers := []ExchangeRateProvider{}
 
 	// Default providers (keeping the original four)
@@ -70,42 +867,82 @@ func loadExchangeRateProviders() []ExchangeRateProvider {
 		{
 			Name:       "erapi",
 			BaseURL:    getEnv("EXCHANGE_RATE_API_BASE_URL", "https://open.er-api.com/v6/latest"),
-			APIKey:     getEnv("EXCHANGE_RATE_API_KEY", ""),
+			MirrorURLs: parseList(getEnv("EXCHANGE_RATE_API_MIRROR_URLS", "")),
+			APIKey:     loader.get("EXCHANGE_RATE_API_KEY", ""),
 			Enabled:    getEnv("EXCHANGE_RATE_API_ENABLED", "true") == "true",
 			Priority:   1,
 			Timeout:    time.Duration(mustAtoi(getEnv("EXCHANGE_RATE_API_TIMEOUT", "30"))) * time.Second,
 			RetryCount: mustAtoi(getEnv("EXCHANGE_RATE_API_RETRY_COUNT", "3")),
 			RetryDelay: time.Duration(mustAtoi(getEnv("EXCHANGE_RATE_API_RETRY_DELAY", "1"))) * time.Second,
+
+			MaxConcurrent:  mustAtoi(getEnv("EXCHANGE_RATE_API_MAX_CONCURRENT", getEnv("MAX_CONCURRENT_REQUESTS", "4"))),
+			RateLimit:      getEnv("EXCHANGE_RATE_API_RATE_LIMIT", ""),
+			RateLimitBurst: mustAtoi(getEnv("EXCHANGE_RATE_API_RATE_LIMIT_BURST", "5")),
+
+			InvertedSymbols: parseList(strings.ToUpper(getEnv("EXCHANGE_RATE_API_INVERTED_SYMBOLS", ""))),
+			FixedBase:       strings.ToUpper(getEnv("EXCHANGE_RATE_API_FIXED_BASE", "")),
+
+			ForwardRequestID: getEnv("EXCHANGE_RATE_API_FORWARD_REQUEST_ID", "true") == "true",
 		},
 		{
 			Name:       "openexchangerates",
 			BaseURL:    getEnv("OPEN_EXCHANGE_RATES_BASE_URL", "https://openexchangerates.org/api/latest.json"),
-			APIKey:     getEnv("OPEN_EXCHANGE_RATES_API_KEY", ""),
+			MirrorURLs: parseList(getEnv("OPEN_EXCHANGE_RATES_MIRROR_URLS", "")),
+			APIKey:     loader.get("OPEN_EXCHANGE_RATES_API_KEY", ""),
 			Enabled:    getEnv("OPEN_EXCHANGE_RATES_ENABLED", "true") == "true",
 			Priority:   2,
 			Timeout:    time.Duration(mustAtoi(getEnv("OPEN_EXCHANGE_RATES_TIMEOUT", "30"))) * time.Second,
 			RetryCount: mustAtoi(getEnv("OPEN_EXCHANGE_RATES_RETRY_COUNT", "3")),
 			RetryDelay: time.Duration(mustAtoi(getEnv("OPEN_EXCHANGE_RATES_RETRY_DELAY", "1"))) * time.Second,
+
+			MaxConcurrent:  mustAtoi(getEnv("OPEN_EXCHANGE_RATES_MAX_CONCURRENT", getEnv("MAX_CONCURRENT_REQUESTS", "4"))),
+			RateLimit:      getEnv("OPEN_EXCHANGE_RATES_RATE_LIMIT", "1000/month"),
+			RateLimitBurst: mustAtoi(getEnv("OPEN_EXCHANGE_RATES_RATE_LIMIT_BURST", "5")),
+
+			InvertedSymbols: parseList(strings.ToUpper(getEnv("OPEN_EXCHANGE_RATES_INVERTED_SYMBOLS", ""))),
+			FixedBase:       strings.ToUpper(getEnv("OPEN_EXCHANGE_RATES_FIXED_BASE", "USD")),
+
+			ForwardRequestID: getEnv("OPEN_EXCHANGE_RATES_FORWARD_REQUEST_ID", "true") == "true",
 		},
 		{
 			Name:       "frankfurter",
 			BaseURL:    getEnv("FRANKFURTER_API_BASE_URL", "https://api.frankfurter.app/latest"),
-			APIKey:     getEnv("FRANKFURTER_API_KEY", ""),
+			MirrorURLs: parseList(getEnv("FRANKFURTER_MIRROR_URLS", "")),
+			APIKey:     loader.get("FRANKFURTER_API_KEY", ""),
 			Enabled:    getEnv("FRANKFURTER_ENABLED", "true") == "true",
 			Priority:   3,
 			Timeout:    time.Duration(mustAtoi(getEnv("FRANKFURTER_TIMEOUT", "30"))) * time.Second,
 			RetryCount: mustAtoi(getEnv("FRANKFURTER_RETRY_COUNT", "3")),
 			RetryDelay: time.Duration(mustAtoi(getEnv("FRANKFURTER_RETRY_DELAY", "1"))) * time.Second,
+
+			MaxConcurrent:  mustAtoi(getEnv("FRANKFURTER_MAX_CONCURRENT", getEnv("MAX_CONCURRENT_REQUESTS", "4"))),
+			RateLimit:      getEnv("FRANKFURTER_RATE_LIMIT", ""),
+			RateLimitBurst: mustAtoi(getEnv("FRANKFURTER_RATE_LIMIT_BURST", "5")),
+
+			InvertedSymbols: parseList(strings.ToUpper(getEnv("FRANKFURTER_INVERTED_SYMBOLS", ""))),
+			FixedBase:       strings.ToUpper(getEnv("FRANKFURTER_FIXED_BASE", "")),
+
+			ForwardRequestID: getEnv("FRANKFURTER_FORWARD_REQUEST_ID", "true") == "true",
 		},
 		{
 			Name:       "exchangerate.host",
 			BaseURL:    getEnv("EXCHANGE_RATE_HOST_BASE_URL", "https://api.exchangerate.host/latest"),
-			APIKey:     getEnv("EXCHANGE_RATE_HOST_API_KEY", ""),
+			MirrorURLs: parseList(getEnv("EXCHANGE_RATE_HOST_MIRROR_URLS", "")),
+			APIKey:     loader.get("EXCHANGE_RATE_HOST_API_KEY", ""),
 			Enabled:    getEnv("EXCHANGE_RATE_HOST_ENABLED", "true") == "true",
 			Priority:   4,
 			Timeout:    time.Duration(mustAtoi(getEnv("EXCHANGE_RATE_HOST_TIMEOUT", "30"))) * time.Second,
 			RetryCount: mustAtoi(getEnv("EXCHANGE_RATE_HOST_RETRY_COUNT", "3")),
 			RetryDelay: time.Duration(mustAtoi(getEnv("EXCHANGE_RATE_HOST_RETRY_DELAY", "1"))) * time.Second,
+
+			MaxConcurrent:  mustAtoi(getEnv("EXCHANGE_RATE_HOST_MAX_CONCURRENT", getEnv("MAX_CONCURRENT_REQUESTS", "4"))),
+			RateLimit:      getEnv("EXCHANGE_RATE_HOST_RATE_LIMIT", "100/month"),
+			RateLimitBurst: mustAtoi(getEnv("EXCHANGE_RATE_HOST_RATE_LIMIT_BURST", "5")),
+
+			InvertedSymbols: parseList(strings.ToUpper(getEnv("EXCHANGE_RATE_HOST_INVERTED_SYMBOLS", ""))),
+			FixedBase:       strings.ToUpper(getEnv("EXCHANGE_RATE_HOST_FIXED_BASE", "")),
+
+			ForwardRequestID: getEnv("EXCHANGE_RATE_HOST_FORWARD_REQUEST_ID", "true") == "true",
 		},
 	}
 
@@ -113,7 +950,7 @@ func loadExchangeRateProviders() []ExchangeRateProvider {
 	providers = append(providers, defaultProviders...)
 
 	// Load additional providers from environment
-	additionalProviders := loadAdditionalProviders()
+	additionalProviders := loadAdditionalProviders(loader)
 	providers = append(providers, additionalProviders...)
 
 	// Filter out disabled providers and sort by priority
@@ -137,7 +974,7 @@ func loadExchangeRateProviders() []ExchangeRateProvider {
 }
 
 // loadAdditionalProviders loads additional providers from environment variables
-func loadAdditionalProviders() []ExchangeRateProvider {
+func loadAdditionalProviders(loader *secretLoader) []ExchangeRateProvider {
 	providers := []ExchangeRateProvider{}
 
 	// Check for additional providers (PROVIDER_1_NAME, PROVIDER_2_NAME, etc.)
@@ -149,16 +986,39 @@ func loadAdditionalProviders() []ExchangeRateProvider {
 
 		provider := ExchangeRateProvider{
 			Name:       name,
+			Type:       strings.ToLower(getEnv(fmt.Sprintf("PROVIDER_%d_TYPE", i), ProviderTypeHTTP)),
 			BaseURL:    getEnv(fmt.Sprintf("PROVIDER_%d_BASE_URL", i), ""),
-			APIKey:     getEnv(fmt.Sprintf("PROVIDER_%d_API_KEY", i), ""),
+			MirrorURLs: parseList(getEnv(fmt.Sprintf("PROVIDER_%d_MIRROR_URLS", i), "")),
+			APIKey:     loader.get(fmt.Sprintf("PROVIDER_%d_API_KEY", i), ""),
 			Enabled:    getEnv(fmt.Sprintf("PROVIDER_%d_ENABLED", i), "true") == "true",
 			Priority:   mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_PRIORITY", i), "10")),
 			Timeout:    time.Duration(mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_TIMEOUT", i), "30"))) * time.Second,
 			RetryCount: mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_RETRY_COUNT", i), "3")),
 			RetryDelay: time.Duration(mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_RETRY_DELAY", i), "1"))) * time.Second,
+
+			MaxConcurrent:  mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_MAX_CONCURRENT", i), getEnv("MAX_CONCURRENT_REQUESTS", "4"))),
+			RateLimit:      getEnv(fmt.Sprintf("PROVIDER_%d_RATE_LIMIT", i), ""),
+			RateLimitBurst: mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_RATE_LIMIT_BURST", i), "5")),
+
+			InvertedSymbols: parseList(strings.ToUpper(getEnv(fmt.Sprintf("PROVIDER_%d_INVERTED_SYMBOLS", i), ""))),
+			FixedBase:       strings.ToUpper(getEnv(fmt.Sprintf("PROVIDER_%d_FIXED_BASE", i), "")),
+
+			Signing: RequestSigningConfig{
+				Algorithm:       strings.ToLower(getEnv(fmt.Sprintf("PROVIDER_%d_SIGNING_ALGORITHM", i), "hmac-sha256")),
+				Key:             loader.get(fmt.Sprintf("PROVIDER_%d_SIGNING_KEY", i), ""),
+				SignatureHeader: getEnv(fmt.Sprintf("PROVIDER_%d_SIGNATURE_HEADER", i), "X-Signature"),
+				TimestampHeader: getEnv(fmt.Sprintf("PROVIDER_%d_TIMESTAMP_HEADER", i), "X-Timestamp"),
+			},
+
+			ForwardRequestID: getEnv(fmt.Sprintf("PROVIDER_%d_FORWARD_REQUEST_ID", i), "true") == "true",
+
+			Members:     parseList(getEnv(fmt.Sprintf("PROVIDER_%d_MEMBERS", i), "")),
+			Combine:     strings.ToLower(getEnv(fmt.Sprintf("PROVIDER_%d_COMBINE", i), "median")),
+			TrimPercent: mustParseFloat(getEnv(fmt.Sprintf("PROVIDER_%d_TRIM_PERCENT", i), "20")),
+			MinMembers:  mustAtoi(getEnv(fmt.Sprintf("PROVIDER_%d_MIN_MEMBERS", i), "2")),
 		}
 
-		if provider.BaseURL != "" {
+		if provider.BaseURL != "" || provider.Type == ProviderTypeComposite {
 			providers = append(providers, provider)
 		}
 	}
@@ -166,11 +1026,231 @@ func loadAdditionalProviders() []ExchangeRateProvider {
 	return providers
 }
 
-// getEnv gets an environment variable with a fallback value
+// loadOAuthClients loads machine clients from environment variables (OAUTH_CLIENT_1_ID,
+// OAUTH_CLIENT_2_ID, etc.); clients without secrets or a tenant are skipped
+func loadOAuthClients(loader *secretLoader) []OAuthClient {
+	clients := []OAuthClient{}
+
+	for i := 1; i <= 50; i++ { // Support up to 50 clients
+		id := getEnv(fmt.Sprintf("OAUTH_CLIENT_%d_ID", i), "")
+		if id == "" {
+			break
+		}
+
+		client := OAuthClient{
+			ID:      id,
+			Secrets: parseList(loader.get(fmt.Sprintf("OAUTH_CLIENT_%d_SECRETS", i), "")),
+			Tenant:  getEnv(fmt.Sprintf("OAUTH_CLIENT_%d_TENANT", i), ""),
+			Tier:    getEnv(fmt.Sprintf("OAUTH_CLIENT_%d_TIER", i), ""),
+		}
+		if len(client.Secrets) > 0 && client.Tenant != "" {
+			clients = append(clients, client)
+		}
+	}
+
+	return clients
+}
+
+// loadAdminKeys loads admin keys from environment variables (ADMIN_KEY_1_NAME,
+// ADMIN_KEY_2_NAME, etc.); entries without keys are skipped
+func loadAdminKeys(loader *secretLoader) []AdminKey {
+	adminKeys := []AdminKey{}
+
+	for i := 1; i <= 50; i++ { // Support up to 50 admin keys
+		name := getEnv(fmt.Sprintf("ADMIN_KEY_%d_NAME", i), "")
+		if name == "" {
+			break
+		}
+
+		adminKey := AdminKey{
+			Name: name,
+			Role: strings.ToLower(getEnv(fmt.Sprintf("ADMIN_KEY_%d_ROLE", i), AdminRoleViewer)),
+			Keys: parseList(loader.get(fmt.Sprintf("ADMIN_KEY_%d_KEYS", i), "")),
+		}
+		if len(adminKey.Keys) > 0 {
+			adminKeys = append(adminKeys, adminKey)
+		}
+	}
+
+	return adminKeys
+}
+
+// validateAdminKeys checks the roles of the admin keys, and that no admin key is also a
+// credential of the public API: a leaked tenant key must not open the admin API
+func validateAdminKeys(configuration *Config) error {
+	publicCredentials := make(map[string]string)
+	for _, tenant := range configuration.Tenants {
+		for _, key := range tenant.APIKeys {
+			publicCredentials[key] = "an API key of tenant " + tenant.ID
+		}
+	}
+	for _, client := range configuration.OAuth.Clients {
+		for _, secret := range client.Secrets {
+			publicCredentials[secret] = "a secret of OAuth client " + client.ID
+		}
+	}
+
+	if use, found := publicCredentials[configuration.AdminAPIKey]; found && configuration.AdminAPIKey != "" {
+		return fmt.Errorf("ADMIN_API_KEY is also %s", use)
+	}
+	names := map[string]bool{"admin": configuration.AdminAPIKey != ""}
+	for _, adminKey := range configuration.AdminKeys {
+		switch adminKey.Role {
+		case AdminRoleViewer, AdminRoleOperator, AdminRoleAdmin:
+		default:
+			return fmt.Errorf("admin key %s has unknown role %q (expected viewer, operator or admin)", adminKey.Name, adminKey.Role)
+		}
+		if names[adminKey.Name] {
+			return fmt.Errorf("admin key %s is defined twice", adminKey.Name)
+		}
+		names[adminKey.Name] = true
+		for _, key := range adminKey.Keys {
+			if use, found := publicCredentials[key]; found {
+				return fmt.Errorf("a key of admin key %s is also %s", adminKey.Name, use)
+			}
+		}
+	}
+	return nil
+}
+
+// loadAlertRules loads alert rules from environment variables (ALERT_RULE_1_NAME,
+// ALERT_RULE_2_NAME, etc.)
+func loadAlertRules() []AlertRule {
+	rules := []AlertRule{}
+
+	for i := 1; i <= 50; i++ { // Support up to 50 rules
+		name := getEnv(fmt.Sprintf("ALERT_RULE_%d_NAME", i), "")
+		if name == "" {
+			break
+		}
+
+		rules = append(rules, AlertRule{
+			Name:      name,
+			Metric:    strings.ToLower(getEnv(fmt.Sprintf("ALERT_RULE_%d_METRIC", i), "")),
+			Threshold: mustParseFloat(getEnv(fmt.Sprintf("ALERT_RULE_%d_THRESHOLD", i), "0")),
+			For:       time.Duration(mustAtoi(getEnv(fmt.Sprintf("ALERT_RULE_%d_FOR_SECONDS", i), "0"))) * time.Second,
+			Severity:  getEnv(fmt.Sprintf("ALERT_RULE_%d_SEVERITY", i), "warning"),
+			Sinks:     parseList(getEnv(fmt.Sprintf("ALERT_RULE_%d_SINKS", i), "")),
+		})
+	}
+
+	return rules
+}
+
+// loadAlertSinks loads alert sinks from environment variables (ALERT_SINK_1_NAME,
+// ALERT_SINK_2_NAME, etc.); their URLs may embed credentials, so they are secrets
+func loadAlertSinks(loader *secretLoader) []AlertSink {
+	sinks := []AlertSink{}
+
+	for i := 1; i <= 10; i++ { // Support up to 10 sinks
+		name := getEnv(fmt.Sprintf("ALERT_SINK_%d_NAME", i), "")
+		if name == "" {
+			break
+		}
+
+		sinks = append(sinks, AlertSink{
+			Name: name,
+			Type: strings.ToLower(getEnv(fmt.Sprintf("ALERT_SINK_%d_TYPE", i), "webhook")),
+			URL:  loader.get(fmt.Sprintf("ALERT_SINK_%d_URL", i), ""),
+		})
+	}
+
+	return sinks
+}
+
+// loadWebhookSecrets loads push source secrets from environment variables
+// (WEBHOOK_1_SOURCE/WEBHOOK_1_SECRET, WEBHOOK_2_SOURCE/WEBHOOK_2_SECRET, etc.)
+func loadWebhookSecrets(loader *secretLoader) map[string]string {
+	secrets := make(map[string]string)
+
+	for i := 1; i <= 10; i++ { // Support up to 10 push sources
+		source := getEnv(fmt.Sprintf("WEBHOOK_%d_SOURCE", i), "")
+		if source == "" {
+			break
+		}
+
+		if secret := loader.get(fmt.Sprintf("WEBHOOK_%d_SECRET", i), ""); secret != "" {
+			secrets[source] = secret
+		}
+	}
+
+	return secrets
+}
+
+// loadSignatureSecrets loads the signing secrets of tenant API keys from environment
+// variables (SIGNATURE_1_API_KEY/SIGNATURE_1_SECRET, SIGNATURE_2_API_KEY/SIGNATURE_2_SECRET, etc.)
+func loadSignatureSecrets(loader *secretLoader) map[string]string {
+	secrets := make(map[string]string)
+
+	for i := 1; i <= 50; i++ { // Support up to 50 signing keys
+		apiKey := loader.get(fmt.Sprintf("SIGNATURE_%d_API_KEY", i), "")
+		if apiKey == "" {
+			break
+		}
+
+		if secret := loader.get(fmt.Sprintf("SIGNATURE_%d_SECRET", i), ""); secret != "" {
+			secrets[apiKey] = secret
+		}
+	}
+
+	return secrets
+}
+
+// loadTenants loads tenants from environment variables (TENANT_1_ID, TENANT_2_ID, etc.)
+// Unset tenant settings fall back to the global markup and rate limits. Tenants without
+// API keys are kept only when they can authenticate with JWTs.
+func loadTenants(defaultMarkup MarkupConfig, defaultRequests, defaultBurst int, defaultQuota QuotaConfig, keyless bool, loader *secretLoader) []Tenant {
+	tenants := []Tenant{}
+
+	for i := 1; i <= 50; i++ { // Support up to 50 tenants
+		id := getEnv(fmt.Sprintf("TENANT_%d_ID", i), "")
+		if id == "" {
+			break
+		}
+
+		markup := defaultMarkup
+		if pairBPS := getEnv(fmt.Sprintf("TENANT_%d_MARKUP_PAIR_BPS", i), ""); pairBPS != "" {
+			markup.PairBPS = parsePairValues(pairBPS)
+		}
+		if globalBPS := getEnv(fmt.Sprintf("TENANT_%d_MARKUP_GLOBAL_BPS", i), ""); globalBPS != "" {
+			markup.GlobalBPS = mustParseFloat(globalBPS)
+		}
+		if fixedFee := getEnv(fmt.Sprintf("TENANT_%d_MARKUP_FIXED_FEE", i), ""); fixedFee != "" {
+			markup.FixedFee = mustParseFloat(fixedFee)
+		}
+
+		tenant := Tenant{
+			ID:                id,
+			APIKeys:           parseList(loader.get(fmt.Sprintf("TENANT_%d_API_KEYS", i), "")),
+			Providers:         parseList(getEnv(fmt.Sprintf("TENANT_%d_PROVIDERS", i), "")),
+			Markup:            markup,
+			RateLimitRequests: mustAtoi(getEnv(fmt.Sprintf("TENANT_%d_RATE_LIMIT_REQUESTS", i), strconv.Itoa(defaultRequests))),
+			RateLimitBurst:    mustAtoi(getEnv(fmt.Sprintf("TENANT_%d_RATE_LIMIT_BURST", i), strconv.Itoa(defaultBurst))),
+			AllowedCurrencies: parseList(strings.ToUpper(getEnv(fmt.Sprintf("TENANT_%d_ALLOWED_CURRENCIES", i), ""))),
+			SourcePolicy:      parseSourcePolicy(getEnv(fmt.Sprintf("TENANT_%d_SOURCE_POLICY", i), "")),
+			DailyQuota:        mustAtoi(getEnv(fmt.Sprintf("TENANT_%d_DAILY_QUOTA", i), strconv.Itoa(defaultQuota.DailyRequests))),
+			MonthlyQuota:      mustAtoi(getEnv(fmt.Sprintf("TENANT_%d_MONTHLY_QUOTA", i), strconv.Itoa(defaultQuota.MonthlyRequests))),
+			Attestations:      getEnv(fmt.Sprintf("TENANT_%d_ATTESTATIONS", i), "false") == "true",
+		}
+
+		if len(tenant.APIKeys) > 0 || keyless {
+			tenants = append(tenants, tenant)
+		}
+	}
+
+	return tenants
+}
+
+// getEnv gets an environment variable, falling back to the profile's default and then
+// to fallback
 func getEnv(key, fallback string) string {
+	recordVariable(key)
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
+	if value, found := profileDefault(key); found {
+		return value
+	}
 	return fallback
 }
 
@@ -181,3 +1261,154 @@ func mustAtoi(s string) int {
 	}
 	return i
 }
+
+func mustParseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// parsePairValues parses a list like "USD/EUR=25,EUR/GBP=10" into a map keyed by pair
+func parsePairValues(s string) map[string]float64 {
+	values := make(map[string]float64)
+	for _, entry := range strings.Split(s, ",") {
+		pair, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			continue
+		}
+		parsedValue, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			continue
+		}
+		values[strings.ToUpper(strings.TrimSpace(pair))] = parsedValue
+	}
+	return values
+}
+
+// parseMaxAges parses max ages in seconds like "BTC=30,metal=300,default=21600", keyed by
+// uppercased currency code or class
+func parseMaxAges(s string) map[string]time.Duration {
+	maxAges := make(map[string]time.Duration)
+	for key, seconds := range parsePairValues(s) {
+		maxAges[key] = time.Duration(seconds * float64(time.Second))
+	}
+	return maxAges
+}
+
+// parseRateLimitTiers parses tiers like "free=60:5,pro=1000:100" (requests per window and
+// optional burst, which defaults to defaultBurst)
+func parseRateLimitTiers(s string, defaultBurst int) map[string]RateLimitTier {
+	tiers := make(map[string]RateLimitTier)
+	for _, entry := range strings.Split(s, ",") {
+		name, limits, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || strings.TrimSpace(name) == "" {
+			continue
+		}
+		requests, burst, hasBurst := strings.Cut(limits, ":")
+		tier := RateLimitTier{Requests: mustAtoi(strings.TrimSpace(requests)), Burst: defaultBurst}
+		if hasBurst {
+			tier.Burst = mustAtoi(strings.TrimSpace(burst))
+		}
+		tiers[strings.TrimSpace(name)] = tier
+	}
+	return tiers
+}
+
+// parseTierWeights parses weights like "free=1,pro=4" into weights by tier; entries
+// without a positive weight are skipped
+func parseTierWeights(s string) map[string]int {
+	weights := make(map[string]int)
+	for _, entry := range strings.Split(s, ",") {
+		tier, value, found := strings.Cut(entry, "=")
+		tier = strings.TrimSpace(tier)
+		weight, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || tier == "" || err != nil || weight <= 0 {
+			continue
+		}
+		weights[tier] = weight
+	}
+	return weights
+}
+
+// parseCurrencyAliases parses aliases like "RMB=CNY,BUCK=USD" into codes keyed by alias,
+// both in upper case
+func parseCurrencyAliases(s string) map[string]string {
+	aliases := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		alias, code, found := strings.Cut(entry, "=")
+		alias, code = strings.ToUpper(strings.TrimSpace(alias)), strings.ToUpper(strings.TrimSpace(code))
+		if !found || alias == "" || code == "" {
+			continue
+		}
+		aliases[alias] = code
+	}
+	return aliases
+}
+
+// parseSourcePolicy parses a policy like "EUR=ecb|frankfurter,GBP=boe" into the providers
+// permitted per currency, in upper case; entries without a currency are skipped
+func parseSourcePolicy(s string) map[string][]string {
+	policy := make(map[string][]string)
+	for _, entry := range strings.Split(s, ",") {
+		code, providers, found := strings.Cut(entry, "=")
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !found || code == "" {
+			continue
+		}
+		permitted := []string{}
+		for _, provider := range strings.Split(providers, "|") {
+			if provider = strings.TrimSpace(provider); provider != "" {
+				permitted = append(permitted, provider)
+			}
+		}
+		policy[code] = permitted
+	}
+	return policy
+}
+
+// parseLatencyBudgets parses budgets like "/api/v1/rates=200,/api/v1/convert=300" into
+// target p95 latencies by name, in milliseconds; entries without a positive budget are
+// skipped
+func parseLatencyBudgets(s string) map[string]time.Duration {
+	budgets := make(map[string]time.Duration)
+	for _, entry := range strings.Split(s, ",") {
+		name, milliseconds, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		budget, err := strconv.Atoi(strings.TrimSpace(milliseconds))
+		if !found || name == "" || err != nil || budget <= 0 {
+			continue
+		}
+		budgets[name] = time.Duration(budget) * time.Millisecond
+	}
+	return budgets
+}
+
+// logFields parses static log fields like "env=production,region=eu-west-1" and adds the
+// service name as the "service" field unless it is empty
+func logFields(serviceName, s string) map[string]string {
+	fields := make(map[string]string)
+	if serviceName != "" {
+		fields["service"] = serviceName
+	}
+	for _, entry := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || strings.TrimSpace(key) == "" {
+			continue
+		}
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// parseList splits a comma-separated list, dropping empty entries
+func parseList(s string) []string {
+	values := []string{}
+	for _, entry := range strings.Split(s, ",") {
+		if trimmed := strings.TrimSpace(entry); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
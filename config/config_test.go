@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -39,7 +40,27 @@ func TestLoad(t *testing.T) {
 					cfg.RateLimitEnabled == true &&
 					cfg.RateLimitRequests == 100 &&
 					cfg.RateLimitWindow == 60*time.Second &&
-					cfg.RateLimitBurst == 10
+					cfg.RateLimitBurst == 10 &&
+					cfg.StartupChecks.Mode == "warn" &&
+					cfg.StartupChecks.Timeout == 10*time.Second &&
+					cfg.StartupChecks.CacheTTL == 10*time.Second &&
+					cfg.StartupChecks.ProbeTimeout == 2*time.Second &&
+					cfg.DatabaseURL == "" &&
+					cfg.DatabaseAutoMigrate == true &&
+					cfg.History.RawRetention == 30*24*time.Hour &&
+					cfg.History.HourlyRetention == 365*24*time.Hour &&
+					cfg.History.DailyRetention == 0 &&
+					cfg.History.CompactInterval == time.Hour &&
+					cfg.Stream.MaxSubscriptions == 20 &&
+					cfg.Stream.Base == "USD" &&
+					cfg.Stream.Heartbeat == 15*time.Second &&
+					cfg.Stream.BufferSize == 64 &&
+					cfg.Stream.Backpressure == "coalesce" &&
+					cfg.Logging.Backend == "logrus" &&
+					cfg.Logging.Format == "json" &&
+					reflect.DeepEqual(cfg.Logging.Outputs, []string{"stdout"}) &&
+					cfg.Logging.TimestampFormat == "rfc3339" &&
+					cfg.Logging.Fields["service"] == "currency-exchange-service"
 			},
 		},
 		{
@@ -68,6 +89,116 @@ func TestLoad(t *testing.T) {
 					cfg.RateLimitBurst == 20
 			},
 		},
+		{
+			name: "provider concurrency",
+			envVars: map[string]string{
+				"MAX_CONCURRENT_REQUESTS":    "6",
+				"FRANKFURTER_MAX_CONCURRENT": "2",
+				"PROVIDER_QUEUE_SIZE":        "8",
+			},
+			expected: func(cfg *Config) bool {
+				caps := map[string]int{}
+				for _, provider := range cfg.ExchangeRateProviders {
+					caps[provider.Name] = provider.MaxConcurrent
+				}
+				return caps["frankfurter"] == 2 &&
+					caps["erapi"] == 6 &&
+					cfg.ProviderQueueSize == 8
+			},
+		},
+		{
+			name: "dns configuration",
+			envVars: map[string]string{
+				"DNS_CACHE_TTL_SECONDS":  "300",
+				"DNS_RESOLVE_TIMEOUT_MS": "500",
+				"DNS_FALLBACK_RESOLVERS": "1.1.1.1:53, 8.8.8.8:53",
+			},
+			expected: func(cfg *Config) bool {
+				return cfg.DNS.CacheTTL == 300*time.Second &&
+					cfg.DNS.ResolveTimeout == 500*time.Millisecond &&
+					len(cfg.DNS.FallbackResolvers) == 2 &&
+					cfg.DNS.FallbackResolvers[1] == "8.8.8.8:53"
+			},
+		},
+		{
+			name: "logging configuration",
+			envVars: map[string]string{
+				"LOG_FORMAT":       "Console",
+				"LOG_OUTPUT":       "stdout, file",
+				"LOG_SERVICE_NAME": "fx-api",
+				"LOG_FIELDS":       "env=staging, region = eu-west-1,malformed",
+				"LOG_FILE":         "/var/log/fx.log",
+			},
+			expected: func(cfg *Config) bool {
+				return cfg.Logging.Format == "console" &&
+					reflect.DeepEqual(cfg.Logging.Outputs, []string{"stdout", "file"}) &&
+					reflect.DeepEqual(cfg.Logging.Fields, map[string]string{"service": "fx-api", "env": "staging", "region": "eu-west-1"}) &&
+					cfg.Logging.File == "/var/log/fx.log" &&
+					cfg.Logging.FileMaxSizeMB == 100 &&
+					cfg.Logging.FileMaxBackups == 5
+			},
+		},
+		{
+			name: "database configuration",
+			envVars: map[string]string{
+				"DATABASE_URL":                     "postgres://localhost:5432/rates",
+				"DATABASE_AUTO_MIGRATE":            "false",
+				"HISTORY_RAW_RETENTION_DAYS":       "7",
+				"HISTORY_COMPACT_INTERVAL_MINUTES": "15",
+			},
+			expected: func(cfg *Config) bool {
+				return cfg.DatabaseURL == "postgres://localhost:5432/rates" &&
+					cfg.DatabaseAutoMigrate == false &&
+					cfg.History.RawRetention == 7*24*time.Hour &&
+					cfg.History.CompactInterval == 15*time.Minute
+			},
+		},
+		{
+			name: "webhook configuration",
+			envVars: map[string]string{
+				"WEBHOOK_1_SOURCE":          "pricing-engine",
+				"WEBHOOK_1_SECRET":          "push-secret",
+				"WEBHOOK_2_SOURCE":          "no-secret",
+				"WEBHOOK_TOLERANCE_SECONDS": "60",
+			},
+			expected: func(cfg *Config) bool {
+				return len(cfg.WebhookSecrets) == 1 &&
+					cfg.WebhookSecrets["pricing-engine"] == "push-secret" &&
+					cfg.WebhookTolerance == 60*time.Second
+			},
+		},
+		{
+			name: "events configuration",
+			envVars: map[string]string{
+				"EVENTS_BROKER":                 "NATS",
+				"EVENTS_URL":                    "nats://localhost:4222",
+				"EVENTS_FORMAT":                 "avro",
+				"EVENTS_MOVE_THRESHOLD_PERCENT": "0.5",
+			},
+			expected: func(cfg *Config) bool {
+				return cfg.Events.Broker == "nats" &&
+					cfg.Events.URL == "nats://localhost:4222" &&
+					cfg.Events.Topic == "rates" &&
+					cfg.Events.Format == "avro" &&
+					cfg.Events.MoveThresholdPercent == 0.5
+			},
+		},
+		{
+			name: "mqtt configuration",
+			envVars: map[string]string{
+				"MQTT_URL":   "mqtt://broker:1883",
+				"MQTT_PAIRS": "usd/eur, EUR/GBP",
+				"MQTT_QOS":   "1",
+			},
+			expected: func(cfg *Config) bool {
+				return cfg.MQTT.URL == "mqtt://broker:1883" &&
+					len(cfg.MQTT.Pairs) == 2 &&
+					cfg.MQTT.Pairs[0] == "USD/EUR" &&
+					cfg.MQTT.TopicTemplate == "rates/{from}/{to}" &&
+					cfg.MQTT.QoS == 1 &&
+					cfg.MQTT.Retain
+			},
+		},
 		{
 			name: "provider configuration",
 			envVars: map[string]string{
@@ -164,6 +295,15 @@ func TestLoadExchangeRateProviders(t *testing.T) {
 			},
 			expected: 6,
 		},
+		{
+			name: "with a composite provider",
+			envVars: map[string]string{
+				"PROVIDER_1_NAME":    "blended",
+				"PROVIDER_1_TYPE":    "composite",
+				"PROVIDER_1_MEMBERS": "erapi,frankfurter",
+			},
+			expected: 5,
+		},
 	}
 
 	for _, tt := range tests {
@@ -177,7 +317,7 @@ func TestLoadExchangeRateProviders(t *testing.T) {
 			}
 
 			// Load providers
-			providers := loadExchangeRateProviders()
+			providers := loadExchangeRateProviders(&secretLoader{})
 
 			// Count enabled providers
 			enabledCount := 0
@@ -194,6 +334,23 @@ func TestLoadExchangeRateProviders(t *testing.T) {
 	}
 }
 
+func TestLoadAdditionalProviders_Composite(t *testing.T) {
+	t.Setenv("PROVIDER_1_NAME", "blended")
+	t.Setenv("PROVIDER_1_TYPE", "Composite")
+	t.Setenv("PROVIDER_1_MEMBERS", "erapi, frankfurter")
+	t.Setenv("PROVIDER_1_COMBINE", "trimmed_mean")
+
+	providers := loadAdditionalProviders(&secretLoader{})
+	if len(providers) != 1 {
+		t.Fatalf("loadAdditionalProviders() = %d providers, want the composite without a base URL", len(providers))
+	}
+	composite := providers[0]
+	if composite.Type != ProviderTypeComposite || !reflect.DeepEqual(composite.Members, []string{"erapi", "frankfurter"}) ||
+		composite.Combine != "trimmed_mean" || composite.TrimPercent != 20 || composite.MinMembers != 2 {
+		t.Errorf("loadAdditionalProviders() composite = %+v", composite)
+	}
+}
+
 func TestGetEnv(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -266,3 +423,262 @@ func TestMustAtoi(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePairValues(t *testing.T) {
+	result := parsePairValues("usd/eur=25, EUR/GBP=10,invalid,GBP/JPY=abc")
+
+	if len(result) != 2 {
+		t.Fatalf("parsePairValues() length = %v, want %v", len(result), 2)
+	}
+	if result["USD/EUR"] != 25 {
+		t.Errorf("parsePairValues() USD/EUR = %v, want %v", result["USD/EUR"], 25)
+	}
+	if result["EUR/GBP"] != 10 {
+		t.Errorf("parsePairValues() EUR/GBP = %v, want %v", result["EUR/GBP"], 10)
+	}
+}
+
+func TestLoadTenants(t *testing.T) {
+	os.Setenv("TENANT_1_ID", "acme")
+	os.Setenv("TENANT_1_API_KEYS", "key-1, key-2")
+	os.Setenv("TENANT_1_PROVIDERS", "frankfurter")
+	os.Setenv("TENANT_1_MARKUP_GLOBAL_BPS", "40")
+	os.Setenv("TENANT_1_ALLOWED_CURRENCIES", "usd,eur")
+	os.Setenv("TENANT_1_DAILY_QUOTA", "500")
+	os.Setenv("TENANT_2_ID", "no-keys")
+	defer func() {
+		for _, key := range []string{"TENANT_1_ID", "TENANT_1_API_KEYS", "TENANT_1_PROVIDERS", "TENANT_1_MARKUP_GLOBAL_BPS", "TENANT_1_ALLOWED_CURRENCIES", "TENANT_1_DAILY_QUOTA", "TENANT_2_ID"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	tenants := loadTenants(MarkupConfig{GlobalBPS: 10, FixedFee: 1}, 100, 10, QuotaConfig{DailyRequests: 1000, MonthlyRequests: 20000}, false, &secretLoader{})
+
+	if len(tenants) != 1 {
+		t.Fatalf("loadTenants() length = %v, want %v", len(tenants), 1)
+	}
+	tenant := tenants[0]
+	if tenant.ID != "acme" || len(tenant.APIKeys) != 2 || tenant.APIKeys[1] != "key-2" {
+		t.Errorf("loadTenants() tenant = %+v", tenant)
+	}
+	if tenant.Markup.GlobalBPS != 40 || tenant.Markup.FixedFee != 1 {
+		t.Errorf("loadTenants() markup = %+v, want GlobalBPS 40 and inherited FixedFee 1", tenant.Markup)
+	}
+	if tenant.RateLimitRequests != 100 || tenant.RateLimitBurst != 10 {
+		t.Errorf("loadTenants() limits = %v/%v, want 100/10", tenant.RateLimitRequests, tenant.RateLimitBurst)
+	}
+	if len(tenant.AllowedCurrencies) != 2 || tenant.AllowedCurrencies[0] != "USD" {
+		t.Errorf("loadTenants() AllowedCurrencies = %v", tenant.AllowedCurrencies)
+	}
+	if tenant.DailyQuota != 500 || tenant.MonthlyQuota != 20000 {
+		t.Errorf("loadTenants() quotas = %v/%v, want 500 and the inherited 20000", tenant.DailyQuota, tenant.MonthlyQuota)
+	}
+
+	// Tenants without keys can still authenticate with JWTs
+	if tenants := loadTenants(MarkupConfig{}, 100, 10, QuotaConfig{}, true, &secretLoader{}); len(tenants) != 2 || tenants[1].ID != "no-keys" {
+		t.Errorf("loadTenants() with JWTs = %+v, want the tenant without keys too", tenants)
+	}
+}
+
+func TestLoadOAuthClients(t *testing.T) {
+	os.Setenv("OAUTH_CLIENT_1_ID", "billing")
+	os.Setenv("OAUTH_CLIENT_1_SECRETS", "new-secret, old-secret")
+	os.Setenv("OAUTH_CLIENT_1_TENANT", "acme")
+	os.Setenv("OAUTH_CLIENT_1_TIER", "pro")
+	os.Setenv("OAUTH_CLIENT_2_ID", "no-secret")
+	os.Setenv("OAUTH_CLIENT_2_TENANT", "acme")
+	defer func() {
+		for _, key := range []string{"OAUTH_CLIENT_1_ID", "OAUTH_CLIENT_1_SECRETS", "OAUTH_CLIENT_1_TENANT", "OAUTH_CLIENT_1_TIER", "OAUTH_CLIENT_2_ID", "OAUTH_CLIENT_2_TENANT"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	clients := loadOAuthClients(&secretLoader{})
+	if len(clients) != 1 {
+		t.Fatalf("loadOAuthClients() = %+v, want only the client with secrets", clients)
+	}
+	client := clients[0]
+	if client.ID != "billing" || len(client.Secrets) != 2 || client.Secrets[1] != "old-secret" || client.Tenant != "acme" || client.Tier != "pro" {
+		t.Errorf("loadOAuthClients() client = %+v", client)
+	}
+}
+
+func TestLoadAdminKeys(t *testing.T) {
+	os.Setenv("ADMIN_KEY_1_NAME", "oncall")
+	os.Setenv("ADMIN_KEY_1_ROLE", "Operator")
+	os.Setenv("ADMIN_KEY_1_KEYS", "new-key, old-key")
+	os.Setenv("ADMIN_KEY_2_NAME", "dashboard")
+	os.Setenv("ADMIN_KEY_2_KEYS", "dashboard-key")
+	os.Setenv("ADMIN_KEY_3_NAME", "no-keys")
+	defer func() {
+		for _, key := range []string{"ADMIN_KEY_1_NAME", "ADMIN_KEY_1_ROLE", "ADMIN_KEY_1_KEYS", "ADMIN_KEY_2_NAME", "ADMIN_KEY_2_KEYS", "ADMIN_KEY_3_NAME"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	adminKeys := loadAdminKeys(&secretLoader{})
+	if len(adminKeys) != 2 {
+		t.Fatalf("loadAdminKeys() = %+v, want the two keys with keys", adminKeys)
+	}
+	if adminKeys[0].Name != "oncall" || adminKeys[0].Role != AdminRoleOperator || len(adminKeys[0].Keys) != 2 || adminKeys[0].Keys[1] != "old-key" {
+		t.Errorf("loadAdminKeys() key = %+v", adminKeys[0])
+	}
+	if adminKeys[1].Role != AdminRoleViewer {
+		t.Errorf("loadAdminKeys() role = %q, want the viewer default", adminKeys[1].Role)
+	}
+}
+
+func TestValidateAdminKeys(t *testing.T) {
+	public := func(configuration Config) *Config {
+		configuration.Tenants = []Tenant{{ID: "acme", APIKeys: []string{"tenant-key"}}}
+		configuration.OAuth.Clients = []OAuthClient{{ID: "billing", Secrets: []string{"client-secret"}, Tenant: "acme"}}
+		return &configuration
+	}
+	tests := []struct {
+		name          string
+		configuration *Config
+		wantErr       bool
+	}{
+		{name: "distinct keys", configuration: public(Config{AdminAPIKey: "admin-key", AdminKeys: []AdminKey{{Name: "oncall", Role: AdminRoleOperator, Keys: []string{"oncall-key"}}}})},
+		{name: "admin key of a tenant", configuration: public(Config{AdminAPIKey: "tenant-key"}), wantErr: true},
+		{name: "named key of an OAuth client", configuration: public(Config{AdminKeys: []AdminKey{{Name: "oncall", Role: AdminRoleViewer, Keys: []string{"client-secret"}}}}), wantErr: true},
+		{name: "unknown role", configuration: public(Config{AdminKeys: []AdminKey{{Name: "oncall", Role: "root", Keys: []string{"oncall-key"}}}}), wantErr: true},
+		{name: "name of ADMIN_API_KEY", configuration: public(Config{AdminAPIKey: "admin-key", AdminKeys: []AdminKey{{Name: "admin", Role: AdminRoleViewer, Keys: []string{"other-key"}}}}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAdminKeys(tt.configuration); (err != nil) != tt.wantErr {
+				t.Errorf("validateAdminKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_JWTRequiresIssuerAndAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   string
+		audience string
+		wantErr  bool
+	}{
+		{name: "issuer and audience", issuer: "https://auth.example.com/", audience: "currency-api"},
+		{name: "no issuer", audience: "currency-api", wantErr: true},
+		{name: "no audience", issuer: "https://auth.example.com/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			t.Setenv("JWT_JWKS_URL", "https://auth.example.com/.well-known/jwks.json")
+			t.Setenv("JWT_ISSUER", tt.issuer)
+			t.Setenv("JWT_AUDIENCE", tt.audience)
+
+			if _, err := Load(); (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadAlerting(t *testing.T) {
+	os.Setenv("ALERT_RULE_1_NAME", "stale_rates")
+	os.Setenv("ALERT_RULE_1_METRIC", "Cache_Staleness")
+	os.Setenv("ALERT_RULE_1_THRESHOLD", "600")
+	os.Setenv("ALERT_RULE_1_FOR_SECONDS", "120")
+	os.Setenv("ALERT_RULE_1_SINKS", "ops, log")
+	os.Setenv("ALERT_SINK_1_NAME", "ops")
+	os.Setenv("ALERT_SINK_1_TYPE", "Slack")
+	os.Setenv("ALERT_SINK_1_URL", "https://hooks.example.com/T0")
+	defer func() {
+		for _, key := range []string{"ALERT_RULE_1_NAME", "ALERT_RULE_1_METRIC", "ALERT_RULE_1_THRESHOLD", "ALERT_RULE_1_FOR_SECONDS", "ALERT_RULE_1_SINKS", "ALERT_SINK_1_NAME", "ALERT_SINK_1_TYPE", "ALERT_SINK_1_URL"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	rules := loadAlertRules()
+	want := AlertRule{Name: "stale_rates", Metric: "cache_staleness", Threshold: 600, For: 2 * time.Minute, Severity: "warning", Sinks: []string{"ops", "log"}}
+	if len(rules) != 1 || !reflect.DeepEqual(rules[0], want) {
+		t.Errorf("loadAlertRules() = %+v, want %+v", rules, want)
+	}
+	sinks := loadAlertSinks(&secretLoader{})
+	if len(sinks) != 1 || sinks[0] != (AlertSink{Name: "ops", Type: "slack", URL: "https://hooks.example.com/T0"}) {
+		t.Errorf("loadAlertSinks() = %+v", sinks)
+	}
+}
+
+func TestLoadSignatureSecrets(t *testing.T) {
+	os.Setenv("SIGNATURE_1_API_KEY", "partner-key")
+	os.Setenv("SIGNATURE_1_SECRET", "shared-secret")
+	os.Setenv("SIGNATURE_2_API_KEY", "no-secret-key")
+	defer func() {
+		for _, key := range []string{"SIGNATURE_1_API_KEY", "SIGNATURE_1_SECRET", "SIGNATURE_2_API_KEY"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	secrets := loadSignatureSecrets(&secretLoader{})
+	if len(secrets) != 1 || secrets["partner-key"] != "shared-secret" {
+		t.Errorf("loadSignatureSecrets() = %v, want only the key with a secret", secrets)
+	}
+}
+
+func TestParseRateLimitTiers(t *testing.T) {
+	tiers := parseRateLimitTiers("free=60:5, pro=1000, =10, broken", 10)
+	want := map[string]RateLimitTier{"free": {Requests: 60, Burst: 5}, "pro": {Requests: 1000, Burst: 10}}
+	if len(tiers) != len(want) {
+		t.Fatalf("parseRateLimitTiers() = %v, want %v", tiers, want)
+	}
+	for name, tier := range want {
+		if tiers[name] != tier {
+			t.Errorf("parseRateLimitTiers()[%q] = %+v, want %+v", name, tiers[name], tier)
+		}
+	}
+}
+
+func TestParseCurrencyAliases(t *testing.T) {
+	aliases := parseCurrencyAliases("rmb=cny, Buck = usd, =EUR, broken")
+	want := map[string]string{"RMB": "CNY", "BUCK": "USD"}
+	if len(aliases) != len(want) {
+		t.Fatalf("parseCurrencyAliases() = %v, want %v", aliases, want)
+	}
+	for alias, code := range want {
+		if aliases[alias] != code {
+			t.Errorf("parseCurrencyAliases()[%q] = %q, want %q", alias, aliases[alias], code)
+		}
+	}
+}
+
+func TestParseSourcePolicy(t *testing.T) {
+	policy := parseSourcePolicy("eur = ecb | frankfurter, GBP=boe, =erapi, broken, JPY=")
+	want := map[string][]string{"EUR": {"ecb", "frankfurter"}, "GBP": {"boe"}, "JPY": {}}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("parseSourcePolicy() = %v, want %v", policy, want)
+	}
+}
+
+func TestParseLatencyBudgets(t *testing.T) {
+	budgets := parseLatencyBudgets(" /api/v1/rates = 200,erapi=1500, /health=0, =100, broken, /x=abc")
+	want := map[string]time.Duration{"/api/v1/rates": 200 * time.Millisecond, "erapi": 1500 * time.Millisecond}
+	if len(budgets) != len(want) {
+		t.Fatalf("parseLatencyBudgets() = %v, want %v", budgets, want)
+	}
+	for name, budget := range want {
+		if budgets[name] != budget {
+			t.Errorf("parseLatencyBudgets()[%q] = %v, want %v", name, budgets[name], budget)
+		}
+	}
+}
+
+func TestParseTierWeights(t *testing.T) {
+	weights := parseTierWeights(" free = 1,pro=4, enterprise=0, =2, broken, gold=x")
+	want := map[string]int{"free": 1, "pro": 4}
+	if len(weights) != len(want) {
+		t.Fatalf("parseTierWeights() = %v, want %v", weights, want)
+	}
+	for tier, weight := range want {
+		if weights[tier] != weight {
+			t.Errorf("parseTierWeights()[%q] = %d, want %d", tier, weights[tier], weight)
+		}
+	}
+}
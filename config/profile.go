@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// providerSimulatorURL is where cmd/providersim listens by default
+const providerSimulatorURL = "http://localhost:9090"
+
+// profiles holds the defaults of each PROFILE. A profile default applies to a variable
+// left unset in the environment and replaces the built-in default.
+var profiles = map[string]map[string]string{
+	// Local development against cmd/providersim, with readable logs and no rate limits
+	"dev": {
+		"LOG_LEVEL":                    "debug",
+		"LOG_FORMAT":                   "console",
+		"GIN_MODE":                     "debug",
+		"RATE_LIMIT_ENABLED":           "false",
+		"STARTUP_CHECK_MODE":           "lazy",
+		"EXCHANGE_RATE_API_BASE_URL":   providerSimulatorURL + "/erapi/v6/latest",
+		"OPEN_EXCHANGE_RATES_BASE_URL": providerSimulatorURL + "/openexchangerates/api/latest.json",
+		"FRANKFURTER_API_BASE_URL":     providerSimulatorURL + "/frankfurter/latest",
+		"EXCHANGE_RATE_HOST_BASE_URL":  providerSimulatorURL + "/exchangeratehost/latest",
+	},
+	// Real providers, with debug logs and rate limits that log instead of rejecting
+	"staging": {
+		"LOG_LEVEL":          "debug",
+		"LOG_FORMAT":         "json",
+		"GIN_MODE":           "release",
+		"RATE_LIMIT_ENABLED": "true",
+		"RATE_LIMIT_SHADOW":  "true",
+	},
+	// Real providers and enforced rate limits
+	"prod": {
+		"LOG_LEVEL":          "info",
+		"LOG_FORMAT":         "json",
+		"GIN_MODE":           "release",
+		"RATE_LIMIT_ENABLED": "true",
+		"RATE_LIMIT_SHADOW":  "false",
+	},
+}
+
+// checkProfile returns the selected profile, or an error when PROFILE names none
+func checkProfile() (string, error) {
+	profile := strings.ToLower(os.Getenv("PROFILE"))
+	if _, found := profiles[profile]; profile != "" && !found {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown PROFILE %q (expected %s)", profile, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// profileDefault returns the default the selected profile sets for a variable
+func profileDefault(key string) (string, bool) {
+	value, found := profiles[strings.ToLower(os.Getenv("PROFILE"))][key]
+	return value, found
+}
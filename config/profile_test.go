@@ -0,0 +1,88 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad_Profile(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_FORMAT", "text")
+	t.Setenv("RATE_LIMIT_ENABLED", "")
+	t.Setenv("GIN_MODE", "")
+	t.Setenv("FRANKFURTER_API_BASE_URL", "")
+
+	tests := []struct {
+		name          string
+		profile       string
+		wantLogLevel  string
+		wantRateLimit bool
+		wantGinMode   string
+		wantSimulator bool
+	}{
+		{name: "no profile", profile: "", wantLogLevel: "info", wantRateLimit: true, wantGinMode: "release"},
+		{name: "dev", profile: "dev", wantLogLevel: "debug", wantRateLimit: false, wantGinMode: "debug", wantSimulator: true},
+		{name: "staging", profile: "staging", wantLogLevel: "debug", wantRateLimit: true, wantGinMode: "release"},
+		{name: "prod in capitals", profile: "PROD", wantLogLevel: "info", wantRateLimit: true, wantGinMode: "release"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROFILE", tt.profile)
+
+			configuration, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if configuration.LogLevel != tt.wantLogLevel || configuration.RateLimitEnabled != tt.wantRateLimit || configuration.Router.GinMode != tt.wantGinMode {
+				t.Errorf("Load() LogLevel = %q, RateLimitEnabled = %v, GinMode = %q, want %q, %v, %q",
+					configuration.LogLevel, configuration.RateLimitEnabled, configuration.Router.GinMode, tt.wantLogLevel, tt.wantRateLimit, tt.wantGinMode)
+			}
+			// Set variables override the profile
+			if configuration.Logging.Format != "text" {
+				t.Errorf("Load() Logging.Format = %q, want the environment's text", configuration.Logging.Format)
+			}
+			for _, provider := range configuration.ExchangeRateProviders {
+				if provider.Name == "frankfurter" && strings.HasPrefix(provider.BaseURL, providerSimulatorURL) != tt.wantSimulator {
+					t.Errorf("Load() frankfurter BaseURL = %s, want the simulator %v", provider.BaseURL, tt.wantSimulator)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_ProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		ginMode string
+	}{
+		{name: "unknown profile", profile: "qa"},
+		{name: "invalid Gin mode", ginMode: "verbose"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROFILE", tt.profile)
+			t.Setenv("GIN_MODE", tt.ginMode)
+
+			if _, err := Load(); err == nil {
+				t.Error("Load() expected error")
+			}
+		})
+	}
+}
+
+func TestConfig_SourcesProfile(t *testing.T) {
+	t.Setenv("PROFILE", "dev")
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_BACKEND", "")
+
+	configuration, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if sources := configuration.Sources(); sources["LOG_LEVEL"] != SourceProfile || sources["LOG_BACKEND"] != SourceDefault {
+		t.Errorf("Sources() LOG_LEVEL = %q, LOG_BACKEND = %q, want profile and default", sources["LOG_LEVEL"], sources["LOG_BACKEND"])
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/secrets"
+)
+
+// secretReferencePrefix marks a variable value naming a secret in the secret manager
+const secretReferencePrefix = "secret:"
+
+// secretTimeout bounds reading one secret from the secret manager
+const secretTimeout = 10 * time.Second
+
+// SecretsConfig selects the secret manager that "secret:" variable values are read from
+type SecretsConfig struct {
+	Provider        string        // vault or aws ("" = no secret manager)
+	RefreshInterval time.Duration // How often secrets are re-read to pick up rotated values (0 = never)
+
+	// Whether any secret was read from a file or the secret manager, and so can rotate
+	External bool
+
+	VaultAddress string
+	VaultToken   string `secret:"true"`
+	VaultMount   string // Mount path of the KV version 2 engine
+
+	AWSRegion      string
+	AWSEndpoint    string // Secrets Manager endpoint (empty = the region's public endpoint)
+	AWSCredentials secrets.AWSCredentials
+}
+
+// loadSecretsConfig loads the secret manager settings. Its own credentials can be read
+// from files, but not from the secret manager.
+func loadSecretsConfig(loader *secretLoader) SecretsConfig {
+	return SecretsConfig{
+		Provider:        strings.ToLower(getEnv("SECRETS_PROVIDER", "")),
+		RefreshInterval: time.Duration(mustAtoi(getEnv("SECRETS_REFRESH_INTERVAL_SECONDS", "300"))) * time.Second,
+
+		VaultAddress: getEnv("VAULT_ADDR", "http://127.0.0.1:8200"),
+		VaultToken:   loader.file("VAULT_TOKEN"),
+		VaultMount:   getEnv("VAULT_KV_MOUNT", "secret"),
+
+		AWSRegion:   getEnv("AWS_REGION", getEnv("AWS_DEFAULT_REGION", "")),
+		AWSEndpoint: getEnv("SECRETS_AWS_ENDPOINT", ""),
+		AWSCredentials: secrets.AWSCredentials{
+			AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey: loader.file("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    loader.file("AWS_SESSION_TOKEN"),
+		},
+	}
+}
+
+// newSecretProvider creates the configured secret manager client, or nil without one
+func newSecretProvider(configuration SecretsConfig) (secrets.Provider, error) {
+	switch configuration.Provider {
+	case "":
+		return nil, nil
+	case "vault":
+		if configuration.VaultToken == "" {
+			return nil, errors.New("SECRETS_PROVIDER=vault requires VAULT_TOKEN")
+		}
+		return secrets.NewVaultProvider(configuration.VaultAddress, configuration.VaultToken, configuration.VaultMount, nil), nil
+	case "aws":
+		if configuration.AWSRegion == "" || configuration.AWSCredentials.AccessKeyID == "" || configuration.AWSCredentials.SecretAccessKey == "" {
+			return nil, errors.New("SECRETS_PROVIDER=aws requires AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return secrets.NewAWSSecretsManagerProvider(configuration.AWSRegion, configuration.AWSEndpoint, configuration.AWSCredentials, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q (expected vault or aws)", configuration.Provider)
+	}
+}
+
+// secretLoader reads secret variables: from the environment, from the file named by the
+// variable's _FILE variant (e.g. OPEN_EXCHANGE_RATES_API_KEY_FILE), or from the secret
+// manager when the value is a "secret:<name>" reference. The first failure is kept.
+type secretLoader struct {
+	provider secrets.Provider
+	external bool
+	err      error
+}
+
+// get returns a secret variable, or fallback when it is unset
+func (loader *secretLoader) get(key, fallback string) string {
+	if os.Getenv(key+"_FILE") != "" {
+		return loader.file(key)
+	}
+
+	value := getEnv(key, fallback)
+	name, isReference := strings.CutPrefix(value, secretReferencePrefix)
+	if !isReference {
+		return value
+	}
+	if loader.provider == nil {
+		loader.fail(fmt.Errorf("%s references a secret but SECRETS_PROVIDER is not set", key))
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	secret, err := loader.provider.Secret(ctx, name)
+	if err != nil {
+		loader.fail(fmt.Errorf("failed to read %s: %w", key, err))
+		return ""
+	}
+	loader.external = true
+	return secret
+}
+
+// file returns the contents of the file named by the variable's _FILE variant without
+// surrounding whitespace, or the variable itself when no file is named
+func (loader *secretLoader) file(key string) string {
+	recordVariable(key)
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return os.Getenv(key)
+	}
+	if os.Getenv(key) != "" {
+		loader.fail(fmt.Errorf("both %s and %s_FILE are set", key, key))
+		return ""
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		loader.fail(fmt.Errorf("failed to read %s_FILE: %w", key, err))
+		return ""
+	}
+	loader.external = true
+	return strings.TrimSpace(string(contents))
+}
+
+// fail records the first failure
+func (loader *secretLoader) fail(err error) {
+	if loader.err == nil {
+		loader.err = err
+	}
+}
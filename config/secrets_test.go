@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/secrets"
+)
+
+// staticSecrets serves secrets from a map
+type staticSecrets map[string]string
+
+func (values staticSecrets) Secret(ctx context.Context, name string) (string, error) {
+	if value, found := values[name]; found {
+		return value, nil
+	}
+	return "", secrets.ErrNotFound
+}
+
+func TestSecretLoader_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oxr-key")
+	if err := os.WriteFile(path, []byte("file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("OPEN_EXCHANGE_RATES_API_KEY_FILE", path)
+	defer os.Unsetenv("OPEN_EXCHANGE_RATES_API_KEY_FILE")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, provider := range cfg.ExchangeRateProviders {
+		if provider.Name == "openexchangerates" && provider.APIKey != "file-key" {
+			t.Errorf("openexchangerates APIKey = %q, want the file contents", provider.APIKey)
+		}
+	}
+	if !cfg.Secrets.External {
+		t.Error("Secrets.External = false, want true for a secret read from a file")
+	}
+
+	// A variable set both ways, or naming a missing file, fails the load
+	os.Setenv("OPEN_EXCHANGE_RATES_API_KEY", "env-key")
+	if _, err := Load(); err == nil {
+		t.Error("Load() with both a variable and its _FILE variant expected an error")
+	}
+	os.Unsetenv("OPEN_EXCHANGE_RATES_API_KEY")
+	os.Setenv("OPEN_EXCHANGE_RATES_API_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := Load(); err == nil {
+		t.Error("Load() with a missing secret file expected an error")
+	}
+}
+
+func TestSecretLoader_References(t *testing.T) {
+	os.Setenv("ADMIN_API_KEY", "secret:currency/admin#key")
+	os.Setenv("TENANT_API_KEYS_TEST", "secret:currency/tenants#missing")
+	os.Setenv("PLAIN_SECRET_TEST", "plain-value")
+	defer func() {
+		for _, key := range []string{"ADMIN_API_KEY", "TENANT_API_KEYS_TEST", "PLAIN_SECRET_TEST"} {
+			os.Unsetenv(key)
+		}
+	}()
+
+	loader := &secretLoader{provider: staticSecrets{"currency/admin#key": "admin-from-vault"}}
+	if got := loader.get("ADMIN_API_KEY", ""); got != "admin-from-vault" || !loader.external {
+		t.Errorf("get() = %q, external = %v, want the referenced secret", got, loader.external)
+	}
+	if got := loader.get("PLAIN_SECRET_TEST", ""); got != "plain-value" || loader.err != nil {
+		t.Errorf("get() of a plain value = %q, %v", got, loader.err)
+	}
+	if loader.get("TENANT_API_KEYS_TEST", ""); loader.err == nil {
+		t.Error("get() of a missing secret expected an error")
+	}
+
+	withoutProvider := &secretLoader{}
+	if withoutProvider.get("ADMIN_API_KEY", ""); withoutProvider.err == nil {
+		t.Error("get() of a reference without a secret manager expected an error")
+	}
+}
+
+func TestNewSecretProvider(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration SecretsConfig
+		wantProvider  bool
+		wantErr       bool
+	}{
+		{"none", SecretsConfig{}, false, false},
+		{"vault", SecretsConfig{Provider: "vault", VaultAddress: "http://vault:8200", VaultToken: "token", VaultMount: "secret"}, true, false},
+		{"vault without token", SecretsConfig{Provider: "vault"}, false, true},
+		{"aws", SecretsConfig{Provider: "aws", AWSRegion: "eu-west-1", AWSCredentials: secrets.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}}, true, false},
+		{"aws without credentials", SecretsConfig{Provider: "aws", AWSRegion: "eu-west-1"}, false, true},
+		{"unknown", SecretsConfig{Provider: "keychain"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := newSecretProvider(tt.configuration)
+			if (err != nil) != tt.wantErr || (provider != nil) != tt.wantProvider {
+				t.Errorf("newSecretProvider() = %v, %v, want provider %v, error %v", provider, err, tt.wantProvider, tt.wantErr)
+			}
+		})
+	}
+}
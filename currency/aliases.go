@@ -0,0 +1,75 @@
+package currency
+
+import (
+	"strings"
+	"sync"
+)
+
+// defaultAliases map common symbols and names, in upper case, to ISO 4217 codes
+var defaultAliases = map[string]string{
+	"$":    "USD",
+	"US$":  "USD",
+	"€":    "EUR",
+	"EURO": "EUR",
+	"£":    "GBP",
+	"¥":    "JPY",
+	"JP¥":  "JPY",
+	"CN¥":  "CNY",
+	"RMB":  "CNY",
+	"YUAN": "CNY",
+	"A$":   "AUD",
+	"AU$":  "AUD",
+	"C$":   "CAD",
+	"CA$":  "CAD",
+	"NZ$":  "NZD",
+	"HK$":  "HKD",
+	"S$":   "SGD",
+	"R$":   "BRL",
+	"MX$":  "MXN",
+	"SFR":  "CHF",
+	"₹":    "INR",
+	"₩":    "KRW",
+	"₽":    "RUB",
+	"₺":    "TRY",
+	"₪":    "ILS",
+	"NIS":  "ILS",
+	"₱":    "PHP",
+	"฿":    "THB",
+	"ZŁ":   "PLN",
+	"KČ":   "CZK",
+}
+
+var (
+	aliasesMutex sync.RWMutex
+	aliases      = defaultAliases
+)
+
+// SetAliases adds aliases to the default table, replacing defaults with the same alias.
+// Aliases are matched case-insensitively.
+func SetAliases(overrides map[string]string) {
+	merged := make(map[string]string, len(defaultAliases)+len(overrides))
+	for alias, code := range defaultAliases {
+		merged[alias] = code
+	}
+	for alias, code := range overrides {
+		merged[strings.ToUpper(strings.TrimSpace(alias))] = strings.ToUpper(strings.TrimSpace(code))
+	}
+
+	aliasesMutex.Lock()
+	defer aliasesMutex.Unlock()
+	aliases = merged
+}
+
+// Normalize returns the currency code of sloppy input: surrounding space is trimmed, case
+// is ignored and aliases such as "€" or "RMB" are resolved. Input that is no alias is
+// returned in upper case, to be validated as a code.
+func Normalize(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	aliasesMutex.RLock()
+	defer aliasesMutex.RUnlock()
+	if code, found := aliases[value]; found {
+		return code
+	}
+	return value
+}
@@ -0,0 +1,84 @@
+package currency
+
+import "sort"
+
+// Type classifies a currency code
+type Type string
+
+const (
+	TypeFiat  Type = "fiat"
+	TypeMetal Type = "metal"
+)
+
+// Currency describes a supported currency or special unit
+type Currency struct {
+	Code string `json:"code" xml:"code"`
+	Name string `json:"name" xml:"name"`
+	Type Type   `json:"type" xml:"type"`
+	Unit string `json:"unit,omitempty" xml:"unit,omitempty"` // Unit of account for non-fiat codes, e.g. "troy ounce"
+}
+
+// table holds all known currencies keyed by ISO 4217 code
+var table = map[string]Currency{
+	"USD": {Code: "USD", Name: "US Dollar", Type: TypeFiat},
+	"EUR": {Code: "EUR", Name: "Euro", Type: TypeFiat},
+	"GBP": {Code: "GBP", Name: "British Pound", Type: TypeFiat},
+	"JPY": {Code: "JPY", Name: "Japanese Yen", Type: TypeFiat},
+	"AUD": {Code: "AUD", Name: "Australian Dollar", Type: TypeFiat},
+	"CAD": {Code: "CAD", Name: "Canadian Dollar", Type: TypeFiat},
+	"CHF": {Code: "CHF", Name: "Swiss Franc", Type: TypeFiat},
+	"CNY": {Code: "CNY", Name: "Chinese Yuan", Type: TypeFiat},
+	"SEK": {Code: "SEK", Name: "Swedish Krona", Type: TypeFiat},
+	"NZD": {Code: "NZD", Name: "New Zealand Dollar", Type: TypeFiat},
+	"BRL": {Code: "BRL", Name: "Brazilian Real", Type: TypeFiat},
+	"RUB": {Code: "RUB", Name: "Russian Ruble", Type: TypeFiat},
+	"INR": {Code: "INR", Name: "Indian Rupee", Type: TypeFiat},
+	"KRW": {Code: "KRW", Name: "South Korean Won", Type: TypeFiat},
+	"SGD": {Code: "SGD", Name: "Singapore Dollar", Type: TypeFiat},
+	"HKD": {Code: "HKD", Name: "Hong Kong Dollar", Type: TypeFiat},
+	"NOK": {Code: "NOK", Name: "Norwegian Krone", Type: TypeFiat},
+	"MXN": {Code: "MXN", Name: "Mexican Peso", Type: TypeFiat},
+	"TRY": {Code: "TRY", Name: "Turkish Lira", Type: TypeFiat},
+	"ZAR": {Code: "ZAR", Name: "South African Rand", Type: TypeFiat},
+	"PLN": {Code: "PLN", Name: "Polish Zloty", Type: TypeFiat},
+	"CZK": {Code: "CZK", Name: "Czech Koruna", Type: TypeFiat},
+	"HUF": {Code: "HUF", Name: "Hungarian Forint", Type: TypeFiat},
+	"ILS": {Code: "ILS", Name: "Israeli New Shekel", Type: TypeFiat},
+	"CLP": {Code: "CLP", Name: "Chilean Peso", Type: TypeFiat},
+	"PHP": {Code: "PHP", Name: "Philippine Peso", Type: TypeFiat},
+	"AED": {Code: "AED", Name: "UAE Dirham", Type: TypeFiat},
+	"COP": {Code: "COP", Name: "Colombian Peso", Type: TypeFiat},
+	"SAR": {Code: "SAR", Name: "Saudi Riyal", Type: TypeFiat},
+	"THB": {Code: "THB", Name: "Thai Baht", Type: TypeFiat},
+	"DKK": {Code: "DKK", Name: "Danish Krone", Type: TypeFiat},
+
+	// Precious metals are quoted per troy ounce
+	"XAU": {Code: "XAU", Name: "Gold", Type: TypeMetal, Unit: "troy ounce"},
+	"XAG": {Code: "XAG", Name: "Silver", Type: TypeMetal, Unit: "troy ounce"},
+	"XPT": {Code: "XPT", Name: "Platinum", Type: TypeMetal, Unit: "troy ounce"},
+	"XPD": {Code: "XPD", Name: "Palladium", Type: TypeMetal, Unit: "troy ounce"},
+}
+
+// Lookup returns the currency for a code
+func Lookup(code string) (Currency, bool) {
+	currency, found := table[code]
+	return currency, found
+}
+
+// IsMetal reports whether the code is a precious metal
+func IsMetal(code string) bool {
+	currency, found := table[code]
+	return found && currency.Type == TypeMetal
+}
+
+// All returns every known currency sorted by code
+func All() []Currency {
+	currencies := make([]Currency, 0, len(table))
+	for _, currency := range table {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
@@ -0,0 +1,112 @@
+package currency
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		code      string
+		wantFound bool
+		wantType  Type
+	}{
+		{code: "USD", wantFound: true, wantType: TypeFiat},
+		{code: "XAU", wantFound: true, wantType: TypeMetal},
+		{code: "XAG", wantFound: true, wantType: TypeMetal},
+		{code: "XYZ", wantFound: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			currency, found := Lookup(tt.code)
+			if found != tt.wantFound {
+				t.Fatalf("Lookup(%s) found = %v, want %v", tt.code, found, tt.wantFound)
+			}
+			if found && currency.Type != tt.wantType {
+				t.Errorf("Lookup(%s) Type = %v, want %v", tt.code, currency.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestIsMetal(t *testing.T) {
+	if !IsMetal("XAU") {
+		t.Error("IsMetal(XAU) = false, want true")
+	}
+	if IsMetal("EUR") {
+		t.Error("IsMetal(EUR) = true, want false")
+	}
+}
+
+func TestAll(t *testing.T) {
+	currencies := All()
+	if len(currencies) != len(table) {
+		t.Fatalf("All() length = %v, want %v", len(currencies), len(table))
+	}
+	for i := 1; i < len(currencies); i++ {
+		if currencies[i-1].Code >= currencies[i].Code {
+			t.Fatalf("All() not sorted at %s, %s", currencies[i-1].Code, currencies[i].Code)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "usd", want: "USD"},
+		{value: " EUR ", want: "EUR"},
+		{value: "US$", want: "USD"},
+		{value: "€", want: "EUR"},
+		{value: "rmb", want: "CNY"},
+		{value: "zł", want: "PLN"},
+		{value: "xyz", want: "XYZ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := Normalize(tt.value); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultAliasesNameKnownCurrencies(t *testing.T) {
+	for alias, code := range defaultAliases {
+		if _, found := Lookup(code); !found {
+			t.Errorf("alias %s names unknown currency %s", alias, code)
+		}
+	}
+}
+
+func TestSetAliases(t *testing.T) {
+	defer SetAliases(nil)
+
+	SetAliases(map[string]string{"buck": "usd", "¥": "CNY"})
+	if got := Normalize("Buck"); got != "USD" {
+		t.Errorf("Normalize(Buck) = %q, want USD", got)
+	}
+	if got := Normalize("¥"); got != "CNY" {
+		t.Errorf("Normalize(¥) with an override = %q, want CNY", got)
+	}
+	if got := Normalize("€"); got != "EUR" {
+		t.Errorf("Normalize(€) = %q, want the default alias EUR", got)
+	}
+}
+
+func FuzzNormalize(f *testing.F) {
+	for _, seed := range []string{"usd", " EUR ", "US$", "€", "rmb", "zł", "Kč", "xyz", "", " ", "ǆ", "\xff"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		code := Normalize(value)
+		if code != strings.TrimSpace(code) {
+			t.Errorf("Normalize(%q) = %q, want no surrounding space", value, code)
+		}
+		if again := Normalize(code); again != code {
+			t.Errorf("Normalize(Normalize(%q)) = %q, want %q", value, again, code)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+// Package digest sends a daily summary of the configured pairs' closing rates and the
+// providers' availability, by email or webhook, for teams that want a morning snapshot.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/clock"
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// dateLayout formats the digest's day
+const dateLayout = "2006-01-02"
+
+// RatesSource is where the digest reads closing rates and provider statuses
+type RatesSource interface {
+	GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (models.RatesResponse, error)
+	GetProviderStatus() []models.ProviderStatus
+}
+
+// Sender delivers a digest
+type Sender interface {
+	Send(ctx context.Context, digest models.RatesDigest) error
+}
+
+// counts are a provider's cumulative calls and failures when the last digest was built
+type counts struct {
+	calls    int64
+	failures int64
+}
+
+// Job builds the digest of the previous UTC day once a day and hands it to the senders.
+// A nil job is valid and sends nothing.
+type Job struct {
+	pairs   []string
+	sendAt  time.Duration // After midnight UTC
+	rates   RatesSource
+	senders []Sender
+	logger  logger.Logger
+	clock   clock.Clock
+
+	mutex      sync.Mutex
+	lastCounts map[string]counts
+}
+
+// NewJob creates the digest job for the configured pairs and deliveries, or returns nil
+// when no pairs are configured
+func NewJob(configuration config.DigestConfig, rates RatesSource, log logger.Logger) (*Job, error) {
+	if len(configuration.Pairs) == 0 {
+		return nil, nil
+	}
+
+	sendAt, err := time.Parse("15:04", configuration.SendAt)
+	if err != nil {
+		return nil, fmt.Errorf("DIGEST_TIME must be HH:MM, got %q", configuration.SendAt)
+	}
+	for _, pair := range configuration.Pairs {
+		from, to, found := strings.Cut(pair, "/")
+		if !found || len(from) != 3 || len(to) != 3 {
+			return nil, fmt.Errorf("DIGEST_PAIRS has invalid pair %q: use FROM/TO, e.g. EUR/USD", pair)
+		}
+	}
+
+	var senders []Sender
+	if configuration.WebhookURL != "" {
+		senders = append(senders, newWebhookSender(configuration.WebhookURL))
+	}
+	if configuration.SMTPAddr != "" {
+		sender, err := newEmailSender(configuration)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, sender)
+	}
+	if len(senders) == 0 {
+		return nil, fmt.Errorf("DIGEST_PAIRS needs DIGEST_WEBHOOK_URL or DIGEST_SMTP_ADDR to deliver the digest")
+	}
+
+	return &Job{
+		pairs:      configuration.Pairs,
+		sendAt:     time.Duration(sendAt.Hour())*time.Hour + time.Duration(sendAt.Minute())*time.Minute,
+		rates:      rates,
+		senders:    senders,
+		logger:     log,
+		clock:      clock.System,
+		lastCounts: make(map[string]counts),
+	}, nil
+}
+
+// Start sends the digest every day at the configured time until ctx is cancelled
+func (job *Job) Start(ctx context.Context) {
+	if job == nil {
+		return
+	}
+
+	go func() {
+		for {
+			now := job.clock.Now()
+			timer := time.NewTimer(job.nextRun(now).Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				job.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Run builds the digest of the previous UTC day and sends it to every sender; failed
+// deliveries are logged
+func (job *Job) Run(ctx context.Context) {
+	digest := job.Build(ctx)
+	delivered := 0
+	for _, sender := range job.senders {
+		if err := sender.Send(ctx, digest); err != nil {
+			job.logger.Errorf("Failed to deliver the rates digest for %s: %v", digest.Date, err)
+			continue
+		}
+		delivered++
+	}
+	job.logger.Infof("Delivered the rates digest for %s to %d of %d destinations", digest.Date, delivered, len(job.senders))
+}
+
+// Build summarises the previous UTC day: each pair's closing rate against the day before,
+// and the providers' calls and failures since the last digest
+func (job *Job) Build(ctx context.Context) models.RatesDigest {
+	now := job.clock.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+
+	digest := models.RatesDigest{
+		Date:        day.Format(dateLayout),
+		Pairs:       make([]models.DigestPair, 0, len(job.pairs)),
+		Providers:   job.providers(),
+		GeneratedAt: now,
+	}
+
+	// Pairs of the same base share one lookup per day
+	tables := make(map[string]models.RatesResponse)
+	failures := make(map[string]error)
+	closing := func(base string, date time.Time) (models.RatesResponse, error) {
+		key := base + " " + date.Format(dateLayout)
+		if err, failed := failures[key]; failed {
+			return models.RatesResponse{}, err
+		}
+		if table, found := tables[key]; found {
+			return table, nil
+		}
+		table, err := job.rates.GetHistoricalRates(ctx, base, date)
+		if err != nil {
+			failures[key] = err
+			return models.RatesResponse{}, err
+		}
+		tables[key] = table
+		return table, nil
+	}
+
+	for _, pair := range job.pairs {
+		from, to, _ := strings.Cut(pair, "/")
+		entry := models.DigestPair{Pair: pair}
+
+		current, err := closing(from, day)
+		if err != nil {
+			entry.Error = err.Error()
+			digest.Pairs = append(digest.Pairs, entry)
+			continue
+		}
+		rate, quoted := current.Rates[to]
+		if !quoted {
+			entry.Error = fmt.Sprintf("%s is not quoted against %s", to, from)
+			digest.Pairs = append(digest.Pairs, entry)
+			continue
+		}
+		entry.Close, entry.Provider = rate, current.Provider
+
+		// Without the day before, the closing rate is still worth reporting
+		if previous, err := closing(from, day.AddDate(0, 0, -1)); err == nil && previous.Rates[to] > 0 {
+			entry.PreviousClose = previous.Rates[to]
+			change := (rate/entry.PreviousClose - 1) * 100
+			entry.ChangePercent = &change
+		}
+		digest.Pairs = append(digest.Pairs, entry)
+	}
+
+	return digest
+}
+
+// providers reports each provider's calls and failures since the last digest, sorted by
+// name
+func (job *Job) providers() []models.DigestProvider {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	statuses := job.rates.GetProviderStatus()
+	providers := make([]models.DigestProvider, 0, len(statuses))
+	for _, status := range statuses {
+		last := job.lastCounts[status.Name]
+		job.lastCounts[status.Name] = counts{calls: status.Calls, failures: status.Failures}
+
+		provider := models.DigestProvider{
+			Name:         status.Name,
+			Calls:        status.Calls - last.calls,
+			Failures:     status.Failures - last.failures,
+			Availability: 100,
+			Disabled:     status.Disabled,
+		}
+		if provider.Calls > 0 {
+			provider.Availability = float64(provider.Calls-provider.Failures) / float64(provider.Calls) * 100
+		}
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+// nextRun returns the next send time after now
+func (job *Job) nextRun(now time.Time) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(job.sendAt)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,151 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// fakeRates serves closing rates by base and day
+type fakeRates struct {
+	tables   map[string]models.RatesResponse // By "BASE YYYY-MM-DD"
+	statuses []models.ProviderStatus
+	lookups  int
+}
+
+func (rates *fakeRates) GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (models.RatesResponse, error) {
+	rates.lookups++
+	table, found := rates.tables[baseCurrency+" "+date.Format(dateLayout)]
+	if !found {
+		return models.RatesResponse{}, errors.New("no historical rates")
+	}
+	return table, nil
+}
+
+func (rates *fakeRates) GetProviderStatus() []models.ProviderStatus {
+	return rates.statuses
+}
+
+// recordingSender keeps the digests sent to it
+type recordingSender struct {
+	digests []models.RatesDigest
+	err     error
+}
+
+func (sender *recordingSender) Send(ctx context.Context, digest models.RatesDigest) error {
+	sender.digests = append(sender.digests, digest)
+	return sender.err
+}
+
+func TestJob_Build(t *testing.T) {
+	rates := &fakeRates{
+		tables: map[string]models.RatesResponse{
+			"EUR 2024-03-01": {Base: "EUR", Provider: "frankfurter", Rates: map[string]float64{"USD": 1.0845, "GBP": 0.8551}},
+			"EUR 2024-02-29": {Base: "EUR", Provider: "frankfurter", Rates: map[string]float64{"USD": 1.0812}},
+		},
+		statuses: []models.ProviderStatus{
+			{Name: "frankfurter", Calls: 200, Failures: 2},
+			{Name: "erapi", Calls: 0, Disabled: true},
+		},
+	}
+	job, err := NewJob(config.DigestConfig{Pairs: []string{"EUR/USD", "EUR/GBP", "GBP/JPY"}, SendAt: "07:00", WebhookURL: "https://example.com/digest"}, rates, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("NewJob() error = %v", err)
+	}
+	job.clock = testutils.NewFakeClock(time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC))
+
+	digest := job.Build(context.Background())
+	if digest.Date != "2024-03-01" || len(digest.Pairs) != 3 {
+		t.Fatalf("Build() = %+v", digest)
+	}
+	if usd := digest.Pairs[0]; usd.Close != 1.0845 || usd.PreviousClose != 1.0812 || usd.ChangePercent == nil || *usd.ChangePercent < 0.30 || *usd.ChangePercent > 0.31 || usd.Provider != "frankfurter" {
+		t.Errorf("EUR/USD = %+v, want the close against the day before", usd)
+	}
+	if gbp := digest.Pairs[1]; gbp.Close != 0.8551 || gbp.ChangePercent != nil {
+		t.Errorf("EUR/GBP = %+v, want the close without a change", gbp)
+	}
+	if jpy := digest.Pairs[2]; jpy.Error == "" {
+		t.Errorf("GBP/JPY = %+v, want an error", jpy)
+	}
+	// EUR for both days and GBP once: pairs of a base share the lookups
+	if rates.lookups != 3 {
+		t.Errorf("historical lookups = %d, want 3", rates.lookups)
+	}
+
+	want := []models.DigestProvider{
+		{Name: "erapi", Availability: 100, Disabled: true},
+		{Name: "frankfurter", Calls: 200, Failures: 2, Availability: 99},
+	}
+	if len(digest.Providers) != 2 || digest.Providers[0] != want[0] || digest.Providers[1] != want[1] {
+		t.Errorf("Build() providers = %+v, want %+v", digest.Providers, want)
+	}
+
+	// The next digest counts the calls made since
+	rates.statuses[0].Calls, rates.statuses[0].Failures = 300, 2
+	if providers := job.Build(context.Background()).Providers; providers[1].Calls != 100 || providers[1].Failures != 0 || providers[1].Availability != 100 {
+		t.Errorf("second Build() providers = %+v, want the calls since the first", providers)
+	}
+}
+
+func TestJob_Run(t *testing.T) {
+	rates := &fakeRates{statuses: []models.ProviderStatus{{Name: "erapi"}}}
+	failing := &recordingSender{err: errors.New("connection refused")}
+	working := &recordingSender{}
+	job := &Job{
+		pairs:      []string{"EUR/USD"},
+		rates:      rates,
+		senders:    []Sender{failing, working},
+		logger:     testutils.MockLogger(),
+		clock:      testutils.NewFakeClock(time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)),
+		lastCounts: make(map[string]counts),
+	}
+
+	// A failed delivery does not keep the digest from the other senders
+	job.Run(context.Background())
+	if len(failing.digests) != 1 || len(working.digests) != 1 || working.digests[0].Date != "2024-03-01" {
+		t.Errorf("Run() delivered %+v and %+v", failing.digests, working.digests)
+	}
+}
+
+func TestJob_NextRun(t *testing.T) {
+	job := &Job{sendAt: 7*time.Hour + 30*time.Minute}
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 1, 7, 30, 0, 0, time.UTC), time.Date(2024, 3, 2, 7, 30, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 7, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := job.nextRun(test.now); !got.Equal(test.want) {
+			t.Errorf("nextRun(%s) = %s, want %s", test.now, got, test.want)
+		}
+	}
+}
+
+func TestNewJob(t *testing.T) {
+	logger := testutils.MockLogger()
+	if job, err := NewJob(config.DigestConfig{SendAt: "07:00"}, &fakeRates{}, logger); job != nil || err != nil {
+		t.Errorf("NewJob() without pairs = %v, %v, want nil", job, err)
+	}
+
+	invalid := []config.DigestConfig{
+		{Pairs: []string{"EUR/USD"}, SendAt: "7am", WebhookURL: "https://example.com"},
+		{Pairs: []string{"EURUSD"}, SendAt: "07:00", WebhookURL: "https://example.com"},
+		{Pairs: []string{"EUR/USD"}, SendAt: "07:00"},
+		{Pairs: []string{"EUR/USD"}, SendAt: "07:00", SMTPAddr: "mail.example.com:587"},
+		{Pairs: []string{"EUR/USD"}, SendAt: "07:00", SMTPAddr: "mail.example.com", EmailFrom: "rates@example.com", EmailTo: []string{"finance@example.com"}},
+	}
+	for _, configuration := range invalid {
+		if _, err := NewJob(configuration, &fakeRates{}, logger); err == nil {
+			t.Errorf("NewJob(%+v) should fail", configuration)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// sendTimeout bounds the delivery of a digest by webhook
+const sendTimeout = 30 * time.Second
+
+// webhookSender POSTs the digest as JSON
+type webhookSender struct {
+	url        string
+	httpClient *http.Client
+}
+
+func newWebhookSender(url string) *webhookSender {
+	return &webhookSender{url: url, httpClient: &http.Client{Timeout: sendTimeout}}
+}
+
+// Send posts the digest; any status but 2xx is an error
+func (sender *webhookSender) Send(ctx context.Context, digest models.RatesDigest) error {
+	body, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("failed to encode digest: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", sender.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := sender.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post digest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("digest webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// emailSender mails the digest as plain text through an SMTP server
+type emailSender struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, message []byte) error
+}
+
+// newEmailSender creates the sender of the configured mail server, authenticating when a
+// username is set
+func newEmailSender(configuration config.DigestConfig) (*emailSender, error) {
+	if configuration.EmailFrom == "" || len(configuration.EmailTo) == 0 {
+		return nil, fmt.Errorf("DIGEST_SMTP_ADDR needs DIGEST_EMAIL_FROM and DIGEST_EMAIL_TO")
+	}
+	host, _, err := net.SplitHostPort(configuration.SMTPAddr)
+	if err != nil {
+		return nil, fmt.Errorf("DIGEST_SMTP_ADDR must be host:port: %w", err)
+	}
+
+	sender := &emailSender{
+		addr:     configuration.SMTPAddr,
+		from:     configuration.EmailFrom,
+		to:       configuration.EmailTo,
+		sendMail: smtp.SendMail,
+	}
+	if configuration.SMTPUsername != "" {
+		sender.auth = smtp.PlainAuth("", configuration.SMTPUsername, configuration.SMTPPassword, host)
+	}
+	return sender, nil
+}
+
+// Send mails the rendered digest to every recipient
+func (sender *emailSender) Send(ctx context.Context, digest models.RatesDigest) error {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", sender.from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(sender.to, ", "))
+	fmt.Fprintf(&message, "Subject: Rates digest for %s\r\n", digest.Date)
+	fmt.Fprintf(&message, "Date: %s\r\n", digest.GeneratedAt.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(Render(digest), "\n", "\r\n"))
+
+	if err := sender.sendMail(sender.addr, sender.auth, sender.from, sender.to, message.Bytes()); err != nil {
+		return fmt.Errorf("failed to mail digest: %w", err)
+	}
+	return nil
+}
+
+// Render formats the digest as plain-text tables
+func Render(digest models.RatesDigest) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Rates digest for %s\n\n", digest.Date)
+
+	table := tabwriter.NewWriter(&text, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Pair\tClose\tPrevious\tChange\tProvider")
+	for _, pair := range digest.Pairs {
+		if pair.Error != "" {
+			fmt.Fprintf(table, "%s\t-\t-\t-\tunavailable: %s\n", pair.Pair, pair.Error)
+			continue
+		}
+		previous, change := "-", "-"
+		if pair.ChangePercent != nil {
+			previous = fmt.Sprintf("%.6f", pair.PreviousClose)
+			change = fmt.Sprintf("%+.2f%%", *pair.ChangePercent)
+		}
+		fmt.Fprintf(table, "%s\t%.6f\t%s\t%s\t%s\n", pair.Pair, pair.Close, previous, change, pair.Provider)
+	}
+	table.Flush()
+
+	text.WriteString("\nProvider availability\n\n")
+	table = tabwriter.NewWriter(&text, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Provider\tCalls\tFailures\tAvailability\tState")
+	for _, provider := range digest.Providers {
+		state := "enabled"
+		if provider.Disabled {
+			state = "disabled"
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.2f%%\t%s\n", provider.Name, provider.Calls, provider.Failures, provider.Availability, state)
+	}
+	table.Flush()
+
+	return text.String()
+}
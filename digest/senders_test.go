@@ -0,0 +1,98 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func testDigest() models.RatesDigest {
+	change := 0.3052
+	return models.RatesDigest{
+		Date: "2024-03-01",
+		Pairs: []models.DigestPair{
+			{Pair: "EUR/USD", Close: 1.0845, PreviousClose: 1.0812, ChangePercent: &change, Provider: "frankfurter"},
+			{Pair: "GBP/JPY", Error: "no historical rates"},
+		},
+		Providers: []models.DigestProvider{
+			{Name: "frankfurter", Calls: 200, Failures: 2, Availability: 99},
+		},
+		GeneratedAt: time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookSender(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	if err := newWebhookSender(server.URL+"/digest").Send(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	var delivered models.RatesDigest
+	if err := json.Unmarshal(body, &delivered); err != nil || delivered.Date != "2024-03-01" || len(delivered.Pairs) != 2 {
+		t.Errorf("posted digest = %s", body)
+	}
+
+	if err := newWebhookSender(server.URL+"/failing").Send(context.Background(), testDigest()); err == nil {
+		t.Error("Send() should fail on a 502 response")
+	}
+}
+
+func TestEmailSender(t *testing.T) {
+	sender, err := newEmailSender(config.DigestConfig{
+		SMTPAddr: "mail.example.com:587", SMTPUsername: "rates", SMTPPassword: "secret",
+		EmailFrom: "rates@example.com", EmailTo: []string{"finance@example.com", "treasury@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("newEmailSender() error = %v", err)
+	}
+	var addr string
+	var to []string
+	var message string
+	sender.sendMail = func(gotAddr string, auth smtp.Auth, from string, gotTo []string, gotMessage []byte) error {
+		addr, to, message = gotAddr, gotTo, string(gotMessage)
+		return nil
+	}
+
+	if err := sender.Send(context.Background(), testDigest()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if addr != "mail.example.com:587" || len(to) != 2 || sender.auth == nil {
+		t.Errorf("mailed through %s to %v", addr, to)
+	}
+	for _, want := range []string{"Subject: Rates digest for 2024-03-01\r\n", "To: finance@example.com, treasury@example.com\r\n", "EUR/USD", "+0.31%"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message does not contain %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	text := Render(testDigest())
+
+	for _, want := range []string{
+		"Rates digest for 2024-03-01",
+		"EUR/USD  1.084500  1.081200  +0.31%  frankfurter",
+		"GBP/JPY  -         -         -       unavailable: no historical rates",
+		"frankfurter  200    2         99.00%        enabled",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Render() does not contain %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+)
+
+// publishTimeout bounds a single publish so a slow broker cannot back up the queue
+const publishTimeout = 5 * time.Second
+
+// queueSize is how many messages may wait for the broker before new ones are dropped
+const queueSize = 256
+
+// queuedMessage is an encoded message waiting to be published
+type queuedMessage struct {
+	topic   string
+	key     string
+	payload []byte
+}
+
+// dispatcher publishes queued messages in order on a background goroutine, so callers
+// on the request path never wait for the broker
+type dispatcher struct {
+	publisher Publisher
+	logger    logger.Logger
+
+	queue chan queuedMessage
+	done  chan struct{}
+
+	mutex         sync.Mutex
+	lastPublishAt time.Time // Last successful publish
+	lastError     error     // Error of the last publish attempt (nil after a success)
+	dropped       int64     // Messages dropped because the queue was full
+	closed        bool      // Set once close started; later messages are dropped
+}
+
+// newDispatcher creates a dispatcher and starts its publishing loop
+func newDispatcher(publisher Publisher, logger logger.Logger) *dispatcher {
+	dispatcher := &dispatcher{
+		publisher: publisher,
+		logger:    logger,
+		queue:     make(chan queuedMessage, queueSize),
+		done:      make(chan struct{}),
+	}
+	go dispatcher.run()
+	return dispatcher
+}
+
+// dispatch queues a message, dropping it when the queue is full. Messages dispatched
+// after close are dropped silently.
+func (dispatcher *dispatcher) dispatch(topic string, key string, payload []byte) {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+	if dispatcher.closed {
+		return
+	}
+
+	select {
+	case dispatcher.queue <- queuedMessage{topic: topic, key: key, payload: payload}:
+	default:
+		dispatcher.logger.Warnf("Publish queue full, dropping message for %s", topic)
+		dispatcher.dropped++
+	}
+}
+
+// check reports the broker as failing while its last publish attempt failed or the
+// queue is full. It uses the outcome of real publishes, so checking adds no broker traffic.
+func (dispatcher *dispatcher) check(ctx context.Context) error {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+
+	if dispatcher.lastError != nil {
+		return fmt.Errorf("last publish failed: %w", dispatcher.lastError)
+	}
+	if len(dispatcher.queue) == cap(dispatcher.queue) {
+		return fmt.Errorf("publish queue full (%d messages dropped)", dispatcher.dropped)
+	}
+	return nil
+}
+
+// healthCheck returns the optional broker check for the dispatcher
+func (dispatcher *dispatcher) healthCheck(name string) health.Check {
+	return health.Check{
+		Name:     name,
+		Kind:     health.KindBroker,
+		Optional: true,
+		Run:      dispatcher.check,
+		Detail:   dispatcher.detail,
+	}
+}
+
+// detail describes the publishing backlog and the last successful publish
+func (dispatcher *dispatcher) detail() string {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+
+	last := "never"
+	if !dispatcher.lastPublishAt.IsZero() {
+		last = time.Since(dispatcher.lastPublishAt).Round(time.Second).String() + " ago"
+	}
+	return fmt.Sprintf("%d queued, %d dropped, last published %s", len(dispatcher.queue), dispatcher.dropped, last)
+}
+
+// close publishes the queued messages and closes the publisher
+func (dispatcher *dispatcher) close() error {
+	dispatcher.mutex.Lock()
+	if dispatcher.closed {
+		dispatcher.mutex.Unlock()
+		return nil
+	}
+	dispatcher.closed = true
+	close(dispatcher.queue)
+	dispatcher.mutex.Unlock()
+
+	<-dispatcher.done
+	return dispatcher.publisher.Close()
+}
+
+// run publishes queued messages until the queue is closed
+func (dispatcher *dispatcher) run() {
+	defer close(dispatcher.done)
+
+	for queued := range dispatcher.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		err := dispatcher.publisher.Publish(ctx, queued.topic, queued.key, queued.payload)
+		if err != nil {
+			dispatcher.logger.Warnf("Failed to publish to %s: %v", queued.topic, err)
+		}
+		cancel()
+
+		dispatcher.mutex.Lock()
+		dispatcher.lastError = err
+		if err == nil {
+			dispatcher.lastPublishAt = time.Now()
+		}
+		dispatcher.mutex.Unlock()
+	}
+}
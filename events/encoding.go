@@ -0,0 +1,85 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Encoder serializes an event into a broker payload
+type Encoder func(event RateEvent) ([]byte, error)
+
+// AvroSchema is the Avro schema of payloads produced by EncodeAvro
+const AvroSchema = `{
+  "type": "record",
+  "name": "RateEvent",
+  "namespace": "currencyexchange",
+  "fields": [
+    {"name": "type", "type": "string"},
+    {"name": "base", "type": "string"},
+    {"name": "provider", "type": "string"},
+    {"name": "timestamp", "type": "long"},
+    {"name": "rates", "type": {"type": "map", "values": "double"}},
+    {"name": "changes", "type": {"type": "map", "values": "double"}}
+  ]
+}`
+
+// EncoderFor returns the encoder for a payload format (json or avro)
+func EncoderFor(format string) (Encoder, error) {
+	switch format {
+	case "", "json":
+		return EncodeJSON, nil
+	case "avro":
+		return EncodeAvro, nil
+	default:
+		return nil, fmt.Errorf("unsupported events format: %s", format)
+	}
+}
+
+// EncodeJSON encodes the event as JSON
+func EncodeJSON(event RateEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// EncodeAvro encodes the event as Avro binary following AvroSchema
+func EncodeAvro(event RateEvent) ([]byte, error) {
+	var buffer []byte
+	buffer = appendAvroString(buffer, event.Type)
+	buffer = appendAvroString(buffer, event.Base)
+	buffer = appendAvroString(buffer, event.Provider)
+	buffer = appendAvroLong(buffer, event.Timestamp)
+	buffer = appendAvroDoubleMap(buffer, event.Rates)
+	buffer = appendAvroDoubleMap(buffer, event.Changes)
+	return buffer, nil
+}
+
+// appendAvroLong appends a zig-zag varint encoded long
+func appendAvroLong(buffer []byte, value int64) []byte {
+	return binary.AppendUvarint(buffer, uint64((value<<1)^(value>>63)))
+}
+
+// appendAvroString appends a length-prefixed UTF-8 string
+func appendAvroString(buffer []byte, value string) []byte {
+	buffer = appendAvroLong(buffer, int64(len(value)))
+	return append(buffer, value...)
+}
+
+// appendAvroDoubleMap appends a map of doubles as a single block with sorted keys
+func appendAvroDoubleMap(buffer []byte, values map[string]float64) []byte {
+	if len(values) > 0 {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buffer = appendAvroLong(buffer, int64(len(keys)))
+		for _, key := range keys {
+			buffer = appendAvroString(buffer, key)
+			buffer = binary.LittleEndian.AppendUint64(buffer, math.Float64bits(values[key]))
+		}
+	}
+	return appendAvroLong(buffer, 0)
+}
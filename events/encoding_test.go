@@ -0,0 +1,55 @@
+package events
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func testEvent() RateEvent {
+	return RateEvent{
+		Type:      TypeRatesUpdated,
+		Base:      "USD",
+		Provider:  "erapi",
+		Timestamp: 1641000000,
+		Rates:     map[string]float64{"EUR": 0.85},
+	}
+}
+
+func TestEncoderFor(t *testing.T) {
+	for _, format := range []string{"", "json", "avro"} {
+		if _, err := EncoderFor(format); err != nil {
+			t.Errorf("EncoderFor(%q) error = %v", format, err)
+		}
+	}
+	if _, err := EncoderFor("protobuf"); err == nil {
+		t.Error("EncoderFor(\"protobuf\") expected error")
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	payload, err := EncodeJSON(testEvent())
+	if err != nil {
+		t.Fatalf("EncodeJSON() error = %v", err)
+	}
+
+	var decoded RateEvent
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("EncodeJSON() produced invalid JSON: %v", err)
+	}
+	if decoded.Base != "USD" || decoded.Rates["EUR"] != 0.85 {
+		t.Errorf("EncodeJSON() round trip = %+v", decoded)
+	}
+}
+
+func TestEncodeAvro(t *testing.T) {
+	payload, err := EncodeAvro(testEvent())
+	if err != nil {
+		t.Fatalf("EncodeAvro() error = %v", err)
+	}
+
+	want := "1a72617465732e75706461746564065553440a657261706980b1fd9c0c0206455552333333333333eb3f0000"
+	if got := hex.EncodeToString(payload); got != want {
+		t.Errorf("EncodeAvro() = %v, want %v", got, want)
+	}
+}
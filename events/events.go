@@ -0,0 +1,172 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Event types published on rate updates
+const (
+	TypeRatesUpdated = "rates.updated" // Fresh rates were cached
+	TypeRatesMoved   = "rates.moved"   // One or more rates moved beyond the threshold
+)
+
+// RateEvent is the message published to the broker
+type RateEvent struct {
+	Type      string             `json:"type"`
+	Base      string             `json:"base"`
+	Provider  string             `json:"provider"`
+	Timestamp int64              `json:"timestamp"`
+	Rates     map[string]float64 `json:"rates"`
+
+	// Changes holds the percent change of each moved rate (rates.moved only)
+	Changes map[string]float64 `json:"changes,omitempty"`
+}
+
+// Publisher delivers encoded events to a message broker
+type Publisher interface {
+	Publish(ctx context.Context, topic string, key string, payload []byte) error
+	Close() error
+}
+
+// NewPublisher creates the publisher for the configured broker
+func NewPublisher(configuration config.EventsConfig) (Publisher, error) {
+	switch configuration.Broker {
+	case "nats":
+		return NewNATSPublisher(configuration.URL)
+	case "kafka-rest", "kafka": // kafka is the former name of kafka-rest
+		return NewKafkaRESTPublisher(configuration.URL), nil
+	default:
+		return nil, fmt.Errorf("unsupported events broker: %s", configuration.Broker)
+	}
+}
+
+// Emitter turns cached rates into events and publishes them in the background.
+// A nil emitter is valid and publishes nothing.
+type Emitter struct {
+	*dispatcher
+	broker    string
+	topic     string
+	encode    Encoder
+	threshold float64
+
+	mutex     sync.Mutex
+	lastRates map[string]models.RateTable // Last published rates per base
+}
+
+// NewEmitter creates an emitter for the events configuration, or returns nil when
+// publishing is disabled or the broker cannot be set up
+func NewEmitter(configuration config.EventsConfig, logger logger.Logger) *Emitter {
+	if configuration.Broker == "" {
+		return nil
+	}
+
+	encode, err := EncoderFor(configuration.Format)
+	if err != nil {
+		logger.Errorf("Rate events disabled: %v", err)
+		return nil
+	}
+	publisher, err := NewPublisher(configuration)
+	if err != nil {
+		logger.Errorf("Rate events disabled: %v", err)
+		return nil
+	}
+
+	logger.Infof("Publishing rate events to %s topic %s", configuration.Broker, configuration.Topic)
+	emitter := newEmitter(publisher, configuration.Topic, encode, configuration.MoveThresholdPercent, logger)
+	emitter.broker = configuration.Broker
+	return emitter
+}
+
+// newEmitter creates an emitter around a publisher
+func newEmitter(publisher Publisher, topic string, encode Encoder, threshold float64, logger logger.Logger) *Emitter {
+	return &Emitter{
+		dispatcher: newDispatcher(publisher, logger),
+		topic:      topic,
+		encode:     encode,
+		threshold:  threshold,
+		lastRates:  make(map[string]models.RateTable),
+	}
+}
+
+// RatesCached publishes a rates.updated event for freshly cached rates, and a rates.moved
+// event when any rate changed by at least the threshold since the last rates for the base
+func (emitter *Emitter) RatesCached(exchangeRates models.RatesResponse) {
+	if emitter == nil {
+		return
+	}
+
+	emitter.mutex.Lock()
+	previous := emitter.lastRates[exchangeRates.Base]
+	emitter.lastRates[exchangeRates.Base] = exchangeRates.Rates
+	emitter.mutex.Unlock()
+
+	event := RateEvent{
+		Type:      TypeRatesUpdated,
+		Base:      exchangeRates.Base,
+		Provider:  exchangeRates.Provider,
+		Timestamp: exchangeRates.Timestamp,
+		Rates:     exchangeRates.Rates,
+	}
+	emitter.enqueue(event)
+
+	if changes := movedRates(previous, exchangeRates.Rates, emitter.threshold); len(changes) > 0 {
+		event.Type = TypeRatesMoved
+		event.Changes = changes
+		emitter.enqueue(event)
+	}
+}
+
+// Checks returns the health check of the events broker, or none when publishing is disabled
+func (emitter *Emitter) Checks() []health.Check {
+	if emitter == nil {
+		return nil
+	}
+	return []health.Check{emitter.healthCheck("broker:" + emitter.broker)}
+}
+
+// Close publishes the queued events and closes the publisher
+func (emitter *Emitter) Close() error {
+	if emitter == nil {
+		return nil
+	}
+	return emitter.dispatcher.close()
+}
+
+// enqueue encodes the event and queues it for publishing
+func (emitter *Emitter) enqueue(event RateEvent) {
+	payload, err := emitter.encode(event)
+	if err != nil {
+		emitter.logger.Errorf("Failed to encode %s event: %v", event.Type, err)
+		return
+	}
+	emitter.dispatch(emitter.topic, event.Base, payload)
+}
+
+// movedRates returns the percent change of every rate that moved by at least the
+// threshold (0 disables move detection, as does a missing previous table)
+func movedRates(previous, current models.RateTable, threshold float64) map[string]float64 {
+	if threshold <= 0 || previous == nil {
+		return nil
+	}
+
+	changes := make(map[string]float64)
+	for symbol, rate := range current {
+		old, found := previous[symbol]
+		if !found || old == 0 {
+			continue
+		}
+		change := (rate - old) / old * 100
+		if math.Abs(change) >= threshold {
+			changes[symbol] = change
+		}
+	}
+	return changes
+}
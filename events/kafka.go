@@ -0,0 +1,70 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaRESTPublisher publishes events to Kafka through a Confluent-compatible REST proxy,
+// so the service needs no native Kafka client
+type KafkaRESTPublisher struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// kafkaRecords is the REST proxy v2 binary produce request; []byte fields are base64 encoded
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+}
+
+// NewKafkaRESTPublisher creates a publisher for the REST proxy at baseURL
+func NewKafkaRESTPublisher(baseURL string) *KafkaRESTPublisher {
+	return &KafkaRESTPublisher{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: publishTimeout},
+	}
+}
+
+// Publish produces the payload to the topic, keyed so a base's events share a partition
+func (publisher *KafkaRESTPublisher) Publish(ctx context.Context, topic string, key string, payload []byte) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: []byte(key), Value: payload}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode Kafka records: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", publisher.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := publisher.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Kafka REST proxy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// Close releases idle connections to the REST proxy
+func (publisher *KafkaRESTPublisher) Close() error {
+	publisher.httpClient.CloseIdleConnections()
+	return nil
+}
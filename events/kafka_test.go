@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKafkaRESTPublisher_Publish(t *testing.T) {
+	var requestedPath, contentType string
+	var received kafkaRecords
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"offsets": [{"partition": 0, "offset": 1}]}`))
+	}))
+	defer server.Close()
+
+	publisher := NewKafkaRESTPublisher(server.URL + "/")
+	defer publisher.Close()
+
+	if err := publisher.Publish(context.Background(), "rates", "USD", []byte(`{"base":"USD"}`)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if requestedPath != "/topics/rates" {
+		t.Errorf("Publish() path = %v, want %v", requestedPath, "/topics/rates")
+	}
+	if contentType != "application/vnd.kafka.binary.v2+json" {
+		t.Errorf("Publish() Content-Type = %v", contentType)
+	}
+	if len(received.Records) != 1 || string(received.Records[0].Key) != "USD" || string(received.Records[0].Value) != `{"base":"USD"}` {
+		t.Errorf("Publish() records = %+v", received.Records)
+	}
+}
+
+func TestKafkaRESTPublisher_PublishError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code": 40401, "message": "Topic not found"}`))
+	}))
+	defer server.Close()
+
+	publisher := NewKafkaRESTPublisher(server.URL)
+	if err := publisher.Publish(context.Background(), "missing", "USD", []byte("{}")); err == nil {
+		t.Error("Publish() expected error for non-200 response")
+	}
+}
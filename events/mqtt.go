@@ -0,0 +1,255 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttPuback     = 0x40
+	mqttPingreq    = 0xC0
+	mqttPingresp   = 0xD0
+	mqttDisconnect = 0xE0
+)
+
+// MQTTPublisher publishes messages to an MQTT 3.1.1 broker. QoS 1 publishes wait for the
+// PUBACK; QoS 0 publishes are followed by a PINGREQ so a dropped connection is noticed.
+type MQTTPublisher struct {
+	address  string
+	clientID string
+	username string
+	password string
+	qos      byte
+	retain   bool
+
+	mutex    sync.Mutex
+	conn     net.Conn
+	reader   *bufio.Reader
+	packetID uint16
+}
+
+// NewMQTTPublisher creates a publisher for an mqtt://host:port URL. The connection is
+// opened on first publish and re-opened after failures.
+func NewMQTTPublisher(brokerURL, clientID, username, password string, qos int, retain bool) (*MQTTPublisher, error) {
+	parsed, err := url.Parse(brokerURL)
+	if err != nil || parsed.Scheme != "mqtt" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid MQTT URL: %q", brokerURL)
+	}
+	if qos != 0 && qos != 1 {
+		return nil, fmt.Errorf("unsupported MQTT QoS %d: use 0 or 1", qos)
+	}
+
+	address := parsed.Host
+	if parsed.Port() == "" {
+		address = net.JoinHostPort(parsed.Hostname(), "1883")
+	}
+	return &MQTTPublisher{
+		address:  address,
+		clientID: clientID,
+		username: username,
+		password: password,
+		qos:      byte(qos),
+		retain:   retain,
+	}, nil
+}
+
+// Publish sends the payload to the topic with the configured QoS and retain flag.
+// MQTT has no partition key, so key is ignored.
+func (publisher *MQTTPublisher) Publish(ctx context.Context, topic string, key string, payload []byte) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+
+	if publisher.conn == nil {
+		if err := publisher.connect(ctx, deadline); err != nil {
+			return err
+		}
+	}
+
+	publisher.conn.SetDeadline(deadline)
+	if err := publisher.publish(topic, payload); err != nil {
+		publisher.disconnect()
+		return err
+	}
+	return nil
+}
+
+// Close sends DISCONNECT and closes the connection
+func (publisher *MQTTPublisher) Close() error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	if publisher.conn != nil {
+		publisher.conn.SetDeadline(time.Now().Add(time.Second))
+		publisher.conn.Write([]byte{mqttDisconnect, 0})
+	}
+	publisher.disconnect()
+	return nil
+}
+
+// connect dials the broker and completes the CONNECT/CONNACK handshake (caller holds the lock)
+func (publisher *MQTTPublisher) connect(ctx context.Context, deadline time.Time) error {
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", publisher.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	publisher.conn = conn
+	publisher.reader = bufio.NewReader(conn)
+	conn.SetDeadline(deadline)
+
+	// Clean session, keep alive disabled: the connection may idle between cache refreshes
+	flags := byte(0x02)
+	body := appendMQTTString(nil, "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1
+	if publisher.username != "" {
+		flags |= 0x80
+	}
+	if publisher.password != "" {
+		flags |= 0x40
+	}
+	body = append(body, flags, 0, 0)
+	body = appendMQTTString(body, publisher.clientID)
+	if publisher.username != "" {
+		body = appendMQTTString(body, publisher.username)
+	}
+	if publisher.password != "" {
+		body = appendMQTTString(body, publisher.password)
+	}
+
+	if _, err := conn.Write(mqttPacket(mqttConnect, body)); err != nil {
+		publisher.disconnect()
+		return fmt.Errorf("failed to send MQTT CONNECT: %w", err)
+	}
+
+	packetType, response, err := publisher.readPacket()
+	if err != nil {
+		publisher.disconnect()
+		return fmt.Errorf("failed to read MQTT CONNACK: %w", err)
+	}
+	if packetType != mqttConnack || len(response) != 2 || response[1] != 0 {
+		publisher.disconnect()
+		return fmt.Errorf("MQTT broker refused connection: %v", response)
+	}
+	return nil
+}
+
+// publish writes a PUBLISH packet and waits for its acknowledgement (caller holds the lock)
+func (publisher *MQTTPublisher) publish(topic string, payload []byte) error {
+	header := byte(mqttPublish) | publisher.qos<<1
+	if publisher.retain {
+		header |= 0x01
+	}
+
+	body := appendMQTTString(nil, topic)
+	expected := byte(mqttPingresp)
+	if publisher.qos > 0 {
+		publisher.packetID++
+		if publisher.packetID == 0 {
+			publisher.packetID = 1
+		}
+		body = binary.BigEndian.AppendUint16(body, publisher.packetID)
+		expected = mqttPuback
+	}
+	body = append(body, payload...)
+
+	message := mqttPacket(header, body)
+	if publisher.qos == 0 {
+		message = append(message, mqttPingreq, 0)
+	}
+	if _, err := publisher.conn.Write(message); err != nil {
+		return fmt.Errorf("failed to publish to MQTT: %w", err)
+	}
+
+	for {
+		packetType, response, err := publisher.readPacket()
+		if err != nil {
+			return fmt.Errorf("failed to read MQTT acknowledgement: %w", err)
+		}
+		if packetType != expected {
+			continue
+		}
+		if expected == mqttPuback && (len(response) != 2 || binary.BigEndian.Uint16(response) != publisher.packetID) {
+			continue
+		}
+		return nil
+	}
+}
+
+// readPacket reads one control packet, returning its type (high nibble) and body
+func (publisher *MQTTPublisher) readPacket() (byte, []byte, error) {
+	header, err := publisher.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		encoded, err := publisher.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(encoded&0x7F) * multiplier
+		if encoded&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, fmt.Errorf("malformed MQTT remaining length")
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(publisher.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header & 0xF0, body, nil
+}
+
+// disconnect closes and forgets the connection (caller holds the lock)
+func (publisher *MQTTPublisher) disconnect() {
+	if publisher.conn != nil {
+		publisher.conn.Close()
+		publisher.conn = nil
+		publisher.reader = nil
+	}
+}
+
+// mqttPacket frames a body with the fixed header and variable-length remaining length
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	length := len(body)
+	for {
+		encoded := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			encoded |= 0x80
+		}
+		packet = append(packet, encoded)
+		if length == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// appendMQTTString appends a UTF-8 string with its 2-byte length prefix
+func appendMQTTString(buffer []byte, value string) []byte {
+	buffer = binary.BigEndian.AppendUint16(buffer, uint16(len(value)))
+	return append(buffer, value...)
+}
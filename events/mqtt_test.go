@@ -0,0 +1,121 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	"net"
+	"testing"
+	"time"
+)
+
+// receivedPublish is a PUBLISH packet seen by the fake broker
+type receivedPublish struct {
+	topic   string
+	payload string
+	qos     byte
+	retain  bool
+}
+
+// fakeMQTTBroker accepts one connection, acknowledges CONNECT, PUBLISH and PINGREQ,
+// and sends received publishes to the returned channel
+func fakeMQTTBroker(t *testing.T) (string, <-chan receivedPublish) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan receivedPublish, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		publisher := &MQTTPublisher{reader: bufio.NewReader(conn)}
+		for {
+			header, err := publisher.reader.ReadByte()
+			if err != nil {
+				return
+			}
+			publisher.reader.UnreadByte()
+			packetType, body, err := publisher.readPacket()
+			if err != nil {
+				return
+			}
+
+			switch packetType {
+			case mqttConnect:
+				conn.Write([]byte{mqttConnack, 2, 0, 0})
+			case mqttPingreq:
+				conn.Write([]byte{mqttPingresp, 0})
+			case mqttPublish:
+				qos := (header >> 1) & 0x03
+				topicLength := int(binary.BigEndian.Uint16(body))
+				message := receivedPublish{topic: string(body[2 : 2+topicLength]), qos: qos, retain: header&0x01 == 1}
+				rest := body[2+topicLength:]
+				if qos > 0 {
+					conn.Write(append([]byte{mqttPuback, 2}, rest[:2]...))
+					rest = rest[2:]
+				}
+				message.payload = string(rest)
+				received <- message
+			}
+		}
+	}()
+
+	return "mqtt://" + listener.Addr().String(), received
+}
+
+func TestNewMQTTPublisher_Invalid(t *testing.T) {
+	if _, err := NewMQTTPublisher("tcp://broker:1883", "id", "", "", 0, false); err == nil {
+		t.Error("NewMQTTPublisher() expected error for non-mqtt URL")
+	}
+	if _, err := NewMQTTPublisher("mqtt://broker", "id", "", "", 2, false); err == nil {
+		t.Error("NewMQTTPublisher() expected error for QoS 2")
+	}
+}
+
+func TestMQTTPublisher_Publish(t *testing.T) {
+	for _, qos := range []int{0, 1} {
+		brokerURL, received := fakeMQTTBroker(t)
+
+		publisher, err := NewMQTTPublisher(brokerURL, "test-client", "user", "pass", qos, true)
+		if err != nil {
+			t.Fatalf("NewMQTTPublisher() error = %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := publisher.Publish(ctx, "rates/USD/EUR", "", []byte(`{"rate":0.92}`)); err != nil {
+			t.Fatalf("Publish() QoS %d error = %v", qos, err)
+		}
+		cancel()
+		publisher.Close()
+
+		message := <-received
+		if message.topic != "rates/USD/EUR" || message.payload != `{"rate":0.92}` {
+			t.Errorf("broker received %+v", message)
+		}
+		if int(message.qos) != qos || !message.retain {
+			t.Errorf("broker received QoS %d retain %v, want QoS %d retained", message.qos, message.retain, qos)
+		}
+	}
+}
+
+func TestMQTTPacket_RemainingLength(t *testing.T) {
+	packet := mqttPacket(mqttPublish, make([]byte, 321))
+	if packet[1] != 0xC1 || packet[2] != 0x02 {
+		t.Errorf("mqttPacket() remaining length bytes = %x %x, want c1 02", packet[1], packet[2])
+	}
+
+	publisher := &MQTTPublisher{reader: bufio.NewReader(bytes.NewReader(packet))}
+	packetType, body, err := publisher.readPacket()
+	if err != nil || packetType != mqttPublish || len(body) != 321 {
+		t.Errorf("readPacket() = %x, %d bytes, %v", packetType, len(body), err)
+	}
+}
@@ -0,0 +1,204 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsDialTimeout bounds connecting to the NATS server
+const natsDialTimeout = 5 * time.Second
+
+// NATSPublisher publishes events to a NATS server using the core text protocol.
+// Each publish is followed by a PING so a PONG confirms the server processed it.
+type NATSPublisher struct {
+	address    string
+	serverName string         // Host name verified against the server's TLS certificate
+	requireTLS bool           // tls:// URL: refuse servers that do not offer TLS
+	rootCAs    *x509.CertPool // CAs trusted to sign the server's certificate (nil = the system's)
+	user       string
+	password   string
+	token      string
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSPublisher creates a publisher for a nats://host:port or tls://host:port URL.
+// Credentials in the URL authenticate the connection: user:password, or a lone token.
+// The connection is upgraded to TLS when the URL is tls:// or the server requires it. It
+// is opened on first publish and re-opened after failures.
+func NewNATSPublisher(serverURL string) (*NATSPublisher, error) {
+	parsed, err := url.Parse(serverURL)
+	if err != nil || (parsed.Scheme != "nats" && parsed.Scheme != "tls") || parsed.Host == "" {
+		// The URL may hold credentials, so it is not echoed
+		return nil, fmt.Errorf("invalid NATS URL: want nats://host:port or tls://host:port")
+	}
+
+	address := parsed.Host
+	if parsed.Port() == "" {
+		address = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	publisher := &NATSPublisher{address: address, serverName: parsed.Hostname(), requireTLS: parsed.Scheme == "tls"}
+	if parsed.User != nil {
+		if password, set := parsed.User.Password(); set {
+			publisher.user, publisher.password = parsed.User.Username(), password
+		} else {
+			publisher.token = parsed.User.Username()
+		}
+	}
+	return publisher, nil
+}
+
+// natsInfo holds the fields of the server's INFO banner the publisher acts on
+type natsInfo struct {
+	TLSRequired  bool `json:"tls_required"`
+	TLSAvailable bool `json:"tls_available"`
+}
+
+// natsConnect is the CONNECT message sent after the INFO banner
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TLSRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	User        string `json:"user,omitempty"`
+	Password    string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+}
+
+// Publish sends the payload to the subject and waits for the server to acknowledge it.
+// NATS has no partition key, so key is ignored.
+func (publisher *NATSPublisher) Publish(ctx context.Context, subject string, key string, payload []byte) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	if publisher.conn == nil {
+		if err := publisher.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := publisher.publish(ctx, subject, payload); err != nil {
+		publisher.disconnect()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection to the server
+func (publisher *NATSPublisher) Close() error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+
+	publisher.disconnect()
+	return nil
+}
+
+// connect dials the server, reads its INFO banner and sends CONNECT (caller holds the lock)
+func (publisher *NATSPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", publisher.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	publisher.conn = conn
+	publisher.reader = bufio.NewReader(conn)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	line, err := publisher.reader.ReadString('\n')
+	banner, isInfo := strings.CutPrefix(strings.TrimSpace(line), "INFO")
+	var info natsInfo
+	if err != nil || !isInfo || json.Unmarshal([]byte(banner), &info) != nil {
+		publisher.disconnect()
+		return fmt.Errorf("unexpected NATS greeting: %q", strings.TrimSpace(line))
+	}
+
+	// The TLS handshake follows the INFO banner, before CONNECT
+	useTLS := publisher.requireTLS || info.TLSRequired
+	if useTLS {
+		if publisher.requireTLS && !info.TLSRequired && !info.TLSAvailable {
+			publisher.disconnect()
+			return fmt.Errorf("NATS server at %s does not offer TLS", publisher.address)
+		}
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: publisher.serverName, RootCAs: publisher.rootCAs, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			publisher.disconnect()
+			return fmt.Errorf("NATS TLS handshake failed: %w", err)
+		}
+		publisher.conn = tlsConn
+		publisher.reader = bufio.NewReader(tlsConn)
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		TLSRequired: useTLS,
+		Name:        "currency-exchange-service",
+		User:        publisher.user,
+		Password:    publisher.password,
+		AuthToken:   publisher.token,
+	})
+	if err != nil {
+		publisher.disconnect()
+		return fmt.Errorf("failed to encode NATS CONNECT: %w", err)
+	}
+	if _, err := publisher.conn.Write(append(append([]byte("CONNECT "), connect...), "\r\n"...)); err != nil {
+		publisher.disconnect()
+		return fmt.Errorf("failed to send NATS CONNECT: %w", err)
+	}
+	return nil
+}
+
+// publish writes PUB followed by PING and waits for the PONG (caller holds the lock)
+func (publisher *NATSPublisher) publish(ctx context.Context, subject string, payload []byte) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(publishTimeout)
+	}
+	publisher.conn.SetDeadline(deadline)
+
+	message := make([]byte, 0, len(subject)+len(payload)+32)
+	message = fmt.Appendf(message, "PUB %s %d\r\n", subject, len(payload))
+	message = append(message, payload...)
+	message = append(message, "\r\nPING\r\n"...)
+	if _, err := publisher.conn.Write(message); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	for {
+		line, err := publisher.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read NATS acknowledgement: %w", err)
+		}
+
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := publisher.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("failed to answer NATS PING: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// disconnect closes and forgets the connection (caller holds the lock)
+func (publisher *NATSPublisher) disconnect() {
+	if publisher.conn != nil {
+		publisher.conn.Close()
+		publisher.conn = nil
+		publisher.reader = nil
+	}
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATSServer accepts one connection, speaks enough of the protocol to acknowledge
+// publishes, and sends received payloads to the returned channel. With a certificate, it
+// requires TLS after the INFO banner.
+func fakeNATSServer(t *testing.T, certificate *tls.Certificate) (string, <-chan string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 10)
+	connects := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if certificate == nil {
+			conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		} else {
+			conn.Write([]byte("INFO {\"server_id\":\"test\",\"tls_required\":true}\r\n"))
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{*certificate}})
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+		}
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 2 && fields[0] == "CONNECT":
+				connects <- fields[1]
+			case len(fields) == 3 && fields[0] == "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+				received <- fields[1] + " " + string(payload[:size])
+			case len(fields) == 1 && fields[0] == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+
+	return "nats://" + listener.Addr().String(), received, connects
+}
+
+func TestNewNATSPublisher_InvalidURL(t *testing.T) {
+	for _, serverURL := range []string{"", "http://localhost:4222", "nats://"} {
+		if _, err := NewNATSPublisher(serverURL); err == nil {
+			t.Errorf("NewNATSPublisher(%q) expected error", serverURL)
+		}
+	}
+}
+
+func TestNATSPublisher_Publish(t *testing.T) {
+	serverURL, received, _ := fakeNATSServer(t, nil)
+
+	publisher, err := NewNATSPublisher(serverURL)
+	if err != nil {
+		t.Fatalf("NewNATSPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, payload := range []string{`{"base":"USD"}`, `{"base":"EUR"}`} {
+		if err := publisher.Publish(ctx, "rates", "", []byte(payload)); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if got := <-received; got != "rates "+payload {
+			t.Errorf("server received %q, want %q", got, "rates "+payload)
+		}
+	}
+}
+
+func TestNATSPublisher_Authentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		userinfo string
+		want     natsConnect
+	}{
+		{name: "user and password", userinfo: "rates:s3cret@", want: natsConnect{User: "rates", Password: "s3cret"}},
+		{name: "token", userinfo: "t0ken@", want: natsConnect{AuthToken: "t0ken"}},
+		{name: "none", userinfo: "", want: natsConnect{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverURL, received, connects := fakeNATSServer(t, nil)
+			publisher, err := NewNATSPublisher(strings.Replace(serverURL, "nats://", "nats://"+tt.userinfo, 1))
+			if err != nil {
+				t.Fatalf("NewNATSPublisher() error = %v", err)
+			}
+			defer publisher.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := publisher.Publish(ctx, "rates", "", []byte(`{}`)); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			<-received
+
+			var connect natsConnect
+			if err := json.Unmarshal([]byte(<-connects), &connect); err != nil {
+				t.Fatalf("failed to decode CONNECT: %v", err)
+			}
+			if connect.User != tt.want.User || connect.Password != tt.want.Password || connect.AuthToken != tt.want.AuthToken {
+				t.Errorf("CONNECT = %+v, want credentials %+v", connect, tt.want)
+			}
+		})
+	}
+}
+
+func TestNATSPublisher_TLS(t *testing.T) {
+	// The test server's certificate is valid for 127.0.0.1
+	certificateServer := httptest.NewTLSServer(nil)
+	defer certificateServer.Close()
+	serverURL, received, connects := fakeNATSServer(t, &certificateServer.TLS.Certificates[0])
+
+	publisher, err := NewNATSPublisher(serverURL)
+	if err != nil {
+		t.Fatalf("NewNATSPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+	publisher.rootCAs = x509.NewCertPool()
+	publisher.rootCAs.AddCert(certificateServer.Certificate())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := publisher.Publish(ctx, "rates", "", []byte(`{"base":"USD"}`)); err != nil {
+		t.Fatalf("Publish() to a server requiring TLS error = %v", err)
+	}
+	if got := <-received; got != `rates {"base":"USD"}` {
+		t.Errorf("server received %q", got)
+	}
+	if connect := <-connects; !strings.Contains(connect, `"tls_required":true`) {
+		t.Errorf("CONNECT = %s, want tls_required", connect)
+	}
+}
+
+func TestNATSPublisher_RequireTLS(t *testing.T) {
+	serverURL, _, _ := fakeNATSServer(t, nil)
+	publisher, err := NewNATSPublisher(strings.Replace(serverURL, "nats://", "tls://", 1))
+	if err != nil {
+		t.Fatalf("NewNATSPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := publisher.Publish(ctx, "rates", "", []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "does not offer TLS") {
+		t.Errorf("Publish() over tls:// to a plain server error = %v, want TLS refused", err)
+	}
+}
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// PairRate is the message published for each selected pair
+type PairRate struct {
+	Pair      string  `json:"pair"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Rate      float64 `json:"rate"`
+	Provider  string  `json:"provider"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// PairEmitter publishes the rate of each selected pair to its own topic whenever rates
+// are refreshed, for subscribers such as displays that want one value per topic.
+// A nil emitter is valid and publishes nothing.
+type PairEmitter struct {
+	*dispatcher
+	pairs         [][2]string
+	topicTemplate string
+}
+
+// NewMQTTPairEmitter creates a pair emitter for the MQTT configuration, or returns nil
+// when MQTT publishing is disabled or cannot be set up
+func NewMQTTPairEmitter(configuration config.MQTTConfig, logger logger.Logger) *PairEmitter {
+	if configuration.URL == "" || len(configuration.Pairs) == 0 {
+		return nil
+	}
+
+	publisher, err := NewMQTTPublisher(configuration.URL, configuration.ClientID, configuration.Username, configuration.Password, configuration.QoS, configuration.Retain)
+	if err != nil {
+		logger.Errorf("MQTT publishing disabled: %v", err)
+		return nil
+	}
+
+	logger.Infof("Publishing %d pairs to MQTT broker %s", len(configuration.Pairs), configuration.URL)
+	return newPairEmitter(publisher, configuration.Pairs, configuration.TopicTemplate, logger)
+}
+
+// newPairEmitter creates a pair emitter around a publisher, skipping malformed pairs
+func newPairEmitter(publisher Publisher, pairs []string, topicTemplate string, logger logger.Logger) *PairEmitter {
+	emitter := &PairEmitter{
+		dispatcher:    newDispatcher(publisher, logger),
+		topicTemplate: topicTemplate,
+	}
+	for _, pair := range pairs {
+		from, to, found := strings.Cut(pair, "/")
+		if !found || from == "" || to == "" {
+			logger.Warnf("Ignoring malformed pair %q: use FROM/TO", pair)
+			continue
+		}
+		emitter.pairs = append(emitter.pairs, [2]string{from, to})
+	}
+	return emitter
+}
+
+// RatesCached publishes the selected pairs that can be derived from the cached rates
+func (emitter *PairEmitter) RatesCached(exchangeRates models.RatesResponse) {
+	if emitter == nil {
+		return
+	}
+
+	for _, pair := range emitter.pairs {
+		rate, found := exchangeRates.PairRate(pair[0], pair[1])
+		if !found {
+			continue
+		}
+
+		payload, err := json.Marshal(PairRate{
+			Pair:      pair[0] + "/" + pair[1],
+			From:      pair[0],
+			To:        pair[1],
+			Rate:      rate,
+			Provider:  exchangeRates.Provider,
+			Timestamp: exchangeRates.Timestamp,
+		})
+		if err != nil {
+			emitter.logger.Errorf("Failed to encode %s/%s rate: %v", pair[0], pair[1], err)
+			continue
+		}
+		emitter.dispatch(emitter.topic(pair[0], pair[1]), pair[0]+"/"+pair[1], payload)
+	}
+}
+
+// Checks returns the health check of the MQTT broker, or none when publishing is disabled
+func (emitter *PairEmitter) Checks() []health.Check {
+	if emitter == nil {
+		return nil
+	}
+	return []health.Check{emitter.healthCheck("broker:mqtt")}
+}
+
+// Close publishes the queued rates and closes the publisher
+func (emitter *PairEmitter) Close() error {
+	if emitter == nil {
+		return nil
+	}
+	return emitter.dispatcher.close()
+}
+
+// topic fills the topic template for a pair
+func (emitter *PairEmitter) topic(from, to string) string {
+	return strings.NewReplacer("{from}", from, "{to}", to).Replace(emitter.topicTemplate)
+}
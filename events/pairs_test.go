@@ -0,0 +1,53 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestNewMQTTPairEmitter_Disabled(t *testing.T) {
+	emitter := NewMQTTPairEmitter(config.MQTTConfig{Pairs: []string{"USD/EUR"}}, testutils.MockLogger())
+	if emitter != nil {
+		t.Fatalf("NewMQTTPairEmitter() = %v, want nil without a broker URL", emitter)
+	}
+
+	emitter.RatesCached(testutils.MockRatesResponse())
+	if err := emitter.Close(); err != nil {
+		t.Errorf("Close() on nil emitter error = %v", err)
+	}
+}
+
+func TestPairEmitter_RatesCached(t *testing.T) {
+	publisher := &recordingPublisher{}
+	emitter := newPairEmitter(publisher, []string{"USD/EUR", "EUR/GBP", "USD/XYZ", "bogus"}, "rates/{from}/{to}", testutils.MockLogger())
+
+	emitter.RatesCached(models.RatesResponse{
+		Base:      "USD",
+		Provider:  "erapi",
+		Timestamp: 1641000000,
+		Rates:     models.RateTable{"EUR": 0.8, "GBP": 0.72},
+	})
+	if err := emitter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// USD/XYZ has no rate and "bogus" is not a pair
+	if len(publisher.payloads) != 2 {
+		t.Fatalf("published %d pairs, want 2", len(publisher.payloads))
+	}
+	if publisher.topics[0] != "rates/USD/EUR" || publisher.topics[1] != "rates/EUR/GBP" {
+		t.Errorf("published topics = %v", publisher.topics)
+	}
+
+	var cross PairRate
+	if err := json.Unmarshal(publisher.payloads[1], &cross); err != nil {
+		t.Fatalf("failed to decode pair rate: %v", err)
+	}
+	if cross.Pair != "EUR/GBP" || cross.Rate < 0.8999 || cross.Rate > 0.9001 || cross.Provider != "erapi" {
+		t.Errorf("EUR/GBP pair rate = %+v, want 0.9 from erapi", cross)
+	}
+}
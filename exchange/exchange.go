@@ -0,0 +1,166 @@
+// Package exchange embeds the exchange rate engine in another Go program: rates are
+// fetched, cached and converted in-process, without the HTTP server.
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+)
+
+// ErrStopped is returned when starting or stopping a Service that was stopped
+var ErrStopped = errors.New("exchange service stopped")
+
+// Service is the exchange rate engine of the HTTP service: the configured providers with
+// their fallbacks, the rates cache, markup and source policies
+type Service struct {
+	configuration *config.Config
+	logger        logger.Logger
+	logOutputs    io.Closer
+	rates         *service.RatesService
+
+	mutex   sync.Mutex
+	stop    context.CancelFunc // Stops the background jobs (nil = not started)
+	stopped bool
+}
+
+// New validates the configuration, as config.Load returns it, and creates the engine.
+// Logs go to the outputs of its logging settings. The currency aliases of the
+// configuration apply process-wide.
+func New(configuration *config.Config) (*Service, error) {
+	if err := Validate(configuration); err != nil {
+		return nil, err
+	}
+	loggerInstance, logOutputs, err := logger.NewFromConfig(configuration.LogLevel, configuration.Logging)
+	if err != nil {
+		return nil, err
+	}
+
+	currency.SetAliases(configuration.CurrencyAliases)
+	rates := service.NewRatesService(configuration, loggerInstance)
+	if _, known := currency.Lookup(rates.DefaultBaseCurrency()); !known {
+		logOutputs.Close()
+		return nil, fmt.Errorf("unknown DEFAULT_BASE_CURRENCY %s", rates.DefaultBaseCurrency())
+	}
+	if !rates.IsBaseAllowed(rates.DefaultBaseCurrency()) {
+		logOutputs.Close()
+		return nil, fmt.Errorf("base %s is not in ALLOWED_BASE_CURRENCIES", rates.DefaultBaseCurrency())
+	}
+
+	return &Service{
+		configuration: configuration,
+		logger:        loggerInstance,
+		logOutputs:    logOutputs,
+		rates:         rates,
+	}, nil
+}
+
+// Validate checks the settings of the exchange rate engine: currency aliases, providers,
+// source policies, latency budgets of providers, dated rates and staleness
+func Validate(configuration *config.Config) error {
+	for alias, code := range configuration.CurrencyAliases {
+		if _, known := currency.Lookup(code); !known {
+			return fmt.Errorf("currency alias %s names unknown currency %s", alias, code)
+		}
+	}
+
+	providerNames := make(map[string]bool)
+	for _, providerConfig := range configuration.ExchangeRateProviders {
+		if _, _, err := service.ParseOutboundLimit(providerConfig.RateLimit); err != nil {
+			return fmt.Errorf("provider %s: %w", providerConfig.Name, err)
+		}
+		switch providerConfig.Type {
+		case "", config.ProviderTypeHTTP:
+		case config.ProviderTypeComposite:
+			if err := service.ValidateComposite(providerConfig, configuration.ExchangeRateProviders); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("provider %s has unknown type %q", providerConfig.Name, providerConfig.Type)
+		}
+		providerNames[providerConfig.Name] = true
+	}
+	for name := range configuration.LatencyBudgets.Providers {
+		if !providerNames[name] {
+			return fmt.Errorf("latency budget for unknown provider %s", name)
+		}
+	}
+
+	if err := service.ValidateSourcePolicy(configuration.SourcePolicy, configuration.ExchangeRateProviders); err != nil {
+		return err
+	}
+	for _, tenant := range configuration.Tenants {
+		if err := service.ValidateSourcePolicy(tenant.SourcePolicy, configuration.ExchangeRateProviders); err != nil {
+			return fmt.Errorf("tenant %s: %w", tenant.ID, err)
+		}
+	}
+	if err := service.ValidateDatedRates(configuration.DatedRates); err != nil {
+		return err
+	}
+	return service.ValidateStaleness(configuration.Staleness)
+}
+
+// Start runs the background jobs until Stop: probing disabled providers in standby and
+// re-enabling them once they recover. Rates can be requested without starting.
+func (exchangeService *Service) Start(ctx context.Context) error {
+	exchangeService.mutex.Lock()
+	defer exchangeService.mutex.Unlock()
+	if exchangeService.stopped {
+		return ErrStopped
+	}
+	if exchangeService.stop != nil {
+		return errors.New("exchange service already started")
+	}
+
+	backgroundCtx, stop := context.WithCancel(ctx)
+	exchangeService.stop = stop
+	exchangeService.rates.StartStandbyProbes(backgroundCtx, exchangeService.configuration.ProviderStandby.ProbeInterval)
+	exchangeService.logger.Info("Exchange service started")
+	return nil
+}
+
+// Stop ends the background jobs, flushes pending rate events and closes the log outputs.
+// A Service that was never started is stopped too, to release its log outputs.
+func (exchangeService *Service) Stop() error {
+	exchangeService.mutex.Lock()
+	defer exchangeService.mutex.Unlock()
+	if exchangeService.stopped {
+		return ErrStopped
+	}
+
+	if exchangeService.stop != nil {
+		exchangeService.stop()
+	}
+	exchangeService.stopped = true
+	exchangeService.logger.Info("Exchange service stopped")
+	return errors.Join(exchangeService.rates.Close(), exchangeService.logOutputs.Close())
+}
+
+// GetRates returns the rates of a base currency, from the cache or the providers. The
+// base may be an alias, e.g. RMB; empty selects the default base.
+func (exchangeService *Service) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
+	if baseCurrency == "" {
+		baseCurrency = exchangeService.rates.DefaultBaseCurrency()
+	}
+	return exchangeService.rates.GetRates(ctx, currency.Normalize(baseCurrency))
+}
+
+// Convert converts an amount between two currencies at the mid rate, with the
+// configured markup applied
+func (exchangeService *Service) Convert(ctx context.Context, fromCurrency, toCurrency string, amount float64) (models.ConversionResponse, error) {
+	return exchangeService.rates.Convert(ctx, currency.Normalize(fromCurrency), currency.Normalize(toCurrency), amount, models.SideMid)
+}
+
+// Rates returns the underlying rates service, for the operations the facade does not
+// cover such as historical rates and bid and ask conversions
+func (exchangeService *Service) Rates() *service.RatesService {
+	return exchangeService.rates
+}
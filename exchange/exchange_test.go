@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// validConfig completes a mock configuration with the settings New validates
+func validConfig(cfg *config.Config) *config.Config {
+	cfg.DatedRates.Interpolation = service.InterpolationNone
+	cfg.LogLevel = "error"
+	return cfg
+}
+
+func TestService(t *testing.T) {
+	providerServer := testsupport.NewProviderServer()
+	defer providerServer.Close()
+
+	exchangeService, err := New(validConfig(testutils.MockConfigWithMocks(providerServer.URL(), providerServer.URL())))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := exchangeService.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := exchangeService.Start(context.Background()); err == nil {
+		t.Error("second Start() expected error")
+	}
+
+	rates, err := exchangeService.GetRates(context.Background(), "usd")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if rates.Base != "USD" || rates.Rates["EUR"] == 0 {
+		t.Errorf("GetRates() = %+v, want USD rates with EUR", rates)
+	}
+
+	conversion, err := exchangeService.Convert(context.Background(), "USD", "EUR", 100)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if want := 100 * rates.Rates["EUR"]; conversion.Converted != want {
+		t.Errorf("Convert() Converted = %v, want %v", conversion.Converted, want)
+	}
+
+	if err := exchangeService.Stop(); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	if err := exchangeService.Stop(); !errors.Is(err, ErrStopped) {
+		t.Errorf("second Stop() error = %v, want ErrStopped", err)
+	}
+	if err := exchangeService.Start(context.Background()); !errors.Is(err, ErrStopped) {
+		t.Errorf("Start() after Stop() error = %v, want ErrStopped", err)
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+	}{
+		{name: "unknown alias target", modify: func(cfg *config.Config) {
+			cfg.CurrencyAliases = map[string]string{"BUCK": "XYZ"}
+		}},
+		{name: "unknown provider type", modify: func(cfg *config.Config) {
+			cfg.ExchangeRateProviders[0].Type = "carrier-pigeon"
+		}},
+		{name: "unknown default base", modify: func(cfg *config.Config) {
+			cfg.DefaultBaseCurrency = "XYZ"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(testutils.MockConfig())
+			if _, err := New(cfg); err != nil {
+				t.Fatalf("New() of the unmodified configuration error = %v", err)
+			}
+			tt.modify(cfg)
+
+			if _, err := New(cfg); err == nil {
+				t.Error("New() expected error")
+			}
+		})
+	}
+}
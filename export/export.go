@@ -0,0 +1,188 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Format identifies a supported export file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// header lists the columns written for every export format
+var header = []string{"base", "currency", "rate", "provider", "published_at"}
+
+// ParseFormat validates a requested export format
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatCSV, FormatXLSX:
+		return Format(value), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", value)
+	}
+}
+
+// ContentType returns the MIME type for the format
+func (format Format) ContentType() string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv"
+	}
+}
+
+// Write writes the rates table in the given format
+func Write(writer io.Writer, format Format, exchangeRates models.RatesResponse) error {
+	switch format {
+	case FormatXLSX:
+		return WriteXLSX(writer, exchangeRates)
+	default:
+		return WriteCSV(writer, exchangeRates)
+	}
+}
+
+// WriteCSV writes the rates table as CSV, one row per currency
+func WriteCSV(writer io.Writer, exchangeRates models.RatesResponse) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows(exchangeRates) {
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteXLSX writes the rates table as a single-sheet Office Open XML workbook
+func WriteXLSX(writer io.Writer, exchangeRates models.RatesResponse) error {
+	zipWriter := zip.NewWriter(writer)
+
+	staticParts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	}
+	for _, part := range staticParts {
+		partWriter, err := zipWriter.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(partWriter, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheetWriter, err := zipWriter.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(sheetWriter, exchangeRates); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+// rows returns the table rows sorted by currency code
+func rows(exchangeRates models.RatesResponse) [][]string {
+	currencies := make([]string, 0, len(exchangeRates.Rates))
+	for currency := range exchangeRates.Rates {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	publishedAt := ""
+	if exchangeRates.PublishedAt > 0 {
+		publishedAt = time.Unix(exchangeRates.PublishedAt, 0).UTC().Format(time.RFC3339)
+	}
+
+	tableRows := make([][]string, 0, len(currencies))
+	for _, currency := range currencies {
+		tableRows = append(tableRows, []string{
+			exchangeRates.Base,
+			currency,
+			strconv.FormatFloat(exchangeRates.Rates[currency], 'f', -1, 64),
+			exchangeRates.Provider,
+			publishedAt,
+		})
+	}
+	return tableRows
+}
+
+// writeSheet writes the worksheet XML; the rate column is numeric, all others are strings
+func writeSheet(writer io.Writer, exchangeRates models.RatesResponse) error {
+	if _, err := io.WriteString(writer, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	allRows := append([][]string{header}, rows(exchangeRates)...)
+	for rowIndex, row := range allRows {
+		if _, err := fmt.Fprintf(writer, `<row r="%d">`, rowIndex+1); err != nil {
+			return err
+		}
+		for columnIndex, value := range row {
+			cellRef := fmt.Sprintf("%c%d", 'A'+columnIndex, rowIndex+1)
+			if rowIndex > 0 && columnIndex == 2 {
+				_, err := fmt.Fprintf(writer, `<c r="%s"><v>%s</v></c>`, cellRef, value)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := fmt.Fprintf(writer, `<c r="%s" t="inlineStr"><is><t>`, cellRef); err != nil {
+				return err
+			}
+			if err := xml.EscapeText(writer, []byte(value)); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(writer, `</t></is></c>`); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(writer, `</row>`); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(writer, `</sheetData></worksheet>`)
+	return err
+}
+
+const contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const rootRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookXML = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Rates" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const workbookRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
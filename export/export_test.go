@@ -0,0 +1,85 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func testRates() models.RatesResponse {
+	return models.RatesResponse{
+		Base:        "USD",
+		PublishedAt: 1640995200,
+		Rates: map[string]float64{
+			"GBP": 0.73,
+			"EUR": 0.85,
+		},
+		Provider: "test-provider",
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, value := range []string{"csv", "xlsx"} {
+		if _, err := ParseFormat(value); err != nil {
+			t.Errorf("ParseFormat(%s) error = %v", value, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("ParseFormat(pdf) expected error")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteCSV(&buffer, testRates()); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	expected := "base,currency,rate,provider,published_at\n" +
+		"USD,EUR,0.85,test-provider,2022-01-01T00:00:00Z\n" +
+		"USD,GBP,0.73,test-provider,2022-01-01T00:00:00Z\n"
+	if buffer.String() != expected {
+		t.Errorf("WriteCSV() = %q, want %q", buffer.String(), expected)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := WriteXLSX(&buffer, testRates()); err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("WriteXLSX() produced invalid zip: %v", err)
+	}
+
+	parts := map[string]string{}
+	for _, file := range zipReader.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+		parts[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, found := parts[name]; !found {
+			t.Errorf("WriteXLSX() missing part %s", name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(sheet, `<c r="C2"><v>0.85</v></c>`) {
+		t.Errorf("WriteXLSX() sheet missing numeric EUR rate: %s", sheet)
+	}
+	if !strings.Contains(sheet, `<t>GBP</t>`) {
+		t.Errorf("WriteXLSX() sheet missing GBP row: %s", sheet)
+	}
+}
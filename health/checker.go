@@ -0,0 +1,303 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Mode controls how failed startup checks are handled
+type Mode string
+
+const (
+	ModeStrict Mode = "strict" // Fail startup unless every check passes
+	ModeWarn   Mode = "warn"   // Run checks at startup, log failures and start anyway
+	ModeLazy   Mode = "lazy"   // Skip checks at startup; run them on the first readiness probe
+)
+
+// Readiness statuses
+const (
+	StatusReady    = "ready"     // Every check passed
+	StatusDegraded = "degraded"  // Some checks failed, but every group has a passing check
+	StatusNotReady = "not_ready" // Every check of some group failed
+	StatusPending  = "pending"   // Checks have not run yet
+)
+
+// Dependency kinds reported with each check
+const (
+	KindProvider = "provider"
+	KindCache    = "cache"
+	KindDatabase = "database"
+	KindBroker   = "broker"
+)
+
+// Check is a single dependency check. Checks sharing a group are alternatives: the
+// service is usable as long as one of them passes (e.g. one reachable provider).
+type Check struct {
+	Name     string
+	Group    string
+	Kind     string // Dependency kind (provider, cache, database or broker)
+	Optional bool   // A failure degrades readiness but never makes the service not ready
+	Run      func(ctx context.Context) error
+	Detail   func() string // Optional state reported with the result (e.g. cache age)
+}
+
+// checkHistory remembers a check's outcomes across runs
+type checkHistory struct {
+	lastSuccessAt time.Time
+	lastError     string
+	lastErrorAt   time.Time
+}
+
+// ParseMode validates a configured check mode
+func ParseMode(value string) (Mode, error) {
+	switch Mode(value) {
+	case ModeStrict, ModeWarn, ModeLazy:
+		return Mode(value), nil
+	default:
+		return "", fmt.Errorf("invalid startup check mode %q: use strict, warn or lazy", value)
+	}
+}
+
+// Checker runs the registered dependency checks and keeps the latest report
+type Checker struct {
+	mode         Mode
+	timeout      time.Duration
+	cacheTTL     time.Duration // Age after which probes refresh the report (0 = never)
+	probeTimeout time.Duration // Time budget of checks run for probes
+	logger       logger.Logger
+	checks       []Check
+
+	mutex      sync.Mutex
+	report     *models.ReadinessReport
+	refreshing chan struct{} // Closed when the in-flight probe run finishes; nil when idle
+	history    map[string]checkHistory
+}
+
+// NewChecker creates a checker for the mode, bounding each run by timeout
+func NewChecker(mode Mode, timeout time.Duration, logger logger.Logger) *Checker {
+	return &Checker{
+		mode:         mode,
+		timeout:      timeout,
+		probeTimeout: timeout,
+		logger:       logger,
+		history:      make(map[string]checkHistory),
+	}
+}
+
+// SetRefresh makes readiness probes re-run the checks once the report is older than
+// ttl. Probes never wait for a refresh: they get the cached report while the checks run
+// in the background, bounded by timeout, so frequent probing cannot multiply upstream
+// traffic and a slow dependency cannot make probes time out.
+func (checker *Checker) SetRefresh(ttl, timeout time.Duration) {
+	checker.cacheTTL = ttl
+	if timeout > 0 {
+		checker.probeTimeout = timeout
+	}
+}
+
+// Register adds checks; it must be called before Startup
+func (checker *Checker) Register(checks ...Check) {
+	checker.checks = append(checker.checks, checks...)
+}
+
+// Startup runs the boot-time check phase. In strict mode it returns an error unless every
+// check passed; in warn mode failures are only logged; in lazy mode nothing runs.
+func (checker *Checker) Startup(ctx context.Context) error {
+	if checker.mode == ModeLazy {
+		checker.logger.Info("Startup checks deferred until the first readiness probe")
+		return nil
+	}
+
+	report := checker.Run(ctx)
+	for _, check := range report.Checks {
+		if check.Status != "ok" {
+			checker.logger.Warnf("Startup check %s failed: %s", check.Name, check.Error)
+		}
+	}
+
+	if report.Status != StatusReady && checker.mode == ModeStrict {
+		return fmt.Errorf("startup checks failed: %s", failedNames(report))
+	}
+	checker.logger.Infof("Startup checks finished: %s", report.Status)
+	return nil
+}
+
+// Report returns the latest readiness report. A report older than the refresh TTL is
+// returned as is while a background run refreshes it. In lazy mode the first report
+// waits for the checks, sharing one run between concurrent probes.
+func (checker *Checker) Report(ctx context.Context) models.ReadinessReport {
+	checker.mutex.Lock()
+	report := checker.report
+	var done <-chan struct{}
+	switch {
+	case report == nil && checker.mode == ModeLazy:
+		done = checker.refresh()
+	case report != nil && checker.cacheTTL > 0 && time.Since(report.CheckedAt) >= checker.cacheTTL:
+		checker.refresh()
+	}
+	checker.mutex.Unlock()
+
+	if report != nil {
+		return *report
+	}
+	if done != nil {
+		select {
+		case <-done:
+			checker.mutex.Lock()
+			report = checker.report
+			checker.mutex.Unlock()
+			return *report
+		case <-ctx.Done():
+		}
+	}
+	return models.ReadinessReport{Status: StatusPending, Mode: string(checker.mode), Checks: []models.DependencyStatus{}}
+}
+
+// Run executes every check concurrently within the timeout and stores the report
+func (checker *Checker) Run(ctx context.Context) models.ReadinessReport {
+	return checker.run(ctx, checker.timeout)
+}
+
+// refresh starts a background run for probes unless one is in flight, returning a
+// channel closed when it finishes (caller holds the lock)
+func (checker *Checker) refresh() <-chan struct{} {
+	if checker.refreshing == nil {
+		done := make(chan struct{})
+		checker.refreshing = done
+		go func() {
+			checker.run(context.Background(), checker.probeTimeout)
+
+			checker.mutex.Lock()
+			checker.refreshing = nil
+			checker.mutex.Unlock()
+			close(done)
+		}()
+	}
+	return checker.refreshing
+}
+
+// run executes every check concurrently within timeout and stores the report
+func (checker *Checker) run(ctx context.Context, timeout time.Duration) models.ReadinessReport {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	statuses := make([]models.DependencyStatus, len(checker.checks))
+	var wg sync.WaitGroup
+	for i, check := range checker.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			statuses[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := models.ReadinessReport{
+		Status:    aggregateStatus(statuses),
+		Mode:      string(checker.mode),
+		CheckedAt: time.Now(),
+		Checks:    statuses,
+	}
+
+	checker.mutex.Lock()
+	for i := range report.Checks {
+		checker.remember(&report.Checks[i], report.CheckedAt)
+	}
+	checker.report = &report
+	checker.mutex.Unlock()
+	return report
+}
+
+// remember records a check's outcome and fills in its last success and last error, which
+// may come from earlier runs (caller holds the lock)
+func (checker *Checker) remember(status *models.DependencyStatus, checkedAt time.Time) {
+	history := checker.history[status.Name]
+	if status.Status == "ok" {
+		history.lastSuccessAt = checkedAt
+	} else {
+		history.lastError = status.Error
+		history.lastErrorAt = checkedAt
+	}
+	checker.history[status.Name] = history
+
+	status.LastSuccessAt = history.lastSuccessAt
+	status.LastError = history.lastError
+	status.LastErrorAt = history.lastErrorAt
+}
+
+// runCheck runs one check and records its outcome and duration
+func runCheck(ctx context.Context, check Check) models.DependencyStatus {
+	group := check.Group
+	if group == "" {
+		group = check.Name
+	}
+
+	start := time.Now()
+	err := check.Run(ctx)
+	status := models.DependencyStatus{
+		Name:       check.Name,
+		Kind:       check.Kind,
+		Group:      group,
+		Optional:   check.Optional,
+		Status:     "ok",
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = "failed"
+		status.Error = err.Error()
+	}
+	if check.Detail != nil {
+		status.Detail = check.Detail()
+	}
+	return status
+}
+
+// aggregateStatus derives the overall readiness from the check results. Failed optional
+// checks only degrade it.
+func aggregateStatus(statuses []models.DependencyStatus) string {
+	groupPassing := make(map[string]bool)
+	failed := false
+	for _, status := range statuses {
+		if status.Status == "ok" {
+			groupPassing[status.Group] = true
+			continue
+		}
+		failed = true
+		if status.Optional {
+			continue
+		}
+		if _, seen := groupPassing[status.Group]; !seen {
+			groupPassing[status.Group] = false
+		}
+	}
+
+	for _, passing := range groupPassing {
+		if !passing {
+			return StatusNotReady
+		}
+	}
+	if failed {
+		return StatusDegraded
+	}
+	return StatusReady
+}
+
+// failedNames lists the failed checks of a report
+func failedNames(report models.ReadinessReport) string {
+	names := []string{}
+	for _, check := range report.Checks {
+		if check.Status != "ok" {
+			names = append(names, check.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
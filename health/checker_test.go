@@ -0,0 +1,241 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func passing(name, group string) Check {
+	return Check{Name: name, Group: group, Run: func(ctx context.Context) error { return nil }}
+}
+
+func failing(name, group string) Check {
+	return Check{Name: name, Group: group, Run: func(ctx context.Context) error { return errors.New("unreachable") }}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, value := range []string{"strict", "warn", "lazy"} {
+		if _, err := ParseMode(value); err != nil {
+			t.Errorf("ParseMode(%q) error = %v", value, err)
+		}
+	}
+	if _, err := ParseMode("sometimes"); err == nil {
+		t.Error("ParseMode(\"sometimes\") expected error")
+	}
+}
+
+func TestChecker_Run_Status(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{"no checks", nil, StatusReady},
+		{"all passing", []Check{passing("provider:a", "providers"), passing("store", "")}, StatusReady},
+		{"one provider down", []Check{passing("provider:a", "providers"), failing("provider:b", "providers")}, StatusDegraded},
+		{"all providers down", []Check{failing("provider:a", "providers"), failing("provider:b", "providers")}, StatusNotReady},
+		{"ungrouped check down", []Check{passing("provider:a", "providers"), failing("store", "")}, StatusNotReady},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(ModeWarn, time.Second, testutils.MockLogger())
+			checker.Register(tt.checks...)
+
+			report := checker.Run(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("Run() status = %v, want %v", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("Run() checks = %v, want %v", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestChecker_Startup(t *testing.T) {
+	tests := []struct {
+		mode        Mode
+		wantErr     bool
+		wantPending bool
+	}{
+		{ModeStrict, true, false},
+		{ModeWarn, false, false},
+		{ModeLazy, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			runs := 0
+			checker := NewChecker(tt.mode, time.Second, testutils.MockLogger())
+			checker.Register(passing("provider:a", "providers"), Check{
+				Name:  "provider:typo",
+				Group: "providers",
+				Run: func(ctx context.Context) error {
+					runs++
+					return errors.New("no such host")
+				},
+			})
+
+			err := checker.Startup(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Startup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (runs == 0) != tt.wantPending {
+				t.Errorf("Startup() ran checks %d times in %s mode", runs, tt.mode)
+			}
+
+			// Lazy mode runs the checks on the first report only
+			checker.Report(context.Background())
+			report := checker.Report(context.Background())
+			if report.Status != StatusDegraded || runs != 1 {
+				t.Errorf("Report() status = %v after %d runs, want %v after 1", report.Status, runs, StatusDegraded)
+			}
+		})
+	}
+}
+
+func TestChecker_Run_Timeout(t *testing.T) {
+	checker := NewChecker(ModeWarn, 20*time.Millisecond, testutils.MockLogger())
+	checker.Register(Check{Name: "slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	start := time.Now()
+	report := checker.Run(context.Background())
+	if report.Status != StatusNotReady {
+		t.Errorf("Run() status = %v, want %v", report.Status, StatusNotReady)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Run() took %v, want it bounded by the timeout", time.Since(start))
+	}
+}
+
+func TestChecker_Report_Refresh(t *testing.T) {
+	var runs atomic.Int32
+	checker := NewChecker(ModeWarn, time.Second, testutils.MockLogger())
+	checker.SetRefresh(50*time.Millisecond, 20*time.Millisecond)
+	checker.Register(Check{Name: "provider:a", Run: func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			return nil
+		}
+		// Refreshes hang until their own deadline
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	if err := checker.Startup(context.Background()); err != nil {
+		t.Fatalf("Startup() error = %v", err)
+	}
+
+	// Fresh reports are served from cache
+	for i := 0; i < 5; i++ {
+		checker.Report(context.Background())
+	}
+	if runs.Load() != 1 {
+		t.Fatalf("checks ran %d times for fresh reports, want 1", runs.Load())
+	}
+
+	// A stale report is returned at once while a single refresh runs in the background
+	time.Sleep(60 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if report := checker.Report(context.Background()); report.Status != StatusReady {
+			t.Errorf("Report() status = %v while refreshing, want the cached %v", report.Status, StatusReady)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("Report() waited %v for the refresh", elapsed)
+	}
+
+	// The refresh is bounded by the probe timeout and then replaces the report
+	deadline := time.Now().Add(time.Second)
+	for checker.Report(context.Background()).Status == StatusReady && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	report := checker.Report(context.Background())
+	if report.Status != StatusNotReady {
+		t.Errorf("Report() status = %v after the refresh timed out, want %v", report.Status, StatusNotReady)
+	}
+	if runs.Load() != 2 {
+		t.Errorf("checks ran %d times, want 2", runs.Load())
+	}
+}
+
+func TestChecker_Report_LazyWaitsForFirstRun(t *testing.T) {
+	var runs atomic.Int32
+	checker := NewChecker(ModeLazy, time.Second, testutils.MockLogger())
+	checker.Register(Check{Name: "provider:a", Run: func(ctx context.Context) error {
+		runs.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if report := checker.Report(context.Background()); report.Status != StatusReady {
+				t.Errorf("Report() status = %v, want %v", report.Status, StatusReady)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs.Load() != 1 {
+		t.Errorf("concurrent first probes ran the checks %d times, want 1", runs.Load())
+	}
+
+	// A probe giving up before the first run finishes gets a pending report
+	slow := NewChecker(ModeLazy, time.Second, testutils.MockLogger())
+	slow.Register(Check{Name: "slow", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if report := slow.Report(ctx); report.Status != StatusPending {
+		t.Errorf("Report() status = %v, want %v", report.Status, StatusPending)
+	}
+}
+
+func TestChecker_Run_Detail(t *testing.T) {
+	fail := true
+	checker := NewChecker(ModeWarn, time.Second, testutils.MockLogger())
+	checker.Register(
+		passing("provider:a", "providers"),
+		Check{Name: "broker:nats", Kind: KindBroker, Optional: true, Run: func(ctx context.Context) error {
+			if fail {
+				return errors.New("connection refused")
+			}
+			return nil
+		}, Detail: func() string { return "3 queued" }},
+	)
+
+	// A failed optional check only degrades readiness
+	report := checker.Run(context.Background())
+	if report.Status != StatusDegraded {
+		t.Errorf("Run() status = %v, want %v", report.Status, StatusDegraded)
+	}
+	broker := report.Checks[1]
+	if broker.Kind != KindBroker || !broker.Optional || broker.Detail != "3 queued" || broker.Error != "connection refused" {
+		t.Errorf("broker status = %+v", broker)
+	}
+	if broker.LastError != "connection refused" || broker.LastErrorAt.IsZero() || !broker.LastSuccessAt.IsZero() {
+		t.Errorf("broker history after failure = %+v", broker)
+	}
+
+	// The last error is still reported after the dependency recovers
+	fail = false
+	broker = checker.Run(context.Background()).Checks[1]
+	if broker.Status != "ok" || broker.Error != "" || broker.LastError != "connection refused" || broker.LastSuccessAt.IsZero() {
+		t.Errorf("broker status after recovery = %+v", broker)
+	}
+}
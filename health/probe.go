@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Probe requests the health endpoint at the URL with the client and returns an error
+// unless the service answers 200 and reports itself healthy. It backs the -healthcheck
+// flag, whose exit code container runtimes such as Docker's HEALTHCHECK act on.
+func Probe(ctx context.Context, client *http.Client, url string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("health endpoint answered %d", response.StatusCode)
+	}
+	var healthCheck models.HealthCheck
+	if err := json.NewDecoder(response.Body).Decode(&healthCheck); err != nil {
+		return fmt.Errorf("invalid health response: %w", err)
+	}
+	if healthCheck.Status != "healthy" {
+		return fmt.Errorf("service reports status %q", healthCheck.Status)
+	}
+	return nil
+}
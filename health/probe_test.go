@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{name: "healthy", status: http.StatusOK, body: `{"status": "healthy"}`},
+		{name: "error status", status: http.StatusServiceUnavailable, body: `{"status": "healthy"}`, wantErr: true},
+		{name: "unhealthy", status: http.StatusOK, body: `{"status": "starting"}`, wantErr: true},
+		{name: "not JSON", status: http.StatusOK, body: `ok`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			if err := Probe(context.Background(), server.Client(), server.URL+"/health"); (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := Probe(context.Background(), http.DefaultClient, "http://127.0.0.1:1/health"); err == nil {
+		t.Error("Probe() of a closed port expected error")
+	}
+}
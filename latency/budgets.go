@@ -0,0 +1,238 @@
+// Package latency checks routes and provider calls against target p95 latencies, giving
+// alerts a counter and a structured warning to fire on.
+package latency
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+const (
+	slotCount   = 10 // Slots the rolling window is divided into
+	bucketCount = 54 // Latency histogram buckets, the last one unbounded
+)
+
+// bucketBounds are the upper bounds of the latency histogram buckets, growing by a
+// quarter from 1ms to almost two minutes
+var bucketBounds = func() [bucketCount - 1]time.Duration {
+	var bounds [bucketCount - 1]time.Duration
+	bound := float64(time.Millisecond)
+	for index := range bounds {
+		bounds[index] = time.Duration(bound)
+		bound *= 1.25
+	}
+	return bounds
+}()
+
+// histogram counts calls per latency bucket
+type histogram [bucketCount]int64
+
+// slot counts the calls of one slice of the window
+type slot struct {
+	start  time.Time
+	total  int64
+	over   int64 // Calls slower than the budget
+	counts histogram
+}
+
+// series is the rolling window and breach state of one route or provider
+type series struct {
+	budget   time.Duration
+	slots    [slotCount]slot
+	exceeded bool
+	breaches int64
+}
+
+// Budgets tracks the rolling p95 latency of routes or providers against their budgets.
+// The p95 is over its budget when more than 5% of the calls in the window were slower,
+// so breaches are detected exactly; the reported p95 is estimated from a histogram.
+// A breach counts as budget_exceeded and logs a warning once, until the p95 is met
+// again. A nil value is valid and tracks nothing.
+type Budgets struct {
+	kind       string // "route" or "provider", the log field naming what is tracked
+	window     time.Duration
+	minSamples int64
+	logger     logger.Logger
+	now        func() time.Time
+
+	mutex  sync.Mutex
+	series map[string]*series
+}
+
+// NewBudgets returns the tracker of the budgets by name, or nil without budgets. Breaches
+// are only reported once the window holds minSamples calls.
+func NewBudgets(kind string, budgets map[string]time.Duration, window time.Duration, minSamples int, log logger.Logger) *Budgets {
+	tracked := make(map[string]*series, len(budgets))
+	for name, budget := range budgets {
+		if budget > 0 {
+			tracked[name] = &series{budget: budget}
+		}
+	}
+	if len(tracked) == 0 {
+		return nil
+	}
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	return &Budgets{
+		kind:       kind,
+		window:     window,
+		minSamples: int64(max(minSamples, 1)),
+		logger:     log,
+		now:        time.Now,
+		series:     tracked,
+	}
+}
+
+// Names returns the names that have a budget, sorted
+func (budgets *Budgets) Names() []string {
+	if budgets == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(budgets.series))
+	for name := range budgets.series {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Observe records the duration of a call, ignoring names without a budget
+func (budgets *Budgets) Observe(name string, duration time.Duration) {
+	if budgets == nil {
+		return
+	}
+	tracked, found := budgets.series[name]
+	if !found {
+		return
+	}
+
+	budgets.mutex.Lock()
+	defer budgets.mutex.Unlock()
+
+	now := budgets.now()
+	current := budgets.slotAt(tracked, now)
+	current.total++
+	current.counts[bucketFor(duration)]++
+	if duration > tracked.budget {
+		current.over++
+	}
+	budgets.evaluate(name, tracked, now)
+}
+
+// Stats reports every budget, sorted by name
+func (budgets *Budgets) Stats() []models.LatencyBudget {
+	if budgets == nil {
+		return nil
+	}
+
+	budgets.mutex.Lock()
+	defer budgets.mutex.Unlock()
+
+	now := budgets.now()
+	stats := make([]models.LatencyBudget, 0, len(budgets.series))
+	for _, name := range budgets.Names() {
+		tracked := budgets.series[name]
+		budgets.evaluate(name, tracked, now)
+		total, over, counts := budgets.sum(tracked, now)
+		stats = append(stats, models.LatencyBudget{
+			Name:           name,
+			BudgetMS:       milliseconds(tracked.budget),
+			P95MS:          milliseconds(p95(total, counts)),
+			Samples:        total,
+			OverBudget:     over,
+			Exceeded:       tracked.exceeded,
+			BudgetExceeded: tracked.breaches,
+		})
+	}
+	return stats
+}
+
+// evaluate updates the breach state from the window, logging changes (caller holds the lock)
+func (budgets *Budgets) evaluate(name string, tracked *series, now time.Time) {
+	total, over, counts := budgets.sum(tracked, now)
+	exceeded := total >= budgets.minSamples && over*20 > total
+	if exceeded == tracked.exceeded {
+		return
+	}
+
+	tracked.exceeded = exceeded
+	fields := logger.Fields{
+		budgets.kind: name,
+		"p95_ms":     milliseconds(p95(total, counts)),
+		"budget_ms":  milliseconds(tracked.budget),
+		"samples":    total,
+	}
+	if exceeded {
+		tracked.breaches++
+		budgets.logger.WithFields(fields).Warn("Latency budget exceeded")
+		return
+	}
+	budgets.logger.WithFields(fields).Info("Latency budget met again")
+}
+
+// slotAt returns the slot of the time, emptied when it last held an older slice of the
+// window (caller holds the lock)
+func (budgets *Budgets) slotAt(tracked *series, now time.Time) *slot {
+	slotDuration := budgets.window / slotCount
+	start := now.Truncate(slotDuration)
+	current := &tracked.slots[(start.UnixNano()/int64(slotDuration))%slotCount]
+	if !current.start.Equal(start) {
+		*current = slot{start: start}
+	}
+	return current
+}
+
+// sum sums the slots within the window (caller holds the lock)
+func (budgets *Budgets) sum(tracked *series, now time.Time) (int64, int64, histogram) {
+	var total, over int64
+	var counts histogram
+	cutoff := now.Add(-budgets.window)
+	for index := range tracked.slots {
+		current := &tracked.slots[index]
+		if current.total == 0 || !current.start.After(cutoff) {
+			continue
+		}
+		total += current.total
+		over += current.over
+		for bucket, count := range current.counts {
+			counts[bucket] += count
+		}
+	}
+	return total, over, counts
+}
+
+// bucketFor returns the histogram bucket of a duration
+func bucketFor(duration time.Duration) int {
+	return sort.Search(len(bucketBounds), func(index int) bool { return duration <= bucketBounds[index] })
+}
+
+// p95 estimates the 95th percentile as the upper bound of the bucket holding it (0
+// without calls)
+func p95(total int64, counts histogram) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	rank := (total*95 + 99) / 100
+	var seen int64
+	for bucket, count := range counts {
+		seen += count
+		if seen >= rank {
+			if bucket < len(bucketBounds) {
+				return bucketBounds[bucket]
+			}
+			break
+		}
+	}
+	return bucketBounds[len(bucketBounds)-1]
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
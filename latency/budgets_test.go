@@ -0,0 +1,128 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// testBudgets tracks /rates against a 100ms budget over a one minute window, on a clock
+// the test advances
+func testBudgets(minSamples int) (*Budgets, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	budgets := NewBudgets("route", map[string]time.Duration{"/rates": 100 * time.Millisecond}, time.Minute, minSamples, testutils.MockLogger())
+	budgets.now = func() time.Time { return now }
+	return budgets, &now
+}
+
+func TestNewBudgets(t *testing.T) {
+	if budgets := NewBudgets("route", nil, time.Minute, 1, testutils.MockLogger()); budgets != nil {
+		t.Error("NewBudgets() without budgets should return nil")
+	}
+	if budgets := NewBudgets("route", map[string]time.Duration{"/rates": 0}, time.Minute, 1, testutils.MockLogger()); budgets != nil {
+		t.Error("NewBudgets() without positive budgets should return nil")
+	}
+
+	budgets := NewBudgets("provider", map[string]time.Duration{"b": time.Second, "a": time.Second}, 0, 0, testutils.MockLogger())
+	if names := budgets.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Names() = %v, want [a b]", names)
+	}
+	if budgets.window != 5*time.Minute {
+		t.Errorf("window = %v, want the 5m default", budgets.window)
+	}
+}
+
+func TestBudgets_Nil(t *testing.T) {
+	var budgets *Budgets
+	budgets.Observe("/rates", time.Second)
+	if budgets.Names() != nil || budgets.Stats() != nil {
+		t.Error("a nil Budgets should track nothing")
+	}
+}
+
+func TestBudgets_BreachAndRecovery(t *testing.T) {
+	budgets, now := testBudgets(20)
+
+	// 19 fast calls and one slow one keep the p95 within budget
+	for i := 0; i < 19; i++ {
+		budgets.Observe("/rates", 10*time.Millisecond)
+	}
+	budgets.Observe("/rates", 500*time.Millisecond)
+	stats := budgets.Stats()
+	if len(stats) != 1 || stats[0].Exceeded || stats[0].Samples != 20 || stats[0].OverBudget != 1 {
+		t.Fatalf("Stats() with 5%% slow calls = %+v, want within budget", stats)
+	}
+
+	// A second slow call puts the p95 over budget
+	budgets.Observe("/rates", 500*time.Millisecond)
+	stats = budgets.Stats()
+	if !stats[0].Exceeded || stats[0].BudgetExceeded != 1 {
+		t.Fatalf("Stats() with over 5%% slow calls = %+v, want one breach", stats[0])
+	}
+	if stats[0].P95MS <= stats[0].BudgetMS {
+		t.Errorf("P95MS = %v, want above the %vms budget", stats[0].P95MS, stats[0].BudgetMS)
+	}
+
+	// Further slow calls do not count the ongoing breach again
+	budgets.Observe("/rates", 500*time.Millisecond)
+	if stats = budgets.Stats(); stats[0].BudgetExceeded != 1 {
+		t.Errorf("BudgetExceeded = %d during one breach, want 1", stats[0].BudgetExceeded)
+	}
+
+	// Once the slow calls leave the window, the budget is met again
+	*now = now.Add(2 * time.Minute)
+	for i := 0; i < 20; i++ {
+		budgets.Observe("/rates", 10*time.Millisecond)
+	}
+	stats = budgets.Stats()
+	if stats[0].Exceeded || stats[0].Samples != 20 || stats[0].BudgetExceeded != 1 {
+		t.Errorf("Stats() after recovery = %+v, want within budget with one past breach", stats[0])
+	}
+
+	// A new breach counts again
+	for i := 0; i < 5; i++ {
+		budgets.Observe("/rates", 500*time.Millisecond)
+	}
+	if stats = budgets.Stats(); !stats[0].Exceeded || stats[0].BudgetExceeded != 2 {
+		t.Errorf("Stats() after a second breach = %+v, want two breaches", stats[0])
+	}
+}
+
+func TestBudgets_MinSamples(t *testing.T) {
+	budgets, _ := testBudgets(10)
+	for i := 0; i < 9; i++ {
+		budgets.Observe("/rates", time.Second)
+	}
+	if stats := budgets.Stats(); stats[0].Exceeded {
+		t.Error("Stats() below the minimum samples should not report a breach")
+	}
+	budgets.Observe("/rates", time.Second)
+	if stats := budgets.Stats(); !stats[0].Exceeded {
+		t.Error("Stats() at the minimum samples should report the breach")
+	}
+}
+
+func TestBudgets_IgnoresUnbudgetedNames(t *testing.T) {
+	budgets, _ := testBudgets(1)
+	budgets.Observe("/health", time.Second)
+	if stats := budgets.Stats(); len(stats) != 1 || stats[0].Samples != 0 {
+		t.Errorf("Stats() = %+v, want only /rates without samples", stats)
+	}
+}
+
+func TestP95(t *testing.T) {
+	var counts histogram
+	counts[bucketFor(5*time.Millisecond)] = 95
+	counts[bucketFor(time.Second)] = 5
+	if estimate := p95(100, counts); estimate < 5*time.Millisecond || estimate > 7*time.Millisecond {
+		t.Errorf("p95() = %v, want the bucket of 5ms", estimate)
+	}
+	if estimate := p95(0, histogram{}); estimate != 0 {
+		t.Errorf("p95() without calls = %v, want 0", estimate)
+	}
+	counts[bucketCount-1] = 1000
+	if estimate := p95(1100, counts); estimate != bucketBounds[len(bucketBounds)-1] {
+		t.Errorf("p95() in the unbounded bucket = %v, want the largest bound", estimate)
+	}
+}
@@ -0,0 +1,152 @@
+// Package listener opens the listeners the HTTP server accepts connections on: TCP on the
+// configured port and a Unix domain socket, for sidecars that proxy over local sockets, or
+// the sockets systemd passes on socket activation.
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+)
+
+// staleSocketTimeout bounds the dial checking whether an existing socket is still served
+const staleSocketTimeout = time.Second
+
+// Open returns the configured listeners. The Unix socket file gets the configured
+// permissions and group, and is removed when its listener is closed. A socket file left
+// behind by a process that crashed is replaced; one another process still accepts
+// connections on is an error. Sockets passed by systemd socket activation replace the
+// configured listeners.
+func Open(port string, listen config.ListenConfig, logger logger.Logger) ([]net.Listener, error) {
+	listeners, err := activated()
+	if err != nil {
+		return nil, err
+	}
+	if len(listeners) > 0 {
+		for _, activatedListener := range listeners {
+			logger.Infof("Listening on %s socket %s passed by systemd", activatedListener.Addr().Network(), activatedListener.Addr())
+		}
+		return listeners, nil
+	}
+
+	if !listen.TCP && listen.UnixSocket == "" {
+		return nil, errors.New("LISTEN_TCP=false requires LISTEN_UNIX_SOCKET")
+	}
+
+	if listen.UnixSocket != "" {
+		unixListener, err := openUnixSocket(listen)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, unixListener)
+		logger.Infof("Listening on Unix socket %s", listen.UnixSocket)
+	}
+	if listen.TCP {
+		tcpListener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			Close(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, tcpListener)
+		logger.Infof("Listening on port %s", port)
+	}
+	return listeners, nil
+}
+
+// Close closes the listeners, removing the socket files of Unix listeners
+func Close(listeners []net.Listener) {
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+// openUnixSocket listens on the socket path with the configured permissions and group
+func openUnixSocket(listen config.ListenConfig) (net.Listener, error) {
+	mode, err := strconv.ParseUint(listen.UnixSocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return nil, fmt.Errorf("invalid LISTEN_UNIX_SOCKET_MODE %q: want octal permissions such as 0660", listen.UnixSocketMode)
+	}
+	gid := -1
+	if listen.UnixSocketGroup != "" {
+		if gid, err = lookupGroup(listen.UnixSocketGroup); err != nil {
+			return nil, err
+		}
+	}
+	if err := removeStaleSocket(listen.UnixSocket); err != nil {
+		return nil, err
+	}
+
+	unixListener, err := net.Listen("unix", listen.UnixSocket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(listen.UnixSocket, fs.FileMode(mode)); err != nil {
+		unixListener.Close()
+		return nil, fmt.Errorf("failed to set the permissions of %s: %w", listen.UnixSocket, err)
+	}
+	if gid >= 0 {
+		if err := os.Chown(listen.UnixSocket, -1, gid); err != nil {
+			unixListener.Close()
+			return nil, fmt.Errorf("failed to set the group of %s: %w", listen.UnixSocket, err)
+		}
+	}
+	return unixListener, nil
+}
+
+// lookupGroup returns the ID of a group given by name or ID
+func lookupGroup(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	found, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LISTEN_UNIX_SOCKET_GROUP: %w", err)
+	}
+	return strconv.Atoi(found.Gid)
+}
+
+// removeStaleSocket removes a socket file nothing accepts connections on anymore
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if connection, err := net.DialTimeout("unix", path, staleSocketTimeout); err == nil {
+		connection.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
+
+// LocalClient returns an HTTP client and the base URL reaching the service from the same
+// host: over TCP when it listens on TCP, otherwise over its Unix socket
+func LocalClient(port string, listen config.ListenConfig) (*http.Client, string) {
+	if listen.TCP || listen.UnixSocket == "" {
+		return &http.Client{}, "http://127.0.0.1:" + port
+	}
+
+	var dialer net.Dialer
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", listen.UnixSocket)
+		},
+	}
+	return &http.Client{Transport: transport}, "http://unix"
+}
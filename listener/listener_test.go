@@ -0,0 +1,128 @@
+package listener
+
+import (
+	"context"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// socketPath returns a socket path in a new temporary directory, short enough for the
+// length limit of socket paths
+func socketPath(t *testing.T) string {
+	t.Helper()
+	directory, err := os.MkdirTemp("", "listener")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(directory) })
+	return filepath.Join(directory, "api.sock")
+}
+
+func TestOpen_UnixSocket(t *testing.T) {
+	path := socketPath(t)
+	listen := config.ListenConfig{UnixSocket: path, UnixSocketMode: "0600"}
+
+	listeners, err := Open("0", listen, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(listeners) != 1 {
+		t.Fatalf("Open() = %d listeners, want only the Unix socket", len(listeners))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Type() != fs.ModeSocket || info.Mode().Perm() != 0600 {
+		t.Errorf("socket file mode = %v, want a socket with 0600", info.Mode())
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})}
+	go server.Serve(listeners[0])
+
+	client, baseURL := LocalClient("0", listen)
+	response, err := client.Get(baseURL + "/health")
+	if err != nil {
+		t.Fatalf("GET over the Unix socket error = %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusTeapot {
+		t.Errorf("GET over the Unix socket status = %v, want %v", response.StatusCode, http.StatusTeapot)
+	}
+
+	// A second process cannot take over a socket that is still served
+	if _, err := Open("0", listen, testutils.MockLogger()); err == nil {
+		t.Error("Open() of a socket in use expected error")
+	}
+
+	server.Shutdown(context.Background())
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file after shutdown: Lstat() error = %v, want it removed", err)
+	}
+}
+
+func TestOpen_StaleSocket(t *testing.T) {
+	path := socketPath(t)
+
+	// A listener that does not remove its file leaves it behind like a crashed process
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listeners, err := Open("0", config.ListenConfig{UnixSocket: path, UnixSocketMode: "0660"}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("Open() over a stale socket error = %v", err)
+	}
+	Close(listeners)
+}
+
+func TestOpen_Errors(t *testing.T) {
+	regularFile := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(regularFile, nil, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		listen config.ListenConfig
+	}{
+		{name: "nothing to listen on", listen: config.ListenConfig{}},
+		{name: "invalid mode", listen: config.ListenConfig{UnixSocket: socketPath(t), UnixSocketMode: "rw-rw----"}},
+		{name: "unknown group", listen: config.ListenConfig{UnixSocket: socketPath(t), UnixSocketMode: "0660", UnixSocketGroup: "no-such-group-here"}},
+		{name: "path is not a socket", listen: config.ListenConfig{UnixSocket: regularFile, UnixSocketMode: "0660"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if listeners, err := Open("0", tt.listen, testutils.MockLogger()); err == nil {
+				Close(listeners)
+				t.Error("Open() expected error")
+			}
+		})
+	}
+}
+
+func TestOpen_TCPAndUnixSocket(t *testing.T) {
+	listeners, err := Open("0", config.ListenConfig{TCP: true, UnixSocket: socketPath(t), UnixSocketMode: "0660"}, testutils.MockLogger())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer Close(listeners)
+
+	if len(listeners) != 2 || listeners[0].Addr().Network() != "unix" || listeners[1].Addr().Network() != "tcp" {
+		t.Errorf("Open() = %v, want a Unix and a TCP listener", listeners)
+	}
+}
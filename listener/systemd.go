@@ -0,0 +1,64 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor systemd passes to an activated service
+const listenFDsStart = 3
+
+// activated returns the sockets systemd passed to the process through socket activation,
+// or none when it was not started that way. The LISTEN_* variables are cleared so child
+// processes do not take the sockets for their own.
+func activated() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for fd := listenFDsStart; fd < listenFDsStart+count; fd++ {
+		file := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		fileListener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			Close(listeners)
+			return nil, fmt.Errorf("socket activation: file descriptor %d is not a listening socket: %w", fd, err)
+		}
+		listeners = append(listeners, fileListener)
+	}
+	return listeners, nil
+}
+
+// Notify sends a state such as READY=1 or STOPPING=1 to the service manager over
+// NOTIFY_SOCKET. Without a service manager listening it does nothing.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ names a socket in the abstract namespace
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	connection, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("sd_notify: %w", err)
+	}
+	defer connection.Close()
+	if _, err := connection.Write([]byte(state)); err != nil {
+		return fmt.Errorf("sd_notify: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestActivated(t *testing.T) {
+	tests := []struct {
+		name      string
+		listenPID string
+		listenFDs string
+		wantErr   bool
+	}{
+		{name: "not activated", listenPID: "", listenFDs: ""},
+		{name: "activated for another process", listenPID: strconv.Itoa(os.Getpid() + 1), listenFDs: "1"},
+		{name: "invalid descriptor count", listenPID: strconv.Itoa(os.Getpid()), listenFDs: "none", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tt.listenPID)
+			t.Setenv("LISTEN_FDS", tt.listenFDs)
+
+			listeners, err := activated()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("activated() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(listeners) != 0 {
+				t.Errorf("activated() = %v, want no listeners", listeners)
+			}
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	path := socketPath(t)
+	manager, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("ListenUnixgram() error = %v", err)
+	}
+	defer manager.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if err := Notify("READY=1"); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	buffer := make([]byte, 64)
+	n, err := manager.Read(buffer)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got := string(buffer[:n]); got != "READY=1" {
+		t.Errorf("service manager received %q, want READY=1", got)
+	}
+}
+
+func TestNotify_WithoutServiceManager(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	if err := Notify("READY=1"); err != nil {
+		t.Errorf("Notify() error = %v, want nil without NOTIFY_SOCKET", err)
+	}
+}
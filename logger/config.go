@@ -0,0 +1,258 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// NewFromConfig creates a logger on the configured backend with the configured format,
+// outputs, timestamp format and static fields. The returned closer releases the log
+// file and syslog connection.
+func NewFromConfig(level string, configuration config.LoggingConfig) (Logger, io.Closer, error) {
+	switch configuration.Format {
+	case "json", "text", "console", "":
+	default:
+		return nil, nil, fmt.Errorf("invalid log format %q: use json, text or console", configuration.Format)
+	}
+
+	writer, syslogWriter, closers, err := openOutputs(configuration)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch configuration.Backend {
+	case "logrus", "":
+		return newLogrusFromConfig(level, configuration, writer, syslogWriter), closers, nil
+	case "slog":
+		return newSlogFromConfig(level, configuration, writer, syslogWriter), closers, nil
+	default:
+		closers.Close()
+		return nil, nil, fmt.Errorf("invalid log backend %q: use logrus or slog", configuration.Backend)
+	}
+}
+
+// newLogrusFromConfig creates a logrus logger writing to writer and, when set, syslog
+func newLogrusFromConfig(level string, configuration config.LoggingConfig, writer io.Writer, syslogWriter *syslog.Writer) Logger {
+	timestampFormat := parseTimestampFormat(configuration.TimestampFormat)
+	logrusLogger := New(level).(*LogrusLogger).Logger
+
+	var formatter logrus.Formatter
+	switch configuration.Format {
+	case "text":
+		formatter = &logrus.TextFormatter{TimestampFormat: timestampFormat, FullTimestamp: true, DisableColors: true}
+	case "console":
+		formatter = &ConsoleFormatter{TimestampFormat: timestampFormat, Colors: isTerminal(configuration.Outputs)}
+	default:
+		formatter = &logrus.JSONFormatter{TimestampFormat: timestampFormat}
+	}
+	logrusLogger.SetFormatter(formatter)
+	logrusLogger.SetOutput(writer)
+
+	if len(configuration.Fields) > 0 {
+		logrusLogger.AddHook(fieldsHook(configuration.Fields))
+	}
+	if syslogWriter != nil {
+		logrusLogger.AddHook(&syslogHook{writer: syslogWriter, formatter: formatter})
+	}
+	return NewLogrusLogger(logrusLogger)
+}
+
+// newSlogFromConfig creates a slog logger writing to writer and, when set, syslog. The
+// console format uses slog's text handler.
+func newSlogFromConfig(level string, configuration config.LoggingConfig, writer io.Writer, syslogWriter *syslog.Writer) Logger {
+	timestampFormat := parseTimestampFormat(configuration.TimestampFormat)
+	options := &slog.HandlerOptions{
+		Level: slogLevel(level),
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return attr
+			}
+			switch attr.Key {
+			case slog.TimeKey:
+				return slog.String(slog.TimeKey, attr.Value.Time().Format(timestampFormat))
+			case slog.LevelKey:
+				if attr.Value.Any() == LevelFatal {
+					return slog.String(slog.LevelKey, "FATAL")
+				}
+			}
+			return attr
+		},
+	}
+	newHandler := func(writer io.Writer) slog.Handler {
+		if configuration.Format == "json" || configuration.Format == "" {
+			return slog.NewJSONHandler(writer, options)
+		}
+		return slog.NewTextHandler(writer, options)
+	}
+
+	handlers := multiHandler{newHandler(writer)}
+	if syslogWriter != nil {
+		handlers = append(handlers, newSyslogHandler(syslogWriter, newHandler))
+	}
+
+	var handler slog.Handler = handlers
+	if len(handlers) == 1 {
+		handler = handlers[0]
+	}
+	attrs := make([]slog.Attr, 0, len(configuration.Fields))
+	for key, value := range configuration.Fields {
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return NewSlogLogger(slog.New(handler.WithAttrs(attrs)))
+}
+
+// openOutputs opens the configured outputs, combining the stream and file outputs into
+// one writer (io.Discard when syslog is the only output)
+func openOutputs(configuration config.LoggingConfig) (io.Writer, *syslog.Writer, outputClosers, error) {
+	configuredOutputs := configuration.Outputs
+	if len(configuredOutputs) == 0 {
+		configuredOutputs = []string{"stdout"}
+	}
+
+	closers := outputClosers{}
+	writers := []io.Writer{}
+	var syslogWriter *syslog.Writer
+	for _, output := range configuredOutputs {
+		switch output {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		case "file":
+			file, err := OpenRotatingFile(configuration.File, int64(configuration.FileMaxSizeMB)<<20, configuration.FileMaxBackups)
+			if err != nil {
+				closers.Close()
+				return nil, nil, nil, err
+			}
+			closers = append(closers, file)
+			writers = append(writers, file)
+		case "syslog":
+			writer, err := dialSyslog(configuration.SyslogAddress, configuration.Fields["service"])
+			if err != nil {
+				closers.Close()
+				return nil, nil, nil, err
+			}
+			closers = append(closers, writer)
+			syslogWriter = writer
+		default:
+			closers.Close()
+			return nil, nil, nil, fmt.Errorf("invalid log output %q: use stdout, stderr, file or syslog", output)
+		}
+	}
+
+	switch len(writers) {
+	case 0:
+		return io.Discard, syslogWriter, closers, nil
+	case 1:
+		return writers[0], syslogWriter, closers, nil
+	default:
+		return io.MultiWriter(writers...), syslogWriter, closers, nil
+	}
+}
+
+// parseTimestampFormat maps named timestamp formats to layouts; other values are used
+// as Go time layouts
+func parseTimestampFormat(value string) string {
+	switch strings.ToLower(value) {
+	case "", "rfc3339":
+		return time.RFC3339
+	case "rfc3339nano":
+		return time.RFC3339Nano
+	default:
+		return value
+	}
+}
+
+// isTerminal reports whether console output goes to a terminal only, so colors are safe
+func isTerminal(outputs []string) bool {
+	if len(outputs) != 1 || outputs[0] != "stdout" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// dialSyslog connects to the local syslog daemon, or to network://host:port when set
+func dialSyslog(address, tag string) (*syslog.Writer, error) {
+	network, host := "", ""
+	if address != "" {
+		var found bool
+		network, host, found = strings.Cut(address, "://")
+		if !found {
+			return nil, fmt.Errorf("invalid syslog address %q: use udp://host:port or tcp://host:port", address)
+		}
+	}
+	writer, err := syslog.Dial(network, host, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return writer, nil
+}
+
+// fieldsHook adds static fields to every record, without replacing fields set on it
+type fieldsHook map[string]string
+
+func (hook fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook fieldsHook) Fire(entry *logrus.Entry) error {
+	for key, value := range hook {
+		if _, set := entry.Data[key]; !set {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
+
+// syslogHook writes every record to syslog with the severity of its level
+type syslogHook struct {
+	writer    *syslog.Writer
+	formatter logrus.Formatter
+}
+
+func (hook *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *syslogHook) Fire(entry *logrus.Entry) error {
+	line, err := hook.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	message := strings.TrimSuffix(string(line), "\n")
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return hook.writer.Crit(message)
+	case logrus.ErrorLevel:
+		return hook.writer.Err(message)
+	case logrus.WarnLevel:
+		return hook.writer.Warning(message)
+	case logrus.InfoLevel:
+		return hook.writer.Info(message)
+	default:
+		return hook.writer.Debug(message)
+	}
+}
+
+// outputClosers closes the log outputs that hold resources
+type outputClosers []io.Closer
+
+func (closers outputClosers) Close() error {
+	var errs []error
+	for _, closer := range closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
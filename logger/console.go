@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// consoleLevels are the label and ANSI color of each level column
+var consoleLevels = map[logrus.Level]struct {
+	label string
+	color int
+}{
+	logrus.TraceLevel: {"TRACE", 37},
+	logrus.DebugLevel: {"DEBUG", 37},
+	logrus.InfoLevel:  {"INFO", 36},
+	logrus.WarnLevel:  {"WARN", 33},
+	logrus.ErrorLevel: {"ERROR", 31},
+	logrus.FatalLevel: {"FATAL", 31},
+	logrus.PanicLevel: {"PANIC", 31},
+}
+
+// ConsoleFormatter renders records as one readable line for local development:
+// time, level, message, then the fields as sorted key=value pairs
+type ConsoleFormatter struct {
+	TimestampFormat string
+	Colors          bool // Color the level column (for terminals)
+}
+
+// Format renders a log entry
+func (formatter *ConsoleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	timestampFormat := formatter.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = "15:04:05.000"
+	}
+
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(entry.Time.Format(timestampFormat))
+	buffer.WriteByte(' ')
+
+	consoleLevel := consoleLevels[entry.Level]
+	level := fmt.Sprintf("%-5s", consoleLevel.label)
+	if formatter.Colors {
+		fmt.Fprintf(buffer, "\x1b[%dm%s\x1b[0m", consoleLevel.color, level)
+	} else {
+		buffer.WriteString(level)
+	}
+	buffer.WriteByte(' ')
+	buffer.WriteString(entry.Message)
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(buffer, " %s=%s", key, consoleValue(entry.Data[key]))
+	}
+	buffer.WriteByte('\n')
+	return buffer.Bytes(), nil
+}
+
+// consoleValue formats a field value, quoting it when it contains spaces or quotes
+func consoleValue(value interface{}) string {
+	var text string
+	switch typed := value.(type) {
+	case error:
+		text = typed.Error()
+	case time.Time:
+		text = typed.Format(time.RFC3339)
+	default:
+		text = fmt.Sprint(typed)
+	}
+	if text == "" || strings.ContainsAny(text, " \t\"=") {
+		return fmt.Sprintf("%q", text)
+	}
+	return text
+}
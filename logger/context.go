@@ -0,0 +1,39 @@
+package logger
+
+import "context"
+
+// contextFieldsKey is the context key of the fields loggers add with WithContext
+type contextFieldsKey struct{}
+
+// ContextWithFields returns a context whose loggers, through WithContext, add the fields
+// to every record, next to the fields already carried by ctx. Middleware uses it for
+// correlation fields such as request_id and trace_id.
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	merged := make(Fields, len(fields))
+	for key, value := range FieldsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, contextFieldsKey{}, merged)
+}
+
+// FieldsFromContext returns the fields carried by the context, or nil
+func FieldsFromContext(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(contextFieldsKey{}).(Fields)
+	return fields
+}
+
+// withContext returns logger adding the fields carried by ctx, or logger itself when ctx
+// carries none
+func withContext(logger Logger, ctx context.Context) Logger {
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.WithFields(fields)
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,16 +22,26 @@ type Logger interface {
 	Fatal(args ...interface{})
 	Fatalf(format string, args ...interface{})
 	WithFields(fields Fields) Logger
+
+	// WithContext returns a logger adding the correlation fields carried by ctx, such as
+	// request_id and trace_id; see ContextWithFields
+	WithContext(ctx context.Context) Logger
 }
 
-// LogrusLogger wraps logrus.Logger to implement our Logger interface
+// LogrusLogger wraps a logrus entry to implement our Logger interface; the entry holds
+// the fields added with WithFields and its Logger field the underlying logrus.Logger
 type LogrusLogger struct {
-	*logrus.Logger
+	*logrus.Entry
 }
 
-// WithFields returns a new logger with fields
+// WithFields returns a new logger that adds fields to every record
 func (l *LogrusLogger) WithFields(fields Fields) Logger {
-	return &LogrusLogger{Logger: l.Logger.WithFields(logrus.Fields(fields)).Logger}
+	return &LogrusLogger{Entry: l.Entry.WithFields(logrus.Fields(fields))}
+}
+
+// WithContext returns a logger that adds the fields carried by ctx to every record
+func (l *LogrusLogger) WithContext(ctx context.Context) Logger {
+	return withContext(l, ctx)
 }
 
 // ensure LogrusLogger implements Logger interface
@@ -54,10 +66,10 @@ func New(level string) Logger {
 		logrusLogger.SetLevel(logrus.InfoLevel)
 	}
 
-	return &LogrusLogger{Logger: logrusLogger}
+	return &LogrusLogger{Entry: logrus.NewEntry(logrusLogger)}
 }
 
 // NewLogrusLogger creates a logger from an existing logrus.Logger instance
 func NewLogrusLogger(logrusLogger *logrus.Logger) Logger {
-	return &LogrusLogger{Logger: logrusLogger}
+	return &LogrusLogger{Entry: logrus.NewEntry(logrusLogger)}
 }
@@ -0,0 +1,298 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	file, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() error = %v", err)
+	}
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	// Each line pushes the file past 10 bytes, so every write rotated and only two backups remain
+	for name, want := range map[string]string{"service.log": "fourth\n", "service.log.1": "third\n", "service.log.2": "second\n"} {
+		content, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+		if err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v, want %q", name, content, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("backup beyond the limit exists: %v", err)
+	}
+}
+
+func TestConsoleFormatter(t *testing.T) {
+	formatter := &ConsoleFormatter{TimestampFormat: time.RFC3339}
+	entry := &logrus.Entry{
+		Time:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "Provider slow",
+		Data:    logrus.Fields{"provider": "erapi", "error": "context deadline exceeded"},
+	}
+
+	line, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := "2024-01-01T12:00:00Z WARN  Provider slow error=\"context deadline exceeded\" provider=erapi\n"
+	if string(line) != want {
+		t.Errorf("Format() = %q, want %q", line, want)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	loggerInstance, outputs, err := NewFromConfig("info", config.LoggingConfig{
+		Format:          "json",
+		Outputs:         []string{"file"},
+		File:            path,
+		TimestampFormat: "2006-01-02",
+		Fields:          map[string]string{"service": "currency-exchange-service", "env": "test"},
+	})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error = %v", err)
+	}
+
+	loggerInstance.Info("started")
+	loggerInstance.(*LogrusLogger).WithField("env", "override").Info("overridden")
+	outputs.Close()
+
+	content, _ := os.ReadFile(path)
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("log file has %d records, want 2: %s", len(lines), content)
+	}
+	var record map[string]string
+	if err := json.Unmarshal(lines[0], &record); err != nil {
+		t.Fatalf("record unmarshal error = %v", err)
+	}
+	if record["service"] != "currency-exchange-service" || record["env"] != "test" || record["msg"] != "started" {
+		t.Errorf("record = %v, want the static fields", record)
+	}
+	if _, err := time.Parse("2006-01-02", record["time"]); err != nil {
+		t.Errorf("record time = %q, want the configured layout", record["time"])
+	}
+	if !strings.Contains(string(lines[1]), `"env":"override"`) {
+		t.Errorf("record = %s, want the record's own field to win", lines[1])
+	}
+}
+
+func TestNewFromConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		configuration config.LoggingConfig
+	}{
+		{"unknown format", config.LoggingConfig{Format: "xml"}},
+		{"unknown backend", config.LoggingConfig{Backend: "zap"}},
+		{"unknown output", config.LoggingConfig{Outputs: []string{"kafka"}}},
+		{"malformed syslog address", config.LoggingConfig{Outputs: []string{"syslog"}, SyslogAddress: "localhost:514"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := NewFromConfig("info", tt.configuration); err == nil {
+				t.Error("NewFromConfig() expected error")
+			}
+		})
+	}
+}
+
+// recordingSugared mimics zap's SugaredLogger: With returns a child carrying key-value pairs
+type recordingSugared struct {
+	context []interface{}
+	records *[]string
+}
+
+func (r *recordingSugared) record(level string, message string) {
+	*r.records = append(*r.records, fmt.Sprintf("%s %s %v", level, message, r.context))
+}
+
+func (r *recordingSugared) Debug(args ...interface{}) { r.record("debug", fmt.Sprint(args...)) }
+func (r *recordingSugared) Debugf(template string, args ...interface{}) {
+	r.record("debug", fmt.Sprintf(template, args...))
+}
+func (r *recordingSugared) Info(args ...interface{}) { r.record("info", fmt.Sprint(args...)) }
+func (r *recordingSugared) Infof(template string, args ...interface{}) {
+	r.record("info", fmt.Sprintf(template, args...))
+}
+func (r *recordingSugared) Warn(args ...interface{}) { r.record("warn", fmt.Sprint(args...)) }
+func (r *recordingSugared) Warnf(template string, args ...interface{}) {
+	r.record("warn", fmt.Sprintf(template, args...))
+}
+func (r *recordingSugared) Error(args ...interface{}) { r.record("error", fmt.Sprint(args...)) }
+func (r *recordingSugared) Errorf(template string, args ...interface{}) {
+	r.record("error", fmt.Sprintf(template, args...))
+}
+func (r *recordingSugared) Fatal(args ...interface{}) { r.record("fatal", fmt.Sprint(args...)) }
+func (r *recordingSugared) Fatalf(template string, args ...interface{}) {
+	r.record("fatal", fmt.Sprintf(template, args...))
+}
+func (r *recordingSugared) With(args ...interface{}) *recordingSugared {
+	return &recordingSugared{context: append(append([]interface{}{}, r.context...), args...), records: r.records}
+}
+
+func TestSugaredLogger(t *testing.T) {
+	records := []string{}
+	loggerInstance := NewSugaredLogger(&recordingSugared{records: &records})
+
+	loggerInstance.Infof("fetched %d rates", 3)
+	loggerInstance.WithFields(Fields{"provider": "erapi"}).Warn("slow")
+
+	want := []string{"info fetched 3 rates []", "warn slow [provider erapi]"}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %v, want %v", records, want)
+	}
+}
+
+func TestLogrusLogger_WithFields(t *testing.T) {
+	var buffer bytes.Buffer
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(&buffer)
+	logrusLogger.SetFormatter(&logrus.JSONFormatter{})
+
+	NewLogrusLogger(logrusLogger).WithFields(Fields{"provider": "erapi"}).WithFields(Fields{"base": "USD"}).Info("fetched")
+
+	var record map[string]string
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("record unmarshal error = %v", err)
+	}
+	if record["provider"] != "erapi" || record["base"] != "USD" {
+		t.Errorf("record = %v, want the fields of both WithFields calls", record)
+	}
+}
+
+func TestLogger_WithContext(t *testing.T) {
+	ctx := ContextWithFields(context.Background(), Fields{"request_id": "req-1"})
+	ctx = ContextWithFields(ctx, Fields{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736"})
+
+	var logrusBuffer bytes.Buffer
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(&logrusBuffer)
+	logrusLogger.SetFormatter(&logrus.JSONFormatter{})
+	var slogBuffer bytes.Buffer
+
+	tests := []struct {
+		name   string
+		logger Logger
+		output *bytes.Buffer
+	}{
+		{name: "logrus", logger: NewLogrusLogger(logrusLogger), output: &logrusBuffer},
+		{name: "slog", logger: NewSlogLogger(slog.New(slog.NewJSONHandler(&slogBuffer, nil))), output: &slogBuffer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.logger.WithContext(ctx).WithFields(Fields{"provider": "erapi"}).Info("fetched")
+			tt.logger.WithContext(context.Background()).Info("warmed up")
+
+			lines := bytes.Split(bytes.TrimSpace(tt.output.Bytes()), []byte("\n"))
+			if len(lines) != 2 {
+				t.Fatalf("output = %q, want two records", tt.output.String())
+			}
+			var record, plain map[string]string
+			if err := json.Unmarshal(lines[0], &record); err != nil {
+				t.Fatalf("record unmarshal error = %v", err)
+			}
+			if record["request_id"] != "req-1" || record["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || record["provider"] != "erapi" {
+				t.Errorf("record = %v, want the context's request_id and trace_id", record)
+			}
+			if err := json.Unmarshal(lines[1], &plain); err != nil {
+				t.Fatalf("record unmarshal error = %v", err)
+			}
+			if _, found := plain["request_id"]; found {
+				t.Errorf("record = %v, want no request_id for a context without fields", plain)
+			}
+		})
+	}
+}
+
+func TestContextWithFields(t *testing.T) {
+	parent := ContextWithFields(context.Background(), Fields{"request_id": "req-1", "tenant": "acme"})
+	child := ContextWithFields(parent, Fields{"tenant": "globex"})
+
+	if got := FieldsFromContext(child); !reflect.DeepEqual(got, Fields{"request_id": "req-1", "tenant": "globex"}) {
+		t.Errorf("FieldsFromContext(child) = %v, want the parent's fields overridden by the child's", got)
+	}
+	if got := FieldsFromContext(parent); got["tenant"] != "acme" {
+		t.Errorf("FieldsFromContext(parent) = %v, want it unchanged by the child", got)
+	}
+	if got := FieldsFromContext(context.Background()); got != nil {
+		t.Errorf("FieldsFromContext(background) = %v, want nil", got)
+	}
+}
+
+func TestNewFromConfig_Slog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	loggerInstance, outputs, err := NewFromConfig("info", config.LoggingConfig{
+		Backend:         "slog",
+		Format:          "json",
+		Outputs:         []string{"file"},
+		File:            path,
+		TimestampFormat: "2006-01-02",
+		Fields:          map[string]string{"service": "currency-exchange-service"},
+	})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error = %v", err)
+	}
+	if _, ok := loggerInstance.(*SlogLogger); !ok {
+		t.Fatalf("NewFromConfig() = %T, want *SlogLogger", loggerInstance)
+	}
+
+	loggerInstance.Debug("hidden")
+	loggerInstance.WithFields(Fields{"provider": "erapi"}).Warnf("fetched %d rates", 3)
+	outputs.Close()
+
+	content, _ := os.ReadFile(path)
+	var record map[string]string
+	if err := json.Unmarshal(bytes.TrimSpace(content), &record); err != nil {
+		t.Fatalf("log file = %s, want one JSON record: %v", content, err)
+	}
+	if record["msg"] != "fetched 3 rates" || record["level"] != "WARN" || record["provider"] != "erapi" || record["service"] != "currency-exchange-service" {
+		t.Errorf("record = %v", record)
+	}
+	if _, err := time.Parse("2006-01-02", record["time"]); err != nil {
+		t.Errorf("record time = %q, want the configured layout", record["time"])
+	}
+}
+
+func TestSlogLogger_Fatal(t *testing.T) {
+	var buffer bytes.Buffer
+	exitCode := 0
+	loggerInstance := &SlogLogger{
+		logger: slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		}})),
+		exit: func(code int) { exitCode = code },
+	}
+
+	loggerInstance.Fatalf("cannot listen on %s", ":8080")
+	if exitCode != 1 || !strings.Contains(buffer.String(), `msg="cannot listen on :8080"`) {
+		t.Errorf("Fatalf() exit = %d, output = %q", exitCode, buffer.String())
+	}
+}
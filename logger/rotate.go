@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile appends log records to a file and rotates it once it would grow past
+// maxSize bytes, keeping the newest maxBackups rotated files (path.1 is the newest)
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// OpenRotatingFile opens or creates the log file; a maxSize of 0 disables rotation
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rotatingFile := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rotatingFile.open(); err != nil {
+		return nil, err
+	}
+	return rotatingFile, nil
+}
+
+// Write appends p, rotating first when it would push the file past its maximum size
+func (rotatingFile *RotatingFile) Write(p []byte) (int, error) {
+	rotatingFile.mutex.Lock()
+	defer rotatingFile.mutex.Unlock()
+
+	if rotatingFile.maxSize > 0 && rotatingFile.size > 0 && rotatingFile.size+int64(len(p)) > rotatingFile.maxSize {
+		if err := rotatingFile.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	written, err := rotatingFile.file.Write(p)
+	rotatingFile.size += int64(written)
+	return written, err
+}
+
+// Close closes the current file
+func (rotatingFile *RotatingFile) Close() error {
+	rotatingFile.mutex.Lock()
+	defer rotatingFile.mutex.Unlock()
+	return rotatingFile.file.Close()
+}
+
+// open opens the log file for appending and records its size (caller holds the lock)
+func (rotatingFile *RotatingFile) open() error {
+	file, err := os.OpenFile(rotatingFile.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	rotatingFile.file = file
+	rotatingFile.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups up by one, moves the current file to path.1 and starts a
+// new file; the oldest backup beyond maxBackups is removed (caller holds the lock)
+func (rotatingFile *RotatingFile) rotate() error {
+	if err := rotatingFile.file.Close(); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if rotatingFile.maxBackups > 0 {
+		os.Remove(rotatingFile.backup(rotatingFile.maxBackups))
+		for i := rotatingFile.maxBackups - 1; i >= 1; i-- {
+			os.Rename(rotatingFile.backup(i), rotatingFile.backup(i+1))
+		}
+		if err := os.Rename(rotatingFile.path, rotatingFile.backup(1)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(rotatingFile.path); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return rotatingFile.open()
+}
+
+// backup returns the path of the n-th rotated file
+func (rotatingFile *RotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", rotatingFile.path, n)
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"log/syslog"
+	"os"
+	"strings"
+)
+
+// LevelFatal is the slog level of Fatal records, logged before the process exits
+const LevelFatal = slog.Level(12)
+
+// SlogLogger adapts a standard library *slog.Logger to the Logger interface
+type SlogLogger struct {
+	logger *slog.Logger
+	exit   func(code int) // Called after Fatal records; os.Exit outside tests
+}
+
+// NewSlogLogger wraps an existing slog logger
+func NewSlogLogger(slogLogger *slog.Logger) Logger {
+	return &SlogLogger{logger: slogLogger, exit: os.Exit}
+}
+
+func (l *SlogLogger) Debug(args ...interface{}) {
+	l.log(slog.LevelDebug, "", args)
+}
+
+func (l *SlogLogger) Debugf(format string, args ...interface{}) {
+	l.log(slog.LevelDebug, format, args)
+}
+
+func (l *SlogLogger) Info(args ...interface{}) {
+	l.log(slog.LevelInfo, "", args)
+}
+
+func (l *SlogLogger) Infof(format string, args ...interface{}) {
+	l.log(slog.LevelInfo, format, args)
+}
+
+func (l *SlogLogger) Warn(args ...interface{}) {
+	l.log(slog.LevelWarn, "", args)
+}
+
+func (l *SlogLogger) Warnf(format string, args ...interface{}) {
+	l.log(slog.LevelWarn, format, args)
+}
+
+func (l *SlogLogger) Error(args ...interface{}) {
+	l.log(slog.LevelError, "", args)
+}
+
+func (l *SlogLogger) Errorf(format string, args ...interface{}) {
+	l.log(slog.LevelError, format, args)
+}
+
+func (l *SlogLogger) Fatal(args ...interface{}) {
+	l.log(LevelFatal, "", args)
+	l.exit(1)
+}
+
+func (l *SlogLogger) Fatalf(format string, args ...interface{}) {
+	l.log(LevelFatal, format, args)
+	l.exit(1)
+}
+
+// WithFields returns a new logger whose records carry the fields as attributes
+func (l *SlogLogger) WithFields(fields Fields) Logger {
+	args := make([]interface{}, 0, 2*len(fields))
+	for key, value := range fields {
+		args = append(args, key, value)
+	}
+	return &SlogLogger{logger: l.logger.With(args...), exit: l.exit}
+}
+
+// WithContext returns a logger that adds the fields carried by ctx to every record
+func (l *SlogLogger) WithContext(ctx context.Context) Logger {
+	return withContext(l, ctx)
+}
+
+// log formats and writes a record, print-style without a format, skipping the
+// formatting work for disabled levels
+func (l *SlogLogger) log(level slog.Level, format string, args []interface{}) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	message := fmt.Sprint(args...)
+	if format != "" {
+		message = fmt.Sprintf(format, args...)
+	}
+	l.logger.Log(ctx, level, message)
+}
+
+// ensure SlogLogger implements Logger interface
+var _ Logger = (*SlogLogger)(nil)
+
+// slogLevel maps a configured level name to a slog level
+func slogLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// multiHandler sends slog records to every handler
+type multiHandler []slog.Handler
+
+func (handlers multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (handlers multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range handlers {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (handlers multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := make(multiHandler, len(handlers))
+	for i, handler := range handlers {
+		derived[i] = handler.WithAttrs(attrs)
+	}
+	return derived
+}
+
+func (handlers multiHandler) WithGroup(name string) slog.Handler {
+	derived := make(multiHandler, len(handlers))
+	for i, handler := range handlers {
+		derived[i] = handler.WithGroup(name)
+	}
+	return derived
+}
+
+// syslogHandler writes slog records to syslog with the severity of their level. It
+// keeps one handler per severity, each writing to the matching syslog method.
+type syslogHandler struct {
+	crit, err, warning, info, debug slog.Handler
+}
+
+// syslogSeverity writes each formatted record to syslog at one severity
+type syslogSeverity func(message string) error
+
+func (write syslogSeverity) Write(p []byte) (int, error) {
+	if err := write(strings.TrimSuffix(string(p), "\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func newSyslogHandler(writer *syslog.Writer, newHandler func(io.Writer) slog.Handler) slog.Handler {
+	return &syslogHandler{
+		crit:    newHandler(syslogSeverity(writer.Crit)),
+		err:     newHandler(syslogSeverity(writer.Err)),
+		warning: newHandler(syslogSeverity(writer.Warning)),
+		info:    newHandler(syslogSeverity(writer.Info)),
+		debug:   newHandler(syslogSeverity(writer.Debug)),
+	}
+}
+
+func (handler *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.info.Enabled(ctx, level)
+}
+
+func (handler *syslogHandler) Handle(ctx context.Context, record slog.Record) error {
+	switch {
+	case record.Level >= LevelFatal:
+		return handler.crit.Handle(ctx, record)
+	case record.Level >= slog.LevelError:
+		return handler.err.Handle(ctx, record)
+	case record.Level >= slog.LevelWarn:
+		return handler.warning.Handle(ctx, record)
+	case record.Level >= slog.LevelInfo:
+		return handler.info.Handle(ctx, record)
+	default:
+		return handler.debug.Handle(ctx, record)
+	}
+}
+
+func (handler *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler.derive(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (handler *syslogHandler) WithGroup(name string) slog.Handler {
+	return handler.derive(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+// derive applies the same change to the handler of every severity
+func (handler *syslogHandler) derive(change func(slog.Handler) slog.Handler) slog.Handler {
+	return &syslogHandler{
+		crit:    change(handler.crit),
+		err:     change(handler.err),
+		warning: change(handler.warning),
+		info:    change(handler.info),
+		debug:   change(handler.debug),
+	}
+}
@@ -0,0 +1,87 @@
+package logger
+
+import "context"
+
+// SugaredBackend is the method set of key-value loggers such as zap's *SugaredLogger:
+// leveled print-style methods plus With, which returns a child logger carrying
+// alternating key-value pairs. S is the logger's own type.
+type SugaredBackend[S any] interface {
+	Debug(args ...interface{})
+	Debugf(template string, args ...interface{})
+	Info(args ...interface{})
+	Infof(template string, args ...interface{})
+	Warn(args ...interface{})
+	Warnf(template string, args ...interface{})
+	Error(args ...interface{})
+	Errorf(template string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalf(template string, args ...interface{})
+	With(args ...interface{}) S
+}
+
+// SugaredLogger adapts a SugaredBackend to the Logger interface, so a zap logger can
+// be used without this module depending on zap:
+//
+//	loggerInstance := logger.NewSugaredLogger(zapLogger.Sugar())
+type SugaredLogger[S SugaredBackend[S]] struct {
+	backend S
+}
+
+// NewSugaredLogger wraps a key-value logger such as zap's *SugaredLogger
+func NewSugaredLogger[S SugaredBackend[S]](backend S) Logger {
+	return &SugaredLogger[S]{backend: backend}
+}
+
+func (l *SugaredLogger[S]) Debug(args ...interface{}) {
+	l.backend.Debug(args...)
+}
+
+func (l *SugaredLogger[S]) Debugf(format string, args ...interface{}) {
+	l.backend.Debugf(format, args...)
+}
+
+func (l *SugaredLogger[S]) Info(args ...interface{}) {
+	l.backend.Info(args...)
+}
+
+func (l *SugaredLogger[S]) Infof(format string, args ...interface{}) {
+	l.backend.Infof(format, args...)
+}
+
+func (l *SugaredLogger[S]) Warn(args ...interface{}) {
+	l.backend.Warn(args...)
+}
+
+func (l *SugaredLogger[S]) Warnf(format string, args ...interface{}) {
+	l.backend.Warnf(format, args...)
+}
+
+func (l *SugaredLogger[S]) Error(args ...interface{}) {
+	l.backend.Error(args...)
+}
+
+func (l *SugaredLogger[S]) Errorf(format string, args ...interface{}) {
+	l.backend.Errorf(format, args...)
+}
+
+func (l *SugaredLogger[S]) Fatal(args ...interface{}) {
+	l.backend.Fatal(args...)
+}
+
+func (l *SugaredLogger[S]) Fatalf(format string, args ...interface{}) {
+	l.backend.Fatalf(format, args...)
+}
+
+// WithFields returns a new logger whose records carry the fields
+func (l *SugaredLogger[S]) WithFields(fields Fields) Logger {
+	args := make([]interface{}, 0, 2*len(fields))
+	for key, value := range fields {
+		args = append(args, key, value)
+	}
+	return &SugaredLogger[S]{backend: l.backend.With(args...)}
+}
+
+// WithContext returns a logger that adds the fields carried by ctx to every record
+func (l *SugaredLogger[S]) WithContext(ctx context.Context) Logger {
+	return withContext(l, ctx)
+}
@@ -2,21 +2,62 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/dalfonso89/currency-exchange-service/abuse"
+	"github.com/dalfonso89/currency-exchange-service/admission"
+	"github.com/dalfonso89/currency-exchange-service/alert"
 	"github.com/dalfonso89/currency-exchange-service/api"
+	"github.com/dalfonso89/currency-exchange-service/attestation"
+	"github.com/dalfonso89/currency-exchange-service/auth"
+	"github.com/dalfonso89/currency-exchange-service/calendar"
+	"github.com/dalfonso89/currency-exchange-service/capture"
 	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/digest"
+	"github.com/dalfonso89/currency-exchange-service/exchange"
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/latency"
+	"github.com/dalfonso89/currency-exchange-service/listener"
 	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/quota"
 	"github.com/dalfonso89/currency-exchange-service/ratelimit"
 	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/store"
+	"github.com/dalfonso89/currency-exchange-service/stream"
+	"github.com/dalfonso89/currency-exchange-service/tenant"
+	"github.com/dalfonso89/currency-exchange-service/usage"
 )
 
+// healthcheckTimeout bounds the -healthcheck probe
+const healthcheckTimeout = 3 * time.Second
+
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations and exit")
+	healthcheck := flag.Bool("healthcheck", false, "probe the local /health endpoint and exit 0 when healthy, 1 otherwise")
+	flag.Parse()
+
+	// Container health probe: no configuration beyond the listeners is loaded
+	if *healthcheck {
+		probeCtx, probeCancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+		probeClient, baseURL := listener.LocalClient(config.LoadListen())
+		err := health.Probe(probeCtx, probeClient, baseURL+"/health")
+		probeCancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "healthcheck: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -24,24 +65,300 @@ func main() {
 	}
 
 	// Initialize logger
-	loggerInstance := logger.New(cfg.LogLevel)
-	logrusLogger := loggerInstance.(*logger.LogrusLogger)
-	logrusLogger.SetOutput(os.Stdout)
+	loggerInstance, logOutputs, err := logger.NewFromConfig(cfg.LogLevel, cfg.Logging)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	defer logOutputs.Close()
+	if cfg.Profile != "" {
+		loggerInstance.Infof("Using the %s configuration profile", cfg.Profile)
+	}
+
+	// Open the database and apply migrations when persistence is enabled
+	var database *store.Store
+	if cfg.DatabaseURL != "" {
+		database, err = store.Open(cfg.DatabaseURL, loggerInstance)
+		if err != nil {
+			log.Fatalf("Failed to open database: %v", err)
+		}
+		defer database.Close()
+
+		if *migrateOnly || cfg.DatabaseAutoMigrate {
+			applied, err := database.Migrate(context.Background())
+			if err != nil {
+				log.Fatalf("Database migration failed: %v", err)
+			}
+			loggerInstance.Infof("Database schema up to date (%d migrations applied)", applied)
+		}
+	}
+	if *migrateOnly {
+		if database == nil {
+			log.Fatalf("-migrate requires DATABASE_URL")
+		}
+		return
+	}
+
+	// Validate the exchange rate engine's settings, then accept the configured currency
+	// aliases in request parameters
+	if err := exchange.Validate(cfg); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	currency.SetAliases(cfg.CurrencyAliases)
 
 	// Initialize services
+	marketCalendar, err := calendar.New(cfg.MarketCalendar)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	ratesService := service.NewRatesService(cfg, loggerInstance)
+	if _, known := currency.Lookup(ratesService.DefaultBaseCurrency()); !known {
+		log.Fatalf("Invalid configuration: unknown DEFAULT_BASE_CURRENCY %s", ratesService.DefaultBaseCurrency())
+	}
+	for _, base := range []string{ratesService.DefaultBaseCurrency(), cfg.Stream.Base} {
+		if !ratesService.IsBaseAllowed(base) {
+			log.Fatalf("Invalid configuration: base %s is not in ALLOWED_BASE_CURRENCIES", base)
+		}
+	}
+	// Restore the buckets of rate limited clients saved by the last shutdown when
+	// persistence is enabled
 	rateLimiter := ratelimit.NewLimiter(cfg, loggerInstance)
+	if database != nil && cfg.RateLimitEnabled {
+		rateLimiter.SetStore(database)
+		if err := rateLimiter.Load(context.Background()); err != nil {
+			loggerInstance.Errorf("Failed to load rate limit buckets, starting with full buckets: %v", err)
+		}
+	}
+	// Ban client IPs and credentials that fail authentication repeatedly
+	abuseDetector, err := abuse.NewDetector(cfg.Abuse, loggerInstance)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	// Record a sample of requests and responses for debugging, when enabled
+	captureRecorder, err := capture.NewRecorder(cfg.Capture)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if captureRecorder != nil {
+		loggerInstance.Warnf("Debug capture enabled: recording %.2f%% of requests with their bodies", cfg.Capture.SampleRate*100)
+	}
+	tenantRegistry := tenant.NewRegistry(cfg.Tenants)
+	oauthIssuer, err := auth.NewIssuer(cfg.OAuth)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	for _, client := range cfg.OAuth.Clients {
+		if _, found := tenantRegistry.Lookup(client.Tenant); !found {
+			log.Fatalf("Invalid configuration: OAuth client %s names unknown tenant %s", client.ID, client.Tenant)
+		}
+	}
+	for apiKey := range cfg.RequestSignatures.Secrets {
+		if _, found := tenantRegistry.Resolve(apiKey); !found {
+			log.Fatalf("Invalid configuration: signing secret configured for an API key of no tenant")
+		}
+	}
+	ratesSigner, err := auth.NewResponseSigner(cfg.ResponseSigning)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	// Attest the conversions of regulated tenants; attestations must outlive restarts, so
+	// they require the database
+	var attestationLedger *attestation.Ledger
+	for _, configuredTenant := range cfg.Tenants {
+		if !configuredTenant.Attestations {
+			continue
+		}
+		if database == nil {
+			log.Fatalf("Invalid configuration: tenant %s enables attestations, which require DATABASE_URL", configuredTenant.ID)
+		}
+		attestationSigner, err := auth.NewAttestationSigner(cfg.Attestation)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		attestationLedger = attestation.NewLedger(attestationSigner, database)
+		break
+	}
+	jwtVerifier := auth.NewVerifier(cfg.JWT, oauthIssuer, nil)
+	if jwtVerifier != nil && len(cfg.Tenants) == 0 {
+		loggerInstance.Warn("JWT authentication is enabled but no tenants are configured; every token will be rejected")
+	}
+
+	// Background jobs stop when main returns
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Probe disabled providers in standby, re-enabling them once they recover
+	ratesService.StartStandbyProbes(backgroundCtx, cfg.ProviderStandby.ProbeInterval)
+
+	// Forget the clients whose authentication failures left the window
+	abuseDetector.Start(backgroundCtx, cfg.Abuse.Window)
+
+	// Record rate history and keep it compacted when persistence is enabled
+	if database != nil {
+		ratesService.SetHistory(database)
+		ratesService.SetRateHistory(database)
+		database.StartCompactor(backgroundCtx, cfg.History)
+	}
+
+	// Aggregate API usage, flushing it to the database when persistence is enabled
+	var usageTracker *usage.Tracker
+	if cfg.Usage.Enabled {
+		var usageStore usage.Store
+		if database != nil {
+			usageStore = database
+		}
+		usageTracker = usage.NewTracker(usageStore, loggerInstance)
+		usageTracker.Start(backgroundCtx, cfg.Usage.FlushInterval)
+	}
+
+	// Enforce the request quotas of tenant keys, restoring this month's counts from the
+	// database when persistence is enabled
+	var quotaManager *quota.Manager
+	for _, configuredTenant := range cfg.Tenants {
+		if !configuredTenant.HasQuota() {
+			continue
+		}
+		var quotaStore quota.Store
+		if database != nil {
+			quotaStore = database
+		}
+		quotaManager = quota.NewManager(quotaStore, loggerInstance)
+		if err := quotaManager.Load(context.Background(), time.Now()); err != nil {
+			loggerInstance.Errorf("Failed to load quota usage, counting from zero: %v", err)
+		}
+		quotaManager.Start(backgroundCtx, cfg.Quota.FlushInterval)
+		break
+	}
+
+	// Evaluate alert rules on provider errors, quota consumption, cache staleness,
+	// authentication failures and, once the handlers exist, request errors
+	alertEngine, err := alert.NewEngine(cfg.Alerting, alert.Sources{Rates: ratesService, Quotas: quotaManager, Abuse: abuseDetector}, loggerInstance)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Announce providers taken out by their failures, and their recovery, in chat
+	outageNotifier, err := alert.NewOutageNotifier(cfg.Alerting, loggerInstance)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if outageNotifier != nil {
+		ratesService.SetTransitionListener(outageNotifier.ProviderTransition)
+	}
+
+	// Send the daily digest of closing rates and provider availability
+	digestJob, err := digest.NewJob(cfg.Digest, ratesService, loggerInstance)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	digestJob.Start(backgroundCtx)
+
+	// Stream pair rates, refreshing them while streams are open
+	streamPolicy, err := stream.ParsePolicy(cfg.Stream.Backpressure)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	streamHub := stream.NewHub(cfg.Stream.MaxSubscriptions, cfg.Stream.BufferSize, streamPolicy, loggerInstance)
+	ratesService.AddRatesListener(streamHub)
+	go streamHub.Run(backgroundCtx, cfg.RatesCacheTTL, func(ctx context.Context) error {
+		_, err := ratesService.GetRates(ctx, cfg.Stream.Base)
+		return err
+	})
+
+	// Run startup dependency checks
+	checkMode, err := health.ParseMode(cfg.StartupChecks.Mode)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	readiness := health.NewChecker(checkMode, cfg.StartupChecks.Timeout, loggerInstance)
+	readiness.SetRefresh(cfg.StartupChecks.CacheTTL, cfg.StartupChecks.ProbeTimeout)
+	readiness.Register(ratesService.ProviderChecks()...)
+	readiness.Register(ratesService.CacheChecks()...)
+	readiness.Register(ratesService.BrokerChecks()...)
+	if database != nil {
+		readiness.Register(database.Checks()...)
+	}
+	if err := readiness.Startup(context.Background()); err != nil {
+		loggerInstance.Errorf("Refusing to start: %v", err)
+		os.Exit(1)
+	}
 
 	// Initialize HTTP handlers
+	v1Lifecycle, err := api.ParseLifecycle(cfg.APIVersions.V1DeprecationDate, cfg.APIVersions.V1SunsetDate)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	signatureVerifier := auth.NewSignatureVerifier(cfg.RequestSignatures)
+	routeBudgets := latency.NewBudgets("route", cfg.LatencyBudgets.Routes, cfg.LatencyBudgets.Window, cfg.LatencyBudgets.MinSamples, loggerInstance)
 	handlerConfig := api.HandlerConfig{
 		Logger:       loggerInstance,
 		RatesService: ratesService,
 		RateLimiter:  rateLimiter,
+		Tenants:      tenantRegistry,
+		AdminAPIKey:  cfg.AdminAPIKey,
+		AdminKeys:    cfg.AdminKeys,
+		Readiness:    readiness,
+		Store:        database,
+		Usage:        usageTracker,
+		Quotas:       quotaManager,
+		JWT:          jwtVerifier,
+		OAuth:        oauthIssuer,
+		Signatures:   signatureVerifier,
+		RatesSigner:  ratesSigner,
+		Attestations: attestationLedger,
+		Alerts:       alertEngine,
+		RouteBudgets: routeBudgets,
+		Admission:    admission.NewScheduler(cfg.Admission),
+		Abuse:        abuseDetector,
+		Capture:      captureRecorder,
+		Calendar:     marketCalendar,
+		Config:       cfg,
+
+		Stream:              streamHub,
+		StreamHeartbeat:     cfg.Stream.Heartbeat,
+		StreamSnapshotEvery: cfg.Stream.SnapshotEvery,
+
+		WebhookSecrets:   cfg.WebhookSecrets,
+		WebhookTolerance: cfg.WebhookTolerance,
+
+		V1Lifecycle: v1Lifecycle,
+		DisableV1:   !cfg.APIVersions.V1Enabled,
 	}
 	handlers := api.NewHandlers(handlerConfig)
+	alertEngine.SetRequestCounter(handlers)
+	alertEngine.Start(backgroundCtx)
+
+	// Re-read secrets from their files and the secret manager, so rotated values apply
+	// without a restart
+	if cfg.Secrets.External && cfg.Secrets.RefreshInterval > 0 {
+		go refreshSecrets(backgroundCtx, cfg.Secrets.RefreshInterval, loggerInstance, func(reloaded *config.Config) {
+			tenantRegistry.RotateAPIKeys(reloaded.Tenants)
+			handlers.RotateSecrets(reloaded.AdminAPIKey, reloaded.AdminKeys, reloaded.WebhookSecrets)
+			ratesService.RotateProviderSecrets(reloaded.ExchangeRateProviders)
+			if signatureVerifier != nil {
+				signatureVerifier.RotateSecrets(reloaded.RequestSignatures.Secrets)
+			}
+			if oauthIssuer != nil {
+				oauthIssuer.RotateClientSecrets(reloaded.OAuth.Clients)
+			}
+		})
+	}
 
 	// Setup Gin router
-	router := handlers.SetupRoutes()
+	routerOptions, err := api.NewRouterOptions(cfg.Router)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	router := handlers.SetupRoutes(routerOptions)
+	routePaths := make(map[string]bool)
+	for _, route := range router.Routes() {
+		routePaths[route.Path] = true
+	}
+	for _, path := range routeBudgets.Names() {
+		if !routePaths[path] {
+			log.Fatalf("Invalid configuration: latency budget for unknown route %s", path)
+		}
+	}
 
 	// Setup HTTP server
 	server := &http.Server{
@@ -50,15 +367,29 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 	}
+	// Open rate streams would otherwise hold up graceful shutdown
+	server.RegisterOnShutdown(streamHub.Close)
 
-	// Start server in a goroutine
-	serverErr := make(chan error, 1)
-	go func() {
-		loggerInstance.Info("Starting microservice on port " + cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			serverErr <- err
-		}
-	}()
+	// Start serving every listener in its own goroutine; shutting the server down
+	// closes them and removes the Unix socket file
+	listeners, err := listener.Open(cfg.Port, cfg.Listen, loggerInstance)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	serverErr := make(chan error, len(listeners))
+	loggerInstance.Info("Starting microservice")
+	for _, serverListener := range listeners {
+		go func(serverListener net.Listener) {
+			if err := server.Serve(serverListener); err != nil && err != http.ErrServerClosed {
+				serverErr <- err
+			}
+		}(serverListener)
+	}
+
+	// Tell systemd the service accepts requests, when it runs as a Type=notify unit
+	if err := listener.Notify("READY=1"); err != nil {
+		loggerInstance.Warnf("Failed to notify readiness: %v", err)
+	}
 
 	// Wait for interrupt signal or server error
 	quit := make(chan os.Signal, 1)
@@ -69,14 +400,21 @@ func main() {
 		loggerInstance.Infof("Received signal: %v", sig)
 	case err := <-serverErr:
 		loggerInstance.Errorf("Server error: %v", err)
+		listener.Close(listeners)
 		os.Exit(1)
 	}
 
 	loggerInstance.Info("Shutting down server...")
+	if err := listener.Notify("STOPPING=1"); err != nil {
+		loggerInstance.Warnf("Failed to notify shutdown: %v", err)
+	}
 
 	// Stop rate limiter cleanup
 	rateLimiter.Stop()
 
+	// Stop the background jobs, which refresh and cache rates
+	stopBackground()
+
 	// Create shutdown context with timeout for graceful shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
@@ -91,5 +429,50 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Flush pending rate events once no request or background job can cache rates
+	if err := ratesService.Close(); err != nil {
+		loggerInstance.Errorf("Rate events shutdown error: %v", err)
+	}
+
+	// Flush the usage and quota counts and the rate limit buckets of the last requests
+	if usageTracker != nil {
+		if err := usageTracker.Flush(shutdownCtx); err != nil {
+			loggerInstance.Errorf("API usage flush error: %v", err)
+		}
+	}
+	if quotaManager != nil {
+		if err := quotaManager.Flush(shutdownCtx); err != nil {
+			loggerInstance.Errorf("Quota usage flush error: %v", err)
+		}
+	}
+	if err := rateLimiter.Save(shutdownCtx); err != nil {
+		loggerInstance.Errorf("Rate limit buckets save error: %v", err)
+	}
+
+	// Post pending outage notices
+	outageNotifier.Close()
+
 	loggerInstance.Info("Server stopped gracefully")
 }
+
+// refreshSecrets reloads the configuration every interval and hands it to rotate. A
+// failed reload keeps the current secrets.
+func refreshSecrets(ctx context.Context, interval time.Duration, loggerInstance logger.Logger, rotate func(*config.Config)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := config.Load()
+			if err != nil {
+				loggerInstance.Errorf("Failed to refresh secrets, keeping the current ones: %v", err)
+				continue
+			}
+			rotate(reloaded)
+			loggerInstance.Debug("Secrets refreshed")
+		}
+	}
+}
@@ -6,12 +6,13 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/service"
 )
 
 // RequestLogger creates a custom request logger middleware
 func RequestLogger(log logger.Logger) gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		log.WithFields(logger.Fields{
+		fields := logger.Fields{
 			"timestamp":  param.TimeStamp.Format(time.RFC3339),
 			"status":     param.StatusCode,
 			"latency":    param.Latency,
@@ -19,8 +20,13 @@ func RequestLogger(log logger.Logger) gin.HandlerFunc {
 			"method":     param.Method,
 			"path":       param.Path,
 			"user_agent": param.Request.UserAgent(),
+			"request_id": param.Keys["request_id"],
 			"error":      param.ErrorMessage,
-		}).Info("HTTP Request")
+		}
+		if traceID, found := param.Keys["trace_id"]; found {
+			fields["trace_id"] = traceID
+		}
+		log.WithFields(fields).Info("HTTP Request")
 		return ""
 	})
 }
@@ -36,30 +42,36 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-// RequestID adds a unique request ID to each request
-func RequestID() gin.HandlerFunc {
+// RequestID adds a unique request ID to each request and to its context, so provider
+// calls made for the request carry the same ID. IDs come from generator, or are UUIDv7s
+// when it is nil; an X-Request-ID sent by the client is kept.
+func RequestID(generator IDGenerator) gin.HandlerFunc {
+	if generator == nil {
+		generator = UUIDv7{}
+	}
 	return func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
 		if requestID == "" {
-			requestID = generateRequestID()
+			requestID = generator.NewID()
 		}
 		c.Header("X-Request-ID", requestID)
 		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(service.WithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
 
-// generateRequestID generates a simple request ID
-func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(6)
-}
-
-// randomString generates a random string of specified length
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+// TraceContext passes the W3C Trace Context and B3 headers of each request through to
+// the provider calls made for it, and logs its trace ID as trace_id, so the service's
+// calls can be stitched into the caller's trace without exporting spans of its own
+func TraceContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if traceContext := service.TraceContext(c.Request.Header); traceContext != nil {
+			if traceID := service.TraceID(traceContext); traceID != "" {
+				c.Set("trace_id", traceID)
+			}
+			c.Request = c.Request.WithContext(service.WithTraceContext(c.Request.Context(), traceContext))
+		}
+		c.Next()
 	}
-	return string(b)
 }
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// IDGenerator creates request IDs; tests inject one to get predictable IDs
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to the IDGenerator interface
+type IDGeneratorFunc func() string
+
+// NewID calls the function
+func (generator IDGeneratorFunc) NewID() string {
+	return generator()
+}
+
+// UUIDv7 generates RFC 9562 version 7 UUIDs: a millisecond Unix timestamp followed by
+// 74 random bits from crypto/rand, so IDs sort by creation time and do not collide
+// under load
+type UUIDv7 struct{}
+
+// NewID returns a new UUIDv7 in its canonical hyphenated form
+func (UUIDv7) NewID() string {
+	return newUUIDv7(time.Now())
+}
+
+// newUUIDv7 builds a UUIDv7 for the given time
+func newUUIDv7(now time.Time) string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[6:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(now.UnixMilli()))
+	copy(uuid[:6], timestamp[2:])
+	uuid[6] = uuid[6]&0x0F | 0x70 // Version 7
+	uuid[8] = uuid[8]&0x3F | 0x80 // RFC 9562 variant
+
+	encoded := hex.EncodeToString(uuid[:])
+	return encoded[0:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:32]
+}
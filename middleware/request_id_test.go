@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/service"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDv7_NewID(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	id := newUUIDv7(now)
+	if !uuidV7Pattern.MatchString(id) {
+		t.Fatalf("newUUIDv7() = %q, want a version 7 UUID", id)
+	}
+	// The first 48 bits are the Unix time in milliseconds
+	if want := "018d5f63-ea00"; id[:13] != want {
+		t.Errorf("newUUIDv7() timestamp = %q, want %q", id[:13], want)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		id := UUIDv7{}.NewID()
+		if seen[id] {
+			t.Fatalf("UUIDv7.NewID() repeated %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		generator IDGenerator
+		header    string
+		want      string
+	}{
+		{name: "injected generator", generator: IDGeneratorFunc(func() string { return "fixed-id" }), want: "fixed-id"},
+		{name: "client ID kept", generator: IDGeneratorFunc(func() string { return "fixed-id" }), header: "client-id", want: "client-id"},
+		{name: "UUIDv7 by default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID, serviceID string
+			router := gin.New()
+			router.Use(RequestID(tt.generator))
+			router.GET("/", func(c *gin.Context) {
+				contextID = c.GetString("request_id")
+				serviceID = service.RequestIDFrom(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			got := w.Header().Get("X-Request-ID")
+			if tt.want == "" {
+				if !uuidV7Pattern.MatchString(got) {
+					t.Errorf("X-Request-ID = %q, want a version 7 UUID", got)
+				}
+			} else if got != tt.want {
+				t.Errorf("X-Request-ID = %q, want %q", got, tt.want)
+			}
+			if contextID != got || serviceID != got {
+				t.Errorf("context IDs = %q and %q, want %q", contextID, serviceID, got)
+			}
+		})
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	for _, header := range []string{traceparent, ""} {
+		var traceID, forwarded string
+		var logged bool
+		router := gin.New()
+		router.Use(TraceContext())
+		router.GET("/", func(c *gin.Context) {
+			_, logged = c.Get("trace_id")
+			traceID = c.GetString("trace_id")
+			forwarded = service.TraceContextFrom(c.Request.Context()).Get(service.TraceparentHeader)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("traceparent", header)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if header == "" {
+			if logged || forwarded != "" {
+				t.Errorf("without trace headers trace_id = %q and forwarded %q, want neither", traceID, forwarded)
+			}
+			continue
+		}
+		if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || forwarded != traceparent {
+			t.Errorf("trace_id = %q and forwarded %q, want the traceparent's", traceID, forwarded)
+		}
+	}
+}
@@ -1,12 +1,235 @@
 package models
 
-import "time"
+import (
+	"encoding/xml"
+	"sort"
+	"time"
+)
+
+// RateTable maps currency codes to rates. It is a named type so that it can be
+// rendered as XML, which has no native representation for maps.
+type RateTable map[string]float64
+
+// MarshalXML renders the table as <rate currency="EUR">0.85</rate> elements sorted by code
+func (rateTable RateTable) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	currencies := make([]string, 0, len(rateTable))
+	for currency := range rateTable {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		rateElement := xml.StartElement{
+			Name: xml.Name{Local: "rate"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "currency"}, Value: currency}},
+		}
+		if err := encoder.EncodeElement(rateTable[currency], rateElement); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
 
 type RatesResponse struct {
-	Base      string             `json:"base"`
-	Timestamp int64              `json:"timestamp"`
-	Rates     map[string]float64 `json:"rates"`
-	Provider  string             `json:"provider"`
+	Base      string    `json:"base" xml:"base"`
+	Timestamp int64     `json:"timestamp" xml:"timestamp"`
+	Rates     RateTable `json:"rates" xml:"rates"`
+	Provider  string    `json:"provider" xml:"provider"`
+
+	// PublishedAt is when the provider published the rates (0 if unknown), FetchedAt is
+	// when they were fetched, and AgeSeconds is how old they were when served
+	PublishedAt int64 `json:"published_at,omitempty" xml:"published_at,omitempty"`
+	FetchedAt   int64 `json:"fetched_at" xml:"fetched_at"`
+	AgeSeconds  int64 `json:"age_seconds" xml:"age_seconds"`
+
+	// Set when the rates were derived from another base via cross rates
+	Rebased    bool   `json:"rebased,omitempty" xml:"rebased,omitempty"`
+	SourceBase string `json:"source_base,omitempty" xml:"source_base,omitempty"`
+
+	// Set when expired rates are served because the provider call budget is spent
+	Degraded bool `json:"degraded,omitempty" xml:"degraded,omitempty"`
+
+	// Currencies whose rates are older than their configured max age
+	Stale []string `json:"stale,omitempty" xml:"stale>currency,omitempty"`
+
+	// Bid and ask quotes of the currencies the provider quotes a spread for; Rates are
+	// mid rates
+	Bid RateTable `json:"bid,omitempty" xml:"bid,omitempty"`
+	Ask RateTable `json:"ask,omitempty" xml:"ask,omitempty"`
+
+	// Set when the bid or ask side was requested: the side Rates hold, and whether some
+	// of them are mid rates for lack of spread data
+	Side        string `json:"side,omitempty" xml:"side,omitempty"`
+	MidFallback bool   `json:"mid_fallback,omitempty" xml:"mid_fallback,omitempty"`
+}
+
+// Sides of a quote a rate can be served at
+const (
+	SideMid = "mid"
+	SideBid = "bid"
+	SideAsk = "ask"
+)
+
+// WithRates replaces the rates, keeping the bid and ask quotes of the currencies still
+// quoted
+func (exchangeRates RatesResponse) WithRates(rates RateTable) RatesResponse {
+	exchangeRates.Rates = rates
+	exchangeRates.Bid = exchangeRates.Bid.restrictTo(rates)
+	exchangeRates.Ask = exchangeRates.Ask.restrictTo(rates)
+	return exchangeRates
+}
+
+// restrictTo returns the entries of the table whose currencies the other table has
+func (rateTable RateTable) restrictTo(other RateTable) RateTable {
+	if len(rateTable) == 0 {
+		return nil
+	}
+	restricted := make(RateTable, len(rateTable))
+	for currency, rate := range rateTable {
+		if _, found := other[currency]; found {
+			restricted[currency] = rate
+		}
+	}
+	return restricted
+}
+
+// Quote returns the currency's rate at the side, falling back to the mid rate when the
+// provider quoted no spread for it
+func (exchangeRates RatesResponse) Quote(currency, side string) (rate float64, found, midFallback bool) {
+	mid, found := exchangeRates.Rates[currency]
+	if !found {
+		return 0, false, false
+	}
+	quotes := exchangeRates.Bid
+	switch side {
+	case SideBid:
+	case SideAsk:
+		quotes = exchangeRates.Ask
+	default:
+		return mid, true, false
+	}
+	if quote, quoted := quotes[currency]; quoted {
+		return quote, true, false
+	}
+	return mid, true, currency != exchangeRates.Base
+}
+
+// AtSide serves the bid or ask quote of every currency as its rate, flagging the
+// response when some currencies fall back to their mid rate. The mid side is served as is.
+func (exchangeRates RatesResponse) AtSide(side string) RatesResponse {
+	if side != SideBid && side != SideAsk {
+		return exchangeRates
+	}
+
+	rates := make(RateTable, len(exchangeRates.Rates))
+	for currency := range exchangeRates.Rates {
+		rate, _, midFallback := exchangeRates.Quote(currency, side)
+		rates[currency] = rate
+		exchangeRates.MidFallback = exchangeRates.MidFallback || midFallback
+	}
+	exchangeRates.Rates, exchangeRates.Bid, exchangeRates.Ask = rates, nil, nil
+	exchangeRates.Side = side
+	return exchangeRates
+}
+
+// PairRate derives the from/to rate from the table, crossing through its base when
+// neither currency is the base
+func (exchangeRates RatesResponse) PairRate(from, to string) (float64, bool) {
+	unitsOf := func(code string) (float64, bool) {
+		if code == exchangeRates.Base {
+			return 1, true
+		}
+		rate, found := exchangeRates.Rates[code]
+		return rate, found && rate > 0
+	}
+
+	fromUnits, fromFound := unitsOf(from)
+	toUnits, toFound := unitsOf(to)
+	if !fromFound || !toFound {
+		return 0, false
+	}
+	return toUnits / fromUnits, true
+}
+
+// PairRateResponse is the rate of a single currency pair and its inverse
+type PairRateResponse struct {
+	Pair        string  `json:"pair" xml:"pair"`
+	Rate        float64 `json:"rate" xml:"rate"`
+	Inverse     float64 `json:"inverse" xml:"inverse"`
+	Provider    string  `json:"provider" xml:"provider"`
+	Timestamp   int64   `json:"timestamp" xml:"timestamp"`
+	PublishedAt int64   `json:"published_at,omitempty" xml:"published_at,omitempty"`
+	FetchedAt   int64   `json:"fetched_at" xml:"fetched_at"`
+	AgeSeconds  int64   `json:"age_seconds" xml:"age_seconds"`
+	Stale       bool    `json:"stale,omitempty" xml:"stale,omitempty"` // Older than the max age of either currency
+}
+
+// RefreshHints tells polling clients how long rates are cached and when the rates of each
+// base refresh next, so they can poll right after a refresh instead of guessing
+type RefreshHints struct {
+	CacheTTLSeconds int64             `json:"cache_ttl_seconds" xml:"cache_ttl_seconds"`
+	Bases           []BaseRefreshHint `json:"bases" xml:"bases>base"`
+}
+
+// BaseRefreshHint is the refresh schedule of the latest rates of one base
+type BaseRefreshHint struct {
+	Base                string `json:"base" xml:"base"`
+	FetchedAt           int64  `json:"fetched_at,omitempty" xml:"fetched_at,omitempty"` // 0 when none are cached
+	NextRefreshAt       int64  `json:"next_refresh_at" xml:"next_refresh_at"`
+	PollIntervalSeconds int64  `json:"poll_interval_seconds" xml:"poll_interval_seconds"`
+}
+
+// StreamSubscriptions lists the pairs a rate stream connection is subscribed to
+type StreamSubscriptions struct {
+	ConnectionID  string   `json:"connection_id" xml:"connection_id"`
+	Subscriptions []string `json:"subscriptions" xml:"subscriptions>pair"`
+}
+
+// StreamDelta is a message of a delta-encoded rate stream. A snapshot holds the rates of
+// every subscribed pair; a delta only those that changed since the previous message.
+// Sequence numbers count the messages of a stream, so a client can tell it missed one.
+type StreamDelta struct {
+	Sequence  uint64             `json:"seq" xml:"seq"`
+	Provider  string             `json:"provider,omitempty" xml:"provider,omitempty"`
+	Timestamp int64              `json:"timestamp" xml:"timestamp"`
+	Rates     map[string]float64 `json:"rates" xml:"-"` // Rate by pair
+}
+
+// StreamStats reports open rate streams and their backpressure totals since startup
+type StreamStats struct {
+	Connections     int    `json:"connections" xml:"connections"`
+	Policy          string `json:"policy" xml:"policy"`
+	BufferSize      int    `json:"buffer_size" xml:"buffer_size"`
+	Pending         int    `json:"pending" xml:"pending"`                   // Updates buffered right now
+	Queued          int64  `json:"queued" xml:"queued"`                     // Updates accepted into buffers
+	Coalesced       int64  `json:"coalesced" xml:"coalesced"`               // Buffered updates replaced by a newer rate of the pair
+	Dropped         int64  `json:"dropped" xml:"dropped"`                   // Updates lost to full buffers
+	SlowDisconnects int64  `json:"slow_disconnects" xml:"slow_disconnects"` // Clients disconnected for falling behind
+}
+
+// RuntimeStats reports the Go runtime's goroutines and heap, next to the sizes of the
+// subsystems that grow with clients, so sustained load can be checked for leaks
+type RuntimeStats struct {
+	SampledAt         time.Time `json:"sampled_at" xml:"sampled_at"`
+	Goroutines        int       `json:"goroutines" xml:"goroutines"`
+	HeapAllocBytes    uint64    `json:"heap_alloc_bytes" xml:"heap_alloc_bytes"`
+	HeapObjects       uint64    `json:"heap_objects" xml:"heap_objects"`
+	GCCycles          uint32    `json:"gc_cycles" xml:"gc_cycles"`
+	LimiterBuckets    int       `json:"limiter_buckets" xml:"limiter_buckets"`
+	StreamConnections int       `json:"stream_connections" xml:"stream_connections"`
+}
+
+// ConfigDump is the configuration the process started with, with secrets redacted, and
+// where each configuration variable came from: env, file, secret_manager or default
+type ConfigDump struct {
+	StartedAt time.Time              `json:"started_at"`
+	Config    map[string]interface{} `json:"config"`
+	Sources   map[string]string      `json:"sources"`
 }
 
 type CacheEntry struct {
@@ -15,14 +238,544 @@ type CacheEntry struct {
 }
 
 type HealthCheck struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
-	Uptime    string    `json:"uptime"`
+	Status    string    `json:"status" xml:"status"`
+	Timestamp time.Time `json:"timestamp" xml:"timestamp"`
+	Version   string    `json:"version" xml:"version"`
+	Uptime    string    `json:"uptime" xml:"uptime"`
+
+	// Set with ?verbose=true: the readiness status and its dependency checks
+	Readiness    string             `json:"readiness,omitempty" xml:"readiness,omitempty"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty" xml:"dependencies>dependency,omitempty"`
+}
+
+// VersionInfo reports the service version and, with persistence enabled, the schema version
+type VersionInfo struct {
+	Version             string `json:"version" xml:"version"`
+	SchemaVersion       int    `json:"schema_version,omitempty" xml:"schema_version,omitempty"`
+	LatestSchemaVersion int    `json:"latest_schema_version,omitempty" xml:"latest_schema_version,omitempty"`
+}
+
+// DependencyStatus is the result of one dependency check
+type DependencyStatus struct {
+	Name          string    `json:"name" xml:"name"`
+	Kind          string    `json:"kind,omitempty" xml:"kind,omitempty"`
+	Group         string    `json:"group" xml:"group"`
+	Optional      bool      `json:"optional,omitempty" xml:"optional,omitempty"`
+	Status        string    `json:"status" xml:"status"`
+	Error         string    `json:"error,omitempty" xml:"error,omitempty"`
+	Detail        string    `json:"detail,omitempty" xml:"detail,omitempty"`
+	DurationMS    float64   `json:"duration_ms" xml:"duration_ms"`
+	LastSuccessAt time.Time `json:"last_success_at,omitempty" xml:"last_success_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty" xml:"last_error,omitempty"`
+	LastErrorAt   time.Time `json:"last_error_at,omitempty" xml:"last_error_at,omitempty"`
+}
+
+// ReadinessReport aggregates the dependency checks behind /health/ready
+type ReadinessReport struct {
+	Status    string             `json:"status" xml:"status"`
+	Mode      string             `json:"mode" xml:"mode"`
+	CheckedAt time.Time          `json:"checked_at,omitempty" xml:"checked_at,omitempty"`
+	Checks    []DependencyStatus `json:"checks" xml:"checks>check"`
 }
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+	Error   string       `json:"error" xml:"error"`
+	Message string       `json:"message" xml:"message"`
+	Code    int          `json:"code" xml:"code"`
+	Fields  []FieldError `json:"fields,omitempty" xml:"fields>field,omitempty"`
+}
+
+// FieldError is one invalid request parameter and why it was rejected
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Message string `json:"message" xml:"message"`
+}
+
+// Envelope wraps every successful API v2 response in a data member with request metadata
+type Envelope struct {
+	Data interface{}  `json:"data" xml:"data"`
+	Meta EnvelopeMeta `json:"meta" xml:"meta"`
+}
+
+// MarshalXML renders the envelope as <response><data>...</data><meta>...</meta></response>.
+// Data that names its own root element (e.g. a map) is nested inside <data>.
+func (envelope Envelope) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "response"}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	dataStart := xml.StartElement{Name: xml.Name{Local: "data"}}
+	if _, namesItself := envelope.Data.(xml.Marshaler); namesItself {
+		if err := encoder.EncodeToken(dataStart); err != nil {
+			return err
+		}
+		if err := encoder.Encode(envelope.Data); err != nil {
+			return err
+		}
+		if err := encoder.EncodeToken(dataStart.End()); err != nil {
+			return err
+		}
+	} else if err := encoder.EncodeElement(envelope.Data, dataStart); err != nil {
+		return err
+	}
+
+	if err := encoder.EncodeElement(envelope.Meta, xml.StartElement{Name: xml.Name{Local: "meta"}}); err != nil {
+		return err
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// EnvelopeMeta describes the request an enveloped response answers
+type EnvelopeMeta struct {
+	APIVersion string    `json:"api_version" xml:"api_version"`
+	RequestID  string    `json:"request_id,omitempty" xml:"request_id,omitempty"`
+	Timestamp  time.Time `json:"timestamp" xml:"timestamp"`
+}
+
+// Problem is an RFC 9457 problem details error body, returned by API v2
+type Problem struct {
+	XMLName   xml.Name `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type      string   `json:"type" xml:"type"`
+	Title     string   `json:"title" xml:"title"`
+	Status    int      `json:"status" xml:"status"`
+	Detail    string   `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance  string   `json:"instance,omitempty" xml:"instance,omitempty"`
+	RequestID string   `json:"request_id,omitempty" xml:"request_id,omitempty"`
+
+	Fields []FieldError `json:"fields,omitempty" xml:"fields>field,omitempty"`
+}
+
+type ProviderStatus struct {
+	Name              string  `json:"name" xml:"name"`
+	Enabled           bool    `json:"enabled" xml:"enabled"`
+	Priority          int     `json:"priority" xml:"priority"`
+	EffectivePriority int     `json:"effective_priority" xml:"effective_priority"`
+	Demoted           bool    `json:"demoted" xml:"demoted"`
+	P95MS             float64 `json:"p95_ms,omitempty" xml:"p95_ms,omitempty"`
+	Calls             int64   `json:"calls,omitempty" xml:"calls,omitempty"`       // Calls since startup
+	Failures          int64   `json:"failures,omitempty" xml:"failures,omitempty"` // Failed calls since startup
+
+	// Set while the provider is skipped: disabled by an operator or after rejected
+	// credentials, backing off after an exceeded quota, or for bases it reported as
+	// unsupported. Disabled providers in standby are probed and re-enabled once
+	// StandbySuccesses reaches the required streak.
+	Disabled         bool       `json:"disabled,omitempty" xml:"disabled,omitempty"`
+	DisabledBy       string     `json:"disabled_by,omitempty" xml:"disabled_by,omitempty"` // "health" or "operator"
+	Standby          bool       `json:"standby,omitempty" xml:"standby,omitempty"`
+	StandbySuccesses int        `json:"standby_successes,omitempty" xml:"standby_successes,omitempty"`
+	BackoffUntil     *time.Time `json:"backoff_until,omitempty" xml:"backoff_until,omitempty"`
+	UnsupportedBases []string   `json:"unsupported_bases,omitempty" xml:"unsupported_bases>base,omitempty"`
+	LastError        string     `json:"last_error,omitempty" xml:"last_error,omitempty"`
+
+	Concurrency *ProviderConcurrency `json:"concurrency,omitempty" xml:"concurrency,omitempty"`
+	Polling     *ProviderPolling     `json:"polling,omitempty" xml:"polling,omitempty"`
+	RateLimit   *ProviderRateLimit   `json:"rate_limit,omitempty" xml:"rate_limit,omitempty"`
+	Composite   *ProviderComposite   `json:"composite,omitempty" xml:"composite,omitempty"`
+}
+
+// ProviderComposite describes a composite provider's members and how their rates combine
+type ProviderComposite struct {
+	Members     []string `json:"members" xml:"members>member"`
+	Combine     string   `json:"combine" xml:"combine"`
+	TrimPercent float64  `json:"trim_percent,omitempty" xml:"trim_percent,omitempty"`
+	MinMembers  int      `json:"min_members" xml:"min_members"`
+}
+
+// ProviderTransition records a provider entering or leaving the disabled and standby states
+type ProviderTransition struct {
+	Provider string    `json:"provider" xml:"provider"`
+	From     string    `json:"from" xml:"from"` // "enabled", "disabled" or "standby"
+	To       string    `json:"to" xml:"to"`
+	By       string    `json:"by" xml:"by"` // "health" or "operator"
+	Reason   string    `json:"reason,omitempty" xml:"reason,omitempty"`
+	At       time.Time `json:"at" xml:"at"`
+}
+
+// AdminAuditEntry records a call of an admin endpoint that changes state, or a denied
+// call of any admin endpoint
+type AdminAuditEntry struct {
+	At        time.Time `json:"at" xml:"at"`
+	Admin     string    `json:"admin,omitempty" xml:"admin,omitempty"` // Holder of the admin key (empty = not authenticated)
+	Role      string    `json:"role,omitempty" xml:"role,omitempty"`
+	Method    string    `json:"method" xml:"method"`
+	Path      string    `json:"path" xml:"path"`
+	Status    int       `json:"status" xml:"status"`
+	ClientIP  string    `json:"client_ip" xml:"client_ip"`
+	RequestID string    `json:"request_id,omitempty" xml:"request_id,omitempty"`
+}
+
+// ProviderRateLimit reports a provider's outbound rate limit and the tokens left in it
+type ProviderRateLimit struct {
+	Limit       string     `json:"limit" xml:"limit"` // Published limit, e.g. "1000/month"
+	Burst       int        `json:"burst" xml:"burst"`
+	Available   int        `json:"available" xml:"available"`
+	NextTokenAt *time.Time `json:"next_token_at,omitempty" xml:"next_token_at,omitempty"` // Set while no token is left
+	Rejected    int64      `json:"rejected" xml:"rejected"`                               // Calls skipped since startup for lack of a token
+}
+
+// ProviderPolling reports how often conditional polling spared a provider a full response
+type ProviderPolling struct {
+	Conditional int64 `json:"conditional" xml:"conditional"`   // Requests sent with ETag or Last-Modified validators
+	NotModified int64 `json:"not_modified" xml:"not_modified"` // 304 responses served from the previous rates
+	Unchanged   int64 `json:"unchanged" xml:"unchanged"`       // Full responses identical to the previous one
+}
+
+// ProviderConcurrency reports a provider's concurrency cap and the calls using or awaiting it
+type ProviderConcurrency struct {
+	MaxConcurrent int   `json:"max_concurrent" xml:"max_concurrent"`
+	InFlight      int   `json:"in_flight" xml:"in_flight"`
+	Queued        int64 `json:"queued" xml:"queued"`
+	QueueSize     int64 `json:"queue_size" xml:"queue_size"`
+	Rejected      int64 `json:"rejected" xml:"rejected"` // Calls refused since startup because the queue was full
+}
+
+type CacheStats struct {
+	Hits    int64 `json:"hits" xml:"hits"`
+	Misses  int64 `json:"misses" xml:"misses"`
+	Entries int   `json:"entries" xml:"entries"` // Valid cached tables of any base, symbols filter and date
+	// Valid provider tables shared by the service and its tenant views (shared service only)
+	ProviderTables int        `json:"provider_tables,omitempty" xml:"provider_tables,omitempty"`
+	Base           string     `json:"base,omitempty" xml:"base,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at" xml:"expires_at"`
+	TTL            string     `json:"ttl" xml:"ttl"`
+	Coalescing     FetchStats `json:"coalescing" xml:"coalescing"`
+}
+
+// LatencyBudget reports a route or provider against its target p95 latency
+type LatencyBudget struct {
+	Name           string  `json:"name" xml:"name"`
+	BudgetMS       float64 `json:"budget_ms" xml:"budget_ms"`             // Target p95 latency
+	P95MS          float64 `json:"p95_ms" xml:"p95_ms"`                   // Rolling p95, rounded up to its histogram bucket
+	Samples        int64   `json:"samples" xml:"samples"`                 // Calls in the window
+	OverBudget     int64   `json:"over_budget" xml:"over_budget"`         // Calls in the window slower than the budget
+	Exceeded       bool    `json:"exceeded" xml:"exceeded"`               // Whether the rolling p95 is over the budget
+	BudgetExceeded int64   `json:"budget_exceeded" xml:"budget_exceeded"` // Times since startup the rolling p95 went over the budget
+}
+
+// AdmissionStats reports the API requests served at once and those queued for a slot
+type AdmissionStats struct {
+	MaxInFlight    int   `json:"max_in_flight" xml:"max_in_flight"`
+	InFlight       int   `json:"in_flight" xml:"in_flight"`
+	Queued         int   `json:"queued" xml:"queued"`
+	QueuedClients  int   `json:"queued_clients" xml:"queued_clients"` // Clients with queued requests
+	QueueSize      int   `json:"queue_size" xml:"queue_size"`
+	QueuePerClient int   `json:"queue_per_client" xml:"queue_per_client"`
+	Admitted       int64 `json:"admitted" xml:"admitted"`
+	Delayed        int64 `json:"delayed" xml:"delayed"`     // Admitted requests that waited in a queue
+	Rejected       int64 `json:"rejected" xml:"rejected"`   // Requests refused because the queues were full
+	TimedOut       int64 `json:"timed_out" xml:"timed_out"` // Requests that gave up waiting in a queue
+}
+
+// RateLimiterStats reports the client buckets held by the rate limiter
+type RateLimiterStats struct {
+	Buckets    int   `json:"buckets" xml:"buckets"`
+	MaxClients int   `json:"max_clients" xml:"max_clients"` // 0 = unlimited
+	Evicted    int64 `json:"evicted" xml:"evicted"`         // Least recently seen buckets dropped for new clients at the cap
+	Expired    int64 `json:"expired" xml:"expired"`         // Idle buckets removed by the cleanup
+
+	// Set in shadow mode, which counts the requests it would have rejected
+	Shadow        bool  `json:"shadow,omitempty" xml:"shadow,omitempty"`
+	ShadowBlocked int64 `json:"shadow_blocked,omitempty" xml:"shadow_blocked,omitempty"`
+
+	Restored int `json:"restored,omitempty" xml:"restored,omitempty"` // Buckets loaded from the store at startup
+}
+
+// CapturedExchange is a sampled API request and its response, kept for debugging with
+// credentials redacted and bodies cut at the size cap
+type CapturedExchange struct {
+	RequestID             string            `json:"request_id" xml:"request_id"`
+	At                    time.Time         `json:"at" xml:"at"`
+	Method                string            `json:"method" xml:"method"`
+	Path                  string            `json:"path" xml:"path"` // Path and query
+	ClientIP              string            `json:"client_ip" xml:"client_ip"`
+	KeyID                 string            `json:"key_id,omitempty" xml:"key_id,omitempty"` // Fingerprint of the caller's API key or token subject
+	Status                int               `json:"status" xml:"status"`
+	DurationMS            float64           `json:"duration_ms" xml:"duration_ms"`
+	RequestHeaders        map[string]string `json:"request_headers" xml:"-"`
+	RequestBody           string            `json:"request_body,omitempty" xml:"request_body,omitempty"`
+	RequestBodyTruncated  bool              `json:"request_body_truncated,omitempty" xml:"request_body_truncated,omitempty"`
+	ResponseHeaders       map[string]string `json:"response_headers" xml:"-"`
+	ResponseBody          string            `json:"response_body,omitempty" xml:"response_body,omitempty"`
+	ResponseBodyTruncated bool              `json:"response_body_truncated,omitempty" xml:"response_body_truncated,omitempty"`
+}
+
+// AbuseStats reports the authentication failures counted towards bans and the bans issued
+type AbuseStats struct {
+	Tracked    int   `json:"tracked" xml:"tracked"`         // Client IPs and credentials with recent failures or bans
+	ActiveBans int   `json:"active_bans" xml:"active_bans"` // Subjects banned now
+	Failures   int64 `json:"failures" xml:"failures"`       // Authentication failures since startup
+	Bans       int64 `json:"bans" xml:"bans"`               // Bans issued since startup
+	Evicted    int64 `json:"evicted" xml:"evicted"`         // Subjects evicted at the subject cap for new ones
+	Untracked  int64 `json:"untracked" xml:"untracked"`     // Failures of new subjects not tracked, as every tracked subject was banned
+}
+
+// Ban is a client IP or credential refused for failing authentication repeatedly
+type Ban struct {
+	Subject  string    `json:"subject" xml:"subject"`   // "ip:<address>", "key:<key ID>" or "client:<OAuth client ID>"
+	Offenses int       `json:"offenses" xml:"offenses"` // Bans of the subject since it was last forgiven, this one included
+	Since    time.Time `json:"since" xml:"since"`
+	Until    time.Time `json:"until" xml:"until"`
+}
+
+// CallBudgetStats reports the outbound provider call budget of the current window
+type CallBudgetStats struct {
+	Limit     int       `json:"limit" xml:"limit"`
+	Window    string    `json:"window" xml:"window"`
+	Used      int       `json:"used" xml:"used"`
+	Remaining int       `json:"remaining" xml:"remaining"`
+	ResetsAt  time.Time `json:"resets_at" xml:"resets_at"`
+	Rejected  int64     `json:"rejected" xml:"rejected"` // Calls refused since startup because the budget was spent
+	Exhausted bool      `json:"exhausted" xml:"exhausted"`
+}
+
+// FetchStats reports how concurrent cache misses are coalesced into provider fetches
+type FetchStats struct {
+	Leaders      int64           `json:"leaders" xml:"leaders"`             // Fetches executed
+	Shared       int64           `json:"shared" xml:"shared"`               // Callers served by a fetch another caller started
+	LargestShare int64           `json:"largest_share" xml:"largest_share"` // Most callers served by one fetch
+	InFlight     []InFlightFetch `json:"in_flight" xml:"in_flight>fetch"`
+}
+
+// InFlightFetch is a provider fetch still running and the callers waiting on it
+type InFlightFetch struct {
+	Key       string    `json:"key" xml:"key"`
+	StartedAt time.Time `json:"started_at" xml:"started_at"`
+	Waiters   int64     `json:"waiters" xml:"waiters"`
+}
+
+// TimeSeriesPoint is one open/high/low/close bucket of stored rate history
+type TimeSeriesPoint struct {
+	Time    time.Time `json:"time" xml:"time"`
+	Open    float64   `json:"open" xml:"open"`
+	High    float64   `json:"high" xml:"high"`
+	Low     float64   `json:"low" xml:"low"`
+	Close   float64   `json:"close" xml:"close"`
+	Samples int64     `json:"samples" xml:"samples"`
+
+	// Whether the point falls on a weekend or holiday of the market calendar
+	NonTradingDay bool `json:"non_trading_day,omitempty" xml:"non_trading_day,omitempty"`
+}
+
+// TimeSeriesResponse is the downsampled history of one currency pair
+type TimeSeriesResponse struct {
+	Base     string            `json:"base" xml:"base"`
+	Symbol   string            `json:"symbol" xml:"symbol"`
+	Interval string            `json:"interval" xml:"interval"`
+	From     time.Time         `json:"from" xml:"from"`
+	To       time.Time         `json:"to" xml:"to"`
+	Points   []TimeSeriesPoint `json:"points" xml:"points>point"`
+
+	Calendar *CalendarConvention `json:"calendar,omitempty" xml:"calendar,omitempty"`
+}
+
+// CalendarConvention documents how a historical query treated non-trading days: with
+// none they are served and marked, with previous a date rolls to the previous business
+// day and a series leaves them out
+type CalendarConvention struct {
+	Convention    string `json:"convention" xml:"convention"`
+	RequestedDate string `json:"requested_date,omitempty" xml:"requested_date,omitempty"` // Date asked for by single-date queries
+	NonTradingDay bool   `json:"non_trading_day,omitempty" xml:"non_trading_day,omitempty"`
+	RolledTo      string `json:"rolled_to,omitempty" xml:"rolled_to,omitempty"` // Business day served instead
+}
+
+// CompactionResult counts the rows one history compaction rolled up and pruned
+type CompactionResult struct {
+	HourlyRolledUp int64 `json:"hourly_rolled_up" xml:"hourly_rolled_up"`
+	DailyRolledUp  int64 `json:"daily_rolled_up" xml:"daily_rolled_up"`
+	RawPruned      int64 `json:"raw_pruned" xml:"raw_pruned"`
+	HourlyPruned   int64 `json:"hourly_pruned" xml:"hourly_pruned"`
+	DailyPruned    int64 `json:"daily_pruned" xml:"daily_pruned"`
+}
+
+// CompactionStats reports the history compactor's totals since startup
+type CompactionStats struct {
+	Runs         int64            `json:"runs" xml:"runs"`
+	Failures     int64            `json:"failures" xml:"failures"`
+	RawPruned    int64            `json:"raw_pruned" xml:"raw_pruned"`
+	HourlyPruned int64            `json:"hourly_pruned" xml:"hourly_pruned"`
+	DailyPruned  int64            `json:"daily_pruned" xml:"daily_pruned"`
+	LastRunAt    time.Time        `json:"last_run_at,omitempty" xml:"last_run_at,omitempty"`
+	LastResult   CompactionResult `json:"last_result" xml:"last_result"`
+	LastError    string           `json:"last_error,omitempty" xml:"last_error,omitempty"`
 }
+
+// UsageRecord is the usage of one endpoint with one API key within an hourly bucket
+type UsageRecord struct {
+	Bucket       time.Time
+	Tenant       string
+	KeyID        string // Fingerprint of the API key ("" for requests without a tenant)
+	Endpoint     string // Method and route, e.g. "GET /api/v1/rates/:base"
+	Requests     int64
+	Errors       int64 // Requests answered with a 4xx or 5xx status
+	Bytes        int64 // Response body bytes
+	LatencyMS    float64
+	MaxLatencyMS float64
+}
+
+// QuotaCount is the number of requests made with one API key on one UTC day
+type QuotaCount struct {
+	Day      time.Time
+	KeyID    string // Fingerprint of the API key
+	Requests int64
+}
+
+// RateLimitBucket is the token bucket of one rate limited client, saved across restarts
+type RateLimitBucket struct {
+	Key        string // Client IP or tenant key
+	Tokens     int
+	Capacity   int
+	RefillRate int // Tokens added per rate limit window
+	LastRefill time.Time
+	LastSeen   time.Time
+}
+
+// UsageEntry is the usage of one endpoint with one API key over a report's range
+type UsageEntry struct {
+	Tenant           string  `json:"tenant,omitempty" xml:"tenant,omitempty"`
+	KeyID            string  `json:"key_id,omitempty" xml:"key_id,omitempty"`
+	Endpoint         string  `json:"endpoint" xml:"endpoint"`
+	Requests         int64   `json:"requests" xml:"requests"`
+	Errors           int64   `json:"errors" xml:"errors"`
+	Bytes            int64   `json:"bytes" xml:"bytes"`
+	AverageLatencyMS float64 `json:"average_latency_ms" xml:"average_latency_ms"`
+	MaxLatencyMS     float64 `json:"max_latency_ms" xml:"max_latency_ms"`
+}
+
+// UsageReport is the API usage per key and endpoint between two times
+type UsageReport struct {
+	From    time.Time    `json:"from" xml:"from"`
+	To      time.Time    `json:"to" xml:"to"`
+	Entries []UsageEntry `json:"entries" xml:"entries>entry"`
+}
+
+type RequestRecord struct {
+	Method     string    `json:"method" xml:"method"`
+	Path       string    `json:"path" xml:"path"`
+	Status     int       `json:"status" xml:"status"`
+	DurationMS float64   `json:"duration_ms" xml:"duration_ms"`
+	Time       time.Time `json:"time" xml:"time"`
+}
+
+type RequestStats struct {
+	Total             int64           `json:"total" xml:"total"`
+	Errors            int64           `json:"errors" xml:"errors"`
+	AverageDurationMS float64         `json:"average_duration_ms" xml:"average_duration_ms"`
+	Recent            []RequestRecord `json:"recent" xml:"recent>request"`
+}
+
+// Alert is a firing alert rule: the metric of one subject, such as a provider or key,
+// above the rule's threshold
+type Alert struct {
+	Rule      string    `json:"rule" xml:"rule"`
+	Metric    string    `json:"metric" xml:"metric"`
+	Subject   string    `json:"subject,omitempty" xml:"subject,omitempty"`
+	Severity  string    `json:"severity" xml:"severity"`
+	Message   string    `json:"message" xml:"message"`
+	Value     float64   `json:"value" xml:"value"`
+	Threshold float64   `json:"threshold" xml:"threshold"`
+	FiredAt   time.Time `json:"fired_at" xml:"fired_at"`
+}
+
+// RatesDigest summarises one UTC day for the daily digest: the closing rates of the
+// configured pairs and how available the providers were
+type RatesDigest struct {
+	Date        string           `json:"date" xml:"date"` // YYYY-MM-DD
+	Pairs       []DigestPair     `json:"pairs" xml:"pairs>pair"`
+	Providers   []DigestProvider `json:"providers" xml:"providers>provider"`
+	GeneratedAt time.Time        `json:"generated_at" xml:"generated_at"`
+}
+
+// DigestPair is a pair's closing rate for the digest's day and its change from the day
+// before. Error is set instead when the closing rates could not be looked up.
+type DigestPair struct {
+	Pair          string   `json:"pair" xml:"pair"`
+	Close         float64  `json:"close,omitempty" xml:"close,omitempty"`
+	PreviousClose float64  `json:"previous_close,omitempty" xml:"previous_close,omitempty"`
+	ChangePercent *float64 `json:"change_percent,omitempty" xml:"change_percent,omitempty"`
+	Provider      string   `json:"provider,omitempty" xml:"provider,omitempty"`
+	Error         string   `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// DigestProvider is a provider's calls and failures since the previous digest, or since
+// startup for the first
+type DigestProvider struct {
+	Name         string  `json:"name" xml:"name"`
+	Calls        int64   `json:"calls" xml:"calls"`
+	Failures     int64   `json:"failures" xml:"failures"`
+	Availability float64 `json:"availability" xml:"availability"` // Percentage of calls that succeeded (100 without calls)
+	Disabled     bool    `json:"disabled,omitempty" xml:"disabled,omitempty"`
+}
+
+type ConversionResponse struct {
+	From      string  `json:"from" xml:"from"`
+	To        string  `json:"to" xml:"to"`
+	Amount    float64 `json:"amount" xml:"amount"`
+	MidRate   float64 `json:"mid_rate" xml:"mid_rate"`
+	Rate      float64 `json:"rate" xml:"rate"`
+	MarkupBPS float64 `json:"markup_bps" xml:"markup_bps"`
+	Fee       float64 `json:"fee" xml:"fee"`
+	Converted float64 `json:"converted" xml:"converted"`
+	Timestamp int64   `json:"timestamp" xml:"timestamp"`
+	Provider  string  `json:"provider" xml:"provider"`
+
+	// Set when the bid or ask side was requested: the side converted at, and whether the
+	// mid rate was used for lack of spread data
+	Side        string `json:"side,omitempty" xml:"side,omitempty"`
+	MidFallback bool   `json:"mid_fallback,omitempty" xml:"mid_fallback,omitempty"`
+
+	// Set on conversions at a date: the day converted at, and whether its rate was
+	// interpolated from neighbouring stored closes or projected with forward points
+	Date         string `json:"date,omitempty" xml:"date,omitempty"`
+	Interpolated bool   `json:"interpolated,omitempty" xml:"interpolated,omitempty"`
+	Forward      bool   `json:"forward,omitempty" xml:"forward,omitempty"`
+
+	Calendar *CalendarConvention `json:"calendar,omitempty" xml:"calendar,omitempty"`
+
+	// Attestation of the applied rate, for tenants that require attestations
+	AttestationID string `json:"attestation_id,omitempty" xml:"attestation_id,omitempty"`
+}
+
+// MultiConversionResponse converts one amount into several target currencies
+type MultiConversionResponse struct {
+	From        string               `json:"from" xml:"from"`
+	Amount      float64              `json:"amount" xml:"amount"`
+	Timestamp   int64                `json:"timestamp" xml:"timestamp"`
+	Provider    string               `json:"provider" xml:"provider"`
+	Conversions []ConversionResponse `json:"conversions" xml:"conversions>conversion"`
+}
+
+// AttestationClaims are the signed facts of a conversion: the pair, the rates applied and
+// where they came from
+type AttestationClaims struct {
+	ID            string  `json:"jti"`
+	Tenant        string  `json:"tenant"`
+	Pair          string  `json:"pair"` // FROM/TO
+	Amount        float64 `json:"amount"`
+	MidRate       float64 `json:"mid_rate"`
+	Rate          float64 `json:"rate"` // Rate applied, after the tenant's markup
+	Converted     float64 `json:"converted"`
+	Provider      string  `json:"provider"`
+	RateTimestamp int64   `json:"rate_timestamp"`
+	IssuedAt      int64   `json:"iat"`
+}
+
+// Attestation is the detached JWS over the claims of a conversion, kept for auditors
+type Attestation struct {
+	ID       string            `json:"id" xml:"id"`
+	Tenant   string            `json:"tenant" xml:"tenant"`
+	Claims   AttestationClaims `json:"claims" xml:"claims"`
+	Payload  string            `json:"payload" xml:"payload"` // The signed claims JSON, base64url-encoded
+	JWS      string            `json:"jws" xml:"jws"`         // Compact JWS with the payload detached
+	KeyID    string            `json:"key_id" xml:"key_id"`
+	IssuedAt time.Time         `json:"issued_at" xml:"issued_at"`
+}
+
+// Link is a HAL hypermedia link; templated links use RFC 6570 URI templates
+type Link struct {
+	Href      string `json:"href" xml:"href"`
+	Templated bool   `json:"templated,omitempty" xml:"templated,omitempty"`
+}
+
+// Links maps link relations to links
+type Links map[string]Link
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/xml"
+	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -208,3 +211,100 @@ func TestErrorResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestRateTable_MarshalXML(t *testing.T) {
+	response := RatesResponse{
+		Base:     "USD",
+		Rates:    RateTable{"GBP": 0.73, "EUR": 0.85},
+		Provider: "test-provider",
+	}
+
+	output, err := xml.Marshal(response)
+	if err != nil {
+		t.Fatalf("xml.Marshal() error = %v", err)
+	}
+
+	expected := `<rates><rate currency="EUR">0.85</rate><rate currency="GBP">0.73</rate></rates>`
+	if !strings.Contains(string(output), expected) {
+		t.Errorf("xml.Marshal() = %s, want it to contain %s", output, expected)
+	}
+	if !strings.Contains(string(output), `<base>USD</base>`) {
+		t.Errorf("xml.Marshal() = %s, want lowercase base element", output)
+	}
+}
+
+func TestRatesResponse_PairRate(t *testing.T) {
+	response := RatesResponse{Base: "USD", Rates: RateTable{"EUR": 0.8, "GBP": 0.5, "XXX": 0}}
+
+	tests := []struct {
+		name      string
+		from, to  string
+		wantRate  float64
+		wantFound bool
+	}{
+		{name: "from base", from: "USD", to: "EUR", wantRate: 0.8, wantFound: true},
+		{name: "to base", from: "EUR", to: "USD", wantRate: 1.25, wantFound: true},
+		{name: "cross rate", from: "GBP", to: "EUR", wantRate: 1.6, wantFound: true},
+		{name: "unknown currency", from: "USD", to: "JPY"},
+		{name: "zero rate", from: "XXX", to: "EUR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, found := response.PairRate(tt.from, tt.to)
+			if found != tt.wantFound || math.Abs(rate-tt.wantRate) > 1e-9 {
+				t.Errorf("PairRate(%s, %s) = %v, %v, want %v, %v", tt.from, tt.to, rate, found, tt.wantRate, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestRatesResponse_AtSide(t *testing.T) {
+	exchangeRates := RatesResponse{
+		Base:  "USD",
+		Rates: RateTable{"USD": 1, "EUR": 0.85, "GBP": 0.73},
+		Bid:   RateTable{"EUR": 0.849},
+		Ask:   RateTable{"EUR": 0.851},
+	}
+
+	if mid := exchangeRates.AtSide(SideMid); mid.Side != "" || len(mid.Bid) != 1 || mid.Rates["EUR"] != 0.85 {
+		t.Errorf("AtSide(mid) = %+v, want the response as is", mid)
+	}
+
+	bid := exchangeRates.AtSide(SideBid)
+	if bid.Side != SideBid || bid.Rates["EUR"] != 0.849 || bid.Rates["GBP"] != 0.73 || !bid.MidFallback || bid.Bid != nil {
+		t.Errorf("AtSide(bid) = %+v, want EUR at its bid and GBP at mid", bid)
+	}
+	if ask := exchangeRates.WithRates(RateTable{"EUR": 0.85}).AtSide(SideAsk); ask.Rates["EUR"] != 0.851 || ask.MidFallback {
+		t.Errorf("AtSide(ask) of EUR only = %+v, want the ask without a fallback", ask)
+	}
+
+	// Restricting the rates restricts the quotes
+	if restricted := exchangeRates.WithRates(RateTable{"GBP": 0.73}); len(restricted.Bid) != 0 || len(restricted.Ask) != 0 {
+		t.Errorf("WithRates() kept quotes %v and %v of currencies no longer quoted", restricted.Bid, restricted.Ask)
+	}
+	if rate, found, midFallback := exchangeRates.Quote("USD", SideAsk); rate != 1 || !found || midFallback {
+		t.Errorf("Quote(base) = %v, %v, %v, want 1 without a fallback", rate, found, midFallback)
+	}
+}
+
+func TestEnvelope_MarshalXML(t *testing.T) {
+	envelope := Envelope{
+		Data: PairRateResponse{Pair: "USD/EUR", Rate: 0.85},
+		Meta: EnvelopeMeta{APIVersion: "2", RequestID: "req-1", Timestamp: time.Unix(0, 0).UTC()},
+	}
+
+	output, err := xml.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("xml.Marshal() error = %v", err)
+	}
+
+	for _, expected := range []string{
+		`<response><data><pair>USD/EUR</pair>`,
+		`</data><meta><api_version>2</api_version><request_id>req-1</request_id>`,
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf("xml.Marshal() = %s, want it to contain %s", output, expected)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package perf
+
+import "testing"
+
+// budgetRuns is the number of runs allocations are averaged over
+const budgetRuns = 100
+
+// AssertAllocs fails the test when fn allocates more than budget times per run on
+// average. Budgets leave about half the measured count as headroom: small changes fit,
+// doubling the allocations of a hot path does not.
+func AssertAllocs(tb testing.TB, name string, budget float64, fn func()) {
+	tb.Helper()
+
+	if allocs := testing.AllocsPerRun(budgetRuns, fn); allocs > budget {
+		tb.Errorf("%s allocates %.1f times per run, over its budget of %.0f", name, allocs, budget)
+	}
+}
@@ -0,0 +1,38 @@
+package perf
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAllocationBudgets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocation budgets are not checked in short mode")
+	}
+
+	ratesService := cachedRatesService(t)
+	engine := router(t)
+	erapi := provider("erapi")
+	body := providerBody(t, "erapi")
+	ctx := context.Background()
+
+	budgets := []struct {
+		name   string
+		budget float64
+		fn     func()
+	}{
+		{"rates cache hit", 1, func() { ratesService.GetRates(ctx, "USD") }},
+		{"filtered rates cache hit", 8, func() { ratesService.GetRatesForSymbols(ctx, "USD", []string{"EUR", "GBP", "JPY"}) }},
+		{"pair rate from cache", 2, func() { ratesService.GetPairRate(ctx, "EUR", "GBP") }},
+		{"convert", 1, func() { ratesService.Convert(ctx, "USD", "EUR", 100, "") }},
+		{"convert many", 2, func() { ratesService.ConvertMany(ctx, "USD", []string{"EUR", "GBP", "JPY", "CHF", "CAD"}, 100, "") }},
+		{"provider response parsing", 24, func() { erapi.ParseResponse(body, "USD") }},
+		{"middleware stack", 100, func() { serve(t, engine, "/health") }},
+		{"rates request", 120, func() { serve(t, engine, "/api/v1/rates/USD") }},
+	}
+	for _, tt := range budgets {
+		t.Run(tt.name, func(t *testing.T) {
+			AssertAllocs(t, tt.name, tt.budget, tt.fn)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package perf
+
+import (
+	"context"
+	"testing"
+)
+
+func BenchmarkRatesCacheHit(b *testing.B) {
+	ratesService := cachedRatesService(b)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ratesService.GetRates(ctx, "USD"); err != nil {
+			b.Fatalf("GetRates() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkRatesCacheHitFiltered(b *testing.B) {
+	ratesService := cachedRatesService(b)
+	ctx := context.Background()
+	symbols := []string{"EUR", "GBP", "JPY"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ratesService.GetRatesForSymbols(ctx, "USD", symbols); err != nil {
+			b.Fatalf("GetRatesForSymbols() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkPairRateFromCache(b *testing.B) {
+	ratesService := cachedRatesService(b)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ratesService.GetPairRate(ctx, "EUR", "GBP"); err != nil {
+			b.Fatalf("GetPairRate() error = %v", err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package perf
+
+import (
+	"context"
+	"testing"
+)
+
+func BenchmarkConvert(b *testing.B) {
+	ratesService := cachedRatesService(b)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ratesService.Convert(ctx, "USD", "EUR", 100, ""); err != nil {
+			b.Fatalf("Convert() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkConvertMany(b *testing.B) {
+	ratesService := cachedRatesService(b)
+	ctx := context.Background()
+	targets := []string{"EUR", "GBP", "JPY", "CHF", "CAD"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ratesService.ConvertMany(ctx, "USD", targets, 100, ""); err != nil {
+			b.Fatalf("ConvertMany() error = %v", err)
+		}
+	}
+}
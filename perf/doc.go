@@ -0,0 +1,11 @@
+// Package perf holds the benchmarks of the request hot paths - the rates cache,
+// provider response parsing, conversion math, the middleware stack and the rate
+// limiter under 10k concurrent clients - and the allocation budgets they are held to.
+//
+// Run the benchmarks with CPU and memory profiles:
+//
+//	go test ./perf -run '^$' -bench . -benchmem -cpuprofile cpu.out -memprofile mem.out
+//
+// The budgets run with the regular tests, so a change that doubles the allocations per
+// request fails locally before it reaches review.
+package perf
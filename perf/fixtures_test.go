@@ -0,0 +1,80 @@
+package perf
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/service"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// quietLogger drops the request logs, keeping their formatting cost in the measurements
+func quietLogger() logger.Logger {
+	return logger.New("error")
+}
+
+// fullRates returns a rates table of base quoting every supported currency, the size of
+// a real provider response
+func fullRates(base string) models.RatesResponse {
+	rates := make(models.RateTable)
+	for index, supported := range currency.All() {
+		if supported.Code != base {
+			rates[supported.Code] = 0.5 + float64(index)/100
+		}
+	}
+	return models.RatesResponse{Base: base, Rates: rates, PublishedAt: time.Now().Unix()}
+}
+
+// cachedRatesService returns a rates service whose cache holds the complete USD table.
+// It has no providers, so any cache miss fails instead of reaching the network.
+func cachedRatesService(tb testing.TB) *service.RatesService {
+	tb.Helper()
+
+	cfg := testutils.MockConfig()
+	cfg.ExchangeRateProviders = nil
+	cfg.RatesCacheTTL = time.Hour
+	ratesService := service.NewRatesService(cfg, quietLogger())
+	tb.Cleanup(func() { ratesService.Close() })
+
+	if _, err := ratesService.PushRates(context.Background(), "perf", fullRates("USD")); err != nil {
+		tb.Fatalf("PushRates() error = %v", err)
+	}
+	if _, err := ratesService.GetRates(context.Background(), "USD"); err != nil {
+		tb.Fatalf("GetRates() from cache error = %v", err)
+	}
+	return ratesService
+}
+
+// providerBody returns the JSON body the named provider answers with for the USD table
+func providerBody(tb testing.TB, name string) []byte {
+	tb.Helper()
+
+	rates := fullRates("USD").Rates
+	var payload any
+	switch name {
+	case "erapi":
+		payload = map[string]any{"result": "success", "base_code": "USD", "time_last_update_unix": 1700000000, "rates": rates}
+	case "openexchangerates":
+		payload = map[string]any{"base": "USD", "timestamp": 1700000000, "rates": rates}
+	case "frankfurter":
+		payload = map[string]any{"base": "USD", "date": "2023-11-14", "rates": rates}
+	default:
+		tb.Fatalf("no body for provider %s", name)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		tb.Fatalf("json.Marshal() error = %v", err)
+	}
+	return body
+}
+
+// provider returns an HTTP provider of the named API, used for parsing only
+func provider(name string) *service.HTTPExchangeRateProvider {
+	return service.NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: name, Enabled: true}, quietLogger())
+}
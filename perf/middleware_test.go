@@ -0,0 +1,52 @@
+package perf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/dalfonso89/currency-exchange-service/api"
+)
+
+// router returns the service's router, with every middleware of a default deployment,
+// answering from a warm rates cache
+func router(tb testing.TB) *gin.Engine {
+	tb.Helper()
+	handlers := api.NewHandlers(api.HandlerConfig{Logger: quietLogger(), RatesService: cachedRatesService(tb)})
+	return handlers.SetupRoutes(api.RouterOptions{})
+}
+
+// serve sends a GET request through the router and checks its status
+func serve(tb testing.TB, engine *gin.Engine, target string) {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if recorder.Code != http.StatusOK {
+		tb.Fatalf("GET %s status code = %d: %s", target, recorder.Code, recorder.Body.String())
+	}
+}
+
+func BenchmarkMiddlewareStack(b *testing.B) {
+	engine := router(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		serve(b, engine, "/health")
+	}
+}
+
+func BenchmarkRatesRequest(b *testing.B) {
+	for _, target := range []string{"/api/v1/rates/USD", "/api/v1/rates/USD?symbols=EUR,GBP", "/api/v1/convert?from=USD&to=EUR&amount=100"} {
+		b.Run(target, func(b *testing.B) {
+			engine := router(b)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				serve(b, engine, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package perf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/service"
+)
+
+func BenchmarkParseResponse(b *testing.B) {
+	for _, name := range []string{"erapi", "openexchangerates", "frankfurter"} {
+		b.Run(name, func(b *testing.B) {
+			provider := provider(name)
+			body := providerBody(b, name)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := provider.ParseResponse(body, "USD"); err != nil {
+					b.Fatalf("ParseResponse() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProviderFetch(b *testing.B) {
+	body := providerBody(b, "openexchangerates")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	provider := service.NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "openexchangerates", BaseURL: server.URL, Enabled: true}, quietLogger())
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := provider.GetRates(ctx, "USD"); err != nil {
+			b.Fatalf("GetRates() error = %v", err)
+		}
+	}
+}
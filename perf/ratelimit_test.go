@@ -0,0 +1,46 @@
+package perf
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/ratelimit"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// BenchmarkRateLimiter measures rate limit checks from concurrent requests, spread over
+// 10k clients whose buckets already exist, and all from one client
+func BenchmarkRateLimiter(b *testing.B) {
+	for _, clients := range []int{10000, 1} {
+		b.Run(fmt.Sprintf("clients=%d", clients), func(b *testing.B) {
+			cfg := testutils.MockConfig()
+			cfg.RateLimitEnabled = true
+			cfg.RateLimitRequests = 1 << 30
+			cfg.RateLimitBurst = 1 << 30
+			cfg.RateLimitWindow = time.Minute
+			limiter := ratelimit.NewLimiter(cfg, quietLogger())
+			defer limiter.Stop()
+
+			keys := make([]string, clients)
+			for index := range keys {
+				keys[index] = fmt.Sprintf("10.%d.%d.%d", index>>16&255, index>>8&255, index&255)
+				limiter.Allow(keys[index])
+			}
+
+			// 10k concurrent requests at GOMAXPROCS=1, more with more processors
+			b.SetParallelism(10000)
+			var next atomic.Int64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				index := int(next.Add(1))
+				for pb.Next() {
+					limiter.Allow(keys[index%clients])
+					index++
+				}
+			})
+		})
+	}
+}
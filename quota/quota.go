@@ -0,0 +1,250 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Quota periods
+const (
+	Daily   = "daily"   // The UTC day
+	Monthly = "monthly" // The UTC calendar month
+)
+
+// flushTimeout bounds a single flush to the store
+const flushTimeout = 30 * time.Second
+
+// Store persists the daily request counts of each key, so restarts do not reset quotas
+type Store interface {
+	RecordQuotaUsage(ctx context.Context, counts []models.QuotaCount) error
+	QuotaUsage(ctx context.Context, since time.Time) ([]models.QuotaCount, error)
+}
+
+// Limits are the request quotas of a key; 0 means unlimited
+type Limits struct {
+	Daily   int
+	Monthly int
+}
+
+// Status is a key's standing against one of its quotas
+type Status struct {
+	Period    string // Daily or Monthly
+	Limit     int
+	Remaining int
+	Reset     time.Time // When the period ends and the count starts over
+}
+
+// counter holds a key's requests in the current day and month, and the limits of its
+// last request
+type counter struct {
+	day     time.Time
+	daily   int
+	month   time.Time
+	monthly int
+	limits  Limits
+}
+
+// Consumption is how much of one of its quotas a key has used
+type Consumption struct {
+	KeyID  string
+	Period string // Daily or Monthly
+	Used   int
+	Limit  int
+}
+
+// countKey identifies the requests of one key on one day
+type countKey struct {
+	day   time.Time
+	keyID string
+}
+
+// Manager counts the requests of each API key against its daily and monthly quotas.
+// Counts are kept in memory and periodically flushed to the store, which they are loaded
+// from at startup. Without a store, counts start over when the service restarts.
+type Manager struct {
+	store  Store
+	logger logger.Logger
+
+	mutex    sync.Mutex
+	counters map[string]*counter
+	pending  map[countKey]int64
+}
+
+// NewManager creates a manager flushing to store, or keeping counts in memory when store is nil
+func NewManager(store Store, logger logger.Logger) *Manager {
+	return &Manager{
+		store:    store,
+		logger:   logger,
+		counters: make(map[string]*counter),
+		pending:  make(map[countKey]int64),
+	}
+}
+
+// Load restores the counts of the current month from the store
+func (manager *Manager) Load(ctx context.Context, now time.Time) error {
+	if manager.store == nil {
+		return nil
+	}
+
+	counts, err := manager.store.QuotaUsage(ctx, monthStart(now))
+	if err != nil {
+		return err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	for _, count := range counts {
+		keyCounter := manager.counter(count.KeyID, now)
+		keyCounter.monthly += int(count.Requests)
+		if count.Day.Equal(keyCounter.day) {
+			keyCounter.daily += int(count.Requests)
+		}
+	}
+	return nil
+}
+
+// Allow counts a request made with keyID unless one of its quotas is exhausted. It
+// returns the key's standing against each of its quotas and whether the request is
+// allowed; rejected requests are not counted.
+func (manager *Manager) Allow(keyID string, limits Limits, now time.Time) ([]Status, bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	keyCounter := manager.counter(keyID, now)
+	keyCounter.limits = limits
+	statuses := make([]Status, 0, 2)
+	if limits.Daily > 0 {
+		statuses = append(statuses, Status{Period: Daily, Limit: limits.Daily, Remaining: limits.Daily - keyCounter.daily, Reset: keyCounter.day.AddDate(0, 0, 1)})
+	}
+	if limits.Monthly > 0 {
+		statuses = append(statuses, Status{Period: Monthly, Limit: limits.Monthly, Remaining: limits.Monthly - keyCounter.monthly, Reset: keyCounter.month.AddDate(0, 1, 0)})
+	}
+
+	allowed := true
+	for i := range statuses {
+		if statuses[i].Remaining <= 0 {
+			statuses[i].Remaining = 0
+			allowed = false
+		}
+	}
+	if !allowed {
+		return statuses, false
+	}
+
+	keyCounter.daily++
+	keyCounter.monthly++
+	if manager.store != nil {
+		manager.pending[countKey{day: keyCounter.day, keyID: keyID}]++
+	}
+	for i := range statuses {
+		statuses[i].Remaining--
+	}
+	return statuses, true
+}
+
+// Consumption returns the use of every quota of the keys seen since startup, against the
+// limits of their last request
+func (manager *Manager) Consumption(now time.Time) []Consumption {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	consumption := []Consumption{}
+	for keyID := range manager.counters {
+		keyCounter := manager.counter(keyID, now)
+		if keyCounter.limits.Daily > 0 {
+			consumption = append(consumption, Consumption{KeyID: keyID, Period: Daily, Used: keyCounter.daily, Limit: keyCounter.limits.Daily})
+		}
+		if keyCounter.limits.Monthly > 0 {
+			consumption = append(consumption, Consumption{KeyID: keyID, Period: Monthly, Used: keyCounter.monthly, Limit: keyCounter.limits.Monthly})
+		}
+	}
+	return consumption
+}
+
+// Flush writes the pending counts to the store. Counts that fail to flush are kept and
+// retried with the next flush.
+func (manager *Manager) Flush(ctx context.Context) error {
+	if manager.store == nil {
+		return nil
+	}
+
+	manager.mutex.Lock()
+	flushing := manager.pending
+	manager.pending = make(map[countKey]int64)
+	manager.mutex.Unlock()
+
+	if len(flushing) == 0 {
+		return nil
+	}
+	counts := make([]models.QuotaCount, 0, len(flushing))
+	for key, requests := range flushing {
+		counts = append(counts, models.QuotaCount{Day: key.day, KeyID: key.keyID, Requests: requests})
+	}
+	if err := manager.store.RecordQuotaUsage(ctx, counts); err != nil {
+		manager.mutex.Lock()
+		for key, requests := range flushing {
+			manager.pending[key] += requests
+		}
+		manager.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Start flushes the pending counts every interval until ctx is cancelled
+func (manager *Manager) Start(ctx context.Context, interval time.Duration) {
+	if manager.store == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				flushCtx, cancel := context.WithTimeout(ctx, flushTimeout)
+				if err := manager.Flush(flushCtx); err != nil {
+					manager.logger.Errorf("Quota usage flush failed: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// counter returns the counter of keyID, starting its counts over when the day or month
+// has changed (caller holds the lock)
+func (manager *Manager) counter(keyID string, now time.Time) *counter {
+	day, month := dayStart(now), monthStart(now)
+	keyCounter, found := manager.counters[keyID]
+	if !found {
+		keyCounter = &counter{day: day, month: month}
+		manager.counters[keyID] = keyCounter
+	}
+	if !keyCounter.day.Equal(day) {
+		keyCounter.day, keyCounter.daily = day, 0
+	}
+	if !keyCounter.month.Equal(month) {
+		keyCounter.month, keyCounter.monthly = month, 0
+	}
+	return keyCounter
+}
+
+// dayStart returns the start of the UTC day of t
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// monthStart returns the start of the UTC calendar month of t
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
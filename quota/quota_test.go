@@ -0,0 +1,130 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// memoryStore keeps flushed counts, failing every flush while fail is set
+type memoryStore struct {
+	counts []models.QuotaCount
+	fail   bool
+}
+
+func (store *memoryStore) RecordQuotaUsage(ctx context.Context, counts []models.QuotaCount) error {
+	if store.fail {
+		return errors.New("database unavailable")
+	}
+	store.counts = append(store.counts, counts...)
+	return nil
+}
+
+func (store *memoryStore) QuotaUsage(ctx context.Context, since time.Time) ([]models.QuotaCount, error) {
+	matching := []models.QuotaCount{}
+	for _, count := range store.counts {
+		if !count.Day.Before(since) {
+			matching = append(matching, count)
+		}
+	}
+	return matching, nil
+}
+
+func TestManager_Allow(t *testing.T) {
+	now := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	manager := NewManager(nil, testutils.MockLogger())
+	limits := Limits{Daily: 2, Monthly: 3}
+
+	for i := 0; i < 2; i++ {
+		if _, allowed := manager.Allow("k1", limits, now); !allowed {
+			t.Fatalf("Allow() request %d rejected within the quota", i+1)
+		}
+	}
+	statuses, allowed := manager.Allow("k1", limits, now)
+	if allowed {
+		t.Fatal("Allow() allowed a request beyond the daily quota")
+	}
+	if len(statuses) != 2 || statuses[0].Period != Daily || statuses[0].Remaining != 0 || !statuses[0].Reset.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Allow() daily status = %+v, want none remaining until the next day", statuses)
+	}
+	if statuses[1].Period != Monthly || statuses[1].Remaining != 1 {
+		t.Errorf("Allow() monthly status = %+v, want one remaining", statuses[1])
+	}
+
+	// Other keys have their own counts, and unlimited keys are never rejected
+	if _, allowed := manager.Allow("k2", limits, now); !allowed {
+		t.Error("Allow() rejected another key")
+	}
+	if statuses, allowed := manager.Allow("k1", Limits{}, now); !allowed || len(statuses) != 0 {
+		t.Errorf("Allow() without limits = %+v, %v, want allowed without statuses", statuses, allowed)
+	}
+
+	// The next day starts a new month too
+	if statuses, allowed := manager.Allow("k1", limits, now.Add(2*time.Hour)); !allowed || statuses[0].Remaining != 1 || statuses[1].Remaining != 2 {
+		t.Errorf("Allow() the next day = %+v, %v, want fresh daily and monthly counts", statuses, allowed)
+	}
+}
+
+func TestManager_FlushAndLoad(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	store := &memoryStore{
+		fail: true,
+		counts: []models.QuotaCount{
+			{Day: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), KeyID: "k1", Requests: 50},
+			{Day: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), KeyID: "k1", Requests: 5},
+		},
+	}
+	manager := NewManager(store, testutils.MockLogger())
+	manager.Allow("k1", Limits{Daily: 10}, now)
+
+	if err := manager.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() expected error from the store")
+	}
+	manager.Allow("k1", Limits{Daily: 10}, now)
+
+	// The failed flush is retried with the requests counted since
+	store.fail = false
+	if err := manager.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if flushed := store.counts[len(store.counts)-1]; len(store.counts) != 3 || flushed.KeyID != "k1" || flushed.Requests != 2 || !flushed.Day.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("flushed counts = %+v, want both requests in one count", store.counts)
+	}
+
+	// A restarted manager continues from the stored counts of the month
+	restarted := NewManager(store, testutils.MockLogger())
+	if err := restarted.Load(context.Background(), now); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	statuses, allowed := restarted.Allow("k1", Limits{Daily: 10, Monthly: 100}, now)
+	if !allowed || statuses[0].Remaining != 7 || statuses[1].Remaining != 92 {
+		t.Errorf("Allow() after Load() = %+v, want 7 daily and 92 monthly requests remaining", statuses)
+	}
+}
+
+func TestManager_Consumption(t *testing.T) {
+	now := time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)
+	manager := NewManager(nil, testutils.MockLogger())
+	manager.Allow("k1", Limits{Daily: 4, Monthly: 10}, now)
+	manager.Allow("k1", Limits{Daily: 4, Monthly: 10}, now)
+	manager.Allow("k2", Limits{Monthly: 100}, now)
+
+	got := map[string]Consumption{}
+	for _, consumption := range manager.Consumption(now) {
+		got[consumption.KeyID+" "+consumption.Period] = consumption
+	}
+	if len(got) != 3 || got["k1 daily"].Used != 2 || got["k1 daily"].Limit != 4 || got["k1 monthly"].Limit != 10 || got["k2 monthly"].Used != 1 {
+		t.Errorf("Consumption() = %+v", got)
+	}
+
+	// Counts start over with the next day and month
+	for _, consumption := range manager.Consumption(now.Add(time.Hour)) {
+		if consumption.Used != 0 {
+			t.Errorf("Consumption() in the next month = %+v, want no use", consumption)
+		}
+	}
+}
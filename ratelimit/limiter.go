@@ -1,46 +1,91 @@
 package ratelimit
 
 import (
+	"container/list"
 	"fmt"
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dalfonso89/currency-exchange-service/clock"
 	"github.com/dalfonso89/currency-exchange-service/config"
 	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
 )
 
-// Limiter implements a token bucket rate limiter per IP
+// ShadowHeader marks responses to requests shadow mode let through over the limits
+const ShadowHeader = "X-RateLimit-Shadow"
+
+// defaultCleanupInterval is how often idle buckets are removed when no interval is configured
+const defaultCleanupInterval = 2 * time.Minute
+
+// Limiter implements a token bucket rate limiter per IP. At most maxClients buckets are
+// kept: a new client beyond the cap evicts the least recently seen one, so floods of
+// spoofed addresses cannot grow memory between cleanups.
+//
+// Requests of clients with a bucket take no lock shared with other clients: the bucket
+// is looked up in a sync.Map and locks only itself. The recency list is kept under a lock
+// taken for new clients, evictions and cleanups only, so seeing a client marks its bucket
+// as touched rather than moving it to the front of the list.
 type Limiter struct {
 	Configuration *config.Config
 	logger        logger.Logger
+	clock         clock.Clock // Time source of refills and idle cleanup, shared with the buckets
+
+	// Map of IP -> *clientBucket, and the buckets from most to least recently positioned,
+	// guarded with the counts by recencyMutex
+	clientBuckets sync.Map
+	recency       *list.List
+	maxClients    int // 0 = unlimited
+	evicted       int64
+	expired       int64
+	restored      int
+	recencyMutex  sync.Mutex
 
-	// Map of IP -> token bucket
-	clientBuckets map[string]*TokenBucket
-	bucketsMutex  sync.RWMutex
+	shadowBlocked int64 // Requests over the limits let through in shadow mode (atomic)
+
+	store Store // Saves buckets across restarts; nil = buckets start full after a restart
 
 	// Cleanup goroutine control
 	cleanupTicker *time.Ticker
 	stopCleanup   chan struct{}
 }
 
+// clientBucket is the bucket of one client, as held in the recency list
+type clientBucket struct {
+	key      string
+	bucket   *TokenBucket
+	element  *list.Element // Guarded by the limiter's recencyMutex
+	lastSeen atomic.Int64  // Unix nanoseconds
+	touched  atomic.Bool   // Seen since it was last moved to the front of the recency list
+}
+
 // TokenBucket represents a token bucket for rate limiting
 type TokenBucket struct {
+	mutex        sync.Mutex
 	capacity     int
 	tokens       int
 	lastRefill   time.Time
 	refillRate   int
 	refillPeriod time.Duration
+	clock        clock.Clock // nil = system clock
 }
 
 // NewLimiter creates a new rate limiter
 func NewLimiter(configuration *config.Config, logger logger.Logger) *Limiter {
+	cleanupInterval := configuration.RateLimitCleanupInterval
+	if cleanupInterval <= 0 {
+		cleanupInterval = defaultCleanupInterval
+	}
 	rateLimiter := &Limiter{
 		Configuration: configuration,
 		logger:        logger,
-		clientBuckets: make(map[string]*TokenBucket),
-		cleanupTicker: time.NewTicker(2 * time.Minute),
+		clock:         clock.System,
+		recency:       list.New(),
+		maxClients:    max(configuration.RateLimitMaxClients, 0),
+		cleanupTicker: time.NewTicker(cleanupInterval),
 		stopCleanup:   make(chan struct{}),
 	}
 
@@ -52,27 +97,122 @@ func NewLimiter(configuration *config.Config, logger logger.Logger) *Limiter {
 
 // Allow checks if a request from the given IP is allowed
 func (rateLimiter *Limiter) Allow(clientIP string) bool {
+	return rateLimiter.AllowWithLimits(clientIP, rateLimiter.Configuration.RateLimitRequests, rateLimiter.Configuration.RateLimitBurst)
+}
+
+// AllowWithLimits checks if a request for the given key is allowed using custom limits,
+// e.g. the per-tenant limits of an API key
+func (rateLimiter *Limiter) AllowWithLimits(key string, requests, burst int) bool {
+	allowed, _ := rateLimiter.Check(key, requests, burst)
+	return allowed
+}
+
+// Check is AllowWithLimits reporting whether the request is over the limits but allowed
+// by shadow mode, which logs and counts such requests instead of rejecting them
+func (rateLimiter *Limiter) Check(key string, requests, burst int) (allowed, shadowBlocked bool) {
 	if !rateLimiter.Configuration.RateLimitEnabled {
-		return true
+		return true, false
+	}
+	if rateLimiter.take(key, requests, burst) {
+		return true, false
+	}
+	if !rateLimiter.Configuration.RateLimitShadow {
+		return false, false
+	}
+
+	atomic.AddInt64(&rateLimiter.shadowBlocked, 1)
+	rateLimiter.logger.Infof("Rate limit shadow mode: would have blocked %s", key)
+	return true, true
+}
+
+// take takes a token from the key's bucket, creating the bucket with the limits
+func (rateLimiter *Limiter) take(key string, requests, burst int) bool {
+	now := rateLimiter.clock.Now()
+	if value, found := rateLimiter.clientBuckets.Load(key); found {
+		return value.(*clientBucket).allow(now)
 	}
 
-	rateLimiter.bucketsMutex.Lock()
-	defer rateLimiter.bucketsMutex.Unlock()
+	rateLimiter.recencyMutex.Lock()
+	client, created := rateLimiter.add(key, requests, burst, now)
+	rateLimiter.recencyMutex.Unlock()
+	if created {
+		return client.bucket.Allow()
+	}
+	return client.allow(now)
+}
+
+// add returns the key's bucket, creating it when no concurrent request did, and evicting
+// the least recently seen client at the cap (caller holds the recency lock)
+func (rateLimiter *Limiter) add(key string, requests, burst int, now time.Time) (*clientBucket, bool) {
+	if value, found := rateLimiter.clientBuckets.Load(key); found {
+		return value.(*clientBucket), false
+	}
 
-	// Get or create bucket for this IP
-	bucket, exists := rateLimiter.clientBuckets[clientIP]
-	if !exists {
-		bucket = &TokenBucket{
-			capacity:     rateLimiter.Configuration.RateLimitBurst,
-			tokens:       rateLimiter.Configuration.RateLimitBurst,
-			lastRefill:   time.Now(),
-			refillRate:   rateLimiter.Configuration.RateLimitRequests,
+	if rateLimiter.maxClients > 0 && rateLimiter.recency.Len() >= rateLimiter.maxClients {
+		rateLimiter.remove(rateLimiter.leastRecentlySeen())
+		rateLimiter.evicted++
+	}
+	client := &clientBucket{
+		key: key,
+		bucket: &TokenBucket{
+			capacity:     burst,
+			tokens:       burst,
+			lastRefill:   now,
+			refillRate:   requests,
 			refillPeriod: rateLimiter.Configuration.RateLimitWindow,
-		}
-		rateLimiter.clientBuckets[clientIP] = bucket
+			clock:        rateLimiter.clock,
+		},
 	}
+	client.lastSeen.Store(now.UnixNano())
+	client.element = rateLimiter.recency.PushFront(client)
+	rateLimiter.clientBuckets.Store(key, client)
+	return client, true
+}
+
+// leastRecentlySeen returns the element of the client to evict. Touched clients at the
+// back of the recency list get a second chance at the front, so the first untouched one
+// has not been seen since it was positioned, before the clients ahead of it. (caller holds
+// the recency lock)
+func (rateLimiter *Limiter) leastRecentlySeen() *list.Element {
+	element := rateLimiter.recency.Back()
+	for moves := rateLimiter.recency.Len(); moves > 0 && element.Value.(*clientBucket).touched.Swap(false); moves-- {
+		rateLimiter.recency.MoveToFront(element)
+		element = rateLimiter.recency.Back()
+	}
+	return element
+}
+
+// remove drops a client's bucket (caller holds the recency lock)
+func (rateLimiter *Limiter) remove(element *list.Element) {
+	rateLimiter.recency.Remove(element)
+	rateLimiter.clientBuckets.Delete(element.Value.(*clientBucket).key)
+}
 
-	return bucket.Allow()
+// allow takes a token from the client's bucket, marking the client as seen
+func (client *clientBucket) allow(now time.Time) bool {
+	client.lastSeen.Store(now.UnixNano())
+	if !client.touched.Load() {
+		client.touched.Store(true)
+	}
+	return client.bucket.Allow()
+}
+
+// Stats reports the client buckets held and how many were removed
+func (rateLimiter *Limiter) Stats() models.RateLimiterStats {
+	rateLimiter.recencyMutex.Lock()
+	defer rateLimiter.recencyMutex.Unlock()
+
+	return models.RateLimiterStats{
+		Buckets:    rateLimiter.recency.Len(),
+		MaxClients: rateLimiter.maxClients,
+		Evicted:    rateLimiter.evicted,
+		Expired:    rateLimiter.expired,
+
+		Shadow:        rateLimiter.Configuration.RateLimitShadow,
+		ShadowBlocked: atomic.LoadInt64(&rateLimiter.shadowBlocked),
+
+		Restored: rateLimiter.restored,
+	}
 }
 
 // Middleware returns an HTTP middleware for rate limiting
@@ -81,11 +221,15 @@ func (rateLimiter *Limiter) Middleware() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
 			clientIP := rateLimiter.GetClientIP(request)
 
-			if !rateLimiter.Allow(clientIP) {
+			allowed, shadowBlocked := rateLimiter.Check(clientIP, rateLimiter.Configuration.RateLimitRequests, rateLimiter.Configuration.RateLimitBurst)
+			if shadowBlocked {
+				responseWriter.Header().Set(ShadowHeader, "would-block")
+			}
+			if !allowed {
 				rateLimiter.logger.Warnf("Rate limit exceeded for IP: %s", clientIP)
 				responseWriter.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", rateLimiter.Configuration.RateLimitRequests))
 				responseWriter.Header().Set("X-RateLimit-Remaining", "0")
-				responseWriter.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(rateLimiter.Configuration.RateLimitWindow).Unix()))
+				responseWriter.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", rateLimiter.clock.Now().Add(rateLimiter.Configuration.RateLimitWindow).Unix()))
 				http.Error(responseWriter, "Rate limit exceeded", http.StatusTooManyRequests)
 				return
 			}
@@ -125,20 +269,22 @@ func (rateLimiter *Limiter) GetClientIP(request *http.Request) string {
 	return clientIP
 }
 
-// cleanup removes old buckets to prevent memory leaks
+// cleanup removes idle buckets to prevent memory leaks
 func (rateLimiter *Limiter) cleanup() {
+	var evicted int64
 	for {
 		select {
 		case <-rateLimiter.cleanupTicker.C:
-			rateLimiter.bucketsMutex.Lock()
-			currentTime := time.Now()
-			for clientIP, bucket := range rateLimiter.clientBuckets {
-				// Remove buckets that haven't been refilled for a long time
-				if currentTime.Sub(bucket.lastRefill) > bucket.refillPeriod*2 {
-					delete(rateLimiter.clientBuckets, clientIP)
-				}
+			rateLimiter.removeIdle(rateLimiter.clock.Now())
+
+			// Evictions mean the cap is too low for the traffic, or a flood is under way
+			rateLimiter.recencyMutex.Lock()
+			newlyEvicted := rateLimiter.evicted - evicted
+			evicted = rateLimiter.evicted
+			rateLimiter.recencyMutex.Unlock()
+			if newlyEvicted > 0 {
+				rateLimiter.logger.Warnf("Rate limiter evicted %d client buckets at its limit of %d clients", newlyEvicted, rateLimiter.maxClients)
 			}
-			rateLimiter.bucketsMutex.Unlock()
 		case <-rateLimiter.stopCleanup:
 			rateLimiter.cleanupTicker.Stop()
 			return
@@ -146,6 +292,29 @@ func (rateLimiter *Limiter) cleanup() {
 	}
 }
 
+// removeIdle removes the buckets not seen for two rate limit windows. The least recently
+// seen buckets are at the back of the recency list; touched buckets found there are moved
+// to the front, so mostly idle buckets are visited.
+func (rateLimiter *Limiter) removeIdle(now time.Time) {
+	rateLimiter.recencyMutex.Lock()
+	defer rateLimiter.recencyMutex.Unlock()
+
+	for moves := rateLimiter.recency.Len(); rateLimiter.recency.Len() > 0; {
+		element := rateLimiter.recency.Back()
+		client := element.Value.(*clientBucket)
+		if now.Sub(time.Unix(0, client.lastSeen.Load())) > client.bucket.refillPeriod*2 {
+			rateLimiter.remove(element)
+			rateLimiter.expired++
+			continue
+		}
+		if moves == 0 || !client.touched.Swap(false) {
+			return
+		}
+		rateLimiter.recency.MoveToFront(element)
+		moves--
+	}
+}
+
 // Stop stops the cleanup goroutine
 func (rateLimiter *Limiter) Stop() {
 	close(rateLimiter.stopCleanup)
@@ -153,7 +322,10 @@ func (rateLimiter *Limiter) Stop() {
 
 // Allow checks if a token is available in the bucket
 func (tokenBucket *TokenBucket) Allow() bool {
-	now := time.Now()
+	tokenBucket.mutex.Lock()
+	defer tokenBucket.mutex.Unlock()
+
+	now := clock.Or(tokenBucket.clock).Now()
 
 	// Refill tokens based on time elapsed
 	timeElapsed := now.Sub(tokenBucket.lastRefill)
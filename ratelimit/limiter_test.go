@@ -1,8 +1,11 @@
 package ratelimit
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,8 +27,8 @@ func TestNewLimiter(t *testing.T) {
 	if limiter.logger != logger {
 		t.Errorf("NewLimiter() logger = %v, want %v", limiter.logger, logger)
 	}
-	if limiter.clientBuckets == nil {
-		t.Errorf("NewLimiter() clientBuckets is nil")
+	if limiter.recency == nil {
+		t.Errorf("NewLimiter() recency list is nil")
 	}
 	if limiter.cleanupTicker == nil {
 		t.Errorf("NewLimiter() cleanupTicker is nil")
@@ -309,6 +312,44 @@ func TestTokenBucket_Allow(t *testing.T) {
 	}
 }
 
+func TestTokenBucket_Refill(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	bucket := &TokenBucket{
+		capacity:     2,
+		tokens:       2,
+		lastRefill:   fakeClock.Now(),
+		refillRate:   1,
+		refillPeriod: time.Minute,
+		clock:        fakeClock,
+	}
+
+	if !bucket.Allow() || !bucket.Allow() {
+		t.Fatal("Allow() within the capacity should be allowed")
+	}
+	if bucket.Allow() {
+		t.Fatal("Allow() of an empty bucket should be denied")
+	}
+
+	// Tokens are added per whole refill period
+	fakeClock.Advance(59 * time.Second)
+	if bucket.Allow() {
+		t.Error("Allow() before a refill period passed should be denied")
+	}
+	fakeClock.Advance(time.Second)
+	if !bucket.Allow() {
+		t.Error("Allow() after a refill period should be allowed")
+	}
+	if bucket.Allow() {
+		t.Error("Allow() should be denied once the refilled token is spent")
+	}
+
+	// Refills never exceed the capacity
+	fakeClock.Advance(10 * time.Minute)
+	if !bucket.Allow() || !bucket.Allow() || bucket.Allow() {
+		t.Error("Allow() after a long pause should allow only the capacity")
+	}
+}
+
 func TestLimiter_Stop(t *testing.T) {
 	cfg := testutils.MockConfig()
 	logger := testutils.MockLogger()
@@ -320,3 +361,129 @@ func TestLimiter_Stop(t *testing.T) {
 	// Give cleanup goroutine time to stop
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestLimiter_MaxClients(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.RateLimitBurst = 1
+	cfg.RateLimitMaxClients = 2
+	limiter := NewLimiter(cfg, testutils.MockLogger())
+	defer limiter.Stop()
+
+	limiter.Allow("10.0.0.1")
+	limiter.Allow("10.0.0.2")
+	// Seeing 10.0.0.1 again makes 10.0.0.2 the least recently seen client
+	if limiter.Allow("10.0.0.1") {
+		t.Fatal("Allow() beyond the burst should be denied")
+	}
+	limiter.Allow("10.0.0.3")
+
+	stats := limiter.Stats()
+	if stats.Buckets != 2 || stats.MaxClients != 2 || stats.Evicted != 1 {
+		t.Errorf("Stats() = %+v, want 2 buckets and 1 eviction", stats)
+	}
+	if _, found := limiter.clientBuckets.Load("10.0.0.2"); found {
+		t.Error("the least recently seen client should have been evicted")
+	}
+	// The recently seen client keeps its spent bucket
+	if limiter.Allow("10.0.0.1") {
+		t.Error("Allow() for a client kept at the cap should still be denied")
+	}
+}
+
+func TestLimiter_ConcurrentClients(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.RateLimitBurst = 10
+	cfg.RateLimitRequests = 1
+	cfg.RateLimitWindow = time.Hour
+	cfg.RateLimitMaxClients = 50
+	limiter := NewLimiter(cfg, testutils.MockLogger())
+	defer limiter.Stop()
+
+	// Every client spends its burst from several goroutines, racing to create its bucket
+	var allowed sync.Map
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for client := 0; client < 40; client++ {
+				key := fmt.Sprintf("10.0.1.%d", client)
+				for request := 0; request < 5; request++ {
+					if limiter.Allow(key) {
+						count, _ := allowed.LoadOrStore(key, new(atomic.Int64))
+						count.(*atomic.Int64).Add(1)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for client := 0; client < 40; client++ {
+		key := fmt.Sprintf("10.0.1.%d", client)
+		if count, _ := allowed.Load(key); count == nil || count.(*atomic.Int64).Load() != 10 {
+			t.Errorf("client %s allowed %v requests, want its burst of 10", key, count)
+		}
+	}
+	if stats := limiter.Stats(); stats.Buckets != 40 || stats.Evicted != 0 {
+		t.Errorf("Stats() = %+v, want one bucket per client", stats)
+	}
+}
+
+func TestLimiter_ShadowMode(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.RateLimitEnabled = true
+	cfg.RateLimitBurst = 1
+	cfg.RateLimitRequests = 1
+	cfg.RateLimitWindow = time.Minute
+	cfg.RateLimitShadow = true
+	limiter := NewLimiter(cfg, testutils.MockLogger())
+	defer limiter.Stop()
+
+	handler := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for i, wantShadowHeader := range []string{"", "would-block", "would-block"} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Header().Get(ShadowHeader) != wantShadowHeader {
+			t.Errorf("request %d = %v with %s %q, want %v with %q", i, w.Code, ShadowHeader, w.Header().Get(ShadowHeader), http.StatusOK, wantShadowHeader)
+		}
+	}
+
+	if stats := limiter.Stats(); !stats.Shadow || stats.ShadowBlocked != 2 {
+		t.Errorf("Stats() = %+v, want 2 requests blocked in shadow mode", stats)
+	}
+
+	// Enforcing the limits rejects the requests shadow mode let through
+	cfg.RateLimitShadow = false
+	if allowed, shadowBlocked := limiter.Check("192.168.1.1", 1, 1); allowed || shadowBlocked {
+		t.Errorf("Check() enforcing = %v, %v, want rejected", allowed, shadowBlocked)
+	}
+}
+
+func TestLimiter_RemoveIdle(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.RateLimitCleanupInterval = time.Hour
+	limiter := NewLimiter(cfg, testutils.MockLogger())
+	defer limiter.Stop()
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	limiter.clock = fakeClock
+
+	limiter.Allow("10.0.0.1")
+	fakeClock.Advance(3 * cfg.RateLimitWindow)
+	limiter.Allow("10.0.0.2")
+
+	limiter.removeIdle(fakeClock.Now())
+
+	stats := limiter.Stats()
+	if stats.Buckets != 1 || stats.Expired != 1 || stats.Evicted != 0 {
+		t.Errorf("Stats() = %+v, want 1 bucket and 1 expired", stats)
+	}
+	if _, found := limiter.clientBuckets.Load("10.0.0.2"); !found {
+		t.Error("the recently seen client should be kept")
+	}
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Store persists the buckets of rate limited clients, so a restart or rolling deploy
+// does not hand every client a fresh burst
+type Store interface {
+	SaveRateLimitBuckets(ctx context.Context, buckets []models.RateLimitBucket, expiredBefore time.Time) error
+	RateLimitBuckets(ctx context.Context, since time.Time) ([]models.RateLimitBucket, error)
+}
+
+// SetStore saves the buckets to store on Save and restores them from it on Load
+func (rateLimiter *Limiter) SetStore(store Store) {
+	rateLimiter.store = store
+}
+
+// Load restores the buckets seen within two rate limit windows, the idle time after
+// which the cleanup would have removed them, keeping the most recently seen at the cap.
+// Clients seen since startup keep their current bucket.
+func (rateLimiter *Limiter) Load(ctx context.Context) error {
+	if rateLimiter.store == nil {
+		return nil
+	}
+
+	buckets, err := rateLimiter.store.RateLimitBuckets(ctx, rateLimiter.clock.Now().Add(-2*rateLimiter.Configuration.RateLimitWindow))
+	if err != nil {
+		return err
+	}
+
+	rateLimiter.recencyMutex.Lock()
+	defer rateLimiter.recencyMutex.Unlock()
+	for _, saved := range buckets {
+		if rateLimiter.maxClients > 0 && rateLimiter.recency.Len() >= rateLimiter.maxClients {
+			break
+		}
+		if _, found := rateLimiter.clientBuckets.Load(saved.Key); found {
+			continue
+		}
+		client := &clientBucket{
+			key: saved.Key,
+			bucket: &TokenBucket{
+				capacity:     saved.Capacity,
+				tokens:       saved.Tokens,
+				lastRefill:   saved.LastRefill,
+				refillRate:   saved.RefillRate,
+				refillPeriod: rateLimiter.Configuration.RateLimitWindow,
+				clock:        rateLimiter.clock,
+			},
+		}
+		client.lastSeen.Store(saved.LastSeen.UnixNano())
+		client.element = rateLimiter.recency.PushBack(client)
+		rateLimiter.clientBuckets.Store(saved.Key, client)
+		rateLimiter.restored++
+	}
+	return nil
+}
+
+// Save writes the buckets with spent tokens to the store; full buckets would be created
+// the same on the next request. Buckets of clients idle for two windows are dropped from
+// the store.
+func (rateLimiter *Limiter) Save(ctx context.Context) error {
+	if rateLimiter.store == nil {
+		return nil
+	}
+
+	now := rateLimiter.clock.Now()
+	rateLimiter.recencyMutex.Lock()
+	buckets := make([]models.RateLimitBucket, 0, rateLimiter.recency.Len())
+	for element := rateLimiter.recency.Front(); element != nil; element = element.Next() {
+		client := element.Value.(*clientBucket)
+		client.bucket.mutex.Lock()
+		if client.bucket.tokens < client.bucket.capacity {
+			buckets = append(buckets, models.RateLimitBucket{
+				Key:        client.key,
+				Tokens:     client.bucket.tokens,
+				Capacity:   client.bucket.capacity,
+				RefillRate: client.bucket.refillRate,
+				LastRefill: client.bucket.lastRefill,
+				LastSeen:   time.Unix(0, client.lastSeen.Load()),
+			})
+		}
+		client.bucket.mutex.Unlock()
+	}
+	rateLimiter.recencyMutex.Unlock()
+
+	return rateLimiter.store.SaveRateLimitBuckets(ctx, buckets, now.Add(-2*rateLimiter.Configuration.RateLimitWindow))
+}
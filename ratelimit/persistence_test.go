@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// fakeStore keeps saved buckets by client key
+type fakeStore struct {
+	buckets       map[string]models.RateLimitBucket
+	expiredBefore time.Time
+}
+
+func (store *fakeStore) SaveRateLimitBuckets(ctx context.Context, buckets []models.RateLimitBucket, expiredBefore time.Time) error {
+	for _, bucket := range buckets {
+		store.buckets[bucket.Key] = bucket
+	}
+	store.expiredBefore = expiredBefore
+	return nil
+}
+
+func (store *fakeStore) RateLimitBuckets(ctx context.Context, since time.Time) ([]models.RateLimitBucket, error) {
+	buckets := []models.RateLimitBucket{}
+	for _, bucket := range store.buckets {
+		if !bucket.LastSeen.Before(since) {
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets, nil
+}
+
+func persistentLimiter(store Store, fakeClock *testutils.FakeClock) *Limiter {
+	cfg := testutils.MockConfig()
+	cfg.RateLimitEnabled = true
+	cfg.RateLimitBurst = 3
+	cfg.RateLimitRequests = 1
+	cfg.RateLimitWindow = time.Minute
+	limiter := NewLimiter(cfg, testutils.MockLogger())
+	limiter.clock = fakeClock
+	limiter.SetStore(store)
+	return limiter
+}
+
+func TestLimiter_SaveAndLoad(t *testing.T) {
+	store := &fakeStore{buckets: map[string]models.RateLimitBucket{}}
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+
+	before := persistentLimiter(store, fakeClock)
+	for i := 0; i < 3; i++ {
+		before.Allow("10.0.0.1")
+	}
+	before.Allow("10.0.0.2")
+	before.Stop()
+
+	fakeClock.Advance(time.Second)
+	if err := before.Save(context.Background()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(store.buckets) != 2 || store.buckets["10.0.0.1"].Tokens != 0 || store.buckets["10.0.0.2"].Tokens != 2 {
+		t.Fatalf("Save() stored %+v, want the spent buckets", store.buckets)
+	}
+	if want := fakeClock.Now().Add(-2 * time.Minute); !store.expiredBefore.Equal(want) {
+		t.Errorf("Save() expired before %v, want %v", store.expiredBefore, want)
+	}
+
+	// The restarted limiter keeps rejecting the client that spent its burst
+	after := persistentLimiter(store, fakeClock)
+	defer after.Stop()
+	if err := after.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if after.Allow("10.0.0.1") {
+		t.Error("Allow() after a restart should not hand out a fresh burst")
+	}
+	if stats := after.Stats(); stats.Restored != 2 || stats.Buckets != 2 {
+		t.Errorf("Stats() = %+v, want 2 restored buckets", stats)
+	}
+
+	// Saved tokens refill over the downtime
+	fakeClock.Advance(time.Minute)
+	if !after.Allow("10.0.0.1") {
+		t.Error("Allow() after a window should be allowed by the refill")
+	}
+}
+
+func TestLimiter_Load_SkipsExpiredAndCapped(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	now := fakeClock.Now()
+	store := &fakeStore{buckets: map[string]models.RateLimitBucket{
+		"10.0.0.1": {Key: "10.0.0.1", Capacity: 3, RefillRate: 1, LastRefill: now, LastSeen: now},
+		"10.0.0.2": {Key: "10.0.0.2", Capacity: 3, RefillRate: 1, LastRefill: now, LastSeen: now.Add(-time.Minute)},
+		"10.0.0.3": {Key: "10.0.0.3", Capacity: 3, RefillRate: 1, LastRefill: now, LastSeen: now.Add(-3 * time.Minute)},
+	}}
+
+	limiter := persistentLimiter(store, fakeClock)
+	defer limiter.Stop()
+	limiter.maxClients = 1
+	if err := limiter.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if stats := limiter.Stats(); stats.Buckets != 1 || stats.Restored != 1 {
+		t.Errorf("Stats() = %+v, want 1 restored bucket at the cap", stats)
+	}
+	if _, found := limiter.clientBuckets.Load("10.0.0.3"); found {
+		t.Error("Load() should not restore a bucket idle for two windows")
+	}
+}
+
+func TestLimiter_SaveWithoutStore(t *testing.T) {
+	limiter := NewLimiter(testutils.MockConfig(), testutils.MockLogger())
+	defer limiter.Stop()
+	if err := limiter.Save(context.Background()); err != nil {
+		t.Errorf("Save() without a store error = %v", err)
+	}
+	if err := limiter.Load(context.Background()); err != nil {
+		t.Errorf("Load() without a store error = %v", err)
+	}
+}
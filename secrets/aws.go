@@ -0,0 +1,155 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the access key signing requests to AWS
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string `secret:"true"`
+	SessionToken    string `secret:"true"` // Set for temporary credentials
+}
+
+// AWSSecretsManagerProvider reads secrets from AWS Secrets Manager
+type AWSSecretsManagerProvider struct {
+	endpoint    string
+	region      string
+	credentials AWSCredentials
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewAWSSecretsManagerProvider creates a provider for the region. An empty endpoint
+// selects the region's public endpoint; set one for VPC endpoints.
+func NewAWSSecretsManagerProvider(region, endpoint string, credentials AWSCredentials, client *http.Client) *AWSSecretsManagerProvider {
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	return &AWSSecretsManagerProvider{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		region:      region,
+		credentials: credentials,
+		client:      defaultClient(client),
+		now:         time.Now,
+	}
+}
+
+// Secret reads the current value of the secret named "id#field". Without a field, the
+// whole secret string is returned; with one, the secret must be a JSON object.
+func (provider *AWSSecretsManagerProvider) Secret(ctx context.Context, name string) (string, error) {
+	secretID, field := splitName(name)
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Secrets Manager request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(request, body, provider.credentials, provider.region, "secretsmanager", provider.now())
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s from Secrets Manager: %w", secretID, err)
+	}
+	defer response.Body.Close()
+
+	var document struct {
+		SecretString string `json:"SecretString"`
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxSecretSize)).Decode(&document); err != nil {
+		return "", fmt.Errorf("failed to decode Secrets Manager response for %s: %w", secretID, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		if strings.HasSuffix(document.Type, "ResourceNotFoundException") {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, secretID)
+		}
+		return "", fmt.Errorf("failed to read secret %s from Secrets Manager: status %d %s", secretID, response.StatusCode, document.Message)
+	}
+
+	if field == "" {
+		return document.SecretString, nil
+	}
+	value, err := selectField(document.SecretString, field)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", secretID, err)
+	}
+	return value, nil
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header, signing the host and every
+// header already set on the request
+func signV4(request *http.Request, body []byte, credentials AWSCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	request.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := request.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	bodySum := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		path,
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodySum[:]),
+	}, "\n")
+
+	scope := now.Format("20060102") + "/" + region + "/" + service + "/aws4_request"
+	requestSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), now.Format("20060102"))
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
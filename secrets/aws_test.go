@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	request, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	credentials := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(request, nil, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := request.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestAWSSecretsManagerProvider_Secret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			r.Header.Get("X-Amz-Security-Token") != "session" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"__type":"AccessDeniedException","message":"denied"}`))
+			return
+		}
+		var input struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&input)
+		switch input.SecretId {
+		case "prod/api-key":
+			w.Write([]byte(`{"Name":"prod/api-key","SecretString":"plain-key"}`))
+		case "prod/providers":
+			w.Write([]byte(`{"Name":"prod/providers","SecretString":"{\"openexchangerates\":\"oxr-key\"}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewAWSSecretsManagerProvider("eu-west-1", server.URL, AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, nil)
+	if got, err := provider.Secret(context.Background(), "prod/api-key"); err != nil || got != "plain-key" {
+		t.Errorf("Secret() = %q, %v, want the secret string", got, err)
+	}
+	if got, err := provider.Secret(context.Background(), "prod/providers#openexchangerates"); err != nil || got != "oxr-key" {
+		t.Errorf("Secret() = %q, %v, want the JSON field", got, err)
+	}
+	if _, err := provider.Secret(context.Background(), "prod/api-key#field"); err == nil {
+		t.Error("Secret() of a field of a plain secret expected an error")
+	}
+	if _, err := provider.Secret(context.Background(), "prod/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Secret() of a missing secret error = %v, want ErrNotFound", err)
+	}
+
+	denied := NewAWSSecretsManagerProvider("eu-west-1", server.URL, AWSCredentials{AccessKeyID: "OTHER", SecretAccessKey: "secret"}, nil)
+	if _, err := denied.Secret(context.Background(), "prod/api-key"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Secret() with denied credentials error = %v, want an access error", err)
+	}
+}
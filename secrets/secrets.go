@@ -0,0 +1,60 @@
+// Package secrets reads secrets such as API keys from external secret managers
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxSecretSize bounds the secret manager responses read
+const maxSecretSize = 1 << 20
+
+// ErrNotFound reports a secret, or a field of it, that does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider reads secrets from a secret manager. A name is the secret's path or ID,
+// optionally followed by "#field" to select a field of a secret holding several values.
+type Provider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// defaultClient is used by providers created without an HTTP client
+func defaultClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return client
+}
+
+// splitName splits a secret name into the secret's path or ID and the selected field
+func splitName(name string) (string, string) {
+	path, field, _ := strings.Cut(name, "#")
+	return path, field
+}
+
+// selectField returns the field of a secret holding a JSON object of string values
+func selectField(secret, field string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so field %q cannot be selected", field)
+	}
+	return fieldValue(fields, field)
+}
+
+// fieldValue returns a string field of a secret's values
+func fieldValue(fields map[string]interface{}, field string) (string, error) {
+	value, found := fields[field]
+	if !found {
+		return "", fmt.Errorf("%w: no field %q", ErrNotFound, field)
+	}
+	text, isString := value.(string)
+	if !isString {
+		return "", fmt.Errorf("field %q is not a string", field)
+	}
+	return text, nil
+}
@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultVaultField is read from Vault secrets named without a field
+const defaultVaultField = "value"
+
+// VaultProvider reads secrets from a HashiCorp Vault KV version 2 secrets engine
+type VaultProvider struct {
+	address string
+	token   string
+	mount   string
+	client  *http.Client
+}
+
+// NewVaultProvider creates a provider for the Vault server at address, authenticating
+// with token and reading the KV engine mounted at mount
+func NewVaultProvider(address, token, mount string, client *http.Client) *VaultProvider {
+	return &VaultProvider{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		client:  defaultClient(client),
+	}
+}
+
+// Secret reads the field of the secret at the path named "path#field". Without a
+// field, the secret's "value" field is read.
+func (provider *VaultProvider) Secret(ctx context.Context, name string) (string, error) {
+	path, field := splitName(name)
+	if field == "" {
+		field = defaultVaultField
+	}
+
+	// KV v2 serves the latest version of a secret under <mount>/data/<path>
+	endpoint := provider.address + "/v1/" + provider.mount + "/data/" + escapePath(strings.Trim(path, "/"))
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Vault request: %w", err)
+	}
+	request.Header.Set("X-Vault-Token", provider.token)
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s from Vault: %w", path, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read secret %s from Vault: status %d", path, response.StatusCode)
+	}
+
+	var document struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxSecretSize)).Decode(&document); err != nil {
+		return "", fmt.Errorf("failed to decode Vault secret %s: %w", path, err)
+	}
+	value, err := fieldValue(document.Data.Data, field)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// escapePath escapes each segment of a secret path
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVaultProvider_Secret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/currency/providers" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"value":"default-key","openexchangerates":"oxr-key","port":8080},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	provider := NewVaultProvider(server.URL+"/", "vault-token", "/kv/", nil)
+	tests := []struct {
+		name    string
+		want    string
+		wantErr error
+	}{
+		{name: "currency/providers#openexchangerates", want: "oxr-key"},
+		{name: "currency/providers", want: "default-key"},
+		{name: "currency/providers#missing", wantErr: ErrNotFound},
+		{name: "currency/unknown#value", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.Secret(context.Background(), tt.name)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Secret() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Secret() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := provider.Secret(context.Background(), "currency/providers#port"); err == nil {
+		t.Error("Secret() of a non-string field expected an error")
+	}
+	if _, err := NewVaultProvider(server.URL, "wrong-token", "kv", nil).Secret(context.Background(), "currency/providers"); err == nil {
+		t.Error("Secret() with a rejected token expected an error")
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// sourcePolicy holds the providers permitted to serve rates involving a currency, as the
+// base or a quoted symbol. Currencies without an entry may be served by any provider.
+// A nil value permits every provider.
+type sourcePolicy map[string]map[string]bool
+
+// newSourcePolicy merges the policies in order, a later entry for a currency replacing an
+// earlier one, or returns nil when no currency is restricted
+func newSourcePolicy(policies ...map[string][]string) sourcePolicy {
+	var policy sourcePolicy
+	for _, entries := range policies {
+		for currency, providers := range entries {
+			if policy == nil {
+				policy = make(sourcePolicy)
+			}
+			permitted := make(map[string]bool, len(providers))
+			for _, provider := range providers {
+				permitted[provider] = true
+			}
+			policy[currency] = permitted
+		}
+	}
+	return policy
+}
+
+// ValidateSourcePolicy checks that the providers a source policy permits are configured
+func ValidateSourcePolicy(policy map[string][]string, providers []config.ExchangeRateProvider) error {
+	configured := make(map[string]bool, len(providers))
+	for _, provider := range providers {
+		configured[provider.Name] = true
+	}
+	for currency, permitted := range policy {
+		if len(permitted) == 0 {
+			return fmt.Errorf("source policy for %s permits no provider", currency)
+		}
+		for _, name := range permitted {
+			if !configured[name] {
+				return fmt.Errorf("source policy for %s names unknown provider %s", currency, name)
+			}
+		}
+	}
+	return nil
+}
+
+// permits reports whether the provider may serve rates involving every currency
+func (policy sourcePolicy) permits(providerName string, currencies ...string) bool {
+	for _, currency := range currencies {
+		if permitted, restricted := policy[currency]; restricted && !permitted[providerName] {
+			return false
+		}
+	}
+	return true
+}
+
+// providersFor keeps the providers permitted to serve rates involving every currency,
+// failing when none is left
+func (policy sourcePolicy) providersFor(providers []ExchangeRateProvider, currencies []string) ([]ExchangeRateProvider, error) {
+	if policy == nil {
+		return providers, nil
+	}
+
+	permitted := []ExchangeRateProvider{}
+	for _, provider := range providers {
+		if policy.permits(provider.GetName(), currencies...) {
+			permitted = append(permitted, provider)
+		}
+	}
+	if len(permitted) == 0 && len(providers) > 0 {
+		return nil, &ServiceError{
+			Type:    ErrorTypeSourceNotPermitted,
+			Message: "no permitted provider available: " + policy.describe(currencies),
+		}
+	}
+	return permitted, nil
+}
+
+// describe lists the providers permitted for each restricted currency, e.g.
+// "EUR may be served by ecb; GBP may be served by boe"
+func (policy sourcePolicy) describe(currencies []string) string {
+	var rules []string
+	for _, currency := range currencies {
+		permitted, restricted := policy[currency]
+		if !restricted {
+			continue
+		}
+		names := make([]string, 0, len(permitted))
+		for name := range permitted {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rules = append(rules, fmt.Sprintf("%s may be served by %s", currency, strings.Join(names, ", ")))
+	}
+	return strings.Join(rules, "; ")
+}
+
+// filter drops the rates of currencies the table's provider may not serve
+func (policy sourcePolicy) filter(exchangeRates models.RatesResponse) models.RatesResponse {
+	if policy == nil {
+		return exchangeRates
+	}
+
+	filteredRates := make(models.RateTable, len(exchangeRates.Rates))
+	for currency, rate := range exchangeRates.Rates {
+		if policy.permits(exchangeRates.Provider, currency) {
+			filteredRates[currency] = rate
+		}
+	}
+	return exchangeRates.WithRates(filteredRates)
+}
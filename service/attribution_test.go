@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestSourcePolicy(t *testing.T) {
+	policy := newSourcePolicy(
+		map[string][]string{"EUR": {"ecb", "frankfurter"}, "GBP": {"boe"}},
+		map[string][]string{"GBP": {"erapi"}},
+	)
+
+	tests := []struct {
+		provider   string
+		currencies []string
+		want       bool
+	}{
+		{"ecb", []string{"EUR"}, true},
+		{"erapi", []string{"EUR"}, false},
+		{"erapi", []string{"USD", "JPY"}, true},
+		{"erapi", []string{"GBP"}, true},
+		{"boe", []string{"GBP"}, false},
+		{"ecb", []string{"EUR", "GBP"}, false},
+	}
+	for _, tt := range tests {
+		if got := policy.permits(tt.provider, tt.currencies...); got != tt.want {
+			t.Errorf("permits(%s, %v) = %v, want %v", tt.provider, tt.currencies, got, tt.want)
+		}
+	}
+
+	if newSourcePolicy(nil, map[string][]string{}) != nil {
+		t.Error("newSourcePolicy() without entries should permit every provider")
+	}
+	if !sourcePolicy(nil).permits("erapi", "EUR") {
+		t.Error("nil policy should permit every provider")
+	}
+
+	filtered := policy.filter(models.RatesResponse{
+		Base:     "USD",
+		Provider: "erapi",
+		Rates:    models.RateTable{"EUR": 0.9, "GBP": 0.8, "JPY": 150},
+	})
+	if len(filtered.Rates) != 2 || filtered.Rates["JPY"] != 150 || filtered.Rates["GBP"] != 0.8 {
+		t.Errorf("filter() = %v, want GBP and JPY only", filtered.Rates)
+	}
+}
+
+func TestRatesService_SourcePolicy(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.SourcePolicy = map[string][]string{"EUR": {"ecb"}}
+	service := NewRatesService(cfg, testutils.MockLogger())
+	service.providers = []ExchangeRateProvider{
+		&testutils.FakeProvider{Name: "erapi", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.91, "GBP": 0.79}},
+		&testutils.FakeProvider{Name: "ecb", Enabled: true, Priority: 2, Rates: map[string]float64{"EUR": 0.92, "GBP": 0.78}},
+		&testutils.FakeProvider{Name: "boe", Enabled: true, Priority: 3, Err: errors.New("upstream down")},
+	}
+
+	result, err := service.GetRatesForSymbols(context.Background(), "USD", []string{"EUR"})
+	if err != nil {
+		t.Fatalf("GetRatesForSymbols() error = %v", err)
+	}
+	if result.Provider != "ecb" || result.Rates["EUR"] != 0.92 {
+		t.Errorf("GetRatesForSymbols() = %s %v, want EUR from ecb", result.Provider, result.Rates)
+	}
+
+	// Tables of any provider serve other currencies, without the EUR rate unless from ecb
+	result, err = service.GetRates(context.Background(), "GBP")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if _, quoted := result.Rates["EUR"]; quoted && result.Provider != "ecb" {
+		t.Errorf("GetRates() served EUR from %s", result.Provider)
+	}
+
+	conversion, err := service.Convert(context.Background(), "EUR", "GBP", 10, "")
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if conversion.Provider != "ecb" {
+		t.Errorf("Convert() provider = %s, want ecb", conversion.Provider)
+	}
+
+	// A tenant's entries replace the global ones: only the failing boe may serve GBP
+	view := service.ForTenant(&config.Tenant{ID: "regulated", SourcePolicy: map[string][]string{"GBP": {"boe"}}})
+	if _, err := view.GetRatesForSymbols(context.Background(), "USD", []string{"GBP"}); err == nil {
+		t.Error("GetRatesForSymbols() should fail when every permitted provider fails")
+	}
+
+	// No permitted provider is configured for both currencies
+	_, err = view.GetPairRate(context.Background(), "EUR", "GBP")
+	var serviceError *ServiceError
+	if !errors.As(err, &serviceError) || serviceError.Type != ErrorTypeSourceNotPermitted {
+		t.Fatalf("GetPairRate() error = %v, want no permitted provider", err)
+	}
+}
+
+func TestValidateSourcePolicy(t *testing.T) {
+	providers := []config.ExchangeRateProvider{{Name: "erapi"}, {Name: "frankfurter"}}
+
+	if err := ValidateSourcePolicy(map[string][]string{"EUR": {"frankfurter"}}, providers); err != nil {
+		t.Errorf("ValidateSourcePolicy() error = %v", err)
+	}
+	if err := ValidateSourcePolicy(map[string][]string{"EUR": {"ecb"}}, providers); err == nil {
+		t.Error("ValidateSourcePolicy() should reject an unknown provider")
+	}
+	if err := ValidateSourcePolicy(map[string][]string{"EUR": {}}, providers); err == nil {
+		t.Error("ValidateSourcePolicy() should reject a currency without providers")
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// ErrBudgetExhausted is returned instead of calling a provider once the call budget of
+// the current window is spent
+var ErrBudgetExhausted = errors.New("provider call budget exhausted")
+
+// callBudget caps the outbound provider calls made per fixed window, across all
+// providers and tenant views, so cache churn cannot burn through API key quotas.
+// A nil budget is valid and allows every call.
+type callBudget struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	used        int
+	rejected    int64
+}
+
+// newCallBudget returns a budget for the configuration, or nil when it is unlimited
+func newCallBudget(configuration config.CallBudgetConfig) *callBudget {
+	if configuration.Calls <= 0 || configuration.Window <= 0 {
+		return nil
+	}
+	return &callBudget{
+		limit:  configuration.Calls,
+		window: configuration.Window,
+		now:    time.Now,
+	}
+}
+
+// take spends one call, returning ErrBudgetExhausted when the window's budget is spent
+func (budget *callBudget) take() error {
+	if budget == nil {
+		return nil
+	}
+
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.roll(budget.now())
+	if budget.used >= budget.limit {
+		budget.rejected++
+		return ErrBudgetExhausted
+	}
+	budget.used++
+	return nil
+}
+
+// exhausted reports whether the current window's budget is spent
+func (budget *callBudget) exhausted() bool {
+	if budget == nil {
+		return false
+	}
+
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.roll(budget.now())
+	return budget.used >= budget.limit
+}
+
+// stats reports the current window, or nothing for an unlimited budget
+func (budget *callBudget) stats() *models.CallBudgetStats {
+	if budget == nil {
+		return nil
+	}
+
+	budget.mutex.Lock()
+	defer budget.mutex.Unlock()
+
+	budget.roll(budget.now())
+	return &models.CallBudgetStats{
+		Limit:     budget.limit,
+		Window:    budget.window.String(),
+		Used:      budget.used,
+		Remaining: budget.limit - budget.used,
+		ResetsAt:  budget.windowStart.Add(budget.window),
+		Rejected:  budget.rejected,
+		Exhausted: budget.used >= budget.limit,
+	}
+}
+
+// roll starts a new window once the current one has elapsed (caller holds the lock)
+func (budget *callBudget) roll(now time.Time) {
+	if now.Sub(budget.windowStart) >= budget.window {
+		budget.windowStart = now
+		budget.used = 0
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestCallBudget(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	budget := newCallBudget(config.CallBudgetConfig{Calls: 2, Window: time.Hour})
+	budget.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := budget.take(); err != nil {
+			t.Fatalf("take() %d error = %v", i, err)
+		}
+	}
+	if err := budget.take(); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("take() over budget error = %v, want %v", err, ErrBudgetExhausted)
+	}
+
+	stats := budget.stats()
+	if !stats.Exhausted || stats.Used != 2 || stats.Remaining != 0 || stats.Rejected != 1 {
+		t.Errorf("stats() = %+v, want exhausted with 2 used and 1 rejected", stats)
+	}
+	if !stats.ResetsAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("stats() ResetsAt = %v, want %v", stats.ResetsAt, now.Add(time.Hour))
+	}
+
+	now = now.Add(time.Hour)
+	if err := budget.take(); err != nil {
+		t.Fatalf("take() in next window error = %v", err)
+	}
+	if stats := budget.stats(); stats.Used != 1 || stats.Exhausted {
+		t.Errorf("stats() in next window = %+v, want 1 used", stats)
+	}
+}
+
+func TestCallBudget_Unlimited(t *testing.T) {
+	budget := newCallBudget(config.CallBudgetConfig{Calls: 0, Window: time.Hour})
+	if budget != nil {
+		t.Fatalf("newCallBudget() with no calls = %+v, want nil", budget)
+	}
+	if err := budget.take(); err != nil {
+		t.Errorf("nil budget take() error = %v", err)
+	}
+	if budget.stats() != nil {
+		t.Error("nil budget stats() should be nil")
+	}
+}
+
+func TestRatesService_BudgetExhausted_ServesStale(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.85}}`))
+	}))
+	defer server.Close()
+
+	cfg := testutils.MockConfig()
+	cfg.ExchangeRateProviders = []config.ExchangeRateProvider{{Name: "test", BaseURL: server.URL, Enabled: true}}
+	cfg.ProviderBudget = config.CallBudgetConfig{Calls: 1, Window: time.Hour}
+	ratesService := NewRatesService(cfg, testutils.MockLogger())
+
+	ctx := context.Background()
+	if _, err := ratesService.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	// Expire the cache: the next fetch is over budget and must not reach the provider
+	expireCache(ratesService)
+
+	stale, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() over budget error = %v", err)
+	}
+	if !stale.Degraded || stale.Rates["EUR"] != 0.85 {
+		t.Errorf("GetRates() over budget = %+v, want degraded cached rates", stale)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("provider calls = %d, want 1", got)
+	}
+
+	// Nothing cached for another base, so the exhausted budget is reported
+	_, err = ratesService.GetRates(ctx, "EUR")
+	if classifyError(err) != ErrorTypeBudgetExhausted {
+		t.Errorf("GetRates() uncached base error = %v, want budget exhausted", err)
+	}
+
+	if stats := ratesService.BudgetStats(); stats == nil || stats.Rejected != 2 {
+		t.Errorf("BudgetStats() = %+v, want 2 rejected calls", stats)
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// historyDateLayout formats the date of cached historical rates
+const historyDateLayout = "2006-01-02"
+
+// ratesKey identifies a cached rates table. Symbols is the sorted, comma-joined set of
+// codes the table was limited to ("" = the complete table) and Date the day of
+// historical rates ("" = latest rates). Tables limited to some symbols never replace
+// the complete table of their base, so they cannot hide its other rates.
+type ratesKey struct {
+	Base    string
+	Symbols string
+	Date    string
+}
+
+// latestKey returns the key of the complete latest rates of a base
+func latestKey(baseCurrency string) ratesKey {
+	return ratesKey{Base: baseCurrency}
+}
+
+// historicalKey returns the key of the complete rates of a base on a past date
+func historicalKey(baseCurrency string, date time.Time) ratesKey {
+	return ratesKey{Base: baseCurrency, Date: date.Format(historyDateLayout)}
+}
+
+// symbolsKey returns the canonical form of a set of symbols: upper case, sorted and
+// without duplicates, so every ordering of the same filter shares one key
+func symbolsKey(symbols []string) string {
+	seen := make(map[string]bool, len(symbols))
+	codes := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		code := strings.ToUpper(strings.TrimSpace(symbol))
+		if code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ",")
+}
+
+// covers reports whether a table cached under key quotes every requested symbol: the
+// complete table covers any filter, a limited one only a subset of its own symbols
+func (key ratesKey) covers(symbols []string) bool {
+	if key.Symbols == "" {
+		return true
+	}
+	quoted := make(map[string]bool)
+	for _, code := range strings.Split(key.Symbols, ",") {
+		quoted[code] = true
+	}
+	for _, symbol := range symbols {
+		if !quoted[symbol] && symbol != key.Base {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedRates returns valid cached rates of the base and date that quote every symbol
+// (none = the complete table is needed), counting a cache hit when found
+func (ratesService *RatesService) cachedRates(baseCurrency, date string, symbols []string) (models.RatesResponse, bool) {
+	cachedResponse, found := ratesService.lookupRates(baseCurrency, date, symbols)
+	if found {
+		atomic.AddInt64(&ratesService.cacheHits, 1)
+	}
+	return cachedResponse, found
+}
+
+// lookupRates is cachedRates without counting a hit. Of several covering tables, the most
+// recently published one is returned, so a fresh partial push beats an older complete
+// table.
+func (ratesService *RatesService) lookupRates(baseCurrency, date string, symbols []string) (models.RatesResponse, bool) {
+	ratesService.cacheMutex.RLock()
+	defer ratesService.cacheMutex.RUnlock()
+
+	now := ratesService.now()
+	var best models.CacheEntry
+	found := false
+	for key, entry := range ratesService.cache {
+		if key.Base != baseCurrency || key.Date != date || !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if (len(symbols) == 0 && key.Symbols != "") || !key.covers(symbols) {
+			continue
+		}
+		if !found || entry.Data.Timestamp > best.Data.Timestamp {
+			best, found = entry, true
+		}
+	}
+	return best.Data, found
+}
+
+// storeRates caches a table under its key until the cache TTL expires. Expired entries
+// are dropped, except the complete latest tables still served when the call budget is
+// spent.
+func (ratesService *RatesService) storeRates(key ratesKey, exchangeRates models.RatesResponse) {
+	ratesService.cacheMutex.Lock()
+	defer ratesService.cacheMutex.Unlock()
+
+	now := ratesService.now()
+	if ratesService.cache == nil {
+		ratesService.cache = make(map[ratesKey]models.CacheEntry)
+	}
+	for cachedKey, entry := range ratesService.cache {
+		if !now.Before(entry.ExpiresAt) && cachedKey != latestKey(cachedKey.Base) {
+			delete(ratesService.cache, cachedKey)
+		}
+	}
+	ratesService.cache[key] = models.CacheEntry{
+		Data:      exchangeRates,
+		ExpiresAt: now.Add(ratesService.CacheTTL()),
+	}
+}
+
+// staleRates returns the complete latest rates for the base even if expired, flagged as
+// degraded
+func (ratesService *RatesService) staleRates(baseCurrency string) (models.RatesResponse, bool) {
+	ratesService.cacheMutex.RLock()
+	defer ratesService.cacheMutex.RUnlock()
+
+	entry, found := ratesService.cache[latestKey(baseCurrency)]
+	if !found {
+		return models.RatesResponse{}, false
+	}
+	staleResponse := entry.Data
+	staleResponse.Degraded = true
+	return staleResponse, true
+}
+
+// newCacheTTL returns the shared cache TTL, starting at the configured one
+func newCacheTTL(ttl time.Duration) *int64 {
+	nanoseconds := int64(ttl)
+	return &nanoseconds
+}
+
+// CacheTTL returns how long fetched rates are cached
+func (ratesService *RatesService) CacheTTL() time.Duration {
+	if ratesService.cacheTTL == nil {
+		return ratesService.configuration.RatesCacheTTL
+	}
+	return time.Duration(atomic.LoadInt64(ratesService.cacheTTL))
+}
+
+// SetCacheTTL changes how long rates are cached from now on, for the service and its
+// tenant views. Tables already cached keep their expiry; purge the cache to drop them.
+func (ratesService *RatesService) SetCacheTTL(ctx context.Context, ttl time.Duration) {
+	if ratesService.cacheTTL == nil {
+		ratesService.cacheTTL = newCacheTTL(ttl)
+	} else {
+		atomic.StoreInt64(ratesService.cacheTTL, int64(ttl))
+	}
+	ratesService.logger.WithContext(ctx).Infof("Rates cache TTL set to %v", ttl)
+}
+
+// CacheAges returns how long ago the complete latest rates of each cached base were
+// fetched. Expired tables are included, so rates that stopped refreshing keep aging.
+func (ratesService *RatesService) CacheAges() map[string]time.Duration {
+	ratesService.cacheMutex.RLock()
+	defer ratesService.cacheMutex.RUnlock()
+
+	now := ratesService.now()
+	ages := make(map[string]time.Duration)
+	for key, entry := range ratesService.cache {
+		if key != latestKey(key.Base) || entry.Data.FetchedAt == 0 {
+			continue
+		}
+		ages[key.Base] = max(0, now.Sub(time.Unix(entry.Data.FetchedAt, 0)))
+	}
+	return ages
+}
+
+// RefreshHints reports the cache TTL and, for the default base and every base with cached
+// latest rates, when the rates were fetched and when they refresh next. Cached rates refresh
+// on the first request after they expire, so an expired or uncached base refreshes now.
+func (ratesService *RatesService) RefreshHints() models.RefreshHints {
+	ratesService.cacheMutex.RLock()
+	defer ratesService.cacheMutex.RUnlock()
+
+	now := ratesService.now()
+	ttl := ratesService.CacheTTL()
+	pollInterval := int64(max(ttl, time.Second) / time.Second)
+	hints := models.RefreshHints{CacheTTLSeconds: int64(ttl / time.Second), Bases: []models.BaseRefreshHint{}}
+
+	bases := []string{ratesService.DefaultBaseCurrency()}
+	for key := range ratesService.cache {
+		if key == latestKey(key.Base) && key.Base != bases[0] {
+			bases = append(bases, key.Base)
+		}
+	}
+	sort.Strings(bases[1:])
+
+	for _, base := range bases {
+		hint := models.BaseRefreshHint{Base: base, NextRefreshAt: now.Unix(), PollIntervalSeconds: pollInterval}
+		if entry, found := ratesService.cache[latestKey(base)]; found {
+			hint.FetchedAt = entry.Data.FetchedAt
+			if now.Before(entry.ExpiresAt) {
+				hint.NextRefreshAt = entry.ExpiresAt.Unix()
+			}
+		}
+		hints.Bases = append(hints.Bases, hint)
+	}
+	return hints
+}
+
+// filterSymbols limits a rates table to the requested symbols (none = every symbol)
+func filterSymbols(exchangeRates models.RatesResponse, symbols []string) models.RatesResponse {
+	if len(symbols) == 0 {
+		return exchangeRates
+	}
+
+	filteredRates := make(models.RateTable, len(symbols))
+	for _, symbol := range symbols {
+		if rate, found := exchangeRates.Rates[symbol]; found {
+			filteredRates[symbol] = rate
+		}
+	}
+	return exchangeRates.WithRates(filteredRates)
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// expireCache makes every cached table of the service, and the provider tables it
+// shares with its tenant views, expire
+func expireCache(ratesService *RatesService) {
+	ratesService.cacheMutex.Lock()
+	defer ratesService.cacheMutex.Unlock()
+	for key, entry := range ratesService.cache {
+		entry.ExpiresAt = time.Now().Add(-time.Second)
+		ratesService.cache[key] = entry
+	}
+
+	if ratesService.raw == nil {
+		return
+	}
+	ratesService.raw.mutex.Lock()
+	defer ratesService.raw.mutex.Unlock()
+	for key, entry := range ratesService.raw.tables {
+		entry.ExpiresAt = time.Now().Add(-time.Second)
+		ratesService.raw.tables[key] = entry
+	}
+}
+
+func TestSymbolsKey(t *testing.T) {
+	tests := []struct {
+		symbols []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{"gbp", "EUR", " eur ", ""}, "EUR,GBP"},
+		{[]string{"JPY"}, "JPY"},
+	}
+	for _, tt := range tests {
+		if got := symbolsKey(tt.symbols); got != tt.want {
+			t.Errorf("symbolsKey(%q) = %q, want %q", tt.symbols, got, tt.want)
+		}
+	}
+}
+
+func TestRatesService_GetRatesForSymbols(t *testing.T) {
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers: []ExchangeRateProvider{&testutils.FakeProvider{
+			Name: "test-provider", Enabled: true, Priority: 1,
+			Rates: map[string]float64{"EUR": 0.85, "GBP": 0.75, "JPY": 110},
+		}},
+	}
+
+	ctx := context.Background()
+	filtered, err := ratesService.GetRatesForSymbols(ctx, "USD", []string{"gbp", "EUR"})
+	if err != nil {
+		t.Fatalf("GetRatesForSymbols() error = %v", err)
+	}
+	if len(filtered.Rates) != 2 || filtered.Rates["EUR"] != 0.85 || filtered.Rates["GBP"] != 0.75 {
+		t.Errorf("GetRatesForSymbols() rates = %v, want EUR and GBP only", filtered.Rates)
+	}
+
+	// The complete table fetched for the filter serves unfiltered and other filters
+	complete, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(complete.Rates) != 3 {
+		t.Errorf("GetRates() rates = %v, want all 3", complete.Rates)
+	}
+	if _, err := ratesService.GetRatesForSymbols(ctx, "USD", []string{"JPY"}); err != nil {
+		t.Fatalf("GetRatesForSymbols() error = %v", err)
+	}
+	if stats := ratesService.CacheStats(); stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("CacheStats() = %+v, want 2 hits, 1 miss and 1 entry", stats)
+	}
+}
+
+func TestRatesService_CacheExpiry(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	provider := &testutils.FakeProvider{Name: "test-provider", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.85}}
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+		clock:         fakeClock,
+	}
+	ttl := ratesService.configuration.RatesCacheTTL
+
+	ctx := context.Background()
+	if _, err := ratesService.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	fakeClock.Advance(ttl - time.Second)
+	provider.Rates = map[string]float64{"EUR": 0.9}
+	cached, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if cached.Rates["EUR"] != 0.85 {
+		t.Errorf("GetRates() within the TTL = %v, want the cached 0.85", cached.Rates["EUR"])
+	}
+
+	fakeClock.Advance(time.Second)
+	refreshed, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if refreshed.Rates["EUR"] != 0.9 {
+		t.Errorf("GetRates() at the TTL = %v, want the refetched 0.9", refreshed.Rates["EUR"])
+	}
+	if stats := ratesService.CacheStats(); stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("CacheStats() = %d hits and %d misses, want 1 and 2", stats.Hits, stats.Misses)
+	}
+}
+
+func TestRatesService_SetCacheTTL(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	provider := &testutils.FakeProvider{Name: "test-provider", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.85}}
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+		clock:         fakeClock,
+		cacheTTL:      newCacheTTL(testutils.MockConfig().RatesCacheTTL),
+	}
+	view := ratesService.ForTenant(&config.Tenant{ID: "acme"})
+
+	ratesService.SetCacheTTL(context.Background(), 10*time.Second)
+	if ttl := view.CacheTTL(); ttl != 10*time.Second {
+		t.Errorf("tenant view CacheTTL() = %v, want %v", ttl, 10*time.Second)
+	}
+	if stats := ratesService.CacheStats(); stats.TTL != "10s" {
+		t.Errorf("CacheStats().TTL = %v, want 10s", stats.TTL)
+	}
+
+	ctx := context.Background()
+	if _, err := ratesService.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	fakeClock.Advance(10 * time.Second)
+	provider.Rates = map[string]float64{"EUR": 0.9}
+	refreshed, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if refreshed.Rates["EUR"] != 0.9 {
+		t.Errorf("GetRates() at the new TTL = %v, want the refetched 0.9", refreshed.Rates["EUR"])
+	}
+}
+
+func TestRatesService_PartialPushKeepsCompleteTable(t *testing.T) {
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers: []ExchangeRateProvider{&testutils.FakeProvider{
+			Name: "test-provider", Enabled: true, Priority: 1,
+			Rates: map[string]float64{"EUR": 0.85, "GBP": 0.75},
+		}},
+	}
+
+	ctx := context.Background()
+	if _, err := ratesService.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	if _, err := ratesService.PushRates(context.Background(), "pricing-engine", models.RatesResponse{
+		Base:        "USD",
+		Rates:       models.RateTable{"EUR": 0.91},
+		PublishedAt: time.Now().Add(time.Minute).Unix(),
+	}); err != nil {
+		t.Fatalf("PushRates() error = %v", err)
+	}
+
+	// The partial push serves its own symbols, the complete table everything else
+	eur, err := ratesService.GetRatesForSymbols(ctx, "USD", []string{"EUR"})
+	if err != nil {
+		t.Fatalf("GetRatesForSymbols() error = %v", err)
+	}
+	if eur.Provider != "pricing-engine" || eur.Rates["EUR"] != 0.91 {
+		t.Errorf("GetRatesForSymbols(EUR) = %+v, want the pushed rate", eur)
+	}
+	complete, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() after partial push error = %v", err)
+	}
+	if complete.Provider != "test-provider" || complete.Rates["GBP"] != 0.75 {
+		t.Errorf("GetRates() after partial push = %+v, want the complete fetched table", complete)
+	}
+}
+
+func TestRatesService_CacheAges(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		clock:         fakeClock,
+	}
+	ttl := ratesService.configuration.RatesCacheTTL
+	ratesService.storeRates(latestKey("USD"), models.RatesResponse{Base: "USD", FetchedAt: 1700000000})
+	ratesService.storeRates(ratesKey{Base: "EUR", Symbols: "USD"}, models.RatesResponse{Base: "EUR", FetchedAt: 1700000000})
+
+	// Expired latest rates keep aging; tables limited to some symbols are not reported
+	fakeClock.Advance(ttl + 30*time.Second)
+	ages := ratesService.CacheAges()
+	if len(ages) != 1 || ages["USD"] != ttl+30*time.Second {
+		t.Errorf("CacheAges() = %v, want USD at %v", ages, ttl+30*time.Second)
+	}
+}
+
+func TestRatesService_RefreshHints(t *testing.T) {
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	cfg := testutils.MockConfig()
+	cfg.DefaultBaseCurrency = "USD"
+	cfg.RatesCacheTTL = time.Minute
+	ratesService := &RatesService{
+		configuration: cfg,
+		logger:        testutils.MockLogger(),
+		clock:         fakeClock,
+	}
+
+	// The default base is reported before anything is cached, refreshing on the next request
+	hints := ratesService.RefreshHints()
+	if hints.CacheTTLSeconds != 60 || len(hints.Bases) != 1 || hints.Bases[0] != (models.BaseRefreshHint{Base: "USD", NextRefreshAt: 1700000000, PollIntervalSeconds: 60}) {
+		t.Errorf("RefreshHints() without cached rates = %+v", hints)
+	}
+
+	ratesService.storeRates(latestKey("GBP"), models.RatesResponse{Base: "GBP", FetchedAt: 1700000000})
+	fakeClock.Advance(30 * time.Second)
+	ratesService.storeRates(latestKey("EUR"), models.RatesResponse{Base: "EUR", FetchedAt: 1700000030})
+	ratesService.storeRates(ratesKey{Base: "JPY", Symbols: "USD"}, models.RatesResponse{Base: "JPY", FetchedAt: 1700000030})
+	fakeClock.Advance(40 * time.Second)
+
+	want := []models.BaseRefreshHint{
+		{Base: "USD", NextRefreshAt: 1700000070, PollIntervalSeconds: 60},
+		{Base: "EUR", FetchedAt: 1700000030, NextRefreshAt: 1700000090, PollIntervalSeconds: 60},
+		{Base: "GBP", FetchedAt: 1700000000, NextRefreshAt: 1700000070, PollIntervalSeconds: 60}, // Expired
+	}
+	hints = ratesService.RefreshHints()
+	if len(hints.Bases) != len(want) {
+		t.Fatalf("RefreshHints() = %+v, want %+v", hints.Bases, want)
+	}
+	for i := range want {
+		if hints.Bases[i] != want[i] {
+			t.Errorf("RefreshHints() base %d = %+v, want %+v", i, hints.Bases[i], want[i])
+		}
+	}
+}
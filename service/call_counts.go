@@ -0,0 +1,55 @@
+package service
+
+import "sync"
+
+// providerCalls are a provider's measured calls and how many of them failed
+type providerCalls struct {
+	calls    int64
+	failures int64
+}
+
+// callCounts counts the calls of each provider since startup, so provider error rates can
+// be alerted on. Like latency, calls skipped by the outbound rate limit or cancelled by
+// the caller are not counted. It is shared with tenant views; a nil counter records
+// nothing.
+type callCounts struct {
+	mutex     sync.Mutex
+	providers map[string]*providerCalls
+}
+
+func newCallCounts() *callCounts {
+	return &callCounts{providers: make(map[string]*providerCalls)}
+}
+
+// observe counts a call of the provider, failed when err is set
+func (counts *callCounts) observe(providerName string, err error) {
+	if counts == nil {
+		return
+	}
+	counts.mutex.Lock()
+	defer counts.mutex.Unlock()
+
+	provider, found := counts.providers[providerName]
+	if !found {
+		provider = &providerCalls{}
+		counts.providers[providerName] = provider
+	}
+	provider.calls++
+	if err != nil {
+		provider.failures++
+	}
+}
+
+// get returns the provider's calls and failures
+func (counts *callCounts) get(providerName string) (calls, failures int64) {
+	if counts == nil {
+		return 0, 0
+	}
+	counts.mutex.Lock()
+	defer counts.mutex.Unlock()
+
+	if provider, found := counts.providers[providerName]; found {
+		return provider.calls, provider.failures
+	}
+	return 0, 0
+}
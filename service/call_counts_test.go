@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_ProviderCallCounts(t *testing.T) {
+	provider := &testutils.FakeProvider{
+		Name: "flaky", Enabled: true, Priority: 1,
+		Rates:  map[string]float64{"EUR": 0.85},
+		Errors: []error{errors.New("upstream down")},
+	}
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+		gate:          newProviderGate(testutils.MockLogger(), 0),
+		calls:         newCallCounts(),
+	}
+
+	if _, err := ratesService.GetRates(context.Background(), "USD"); err == nil {
+		t.Fatal("GetRates() should fail with the first call")
+	}
+	if _, err := ratesService.GetRates(context.Background(), "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	status := ratesService.GetProviderStatus()[0]
+	if status.Calls != 2 || status.Failures != 1 {
+		t.Errorf("GetProviderStatus() calls = %d, failures = %d, want 2 and 1", status.Calls, status.Failures)
+	}
+}
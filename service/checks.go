@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/health"
+)
+
+// ProviderChecks returns a reachability check per provider. The checks share the
+// "providers" group, so the service counts as ready while any provider answers.
+// Checks spend provider calls too; once the budget is spent they pass without calling,
+// since expired rates can still be served, and the budget check reports the shortage.
+// Their outcomes feed the provider gate, so they count as probes of providers in standby.
+func (ratesService *RatesService) ProviderChecks() []health.Check {
+	checks := make([]health.Check, len(ratesService.providers))
+	for i, provider := range ratesService.providers {
+		provider := provider
+		checks[i] = health.Check{
+			Name:  "provider:" + provider.GetName(),
+			Group: "providers",
+			Kind:  health.KindProvider,
+			Run: func(ctx context.Context) error {
+				_, err := provider.GetRates(ctx, ratesService.DefaultBaseCurrency())
+				if ctx.Err() == nil {
+					ratesService.gate.observe(provider.GetName(), ratesService.DefaultBaseCurrency(), err)
+				}
+				if errors.Is(err, ErrBudgetExhausted) || errors.Is(err, ErrProviderRateLimited) {
+					return nil
+				}
+				return err
+			},
+		}
+	}
+	if ratesService.budget != nil {
+		checks = append(checks, health.Check{
+			Name:     "budget:providers",
+			Kind:     health.KindProvider,
+			Optional: true,
+			Run: func(ctx context.Context) error {
+				if ratesService.budget.exhausted() {
+					return ErrBudgetExhausted
+				}
+				return nil
+			},
+			Detail: ratesService.budgetDetail,
+		})
+	}
+	return checks
+}
+
+// CacheChecks returns the check of the in-memory rates cache. The cache itself cannot
+// fail; the check reports what it holds and how long until it expires.
+func (ratesService *RatesService) CacheChecks() []health.Check {
+	return []health.Check{{
+		Name:     "cache",
+		Kind:     health.KindCache,
+		Optional: true,
+		Run:      func(ctx context.Context) error { return nil },
+		Detail:   ratesService.cacheDetail,
+	}}
+}
+
+// BrokerChecks returns the checks of the enabled event and MQTT brokers. Broker outages
+// only degrade readiness, since publishing is best effort.
+func (ratesService *RatesService) BrokerChecks() []health.Check {
+	return append(ratesService.events.Checks(), ratesService.pairs.Checks()...)
+}
+
+// cacheDetail describes the cached rates and the cache hit counts
+func (ratesService *RatesService) cacheDetail() string {
+	stats := ratesService.CacheStats()
+	if stats.Base == "" {
+		return fmt.Sprintf("in-memory, empty, %d hits / %d misses", stats.Hits, stats.Misses)
+	}
+	return fmt.Sprintf("in-memory, %s rates expire in %s, %d hits / %d misses",
+		stats.Base, time.Until(stats.ExpiresAt).Round(time.Second), stats.Hits, stats.Misses)
+}
+
+// budgetDetail describes how much of the provider call budget is left
+func (ratesService *RatesService) budgetDetail() string {
+	stats := ratesService.budget.stats()
+	return fmt.Sprintf("%d of %d calls left, resets in %s",
+		stats.Remaining, stats.Limit, time.Until(stats.ResetsAt).Round(time.Second))
+}
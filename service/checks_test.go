@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_ProviderChecks(t *testing.T) {
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers: []ExchangeRateProvider{
+			&testutils.FakeProvider{Name: "up", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.9}},
+			&testutils.FakeProvider{Name: "down", Enabled: true, Priority: 2, Err: errors.New("no such host")},
+		},
+	}
+
+	checks := ratesService.ProviderChecks()
+	if len(checks) != 2 {
+		t.Fatalf("ProviderChecks() = %d checks, want 2", len(checks))
+	}
+	if checks[0].Name != "provider:up" || checks[0].Group != "providers" || checks[0].Kind != health.KindProvider {
+		t.Errorf("ProviderChecks()[0] = %s/%s, want provider:up/providers", checks[0].Name, checks[0].Group)
+	}
+	if err := checks[0].Run(context.Background()); err != nil {
+		t.Errorf("reachable provider check error = %v", err)
+	}
+	if err := checks[1].Run(context.Background()); err == nil {
+		t.Error("unreachable provider check expected error")
+	}
+}
+
+func TestRatesService_CacheChecks(t *testing.T) {
+	ratesService := NewRatesService(testutils.MockConfig(), testutils.MockLogger())
+
+	checks := ratesService.CacheChecks()
+	if len(checks) != 1 || checks[0].Kind != health.KindCache || !checks[0].Optional {
+		t.Fatalf("CacheChecks() = %+v, want one optional cache check", checks)
+	}
+	if err := checks[0].Run(context.Background()); err != nil {
+		t.Errorf("cache check error = %v", err)
+	}
+	if detail := checks[0].Detail(); !strings.Contains(detail, "empty") {
+		t.Errorf("cache detail = %q, want an empty cache", detail)
+	}
+
+	ratesService.cacheRates(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.9}})
+	if detail := checks[0].Detail(); !strings.Contains(detail, "USD rates expire in") {
+		t.Errorf("cache detail = %q, want the cached USD rates", detail)
+	}
+	if checks := ratesService.BrokerChecks(); len(checks) != 0 {
+		t.Errorf("BrokerChecks() = %d checks without brokers, want 0", len(checks))
+	}
+}
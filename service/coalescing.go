@@ -0,0 +1,105 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// fetchTracker counts how singleflight coalesces concurrent cache misses into provider
+// fetches, so request coalescing and stampedes on cache expiry are visible. It is
+// shared with tenant views; a nil tracker records nothing.
+type fetchTracker struct {
+	leaders atomic.Int64 // Fetches executed
+	shared  atomic.Int64 // Callers served by a fetch another caller started
+
+	mutex        sync.Mutex
+	inFlight     map[string]*inFlightFetch
+	largestShare int64
+}
+
+// inFlightFetch is a running fetch and the callers that joined it
+type inFlightFetch struct {
+	startedAt time.Time
+	waiters   int64
+}
+
+func newFetchTracker() *fetchTracker {
+	return &fetchTracker{inFlight: make(map[string]*inFlightFetch)}
+}
+
+// join counts a caller about to wait on the key's running fetch, if there is one
+func (tracker *fetchTracker) join(key string) {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if fetch, running := tracker.inFlight[key]; running {
+		fetch.waiters++
+	}
+}
+
+// begin registers the fetch a leader starts for the key
+func (tracker *fetchTracker) begin(key string) {
+	if tracker == nil {
+		return
+	}
+	tracker.leaders.Add(1)
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.inFlight[key] = &inFlightFetch{startedAt: time.Now()}
+}
+
+// end removes the key's fetch and records how many callers it served
+func (tracker *fetchTracker) end(key string) {
+	if tracker == nil {
+		return
+	}
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if fetch, running := tracker.inFlight[key]; running {
+		tracker.largestShare = max(tracker.largestShare, fetch.waiters+1)
+		delete(tracker.inFlight, key)
+	}
+}
+
+// served counts a caller that got the result of another caller's fetch
+func (tracker *fetchTracker) served() {
+	if tracker == nil {
+		return
+	}
+	tracker.shared.Add(1)
+}
+
+// stats returns the totals and the running fetches, oldest first
+func (tracker *fetchTracker) stats() models.FetchStats {
+	stats := models.FetchStats{InFlight: []models.InFlightFetch{}}
+	if tracker == nil {
+		return stats
+	}
+	stats.Leaders = tracker.leaders.Load()
+	stats.Shared = tracker.shared.Load()
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	stats.LargestShare = tracker.largestShare
+	for key, fetch := range tracker.inFlight {
+		stats.InFlight = append(stats.InFlight, models.InFlightFetch{
+			Key:       key,
+			StartedAt: fetch.startedAt,
+			Waiters:   fetch.waiters,
+		})
+	}
+	sort.Slice(stats.InFlight, func(i, j int) bool {
+		return stats.InFlight[i].StartedAt.Before(stats.InFlight[j].StartedAt)
+	})
+	return stats
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// blockingProvider holds every fetch until released
+type blockingProvider struct {
+	*testutils.FakeProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (provider *blockingProvider) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
+	provider.started <- struct{}{}
+	<-provider.release
+	return provider.FakeProvider.GetRates(ctx, baseCurrency)
+}
+
+func TestRatesService_CoalescingStats(t *testing.T) {
+	provider := &blockingProvider{
+		FakeProvider: &testutils.FakeProvider{Name: "slow", Enabled: true, Rates: map[string]float64{"EUR": 0.85}},
+		started:      make(chan struct{}, 1),
+		release:      make(chan struct{}),
+	}
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+		fetches:       newFetchTracker(),
+	}
+
+	var wg sync.WaitGroup
+	fetch := func() {
+		defer wg.Done()
+		if _, err := service.GetRates(context.Background(), "USD"); err != nil {
+			t.Errorf("GetRates() error = %v", err)
+		}
+	}
+
+	wg.Add(1)
+	go fetch()
+	<-provider.started
+
+	// Callers arriving while the fetch runs wait on it
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go fetch()
+	}
+	deadline := time.Now().Add(time.Second)
+	var inFlight []models.InFlightFetch
+	for time.Now().Before(deadline) {
+		if inFlight = service.CacheStats().Coalescing.InFlight; len(inFlight) == 1 && inFlight[0].Waiters == 4 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(inFlight) != 1 || inFlight[0].Key != "rates:USD" || inFlight[0].Waiters != 4 {
+		t.Fatalf("in-flight fetches = %+v, want rates:USD with 4 waiters", inFlight)
+	}
+
+	close(provider.release)
+	wg.Wait()
+
+	stats := service.CacheStats().Coalescing
+	if stats.Leaders != 1 || stats.Shared != 4 || stats.LargestShare != 5 || len(stats.InFlight) != 0 {
+		t.Errorf("coalescing stats = %+v, want 1 leader shared with 4 callers", stats)
+	}
+
+	// Cache hits never reach singleflight
+	service.GetRates(context.Background(), "USD")
+	if stats := service.CacheStats().Coalescing; stats.Leaders != 1 || stats.Shared != 4 {
+		t.Errorf("coalescing stats after a cache hit = %+v", stats)
+	}
+}
+
+func TestRatesService_GetRates_Cancellation(t *testing.T) {
+	// The provider ignores its context, like a call stuck on a slow upstream
+	provider := &blockingProvider{
+		FakeProvider: &testutils.FakeProvider{Name: "stuck", Enabled: true, Rates: map[string]float64{"EUR": 0.85}},
+		started:      make(chan struct{}, 2),
+		release:      make(chan struct{}),
+	}
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+		fetches:       newFetchTracker(),
+	}
+	defer close(provider.release)
+
+	// getRates starts GetRates and returns when it returned and with which error
+	type outcome struct {
+		at  time.Time
+		err error
+	}
+	getRates := func(ctx context.Context, base string) chan outcome {
+		returned := make(chan outcome, 1)
+		go func() {
+			_, err := service.GetRates(ctx, base)
+			returned <- outcome{time.Now(), err}
+		}()
+		return returned
+	}
+	// cancel cancels the request and checks that GetRates returns within milliseconds
+	cancel := func(t *testing.T, cancelRequest context.CancelFunc, returned chan outcome) {
+		cancelledAt := time.Now()
+		cancelRequest()
+		select {
+		case result := <-returned:
+			var serviceError *ServiceError
+			if !errors.As(result.err, &serviceError) || serviceError.Type != ErrorTypeContextCancelled {
+				t.Errorf("GetRates() error = %v, want a cancelled request", result.err)
+			}
+			if elapsed := result.at.Sub(cancelledAt); elapsed > 50*time.Millisecond {
+				t.Errorf("GetRates() returned %v after the cancellation", elapsed)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("GetRates() still waits for the provider after the cancellation")
+		}
+	}
+
+	t.Run("fetching caller", func(t *testing.T) {
+		ctx, cancelRequest := context.WithCancel(context.Background())
+		returned := getRates(ctx, "USD")
+		<-provider.started
+		cancel(t, cancelRequest, returned)
+	})
+
+	t.Run("caller waiting on another's fetch", func(t *testing.T) {
+		getRates(context.Background(), "GBP")
+		<-provider.started
+
+		ctx, cancelRequest := context.WithCancel(context.Background())
+		returned := getRates(ctx, "GBP")
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if inFlight := service.CacheStats().Coalescing.InFlight; len(inFlight) == 1 && inFlight[0].Waiters == 1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		cancel(t, cancelRequest, returned)
+	})
+}
+
+// cancellableProvider holds every fetch until released or its context is cancelled
+type cancellableProvider struct {
+	*testutils.FakeProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (provider *cancellableProvider) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
+	provider.started <- struct{}{}
+	select {
+	case <-provider.release:
+		return provider.FakeProvider.GetRates(ctx, baseCurrency)
+	case <-ctx.Done():
+		return models.RatesResponse{}, ctx.Err()
+	}
+}
+
+func TestRatesService_GetRates_FetchingCallerCancelled(t *testing.T) {
+	provider := &cancellableProvider{
+		FakeProvider: &testutils.FakeProvider{Name: "slow", Enabled: true, Rates: map[string]float64{"EUR": 0.85}},
+		started:      make(chan struct{}, 1),
+		release:      make(chan struct{}),
+	}
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+		fetches:       newFetchTracker(),
+	}
+
+	ctx, cancelRequest := context.WithCancel(context.Background())
+	go service.GetRates(ctx, "USD")
+	<-provider.started
+
+	joined := make(chan error, 1)
+	go func() {
+		_, err := service.GetRates(context.Background(), "USD")
+		joined <- err
+	}()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if inFlight := service.CacheStats().Coalescing.InFlight; len(inFlight) == 1 && inFlight[0].Waiters == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The caller that started the fetch goes away; the caller that joined it still
+	// gets the rates
+	cancelRequest()
+	time.Sleep(10 * time.Millisecond)
+	close(provider.release)
+	select {
+	case err := <-joined:
+		if err != nil {
+			t.Errorf("GetRates() of the joined caller error = %v, want rates", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetRates() of the joined caller did not return")
+	}
+}
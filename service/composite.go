@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// combiners reduce the rates members quote for one currency to the served rate
+var combiners = map[string]func(rates []float64, trimPercent float64) float64{
+	"mean":         func(rates []float64, _ float64) float64 { return mean(rates) },
+	"median":       func(rates []float64, _ float64) float64 { return median(rates) },
+	"trimmed_mean": trimmedMean,
+}
+
+// CompositeProvider is a virtual provider serving a blended reference rate: it queries
+// its member providers concurrently and combines the rates they quote per currency.
+// It takes a place in the priority chain like any other provider.
+type CompositeProvider struct {
+	configuration config.ExchangeRateProvider
+	members       []ExchangeRateProvider
+	combine       func(rates []float64, trimPercent float64) float64
+	logger        logger.Logger
+}
+
+// ValidateComposite checks that a composite provider combines known HTTP providers with a
+// known function, and that enough of them are configured to answer
+func ValidateComposite(composite config.ExchangeRateProvider, providers []config.ExchangeRateProvider) error {
+	if _, known := combiners[composite.Combine]; !known {
+		return fmt.Errorf("composite provider %s: unknown combine function %q (want mean, median or trimmed_mean)", composite.Name, composite.Combine)
+	}
+	if composite.TrimPercent < 0 || composite.TrimPercent >= 50 {
+		return fmt.Errorf("composite provider %s: trim percent must be in [0, 50), got %g", composite.Name, composite.TrimPercent)
+	}
+	if composite.MinMembers < 1 || composite.MinMembers > len(composite.Members) {
+		return fmt.Errorf("composite provider %s: needs between 1 and %d answering members, got %d", composite.Name, len(composite.Members), composite.MinMembers)
+	}
+
+	httpProviders := make(map[string]bool, len(providers))
+	for _, provider := range providers {
+		if provider.Type != config.ProviderTypeComposite {
+			httpProviders[provider.Name] = true
+		}
+	}
+	for _, member := range composite.Members {
+		if !httpProviders[member] {
+			return fmt.Errorf("composite provider %s: member %s is not an enabled HTTP provider", composite.Name, member)
+		}
+	}
+	return nil
+}
+
+// newCompositeProvider returns the composite of the members, which the caller resolved
+// from the configured member names
+func newCompositeProvider(configuration config.ExchangeRateProvider, members []ExchangeRateProvider, logger logger.Logger) *CompositeProvider {
+	combine, known := combiners[configuration.Combine]
+	if !known {
+		combine = combiners["median"]
+	}
+	return &CompositeProvider{
+		configuration: configuration,
+		members:       members,
+		combine:       combine,
+		logger:        logger,
+	}
+}
+
+// GetName returns the provider name
+func (composite *CompositeProvider) GetName() string {
+	return composite.configuration.Name
+}
+
+// IsEnabled returns whether the provider is enabled
+func (composite *CompositeProvider) IsEnabled() bool {
+	return composite.configuration.Enabled
+}
+
+// GetPriority returns the provider priority
+func (composite *CompositeProvider) GetPriority() int {
+	return composite.configuration.Priority
+}
+
+// Composite reports the members and how their rates are combined
+func (composite *CompositeProvider) Composite() *models.ProviderComposite {
+	return &models.ProviderComposite{
+		Members:     composite.configuration.Members,
+		Combine:     composite.configuration.Combine,
+		TrimPercent: composite.configuration.TrimPercent,
+		MinMembers:  composite.configuration.MinMembers,
+	}
+}
+
+// GetRates combines the rates of the members that answer. A currency is served when at
+// least MinMembers quote it; the rates are as old as the oldest member's.
+func (composite *CompositeProvider) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
+	responses := make([]models.RatesResponse, len(composite.members))
+	errs := make([]error, len(composite.members))
+	var waitGroup sync.WaitGroup
+	for i, member := range composite.members {
+		waitGroup.Add(1)
+		go func(i int, member ExchangeRateProvider) {
+			defer waitGroup.Done()
+			responses[i], errs[i] = member.GetRates(ctx, baseCurrency)
+		}(i, member)
+	}
+	waitGroup.Wait()
+
+	var answered []models.RatesResponse
+	var failures []error
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+		answered = append(answered, responses[i])
+	}
+	if len(answered) < composite.configuration.MinMembers {
+		return models.RatesResponse{}, composite.failure(len(answered), failures)
+	}
+	if len(failures) > 0 {
+		composite.logger.WithContext(ctx).Warnf("Composite provider %s combined %d of %d members: %v", composite.GetName(), len(answered), len(composite.members), errors.Join(failures...))
+	}
+
+	quotes := make(map[string][]float64)
+	combined := models.RatesResponse{
+		Base:      baseCurrency,
+		Timestamp: math.MaxInt64,
+		Provider:  composite.GetName(),
+		FetchedAt: time.Now().Unix(),
+	}
+	for _, response := range answered {
+		for symbol, rate := range response.Rates {
+			quotes[symbol] = append(quotes[symbol], rate)
+		}
+		combined.Timestamp = min(combined.Timestamp, response.Timestamp)
+		if response.PublishedAt != 0 && (combined.PublishedAt == 0 || response.PublishedAt < combined.PublishedAt) {
+			combined.PublishedAt = response.PublishedAt
+		}
+	}
+
+	combined.Rates = make(models.RateTable, len(quotes))
+	for symbol, rates := range quotes {
+		if len(rates) >= composite.configuration.MinMembers {
+			combined.Rates[symbol] = composite.combine(rates, composite.configuration.TrimPercent)
+		}
+	}
+	combined.Rates[baseCurrency] = 1
+	return combined, nil
+}
+
+// failure builds the error of a request too few members answered: a base every failing
+// member does not support is unsupported, anything else an upstream failure
+func (composite *CompositeProvider) failure(answered int, failures []error) error {
+	kind := ErrUnsupportedBase
+	details := make([]string, len(failures))
+	for i, err := range failures {
+		if !errors.Is(err, ErrUnsupportedBase) {
+			kind = ErrUpstream5xx
+		}
+		details[i] = err.Error()
+	}
+	return &ProviderError{
+		Provider: composite.GetName(),
+		Kind:     kind,
+		Detail: fmt.Sprintf("%d of %d members answered, %d required: %s",
+			answered, len(composite.members), composite.configuration.MinMembers, strings.Join(details, "; ")),
+	}
+}
+
+// mean returns the arithmetic mean of the rates
+func mean(rates []float64) float64 {
+	var sum float64
+	for _, rate := range rates {
+		sum += rate
+	}
+	return sum / float64(len(rates))
+}
+
+// median returns the middle rate, or the mean of the two middle rates
+func median(rates []float64) float64 {
+	sorted := append([]float64(nil), rates...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// trimmedMean returns the mean of the rates without the trimPercent lowest and highest
+// ones, rounding the trimmed count down
+func trimmedMean(rates []float64, trimPercent float64) float64 {
+	sorted := append([]float64(nil), rates...)
+	sort.Float64s(sorted)
+	trim := int(float64(len(sorted)) * trimPercent / 100)
+	return mean(sorted[trim : len(sorted)-trim])
+}
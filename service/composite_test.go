@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestCombiners(t *testing.T) {
+	rates := []float64{0.90, 0.92, 0.91, 0.99, 0.50}
+	tests := []struct {
+		combine string
+		want    float64
+	}{
+		{combine: "mean", want: 0.844},
+		{combine: "median", want: 0.91},
+		{combine: "trimmed_mean", want: 0.91}, // 20% of 5 drops 0.50 and 0.99
+	}
+	for _, tt := range tests {
+		if got := combiners[tt.combine](rates, 20); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s() = %v, want %v", tt.combine, got, tt.want)
+		}
+	}
+	if got := median([]float64{1, 3, 2, 4}); got != 2.5 {
+		t.Errorf("median() of an even count = %v, want 2.5", got)
+	}
+	if got := trimmedMean([]float64{1, 2}, 40); got != 1.5 {
+		t.Errorf("trimmedMean() trimming less than one rate = %v, want 1.5", got)
+	}
+}
+
+func TestCompositeProvider_GetRates(t *testing.T) {
+	configuration := config.ExchangeRateProvider{Name: "blended", Type: config.ProviderTypeComposite, Enabled: true, Combine: "median", MinMembers: 2}
+	members := []ExchangeRateProvider{
+		&testutils.FakeProvider{Name: "a", Enabled: true, Rates: map[string]float64{"EUR": 0.90, "GBP": 0.80, "JPY": 150}},
+		&testutils.FakeProvider{Name: "b", Enabled: true, Rates: map[string]float64{"EUR": 0.92, "GBP": 0.78}},
+		&testutils.FakeProvider{Name: "c", Enabled: true, Rates: map[string]float64{"EUR": 0.95, "GBP": 0.79}},
+		&testutils.FakeProvider{Name: "down", Enabled: true, Err: &ProviderError{Provider: "down", Kind: ErrUpstream5xx}},
+	}
+	composite := newCompositeProvider(configuration, members, testutils.MockLogger())
+
+	response, err := composite.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if response.Provider != "blended" || response.Base != "USD" || response.Rates["USD"] != 1 {
+		t.Errorf("GetRates() = %+v, want blended USD rates", response)
+	}
+	if response.Rates["EUR"] != 0.92 || response.Rates["GBP"] != 0.79 {
+		t.Errorf("GetRates() rates = %v, want the medians", response.Rates)
+	}
+	if _, quoted := response.Rates["JPY"]; quoted {
+		t.Error("GetRates() should drop currencies fewer than MinMembers quote")
+	}
+
+	// Too few members answering fail the composite, not as the members' failure class
+	configuration.MinMembers = 4
+	_, err = newCompositeProvider(configuration, members, testutils.MockLogger()).GetRates(context.Background(), "USD")
+	var providerError *ProviderError
+	if !errors.As(err, &providerError) || providerError.Provider != "blended" || !errors.Is(err, ErrUpstream5xx) || !strings.Contains(providerError.Detail, "3 of 4 members answered") {
+		t.Errorf("GetRates() with too few members error = %v", err)
+	}
+
+	unsupported := []ExchangeRateProvider{
+		&testutils.FakeProvider{Name: "a", Enabled: true, Err: &ProviderError{Provider: "a", Kind: ErrUnsupportedBase}},
+		&testutils.FakeProvider{Name: "b", Enabled: true, Err: &ProviderError{Provider: "b", Kind: ErrUnsupportedBase}},
+	}
+	configuration.MinMembers = 1
+	if _, err := newCompositeProvider(configuration, unsupported, testutils.MockLogger()).GetRates(context.Background(), "XAU"); !errors.Is(err, ErrUnsupportedBase) {
+		t.Errorf("GetRates() of a base no member supports error = %v, want %v", err, ErrUnsupportedBase)
+	}
+}
+
+func TestValidateComposite(t *testing.T) {
+	providers := []config.ExchangeRateProvider{
+		{Name: "erapi"},
+		{Name: "frankfurter", Type: config.ProviderTypeHTTP},
+		{Name: "other", Type: config.ProviderTypeComposite},
+	}
+	valid := config.ExchangeRateProvider{Name: "blended", Type: config.ProviderTypeComposite, Members: []string{"erapi", "frankfurter"}, Combine: "trimmed_mean", TrimPercent: 20, MinMembers: 2}
+	if err := ValidateComposite(valid, providers); err != nil {
+		t.Errorf("ValidateComposite() error = %v", err)
+	}
+
+	tests := map[string]func(composite *config.ExchangeRateProvider){
+		"unknown combine":        func(composite *config.ExchangeRateProvider) { composite.Combine = "mode" },
+		"trim too large":         func(composite *config.ExchangeRateProvider) { composite.TrimPercent = 50 },
+		"too many required":      func(composite *config.ExchangeRateProvider) { composite.MinMembers = 3 },
+		"unknown member":         func(composite *config.ExchangeRateProvider) { composite.Members = []string{"erapi", "missing"} },
+		"composite of composite": func(composite *config.ExchangeRateProvider) { composite.Members = []string{"erapi", "other"} },
+	}
+	for name, change := range tests {
+		composite := valid
+		change(&composite)
+		if err := ValidateComposite(composite, providers); err == nil {
+			t.Errorf("ValidateComposite() with %s should fail", name)
+		}
+	}
+}
+
+func TestProviderFactory_CreateProviders_Composite(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.ExchangeRateProviders = append([]config.ExchangeRateProvider{
+		{Name: "blended", Type: config.ProviderTypeComposite, Enabled: true, Priority: 0, Members: []string{"erapi", "openexchangerates"}, Combine: "mean", MinMembers: 1},
+	}, cfg.ExchangeRateProviders...)
+
+	providers := NewProviderFactory(cfg, testutils.MockLogger()).CreateProviders()
+	if len(providers) != 3 || providers[0].GetName() != "blended" {
+		t.Fatalf("CreateProviders() = %d providers, want the composite first of 3", len(providers))
+	}
+	composite := providers[0].(*CompositeProvider)
+	if len(composite.members) != 2 || composite.members[0] != providers[1] || composite.members[1] != providers[2] {
+		t.Error("the composite should share the member provider instances")
+	}
+	if status := composite.Composite(); status.Combine != "mean" || len(status.Members) != 2 {
+		t.Errorf("Composite() = %+v", status)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// providerSlots caps one provider's in-flight requests. Calls beyond the cap wait in a
+// bounded queue until a slot frees up or their context ends, and fail fast once the
+// queue is full, so a slow provider only holds up its own callers.
+// A nil value is valid and admits every call.
+type providerSlots struct {
+	name      string
+	slots     chan struct{}
+	queueSize int64
+
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+// newProviderSlots returns slots for the provider, or nil when its concurrency is unlimited
+func newProviderSlots(name string, maxConcurrent, queueSize int) *providerSlots {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &providerSlots{
+		name:      name,
+		slots:     make(chan struct{}, maxConcurrent),
+		queueSize: int64(max(queueSize, 0)),
+	}
+}
+
+// acquire takes a slot, queueing for one when the provider is at its cap
+func (slots *providerSlots) acquire(ctx context.Context) error {
+	if slots == nil {
+		return nil
+	}
+
+	select {
+	case slots.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if slots.queued.Add(1) > slots.queueSize {
+		slots.queued.Add(-1)
+		slots.rejected.Add(1)
+		return fmt.Errorf("provider %s is at its concurrency limit with %d calls queued", slots.name, slots.queueSize)
+	}
+	defer slots.queued.Add(-1)
+
+	select {
+	case slots.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (slots *providerSlots) release() {
+	if slots == nil {
+		return
+	}
+	<-slots.slots
+}
+
+// stats reports the slots in use and the waiting calls, or nothing when unlimited
+func (slots *providerSlots) stats() *models.ProviderConcurrency {
+	if slots == nil {
+		return nil
+	}
+	return &models.ProviderConcurrency{
+		MaxConcurrent: cap(slots.slots),
+		InFlight:      len(slots.slots),
+		Queued:        slots.queued.Load(),
+		QueueSize:     slots.queueSize,
+		Rejected:      slots.rejected.Load(),
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProviderSlots(t *testing.T) {
+	slots := newProviderSlots("slow", 1, 1)
+	ctx := context.Background()
+
+	if err := slots.acquire(ctx); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	// The second call queues for the slot until the first releases it
+	acquired := make(chan error, 1)
+	go func() { acquired <- slots.acquire(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for slots.stats().Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("second call never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queue is full, so a third call fails fast
+	if err := slots.acquire(ctx); err == nil {
+		t.Fatal("acquire() with a full queue should fail")
+	}
+
+	slots.release()
+	if err := <-acquired; err != nil {
+		t.Fatalf("queued acquire() error = %v", err)
+	}
+
+	stats := slots.stats()
+	if stats.MaxConcurrent != 1 || stats.InFlight != 1 || stats.Queued != 0 || stats.Rejected != 1 {
+		t.Errorf("stats() = %+v, want 1 in flight and 1 rejected", stats)
+	}
+	slots.release()
+}
+
+func TestProviderSlots_ContextCancelled(t *testing.T) {
+	slots := newProviderSlots("slow", 1, 4)
+	if err := slots.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	defer slots.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := slots.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if queued := slots.stats().Queued; queued != 0 {
+		t.Errorf("stats() Queued = %d after the caller gave up, want 0", queued)
+	}
+}
+
+func TestProviderSlots_Unlimited(t *testing.T) {
+	slots := newProviderSlots("fast", 0, 4)
+	if slots != nil {
+		t.Fatalf("newProviderSlots() with no cap = %+v, want nil", slots)
+	}
+	if err := slots.acquire(context.Background()); err != nil {
+		t.Errorf("nil slots acquire() error = %v", err)
+	}
+	slots.release()
+	if slots.stats() != nil {
+		t.Error("nil slots stats() should be nil")
+	}
+}
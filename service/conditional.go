@@ -0,0 +1,138 @@
+package service
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// validatedResponse is the last rates response parsed from a URL, with the validators
+// and body digest used to recognise it when it is served again
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	digest       [sha256.Size]byte
+	response     models.RatesResponse
+}
+
+// conditionalPoller makes provider polling conditional. It remembers the ETag and
+// Last-Modified validators of each URL's last response and sends them back, so an
+// unchanged rates table costs a bodyless 304; providers without validators have a
+// body identical to the last one recognised and reused without parsing.
+// A nil poller is valid and makes every request unconditional.
+type conditionalPoller struct {
+	mutex     sync.Mutex
+	responses map[string]validatedResponse
+
+	conditionalPolls atomic.Int64 // Requests sent with validators
+	notModifiedPolls atomic.Int64 // 304 responses answered from the remembered response
+	unchangedPolls   atomic.Int64 // 200 responses identical to the remembered body
+}
+
+func newConditionalPoller() *conditionalPoller {
+	return &conditionalPoller{responses: make(map[string]validatedResponse)}
+}
+
+// prepare adds the validators remembered for the request URL to the request
+func (poller *conditionalPoller) prepare(req *http.Request) {
+	if poller == nil {
+		return
+	}
+
+	poller.mutex.Lock()
+	remembered, found := poller.responses[req.URL.String()]
+	poller.mutex.Unlock()
+	if !found || (remembered.etag == "" && remembered.lastModified == "") {
+		return
+	}
+
+	if remembered.etag != "" {
+		req.Header.Set("If-None-Match", remembered.etag)
+	}
+	if remembered.lastModified != "" {
+		req.Header.Set("If-Modified-Since", remembered.lastModified)
+	}
+	poller.conditionalPolls.Add(1)
+}
+
+// notModified returns the remembered response of a URL answered with 304
+func (poller *conditionalPoller) notModified(url string) (models.RatesResponse, bool) {
+	if poller == nil {
+		return models.RatesResponse{}, false
+	}
+
+	response, found := poller.remembered(url)
+	if found {
+		poller.notModifiedPolls.Add(1)
+	}
+	return response, found
+}
+
+// unchanged returns the remembered response of a URL when body is identical to its body
+func (poller *conditionalPoller) unchanged(url string, body []byte) (models.RatesResponse, bool) {
+	if poller == nil {
+		return models.RatesResponse{}, false
+	}
+
+	poller.mutex.Lock()
+	remembered, found := poller.responses[url]
+	poller.mutex.Unlock()
+	if !found || remembered.digest != sha256.Sum256(body) {
+		return models.RatesResponse{}, false
+	}
+
+	response, _ := poller.remembered(url)
+	poller.unchangedPolls.Add(1)
+	return response, true
+}
+
+// remember stores a parsed response with its validators and body digest
+func (poller *conditionalPoller) remember(url string, header http.Header, body []byte, response models.RatesResponse) {
+	if poller == nil {
+		return
+	}
+
+	poller.mutex.Lock()
+	defer poller.mutex.Unlock()
+	poller.responses[url] = validatedResponse{
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+		digest:       sha256.Sum256(body),
+		response:     response,
+	}
+}
+
+// remembered returns a copy of the URL's remembered response, fetched again just now
+func (poller *conditionalPoller) remembered(url string) (models.RatesResponse, bool) {
+	poller.mutex.Lock()
+	defer poller.mutex.Unlock()
+
+	remembered, found := poller.responses[url]
+	if !found {
+		return models.RatesResponse{}, false
+	}
+
+	response := remembered.response
+	response.FetchedAt = time.Now().Unix()
+	if response.PublishedAt == 0 {
+		response.Timestamp = response.FetchedAt
+	}
+	return response, true
+}
+
+// stats reports how many polls were conditional and how many were answered without a
+// new rates table, or nothing when conditional polling is off
+func (poller *conditionalPoller) stats() *models.ProviderPolling {
+	if poller == nil {
+		return nil
+	}
+	return &models.ProviderPolling{
+		Conditional: poller.conditionalPolls.Load(),
+		NotModified: poller.notModifiedPolls.Load(),
+		Unchanged:   poller.unchangedPolls.Load(),
+	}
+}
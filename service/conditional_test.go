@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHTTPExchangeRateProvider_GetRates_NotModified(t *testing.T) {
+	var conditionalRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") != "" {
+			conditionalRequests++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.85}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true}, testutils.MockLogger())
+	provider.poller = newConditionalPoller()
+
+	ctx := context.Background()
+	first, err := provider.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	second, err := provider.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() after 304 error = %v", err)
+	}
+
+	if conditionalRequests != 1 {
+		t.Errorf("conditional requests = %d, want 1", conditionalRequests)
+	}
+	if second.Rates["EUR"] != first.Rates["EUR"] || second.PublishedAt != first.PublishedAt {
+		t.Errorf("GetRates() after 304 = %+v, want %+v", second, first)
+	}
+	if stats := provider.Polling(); stats.Conditional != 1 || stats.NotModified != 1 || stats.Unchanged != 0 {
+		t.Errorf("Polling() = %+v, want 1 conditional and 1 not modified", stats)
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_UnchangedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Error("request without remembered validators should be unconditional")
+		}
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.85}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true}, testutils.MockLogger())
+	provider.poller = newConditionalPoller()
+
+	for i := 0; i < 2; i++ {
+		response, err := provider.GetRates(context.Background(), "USD")
+		if err != nil {
+			t.Fatalf("GetRates() %d error = %v", i, err)
+		}
+		if response.Rates["EUR"] != 0.85 {
+			t.Errorf("GetRates() %d EUR = %v, want 0.85", i, response.Rates["EUR"])
+		}
+	}
+
+	if stats := provider.Polling(); stats.Conditional != 0 || stats.Unchanged != 1 {
+		t.Errorf("Polling() = %+v, want 1 unchanged", stats)
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_NotModifiedWithoutPolling(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true}, testutils.MockLogger())
+	if _, err := provider.GetRates(context.Background(), "USD"); err == nil {
+		t.Error("GetRates() should fail on a 304 with no remembered response")
+	}
+	if provider.Polling() != nil {
+		t.Error("Polling() should be nil when conditional polling is off")
+	}
+}
@@ -0,0 +1,227 @@
+//go:build contract
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// Contract tests run the provider parsers against the live provider APIs, so an upstream
+// format change fails here instead of surfacing as 502s in production. They are excluded
+// from the regular tests:
+//
+//	go test -tags contract ./service -run Contract -v
+//
+// Providers are configured from the environment like the service, keys included.
+// CONTRACT_REPORT names a file the drift report is written to as JSON.
+
+// contractBase is the base requested from every provider
+const contractBase = "USD"
+
+// providerContract is the response schema a provider's parser relies on
+type providerContract struct {
+	required map[string]string // Fields the parser needs, by JSON kind
+	optional map[string]string // Fields the parser reads when present, by JSON kind
+	needsKey bool              // The provider rejects requests without an API key
+}
+
+// providerContracts are the contracts of the built-in providers; custom providers are
+// held to the generic one
+var providerContracts = map[string]providerContract{
+	"erapi": {
+		required: map[string]string{"base_code": "string", "time_last_update_unix": "number", "rates": "object"},
+	},
+	"openexchangerates": {
+		required: map[string]string{"base": "string", "timestamp": "number", "rates": "object"},
+		needsKey: true,
+	},
+	"frankfurter": {
+		required: map[string]string{"base": "string", "date": "string", "rates": "object"},
+		optional: map[string]string{"timestamp": "number"},
+	},
+	"exchangerate.host": {
+		required: map[string]string{"base": "string", "rates": "object"},
+		optional: map[string]string{"date": "string", "timestamp": "number"},
+		needsKey: true,
+	},
+}
+
+// genericContract is the contract of custom providers
+var genericContract = providerContract{
+	required: map[string]string{"base": "string", "rates": "object"},
+	optional: map[string]string{"timestamp": "number", "published_at": "number"},
+}
+
+// contractReport is the outcome of one provider's contract check
+type contractReport struct {
+	Provider       string   `json:"provider"`
+	URL            string   `json:"url,omitempty"`
+	Status         int      `json:"status,omitempty"`
+	Skipped        string   `json:"skipped,omitempty"`
+	RequestError   string   `json:"request_error,omitempty"`
+	MissingFields  []string `json:"missing_fields,omitempty"`
+	MistypedFields []string `json:"mistyped_fields,omitempty"`
+	NewFields      []string `json:"new_fields,omitempty"` // Informational: fields no parser reads
+	ParseError     string   `json:"parse_error,omitempty"`
+	Rates          int      `json:"rates"`
+}
+
+// broken reports whether the provider's answer breaks its contract
+func (report contractReport) broken() bool {
+	return report.RequestError != "" || report.Status != http.StatusOK || len(report.MissingFields) > 0 || len(report.MistypedFields) > 0 || report.ParseError != "" || report.Rates == 0
+}
+
+func TestContract_Providers(t *testing.T) {
+	configuration, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load() error = %v", err)
+	}
+
+	var reports []contractReport
+	t.Cleanup(func() {
+		writeContractReport(t, reports)
+	})
+
+	for _, providerConfig := range configuration.ExchangeRateProviders {
+		if providerConfig.Type == config.ProviderTypeComposite {
+			continue
+		}
+		providerConfig := providerConfig
+		t.Run(providerConfig.Name, func(t *testing.T) {
+			report := checkContract(t, providerConfig)
+			reports = append(reports, report)
+			switch {
+			case report.Skipped != "":
+				t.Skip(report.Skipped)
+			case report.broken():
+				t.Errorf("contract broken: %+v", report)
+			case len(report.NewFields) > 0:
+				t.Logf("new upstream fields: %v", report.NewFields)
+			}
+		})
+	}
+}
+
+// checkContract fetches the provider's latest rates and checks the raw answer against its
+// contract and the parsed one against what the service serves
+func checkContract(t *testing.T, providerConfig config.ExchangeRateProvider) contractReport {
+	contract, known := providerContracts[providerConfig.Name]
+	if !known {
+		contract = genericContract
+	}
+	report := contractReport{Provider: providerConfig.Name}
+	if contract.needsKey && providerConfig.APIKey == "" {
+		report.Skipped = "no API key configured"
+		return report
+	}
+
+	provider := NewHTTPExchangeRateProvider(providerConfig, testutils.MockLogger())
+	report.URL = provider.buildURL(providerConfig.BaseURL, contractBase)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, report.URL, nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		report.RequestError = err.Error()
+		return report
+	}
+	defer response.Body.Close()
+	report.Status = response.StatusCode
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		report.RequestError = err.Error()
+		return report
+	}
+	if response.StatusCode != http.StatusOK {
+		return report
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		report.ParseError = err.Error()
+		return report
+	}
+	for name, kind := range contract.required {
+		value, present := fields[name]
+		if !present {
+			report.MissingFields = append(report.MissingFields, name)
+		} else if jsonKind(value) != kind {
+			report.MistypedFields = append(report.MistypedFields, name+": "+jsonKind(value)+", want "+kind)
+		}
+	}
+	for name, value := range fields {
+		if _, required := contract.required[name]; required {
+			continue
+		}
+		if kind, read := contract.optional[name]; !read {
+			report.NewFields = append(report.NewFields, name)
+		} else if jsonKind(value) != kind {
+			report.MistypedFields = append(report.MistypedFields, name+": "+jsonKind(value)+", want "+kind)
+		}
+	}
+	sort.Strings(report.MissingFields)
+	sort.Strings(report.MistypedFields)
+	sort.Strings(report.NewFields)
+
+	parsed, err := provider.ParseResponse(body, contractBase)
+	if err != nil {
+		report.ParseError = err.Error()
+		return report
+	}
+	report.Rates = len(parsed.Rates)
+	if parsed.Base != contractBase {
+		report.ParseError = "parsed base " + parsed.Base + ", want " + contractBase
+	}
+	return report
+}
+
+// jsonKind names the kind of a JSON value
+func jsonKind(value json.RawMessage) string {
+	if len(value) == 0 {
+		return "empty"
+	}
+	switch value[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// writeContractReport writes the drift report to CONTRACT_REPORT, if set
+func writeContractReport(t *testing.T, reports []contractReport) {
+	path := os.Getenv("CONTRACT_REPORT")
+	if path == "" {
+		return
+	}
+	encoded, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		t.Errorf("encoding the contract report: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, encoded, 0o644); err != nil {
+		t.Errorf("writing the contract report: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Convert converts an amount between two currencies at the mid, bid or ask side of the
+// rate ("" = mid), applying the configured markup
+func (ratesService *RatesService) Convert(requestContext context.Context, fromCurrency, toCurrency string, amount float64, side string) (models.ConversionResponse, error) {
+	if err := ratesService.validateConversion(amount, []string{toCurrency}); err != nil {
+		return models.ConversionResponse{}, err
+	}
+
+	exchangeRates, err := ratesService.conversionRates(requestContext, fromCurrency, []string{toCurrency})
+	if err != nil {
+		return models.ConversionResponse{}, err
+	}
+	return ratesService.convert(exchangeRates, fromCurrency, toCurrency, amount, side)
+}
+
+// ConvertMany converts an amount into each target currency from a single rates fetch
+func (ratesService *RatesService) ConvertMany(requestContext context.Context, fromCurrency string, toCurrencies []string, amount float64, side string) (models.MultiConversionResponse, error) {
+	if err := ratesService.validateConversion(amount, toCurrencies); err != nil {
+		return models.MultiConversionResponse{}, err
+	}
+
+	exchangeRates, err := ratesService.conversionRates(requestContext, fromCurrency, toCurrencies)
+	if err != nil {
+		return models.MultiConversionResponse{}, err
+	}
+
+	conversions := make([]models.ConversionResponse, 0, len(toCurrencies))
+	for _, toCurrency := range toCurrencies {
+		conversion, err := ratesService.convert(exchangeRates, fromCurrency, toCurrency, amount, side)
+		if err != nil {
+			return models.MultiConversionResponse{}, err
+		}
+		conversions = append(conversions, conversion)
+	}
+
+	return models.MultiConversionResponse{
+		From:        fromCurrency,
+		Amount:      amount,
+		Timestamp:   exchangeRates.Timestamp,
+		Provider:    exchangeRates.Provider,
+		Conversions: conversions,
+	}, nil
+}
+
+// conversionRates returns the rates of the source currency to convert with. Under a source
+// policy they come from a provider permitted for every target currency; otherwise the
+// complete table is used as is, sparing the hot path the symbols filter.
+func (ratesService *RatesService) conversionRates(requestContext context.Context, fromCurrency string, toCurrencies []string) (models.RatesResponse, error) {
+	if ratesService.sourcePolicy == nil {
+		return ratesService.GetRates(requestContext, fromCurrency)
+	}
+	return ratesService.GetRatesForSymbols(requestContext, fromCurrency, toCurrencies)
+}
+
+// validateConversion checks the amount and that every target currency may be used
+func (ratesService *RatesService) validateConversion(amount float64, toCurrencies []string) error {
+	if amount <= 0 {
+		return &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: "amount must be greater than zero",
+		}
+	}
+	if math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: "amount must be a finite number",
+		}
+	}
+
+	for _, toCurrency := range toCurrencies {
+		if !ratesService.IsCurrencyAllowed(toCurrency) {
+			return &ServiceError{
+				Type:    ErrorTypeInvalidRequest,
+				Message: fmt.Sprintf("currency not allowed: %s", toCurrency),
+			}
+		}
+	}
+	return nil
+}
+
+// convert converts an amount using rates already fetched for the source currency. The
+// markup applies to the quote of the side, which is the mid rate without spread data.
+// The fixed fee is in the source currency and deducted before converting; an amount
+// that does not cover it is rejected.
+func (ratesService *RatesService) convert(exchangeRates models.RatesResponse, fromCurrency, toCurrency string, amount float64, side string) (models.ConversionResponse, error) {
+	midRate, quote, midFallback := 1.0, 1.0, false
+	if fromCurrency != toCurrency {
+		rate, found := exchangeRates.Rates[toCurrency]
+		if !found {
+			return models.ConversionResponse{}, &ServiceError{
+				Type:    ErrorTypeInvalidRequest,
+				Message: fmt.Sprintf("unsupported target currency: %s", toCurrency),
+			}
+		}
+		midRate = rate
+		quote, _, midFallback = exchangeRates.Quote(toCurrency, side)
+	}
+	if side == models.SideMid {
+		side = ""
+	}
+
+	markupBPS := markupFor(ratesService.configuration.Markup, fromCurrency, toCurrency)
+	appliedRate := applyMarkup(quote, markupBPS)
+
+	fee := ratesService.configuration.Markup.FixedFee
+	if fee > 0 && amount <= fee {
+		return models.ConversionResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: fmt.Sprintf("amount %g %s does not cover the fixed fee of %g %s", amount, fromCurrency, fee, fromCurrency),
+		}
+	}
+	convertedAmount := (amount - fee) * appliedRate
+
+	return models.ConversionResponse{
+		From:      fromCurrency,
+		To:        toCurrency,
+		Amount:    amount,
+		MidRate:   midRate,
+		Rate:      appliedRate,
+		MarkupBPS: markupBPS,
+		Fee:       fee,
+		Converted: convertedAmount,
+		Timestamp: exchangeRates.Timestamp,
+		Provider:  exchangeRates.Provider,
+
+		Side:        side,
+		MidFallback: midFallback,
+	}, nil
+}
+
+// markupFor returns the markup in basis points for a pair, preferring per-pair overrides
+func markupFor(markup config.MarkupConfig, fromCurrency, toCurrency string) float64 {
+	if pairBPS, found := markup.PairBPS[fromCurrency+"/"+toCurrency]; found {
+		return pairBPS
+	}
+	return markup.GlobalBPS
+}
+
+// applyMarkup reduces the mid-market rate by the given basis points
+func applyMarkup(midRate, markupBPS float64) float64 {
+	return midRate * (1 - markupBPS/10000)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_Convert(t *testing.T) {
+	tests := []struct {
+		name          string
+		markup        config.MarkupConfig
+		to            string
+		amount        float64
+		wantMidRate   float64
+		wantRate      float64
+		wantConverted float64
+	}{
+		{
+			name:          "no markup",
+			to:            "EUR",
+			amount:        100,
+			wantMidRate:   0.85,
+			wantRate:      0.85,
+			wantConverted: 85,
+		},
+		{
+			name:          "global markup",
+			markup:        config.MarkupConfig{GlobalBPS: 100},
+			to:            "EUR",
+			amount:        100,
+			wantMidRate:   0.85,
+			wantRate:      0.8415,
+			wantConverted: 84.15,
+		},
+		{
+			name: "pair markup overrides global",
+			markup: config.MarkupConfig{
+				GlobalBPS: 100,
+				PairBPS:   map[string]float64{"USD/EUR": 200},
+			},
+			to:            "EUR",
+			amount:        100,
+			wantMidRate:   0.85,
+			wantRate:      0.833,
+			wantConverted: 83.3,
+		},
+		{
+			name:          "fixed fee",
+			markup:        config.MarkupConfig{FixedFee: 10},
+			to:            "EUR",
+			amount:        100,
+			wantMidRate:   0.85,
+			wantRate:      0.85,
+			wantConverted: 76.5,
+		},
+		{
+			name:          "same currency",
+			to:            "USD",
+			amount:        100,
+			wantMidRate:   1,
+			wantRate:      1,
+			wantConverted: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutils.MockConfig()
+			cfg.Markup = tt.markup
+
+			service := &RatesService{
+				configuration: cfg,
+				logger:        testutils.MockLogger(),
+				providers: []ExchangeRateProvider{&testutils.FakeProvider{
+					Name:    "test-provider",
+					Enabled: true,
+					Rates:   map[string]float64{"EUR": 0.85},
+				}},
+			}
+
+			result, err := service.Convert(context.Background(), "USD", tt.to, tt.amount, "")
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+			if result.MidRate != tt.wantMidRate {
+				t.Errorf("Convert() MidRate = %v, want %v", result.MidRate, tt.wantMidRate)
+			}
+			if math.Abs(result.Rate-tt.wantRate) > 1e-9 {
+				t.Errorf("Convert() Rate = %v, want %v", result.Rate, tt.wantRate)
+			}
+			if math.Abs(result.Converted-tt.wantConverted) > 1e-9 {
+				t.Errorf("Convert() Converted = %v, want %v", result.Converted, tt.wantConverted)
+			}
+		})
+	}
+}
+
+func TestRatesService_Convert_AmountBelowFee(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.Markup = config.MarkupConfig{FixedFee: 10}
+	service := &RatesService{
+		configuration: cfg,
+		logger:        testutils.MockLogger(),
+		providers: []ExchangeRateProvider{&testutils.FakeProvider{
+			Name:    "test-provider",
+			Enabled: true,
+			Rates:   map[string]float64{"EUR": 0.85},
+		}},
+	}
+
+	for _, amount := range []float64{5, 10} {
+		_, err := service.Convert(context.Background(), "USD", "EUR", amount, "")
+		serviceError, ok := err.(*ServiceError)
+		if !ok || serviceError.Type != ErrorTypeInvalidRequest {
+			t.Errorf("Convert(%v) with a fee of 10 error = %v, want an invalid request", amount, err)
+		}
+	}
+}
+
+func TestRatesService_Convert_UnsupportedCurrency(t *testing.T) {
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers: []ExchangeRateProvider{&testutils.FakeProvider{
+			Name:    "test-provider",
+			Enabled: true,
+			Rates:   map[string]float64{"EUR": 0.85},
+		}},
+	}
+
+	_, err := service.Convert(context.Background(), "USD", "XYZ", 100, "")
+	serviceError, ok := err.(*ServiceError)
+	if !ok {
+		t.Fatalf("Convert() error = %v, want *ServiceError", err)
+	}
+	if serviceError.Type != ErrorTypeInvalidRequest {
+		t.Errorf("Convert() error type = %v, want %v", serviceError.Type, ErrorTypeInvalidRequest)
+	}
+}
+
+func TestRatesService_ConvertMany(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.Markup = config.MarkupConfig{GlobalBPS: 100, PairBPS: map[string]float64{"USD/JPY": 0}}
+	provider := &testutils.FakeProvider{
+		Name:    "test-provider",
+		Enabled: true,
+		Rates:   map[string]float64{"EUR": 0.85, "GBP": 0.75, "JPY": 150},
+	}
+	service := &RatesService{
+		configuration: cfg,
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+	}
+
+	result, err := service.ConvertMany(context.Background(), "USD", []string{"EUR", "GBP", "JPY"}, 100, "")
+	if err != nil {
+		t.Fatalf("ConvertMany() error = %v", err)
+	}
+	if provider.Calls() != 1 {
+		t.Errorf("ConvertMany() fetched rates %d times, want 1", provider.Calls())
+	}
+	if result.From != "USD" || result.Amount != 100 || result.Provider != "test-provider" || len(result.Conversions) != 3 {
+		t.Fatalf("ConvertMany() = %+v, want 3 conversions from USD", result)
+	}
+
+	want := map[string]float64{"EUR": 84.15, "GBP": 74.25, "JPY": 15000}
+	for _, conversion := range result.Conversions {
+		if math.Abs(conversion.Converted-want[conversion.To]) > 1e-9 {
+			t.Errorf("ConvertMany() %s Converted = %v, want %v", conversion.To, conversion.Converted, want[conversion.To])
+		}
+	}
+
+	// One unsupported target fails the whole request
+	_, err = service.ConvertMany(context.Background(), "USD", []string{"EUR", "XYZ"}, 100, "")
+	if serviceError, ok := err.(*ServiceError); !ok || serviceError.Type != ErrorTypeInvalidRequest {
+		t.Errorf("ConvertMany() error = %v, want invalid request", err)
+	}
+}
+
+func TestRatesService_Convert_Side(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.Markup = config.MarkupConfig{GlobalBPS: 100}
+	service := &RatesService{
+		configuration: cfg,
+		logger:        testutils.MockLogger(),
+		providers: []ExchangeRateProvider{&testutils.FakeProvider{
+			Name:    "dealer",
+			Enabled: true,
+			Rates:   map[string]float64{"EUR": 0.85, "GBP": 0.75},
+			Bid:     map[string]float64{"EUR": 0.84},
+			Ask:     map[string]float64{"EUR": 0.86},
+		}},
+	}
+
+	result, err := service.Convert(context.Background(), "USD", "EUR", 100, models.SideAsk)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if result.MidRate != 0.85 || math.Abs(result.Rate-0.8514) > 1e-9 || result.Side != models.SideAsk || result.MidFallback {
+		t.Errorf("Convert(ask) = %+v, want the marked-up ask with the mid rate", result)
+	}
+
+	result, err = service.Convert(context.Background(), "USD", "GBP", 100, models.SideBid)
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if math.Abs(result.Rate-0.7425) > 1e-9 || !result.MidFallback {
+		t.Errorf("Convert(bid) without spread data = %+v, want the mid rate flagged", result)
+	}
+}
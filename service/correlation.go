@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+)
+
+// RequestIDHeader carries the ID of the API request a provider call is made for, so
+// upstream support can find the call under the ID in our logs
+const RequestIDHeader = "X-Request-ID"
+
+// Trace context headers: W3C Trace Context, and B3 in its single and multi header forms
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+	B3Header          = "b3"
+	B3TraceIDHeader   = "X-B3-TraceId"
+)
+
+// traceHeaders are the trace context headers passed through from API requests to
+// provider calls
+var traceHeaders = []string{
+	TraceparentHeader, TracestateHeader,
+	B3Header, B3TraceIDHeader, "X-B3-SpanId", "X-B3-ParentSpanId", "X-B3-Sampled", "X-B3-Flags",
+}
+
+// maxTraceHeaderLength bounds a passed-through trace header, the length W3C Trace
+// Context asks tracestate propagation to keep at least
+const maxTraceHeaderLength = 512
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// traceContextKey is the context key of the trace context headers
+type traceContextKey struct{}
+
+// WithRequestID returns a context whose provider calls carry the request ID, and whose
+// loggers log it as request_id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = logger.ContextWithFields(ctx, logger.Fields{"request_id": requestID})
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID carried by the context, or "" for calls made
+// outside an API request (cache warm-up, refreshes and readiness checks)
+func RequestIDFrom(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// TraceContext returns the trace context headers of an API request to pass through to
+// provider calls, or nil when it has none. An invalid traceparent is dropped with its
+// tracestate, as W3C Trace Context requires, and so are headers over 512 bytes.
+func TraceContext(header http.Header) http.Header {
+	var traceContext http.Header
+	for _, name := range traceHeaders {
+		value := header.Get(name)
+		if value == "" || len(value) > maxTraceHeaderLength {
+			continue
+		}
+		if traceContext == nil {
+			traceContext = make(http.Header, len(traceHeaders))
+		}
+		traceContext.Set(name, value)
+	}
+	if traceContext != nil && !validTraceparent(traceContext.Get(TraceparentHeader)) {
+		traceContext.Del(TraceparentHeader)
+		traceContext.Del(TracestateHeader)
+	}
+	return traceContext
+}
+
+// WithTraceContext returns a context whose provider calls carry the trace context
+// headers, and whose loggers log their trace ID as trace_id
+func WithTraceContext(ctx context.Context, traceContext http.Header) context.Context {
+	if traceID := TraceID(traceContext); traceID != "" {
+		ctx = logger.ContextWithFields(ctx, logger.Fields{"trace_id": traceID})
+	}
+	return context.WithValue(ctx, traceContextKey{}, traceContext)
+}
+
+// TraceContextFrom returns the trace context headers carried by the context, or nil
+func TraceContextFrom(ctx context.Context) http.Header {
+	traceContext, _ := ctx.Value(traceContextKey{}).(http.Header)
+	return traceContext
+}
+
+// TraceID returns the trace ID of trace context headers, from the traceparent or else
+// from B3, or "" without one
+func TraceID(traceContext http.Header) string {
+	if traceparent := traceContext.Get(TraceparentHeader); traceparent != "" {
+		return traceparent[3:35]
+	}
+	if traceID := traceContext.Get(B3TraceIDHeader); traceID != "" {
+		return traceID
+	}
+	traceID, _, found := strings.Cut(traceContext.Get(B3Header), "-")
+	if !found {
+		return ""
+	}
+	return traceID
+}
+
+// validTraceparent reports whether a traceparent is "version-traceid-parentid-flags"
+// with non-zero IDs; versions after 00 may append fields
+func validTraceparent(traceparent string) bool {
+	if len(traceparent) < 55 || (len(traceparent) > 55 && (traceparent[:2] == "00" || traceparent[55] != '-')) {
+		return false
+	}
+	version, traceID, parentID, flags := traceparent[0:2], traceparent[3:35], traceparent[36:52], traceparent[53:55]
+	return traceparent[2] == '-' && traceparent[35] == '-' && traceparent[52] == '-' &&
+		isLowerHex(version) && version != "ff" && isLowerHex(flags) &&
+		isLowerHex(traceID) && strings.Trim(traceID, "0") != "" &&
+		isLowerHex(parentID) && strings.Trim(parentID, "0") != ""
+}
+
+// isLowerHex reports whether a string only holds lowercase hexadecimal digits
+func isLowerHex(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if !('0' <= value[index] && value[index] <= '9' || 'a' <= value[index] && value[index] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
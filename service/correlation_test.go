@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestHTTPExchangeRateProvider_GetRates_ForwardsRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		forward   bool
+		want      string
+	}{
+		{name: "forwarded", requestID: "req-123", forward: true, want: "req-123"},
+		{name: "forwarding disabled", requestID: "req-123", forward: false, want: ""},
+		{name: "no API request", requestID: "", forward: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Get(RequestIDHeader)
+				w.Write([]byte(`{"result": "success", "base_code": "USD", "rates": {"EUR": 0.85}}`))
+			}))
+			defer server.Close()
+
+			provider := NewHTTPExchangeRateProvider(
+				config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true, ForwardRequestID: tt.forward},
+				testutils.MockLogger(),
+			)
+
+			ctx := context.Background()
+			if tt.requestID != "" {
+				ctx = WithRequestID(ctx, tt.requestID)
+			}
+			if _, err := provider.GetRates(ctx, "USD"); err != nil {
+				t.Fatalf("GetRates() error = %v", err)
+			}
+			if received != tt.want {
+				t.Errorf("%s header = %q, want %q", RequestIDHeader, received, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_ErrorCarriesRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true, ForwardRequestID: true},
+		testutils.MockLogger(),
+	)
+
+	_, err := provider.GetRates(WithRequestID(context.Background(), "req-123"), "USD")
+	if err == nil || !strings.Contains(err.Error(), "[request req-123]") {
+		t.Errorf("GetRates() error = %v, want it to name request req-123", err)
+	}
+
+	server.Close()
+	_, err = provider.GetRates(WithRequestID(context.Background(), "req-456"), "USD")
+	if err == nil || !strings.Contains(err.Error(), "[request req-456]") {
+		t.Errorf("GetRates() transport error = %v, want it to name request req-456", err)
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		header      http.Header
+		want        http.Header
+		wantTraceID string
+	}{
+		{
+			name:        "W3C trace context",
+			header:      http.Header{"Traceparent": {traceparent}, "Tracestate": {"vendor=value"}, "Accept": {"*/*"}},
+			want:        http.Header{"Traceparent": {traceparent}, "Tracestate": {"vendor=value"}},
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:   "invalid traceparent dropped with its tracestate",
+			header: http.Header{"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}, "Tracestate": {"vendor=value"}},
+			want:   http.Header{},
+		},
+		{
+			name:        "future version with more fields",
+			header:      http.Header{"Traceparent": {"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"}},
+			want:        http.Header{"Traceparent": {"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"}},
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:        "B3 single header",
+			header:      http.Header{"B3": {"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"}},
+			want:        http.Header{"B3": {"80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"}},
+			wantTraceID: "80f198ee56343ba864fe8b2a57d3eff7",
+		},
+		{
+			name:        "B3 multi headers",
+			header:      http.Header{"X-B3-Traceid": {"463ac35c9f6413ad"}, "X-B3-Spanid": {"a2fb4a1d1a96d312"}, "X-B3-Sampled": {"1"}},
+			want:        http.Header{"X-B3-Traceid": {"463ac35c9f6413ad"}, "X-B3-Spanid": {"a2fb4a1d1a96d312"}, "X-B3-Sampled": {"1"}},
+			wantTraceID: "463ac35c9f6413ad",
+		},
+		{
+			name:   "oversized header dropped",
+			header: http.Header{"Tracestate": {strings.Repeat("a", 513)}},
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TraceContext(tt.header)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TraceContext() = %v, want %v", got, tt.want)
+			}
+			if traceID := TraceID(got); traceID != tt.wantTraceID {
+				t.Errorf("TraceID() = %q, want %q", traceID, tt.wantTraceID)
+			}
+		})
+	}
+}
+
+func TestCorrelationLogFields(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithTraceContext(ctx, http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}})
+
+	want := logger.Fields{"request_id": "req-1", "trace_id": "4bf92f3577b34da6a3ce929d0e0e4736"}
+	if got := logger.FieldsFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldsFromContext() = %v, want %v", got, want)
+	}
+
+	// A request without trace context logs no empty trace_id
+	ctx = WithTraceContext(WithRequestID(context.Background(), "req-2"), nil)
+	if got := logger.FieldsFromContext(ctx); !reflect.DeepEqual(got, logger.Fields{"request_id": "req-2"}) {
+		t.Errorf("FieldsFromContext() = %v, want only the request_id", got)
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_ForwardsTraceContext(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	for _, forward := range []bool{true, false} {
+		var received http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+			w.Write([]byte(`{"result": "success", "base_code": "USD", "rates": {"EUR": 0.85}}`))
+		}))
+
+		provider := NewHTTPExchangeRateProvider(
+			config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true, ForwardRequestID: forward},
+			testutils.MockLogger(),
+		)
+		ctx := WithTraceContext(context.Background(), TraceContext(http.Header{"Traceparent": {traceparent}, "X-B3-Sampled": {"1"}}))
+		if _, err := provider.GetRates(ctx, "USD"); err != nil {
+			t.Fatalf("GetRates() error = %v", err)
+		}
+		server.Close()
+
+		if forward && (received.Get(TraceparentHeader) != traceparent || received.Get("X-B3-Sampled") != "1") {
+			t.Errorf("provider request headers = %v, want the trace context passed through", received)
+		}
+		if !forward && received.Get(TraceparentHeader) != "" {
+			t.Errorf("provider request headers = %v, want no trace context when forwarding is disabled", received)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Interpolation modes of conversions at past dates without a stored close
+const (
+	InterpolationNone    = "none"
+	InterpolationNearest = "nearest"
+	InterpolationLinear  = "linear"
+)
+
+// dailyInterval is the resolution of dated conversions and of the stored closes they read
+const dailyInterval = 24 * time.Hour
+
+// historySource is the provider reported for rates read from the stored history
+const historySource = "history"
+
+// RateHistory reads OHLC points from the stored rate history
+type RateHistory interface {
+	TimeSeries(ctx context.Context, base, currency string, from, to time.Time, interval time.Duration) ([]models.TimeSeriesPoint, error)
+}
+
+// SetRateHistory lets conversions at past dates read daily closes from the history
+func (ratesService *RatesService) SetRateHistory(history RateHistory) {
+	ratesService.rateHistory = history
+}
+
+// ValidateDatedRates checks the interpolation mode, its gap and the forward points pairs
+func ValidateDatedRates(configuration config.DatedRatesConfig) error {
+	switch configuration.Interpolation {
+	case InterpolationNone, InterpolationNearest, InterpolationLinear:
+	default:
+		return fmt.Errorf("HISTORY_INTERPOLATION must be none, nearest or linear, got %q", configuration.Interpolation)
+	}
+	if configuration.Interpolation != InterpolationNone && configuration.MaxGap < dailyInterval {
+		return fmt.Errorf("HISTORY_INTERPOLATION_MAX_GAP_DAYS must be at least 1")
+	}
+	for pair := range configuration.ForwardPoints {
+		fromCurrency, toCurrency, found := strings.Cut(pair, "/")
+		if !found || len(fromCurrency) != 3 || len(toCurrency) != 3 {
+			return fmt.Errorf("FORWARD_POINTS pair %q must be FROM/TO", pair)
+		}
+	}
+	return nil
+}
+
+// ConvertAt converts an amount at the pair's rate on a UTC day. Today converts at the
+// latest rates; a past day at its stored daily close, or at one interpolated from the
+// closes around it when it has none; a future day at the spot rate moved by the pair's
+// forward points. Stored closes and forward rates are mid rates, so the bid and ask sides
+// fall back to them.
+func (ratesService *RatesService) ConvertAt(requestContext context.Context, fromCurrency, toCurrency string, amount float64, date time.Time, side string) (models.ConversionResponse, error) {
+	if err := ratesService.validateConversion(amount, []string{toCurrency}); err != nil {
+		return models.ConversionResponse{}, err
+	}
+
+	requested := date.UTC().Truncate(dailyInterval)
+	today := ratesService.now().UTC().Truncate(dailyInterval)
+
+	var conversion models.ConversionResponse
+	var err error
+	switch {
+	case requested.Equal(today) || fromCurrency == toCurrency:
+		conversion, err = ratesService.Convert(requestContext, fromCurrency, toCurrency, amount, side)
+	case requested.After(today):
+		conversion, err = ratesService.convertForward(requestContext, fromCurrency, toCurrency, amount, requested.Sub(today), side)
+	default:
+		conversion, err = ratesService.convertHistorical(requestContext, fromCurrency, toCurrency, amount, requested, side)
+	}
+	if err != nil {
+		return models.ConversionResponse{}, err
+	}
+	conversion.Date = requested.Format(historyDateLayout)
+	return conversion, nil
+}
+
+// convertForward converts at the spot rate plus the pair's annualised forward points,
+// prorated over the days until the requested day
+func (ratesService *RatesService) convertForward(requestContext context.Context, fromCurrency, toCurrency string, amount float64, ahead time.Duration, side string) (models.ConversionResponse, error) {
+	points, found := ratesService.configuration.DatedRates.ForwardPoints[fromCurrency+"/"+toCurrency]
+	if !found {
+		return models.ConversionResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: fmt.Sprintf("no forward points configured for %s/%s", fromCurrency, toCurrency),
+		}
+	}
+
+	exchangeRates, err := ratesService.conversionRates(requestContext, fromCurrency, []string{toCurrency})
+	if err != nil {
+		return models.ConversionResponse{}, err
+	}
+	spot, found := exchangeRates.Rates[toCurrency]
+	if !found {
+		return models.ConversionResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: fmt.Sprintf("unsupported target currency: %s", toCurrency),
+		}
+	}
+
+	forwardRate := spot + points/10000*float64(ahead/dailyInterval)/365
+	conversion, err := ratesService.convert(models.RatesResponse{
+		Base:      fromCurrency,
+		Rates:     map[string]float64{toCurrency: forwardRate},
+		Timestamp: exchangeRates.Timestamp,
+		Provider:  exchangeRates.Provider,
+	}, fromCurrency, toCurrency, amount, side)
+	conversion.Forward = true
+	return conversion, err
+}
+
+// convertHistorical converts at the stored daily close of a past day, interpolating
+// within the configured gap when the day has none
+func (ratesService *RatesService) convertHistorical(requestContext context.Context, fromCurrency, toCurrency string, amount float64, requested time.Time, side string) (models.ConversionResponse, error) {
+	if ratesService.rateHistory == nil {
+		return models.ConversionResponse{}, &ServiceError{
+			Type:    ErrorTypeHistoryUnavailable,
+			Message: "rate history is not configured",
+		}
+	}
+	if !ratesService.IsCurrencyAllowed(fromCurrency) {
+		return models.ConversionResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: fmt.Sprintf("currency not allowed: %s", fromCurrency),
+		}
+	}
+
+	mode := ratesService.configuration.DatedRates.Interpolation
+	gap := time.Duration(0)
+	if mode != InterpolationNone && mode != "" {
+		gap = ratesService.configuration.DatedRates.MaxGap.Truncate(dailyInterval)
+	}
+	points, err := ratesService.dailyCloses(requestContext, fromCurrency, toCurrency, requested.Add(-gap), requested.Add(gap+dailyInterval))
+	if err != nil {
+		return models.ConversionResponse{}, err
+	}
+
+	rate, interpolated, found := closeAt(points, requested, mode)
+	if !found {
+		return models.ConversionResponse{}, &ServiceError{
+			Type:    ErrorTypeRateNotFound,
+			Message: fmt.Sprintf("no stored %s/%s rate for %s", fromCurrency, toCurrency, requested.Format(historyDateLayout)),
+		}
+	}
+
+	conversion, err := ratesService.convert(models.RatesResponse{
+		Base:      fromCurrency,
+		Rates:     map[string]float64{toCurrency: rate},
+		Timestamp: requested.Unix(),
+		Provider:  historySource,
+	}, fromCurrency, toCurrency, amount, side)
+	conversion.Interpolated = interpolated
+	return conversion, err
+}
+
+// dailyCloses returns the stored daily points of the pair, inverting those of the
+// reverse pair when the history only holds the pair the other way round
+func (ratesService *RatesService) dailyCloses(requestContext context.Context, fromCurrency, toCurrency string, from, to time.Time) ([]models.TimeSeriesPoint, error) {
+	points, err := ratesService.rateHistory.TimeSeries(requestContext, fromCurrency, toCurrency, from, to, dailyInterval)
+	if err == nil && len(points) == 0 {
+		var reverse []models.TimeSeriesPoint
+		reverse, err = ratesService.rateHistory.TimeSeries(requestContext, toCurrency, fromCurrency, from, to, dailyInterval)
+		for _, point := range reverse {
+			if point.Close > 0 {
+				point.Close = 1 / point.Close
+				points = append(points, point)
+			}
+		}
+	}
+	if err != nil {
+		return nil, &ServiceError{
+			Type:    ErrorTypeHistoryUnavailable,
+			Message: "failed to read rate history",
+			Cause:   err,
+		}
+	}
+	return points, nil
+}
+
+// closeAt returns the close of the requested day among points ordered by time. Without
+// one, nearest takes the closest close, the earlier on a tie, and linear interpolates
+// between the closes on either side, taking the closest at the edges of the history.
+func closeAt(points []models.TimeSeriesPoint, requested time.Time, mode string) (rate float64, interpolated, found bool) {
+	var before, after *models.TimeSeriesPoint
+	for index := range points {
+		point := &points[index]
+		switch {
+		case point.Time.Equal(requested):
+			return point.Close, false, true
+		case point.Time.Before(requested):
+			before = point
+		case after == nil:
+			after = point
+		}
+	}
+
+	switch {
+	case mode != InterpolationNearest && mode != InterpolationLinear, before == nil && after == nil:
+		return 0, false, false
+	case mode == InterpolationLinear && before != nil && after != nil:
+		weight := float64(requested.Sub(before.Time)) / float64(after.Time.Sub(before.Time))
+		return before.Close + (after.Close-before.Close)*weight, true, true
+	case after == nil || (before != nil && requested.Sub(before.Time) <= after.Time.Sub(requested)):
+		return before.Close, true, true
+	default:
+		return after.Close, true, true
+	}
+}
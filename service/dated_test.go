@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// fakeRateHistory serves stored daily closes by "BASE/CURRENCY"
+type fakeRateHistory struct {
+	closes map[string]map[string]float64 // Close by pair, then by YYYY-MM-DD
+}
+
+func (history *fakeRateHistory) TimeSeries(ctx context.Context, base, currency string, from, to time.Time, interval time.Duration) ([]models.TimeSeriesPoint, error) {
+	points := []models.TimeSeriesPoint{}
+	for day := from; day.Before(to); day = day.Add(interval) {
+		if rate, found := history.closes[base+"/"+currency][day.Format(historyDateLayout)]; found {
+			points = append(points, models.TimeSeriesPoint{Time: day, Close: rate, Samples: 1})
+		}
+	}
+	return points, nil
+}
+
+func datedRatesService(dated config.DatedRatesConfig) *RatesService {
+	cfg := testutils.MockConfig()
+	cfg.DatedRates = dated
+	return &RatesService{
+		configuration: cfg,
+		logger:        testutils.MockLogger(),
+		clock:         testutils.NewFakeClock(time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)),
+		providers: []ExchangeRateProvider{&testutils.FakeProvider{
+			Name:    "test-provider",
+			Enabled: true,
+			Rates:   map[string]float64{"EUR": 0.90},
+		}},
+		rateHistory: &fakeRateHistory{closes: map[string]map[string]float64{
+			// Friday and Monday closes around a weekend
+			"USD/EUR": {"2024-03-08": 0.92, "2024-03-11": 0.95},
+			"GBP/USD": {"2024-03-08": 1.25},
+		}},
+	}
+}
+
+func TestRatesService_ConvertAt(t *testing.T) {
+	tests := []struct {
+		name             string
+		interpolation    string
+		date             time.Time
+		wantMidRate      float64
+		wantInterpolated bool
+		wantProvider     string
+		wantErrorType    ErrorType
+	}{
+		{name: "stored close", interpolation: InterpolationNone, date: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), wantMidRate: 0.92, wantProvider: historySource},
+		{name: "weekend without interpolation", interpolation: InterpolationNone, date: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), wantErrorType: ErrorTypeRateNotFound},
+		{name: "nearest prefers the earlier close", interpolation: InterpolationNearest, date: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), wantMidRate: 0.92, wantInterpolated: true, wantProvider: historySource},
+		{name: "nearest later close", interpolation: InterpolationNearest, date: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), wantMidRate: 0.95, wantInterpolated: true, wantProvider: historySource},
+		{name: "linear", interpolation: InterpolationLinear, date: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), wantMidRate: 0.93, wantInterpolated: true, wantProvider: historySource},
+		{name: "linear at the edge takes the nearest", interpolation: InterpolationLinear, date: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), wantMidRate: 0.95, wantInterpolated: true, wantProvider: historySource},
+		{name: "beyond the gap", interpolation: InterpolationNearest, date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), wantErrorType: ErrorTypeRateNotFound},
+		{name: "today", interpolation: InterpolationNone, date: time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC), wantMidRate: 0.90, wantProvider: "test-provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := datedRatesService(config.DatedRatesConfig{Interpolation: tt.interpolation, MaxGap: 3 * 24 * time.Hour})
+
+			result, err := service.ConvertAt(context.Background(), "USD", "EUR", 100, tt.date, "")
+			if tt.wantErrorType != 0 {
+				if serviceError, ok := err.(*ServiceError); !ok || serviceError.Type != tt.wantErrorType {
+					t.Fatalf("ConvertAt() error = %v, want type %v", err, tt.wantErrorType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertAt() error = %v", err)
+			}
+			if math.Abs(result.MidRate-tt.wantMidRate) > 1e-9 || result.Interpolated != tt.wantInterpolated || result.Provider != tt.wantProvider {
+				t.Errorf("ConvertAt() = %+v, want mid rate %v, interpolated %v from %s", result, tt.wantMidRate, tt.wantInterpolated, tt.wantProvider)
+			}
+			if result.Date != tt.date.Format(historyDateLayout) {
+				t.Errorf("ConvertAt() Date = %s, want %s", result.Date, tt.date.Format(historyDateLayout))
+			}
+		})
+	}
+}
+
+func TestRatesService_ConvertAt_ReversePair(t *testing.T) {
+	service := datedRatesService(config.DatedRatesConfig{Interpolation: InterpolationNone})
+
+	result, err := service.ConvertAt(context.Background(), "USD", "GBP", 125, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("ConvertAt() error = %v", err)
+	}
+	if math.Abs(result.MidRate-0.8) > 1e-9 || math.Abs(result.Converted-100) > 1e-9 {
+		t.Errorf("ConvertAt() = %+v, want the inverted GBP/USD close", result)
+	}
+}
+
+func TestRatesService_ConvertAt_Forward(t *testing.T) {
+	service := datedRatesService(config.DatedRatesConfig{Interpolation: InterpolationNone, ForwardPoints: map[string]float64{"USD/EUR": -73}})
+
+	// 73 points a year over 50 days moves the rate by 0.001
+	result, err := service.ConvertAt(context.Background(), "USD", "EUR", 100, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("ConvertAt() error = %v", err)
+	}
+	if math.Abs(result.MidRate-0.899) > 1e-9 || !result.Forward || result.Interpolated || result.Date != "2024-05-02" {
+		t.Errorf("ConvertAt() = %+v, want the forward rate 0.899", result)
+	}
+
+	_, err = service.ConvertAt(context.Background(), "EUR", "USD", 100, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), "")
+	if serviceError, ok := err.(*ServiceError); !ok || serviceError.Type != ErrorTypeInvalidRequest {
+		t.Errorf("ConvertAt() without forward points error = %v, want an invalid request", err)
+	}
+}
+
+func TestRatesService_ConvertAt_WithoutHistory(t *testing.T) {
+	service := datedRatesService(config.DatedRatesConfig{Interpolation: InterpolationNone})
+	service.rateHistory = nil
+
+	_, err := service.ConvertAt(context.Background(), "USD", "EUR", 100, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), "")
+	if serviceError, ok := err.(*ServiceError); !ok || serviceError.Type != ErrorTypeHistoryUnavailable {
+		t.Errorf("ConvertAt() error = %v, want history unavailable", err)
+	}
+}
+
+func TestValidateDatedRates(t *testing.T) {
+	if err := ValidateDatedRates(config.DatedRatesConfig{Interpolation: InterpolationLinear, MaxGap: 24 * time.Hour, ForwardPoints: map[string]float64{"EUR/USD": 45}}); err != nil {
+		t.Errorf("ValidateDatedRates() error = %v", err)
+	}
+
+	invalid := []config.DatedRatesConfig{
+		{Interpolation: "spline", MaxGap: 24 * time.Hour},
+		{Interpolation: InterpolationNearest},
+		{Interpolation: InterpolationNone, ForwardPoints: map[string]float64{"EURUSD": 45}},
+	}
+	for _, configuration := range invalid {
+		if err := ValidateDatedRates(configuration); err == nil {
+			t.Errorf("ValidateDatedRates(%+v) should fail", configuration)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+)
+
+// lookupFunc resolves a hostname to its IP addresses
+type lookupFunc func(ctx context.Context, host string) ([]string, error)
+
+// dnsEntry is a cached resolution result
+type dnsEntry struct {
+	addresses []string
+	expires   time.Time
+}
+
+// cachingResolver resolves provider hostnames with a per-attempt timeout, falling back
+// to the configured resolvers when the system resolver fails, and caches the results.
+// Expired entries are kept and served when every resolver fails, so a DNS outage does
+// not take down providers that were reachable a moment ago.
+type cachingResolver struct {
+	lookups []lookupFunc // System resolver first, then the fallback resolvers
+	timeout time.Duration
+	ttl     time.Duration
+	logger  logger.Logger
+	now     func() time.Time
+	dialer  *net.Dialer
+
+	mutex   sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// newCachingResolver creates a resolver from the DNS configuration
+func newCachingResolver(configuration config.DNSConfig, logger logger.Logger) *cachingResolver {
+	lookups := []lookupFunc{net.DefaultResolver.LookupHost}
+	for _, server := range configuration.FallbackResolvers {
+		lookups = append(lookups, resolverLookup(server))
+	}
+
+	return &cachingResolver{
+		lookups: lookups,
+		timeout: configuration.ResolveTimeout,
+		ttl:     configuration.CacheTTL,
+		logger:  logger,
+		now:     time.Now,
+		dialer:  &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		entries: make(map[string]dnsEntry),
+	}
+}
+
+// newProviderTransport returns an HTTP transport whose connections resolve through a caching resolver
+func newProviderTransport(configuration config.DNSConfig, logger logger.Logger) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = newCachingResolver(configuration, logger).dialContext
+	return transport
+}
+
+// resolverLookup returns a lookup that queries a specific DNS server (host:port)
+func resolverLookup(server string) lookupFunc {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+	return resolver.LookupHost
+}
+
+// dialContext resolves the address host and dials its IPs in turn until one connects
+func (resolver *cachingResolver) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return resolver.dialer.DialContext(ctx, network, address)
+	}
+
+	addresses, err := resolver.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastError error
+	for _, ip := range addresses {
+		conn, err := resolver.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+		if err == nil {
+			return conn, nil
+		}
+		lastError = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastError
+}
+
+// resolve returns the host's addresses from the cache, or looks them up and caches them
+func (resolver *cachingResolver) resolve(ctx context.Context, host string) ([]string, error) {
+	resolver.mutex.Lock()
+	entry, cached := resolver.entries[host]
+	resolver.mutex.Unlock()
+
+	if cached && resolver.now().Before(entry.expires) {
+		return entry.addresses, nil
+	}
+
+	var lastError error
+	for _, lookup := range resolver.lookups {
+		addresses, err := resolver.lookupWithTimeout(ctx, lookup, host)
+		if err == nil && len(addresses) > 0 {
+			if resolver.ttl > 0 {
+				resolver.mutex.Lock()
+				resolver.entries[host] = dnsEntry{addresses: addresses, expires: resolver.now().Add(resolver.ttl)}
+				resolver.mutex.Unlock()
+			}
+			return addresses, nil
+		}
+
+		lastError = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if cached {
+		resolver.logger.WithContext(ctx).Warnf("DNS resolution for %s failed, using stale addresses: %v", host, lastError)
+		return entry.addresses, nil
+	}
+	if lastError == nil {
+		lastError = fmt.Errorf("no addresses found")
+	}
+	return nil, fmt.Errorf("failed to resolve %s: %w", host, lastError)
+}
+
+// lookupWithTimeout runs a single lookup bounded by the resolve timeout
+func (resolver *cachingResolver) lookupWithTimeout(ctx context.Context, lookup lookupFunc, host string) ([]string, error) {
+	if resolver.timeout <= 0 {
+		return lookup(ctx, host)
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, resolver.timeout)
+	defer cancel()
+	return lookup(lookupCtx, host)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// countingLookup returns a lookup that answers with the given addresses or error and counts calls
+func countingLookup(addresses []string, err error, calls *int) lookupFunc {
+	return func(ctx context.Context, host string) ([]string, error) {
+		*calls++
+		return addresses, err
+	}
+}
+
+func TestCachingResolver_CachesUntilTTL(t *testing.T) {
+	now := time.Unix(1641000000, 0)
+	calls := 0
+	resolver := newCachingResolver(config.DNSConfig{CacheTTL: time.Minute}, testutils.MockLogger())
+	resolver.lookups = []lookupFunc{countingLookup([]string{"10.0.0.1"}, nil, &calls)}
+	resolver.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.resolve(context.Background(), "api.example.com"); err != nil {
+			t.Fatalf("resolve() error = %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("lookup calls within TTL = %v, want %v", calls, 1)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := resolver.resolve(context.Background(), "api.example.com"); err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("lookup calls after TTL = %v, want %v", calls, 2)
+	}
+}
+
+func TestCachingResolver_FallbackResolvers(t *testing.T) {
+	systemCalls, fallbackCalls := 0, 0
+	resolver := newCachingResolver(config.DNSConfig{ResolveTimeout: time.Second}, testutils.MockLogger())
+	resolver.lookups = []lookupFunc{
+		countingLookup(nil, errors.New("server misbehaving"), &systemCalls),
+		countingLookup([]string{"10.0.0.2"}, nil, &fallbackCalls),
+	}
+
+	addresses, err := resolver.resolve(context.Background(), "api.example.com")
+	if err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+	if len(addresses) != 1 || addresses[0] != "10.0.0.2" {
+		t.Errorf("resolve() = %v, want [10.0.0.2]", addresses)
+	}
+	if systemCalls != 1 || fallbackCalls != 1 {
+		t.Errorf("lookup calls = %v/%v, want 1/1", systemCalls, fallbackCalls)
+	}
+
+	// Without a TTL nothing is cached
+	resolver.resolve(context.Background(), "api.example.com")
+	if systemCalls != 2 {
+		t.Errorf("system lookup calls with caching disabled = %v, want %v", systemCalls, 2)
+	}
+}
+
+func TestCachingResolver_ServesStaleOnFailure(t *testing.T) {
+	now := time.Unix(1641000000, 0)
+	calls := 0
+	resolver := newCachingResolver(config.DNSConfig{CacheTTL: time.Minute}, testutils.MockLogger())
+	resolver.lookups = []lookupFunc{countingLookup([]string{"10.0.0.1"}, nil, &calls)}
+	resolver.now = func() time.Time { return now }
+
+	if _, err := resolver.resolve(context.Background(), "api.example.com"); err != nil {
+		t.Fatalf("resolve() error = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	resolver.lookups = []lookupFunc{countingLookup(nil, errors.New("timeout"), &calls)}
+
+	addresses, err := resolver.resolve(context.Background(), "api.example.com")
+	if err != nil {
+		t.Fatalf("resolve() with stale entry error = %v", err)
+	}
+	if len(addresses) != 1 || addresses[0] != "10.0.0.1" {
+		t.Errorf("resolve() = %v, want stale [10.0.0.1]", addresses)
+	}
+
+	if _, err := resolver.resolve(context.Background(), "unknown.example.com"); err == nil {
+		t.Error("resolve() expected error for uncached host when every resolver fails")
+	}
+}
+
+func TestCachingResolver_Timeout(t *testing.T) {
+	resolver := newCachingResolver(config.DNSConfig{ResolveTimeout: 20 * time.Millisecond}, testutils.MockLogger())
+	resolver.lookups = []lookupFunc{
+		func(ctx context.Context, host string) ([]string, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	start := time.Now()
+	if _, err := resolver.resolve(context.Background(), "slow.example.com"); err == nil {
+		t.Fatal("resolve() expected error when the resolver times out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("resolve() took %v, want it bounded by the resolve timeout", elapsed)
+	}
+}
+
+func TestCachingResolver_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+
+	calls := 0
+	resolver := newCachingResolver(config.DNSConfig{CacheTTL: time.Minute}, testutils.MockLogger())
+	resolver.lookups = []lookupFunc{countingLookup([]string{"127.0.0.1"}, nil, &calls)}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = resolver.dialContext
+	transport.DisableKeepAlives = true
+	transport.Proxy = nil
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		response, err := client.Get("http://rates.internal:" + port + "/")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		response.Body.Close()
+	}
+	if calls != 1 {
+		t.Errorf("lookup calls = %v, want %v", calls, 1)
+	}
+}
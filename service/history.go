@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// GetHistoricalRates returns the rates published for a date, trying history-capable
+// providers the source policy permits for the base in priority order until one succeeds
+func (ratesService *RatesService) GetHistoricalRates(requestContext context.Context, baseCurrency string, date time.Time) (models.RatesResponse, error) {
+	if err := ratesService.checkBase(baseCurrency); err != nil {
+		return models.RatesResponse{}, err
+	}
+	if date.After(ratesService.now()) {
+		return models.RatesResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: "date must not be in the future",
+		}
+	}
+
+	if cachedRates, found := ratesService.lookupRates(baseCurrency, date.Format(historyDateLayout), nil); found && ratesService.sourcePolicy.permits(cachedRates.Provider, baseCurrency) {
+		atomic.AddInt64(&ratesService.cacheHits, 1)
+		return withAge(ratesService.filterAllowedRates(ratesService.sourcePolicy.filter(cachedRates)), ratesService.now()), nil
+	}
+	atomic.AddInt64(&ratesService.cacheMisses, 1)
+
+	providers, err := ratesService.sourcePolicy.providersFor(ratesService.providers, []string{baseCurrency})
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+
+	var providerErrors []error
+	for _, provider := range providers {
+		historicalProvider, ok := provider.(HistoricalProvider)
+		if !ok || !historicalProvider.SupportsHistory() {
+			continue
+		}
+		if err := ratesService.gate.admit(provider.GetName(), baseCurrency); err != nil {
+			providerErrors = append(providerErrors, err)
+			continue
+		}
+
+		exchangeRates, err := historicalProvider.GetHistoricalRates(requestContext, baseCurrency, date)
+		ratesService.gate.observe(provider.GetName(), baseCurrency, err)
+		if err == nil {
+			ratesService.storeRates(historicalKey(baseCurrency, date), exchangeRates)
+			return withAge(ratesService.filterAllowedRates(ratesService.sourcePolicy.filter(exchangeRates)), ratesService.now()), nil
+		}
+
+		ratesService.logger.WithContext(requestContext).Warnf("Historical rates from %s failed: %v", provider.GetName(), err)
+		providerErrors = append(providerErrors, err)
+		if requestContext.Err() != nil {
+			break
+		}
+	}
+
+	if len(providerErrors) == 0 {
+		return models.RatesResponse{}, &ServiceError{
+			Type:    ErrorTypeNoProviders,
+			Message: "no configured provider supports historical rates",
+		}
+	}
+	return models.RatesResponse{}, providerFailure("historical provider request failed", providerErrors)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_GetHistoricalRates_NoHistoryProviders(t *testing.T) {
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{&testutils.FakeProvider{Name: "test-provider", Enabled: true}},
+	}
+
+	_, err := service.GetHistoricalRates(context.Background(), "USD", time.Now().AddDate(0, 0, -1))
+	serviceError, ok := err.(*ServiceError)
+	if !ok || serviceError.Type != ErrorTypeNoProviders {
+		t.Errorf("GetHistoricalRates() error = %v, want ErrorTypeNoProviders", err)
+	}
+}
+
+func TestRatesService_GetHistoricalRates_FutureDate(t *testing.T) {
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+	}
+
+	_, err := service.GetHistoricalRates(context.Background(), "USD", time.Now().AddDate(0, 0, 1))
+	serviceError, ok := err.(*ServiceError)
+	if !ok || serviceError.Type != ErrorTypeInvalidRequest {
+		t.Errorf("GetHistoricalRates() error = %v, want ErrorTypeInvalidRequest", err)
+	}
+}
@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dalfonso89/currency-exchange-service/config"
@@ -18,6 +22,19 @@ type HTTPExchangeRateProvider struct {
 	configuration config.ExchangeRateProvider
 	logger        logger.Logger
 	httpClient    *http.Client
+	budget        *callBudget        // Shared outbound call budget (nil = unlimited)
+	slots         *providerSlots     // Concurrency cap of this provider (nil = unlimited)
+	rateLimit     *outboundLimit     // Published request limit of this provider (nil = unlimited)
+	poller        *conditionalPoller // Validators of previous responses (nil = unconditional polling)
+
+	maxResponseBytes int64 // Largest accepted response body
+
+	// Index into endpoints() of the endpoint that last answered successfully
+	endpointMutex     sync.Mutex
+	preferredEndpoint int
+
+	// Guards the configuration's API key and signing key, which change when they rotate
+	secretsMutex sync.RWMutex
 }
 
 // NewHTTPExchangeRateProvider creates a new HTTP exchange rate provider
@@ -28,6 +45,7 @@ func NewHTTPExchangeRateProvider(configuration config.ExchangeRateProvider, logg
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		maxResponseBytes: DefaultMaxResponseBytes,
 	}
 }
 
@@ -46,37 +64,266 @@ func (provider *HTTPExchangeRateProvider) GetPriority() int {
 	return provider.configuration.Priority
 }
 
-// GetRates fetches exchange rates from the provider
+// GetRates fetches exchange rates from the provider. Providers restricted to a fixed base,
+// or that answer with a different base than requested, are re-based via cross rates.
 func (provider *HTTPExchangeRateProvider) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
-	url := provider.buildURL(baseCurrency)
+	requestBase := baseCurrency
+	if provider.configuration.FixedBase != "" {
+		requestBase = provider.configuration.FixedBase
+	}
+
+	response, err := provider.fetchWithFailover(ctx, requestBase, func(baseURL string) (string, bool) {
+		return provider.buildURL(baseURL, requestBase), true
+	})
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+
+	if response.Base != "" && response.Base != baseCurrency {
+		return rebaseRates(response, baseCurrency)
+	}
+	return response, nil
+}
+
+// SupportsHistory reports whether the provider exposes historical rates
+func (provider *HTTPExchangeRateProvider) SupportsHistory() bool {
+	_, supported := provider.buildHistoricalURL(provider.configuration.BaseURL, "USD", time.Time{})
+	return supported
+}
+
+// GetHistoricalRates fetches the rates published for a specific date
+func (provider *HTTPExchangeRateProvider) GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (models.RatesResponse, error) {
+	requestBase := baseCurrency
+	if provider.configuration.FixedBase != "" {
+		requestBase = provider.configuration.FixedBase
+	}
+
+	if !provider.SupportsHistory() {
+		return models.RatesResponse{}, fmt.Errorf("provider %s does not support historical rates", provider.configuration.Name)
+	}
+
+	response, err := provider.fetchWithFailover(ctx, requestBase, func(baseURL string) (string, bool) {
+		return provider.buildHistoricalURL(baseURL, requestBase, date)
+	})
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+
+	if response.Base != "" && response.Base != baseCurrency {
+		return rebaseRates(response, baseCurrency)
+	}
+	return response, nil
+}
+
+// Concurrency reports the provider's concurrency cap, or nil when it is unlimited
+func (provider *HTTPExchangeRateProvider) Concurrency() *models.ProviderConcurrency {
+	return provider.slots.stats()
+}
+
+// RateLimit reports the provider's outbound rate limit, or nil when it is unlimited
+func (provider *HTTPExchangeRateProvider) RateLimit() *models.ProviderRateLimit {
+	return provider.rateLimit.stats()
+}
+
+// Polling reports the provider's conditional polling counts, or nil when it is off
+func (provider *HTTPExchangeRateProvider) Polling() *models.ProviderPolling {
+	return provider.poller.stats()
+}
 
+// RotateSecrets replaces the provider's API key and signing key with rotated values
+func (provider *HTTPExchangeRateProvider) RotateSecrets(apiKey, signingKey string) {
+	provider.secretsMutex.Lock()
+	defer provider.secretsMutex.Unlock()
+	provider.configuration.APIKey = apiKey
+	provider.configuration.Signing.Key = signingKey
+}
+
+// signing returns the provider's current request signing settings
+func (provider *HTTPExchangeRateProvider) signing() config.RequestSigningConfig {
+	provider.secretsMutex.RLock()
+	defer provider.secretsMutex.RUnlock()
+	return provider.configuration.Signing
+}
+
+// endpoints returns the primary base URL followed by the configured regional mirrors
+func (provider *HTTPExchangeRateProvider) endpoints() []string {
+	return append([]string{provider.configuration.BaseURL}, provider.configuration.MirrorURLs...)
+}
+
+// fetchWithFailover tries each endpoint in turn, starting with the one that last succeeded,
+// and remembers whichever endpoint answers so later requests go there first. Every
+// attempt spends a call from the budget and a token of the provider's rate limit; once
+// either is spent no further endpoint is tried. The attempts run within one of the
+// provider's concurrency slots, which a provider out of tokens does not queue for.
+// urlFor builds the request URL for an endpoint and reports false to skip it.
+func (provider *HTTPExchangeRateProvider) fetchWithFailover(ctx context.Context, baseCurrency string, urlFor func(baseURL string) (string, bool)) (models.RatesResponse, error) {
+	if err := provider.rateLimit.admit(); err != nil {
+		return models.RatesResponse{}, fmt.Errorf("provider %s: %w", provider.configuration.Name, err)
+	}
+	if err := provider.slots.acquire(ctx); err != nil {
+		return models.RatesResponse{}, err
+	}
+	defer provider.slots.release()
+
+	endpoints := provider.endpoints()
+
+	provider.endpointMutex.Lock()
+	preferred := provider.preferredEndpoint % len(endpoints)
+	provider.endpointMutex.Unlock()
+
+	lastError := fmt.Errorf("provider %s has no usable endpoint", provider.configuration.Name)
+	for attempt := 0; attempt < len(endpoints); attempt++ {
+		index := (preferred + attempt) % len(endpoints)
+		url, usable := urlFor(endpoints[index])
+		if !usable {
+			continue
+		}
+
+		if err := provider.rateLimit.take(); err != nil {
+			return models.RatesResponse{}, fmt.Errorf("provider %s: %w", provider.configuration.Name, err)
+		}
+		if err := provider.budget.take(); err != nil {
+			return models.RatesResponse{}, fmt.Errorf("provider %s: %w", provider.configuration.Name, err)
+		}
+
+		response, err := provider.fetchRates(ctx, url, baseCurrency)
+		if err == nil {
+			if index != preferred {
+				provider.endpointMutex.Lock()
+				provider.preferredEndpoint = index
+				provider.endpointMutex.Unlock()
+				provider.logger.WithContext(ctx).Infof("Provider %s failed over to endpoint %s", provider.configuration.Name, endpoints[index])
+			}
+			return response, nil
+		}
+
+		lastError = err
+		if ctx.Err() != nil || failsOnEveryEndpoint(err) {
+			break
+		}
+		if len(endpoints) > 1 {
+			provider.logger.WithContext(ctx).Warnf("Provider %s endpoint %s failed: %v", provider.configuration.Name, endpoints[index], err)
+		}
+	}
+	return models.RatesResponse{}, lastError
+}
+
+// fetchRates performs the HTTP request against a provider URL and parses the response
+func (provider *HTTPExchangeRateProvider) fetchRates(ctx context.Context, url string, baseCurrency string) (models.RatesResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return models.RatesResponse{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	requestID := RequestIDFrom(ctx)
+	if provider.configuration.ForwardRequestID {
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		for name, values := range TraceContextFrom(ctx) {
+			req.Header[name] = values
+		}
+	}
+	provider.poller.prepare(req)
+	if signing := provider.signing(); signing.Key != "" {
+		if err := signRequest(req, signing, time.Now()); err != nil {
+			return models.RatesResponse{}, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
 	resp, err := provider.httpClient.Do(req)
 	if err != nil {
+		if requestID != "" {
+			return models.RatesResponse{}, fmt.Errorf("failed to make request [request %s]: %w", requestID, err)
+		}
 		return models.RatesResponse{}, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Unchanged rates are served from the previous response, so caching them again
+	// extends their cache TTL without transferring or parsing the table
+	if resp.StatusCode == http.StatusNotModified {
+		if response, found := provider.poller.notModified(url); found {
+			provider.logger.WithContext(ctx).Debugf("Provider %s rates not modified", provider.configuration.Name)
+			return response, nil
+		}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return models.RatesResponse{}, fmt.Errorf("provider returned status %d", resp.StatusCode)
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		providerError := newStatusError(provider.configuration.Name, resp, detail)
+		providerError.RequestID = requestID
+		return models.RatesResponse{}, providerError
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	response, err := provider.readResponse(url, resp, baseCurrency)
+	if errors.Is(err, errResponseTooLarge) {
+		return models.RatesResponse{}, &ProviderError{
+			Provider:  provider.configuration.Name,
+			Detail:    fmt.Sprintf("response body exceeds %d bytes", provider.maxResponseBytes),
+			Kind:      ErrDecode,
+			RequestID: requestID,
+		}
+	}
+	var readError *bodyReadError
+	if errors.As(err, &readError) {
+		return models.RatesResponse{}, fmt.Errorf("failed to read response body: %w", readError.err)
+	}
 	if err != nil {
-		return models.RatesResponse{}, fmt.Errorf("failed to read response body: %w", err)
+		return models.RatesResponse{}, &ProviderError{Provider: provider.configuration.Name, Detail: err.Error(), Kind: ErrDecode, RequestID: requestID}
 	}
+	return response, nil
+}
 
-	return provider.parseResponse(body, baseCurrency)
+// bodyReadError is a failure to read a response body, as opposed to decoding it
+type bodyReadError struct {
+	err error
 }
 
-// buildURL constructs the URL for the provider based on its configuration
-func (provider *HTTPExchangeRateProvider) buildURL(baseCurrency string) string {
-	baseURL := provider.configuration.BaseURL
+func (e *bodyReadError) Error() string { return e.err.Error() }
+
+func (e *bodyReadError) Unwrap() error { return e.err }
+
+// readResponse decodes a response body of at most maxResponseBytes. With conditional
+// polling the body is read into a pooled buffer, so an unchanged body is recognized
+// before decoding it; otherwise it is decoded as it streams in.
+func (provider *HTTPExchangeRateProvider) readResponse(url string, resp *http.Response, baseCurrency string) (models.RatesResponse, error) {
+	if provider.poller == nil {
+		body := &boundedReader{reader: resp.Body, remaining: provider.maxResponseBytes}
+		response, err := provider.decodeResponse(json.NewDecoder(body).Decode, baseCurrency)
+		if body.err != nil {
+			if errors.Is(body.err, errResponseTooLarge) {
+				return models.RatesResponse{}, errResponseTooLarge
+			}
+			return models.RatesResponse{}, &bodyReadError{err: body.err}
+		}
+		// Drain what follows the JSON value, so the connection can be reused
+		io.Copy(io.Discard, body)
+		return response, err
+	}
+
+	buffer, err := readBody(resp.Body, provider.maxResponseBytes)
+	if errors.Is(err, errResponseTooLarge) {
+		return models.RatesResponse{}, err
+	}
+	if err != nil {
+		return models.RatesResponse{}, &bodyReadError{err: err}
+	}
+	defer releaseBuffer(buffer)
+
+	body := buffer.Bytes()
+	if response, found := provider.poller.unchanged(url, body); found {
+		return response, nil
+	}
+	response, err := provider.decodeResponse(bytesDecoder(body), baseCurrency)
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+	provider.poller.remember(url, resp.Header, body, response)
+	return response, nil
+}
 
+// buildURL constructs the URL for one of the provider's endpoints based on its configuration
+func (provider *HTTPExchangeRateProvider) buildURL(baseURL string, baseCurrency string) string {
 	// Handle different provider URL patterns
 	switch provider.configuration.Name {
 	case "erapi":
@@ -97,127 +344,253 @@ func (provider *HTTPExchangeRateProvider) buildURL(baseCurrency string) string {
 	}
 }
 
-// parseResponse parses the JSON response from the provider
-func (provider *HTTPExchangeRateProvider) parseResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
-	var response models.RatesResponse
+// buildHistoricalURL constructs the URL for a historical date, if the provider supports it
+func (provider *HTTPExchangeRateProvider) buildHistoricalURL(baseURL string, baseCurrency string, date time.Time) (string, bool) {
+	day := date.Format("2006-01-02")
 
-	// Try to parse as generic response first
-	if err := json.Unmarshal(body, &response); err == nil && response.Base != "" {
-		response.Provider = provider.configuration.Name
-		return response, nil
+	switch provider.configuration.Name {
+	case "openexchangerates":
+		// OpenExchangeRates format: https://openexchangerates.org/api/historical/2024-01-31.json?base=USD
+		if !strings.HasSuffix(baseURL, "/latest.json") {
+			return "", false
+		}
+		return fmt.Sprintf("%s/historical/%s.json?base=%s", strings.TrimSuffix(baseURL, "/latest.json"), day, baseCurrency), true
+	case "frankfurter":
+		// Frankfurter format: https://api.frankfurter.app/2024-01-31?from=USD
+		if !strings.HasSuffix(baseURL, "/latest") {
+			return "", false
+		}
+		return fmt.Sprintf("%s/%s?from=%s", strings.TrimSuffix(baseURL, "/latest"), day, baseCurrency), true
+	case "exchangerate.host":
+		// ExchangeRate.host format: https://api.exchangerate.host/2024-01-31?base=USD
+		if !strings.HasSuffix(baseURL, "/latest") {
+			return "", false
+		}
+		return fmt.Sprintf("%s/%s?base=%s", strings.TrimSuffix(baseURL, "/latest"), day, baseCurrency), true
+	default:
+		// ExchangeRate-API's open endpoint and generic providers have no history
+		return "", false
+	}
+}
+
+// ParseResponse parses the JSON response from the provider and normalizes quoting
+func (provider *HTTPExchangeRateProvider) ParseResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
+	return provider.decodeResponse(bytesDecoder(body), baseCurrency)
+}
+
+// decodeResponse decodes a response in the provider's format and normalizes quoting
+func (provider *HTTPExchangeRateProvider) decodeResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
+	response, err := provider.parseProviderResponse(decode, baseCurrency)
+	if err != nil {
+		return models.RatesResponse{}, err
 	}
+	return provider.normalizeRates(response), nil
+}
+
+// normalizeRates inverts rates for symbols the provider quotes as base-per-unit, so every
+// rate is expressed as units of the symbol per one unit of the base currency
+func (provider *HTTPExchangeRateProvider) normalizeRates(response models.RatesResponse) models.RatesResponse {
+	if len(provider.configuration.InvertedSymbols) == 0 {
+		return response
+	}
+
+	normalizedRates := make(map[string]float64, len(response.Rates))
+	for symbol, rate := range response.Rates {
+		normalizedRates[symbol] = rate
+	}
+	for _, symbol := range provider.configuration.InvertedSymbols {
+		if rate, found := normalizedRates[symbol]; found && rate != 0 {
+			if inverse := 1 / rate; !math.IsInf(inverse, 0) {
+				normalizedRates[symbol] = inverse
+			} else {
+				// Too small to invert, the rate is unusable
+				delete(normalizedRates, symbol)
+			}
+		}
+	}
+
+	// Inverting a quote swaps its sides: the inverse of the ask is the bid
+	bid, ask := response.Bid, response.Ask
+	if len(bid) > 0 {
+		bid, ask = make(map[string]float64, len(bid)), make(map[string]float64, len(ask))
+		for symbol := range response.Bid {
+			bid[symbol], ask[symbol] = response.Bid[symbol], response.Ask[symbol]
+		}
+		for _, symbol := range provider.configuration.InvertedSymbols {
+			if _, quoted := bid[symbol]; quoted {
+				bid[symbol], ask[symbol] = 1/response.Ask[symbol], 1/response.Bid[symbol]
+			}
+		}
+	}
+	return withSpreads(response.WithRates(normalizedRates), bid, ask)
+}
 
-	// Provider-specific parsing
+// parseProviderResponse dispatches to the parser matching the provider's format
+func (provider *HTTPExchangeRateProvider) parseProviderResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
 	switch provider.configuration.Name {
 	case "erapi":
-		return provider.parseERAPIResponse(body, baseCurrency)
+		return provider.parseERAPIResponse(decode, baseCurrency)
 	case "openexchangerates":
-		return provider.parseOpenExchangeRatesResponse(body, baseCurrency)
+		return provider.parseOpenExchangeRatesResponse(decode, baseCurrency)
 	case "frankfurter":
-		return provider.parseFrankfurterResponse(body, baseCurrency)
+		return provider.parseFrankfurterResponse(decode, baseCurrency)
 	case "exchangerate.host":
-		return provider.parseExchangeRateHostResponse(body, baseCurrency)
+		return provider.parseExchangeRateHostResponse(decode, baseCurrency)
 	default:
-		return provider.parseGenericResponse(body, baseCurrency)
+		return provider.parseGenericResponse(decode, baseCurrency)
 	}
 }
 
 // parseERAPIResponse parses ExchangeRate-API response format
-func (provider *HTTPExchangeRateProvider) parseERAPIResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
+func (provider *HTTPExchangeRateProvider) parseERAPIResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
 	var data struct {
-		Base      string             `json:"base"`
-		Timestamp int64              `json:"timestamp"`
-		Rates     map[string]float64 `json:"rates"`
+		Base               string             `json:"base"`
+		BaseCode           string             `json:"base_code"`
+		Timestamp          int64              `json:"timestamp"`
+		TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+		Rates              map[string]float64 `json:"rates"`
 	}
 
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := decode(&data); err != nil {
 		return models.RatesResponse{}, fmt.Errorf("failed to parse ERAPI response: %w", err)
 	}
 
-	return models.RatesResponse{
-		Base:      data.Base,
-		Timestamp: data.Timestamp,
-		Rates:     data.Rates,
-		Provider:  provider.configuration.Name,
-	}, nil
+	base := data.BaseCode
+	if base == "" {
+		base = data.Base
+	}
+	publishedAt := data.TimeLastUpdateUnix
+	if publishedAt == 0 {
+		publishedAt = data.Timestamp
+	}
+
+	return provider.newRatesResponse(base, publishedAt, data.Rates), nil
 }
 
 // parseOpenExchangeRatesResponse parses OpenExchangeRates response format
-func (provider *HTTPExchangeRateProvider) parseOpenExchangeRatesResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
+func (provider *HTTPExchangeRateProvider) parseOpenExchangeRatesResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
 	var data struct {
 		Base      string             `json:"base"`
 		Timestamp int64              `json:"timestamp"`
 		Rates     map[string]float64 `json:"rates"`
 	}
 
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := decode(&data); err != nil {
 		return models.RatesResponse{}, fmt.Errorf("failed to parse OpenExchangeRates response: %w", err)
 	}
 
-	return models.RatesResponse{
-		Base:      data.Base,
-		Timestamp: data.Timestamp,
-		Rates:     data.Rates,
-		Provider:  provider.configuration.Name,
-	}, nil
+	return provider.newRatesResponse(data.Base, data.Timestamp, data.Rates), nil
 }
 
 // parseFrankfurterResponse parses Frankfurter response format
-func (provider *HTTPExchangeRateProvider) parseFrankfurterResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
+func (provider *HTTPExchangeRateProvider) parseFrankfurterResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
 	var data struct {
 		Base      string             `json:"base"`
 		Timestamp int64              `json:"timestamp"`
+		Date      string             `json:"date"`
 		Rates     map[string]float64 `json:"rates"`
 	}
 
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := decode(&data); err != nil {
 		return models.RatesResponse{}, fmt.Errorf("failed to parse Frankfurter response: %w", err)
 	}
 
-	return models.RatesResponse{
-		Base:      data.Base,
-		Timestamp: data.Timestamp,
-		Rates:     data.Rates,
-		Provider:  provider.configuration.Name,
-	}, nil
+	publishedAt := data.Timestamp
+	if publishedAt == 0 {
+		publishedAt = parseDate(data.Date)
+	}
+
+	return provider.newRatesResponse(data.Base, publishedAt, data.Rates), nil
 }
 
 // parseExchangeRateHostResponse parses ExchangeRate.host response format
-func (provider *HTTPExchangeRateProvider) parseExchangeRateHostResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
+func (provider *HTTPExchangeRateProvider) parseExchangeRateHostResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
 	var data struct {
 		Base      string             `json:"base"`
 		Timestamp int64              `json:"timestamp"`
+		Date      string             `json:"date"`
 		Rates     map[string]float64 `json:"rates"`
 	}
 
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := decode(&data); err != nil {
 		return models.RatesResponse{}, fmt.Errorf("failed to parse ExchangeRate.host response: %w", err)
 	}
 
-	return models.RatesResponse{
-		Base:      data.Base,
-		Timestamp: data.Timestamp,
-		Rates:     data.Rates,
-		Provider:  provider.configuration.Name,
-	}, nil
+	publishedAt := data.Timestamp
+	if publishedAt == 0 {
+		publishedAt = parseDate(data.Date)
+	}
+
+	return provider.newRatesResponse(data.Base, publishedAt, data.Rates), nil
 }
 
 // parseGenericResponse attempts to parse a generic response format
-func (provider *HTTPExchangeRateProvider) parseGenericResponse(body []byte, baseCurrency string) (models.RatesResponse, error) {
+func (provider *HTTPExchangeRateProvider) parseGenericResponse(decode responseDecoder, baseCurrency string) (models.RatesResponse, error) {
 	var data struct {
-		Base      string             `json:"base"`
-		Timestamp int64              `json:"timestamp"`
-		Rates     map[string]float64 `json:"rates"`
+		Base        string             `json:"base"`
+		Timestamp   int64              `json:"timestamp"`
+		PublishedAt int64              `json:"published_at"`
+		Rates       map[string]float64 `json:"rates"`
+		Bid         map[string]float64 `json:"bid"`
+		Ask         map[string]float64 `json:"ask"`
 	}
 
-	if err := json.Unmarshal(body, &data); err != nil {
+	if err := decode(&data); err != nil {
 		return models.RatesResponse{}, fmt.Errorf("failed to parse generic response: %w", err)
 	}
 
+	publishedAt := data.PublishedAt
+	if publishedAt == 0 {
+		publishedAt = data.Timestamp
+	}
+
+	return withSpreads(provider.newRatesResponse(data.Base, publishedAt, data.Rates), data.Bid, data.Ask), nil
+}
+
+// newRatesResponse builds a response with normalized timestamps: PublishedAt is when the
+// provider published the rates (0 if unknown), FetchedAt is when we received them, and
+// Timestamp keeps its legacy meaning of publication time, falling back to fetch time.
+func (provider *HTTPExchangeRateProvider) newRatesResponse(base string, publishedAt int64, rates map[string]float64) models.RatesResponse {
+	fetchedAt := time.Now().Unix()
+
+	timestamp := publishedAt
+	if timestamp == 0 {
+		timestamp = fetchedAt
+	}
+
 	return models.RatesResponse{
-		Base:      data.Base,
-		Timestamp: data.Timestamp,
-		Rates:     data.Rates,
-		Provider:  provider.configuration.Name,
-	}, nil
+		Base:        base,
+		Timestamp:   timestamp,
+		PublishedAt: publishedAt,
+		FetchedAt:   fetchedAt,
+		Rates:       rates,
+		Provider:    provider.configuration.Name,
+	}
+}
+
+// withSpreads adds the bid and ask quotes of the currencies that have a rate and a
+// positive quote on both sides, bid not above ask. Currencies with an incomplete or
+// crossed quote keep only their mid rate.
+func withSpreads(response models.RatesResponse, bid, ask map[string]float64) models.RatesResponse {
+	response.Bid, response.Ask = nil, nil
+	for symbol, bidRate := range bid {
+		askRate, quoted := ask[symbol]
+		if _, rated := response.Rates[symbol]; !rated || !quoted || bidRate <= 0 || bidRate > askRate || math.IsInf(askRate, 0) {
+			continue
+		}
+		if response.Bid == nil {
+			response.Bid, response.Ask = make(models.RateTable), make(models.RateTable)
+		}
+		response.Bid[symbol], response.Ask[symbol] = bidRate, askRate
+	}
+	return response
+}
+
+// parseDate converts a provider date ("2006-01-02") to a Unix timestamp, or 0 if invalid
+func parseDate(date string) int64 {
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0
+	}
+	return parsedDate.Unix()
 }
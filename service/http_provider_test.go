@@ -2,12 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testsupport"
 	"github.com/dalfonso89/currency-exchange-service/testutils"
 )
 
@@ -120,7 +123,7 @@ func TestHTTPExchangeRateProvider_buildURL(t *testing.T) {
 				testutils.MockLogger(),
 			)
 
-			result := provider.buildURL(tt.baseCurrency)
+			result := provider.buildURL(tt.baseURL, tt.baseCurrency)
 			if result != tt.expected {
 				t.Errorf("buildURL() = %v, want %v", result, tt.expected)
 			}
@@ -144,7 +147,7 @@ func TestHTTPExchangeRateProvider_parseERAPIResponse(t *testing.T) {
 		}
 	}`
 
-	result, err := provider.parseERAPIResponse([]byte(jsonResponse), "USD")
+	result, err := provider.parseERAPIResponse(bytesDecoder([]byte(jsonResponse)), "USD")
 	if err != nil {
 		t.Fatalf("parseERAPIResponse() error = %v", err)
 	}
@@ -179,7 +182,7 @@ func TestHTTPExchangeRateProvider_parseOpenExchangeRatesResponse(t *testing.T) {
 		}
 	}`
 
-	result, err := provider.parseOpenExchangeRatesResponse([]byte(jsonResponse), "USD")
+	result, err := provider.parseOpenExchangeRatesResponse(bytesDecoder([]byte(jsonResponse)), "USD")
 	if err != nil {
 		t.Fatalf("parseOpenExchangeRatesResponse() error = %v", err)
 	}
@@ -215,7 +218,7 @@ func TestHTTPExchangeRateProvider_parseFrankfurterResponse(t *testing.T) {
 		}
 	}`
 
-	result, err := provider.parseFrankfurterResponse([]byte(jsonResponse), "USD")
+	result, err := provider.parseFrankfurterResponse(bytesDecoder([]byte(jsonResponse)), "USD")
 	if err != nil {
 		t.Fatalf("parseFrankfurterResponse() error = %v", err)
 	}
@@ -250,7 +253,7 @@ func TestHTTPExchangeRateProvider_parseExchangeRateHostResponse(t *testing.T) {
 		}
 	}`
 
-	result, err := provider.parseExchangeRateHostResponse([]byte(jsonResponse), "USD")
+	result, err := provider.parseExchangeRateHostResponse(bytesDecoder([]byte(jsonResponse)), "USD")
 	if err != nil {
 		t.Fatalf("parseExchangeRateHostResponse() error = %v", err)
 	}
@@ -285,7 +288,7 @@ func TestHTTPExchangeRateProvider_parseGenericResponse(t *testing.T) {
 		}
 	}`
 
-	result, err := provider.parseGenericResponse([]byte(jsonResponse), "USD")
+	result, err := provider.parseGenericResponse(bytesDecoder([]byte(jsonResponse)), "USD")
 	if err != nil {
 		t.Fatalf("parseGenericResponse() error = %v", err)
 	}
@@ -398,3 +401,297 @@ func TestHTTPExchangeRateProvider_GetRates_InvalidJSON(t *testing.T) {
 		t.Error("GetRates() expected error for invalid JSON, got nil")
 	}
 }
+
+func TestHTTPExchangeRateProvider_GetRates_ScriptedServer(t *testing.T) {
+	server := testsupport.NewProviderServer()
+	defer server.Close()
+	server.Script("/USD",
+		testsupport.Status(http.StatusServiceUnavailable),
+		testsupport.QuotaExceeded(time.Minute),
+		testsupport.MalformedJSON(),
+		testsupport.Delayed(time.Hour),
+	)
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "erapi", BaseURL: server.URL(), Enabled: true},
+		testutils.MockLogger(),
+	)
+	ctx := context.Background()
+
+	var providerError *ProviderError
+	if _, err := provider.GetRates(ctx, "USD"); !errors.Is(err, ErrUpstream5xx) {
+		t.Errorf("GetRates() of a 503 error = %v, want %v", err, ErrUpstream5xx)
+	}
+	if _, err := provider.GetRates(ctx, "USD"); !errors.As(err, &providerError) || providerError.Kind != ErrQuotaExceeded || providerError.RetryAfter != time.Minute {
+		t.Errorf("GetRates() of a quota error = %v, want %v retrying after a minute", err, ErrQuotaExceeded)
+	}
+	if _, err := provider.GetRates(ctx, "USD"); !errors.Is(err, ErrDecode) {
+		t.Errorf("GetRates() of malformed JSON error = %v, want %v", err, ErrDecode)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := provider.GetRates(timeoutCtx, "USD"); err == nil {
+		t.Error("GetRates() of a delayed answer should fail at the deadline")
+	}
+
+	// After the script the server answers normally, its rates drifting with the clock
+	fakeClock := testutils.NewFakeClock(time.Unix(1700000000, 0))
+	server.SetDrift(0.01, fakeClock)
+	fakeClock.Advance(2 * time.Hour)
+	result, err := provider.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() after the script error = %v", err)
+	}
+	if want := 0.85 * 1.01 * 1.01; math.Abs(result.Rates["EUR"]-want) > 1e-9 {
+		t.Errorf("GetRates() EUR after two hours of drift = %v, want %v", result.Rates["EUR"], want)
+	}
+	if requests := server.Requests("/USD"); requests != 5 {
+		t.Errorf("Requests() = %d, want 5", requests)
+	}
+}
+
+func TestHTTPExchangeRateProvider_parseGenericResponse_Spreads(t *testing.T) {
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "dealer", InvertedSymbols: []string{"XAU"}},
+		testutils.MockLogger(),
+	)
+
+	// GBP has no ask and JPY a crossed quote, so both keep only their mid rate
+	jsonResponse := `{
+		"base": "USD",
+		"rates": {"EUR": 0.85, "GBP": 0.73, "JPY": 110.0, "XAU": 2000},
+		"bid": {"EUR": 0.849, "GBP": 0.729, "JPY": 110.2, "XAU": 1999, "CHF": 0.9},
+		"ask": {"EUR": 0.851, "JPY": 109.8, "XAU": 2001, "CHF": 0.91}
+	}`
+
+	result, err := provider.ParseResponse([]byte(jsonResponse), "USD")
+	if err != nil {
+		t.Fatalf("ParseResponse() error = %v", err)
+	}
+
+	if len(result.Bid) != 2 || result.Bid["EUR"] != 0.849 || result.Ask["EUR"] != 0.851 {
+		t.Errorf("ParseResponse() bid = %v, ask = %v, want the EUR and XAU quotes", result.Bid, result.Ask)
+	}
+	// Inverting a quote swaps its sides
+	if result.Bid["XAU"] != 1.0/2001 || result.Ask["XAU"] != 1.0/1999 {
+		t.Errorf("ParseResponse() XAU bid = %v, ask = %v, want the inverted ask and bid", result.Bid["XAU"], result.Ask["XAU"])
+	}
+}
+
+func TestHTTPExchangeRateProvider_normalizeRates(t *testing.T) {
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "metals", InvertedSymbols: []string{"XAU", "XAG"}},
+		testutils.MockLogger(),
+	)
+
+	jsonResponse := `{
+		"base": "USD",
+		"timestamp": 1640995200,
+		"rates": {
+			"EUR": 0.85,
+			"XAU": 2000,
+			"XAG": 25
+		}
+	}`
+
+	result, err := provider.ParseResponse([]byte(jsonResponse), "USD")
+	if err != nil {
+		t.Fatalf("ParseResponse() error = %v", err)
+	}
+
+	if result.Rates["EUR"] != 0.85 {
+		t.Errorf("ParseResponse() EUR = %v, want %v", result.Rates["EUR"], 0.85)
+	}
+	if result.Rates["XAU"] != 0.0005 {
+		t.Errorf("ParseResponse() XAU = %v, want %v", result.Rates["XAU"], 0.0005)
+	}
+	if result.Rates["XAG"] != 0.04 {
+		t.Errorf("ParseResponse() XAG = %v, want %v", result.Rates["XAG"], 0.04)
+	}
+}
+
+func TestHTTPExchangeRateProvider_parseResponse_Timestamps(t *testing.T) {
+	tests := []struct {
+		name            string
+		providerName    string
+		body            string
+		wantBase        string
+		wantPublishedAt int64
+	}{
+		{
+			name:            "erapi native format",
+			providerName:    "erapi",
+			body:            `{"base_code": "USD", "time_last_update_unix": 1640995200, "rates": {"EUR": 0.85}}`,
+			wantBase:        "USD",
+			wantPublishedAt: 1640995200,
+		},
+		{
+			name:            "frankfurter date",
+			providerName:    "frankfurter",
+			body:            `{"amount": 1.0, "base": "EUR", "date": "2022-01-01", "rates": {"USD": 1.13}}`,
+			wantBase:        "EUR",
+			wantPublishedAt: 1640995200,
+		},
+		{
+			name:            "generic without publication time",
+			providerName:    "custom",
+			body:            `{"base": "USD", "rates": {"EUR": 0.85}}`,
+			wantBase:        "USD",
+			wantPublishedAt: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewHTTPExchangeRateProvider(
+				config.ExchangeRateProvider{Name: tt.providerName},
+				testutils.MockLogger(),
+			)
+
+			before := time.Now().Unix()
+			result, err := provider.ParseResponse([]byte(tt.body), tt.wantBase)
+			if err != nil {
+				t.Fatalf("ParseResponse() error = %v", err)
+			}
+
+			if result.Base != tt.wantBase {
+				t.Errorf("ParseResponse() Base = %v, want %v", result.Base, tt.wantBase)
+			}
+			if result.PublishedAt != tt.wantPublishedAt {
+				t.Errorf("ParseResponse() PublishedAt = %v, want %v", result.PublishedAt, tt.wantPublishedAt)
+			}
+			if result.FetchedAt < before {
+				t.Errorf("ParseResponse() FetchedAt = %v, want >= %v", result.FetchedAt, before)
+			}
+			if result.Timestamp == 0 {
+				t.Error("ParseResponse() Timestamp should not be zero")
+			}
+		})
+	}
+}
+
+func TestHTTPExchangeRateProvider_buildHistoricalURL(t *testing.T) {
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		providerName  string
+		baseURL       string
+		wantURL       string
+		wantSupported bool
+	}{
+		{"openexchangerates", "https://openexchangerates.org/api/latest.json", "https://openexchangerates.org/api/historical/2024-01-31.json?base=USD", true},
+		{"frankfurter", "https://api.frankfurter.app/latest", "https://api.frankfurter.app/2024-01-31?from=USD", true},
+		{"exchangerate.host", "https://api.exchangerate.host/latest", "https://api.exchangerate.host/2024-01-31?base=USD", true},
+		{"erapi", "https://open.er-api.com/v6/latest", "", false},
+		{"custom", "https://custom.api.com/rates", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.providerName, func(t *testing.T) {
+			provider := NewHTTPExchangeRateProvider(
+				config.ExchangeRateProvider{Name: tt.providerName, BaseURL: tt.baseURL},
+				testutils.MockLogger(),
+			)
+
+			url, supported := provider.buildHistoricalURL(tt.baseURL, "USD", date)
+			if supported != tt.wantSupported {
+				t.Fatalf("buildHistoricalURL() supported = %v, want %v", supported, tt.wantSupported)
+			}
+			if url != tt.wantURL {
+				t.Errorf("buildHistoricalURL() = %v, want %v", url, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetHistoricalRates(t *testing.T) {
+	var requestedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"amount": 1.0, "base": "USD", "date": "2024-01-31", "rates": {"EUR": 0.92}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "frankfurter", BaseURL: server.URL + "/latest", Enabled: true},
+		testutils.MockLogger(),
+	)
+
+	result, err := provider.GetHistoricalRates(context.Background(), "USD", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GetHistoricalRates() error = %v", err)
+	}
+	if requestedPath != "/2024-01-31" {
+		t.Errorf("GetHistoricalRates() path = %v, want %v", requestedPath, "/2024-01-31")
+	}
+	if result.Rates["EUR"] != 0.92 {
+		t.Errorf("GetHistoricalRates() EUR = %v, want %v", result.Rates["EUR"], 0.92)
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_MirrorFailover(t *testing.T) {
+	primaryCalls := 0
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+
+	mirrorCalls := 0
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorCalls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base_code": "USD", "time_last_update_unix": 1640995200, "rates": {"EUR": 0.85}}`))
+	}))
+	defer mirror.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{
+			Name:       "erapi",
+			BaseURL:    primary.URL,
+			MirrorURLs: []string{mirror.URL},
+			Enabled:    true,
+		},
+		testutils.MockLogger(),
+	)
+
+	for i := 0; i < 2; i++ {
+		result, err := provider.GetRates(context.Background(), "USD")
+		if err != nil {
+			t.Fatalf("GetRates() call %d error = %v", i+1, err)
+		}
+		if result.Rates["EUR"] != 0.85 {
+			t.Errorf("GetRates() EUR = %v, want %v", result.Rates["EUR"], 0.85)
+		}
+	}
+
+	// The second request should go straight to the mirror that answered the first
+	if primaryCalls != 1 {
+		t.Errorf("primary endpoint calls = %v, want %v", primaryCalls, 1)
+	}
+	if mirrorCalls != 2 {
+		t.Errorf("mirror endpoint calls = %v, want %v", mirrorCalls, 2)
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_AllEndpointsFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{
+			Name:       "erapi",
+			BaseURL:    server.URL,
+			MirrorURLs: []string{server.URL + "/mirror"},
+			Enabled:    true,
+		},
+		testutils.MockLogger(),
+	)
+
+	if _, err := provider.GetRates(context.Background(), "USD"); err == nil {
+		t.Error("GetRates() expected error when every endpoint fails")
+	}
+}
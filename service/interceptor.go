@@ -0,0 +1,41 @@
+package service
+
+import "net/http"
+
+// ProviderInterceptor wraps the HTTP transport of the named provider, so an embedding
+// application can add telemetry, caching proxies or authentication to provider calls.
+// Requests reach the interceptor fully prepared: signed, with validators and request ID.
+type ProviderInterceptor func(provider string, next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper, for writing interceptors
+type RoundTripperFunc func(request *http.Request) (*http.Response, error)
+
+// RoundTrip calls the function
+func (roundTrip RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return roundTrip(request)
+}
+
+// UseInterceptors wraps the provider's transport with the interceptors, the first one
+// outermost. Interceptors added by a later call wrap those added before. Add them before
+// the provider makes its first call.
+func (provider *HTTPExchangeRateProvider) UseInterceptors(interceptors ...ProviderInterceptor) {
+	transport := provider.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		transport = interceptors[i](provider.configuration.Name, transport)
+	}
+	provider.httpClient.Transport = transport
+}
+
+// UseProviderInterceptors wraps the transport of every HTTP provider with the
+// interceptors, including the members of composite providers and the providers of tenant
+// views. Add them before the service starts serving.
+func (ratesService *RatesService) UseProviderInterceptors(interceptors ...ProviderInterceptor) {
+	for _, provider := range ratesService.providers {
+		if httpProvider, isHTTP := provider.(*HTTPExchangeRateProvider); isHTTP {
+			httpProvider.UseInterceptors(interceptors...)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_UseProviderInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer proxy-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.85}}`))
+	}))
+	defer server.Close()
+
+	service := NewRatesService(testutils.MockConfig(), testutils.MockLogger())
+	service.providers = []ExchangeRateProvider{
+		NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "internal", BaseURL: server.URL, Enabled: true}, testutils.MockLogger()),
+	}
+
+	var mutex sync.Mutex
+	var calls []string
+	record := func(name string) ProviderInterceptor {
+		return func(provider string, next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				mutex.Lock()
+				calls = append(calls, name+":"+provider)
+				mutex.Unlock()
+				return next.RoundTrip(request)
+			})
+		}
+	}
+	authenticate := func(provider string, next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			request = request.Clone(request.Context())
+			request.Header.Set("Authorization", "Bearer proxy-token")
+			return next.RoundTrip(request)
+		})
+	}
+	service.UseProviderInterceptors(record("outer"), authenticate, record("inner"))
+
+	result, err := service.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if result.Rates["EUR"] != 0.85 {
+		t.Errorf("GetRates() rates = %v", result.Rates)
+	}
+	if len(calls) != 2 || calls[0] != "outer:internal" || calls[1] != "inner:internal" {
+		t.Errorf("interceptor calls = %v, want outer:internal then inner:internal", calls)
+	}
+}
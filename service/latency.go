@@ -0,0 +1,190 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+)
+
+// latencySample is a single observed provider call duration
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// providerLatency holds the rolling samples and SLO state of one provider
+type providerLatency struct {
+	samples         []latencySample
+	breachingSince  time.Time
+	recoveringSince time.Time
+	demoted         bool
+}
+
+// latencyTracker tracks rolling p95 latency per provider and demotes providers that
+// breach the configured SLO for too long, restoring them once they recover.
+// A nil tracker is valid and never demotes anything.
+type latencyTracker struct {
+	slo    config.LatencySLOConfig
+	logger logger.Logger
+	now    func() time.Time
+
+	mutex     sync.Mutex
+	providers map[string]*providerLatency
+}
+
+// newLatencyTracker returns a tracker for the SLO, or nil when the SLO is disabled
+func newLatencyTracker(slo config.LatencySLOConfig, logger logger.Logger) *latencyTracker {
+	if slo.P95Threshold <= 0 {
+		return nil
+	}
+	return &latencyTracker{
+		slo:       slo,
+		logger:    logger,
+		now:       time.Now,
+		providers: make(map[string]*providerLatency),
+	}
+}
+
+// observe records a call duration for the provider and re-evaluates its SLO state
+func (tracker *latencyTracker) observe(providerName string, duration time.Duration) {
+	if tracker == nil {
+		return
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	now := tracker.now()
+	state := tracker.stateFor(providerName)
+	state.samples = append(state.samples, latencySample{at: now, duration: duration})
+	tracker.evaluate(providerName, state, now)
+}
+
+// isDemoted reports whether the provider is currently demoted
+func (tracker *latencyTracker) isDemoted(providerName string) bool {
+	if tracker == nil {
+		return false
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	state, exists := tracker.providers[providerName]
+	if !exists {
+		return false
+	}
+	tracker.evaluate(providerName, state, tracker.now())
+	return state.demoted
+}
+
+// p95 returns the provider's rolling p95 latency (0 without samples)
+func (tracker *latencyTracker) p95(providerName string) time.Duration {
+	if tracker == nil {
+		return 0
+	}
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	state, exists := tracker.providers[providerName]
+	if !exists {
+		return 0
+	}
+	tracker.evaluate(providerName, state, tracker.now())
+	return percentile(state.samples, 0.95)
+}
+
+// order returns the providers sorted by effective priority: healthy providers first,
+// then demoted ones, each group by configured priority
+func (tracker *latencyTracker) order(providers []ExchangeRateProvider) []ExchangeRateProvider {
+	ordered := make([]ExchangeRateProvider, len(providers))
+	copy(ordered, providers)
+
+	demoted := make(map[string]bool, len(providers))
+	for _, provider := range providers {
+		demoted[provider.GetName()] = tracker.isDemoted(provider.GetName())
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		leftDemoted, rightDemoted := demoted[ordered[i].GetName()], demoted[ordered[j].GetName()]
+		if leftDemoted != rightDemoted {
+			return !leftDemoted
+		}
+		return ordered[i].GetPriority() < ordered[j].GetPriority()
+	})
+	return ordered
+}
+
+// stateFor returns the provider's state, creating it on first use (caller holds the lock)
+func (tracker *latencyTracker) stateFor(providerName string) *providerLatency {
+	state, exists := tracker.providers[providerName]
+	if !exists {
+		state = &providerLatency{}
+		tracker.providers[providerName] = state
+	}
+	return state
+}
+
+// evaluate drops samples outside the window and applies demotion/restoration (caller holds the lock).
+// A window without samples counts as meeting the SLO so demoted providers get another chance.
+func (tracker *latencyTracker) evaluate(providerName string, state *providerLatency, now time.Time) {
+	cutoff := now.Add(-tracker.slo.Window)
+	firstKept := 0
+	for firstKept < len(state.samples) && state.samples[firstKept].at.Before(cutoff) {
+		firstKept++
+	}
+	state.samples = state.samples[firstKept:]
+
+	p95 := percentile(state.samples, 0.95)
+	if p95 > tracker.slo.P95Threshold {
+		state.recoveringSince = time.Time{}
+		if state.breachingSince.IsZero() {
+			state.breachingSince = now
+		}
+		if !state.demoted && now.Sub(state.breachingSince) >= tracker.slo.BreachDuration {
+			state.demoted = true
+			tracker.logger.WithFields(logger.Fields{
+				"provider":     providerName,
+				"p95_ms":       p95.Milliseconds(),
+				"threshold_ms": tracker.slo.P95Threshold.Milliseconds(),
+			}).Warn("Provider demoted: latency SLO breached")
+		}
+		return
+	}
+
+	state.breachingSince = time.Time{}
+	if !state.demoted {
+		return
+	}
+	if state.recoveringSince.IsZero() {
+		state.recoveringSince = now
+	}
+	if now.Sub(state.recoveringSince) >= tracker.slo.RecoveryDuration {
+		state.demoted = false
+		state.recoveringSince = time.Time{}
+		tracker.logger.WithFields(logger.Fields{
+			"provider": providerName,
+			"p95_ms":   p95.Milliseconds(),
+		}).Info("Provider restored: latency SLO met")
+	}
+}
+
+// percentile returns the nearest-rank percentile of the sample durations
+func percentile(samples []latencySample, quantile float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	durations := make([]time.Duration, len(samples))
+	for i, sample := range samples {
+		durations[i] = sample.duration
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	rank := int(math.Ceil(quantile*float64(len(durations)))) - 1
+	return durations[max(rank, 0)]
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func testSLO() config.LatencySLOConfig {
+	return config.LatencySLOConfig{
+		P95Threshold:     800 * time.Millisecond,
+		Window:           time.Minute,
+		BreachDuration:   5 * time.Minute,
+		RecoveryDuration: 2 * time.Minute,
+	}
+}
+
+func TestNewLatencyTracker_Disabled(t *testing.T) {
+	tracker := newLatencyTracker(config.LatencySLOConfig{}, testutils.MockLogger())
+	if tracker != nil {
+		t.Fatalf("newLatencyTracker() = %v, want nil when threshold is 0", tracker)
+	}
+
+	tracker.observe("slow", time.Hour)
+	if tracker.isDemoted("slow") {
+		t.Error("isDemoted() on nil tracker = true, want false")
+	}
+}
+
+func TestLatencyTracker_DemoteAndRestore(t *testing.T) {
+	now := time.Unix(1641000000, 0)
+	tracker := newLatencyTracker(testSLO(), testutils.MockLogger())
+	tracker.now = func() time.Time { return now }
+
+	// Breaching for less than the breach duration does not demote
+	for i := 0; i < 4; i++ {
+		tracker.observe("slow", 1200*time.Millisecond)
+		tracker.observe("fast", 100*time.Millisecond)
+		now = now.Add(time.Minute)
+	}
+	if tracker.isDemoted("slow") {
+		t.Fatal("isDemoted() after 4m breach = true, want false")
+	}
+
+	// Sustained breach demotes
+	for i := 0; i < 2; i++ {
+		tracker.observe("slow", 1200*time.Millisecond)
+		now = now.Add(time.Minute)
+	}
+	tracker.observe("slow", 1200*time.Millisecond)
+	if !tracker.isDemoted("slow") {
+		t.Fatal("isDemoted() after 6m breach = false, want true")
+	}
+	if tracker.isDemoted("fast") {
+		t.Error("isDemoted() fast provider = true, want false")
+	}
+
+	// Recovery must last the recovery duration before restoring
+	now = now.Add(2 * time.Minute)
+	tracker.observe("slow", 200*time.Millisecond)
+	if !tracker.isDemoted("slow") {
+		t.Fatal("isDemoted() right after recovery = false, want true")
+	}
+	now = now.Add(30 * time.Second)
+	tracker.observe("slow", 200*time.Millisecond)
+	now = now.Add(2 * time.Minute)
+	tracker.observe("slow", 200*time.Millisecond)
+	if tracker.isDemoted("slow") {
+		t.Error("isDemoted() after sustained recovery = true, want false")
+	}
+}
+
+func TestLatencyTracker_Order(t *testing.T) {
+	now := time.Unix(1641000000, 0)
+	slo := testSLO()
+	slo.BreachDuration = 0
+	tracker := newLatencyTracker(slo, testutils.MockLogger())
+	tracker.now = func() time.Time { return now }
+
+	providers := []ExchangeRateProvider{
+		&testutils.FakeProvider{Name: "primary", Priority: 1},
+		&testutils.FakeProvider{Name: "secondary", Priority: 2},
+		&testutils.FakeProvider{Name: "tertiary", Priority: 3},
+	}
+	tracker.observe("primary", 2*time.Second)
+
+	ordered := tracker.order(providers)
+	got := []string{ordered[0].GetName(), ordered[1].GetName(), ordered[2].GetName()}
+	want := []string{"secondary", "tertiary", "primary"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("order() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	samples := []latencySample{}
+	for i := 1; i <= 20; i++ {
+		samples = append(samples, latencySample{duration: time.Duration(i) * time.Millisecond})
+	}
+
+	if got := percentile(samples, 0.95); got != 19*time.Millisecond {
+		t.Errorf("percentile() = %v, want %v", got, 19*time.Millisecond)
+	}
+	if got := percentile(nil, 0.95); got != 0 {
+		t.Errorf("percentile() without samples = %v, want 0", got)
+	}
+}
+
+func TestRatesService_DemotedProviderFallback(t *testing.T) {
+	slo := testSLO()
+	slo.BreachDuration = 0
+	tracker := newLatencyTracker(slo, testutils.MockLogger())
+	tracker.observe("demoted", 2*time.Second)
+
+	demoted := &testutils.FakeProvider{Name: "demoted", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.80}}
+	healthy := &testutils.FakeProvider{Name: "healthy", Enabled: true, Priority: 2, Rates: map[string]float64{"EUR": 0.85}}
+
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{demoted, healthy},
+		latency:       tracker,
+	}
+
+	result, err := service.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if result.Provider != "healthy" {
+		t.Errorf("GetRates() Provider = %v, want %v", result.Provider, "healthy")
+	}
+
+	statuses := service.GetProviderStatus()
+	if !statuses[0].Demoted || statuses[0].EffectivePriority != 2 || statuses[1].EffectivePriority != 1 {
+		t.Errorf("GetProviderStatus() = %+v, want demoted provider ranked last", statuses)
+	}
+
+	// With the healthy provider failing, the demoted provider is used as a fallback
+	healthy.Err = context.DeadlineExceeded
+	service.PurgeCache(context.Background())
+	result, err = service.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() fallback error = %v", err)
+	}
+	if result.Provider != "demoted" {
+		t.Errorf("GetRates() fallback Provider = %v, want %v", result.Provider, "demoted")
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// ErrProviderRateLimited is returned instead of calling a provider whose outbound rate
+// limit is used up, so the request falls through to the other providers
+var ErrProviderRateLimited = errors.New("provider outbound rate limit reached")
+
+// outboundPeriods are the units of an outbound rate limit; a month is counted as 30 days
+var outboundPeriods = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+}
+
+// ParseOutboundLimit parses a provider's published limit like "1000/month" into requests
+// per period. An empty limit, or "unlimited", returns zero requests.
+func ParseOutboundLimit(limit string) (int, time.Duration, error) {
+	limit = strings.ToLower(strings.TrimSpace(limit))
+	if limit == "" || limit == "unlimited" {
+		return 0, 0, nil
+	}
+
+	count, unit, found := strings.Cut(limit, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid outbound rate limit %q (expected requests/unit, e.g. 1000/month)", limit)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || requests <= 0 {
+		return 0, 0, fmt.Errorf("invalid outbound rate limit %q: requests must be a positive number", limit)
+	}
+	period, known := outboundPeriods[strings.TrimSuffix(strings.TrimSpace(unit), "s")]
+	if !known {
+		return 0, 0, fmt.Errorf("invalid outbound rate limit %q: unit must be second, minute, hour, day or month", limit)
+	}
+	return requests, period, nil
+}
+
+// outboundLimit is a token bucket holding a provider to its published request limit, so
+// our own retries and cache misses cannot get its API key banned. Calls never wait for a
+// token: without one they fail at once and the other providers answer instead.
+// A nil value is valid and allows every call.
+type outboundLimit struct {
+	limit    string
+	burst    int
+	interval time.Duration // Time to earn one token
+	now      func() time.Time
+
+	mutex      sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	rejected   int64
+}
+
+// newOutboundLimit returns the provider's token bucket, or nil when its limit is empty or
+// unparsable. The bucket starts full, holding burst tokens (at least one).
+func newOutboundLimit(limit string, burst int) *outboundLimit {
+	requests, period, err := ParseOutboundLimit(limit)
+	if err != nil || requests == 0 {
+		return nil
+	}
+	burst = max(burst, 1)
+	now := time.Now
+	return &outboundLimit{
+		limit:      strings.TrimSpace(limit),
+		burst:      burst,
+		interval:   period / time.Duration(requests),
+		now:        now,
+		tokens:     float64(burst),
+		lastRefill: now(),
+	}
+}
+
+// take spends a token, returning ErrProviderRateLimited when none is left
+func (limit *outboundLimit) take() error {
+	if limit == nil {
+		return nil
+	}
+
+	limit.mutex.Lock()
+	defer limit.mutex.Unlock()
+
+	limit.refill(limit.now())
+	if limit.tokens < 1 {
+		limit.rejected++
+		return ErrProviderRateLimited
+	}
+	limit.tokens--
+	return nil
+}
+
+// admit reports ErrProviderRateLimited when no token is left, without spending one
+func (limit *outboundLimit) admit() error {
+	if limit == nil {
+		return nil
+	}
+
+	limit.mutex.Lock()
+	defer limit.mutex.Unlock()
+
+	limit.refill(limit.now())
+	if limit.tokens < 1 {
+		limit.rejected++
+		return ErrProviderRateLimited
+	}
+	return nil
+}
+
+// stats reports the bucket, or nothing when the provider is unlimited
+func (limit *outboundLimit) stats() *models.ProviderRateLimit {
+	if limit == nil {
+		return nil
+	}
+
+	limit.mutex.Lock()
+	defer limit.mutex.Unlock()
+
+	now := limit.now()
+	limit.refill(now)
+	stats := &models.ProviderRateLimit{
+		Limit:     limit.limit,
+		Burst:     limit.burst,
+		Available: int(limit.tokens),
+		Rejected:  limit.rejected,
+	}
+	if limit.tokens < 1 {
+		nextToken := now.Add(time.Duration((1 - limit.tokens) * float64(limit.interval)))
+		stats.NextTokenAt = &nextToken
+	}
+	return stats
+}
+
+// refill adds the tokens earned since the last refill (caller holds the lock)
+func (limit *outboundLimit) refill(now time.Time) {
+	elapsed := now.Sub(limit.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+	limit.tokens = min(float64(limit.burst), limit.tokens+float64(elapsed)/float64(limit.interval))
+	limit.lastRefill = now
+}
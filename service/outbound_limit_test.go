@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestParseOutboundLimit(t *testing.T) {
+	tests := []struct {
+		limit    string
+		requests int
+		period   time.Duration
+		wantErr  bool
+	}{
+		{"", 0, 0, false},
+		{"unlimited", 0, 0, false},
+		{"1000/month", 1000, 30 * 24 * time.Hour, false},
+		{"10/Seconds", 10, time.Second, false},
+		{" 250 / day ", 250, 24 * time.Hour, false},
+		{"1000", 0, 0, true},
+		{"0/hour", 0, 0, true},
+		{"many/hour", 0, 0, true},
+		{"5/week", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			requests, period, err := ParseOutboundLimit(tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOutboundLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requests != tt.requests || period != tt.period {
+				t.Errorf("ParseOutboundLimit() = %d per %s, want %d per %s", requests, period, tt.requests, tt.period)
+			}
+		})
+	}
+}
+
+func TestOutboundLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limit := newOutboundLimit("60/minute", 2)
+	limit.now = func() time.Time { return now }
+	limit.lastRefill = now
+
+	for i := 0; i < 2; i++ {
+		if err := limit.take(); err != nil {
+			t.Fatalf("take() %d error = %v", i, err)
+		}
+	}
+	if err := limit.take(); !errors.Is(err, ErrProviderRateLimited) {
+		t.Fatalf("take() without tokens error = %v, want %v", err, ErrProviderRateLimited)
+	}
+	if err := limit.admit(); !errors.Is(err, ErrProviderRateLimited) {
+		t.Errorf("admit() without tokens error = %v, want %v", err, ErrProviderRateLimited)
+	}
+
+	stats := limit.stats()
+	if stats.Limit != "60/minute" || stats.Burst != 2 || stats.Available != 0 || stats.Rejected != 2 {
+		t.Errorf("stats() = %+v, want no tokens and 2 rejected", stats)
+	}
+	if stats.NextTokenAt == nil || !stats.NextTokenAt.Equal(now.Add(time.Second)) {
+		t.Errorf("stats() NextTokenAt = %v, want %v", stats.NextTokenAt, now.Add(time.Second))
+	}
+
+	// A token is earned every second, and the bucket never holds more than the burst
+	now = now.Add(time.Second)
+	if err := limit.take(); err != nil {
+		t.Fatalf("take() after a second error = %v", err)
+	}
+	now = now.Add(time.Hour)
+	if stats := limit.stats(); stats.Available != 2 || stats.NextTokenAt != nil {
+		t.Errorf("stats() after an hour = %+v, want a full bucket of 2", stats)
+	}
+}
+
+func TestOutboundLimit_Unlimited(t *testing.T) {
+	for _, spec := range []string{"", "unlimited", "not-a-limit"} {
+		limit := newOutboundLimit(spec, 5)
+		if limit != nil {
+			t.Fatalf("newOutboundLimit(%q) = %+v, want nil", spec, limit)
+		}
+		if err := limit.take(); err != nil || limit.admit() != nil || limit.stats() != nil {
+			t.Errorf("nil limit should allow every call and report nothing")
+		}
+	}
+}
+
+func TestRatesService_RateLimitedProviderFallsThrough(t *testing.T) {
+	var limitedCalls, otherCalls atomic.Int64
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limitedCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.85}}`))
+	}))
+	defer limited.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherCalls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.86}}`))
+	}))
+	defer other.Close()
+
+	cfg := testutils.MockConfig()
+	cfg.ExchangeRateProviders = []config.ExchangeRateProvider{
+		{Name: "limited", BaseURL: limited.URL, Enabled: true, Priority: 1, RateLimit: "1/month", RateLimitBurst: 1},
+		{Name: "other", BaseURL: other.URL, Enabled: true, Priority: 2},
+	}
+	ratesService := NewRatesService(cfg, testutils.MockLogger())
+
+	ctx := context.Background()
+	if _, err := ratesService.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	// Expire the cache: the limited provider has no token left, so the other one answers
+	expireCache(ratesService)
+
+	response, err := ratesService.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() with the limited provider out of tokens error = %v", err)
+	}
+	if response.Provider != "other" {
+		t.Errorf("GetRates() provider = %s, want other", response.Provider)
+	}
+	if calls := limitedCalls.Load(); calls != 1 {
+		t.Errorf("limited provider calls = %d, want 1", calls)
+	}
+
+	var status *ProviderStatus
+	for _, providerStatus := range ratesService.GetProviderStatus() {
+		if providerStatus.Name == "limited" {
+			providerStatus := providerStatus
+			status = &providerStatus
+		}
+	}
+	if status == nil || status.RateLimit == nil || status.RateLimit.Rejected != 1 {
+		t.Errorf("GetProviderStatus() limited = %+v, want a rate limit with 1 rejected call", status)
+	}
+}
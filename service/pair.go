@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// GetPairRate returns the rate of one currency pair and its inverse. Valid cached rates
+// for any base that quotes both currencies are reused, so pollers of different pairs do
+// not keep replacing each other's base in the single-entry cache, as long as the source
+// policy permits their provider for both currencies.
+func (ratesService *RatesService) GetPairRate(requestContext context.Context, fromCurrency, toCurrency string) (models.PairRateResponse, error) {
+	for _, code := range []string{fromCurrency, toCurrency} {
+		if !ratesService.IsCurrencyAllowed(code) {
+			return models.PairRateResponse{}, &ServiceError{
+				Type:    ErrorTypeInvalidRequest,
+				Message: fmt.Sprintf("currency not allowed: %s", code),
+			}
+		}
+	}
+
+	exchangeRates, found := ratesService.cachedPairRates(fromCurrency, toCurrency)
+	if !found || ratesService.pairNeedsRefresh(exchangeRates, fromCurrency, toCurrency) {
+		var err error
+		if exchangeRates, err = ratesService.GetRatesForSymbols(requestContext, fromCurrency, []string{toCurrency}); err != nil {
+			return models.PairRateResponse{}, err
+		}
+	}
+
+	rate, found := exchangeRates.PairRate(fromCurrency, toCurrency)
+	if !found {
+		return models.PairRateResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: fmt.Sprintf("unsupported currency pair: %s/%s", fromCurrency, toCurrency),
+		}
+	}
+
+	return models.PairRateResponse{
+		Pair:        fromCurrency + "/" + toCurrency,
+		Rate:        rate,
+		Inverse:     1 / rate,
+		Provider:    exchangeRates.Provider,
+		Timestamp:   exchangeRates.Timestamp,
+		PublishedAt: exchangeRates.PublishedAt,
+		FetchedAt:   exchangeRates.FetchedAt,
+		AgeSeconds:  exchangeRates.AgeSeconds,
+		Stale:       ratesService.isStale(time.Duration(exchangeRates.AgeSeconds)*time.Second, fromCurrency, toCurrency),
+	}, nil
+}
+
+// cachedPairRates returns valid cached latest rates of any base that quote the pair,
+// preferring the most recently published table
+func (ratesService *RatesService) cachedPairRates(fromCurrency, toCurrency string) (models.RatesResponse, bool) {
+	ratesService.cacheMutex.RLock()
+	now := ratesService.now()
+	var best models.RatesResponse
+	found := false
+	for key, entry := range ratesService.cache {
+		if key.Date != "" || !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if _, quoted := entry.Data.PairRate(fromCurrency, toCurrency); !quoted {
+			continue
+		}
+		if !ratesService.sourcePolicy.permits(entry.Data.Provider, fromCurrency, toCurrency) {
+			continue
+		}
+		if !found || entry.Data.Timestamp > best.Timestamp {
+			best, found = entry.Data, true
+		}
+	}
+	ratesService.cacheMutex.RUnlock()
+
+	if !found {
+		return models.RatesResponse{}, false
+	}
+	atomic.AddInt64(&ratesService.cacheHits, 1)
+	return withAge(best, now), true
+}
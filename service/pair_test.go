@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_GetPairRate(t *testing.T) {
+	provider := &testutils.FakeProvider{
+		Name:    "test-provider",
+		Enabled: true,
+		Rates:   map[string]float64{"EUR": 0.8, "GBP": 0.5},
+	}
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{provider},
+	}
+
+	result, err := service.GetPairRate(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("GetPairRate() error = %v", err)
+	}
+	if result.Pair != "USD/EUR" || result.Rate != 0.8 || result.Inverse != 1.25 || result.Provider != "test-provider" {
+		t.Errorf("GetPairRate() = %+v, want USD/EUR 0.8 with inverse 1.25", result)
+	}
+
+	// Other pairs quoted by the cached USD table are served without another fetch
+	result, err = service.GetPairRate(context.Background(), "GBP", "EUR")
+	if err != nil {
+		t.Fatalf("GetPairRate() cross error = %v", err)
+	}
+	if math.Abs(result.Rate-1.6) > 1e-9 || math.Abs(result.Inverse-0.625) > 1e-9 {
+		t.Errorf("GetPairRate() cross = %+v, want 1.6 with inverse 0.625", result)
+	}
+	if provider.Calls() != 1 {
+		t.Errorf("GetPairRate() fetched rates %d times, want 1", provider.Calls())
+	}
+
+	if _, err := service.GetPairRate(context.Background(), "USD", "XYZ"); err == nil {
+		t.Error("GetPairRate() expected error for an unsupported pair")
+	}
+}
+
+func TestRatesService_GetPairRate_AllowedCurrencies(t *testing.T) {
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{&testutils.FakeProvider{Name: "test-provider", Enabled: true, Rates: map[string]float64{"EUR": 0.8, "GBP": 0.5}}},
+	}
+	view := service.ForTenant(&config.Tenant{ID: "acme", AllowedCurrencies: []string{"USD", "EUR"}})
+
+	_, err := view.GetPairRate(context.Background(), "USD", "GBP")
+	if serviceError, ok := err.(*ServiceError); !ok || serviceError.Type != ErrorTypeInvalidRequest {
+		t.Errorf("GetPairRate() error = %v, want currency not allowed", err)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// fuzzedParsers are the provider names selecting each parser; unknown names get the
+// generic one
+var fuzzedParsers = []string{"erapi", "openexchangerates", "frankfurter", "exchangerate.host", "custom"}
+
+// FuzzHTTPExchangeRateProvider_ParseResponse feeds upstream bytes to every parser, both
+// buffered and streamed, seeded with the recorded responses of the golden tests
+func FuzzHTTPExchangeRateProvider_ParseResponse(f *testing.F) {
+	samples, err := filepath.Glob(filepath.Join(parserCorpus, "*", "*.json"))
+	if err != nil {
+		f.Fatalf("Glob() error = %v", err)
+	}
+	for _, sample := range samples {
+		body, err := os.ReadFile(sample)
+		if err != nil {
+			f.Fatalf("ReadFile() error = %v", err)
+		}
+		f.Add(body)
+	}
+	f.Add([]byte(`{"base":"USD","rates":{"EUR":0,"GBP":-1,"JPY":1e308}}`))
+	f.Add([]byte(`{"base":"USD","rates":{"EUR":5e-324,"GBP":-5e-324}}`))
+
+	providers := make([]*HTTPExchangeRateProvider, 0, len(fuzzedParsers))
+	for _, name := range fuzzedParsers {
+		providers = append(providers, NewHTTPExchangeRateProvider(
+			config.ExchangeRateProvider{Name: name, InvertedSymbols: []string{"EUR", "GBP"}},
+			testutils.MockLogger(),
+		))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, provider := range providers {
+			result, err := provider.ParseResponse(body, "USD")
+			if err == nil {
+				if result.Provider != provider.GetName() {
+					t.Errorf("%s: Provider = %q", provider.GetName(), result.Provider)
+				}
+				if result.Timestamp <= 0 && result.PublishedAt == 0 {
+					t.Errorf("%s: Timestamp = %d, want the fetch time without a publication time", provider.GetName(), result.Timestamp)
+				}
+				// Rates are encoded into API responses, where JSON has no infinities
+				for symbol, rate := range result.Rates {
+					if math.IsInf(rate, 0) || math.IsNaN(rate) {
+						t.Errorf("%s: rate of %s = %v", provider.GetName(), symbol, rate)
+					}
+				}
+			}
+
+			// Streamed decoding reads the first JSON value only, so it may accept what the
+			// buffered one rejects for trailing bytes, but must not panic either
+			provider.decodeResponse(json.NewDecoder(bytes.NewReader(body)).Decode, "USD")
+		}
+	})
+}
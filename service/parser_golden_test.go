@@ -0,0 +1,101 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// updateGolden rewrites the golden files from the current parsers:
+//
+//	go test ./service -run ParserGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite the parser golden files")
+
+// parserCorpus holds recorded provider responses, one directory per provider name. Each
+// <base>_<case>.json response is parsed for the base its name starts with and compared
+// with the <base>_<case>.golden file next to it.
+const parserCorpus = "testdata/parsers"
+
+// goldenResponse is the expected outcome of parsing a recorded response. The fetch time
+// differs per run, so it is left out and checked separately.
+type goldenResponse struct {
+	Base        string             `json:"base,omitempty"`
+	Provider    string             `json:"provider,omitempty"`
+	PublishedAt int64              `json:"published_at,omitempty"`
+	Rates       map[string]float64 `json:"rates,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+func TestHTTPExchangeRateProvider_ParserGolden(t *testing.T) {
+	samples, err := filepath.Glob(filepath.Join(parserCorpus, "*", "*.json"))
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	if len(samples) == 0 {
+		t.Fatalf("no recorded responses in %s", parserCorpus)
+	}
+
+	for _, sample := range samples {
+		providerName := filepath.Base(filepath.Dir(sample))
+		caseName := strings.TrimSuffix(filepath.Base(sample), ".json")
+		t.Run(providerName+"/"+caseName, func(t *testing.T) {
+			body, err := os.ReadFile(sample)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			baseCurrency := strings.ToUpper(strings.SplitN(caseName, "_", 2)[0])
+			provider := NewHTTPExchangeRateProvider(
+				config.ExchangeRateProvider{Name: providerName},
+				testutils.MockLogger(),
+			)
+
+			var got goldenResponse
+			result, err := provider.ParseResponse(body, baseCurrency)
+			if err != nil {
+				got.Error = err.Error()
+			} else {
+				got = goldenResponse{
+					Base:        result.Base,
+					Provider:    result.Provider,
+					PublishedAt: result.PublishedAt,
+					Rates:       result.Rates,
+				}
+				wantTimestamp := result.PublishedAt
+				if wantTimestamp == 0 {
+					wantTimestamp = result.FetchedAt
+				}
+				if result.Timestamp != wantTimestamp {
+					t.Errorf("Timestamp = %d, want %d", result.Timestamp, wantTimestamp)
+				}
+			}
+
+			encoded, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatalf("MarshalIndent() error = %v", err)
+			}
+			encoded = append(encoded, '\n')
+
+			goldenPath := strings.TrimSuffix(sample, ".json") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, encoded, 0o644); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v; run with -update to record it", err)
+			}
+			if !bytes.Equal(encoded, want) {
+				t.Errorf("parsed %s differs from %s:\ngot:\n%s\nwant:\n%s", sample, goldenPath, encoded, want)
+			}
+		})
+	}
+}
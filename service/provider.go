@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/dalfonso89/currency-exchange-service/config"
 	"github.com/dalfonso89/currency-exchange-service/logger"
@@ -16,10 +18,42 @@ type ExchangeRateProvider interface {
 	GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error)
 }
 
+// HistoricalProvider is implemented by providers that can serve rates for past dates
+type HistoricalProvider interface {
+	SupportsHistory() bool
+	GetHistoricalRates(ctx context.Context, baseCurrency string, date time.Time) (models.RatesResponse, error)
+}
+
+// ConcurrencyReporter is implemented by providers that cap their concurrent requests
+type ConcurrencyReporter interface {
+	Concurrency() *models.ProviderConcurrency
+}
+
+// RateLimitReporter is implemented by providers held to an outbound rate limit
+type RateLimitReporter interface {
+	RateLimit() *models.ProviderRateLimit
+}
+
+// CompositeReporter is implemented by providers combining the rates of other providers
+type CompositeReporter interface {
+	Composite() *models.ProviderComposite
+}
+
+// PollingReporter is implemented by providers that poll conditionally
+type PollingReporter interface {
+	Polling() *models.ProviderPolling
+}
+
 // ProviderFactory creates exchange rate providers based on configuration
 type ProviderFactory struct {
 	configuration *config.Config
 	logger        logger.Logger
+
+	// transport is shared by all providers so they share one DNS cache
+	transport *http.Transport
+
+	// budget is shared by all providers so it caps their combined calls
+	budget *callBudget
 }
 
 // NewProviderFactory creates a new provider factory
@@ -27,19 +61,53 @@ func NewProviderFactory(configuration *config.Config, logger logger.Logger) *Pro
 	return &ProviderFactory{
 		configuration: configuration,
 		logger:        logger,
+		transport:     newProviderTransport(configuration.DNS, logger),
+		budget:        newCallBudget(configuration.ProviderBudget),
 	}
 }
 
-// CreateProviders creates all configured providers
+// CreateProviders creates all configured providers. Composite providers share the
+// instances of their members, so members keep one concurrency cap and rate limit.
 func (factory *ProviderFactory) CreateProviders() []ExchangeRateProvider {
 	var providers []ExchangeRateProvider
+	byName := make(map[string]ExchangeRateProvider)
 
 	for _, providerConfig := range factory.configuration.ExchangeRateProviders {
-		if providerConfig.Enabled {
+		if providerConfig.Enabled && providerConfig.Type != config.ProviderTypeComposite {
 			provider := NewHTTPExchangeRateProvider(providerConfig, factory.logger)
+			provider.httpClient.Transport = factory.transport
+			provider.budget = factory.budget
+			provider.slots = newProviderSlots(providerConfig.Name, providerConfig.MaxConcurrent, factory.configuration.ProviderQueueSize)
+			provider.rateLimit = newOutboundLimit(providerConfig.RateLimit, providerConfig.RateLimitBurst)
+			if factory.configuration.ConditionalPolling {
+				provider.poller = newConditionalPoller()
+			}
+			if factory.configuration.ProviderMaxResponseBytes > 0 {
+				provider.maxResponseBytes = factory.configuration.ProviderMaxResponseBytes
+			}
 			providers = append(providers, provider)
+			byName[providerConfig.Name] = provider
+		}
+	}
+
+	// Composites take their place in the priority order among the other providers
+	var ordered []ExchangeRateProvider
+	for _, providerConfig := range factory.configuration.ExchangeRateProviders {
+		if !providerConfig.Enabled {
+			continue
+		}
+		if providerConfig.Type != config.ProviderTypeComposite {
+			ordered = append(ordered, byName[providerConfig.Name])
+			continue
+		}
+		var members []ExchangeRateProvider
+		for _, name := range providerConfig.Members {
+			if member, found := byName[name]; found {
+				members = append(members, member)
+			}
 		}
+		ordered = append(ordered, newCompositeProvider(providerConfig, members, factory.logger))
 	}
 
-	return providers
+	return ordered
 }
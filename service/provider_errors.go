@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provider failure classes. Provider errors wrap one of them, so callers can test a
+// failure with errors.Is and decide whether to skip, back off or disable the provider.
+var (
+	ErrAuth            = errors.New("provider rejected the credentials")
+	ErrQuotaExceeded   = errors.New("provider quota exceeded")
+	ErrUnsupportedBase = errors.New("provider does not support the base currency")
+	ErrUpstream5xx     = errors.New("provider server error")
+	ErrDecode          = errors.New("provider response could not be decoded")
+)
+
+// ProviderError is a classified failure of a provider request
+type ProviderError struct {
+	Provider   string
+	StatusCode int           // HTTP status, 0 when the failure was not an HTTP error status
+	RetryAfter time.Duration // How long the provider asked us to wait (0 = not given)
+	Detail     string        // Start of the provider's error body or the decode error
+	Kind       error         // One of the provider failure classes
+	RequestID  string        // ID of the API request the call was made for ("" = none)
+}
+
+func (e *ProviderError) Error() string {
+	message := fmt.Sprintf("provider %s: %v", e.Provider, e.Kind)
+	if e.StatusCode != 0 {
+		message += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	if e.RequestID != "" {
+		message += fmt.Sprintf(" [request %s]", e.RequestID)
+	}
+	return message
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Kind
+}
+
+// newStatusError classifies a non-200 provider response by its status code
+func newStatusError(provider string, resp *http.Response, body []byte) *ProviderError {
+	providerError := &ProviderError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Detail:     strings.TrimSpace(string(body)),
+		Kind:       ErrUpstream5xx,
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		providerError.Kind = ErrAuth
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusPaymentRequired:
+		providerError.Kind = ErrQuotaExceeded
+		providerError.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity:
+		providerError.Kind = ErrUnsupportedBase
+	}
+	return providerError
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// failsOnEveryEndpoint reports whether an error would repeat on the provider's mirrors,
+// which share its credentials, quota and supported currencies
+func failsOnEveryEndpoint(err error) bool {
+	return errors.Is(err, ErrAuth) || errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrUnsupportedBase)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestNewStatusError(t *testing.T) {
+	tests := []struct {
+		status     int
+		retryAfter string
+		want       error
+		wantRetry  time.Duration
+	}{
+		{status: http.StatusUnauthorized, want: ErrAuth},
+		{status: http.StatusForbidden, want: ErrAuth},
+		{status: http.StatusTooManyRequests, retryAfter: "30", want: ErrQuotaExceeded, wantRetry: 30 * time.Second},
+		{status: http.StatusPaymentRequired, want: ErrQuotaExceeded},
+		{status: http.StatusBadRequest, want: ErrUnsupportedBase},
+		{status: http.StatusNotFound, want: ErrUnsupportedBase},
+		{status: http.StatusInternalServerError, want: ErrUpstream5xx},
+		{status: http.StatusServiceUnavailable, want: ErrUpstream5xx},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			err := newStatusError("test", resp, []byte(" upstream says no \n"))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("newStatusError() = %v, want %v", err, tt.want)
+			}
+			if err.RetryAfter != tt.wantRetry {
+				t.Errorf("newStatusError() RetryAfter = %v, want %v", err.RetryAfter, tt.wantRetry)
+			}
+			if err.Detail != "upstream says no" {
+				t.Errorf("newStatusError() Detail = %q, want %q", err.Detail, "upstream says no")
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "120", want: 2 * time.Minute},
+		{value: now.Add(time.Hour).Format(http.TimeFormat), want: time.Hour},
+		{value: now.Add(-time.Hour).Format(http.TimeFormat), want: 0},
+		{value: "", want: 0},
+		{value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestProviderFailure(t *testing.T) {
+	auth := &ProviderError{Provider: "a", StatusCode: 401, Kind: ErrAuth}
+	unsupported := &ProviderError{Provider: "b", StatusCode: 404, Kind: ErrUnsupportedBase}
+	upstream := &ProviderError{Provider: "c", StatusCode: 502, Kind: ErrUpstream5xx}
+
+	tests := []struct {
+		name string
+		errs []error
+		want ErrorType
+	}{
+		{name: "every provider lacks the base", errs: []error{unsupported, unsupported}, want: ErrorTypeUnsupportedBase},
+		{name: "providers disabled or lacking the base", errs: []error{unsupported, auth}, want: ErrorTypeProviderUnavailable},
+		{name: "an upstream failure", errs: []error{auth, upstream, unsupported}, want: ErrorTypeProviderFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := providerFailure("failed", tt.errs); got.Type != tt.want {
+				t.Errorf("providerFailure() type = %v, want %v", got.Type, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderGate(t *testing.T) {
+	now := time.Now()
+	gate := newProviderGate(testutils.MockLogger(), 1)
+	gate.now = func() time.Time { return now }
+
+	gate.observe("keyless", "USD", &ProviderError{Provider: "keyless", StatusCode: 401, Kind: ErrAuth})
+	gate.observe("limited", "USD", &ProviderError{Provider: "limited", StatusCode: 429, RetryAfter: time.Minute, Kind: ErrQuotaExceeded})
+	gate.observe("narrow", "XAU", &ProviderError{Provider: "narrow", StatusCode: 404, Kind: ErrUnsupportedBase})
+	gate.observe("flaky", "USD", &ProviderError{Provider: "flaky", StatusCode: 503, Kind: ErrUpstream5xx})
+
+	tests := []struct {
+		provider string
+		base     string
+		want     error
+	}{
+		{provider: "keyless", base: "EUR", want: ErrAuth},
+		{provider: "limited", base: "EUR", want: ErrQuotaExceeded},
+		{provider: "narrow", base: "XAU", want: ErrUnsupportedBase},
+		{provider: "narrow", base: "USD", want: nil},
+		{provider: "flaky", base: "USD", want: nil},
+	}
+	for _, tt := range tests {
+		if err := gate.admit(tt.provider, tt.base); !errors.Is(err, tt.want) {
+			t.Errorf("admit(%s, %s) = %v, want %v", tt.provider, tt.base, err, tt.want)
+		}
+	}
+
+	if disabled, _, _, lastError := gate.status("keyless"); !disabled || lastError == "" {
+		t.Errorf("status(keyless) disabled = %v, lastError = %q, want disabled with the error", disabled, lastError)
+	}
+
+	// The back-off ends after the provider's Retry-After, and a success re-enables
+	now = now.Add(time.Minute)
+	if err := gate.admit("limited", "EUR"); err != nil {
+		t.Errorf("admit(limited) after back-off = %v, want nil", err)
+	}
+	gate.observe("keyless", "USD", nil)
+	if err := gate.admit("keyless", "EUR"); err != nil {
+		t.Errorf("admit(keyless) after success = %v, want nil", err)
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_AuthSkipsMirrors(t *testing.T) {
+	mirrorCalls := 0
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "invalid_app_id"}`))
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorCalls++
+	}))
+	defer mirror.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "test", BaseURL: primary.URL, MirrorURLs: []string{mirror.URL}, Enabled: true},
+		testutils.MockLogger(),
+	)
+
+	_, err := provider.GetRates(context.Background(), "USD")
+	var providerError *ProviderError
+	if !errors.As(err, &providerError) || !errors.Is(err, ErrAuth) || providerError.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetRates() error = %v, want an auth ProviderError", err)
+	}
+	if mirrorCalls != 0 {
+		t.Errorf("mirror calls = %d, want 0: the mirror shares the rejected credentials", mirrorCalls)
+	}
+}
+
+func TestRatesService_GetRates_SkipsDisabledProvider(t *testing.T) {
+	rejecting := &testutils.FakeProvider{Name: "rejecting", Enabled: true, Priority: 1, Err: &ProviderError{Provider: "rejecting", StatusCode: 403, Kind: ErrAuth}}
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{rejecting},
+		gate:          newProviderGate(testutils.MockLogger(), 1),
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := ratesService.fetchRatesFromProviders(context.Background(), "USD", ratesService.providers)
+		if classifyError(err) != ErrorTypeProviderUnavailable {
+			t.Errorf("fetch %d error = %v, want provider unavailable", i, err)
+		}
+	}
+
+	statuses := ratesService.GetProviderStatus()
+	if !statuses[0].Disabled {
+		t.Errorf("GetProviderStatus() = %+v, want the provider disabled", statuses[0])
+	}
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// defaultQuotaBackoff is how long a provider over its quota is skipped when it does not
+// say when to retry
+const defaultQuotaBackoff = time.Minute
+
+// maxTransitions is how many provider state transitions the gate keeps
+const maxTransitions = 100
+
+// Provider states reported in transitions
+const (
+	stateEnabled  = "enabled"
+	stateDisabled = "disabled" // Held out until an operator enables it
+	stateStandby  = "standby"  // Held out and probed, until enough probes succeed in a row
+)
+
+var (
+	// ErrProviderDisabled is returned instead of calling a provider an operator disabled
+	ErrProviderDisabled = errors.New("provider disabled by an operator")
+	// ErrUnknownProvider is returned when an operator names a provider that is not configured
+	ErrUnknownProvider = errors.New("unknown provider")
+)
+
+// gateState is what the gate has learned about one provider
+type gateState struct {
+	disabled         bool
+	standby          bool   // Re-enabled after standbySuccesses consecutive successes
+	disabledBy       string // "health" or "operator"
+	successes        int    // Consecutive successes while in standby
+	backoffUntil     time.Time
+	unsupportedBases map[string]bool
+	lastError        string
+}
+
+// state names the provider's state for transitions
+func (state *gateState) state() string {
+	switch {
+	case state == nil || !state.disabled:
+		return stateEnabled
+	case state.standby:
+		return stateStandby
+	default:
+		return stateDisabled
+	}
+}
+
+// providerGate keeps providers out of fetches after failures that calling them again
+// would only repeat: rejected credentials put a provider in standby until
+// standbySuccesses probes or readiness checks succeed in a row, an exceeded quota backs
+// it off until its Retry-After, and an unsupported base is skipped for that base.
+// Operators can also disable a provider, held out until they enable it or, in standby,
+// until it recovers. It is shared with tenant views; a nil gate admits every provider.
+type providerGate struct {
+	logger           logger.Logger
+	now              func() time.Time
+	standbySuccesses int
+
+	mutex       sync.Mutex
+	providers   map[string]*gateState
+	transitions []models.ProviderTransition // Oldest first
+	listener    func(models.ProviderTransition)
+}
+
+func newProviderGate(logger logger.Logger, standbySuccesses int) *providerGate {
+	return &providerGate{
+		logger:           logger,
+		now:              time.Now,
+		standbySuccesses: max(standbySuccesses, 1),
+		providers:        make(map[string]*gateState),
+	}
+}
+
+// admit returns nil when the provider may be asked for the base, or the failure class
+// it is being skipped for
+func (gate *providerGate) admit(providerName, baseCurrency string) error {
+	if gate == nil {
+		return nil
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	state, exists := gate.providers[providerName]
+	switch {
+	case !exists:
+		return nil
+	case state.disabled && state.disabledBy == "operator":
+		return fmt.Errorf("provider %s is %s: %w", providerName, state.state(), ErrProviderDisabled)
+	case state.disabled:
+		return fmt.Errorf("provider %s is disabled: %w", providerName, ErrAuth)
+	case gate.now().Before(state.backoffUntil):
+		return fmt.Errorf("provider %s is backing off until %s: %w", providerName, state.backoffUntil.Format(time.RFC3339), ErrQuotaExceeded)
+	case state.unsupportedBases[baseCurrency]:
+		return fmt.Errorf("provider %s does not support %s: %w", providerName, baseCurrency, ErrUnsupportedBase)
+	}
+	return nil
+}
+
+// observe updates the provider's state from the outcome of a request for the base
+func (gate *providerGate) observe(providerName, baseCurrency string, err error) {
+	if gate == nil {
+		return
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	state := gate.providers[providerName]
+	if state == nil {
+		if err == nil {
+			return
+		}
+		state = gate.stateOf(providerName)
+	}
+
+	switch {
+	case err == nil:
+		state.backoffUntil = time.Time{}
+		if !state.disabled || !state.standby {
+			return
+		}
+		state.successes++
+		if state.successes < gate.standbySuccesses {
+			gate.logger.Infof("Provider %s in standby: %d of %d consecutive successes", providerName, state.successes, gate.standbySuccesses)
+			return
+		}
+		gate.logger.Infof("Re-enabling provider %s after %d consecutive successes", providerName, state.successes)
+		gate.transition(providerName, state, "health", fmt.Sprintf("%d consecutive successes", state.successes), func() {
+			state.disabled, state.standby, state.disabledBy, state.successes = false, false, "", 0
+		})
+	case errors.Is(err, ErrAuth):
+		state.successes = 0
+		state.lastError = err.Error()
+		if !state.disabled {
+			gate.logger.Errorf("Disabling provider %s until it recovers: %v", providerName, err)
+			gate.transition(providerName, state, "health", err.Error(), func() {
+				state.disabled, state.standby, state.disabledBy = true, true, "health"
+			})
+		}
+	case errors.Is(err, ErrBudgetExhausted), errors.Is(err, ErrProviderRateLimited):
+		// Our own limits say nothing about the provider's health
+	case errors.Is(err, ErrQuotaExceeded):
+		backoff := defaultQuotaBackoff
+		var providerError *ProviderError
+		if errors.As(err, &providerError) && providerError.RetryAfter > 0 {
+			backoff = providerError.RetryAfter
+		}
+		state.backoffUntil = gate.now().Add(backoff)
+		state.lastError = err.Error()
+		gate.logger.Warnf("Backing off provider %s for %s: %v", providerName, backoff, err)
+	case errors.Is(err, ErrUnsupportedBase):
+		if !state.unsupportedBases[baseCurrency] {
+			gate.logger.Infof("Skipping provider %s for %s from now on: %v", providerName, baseCurrency, err)
+		}
+		state.unsupportedBases[baseCurrency] = true
+	default:
+		if state.successes > 0 {
+			gate.logger.Infof("Provider %s in standby failed a probe: %v", providerName, err)
+		}
+		state.successes = 0
+	}
+}
+
+// disable holds a provider out on an operator's request. In standby, it is probed and
+// re-enabled once it recovers; otherwise it stays disabled until enable.
+func (gate *providerGate) disable(providerName string, standby bool, reason string) {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	state := gate.stateOf(providerName)
+	gate.transition(providerName, state, "operator", reason, func() {
+		state.disabled, state.standby, state.disabledBy, state.successes = true, standby, "operator", 0
+	})
+	gate.logger.Warnf("Provider %s disabled by an operator (%s): %s", providerName, state.state(), reason)
+}
+
+// enable puts a provider back into fetches on an operator's request
+func (gate *providerGate) enable(providerName, reason string) {
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	state := gate.stateOf(providerName)
+	gate.transition(providerName, state, "operator", reason, func() {
+		state.disabled, state.standby, state.disabledBy, state.successes = false, false, "", 0
+	})
+	gate.logger.Infof("Provider %s enabled by an operator: %s", providerName, reason)
+}
+
+// inStandby returns the providers in standby, sorted
+func (gate *providerGate) inStandby() []string {
+	if gate == nil {
+		return nil
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	var names []string
+	for name, state := range gate.providers {
+		if state.disabled && state.standby {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// standbyStatus reports who disabled the provider, whether it is in standby and its
+// consecutive successes there
+func (gate *providerGate) standbyStatus(providerName string) (disabledBy string, standby bool, successes int) {
+	if gate == nil {
+		return "", false, 0
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	state, exists := gate.providers[providerName]
+	if !exists || !state.disabled {
+		return "", false, 0
+	}
+	return state.disabledBy, state.standby, state.successes
+}
+
+// transitionLog returns the recorded state transitions, newest first
+func (gate *providerGate) transitionLog() []models.ProviderTransition {
+	if gate == nil {
+		return []models.ProviderTransition{}
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	transitions := make([]models.ProviderTransition, len(gate.transitions))
+	for i, transition := range gate.transitions {
+		transitions[len(transitions)-1-i] = transition
+	}
+	return transitions
+}
+
+// stateOf returns the provider's state, adding it when nothing was learned yet (caller
+// holds the lock)
+func (gate *providerGate) stateOf(providerName string) *gateState {
+	state := gate.providers[providerName]
+	if state == nil {
+		state = &gateState{unsupportedBases: make(map[string]bool)}
+		gate.providers[providerName] = state
+	}
+	return state
+}
+
+// transition applies a change to the provider's state and records it when the state
+// changes (caller holds the lock)
+func (gate *providerGate) transition(providerName string, state *gateState, by, reason string, change func()) {
+	from := state.state()
+	change()
+	to := state.state()
+	if from == to {
+		return
+	}
+
+	transition := models.ProviderTransition{
+		Provider: providerName,
+		From:     from,
+		To:       to,
+		By:       by,
+		Reason:   reason,
+		At:       gate.now(),
+	}
+	gate.transitions = append(gate.transitions, transition)
+	if len(gate.transitions) > maxTransitions {
+		gate.transitions = gate.transitions[len(gate.transitions)-maxTransitions:]
+	}
+	if gate.listener != nil {
+		gate.listener(transition)
+	}
+}
+
+// setListener sets the function told of every transition; it is called with the lock held,
+// so it must not block or call back into the gate
+func (gate *providerGate) setListener(listener func(models.ProviderTransition)) {
+	if gate == nil {
+		return
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+	gate.listener = listener
+}
+
+// status reports whether the provider is disabled, when its back-off ends, the bases it
+// does not support and the failure that disabled or backed it off
+func (gate *providerGate) status(providerName string) (disabled bool, backoffUntil time.Time, unsupportedBases []string, lastError string) {
+	if gate == nil {
+		return false, time.Time{}, nil, ""
+	}
+
+	gate.mutex.Lock()
+	defer gate.mutex.Unlock()
+
+	state, exists := gate.providers[providerName]
+	if !exists {
+		return false, time.Time{}, nil, ""
+	}
+	if gate.now().Before(state.backoffUntil) {
+		backoffUntil = state.backoffUntil
+	}
+	for base := range state.unsupportedBases {
+		unsupportedBases = append(unsupportedBases, base)
+	}
+	sort.Strings(unsupportedBases)
+	return state.disabled, backoffUntil, unsupportedBases, state.lastError
+}
+
+// providerFailure builds the service error for a request every provider failed: a base
+// no provider supports is a bad request, providers that are all disabled, backing off or
+// over budget or rate limit are unavailable, and anything else is an upstream failure
+func providerFailure(message string, errs []error) *ServiceError {
+	var failed, unavailable, unsupported error
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrUnsupportedBase):
+			if unsupported == nil {
+				unsupported = err
+			}
+		case errors.Is(err, ErrAuth), errors.Is(err, ErrProviderDisabled), errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrBudgetExhausted), errors.Is(err, ErrProviderRateLimited):
+			if unavailable == nil {
+				unavailable = err
+			}
+		default:
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+
+	switch {
+	case failed != nil:
+		return &ServiceError{Type: ErrorTypeProviderFailed, Message: message, Cause: failed}
+	case unavailable != nil:
+		return &ServiceError{Type: ErrorTypeProviderUnavailable, Message: "no provider is currently available", Cause: unavailable}
+	default:
+		return &ServiceError{Type: ErrorTypeUnsupportedBase, Message: "base currency not supported by any provider", Cause: unsupported}
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// PushRates accepts rates delivered by a push-based source, normalizes them and stores
+// them in the cache of this service and of every tenant view allowed to use the source.
+// Rates older than cached rates quoting the same symbols are rejected.
+func (ratesService *RatesService) PushRates(ctx context.Context, source string, exchangeRates models.RatesResponse) (models.RatesResponse, error) {
+	normalized, err := normalizePushedRates(source, exchangeRates, ratesService.now())
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+
+	cached, found := ratesService.lookupRates(normalized.Base, "", pushedSymbols(normalized))
+	if found && cached.Timestamp > normalized.Timestamp {
+		return models.RatesResponse{}, &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: "pushed rates are older than the cached rates",
+		}
+	}
+
+	ratesService.cacheRatesAs(ratesService.pushKey(normalized), normalized)
+
+	ratesService.tenantViewsMutex.Lock()
+	for _, view := range ratesService.tenantViews {
+		if allowsSource(view.tenant.Providers, source) {
+			view.cacheRatesAs(view.pushKey(normalized), normalized)
+		}
+	}
+	ratesService.tenantViewsMutex.Unlock()
+
+	ratesService.logger.WithContext(ctx).Infof("Accepted pushed rates from %s for base %s", source, normalized.Base)
+	return normalized, nil
+}
+
+// pushKey returns the cache key of pushed rates: they replace the complete table of their
+// base when none is cached or they quote all of its symbols, and are otherwise kept apart
+// under their own symbols, so a partial push cannot hide the other rates
+func (ratesService *RatesService) pushKey(pushedRates models.RatesResponse) ratesKey {
+	key := latestKey(pushedRates.Base)
+
+	ratesService.cacheMutex.RLock()
+	cached, found := ratesService.cache[key]
+	ratesService.cacheMutex.RUnlock()
+	if !found || !ratesService.now().Before(cached.ExpiresAt) {
+		return key
+	}
+	for symbol := range cached.Data.Rates {
+		if _, quoted := pushedRates.Rates[symbol]; !quoted && symbol != pushedRates.Base {
+			key.Symbols = symbolsKey(pushedSymbols(pushedRates))
+			return key
+		}
+	}
+	return key
+}
+
+// pushedSymbols returns the symbols quoted by pushed rates
+func pushedSymbols(pushedRates models.RatesResponse) []string {
+	symbols := make([]string, 0, len(pushedRates.Rates))
+	for symbol := range pushedRates.Rates {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// normalizePushedRates validates pushed rates and fills in provider and timestamps.
+// Bid and ask quotes are optional; incomplete or crossed ones are dropped.
+func normalizePushedRates(source string, exchangeRates models.RatesResponse, now time.Time) (models.RatesResponse, error) {
+	base := strings.ToUpper(strings.TrimSpace(exchangeRates.Base))
+	if base == "" {
+		return models.RatesResponse{}, &ServiceError{Type: ErrorTypeInvalidRequest, Message: "base is required"}
+	}
+	if len(exchangeRates.Rates) == 0 {
+		return models.RatesResponse{}, &ServiceError{Type: ErrorTypeInvalidRequest, Message: "rates are required"}
+	}
+
+	rates := make(models.RateTable, len(exchangeRates.Rates))
+	for symbol, rate := range exchangeRates.Rates {
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return models.RatesResponse{}, &ServiceError{
+				Type:    ErrorTypeInvalidRequest,
+				Message: fmt.Sprintf("invalid rate for %s", symbol),
+			}
+		}
+		rates[strings.ToUpper(symbol)] = rate
+	}
+
+	timestamp := exchangeRates.PublishedAt
+	if timestamp == 0 {
+		timestamp = now.Unix()
+	}
+
+	return withSpreads(models.RatesResponse{
+		Base:        base,
+		Timestamp:   timestamp,
+		Rates:       rates,
+		Provider:    source,
+		PublishedAt: exchangeRates.PublishedAt,
+		FetchedAt:   now.Unix(),
+	}, upperKeys(exchangeRates.Bid), upperKeys(exchangeRates.Ask)), nil
+}
+
+// upperKeys returns the table with its currency codes uppercased
+func upperKeys(rateTable models.RateTable) models.RateTable {
+	upper := make(models.RateTable, len(rateTable))
+	for symbol, rate := range rateTable {
+		upper[strings.ToUpper(symbol)] = rate
+	}
+	return upper
+}
+
+// allowsSource reports whether a tenant provider list (empty = all) includes the source
+func allowsSource(providerNames []string, source string) bool {
+	if len(providerNames) == 0 {
+		return true
+	}
+	for _, name := range providerNames {
+		if name == source {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_PushRates(t *testing.T) {
+	failingProvider := &testutils.FakeProvider{Name: "pull", Enabled: true, Priority: 1, Err: &ServiceError{Type: ErrorTypeProviderFailed, Message: "down"}}
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{failingProvider},
+	}
+
+	pushed, err := ratesService.PushRates(context.Background(), "pricing-engine", models.RatesResponse{
+		Base:        "usd",
+		Rates:       models.RateTable{"eur": 0.91},
+		PublishedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatalf("PushRates() error = %v", err)
+	}
+	if pushed.Base != "USD" || pushed.Rates["EUR"] != 0.91 || pushed.Provider != "pricing-engine" {
+		t.Errorf("PushRates() = %+v, want normalized USD rates from pricing-engine", pushed)
+	}
+
+	// The pushed rates are served from cache even though the pull provider is down
+	result, err := ratesService.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() after push error = %v", err)
+	}
+	if result.Provider != "pricing-engine" {
+		t.Errorf("GetRates() Provider = %v, want %v", result.Provider, "pricing-engine")
+	}
+
+	// Older rates for the same base are rejected
+	_, err = ratesService.PushRates(context.Background(), "pricing-engine", models.RatesResponse{
+		Base:        "USD",
+		Rates:       models.RateTable{"EUR": 0.5},
+		PublishedAt: time.Now().Add(-time.Hour).Unix(),
+	})
+	if err == nil {
+		t.Error("PushRates() expected error for rates older than the cache")
+	}
+}
+
+func TestRatesService_PushRates_TenantViews(t *testing.T) {
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+	}
+	allowed := ratesService.ForTenant(&config.Tenant{ID: "allowed", Providers: []string{"pricing-engine"}})
+	excluded := ratesService.ForTenant(&config.Tenant{ID: "excluded", Providers: []string{"erapi"}})
+
+	if _, err := ratesService.PushRates(context.Background(), "pricing-engine", models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.91}}); err != nil {
+		t.Fatalf("PushRates() error = %v", err)
+	}
+
+	if allowed.CacheStats().Base != "USD" {
+		t.Error("tenant view using the source should have the pushed rates cached")
+	}
+	if excluded.CacheStats().Base != "" {
+		t.Error("tenant view not using the source should not have the pushed rates cached")
+	}
+}
+
+func TestNormalizePushedRates_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rates models.RatesResponse
+	}{
+		{"missing base", models.RatesResponse{Rates: models.RateTable{"EUR": 0.9}}},
+		{"missing rates", models.RatesResponse{Base: "USD"}},
+		{"non-positive rate", models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := normalizePushedRates("pricing-engine", tt.rates, time.Now())
+			if classifyError(err) != ErrorTypeInvalidRequest {
+				t.Errorf("normalizePushedRates() error = %v, want invalid request", err)
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dalfonso89/currency-exchange-service/clock"
 	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/events"
+	"github.com/dalfonso89/currency-exchange-service/latency"
 	"github.com/dalfonso89/currency-exchange-service/logger"
 	"github.com/dalfonso89/currency-exchange-service/models"
 
@@ -24,6 +29,13 @@ const (
 	ErrorTypeNetworkError
 	ErrorTypeInvalidResponse
 	ErrorTypeUnknown
+	ErrorTypeInvalidRequest
+	ErrorTypeBudgetExhausted
+	ErrorTypeUnsupportedBase
+	ErrorTypeProviderUnavailable
+	ErrorTypeSourceNotPermitted
+	ErrorTypeHistoryUnavailable
+	ErrorTypeRateNotFound
 )
 
 // ServiceError represents a service-specific error with type information
@@ -34,11 +46,7 @@ type ServiceError struct {
 }
 
 // ProviderStatus represents the status of a provider
-type ProviderStatus struct {
-	Name     string `json:"name"`
-	Enabled  bool   `json:"enabled"`
-	Priority int    `json:"priority"`
-}
+type ProviderStatus = models.ProviderStatus
 
 func (e ServiceError) Error() string {
 	if e.Cause != nil {
@@ -58,6 +66,19 @@ func classifyError(err error) ErrorType {
 	case *ServiceError:
 		return e.Type
 	default:
+		switch {
+		case errors.Is(err, ErrBudgetExhausted):
+			return ErrorTypeBudgetExhausted
+		case errors.Is(err, ErrUnsupportedBase):
+			return ErrorTypeUnsupportedBase
+		case errors.Is(err, ErrAuth), errors.Is(err, ErrProviderDisabled), errors.Is(err, ErrQuotaExceeded), errors.Is(err, ErrProviderRateLimited):
+			return ErrorTypeProviderUnavailable
+		case errors.Is(err, ErrUpstream5xx):
+			return ErrorTypeProviderFailed
+		case errors.Is(err, ErrDecode):
+			return ErrorTypeInvalidResponse
+		}
+
 		// Check error message patterns
 		errMsg := err.Error()
 		switch {
@@ -78,101 +99,454 @@ type RatesService struct {
 	logger        logger.Logger
 	providers     []ExchangeRateProvider
 
-	cacheMutex sync.RWMutex
-	cache      models.CacheEntry
+	// Time source of cache expiry and rate ages, shared with tenant views (nil = system clock)
+	clock clock.Clock
+
+	// Cached rates tables by base, symbols filter and date
+	cacheMutex  sync.RWMutex
+	cache       map[ratesKey]models.CacheEntry
+	cacheHits   int64
+	cacheMisses int64
+
+	// Nanoseconds newly cached rates live, shared with tenant views (nil = RatesCacheTTL)
+	cacheTTL *int64
+
+	// Provider tables and fetches shared with tenant views, whose cache holds the responses
+	// derived for their tenant (nil = not shared)
+	raw *rawCache
 
 	singleFlightGroup singleflight.Group
+	fetches           *fetchTracker // Shared with tenant views (nil = not tracked)
+
+	// Outbound provider call budget, shared with the providers and tenant views (nil = unlimited)
+	budget *callBudget
+
+	// Provider latency SLO tracking, shared with tenant views (nil = disabled)
+	latency *latencyTracker
+
+	// Provider latency budgets reported when breached, shared with tenant views (nil = none)
+	latencyBudgets *latency.Budgets
+
+	// Providers skipped after auth, quota or unsupported base failures, shared with tenant views
+	gate *providerGate
+
+	// Calls and failures of each provider, shared with tenant views
+	calls *callCounts
+
+	// Rate update events and MQTT pair rates, published by the shared service only (nil = disabled)
+	events *events.Emitter
+	pairs  *events.PairEmitter
+
+	// Persistent rate history, recorded by the shared service only (nil = disabled)
+	history HistoryRecorder
+
+	// Stored daily closes that dated conversions read, shared with tenant views (nil = disabled)
+	rateHistory RateHistory
+
+	// Listeners notified of each rates table the shared service caches
+	listeners []RatesListener
+
+	// Bases that may be requested from providers, shared with tenant views (nil = any)
+	allowedBases map[string]bool
+
+	// Providers permitted to serve rates involving a currency (nil = any)
+	sourcePolicy sourcePolicy
+
+	// Tenant scoping (nil/empty for the shared service)
+	tenant            *config.Tenant
+	allowedCurrencies map[string]bool
+
+	tenantViewsMutex sync.Mutex
+	tenantViews      map[string]*RatesService
 }
 
 func NewRatesService(configuration *config.Config, logger logger.Logger) *RatesService {
 	// Create provider factory and get all enabled providers
 	providerFactory := NewProviderFactory(configuration, logger)
 	providers := providerFactory.CreateProviders()
+	budgets := configuration.LatencyBudgets
 
 	return &RatesService{
-		configuration: configuration,
-		logger:        logger,
-		providers:     providers,
+		configuration:  configuration,
+		logger:         logger,
+		providers:      providers,
+		clock:          clock.System,
+		cacheTTL:       newCacheTTL(configuration.RatesCacheTTL),
+		latency:        newLatencyTracker(configuration.ProviderSLO, logger),
+		latencyBudgets: latency.NewBudgets("provider", budgets.Providers, budgets.Window, budgets.MinSamples, logger),
+		gate:           newProviderGate(logger, configuration.ProviderStandby.Successes),
+		calls:          newCallCounts(),
+		raw:            newRawCache(),
+		fetches:        newFetchTracker(),
+		budget:         providerFactory.budget,
+		events:         events.NewEmitter(configuration.Events, logger),
+		pairs:          events.NewMQTTPairEmitter(configuration.MQTT, logger),
+		allowedBases:   currencySet(configuration.AllowedBaseCurrencies),
+		sourcePolicy:   newSourcePolicy(configuration.SourcePolicy),
+	}
+}
+
+// now returns the current time of the service's clock
+func (ratesService *RatesService) now() time.Time {
+	return clock.Or(ratesService.clock).Now()
+}
+
+// currencySet returns the currencies as a set, or nil when there are none
+func currencySet(currencies []string) map[string]bool {
+	if len(currencies) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		set[currency] = true
+	}
+	return set
+}
+
+// RotateProviderSecrets applies the rotated API keys and signing keys of the reloaded
+// provider configurations to the running providers with the same names
+func (ratesService *RatesService) RotateProviderSecrets(configurations []config.ExchangeRateProvider) {
+	for _, configuration := range configurations {
+		for _, provider := range ratesService.providers {
+			if httpProvider, isHTTP := provider.(*HTTPExchangeRateProvider); isHTTP && httpProvider.GetName() == configuration.Name {
+				httpProvider.RotateSecrets(configuration.APIKey, configuration.Signing.Key)
+			}
+		}
 	}
 }
 
+// HistoryRecorder persists fetched rates as rate history
+type HistoryRecorder interface {
+	RecordRates(ctx context.Context, exchangeRates models.RatesResponse) error
+}
+
+// RatesListener is notified of every rates table the service caches
+type RatesListener interface {
+	RatesCached(exchangeRates models.RatesResponse)
+}
+
+// AddRatesListener notifies the listener of every rates table cached from now on
+func (ratesService *RatesService) AddRatesListener(listener RatesListener) {
+	ratesService.listeners = append(ratesService.listeners, listener)
+}
+
+// historyTimeout bounds recording one fetch in the rate history
+const historyTimeout = 5 * time.Second
+
+// sharedFetchTimeout bounds a provider fetch shared by coalesced callers, which no longer
+// ends when the caller that started it goes away
+const sharedFetchTimeout = 30 * time.Second
+
+// SetHistory records every rate fetch with the recorder from now on
+func (ratesService *RatesService) SetHistory(history HistoryRecorder) {
+	ratesService.history = history
+}
+
+// Close flushes pending rate events and releases the publishers
+func (ratesService *RatesService) Close() error {
+	return errors.Join(ratesService.events.Close(), ratesService.pairs.Close())
+}
+
+// ForTenant returns a tenant-scoped view of the service restricted to the tenant's
+// providers, markup, allowed currencies and source policy, whose entries replace the
+// global ones of the same currencies. Views are created once and reused.
+func (ratesService *RatesService) ForTenant(tenant *config.Tenant) *RatesService {
+	if tenant == nil {
+		return ratesService
+	}
+
+	ratesService.tenantViewsMutex.Lock()
+	defer ratesService.tenantViewsMutex.Unlock()
+
+	if view, exists := ratesService.tenantViews[tenant.ID]; exists {
+		return view
+	}
+
+	tenantConfiguration := *ratesService.configuration
+	tenantConfiguration.Markup = tenant.Markup
+
+	view := &RatesService{
+		configuration:  &tenantConfiguration,
+		logger:         ratesService.logger.WithFields(logger.Fields{"tenant": tenant.ID}),
+		providers:      filterProviders(ratesService.providers, tenant.Providers),
+		tenant:         tenant,
+		clock:          ratesService.clock,
+		cacheTTL:       ratesService.cacheTTL,
+		latency:        ratesService.latency,
+		latencyBudgets: ratesService.latencyBudgets,
+		gate:           ratesService.gate,
+		calls:          ratesService.calls,
+		raw:            ratesService.raw,
+		rateHistory:    ratesService.rateHistory,
+		fetches:        ratesService.fetches,
+		budget:         ratesService.budget,
+		allowedBases:   ratesService.allowedBases,
+		sourcePolicy:   newSourcePolicy(ratesService.configuration.SourcePolicy, tenant.SourcePolicy),
+	}
+	view.allowedCurrencies = currencySet(tenant.AllowedCurrencies)
+
+	if ratesService.tenantViews == nil {
+		ratesService.tenantViews = make(map[string]*RatesService)
+	}
+	ratesService.tenantViews[tenant.ID] = view
+	return view
+}
+
+// Tenant returns the tenant this service is scoped to, or nil for the shared service
+func (ratesService *RatesService) Tenant() *config.Tenant {
+	return ratesService.tenant
+}
+
+// IsCurrencyAllowed reports whether the currency may be used by this service view
+func (ratesService *RatesService) IsCurrencyAllowed(currency string) bool {
+	return ratesService.allowedCurrencies == nil || ratesService.allowedCurrencies[currency]
+}
+
+// DefaultBaseCurrency returns the base currency of requests that name none
+func (ratesService *RatesService) DefaultBaseCurrency() string {
+	if ratesService.configuration.DefaultBaseCurrency == "" {
+		return "USD"
+	}
+	return ratesService.configuration.DefaultBaseCurrency
+}
+
+// IsBaseAllowed reports whether rates for the base may be requested from providers
+func (ratesService *RatesService) IsBaseAllowed(baseCurrency string) bool {
+	return ratesService.allowedBases == nil || ratesService.allowedBases[baseCurrency]
+}
+
+// checkBase rejects a base the service view may not use or request from providers
+func (ratesService *RatesService) checkBase(baseCurrency string) error {
+	if !ratesService.IsCurrencyAllowed(baseCurrency) {
+		return &ServiceError{
+			Type:    ErrorTypeInvalidRequest,
+			Message: fmt.Sprintf("currency not allowed: %s", baseCurrency),
+		}
+	}
+	if !ratesService.IsBaseAllowed(baseCurrency) {
+		return &ServiceError{
+			Type:    ErrorTypeUnsupportedBase,
+			Message: fmt.Sprintf("base currency not allowed: %s", baseCurrency),
+		}
+	}
+	return nil
+}
+
 // GetRates concurrently queries providers, returns first successful response and caches it.
 func (ratesService *RatesService) GetRates(requestContext context.Context, baseCurrency string) (models.RatesResponse, error) {
-	// serve from cache when valid and base unchanged
-	ratesService.cacheMutex.RLock()
-	if ratesService.cache.Data.Base == baseCurrency && time.Now().Before(ratesService.cache.ExpiresAt) {
-		cachedResponse := ratesService.cache.Data
-		ratesService.cacheMutex.RUnlock()
-		return cachedResponse, nil
+	return ratesService.GetRatesForSymbols(requestContext, baseCurrency, nil)
+}
+
+// GetRatesForSymbols returns the rates of the base limited to the symbols (none = every
+// symbol). Any cached table quoting all of the symbols serves the request.
+func (ratesService *RatesService) GetRatesForSymbols(requestContext context.Context, baseCurrency string, symbols []string) (models.RatesResponse, error) {
+	if err := ratesService.checkBase(baseCurrency); err != nil {
+		return models.RatesResponse{}, err
+	}
+	if key := symbolsKey(symbols); key != "" {
+		symbols = strings.Split(key, ",")
+	} else {
+		symbols = nil
+	}
+	for _, symbol := range symbols {
+		if !ratesService.IsCurrencyAllowed(symbol) {
+			return models.RatesResponse{}, &ServiceError{
+				Type:    ErrorTypeInvalidRequest,
+				Message: fmt.Sprintf("currency not allowed: %s", symbol),
+			}
+		}
+	}
+
+	exchangeRates, err := ratesService.getCachedOrFetch(requestContext, baseCurrency, symbols)
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+	exchangeRates = ratesService.sourcePolicy.filter(filterSymbols(exchangeRates, symbols))
+	return ratesService.markStale(withAge(ratesService.filterAllowedRates(exchangeRates), ratesService.now())), nil
+}
+
+// withAge sets AgeSeconds relative to publication time, or fetch time when unknown
+func withAge(exchangeRates models.RatesResponse, now time.Time) models.RatesResponse {
+	reference := exchangeRates.PublishedAt
+	if reference == 0 {
+		reference = exchangeRates.FetchedAt
+	}
+	if reference > 0 {
+		exchangeRates.AgeSeconds = max(0, now.Unix()-reference)
+	}
+	return exchangeRates
+}
+
+// getCachedOrFetch serves rates quoting the symbols from cache or fetches them once per
+// key via singleflight. Providers answer with complete tables, so a filtered request
+// that misses the cache fetches and caches the complete table, shared with unfiltered
+// requests for the base. Only tables of providers the source policy permits for the base
+// and symbols are served, and only those providers are asked on a miss. With stale
+// refreshes enabled, a cached table whose requested currencies went stale is fetched
+// again, and still served if the fetch fails.
+func (ratesService *RatesService) getCachedOrFetch(requestContext context.Context, baseCurrency string, symbols []string) (models.RatesResponse, error) {
+	currencies := append([]string{baseCurrency}, symbols...)
+	var refreshing *models.RatesResponse
+	if cachedResponse, found := ratesService.lookupRates(baseCurrency, "", symbols); found && ratesService.sourcePolicy.permits(cachedResponse.Provider, currencies...) {
+		if !ratesService.needsRefresh(cachedResponse, symbols) {
+			atomic.AddInt64(&ratesService.cacheHits, 1)
+			return cachedResponse, nil
+		}
+		ratesService.logger.WithContext(requestContext).Debugf("Refreshing stale %s rates", baseCurrency)
+		refreshing = &cachedResponse
+	}
+	atomic.AddInt64(&ratesService.cacheMisses, 1)
+
+	providers, err := ratesService.sourcePolicy.providersFor(ratesService.providers, currencies)
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+
+	// A table another view fetched from a permitted provider is derived for this view
+	if rawResponse, found := ratesService.raw.lookup(ratesService, baseCurrency, providers); found && !ratesService.needsRefresh(rawResponse, symbols) {
+		ratesService.cacheRates(rawResponse)
+		return rawResponse, nil
 	}
-	ratesService.cacheMutex.RUnlock()
 
 	cacheKey := "rates:" + baseCurrency
-	result, err, _ := ratesService.singleFlightGroup.Do(cacheKey, func() (interface{}, error) {
-		return ratesService.fetchRatesFromProviders(requestContext, baseCurrency)
+	if len(providers) < len(ratesService.providers) {
+		// Fetches restricted to fewer providers cannot share the unrestricted fetch
+		cacheKey += "@" + strings.Join(providerNames(providers), ",")
+	}
+	trackedKey := cacheKey
+	if ratesService.tenant != nil {
+		trackedKey = ratesService.tenant.ID + "/" + cacheKey
+	}
+
+	// Views share fetches from the same providers, so the key names them all
+	flights, flightKey := &ratesService.singleFlightGroup, cacheKey
+	if ratesService.raw != nil {
+		flights, flightKey = &ratesService.raw.flights, "rates:"+baseCurrency+"@"+strings.Join(providerNames(providers), ",")
+	}
+
+	ratesService.fetches.join(trackedKey)
+	leader := false
+	fetch := flights.DoChan(flightKey, func() (interface{}, error) {
+		leader = true
+		ratesService.fetches.begin(trackedKey)
+		defer ratesService.fetches.end(trackedKey)
+		// The fetch is shared, so it keeps the starting caller's correlation fields but
+		// not its cancellation
+		fetchContext, cancel := context.WithTimeout(context.WithoutCancel(requestContext), sharedFetchTimeout)
+		defer cancel()
+		exchangeRates, err := ratesService.fetchRatesFromProviders(fetchContext, baseCurrency, providers)
+		return sharedFetch{rates: exchangeRates, by: ratesService}, err
 	})
 
+	// A cancelled caller stops waiting at once; the fetch it started or joined still
+	// completes for the other callers
+	var outcome singleflight.Result
+	select {
+	case outcome = <-fetch:
+	case <-requestContext.Done():
+		return models.RatesResponse{}, contextCancelled(requestContext)
+	}
+	if !leader {
+		ratesService.fetches.served()
+	}
+	err = outcome.Err
+
 	if err != nil {
+		if classifyError(err) == ErrorTypeBudgetExhausted {
+			if staleResponse, found := ratesService.staleRates(baseCurrency); found && ratesService.sourcePolicy.permits(staleResponse.Provider, currencies...) {
+				ratesService.logger.WithContext(requestContext).Warnf("Provider call budget exhausted: serving expired %s rates", baseCurrency)
+				return staleResponse, nil
+			}
+		}
+		if refreshing != nil {
+			ratesService.logger.WithContext(requestContext).Warnf("Refreshing stale %s rates failed: serving cached rates: %v", baseCurrency, err)
+			return *refreshing, nil
+		}
 		return models.RatesResponse{}, err
 	}
-	return result.(models.RatesResponse), nil
+
+	// The view that made the fetch cached it; a view joining it derives its own
+	fetched := outcome.Val.(sharedFetch)
+	if fetched.by != ratesService {
+		ratesService.cacheRates(fetched.rates)
+	}
+	return fetched.rates, nil
 }
 
-// fetchRatesFromProviders fetches rates from all enabled providers concurrently
-func (ratesService *RatesService) fetchRatesFromProviders(requestContext context.Context, baseCurrency string) (models.RatesResponse, error) {
-	if len(ratesService.providers) == 0 {
+// fetchRatesFromProviders fetches rates from the providers concurrently
+func (ratesService *RatesService) fetchRatesFromProviders(requestContext context.Context, baseCurrency string, providers []ExchangeRateProvider) (models.RatesResponse, error) {
+	if len(providers) == 0 {
 		return models.RatesResponse{}, &ServiceError{
 			Type:    ErrorTypeNoProviders,
 			Message: "no exchange rate providers configured",
 		}
 	}
 
-	resultsChannel := make(chan providerResult, len(ratesService.providers))
+	// The channel holds a result of every provider, so provider calls finishing after the
+	// collection stopped never block and their goroutines always exit
+	resultsChannel := make(chan providerResult, len(providers))
 	var wg sync.WaitGroup
 
-	for _, provider := range ratesService.providers {
+	// Demoted providers are still queried so their latency keeps being measured,
+	// but their result is only used when no healthy provider succeeds. Providers the
+	// gate holds back are not queried; their reason counts as their failure.
+	demoted := make(map[string]bool)
+	for _, provider := range ratesService.latency.order(providers) {
+		if err := ratesService.gate.admit(provider.GetName(), baseCurrency); err != nil {
+			resultsChannel <- providerResult{provider.GetName(), models.RatesResponse{}, err}
+			continue
+		}
+		demoted[provider.GetName()] = ratesService.latency.isDemoted(provider.GetName())
+
 		wg.Add(1)
 		go func(p ExchangeRateProvider) {
 			defer wg.Done()
-			ratesService.logger.Debugf("Fetching rates from provider: %s", p.GetName())
+			ratesService.logger.WithContext(requestContext).Debugf("Fetching rates from provider: %s", p.GetName())
+			start := time.Now()
 			data, err := p.GetRates(requestContext, baseCurrency)
-			resultsChannel <- providerResult{data, err}
+			// Calls skipped by the outbound rate limit took no time, so they are not measured
+			if err == nil || (classifyError(err) != ErrorTypeContextCancelled && !errors.Is(err, ErrProviderRateLimited)) {
+				duration := time.Since(start)
+				ratesService.latency.observe(p.GetName(), duration)
+				ratesService.latencyBudgets.Observe(p.GetName(), duration)
+				ratesService.calls.observe(p.GetName(), err)
+			}
+			ratesService.gate.observe(p.GetName(), baseCurrency, err)
+			resultsChannel <- providerResult{p.GetName(), data, err}
 		}(provider)
 	}
 
-	// Wait for all providers to finish or context to be cancelled
+	// Close the channel once every provider call is done, without waiting for them here
 	go func() {
 		wg.Wait()
 		close(resultsChannel)
 	}()
 
 	// Collect results
-	var firstError error
+	var cancelled error
+	var providerErrors []error
+	var fallback *models.RatesResponse
+	budgetExhausted := false
 
 	// Use labeled loop for proper break control
 collectLoop:
-	for i := 0; i < len(ratesService.providers); i++ {
+	for i := 0; i < len(providers); i++ {
 		select {
 		case <-requestContext.Done():
-			if firstError == nil {
-				firstError = &ServiceError{
-					Type:    ErrorTypeContextCancelled,
-					Message: "request context cancelled",
-					Cause:   requestContext.Err(),
-				}
-			}
+			cancelled = contextCancelled(requestContext)
 			break collectLoop
 		case result := <-resultsChannel:
 			if result.err == nil {
-				// Cache the successful result
-				ratesService.cacheMutex.Lock()
-				ratesService.cache = models.CacheEntry{
-					Data:      result.data,
-					ExpiresAt: time.Now().Add(ratesService.configuration.RatesCacheTTL),
+				if demoted[result.provider] {
+					if fallback == nil {
+						fallback = &result.data
+					}
+					continue
 				}
-				ratesService.cacheMutex.Unlock()
 
-				ratesService.logger.Infof("Successfully fetched rates from provider: %s", result.data.Provider)
+				ratesService.raw.store(ratesService, result.data)
+				ratesService.cacheRates(result.data)
+				ratesService.logger.WithContext(requestContext).Infof("Successfully fetched rates from provider: %s", result.data.Provider)
 				return result.data, nil
 			}
 
@@ -180,44 +554,241 @@ collectLoop:
 			errorType := classifyError(result.err)
 			switch errorType {
 			case ErrorTypeContextCancelled:
-				ratesService.logger.Warnf("Provider cancelled: %v", result.err)
+				ratesService.logger.WithContext(requestContext).Warnf("Provider cancelled: %v", result.err)
 			case ErrorTypeNetworkError:
-				ratesService.logger.Warnf("Provider network error: %v", result.err)
+				ratesService.logger.WithContext(requestContext).Warnf("Provider network error: %v", result.err)
 			case ErrorTypeInvalidResponse:
-				ratesService.logger.Warnf("Provider invalid response: %v", result.err)
+				ratesService.logger.WithContext(requestContext).Warnf("Provider invalid response: %v", result.err)
+			case ErrorTypeBudgetExhausted:
+				budgetExhausted = true
+				ratesService.logger.WithContext(requestContext).Debugf("Provider skipped: %v", result.err)
+			case ErrorTypeUnsupportedBase, ErrorTypeProviderUnavailable:
+				ratesService.logger.WithContext(requestContext).Debugf("Provider unavailable: %v", result.err)
 			default:
-				ratesService.logger.Warnf("Provider failed: %v", result.err)
+				ratesService.logger.WithContext(requestContext).Warnf("Provider failed: %v", result.err)
 			}
+			providerErrors = append(providerErrors, result.err)
+		}
+	}
 
-			if firstError == nil {
-				firstError = &ServiceError{
-					Type:    ErrorTypeProviderFailed,
-					Message: "provider request failed",
-					Cause:   result.err,
-				}
-			}
+	if fallback != nil {
+		ratesService.raw.store(ratesService, *fallback)
+		ratesService.cacheRates(*fallback)
+		ratesService.logger.WithContext(requestContext).Warnf("Using rates from demoted provider %s: no healthy provider succeeded", fallback.Provider)
+		return *fallback, nil
+	}
+
+	// A spent budget, rather than the first provider error, is why nothing was fetched
+	if budgetExhausted {
+		return models.RatesResponse{}, &ServiceError{
+			Type:    ErrorTypeBudgetExhausted,
+			Message: "provider request failed",
+			Cause:   ErrBudgetExhausted,
 		}
 	}
 
+	if cancelled != nil {
+		return models.RatesResponse{}, cancelled
+	}
+
 	// If we get here, all providers failed
-	ratesService.logger.Errorf("All %d exchange rate providers failed", len(ratesService.providers))
-	return models.RatesResponse{}, firstError
+	ratesService.logger.WithContext(requestContext).Errorf("All %d exchange rate providers failed", len(providers))
+	return models.RatesResponse{}, providerFailure("provider request failed", providerErrors)
+}
+
+// contextCancelled is the error of a request whose context ended
+func contextCancelled(requestContext context.Context) *ServiceError {
+	return &ServiceError{
+		Type:    ErrorTypeContextCancelled,
+		Message: "request context cancelled",
+		Cause:   requestContext.Err(),
+	}
+}
+
+// cacheRates stores a successful fetch of the complete latest rates until the cache TTL
+// expires and announces it
+func (ratesService *RatesService) cacheRates(exchangeRates models.RatesResponse) {
+	ratesService.cacheRatesAs(latestKey(exchangeRates.Base), exchangeRates)
+}
+
+// cacheRatesAs stores latest rates under the key until the cache TTL expires and
+// announces them
+func (ratesService *RatesService) cacheRatesAs(key ratesKey, exchangeRates models.RatesResponse) {
+	ratesService.storeRates(key, exchangeRates)
+
+	ratesService.events.RatesCached(exchangeRates)
+	ratesService.pairs.RatesCached(exchangeRates)
+	for _, listener := range ratesService.listeners {
+		listener.RatesCached(exchangeRates)
+	}
+	if ratesService.history != nil {
+		go ratesService.recordHistory(exchangeRates)
+	}
+}
+
+// recordHistory writes a fetch to the rate history without holding up the request
+func (ratesService *RatesService) recordHistory(exchangeRates models.RatesResponse) {
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	if err := ratesService.history.RecordRates(ctx, exchangeRates); err != nil {
+		ratesService.logger.Errorf("Failed to record rate history: %v", err)
+	}
+}
+
+// filterAllowedRates drops rates for currencies outside the allowed set
+func (ratesService *RatesService) filterAllowedRates(exchangeRates models.RatesResponse) models.RatesResponse {
+	if ratesService.allowedCurrencies == nil {
+		return exchangeRates
+	}
+
+	filteredRates := make(map[string]float64, len(ratesService.allowedCurrencies))
+	for currency, rate := range exchangeRates.Rates {
+		if ratesService.allowedCurrencies[currency] {
+			filteredRates[currency] = rate
+		}
+	}
+	return exchangeRates.WithRates(filteredRates)
+}
+
+// filterProviders keeps only the providers whose names are listed (empty list keeps all)
+func filterProviders(providers []ExchangeRateProvider, names []string) []ExchangeRateProvider {
+	if len(names) == 0 {
+		return providers
+	}
+
+	allowedNames := make(map[string]bool, len(names))
+	for _, name := range names {
+		allowedNames[name] = true
+	}
+
+	filtered := []ExchangeRateProvider{}
+	for _, provider := range providers {
+		if allowedNames[provider.GetName()] {
+			filtered = append(filtered, provider)
+		}
+	}
+	return filtered
+}
+
+// providerNames returns the names of the providers
+func providerNames(providers []ExchangeRateProvider) []string {
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		names[i] = provider.GetName()
+	}
+	return names
+}
+
+// PurgeCache drops all cached rates, including those of tenant views and the provider
+// tables they share
+func (ratesService *RatesService) PurgeCache(ctx context.Context) {
+	ratesService.cacheMutex.Lock()
+	ratesService.cache = nil
+	ratesService.cacheMutex.Unlock()
+	ratesService.raw.purge()
+
+	ratesService.tenantViewsMutex.Lock()
+	views := make([]*RatesService, 0, len(ratesService.tenantViews))
+	for _, view := range ratesService.tenantViews {
+		views = append(views, view)
+	}
+	ratesService.tenantViewsMutex.Unlock()
+
+	for _, view := range views {
+		view.PurgeCache(ctx)
+	}
+	ratesService.logger.WithContext(ctx).Info("Rates cache purged")
+}
+
+// CacheStats returns hit/miss counters and the cached entries of this service view. Base
+// and ExpiresAt describe the most recently cached complete latest rates.
+func (ratesService *RatesService) CacheStats() models.CacheStats {
+	ratesService.cacheMutex.RLock()
+	defer ratesService.cacheMutex.RUnlock()
+
+	stats := models.CacheStats{
+		Hits:       atomic.LoadInt64(&ratesService.cacheHits),
+		Misses:     atomic.LoadInt64(&ratesService.cacheMisses),
+		TTL:        ratesService.CacheTTL().String(),
+		Coalescing: ratesService.fetches.stats(),
+	}
+	if ratesService.tenant == nil {
+		stats.ProviderTables = ratesService.raw.entries(ratesService)
+	}
+	now := ratesService.now()
+	for key, entry := range ratesService.cache {
+		if !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		stats.Entries++
+		if key == latestKey(key.Base) && entry.ExpiresAt.After(stats.ExpiresAt) {
+			stats.Base = key.Base
+			stats.ExpiresAt = entry.ExpiresAt
+		}
+	}
+	return stats
+}
+
+// LatencyBudgetStats reports the providers' latency budgets, or nothing when none is set
+func (ratesService *RatesService) LatencyBudgetStats() []models.LatencyBudget {
+	return ratesService.latencyBudgets.Stats()
+}
+
+// BudgetStats reports the provider call budget, or nil when calls are unlimited
+func (ratesService *RatesService) BudgetStats() *models.CallBudgetStats {
+	return ratesService.budget.stats()
 }
 
 // GetProviderStatus returns the status of all configured providers
 func (ratesService *RatesService) GetProviderStatus() []ProviderStatus {
+	effectivePriority := make(map[string]int, len(ratesService.providers))
+	for i, provider := range ratesService.latency.order(ratesService.providers) {
+		effectivePriority[provider.GetName()] = i + 1
+	}
+
 	statuses := make([]ProviderStatus, len(ratesService.providers))
 	for i, provider := range ratesService.providers {
 		statuses[i] = ProviderStatus{
-			Name:     provider.GetName(),
-			Enabled:  provider.IsEnabled(),
-			Priority: provider.GetPriority(),
+			Name:              provider.GetName(),
+			Enabled:           provider.IsEnabled(),
+			Priority:          provider.GetPriority(),
+			EffectivePriority: effectivePriority[provider.GetName()],
+			Demoted:           ratesService.latency.isDemoted(provider.GetName()),
+			P95MS:             float64(ratesService.latency.p95(provider.GetName()).Microseconds()) / 1000,
+		}
+		statuses[i].Calls, statuses[i].Failures = ratesService.calls.get(provider.GetName())
+		disabled, backoffUntil, unsupportedBases, lastError := ratesService.gate.status(provider.GetName())
+		statuses[i].Disabled = disabled
+		statuses[i].DisabledBy, statuses[i].Standby, statuses[i].StandbySuccesses = ratesService.gate.standbyStatus(provider.GetName())
+		statuses[i].UnsupportedBases = unsupportedBases
+		statuses[i].LastError = lastError
+		if !backoffUntil.IsZero() {
+			statuses[i].BackoffUntil = &backoffUntil
+		}
+		if reporter, ok := provider.(ConcurrencyReporter); ok {
+			statuses[i].Concurrency = reporter.Concurrency()
+		}
+		if reporter, ok := provider.(PollingReporter); ok {
+			statuses[i].Polling = reporter.Polling()
+		}
+		if reporter, ok := provider.(RateLimitReporter); ok {
+			statuses[i].RateLimit = reporter.RateLimit()
+		}
+		if reporter, ok := provider.(CompositeReporter); ok {
+			statuses[i].Composite = reporter.Composite()
 		}
 	}
 	return statuses
 }
 
+// EffectiveProviderOrder returns provider names in the order they are currently preferred
+func (ratesService *RatesService) EffectiveProviderOrder() []string {
+	return providerNames(ratesService.latency.order(ratesService.providers))
+}
+
 type providerResult struct {
-	data models.RatesResponse
-	err  error
+	provider string
+	data     models.RatesResponse
+	err      error
 }
@@ -2,46 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/dalfonso89/currency-exchange-service/config"
 	"github.com/dalfonso89/currency-exchange-service/models"
 	"github.com/dalfonso89/currency-exchange-service/testutils"
 )
 
-// MockProvider is a mock implementation of ExchangeRateProvider for testing
-type MockProvider struct {
-	name     string
-	enabled  bool
-	priority int
-	rates    map[string]float64
-	error    error
-}
-
-func (m *MockProvider) GetName() string {
-	return m.name
-}
-
-func (m *MockProvider) IsEnabled() bool {
-	return m.enabled
-}
-
-func (m *MockProvider) GetPriority() int {
-	return m.priority
-}
-
-func (m *MockProvider) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
-	if m.error != nil {
-		return models.RatesResponse{}, m.error
-	}
-	return models.RatesResponse{
-		Base:      baseCurrency,
-		Timestamp: time.Now().Unix(),
-		Rates:     m.rates,
-		Provider:  m.name,
-	}, nil
-}
-
 func TestNewRatesService(t *testing.T) {
 	cfg := testutils.MockConfig()
 	logger := testutils.MockLogger()
@@ -67,16 +36,16 @@ func TestRatesService_GetRates_Success(t *testing.T) {
 	logger := testutils.MockLogger()
 
 	// Create a mock provider
-	mockProvider := &MockProvider{
-		name:     "test-provider",
-		enabled:  true,
-		priority: 1,
-		rates: map[string]float64{
+	mockProvider := &testutils.FakeProvider{
+		Name:     "test-provider",
+		Enabled:  true,
+		Priority: 1,
+		Rates: map[string]float64{
 			"EUR": 0.85,
 			"GBP": 0.73,
 			"JPY": 110.0,
 		},
-		error: nil,
+		Err: nil,
 	}
 
 	service := &RatesService{
@@ -124,19 +93,19 @@ func TestRatesService_GetRates_AllProvidersFail(t *testing.T) {
 	logger := testutils.MockLogger()
 
 	// Create mock providers that all fail
-	mockProvider1 := &MockProvider{
-		name:     "provider1",
-		enabled:  true,
-		priority: 1,
-		rates:    nil,
-		error:    context.DeadlineExceeded,
+	mockProvider1 := &testutils.FakeProvider{
+		Name:     "provider1",
+		Enabled:  true,
+		Priority: 1,
+		Rates:    nil,
+		Err:      context.DeadlineExceeded,
 	}
-	mockProvider2 := &MockProvider{
-		name:     "provider2",
-		enabled:  true,
-		priority: 2,
-		rates:    nil,
-		error:    context.DeadlineExceeded,
+	mockProvider2 := &testutils.FakeProvider{
+		Name:     "provider2",
+		Enabled:  true,
+		Priority: 2,
+		Rates:    nil,
+		Err:      context.DeadlineExceeded,
 	}
 
 	service := &RatesService{
@@ -157,14 +126,14 @@ func TestRatesService_GetRates_Cache(t *testing.T) {
 	logger := testutils.MockLogger()
 
 	// Create a mock provider
-	mockProvider := &MockProvider{
-		name:     "test-provider",
-		enabled:  true,
-		priority: 1,
-		rates: map[string]float64{
+	mockProvider := &testutils.FakeProvider{
+		Name:     "test-provider",
+		Enabled:  true,
+		Priority: 1,
+		Rates: map[string]float64{
 			"EUR": 0.85,
 		},
-		error: nil,
+		Err: nil,
 	}
 
 	service := &RatesService{
@@ -201,15 +170,15 @@ func TestRatesService_GetProviderStatus(t *testing.T) {
 	logger := testutils.MockLogger()
 
 	// Create mock providers
-	mockProvider1 := &MockProvider{
-		name:     "provider1",
-		enabled:  true,
-		priority: 1,
+	mockProvider1 := &testutils.FakeProvider{
+		Name:     "provider1",
+		Enabled:  true,
+		Priority: 1,
 	}
-	mockProvider2 := &MockProvider{
-		name:     "provider2",
-		enabled:  false,
-		priority: 2,
+	mockProvider2 := &testutils.FakeProvider{
+		Name:     "provider2",
+		Enabled:  false,
+		Priority: 2,
 	}
 
 	service := &RatesService{
@@ -252,14 +221,14 @@ func TestRatesService_ConcurrentRequests(t *testing.T) {
 	logger := testutils.MockLogger()
 
 	// Create a mock provider
-	mockProvider := &MockProvider{
-		name:     "test-provider",
-		enabled:  true,
-		priority: 1,
-		rates: map[string]float64{
+	mockProvider := &testutils.FakeProvider{
+		Name:     "test-provider",
+		Enabled:  true,
+		Priority: 1,
+		Rates: map[string]float64{
 			"EUR": 0.85,
 		},
-		error: nil,
+		Err: nil,
 	}
 
 	service := &RatesService{
@@ -305,3 +274,185 @@ func TestRatesService_ConcurrentRequests(t *testing.T) {
 		t.Errorf("Concurrent requests: %v errors occurred", errorCount)
 	}
 }
+
+func TestRatesService_ForTenant(t *testing.T) {
+	cfg := testutils.MockConfig()
+	logger := testutils.MockLogger()
+
+	service := &RatesService{
+		configuration: cfg,
+		logger:        logger,
+		providers: []ExchangeRateProvider{
+			&testutils.FakeProvider{Name: "provider1", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.85, "GBP": 0.73}},
+			&testutils.FakeProvider{Name: "provider2", Enabled: true, Priority: 2, Rates: map[string]float64{"EUR": 0.86, "GBP": 0.74}},
+		},
+	}
+
+	tenant := &config.Tenant{
+		ID:                "acme",
+		Providers:         []string{"provider2"},
+		Markup:            config.MarkupConfig{GlobalBPS: 50},
+		AllowedCurrencies: []string{"USD", "EUR"},
+	}
+
+	view := service.ForTenant(tenant)
+	if view == service {
+		t.Fatal("ForTenant() returned the shared service")
+	}
+	if service.ForTenant(tenant) != view {
+		t.Error("ForTenant() did not reuse the existing view")
+	}
+	if len(view.providers) != 1 || view.providers[0].GetName() != "provider2" {
+		t.Errorf("ForTenant() providers = %v, want [provider2]", view.providers)
+	}
+	if view.configuration.Markup.GlobalBPS != 50 {
+		t.Errorf("ForTenant() markup = %v, want %v", view.configuration.Markup.GlobalBPS, 50)
+	}
+	if service.configuration.Markup.GlobalBPS != 0 {
+		t.Errorf("ForTenant() modified shared markup to %v", service.configuration.Markup.GlobalBPS)
+	}
+
+	result, err := view.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(result.Rates) != 1 || result.Rates["EUR"] != 0.86 {
+		t.Errorf("GetRates() Rates = %v, want only EUR from provider2", result.Rates)
+	}
+
+	if _, err := view.GetRates(context.Background(), "GBP"); err == nil {
+		t.Error("GetRates() expected error for disallowed base currency")
+	}
+	if _, err := view.Convert(context.Background(), "USD", "GBP", 10, ""); err == nil {
+		t.Error("Convert() expected error for disallowed target currency")
+	}
+}
+
+func TestRatesService_AllowedBases(t *testing.T) {
+	cfg := testutils.MockConfig()
+	cfg.DefaultBaseCurrency = "EUR"
+	cfg.AllowedBaseCurrencies = []string{"EUR", "GBP"}
+	service := NewRatesService(cfg, testutils.MockLogger())
+	service.providers = []ExchangeRateProvider{
+		&testutils.FakeProvider{Name: "provider1", Enabled: true, Priority: 1, Rates: map[string]float64{"USD": 1.08, "GBP": 0.86}},
+	}
+
+	if base := service.DefaultBaseCurrency(); base != "EUR" {
+		t.Errorf("DefaultBaseCurrency() = %s, want EUR", base)
+	}
+	if _, err := service.GetRates(context.Background(), "GBP"); err != nil {
+		t.Fatalf("GetRates() of an allowed base error = %v", err)
+	}
+
+	_, err := service.GetRates(context.Background(), "USD")
+	var serviceError *ServiceError
+	if !errors.As(err, &serviceError) || serviceError.Type != ErrorTypeUnsupportedBase {
+		t.Fatalf("GetRates() of a disallowed base error = %v, want an unsupported base", err)
+	}
+	if _, err := service.Convert(context.Background(), "USD", "EUR", 10, ""); err == nil {
+		t.Error("Convert() from a disallowed base should fail")
+	}
+
+	// Tenant views share the allowed bases
+	view := service.ForTenant(&config.Tenant{ID: "acme"})
+	if view.IsBaseAllowed("USD") || !view.IsBaseAllowed("EUR") {
+		t.Error("ForTenant() view should keep the allowed bases")
+	}
+
+	if base := NewRatesService(testutils.MockConfig(), testutils.MockLogger()).DefaultBaseCurrency(); base != "USD" {
+		t.Errorf("DefaultBaseCurrency() without configuration = %s, want USD", base)
+	}
+}
+
+func TestWithAge(t *testing.T) {
+	now := time.Unix(1641000000, 0)
+
+	tests := []struct {
+		name     string
+		response models.RatesResponse
+		wantAge  int64
+	}{
+		{name: "published time", response: models.RatesResponse{PublishedAt: 1640999000, FetchedAt: 1640999900}, wantAge: 1000},
+		{name: "fetch time fallback", response: models.RatesResponse{FetchedAt: 1640999900}, wantAge: 100},
+		{name: "future timestamp", response: models.RatesResponse{PublishedAt: 1641000100}, wantAge: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withAge(tt.response, now).AgeSeconds; got != tt.wantAge {
+				t.Errorf("withAge() AgeSeconds = %v, want %v", got, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestRatesService_CacheStats(t *testing.T) {
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{&testutils.FakeProvider{Name: "test-provider", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.85}}},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetRates(context.Background(), "USD"); err != nil {
+			t.Fatalf("GetRates() error = %v", err)
+		}
+	}
+
+	stats := service.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("CacheStats() hits/misses = %v/%v, want 2/1", stats.Hits, stats.Misses)
+	}
+	if stats.Base != "USD" {
+		t.Errorf("CacheStats() Base = %v, want %v", stats.Base, "USD")
+	}
+
+	service.PurgeCache(context.Background())
+	if stats := service.CacheStats(); stats.Base != "" {
+		t.Errorf("CacheStats() after purge Base = %v, want empty", stats.Base)
+	}
+}
+
+// recordingHistory collects the rates recorded by the service
+type recordingHistory struct {
+	recorded chan models.RatesResponse
+}
+
+func (history *recordingHistory) RecordRates(ctx context.Context, exchangeRates models.RatesResponse) error {
+	history.recorded <- exchangeRates
+	return nil
+}
+
+func TestRatesService_SetHistory(t *testing.T) {
+	mockProvider := &testutils.FakeProvider{Name: "test-provider", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.85}}
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{mockProvider},
+	}
+	history := &recordingHistory{recorded: make(chan models.RatesResponse, 1)}
+	service.SetHistory(history)
+
+	if _, err := service.GetRates(context.Background(), "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	select {
+	case recorded := <-history.recorded:
+		if recorded.Base != "USD" || recorded.Rates["EUR"] != 0.85 {
+			t.Errorf("recorded rates = %+v, want the fetched USD rates", recorded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fetched rates were not recorded in the history")
+	}
+
+	// Cache hits are not recorded again
+	if _, err := service.GetRates(context.Background(), "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	select {
+	case recorded := <-history.recorded:
+		t.Errorf("cache hit recorded %+v", recorded)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
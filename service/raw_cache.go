@@ -0,0 +1,114 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// rawKey identifies the complete latest rates one provider answered for a base
+type rawKey struct {
+	Provider string
+	Base     string
+}
+
+// rawCache is the provider data shared by the service and its tenant views: the complete
+// latest table each provider answered for each base, and the provider fetches in flight.
+// Each view keeps its own cache of the responses derived for its tenant, so tenant
+// restrictions never leak between tenants, but a table fetched for one tenant serves
+// every tenant whose providers and source policy permit it, and concurrent fetches from
+// the same providers are made once. A nil cache shares nothing.
+type rawCache struct {
+	mutex   sync.RWMutex
+	tables  map[rawKey]models.CacheEntry
+	flights singleflight.Group
+}
+
+func newRawCache() *rawCache {
+	return &rawCache{tables: make(map[rawKey]models.CacheEntry)}
+}
+
+// store keeps a provider's table until the cache TTL expires, dropping expired tables
+func (cache *rawCache) store(ratesService *RatesService, exchangeRates models.RatesResponse) {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	now := ratesService.now()
+	for key, entry := range cache.tables {
+		if !now.Before(entry.ExpiresAt) {
+			delete(cache.tables, key)
+		}
+	}
+	cache.tables[rawKey{Provider: exchangeRates.Provider, Base: exchangeRates.Base}] = models.CacheEntry{
+		Data:      exchangeRates,
+		ExpiresAt: now.Add(ratesService.CacheTTL()),
+	}
+}
+
+// lookup returns the most recently published valid table of the base from any of the
+// providers
+func (cache *rawCache) lookup(ratesService *RatesService, baseCurrency string, providers []ExchangeRateProvider) (models.RatesResponse, bool) {
+	if cache == nil {
+		return models.RatesResponse{}, false
+	}
+
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	now := ratesService.now()
+	var best models.CacheEntry
+	found := false
+	for _, provider := range providers {
+		entry, cached := cache.tables[rawKey{Provider: provider.GetName(), Base: baseCurrency}]
+		if !cached || !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if !found || entry.Data.Timestamp > best.Data.Timestamp {
+			best, found = entry, true
+		}
+	}
+	return best.Data, found
+}
+
+// entries counts the valid tables
+func (cache *rawCache) entries(ratesService *RatesService) int {
+	if cache == nil {
+		return 0
+	}
+
+	cache.mutex.RLock()
+	defer cache.mutex.RUnlock()
+
+	now := ratesService.now()
+	count := 0
+	for _, entry := range cache.tables {
+		if now.Before(entry.ExpiresAt) {
+			count++
+		}
+	}
+	return count
+}
+
+// purge drops every table
+func (cache *rawCache) purge() {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.tables = make(map[rawKey]models.CacheEntry)
+}
+
+// sharedFetch is the outcome of a fetch coalesced across the service and its views, with
+// the view that made it
+type sharedFetch struct {
+	rates models.RatesResponse
+	by    *RatesService
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRatesService_TenantViewsShareProviderTables(t *testing.T) {
+	primary := &testutils.FakeProvider{Name: "primary", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.85, "GBP": 0.73}}
+	secondary := &testutils.FakeProvider{Name: "secondary", Enabled: true, Priority: 2, Rates: map[string]float64{"EUR": 0.86, "GBP": 0.74}}
+	service := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{primary, secondary},
+		raw:           newRawCache(),
+	}
+	restricted := service.ForTenant(&config.Tenant{ID: "acme", Providers: []string{"primary"}, AllowedCurrencies: []string{"USD", "EUR"}})
+	unrestricted := service.ForTenant(&config.Tenant{ID: "globex", Providers: []string{"primary"}})
+	other := service.ForTenant(&config.Tenant{ID: "initech", Providers: []string{"secondary"}})
+	ctx := context.Background()
+
+	rates, err := restricted.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(rates.Rates) != 1 || primary.Calls() != 1 {
+		t.Fatalf("restricted GetRates() = %v after %d calls, want only EUR from one call", rates.Rates, primary.Calls())
+	}
+
+	// Another tenant of the same provider is served its table without a call, and without
+	// the first tenant's restrictions
+	rates, err = unrestricted.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(rates.Rates) != 2 || primary.Calls() != 1 {
+		t.Errorf("unrestricted GetRates() = %v after %d calls, want EUR and GBP from the shared table", rates.Rates, primary.Calls())
+	}
+
+	// A tenant whose providers did not answer yet fetches from them
+	rates, err = other.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if rates.Provider != "secondary" || secondary.Calls() != 1 {
+		t.Errorf("GetRates() of another provider's tenant = %+v after %d calls, want a secondary fetch", rates, secondary.Calls())
+	}
+
+	// The shared service derives its response from the tables too
+	if _, err := service.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if calls := primary.Calls() + secondary.Calls(); calls != 2 {
+		t.Errorf("provider calls = %d, want 2", calls)
+	}
+	if stats := service.CacheStats(); stats.ProviderTables != 2 {
+		t.Errorf("CacheStats() ProviderTables = %d, want 2", stats.ProviderTables)
+	}
+
+	service.PurgeCache(context.Background())
+	if stats := service.CacheStats(); stats.ProviderTables != 0 {
+		t.Errorf("CacheStats() ProviderTables after PurgeCache() = %d, want 0", stats.ProviderTables)
+	}
+	if _, err := unrestricted.GetRates(ctx, "USD"); err != nil || primary.Calls() != 2 {
+		t.Errorf("GetRates() after PurgeCache() = %v after %d calls, want a new fetch", err, primary.Calls())
+	}
+}
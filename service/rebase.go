@@ -0,0 +1,32 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// rebaseRates derives rates for targetBase from rates quoted against another base.
+// For source base S and target base T: rate(T->X) = rate(S->X) / rate(S->T).
+func rebaseRates(response models.RatesResponse, targetBase string) (models.RatesResponse, error) {
+	sourceBase := response.Base
+
+	targetRate, found := response.Rates[targetBase]
+	if !found || targetRate == 0 {
+		return models.RatesResponse{}, fmt.Errorf("cannot rebase %s rates to %s, which has no rate: %w", sourceBase, targetBase, ErrUnsupportedBase)
+	}
+
+	rebasedRates := make(map[string]float64, len(response.Rates)+1)
+	for symbol, rate := range response.Rates {
+		rebasedRates[symbol] = rate / targetRate
+	}
+	rebasedRates[sourceBase] = 1 / targetRate
+	rebasedRates[targetBase] = 1
+
+	response.Base = targetBase
+	response.Rates = rebasedRates
+	response.Bid, response.Ask = nil, nil // Cross rates are mid rates only
+	response.Rebased = true
+	response.SourceBase = sourceBase
+	return response, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestRebaseRates(t *testing.T) {
+	response := models.RatesResponse{
+		Base:      "USD",
+		Timestamp: 1640995200,
+		Rates: map[string]float64{
+			"EUR": 0.8,
+			"GBP": 0.75,
+			"JPY": 110.0,
+		},
+		Provider: "test",
+	}
+
+	result, err := rebaseRates(response, "EUR")
+	if err != nil {
+		t.Fatalf("rebaseRates() error = %v", err)
+	}
+
+	if result.Base != "EUR" || !result.Rebased || result.SourceBase != "USD" {
+		t.Errorf("rebaseRates() metadata = %+v", result)
+	}
+
+	expected := map[string]float64{
+		"USD": 1.25,
+		"EUR": 1,
+		"GBP": 0.9375,
+		"JPY": 137.5,
+	}
+	for symbol, want := range expected {
+		if math.Abs(result.Rates[symbol]-want) > 1e-9 {
+			t.Errorf("rebaseRates() %s = %v, want %v", symbol, result.Rates[symbol], want)
+		}
+	}
+}
+
+func TestRebaseRates_MissingTarget(t *testing.T) {
+	response := models.RatesResponse{Base: "USD", Rates: map[string]float64{"EUR": 0.8}}
+
+	if _, err := rebaseRates(response, "CHF"); err == nil {
+		t.Error("rebaseRates() expected error for missing target rate")
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_FixedBase(t *testing.T) {
+	var requestedBase string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedBase = r.URL.Query().Get("base")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.8, "GBP": 0.75}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{
+			Name:      "openexchangerates",
+			BaseURL:   server.URL,
+			Enabled:   true,
+			FixedBase: "USD",
+		},
+		testutils.MockLogger(),
+	)
+
+	result, err := provider.GetRates(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+
+	if requestedBase != "USD" {
+		t.Errorf("GetRates() requested base = %v, want %v", requestedBase, "USD")
+	}
+	if result.Base != "EUR" || !result.Rebased {
+		t.Errorf("GetRates() Base = %v, Rebased = %v, want EUR rebased", result.Base, result.Rebased)
+	}
+	if math.Abs(result.Rates["USD"]-1.25) > 1e-9 {
+		t.Errorf("GetRates() USD = %v, want %v", result.Rates["USD"], 1.25)
+	}
+}
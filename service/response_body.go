@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultMaxResponseBytes bounds provider response bodies when no limit is configured
+const DefaultMaxResponseBytes = 1 << 20
+
+// maxPooledBufferBytes is the largest buffer returned to the pool, so one oversized
+// response does not keep its memory alive
+const maxPooledBufferBytes = 256 << 10
+
+// errResponseTooLarge is returned when a provider response exceeds the body size limit
+var errResponseTooLarge = errors.New("response body too large")
+
+// responseBuffers reuses the buffers provider responses are read into, so refresh
+// storms do not allocate a fresh buffer per call
+var responseBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// responseDecoder decodes a provider response into target
+type responseDecoder func(target interface{}) error
+
+// bytesDecoder decodes a response body held in memory
+func bytesDecoder(body []byte) responseDecoder {
+	return func(target interface{}) error {
+		return json.Unmarshal(body, target)
+	}
+}
+
+// boundedReader reads at most limit bytes, failing with errResponseTooLarge when the
+// body holds more. The first read failure is kept, to tell it from a decode failure.
+type boundedReader struct {
+	reader    io.Reader
+	remaining int64
+	err       error
+}
+
+func (bounded *boundedReader) Read(buffer []byte) (int, error) {
+	if bounded.err != nil {
+		return 0, bounded.err
+	}
+	if bounded.remaining <= 0 {
+		// The limit is reached: any further byte makes the body too large
+		var probe [1]byte
+		read, err := bounded.reader.Read(probe[:])
+		if read > 0 {
+			err = errResponseTooLarge
+		}
+		return 0, bounded.fail(err)
+	}
+
+	if int64(len(buffer)) > bounded.remaining {
+		buffer = buffer[:bounded.remaining]
+	}
+	read, err := bounded.reader.Read(buffer)
+	bounded.remaining -= int64(read)
+	return read, bounded.fail(err)
+}
+
+// fail records a read failure other than the end of the body
+func (bounded *boundedReader) fail(err error) error {
+	if err != nil && err != io.EOF {
+		bounded.err = err
+	}
+	return err
+}
+
+// readBody reads a response body of at most limit bytes into a pooled buffer, which the
+// caller hands back with releaseBuffer
+func readBody(body io.Reader, limit int64) (*bytes.Buffer, error) {
+	buffer := responseBuffers.Get().(*bytes.Buffer)
+	buffer.Reset()
+	if _, err := buffer.ReadFrom(&boundedReader{reader: body, remaining: limit}); err != nil {
+		releaseBuffer(buffer)
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// releaseBuffer returns a buffer to the pool unless it grew too large to keep
+func releaseBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() <= maxPooledBufferBytes {
+		responseBuffers.Put(buffer)
+	}
+}
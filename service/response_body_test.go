@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestReadBody(t *testing.T) {
+	buffer, err := readBody(strings.NewReader("0123456789"), 10)
+	if err != nil {
+		t.Fatalf("readBody() of a body at the limit error = %v", err)
+	}
+	if buffer.String() != "0123456789" {
+		t.Errorf("readBody() = %q, want the whole body", buffer.String())
+	}
+	releaseBuffer(buffer)
+
+	if _, err := readBody(strings.NewReader("0123456789!"), 10); !errors.Is(err, errResponseTooLarge) {
+		t.Errorf("readBody() of a body over the limit error = %v, want %v", err, errResponseTooLarge)
+	}
+
+	failure := errors.New("connection reset")
+	if _, err := readBody(io.MultiReader(strings.NewReader("01"), &failingReader{err: failure}), 10); !errors.Is(err, failure) {
+		t.Errorf("readBody() of a failing body error = %v, want %v", err, failure)
+	}
+}
+
+// failingReader fails every read with err
+type failingReader struct {
+	err error
+}
+
+func (reader *failingReader) Read([]byte) (int, error) {
+	return 0, reader.err
+}
+
+func TestHTTPExchangeRateProvider_GetRates_ResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"base": "USD", "timestamp": 1640995200, "rates": {"EUR": 0.85, "GBP": 0.73}}`))
+	}))
+	defer server.Close()
+
+	for _, conditional := range []bool{false, true} {
+		newProvider := func(maxResponseBytes int64) *HTTPExchangeRateProvider {
+			provider := NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "test", BaseURL: server.URL, Enabled: true}, testutils.MockLogger())
+			provider.maxResponseBytes = maxResponseBytes
+			if conditional {
+				provider.poller = newConditionalPoller()
+			}
+			return provider
+		}
+
+		response, err := newProvider(DefaultMaxResponseBytes).GetRates(context.Background(), "USD")
+		if err != nil {
+			t.Fatalf("GetRates() conditional=%v error = %v", conditional, err)
+		}
+		if response.Rates["EUR"] != 0.85 || response.Rates["GBP"] != 0.73 {
+			t.Errorf("GetRates() conditional=%v rates = %v", conditional, response.Rates)
+		}
+
+		_, err = newProvider(32).GetRates(context.Background(), "USD")
+		var providerError *ProviderError
+		if !errors.As(err, &providerError) || !errors.Is(err, ErrDecode) || !strings.Contains(providerError.Detail, "exceeds 32 bytes") {
+			t.Errorf("GetRates() conditional=%v of an oversized body error = %v, want a decode error naming the limit", conditional, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// signingHashes maps supported signing algorithms to their hash constructors
+var signingHashes = map[string]func() hash.Hash{
+	"hmac-sha256": sha256.New,
+	"hmac-sha512": sha512.New,
+}
+
+// signRequest adds a timestamp header and an HMAC signature over
+// "<timestamp>\n<method>\n<path?query>" to the request
+func signRequest(req *http.Request, signing config.RequestSigningConfig, now time.Time) error {
+	newHash, supported := signingHashes[signing.Algorithm]
+	if !supported {
+		return fmt.Errorf("unsupported signing algorithm %q", signing.Algorithm)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(newHash, []byte(signing.Key))
+	mac.Write([]byte(timestamp + "\n" + req.Method + "\n" + req.URL.RequestURI()))
+
+	req.Header.Set(signing.TimestampHeader, timestamp)
+	req.Header.Set(signing.SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
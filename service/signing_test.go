@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func testSigning() config.RequestSigningConfig {
+	return config.RequestSigningConfig{
+		Algorithm:       "hmac-sha256",
+		Key:             "secret",
+		SignatureHeader: "X-Signature",
+		TimestampHeader: "X-Timestamp",
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://rates.internal/rates?base=USD", nil)
+
+	if err := signRequest(req, testSigning(), time.Unix(1641000000, 0)); err != nil {
+		t.Fatalf("signRequest() error = %v", err)
+	}
+
+	if got := req.Header.Get("X-Timestamp"); got != "1641000000" {
+		t.Errorf("X-Timestamp = %v, want %v", got, "1641000000")
+	}
+	want := "25f96483874ebf203e8ddccc3d1ec23a4d88f87c176880cc2218bb5d52df5ebc"
+	if got := req.Header.Get("X-Signature"); got != want {
+		t.Errorf("X-Signature = %v, want %v", got, want)
+	}
+}
+
+func TestSignRequest_UnsupportedAlgorithm(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://rates.internal/rates", nil)
+	signing := testSigning()
+	signing.Algorithm = "md5"
+
+	if err := signRequest(req, signing, time.Now()); err == nil {
+		t.Error("signRequest() expected error for unsupported algorithm")
+	}
+}
+
+func TestHTTPExchangeRateProvider_GetRates_SignsRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(r.Header.Get("X-Timestamp") + "\n" + r.Method + "\n" + r.URL.RequestURI()))
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.85}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "internal", BaseURL: server.URL + "/rates", Enabled: true, Signing: testSigning()},
+		testutils.MockLogger(),
+	)
+
+	result, err := provider.GetRates(context.Background(), "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if result.Rates["EUR"] != 0.85 {
+		t.Errorf("GetRates() EUR = %v, want %v", result.Rates["EUR"], 0.85)
+	}
+}
+
+func TestHTTPExchangeRateProvider_RotateSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mac := hmac.New(sha256.New, []byte("rotated-secret"))
+		mac.Write([]byte(r.Header.Get("X-Timestamp") + "\n" + r.Method + "\n" + r.URL.RequestURI()))
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"base": "USD", "rates": {"EUR": 0.85}}`))
+	}))
+	defer server.Close()
+
+	provider := NewHTTPExchangeRateProvider(
+		config.ExchangeRateProvider{Name: "internal", BaseURL: server.URL + "/rates", Enabled: true, Signing: testSigning()},
+		testutils.MockLogger(),
+	)
+	if _, err := provider.GetRates(context.Background(), "USD"); err == nil {
+		t.Fatal("GetRates() with the old signing key expected an error")
+	}
+
+	provider.RotateSecrets("", "rotated-secret")
+	if _, err := provider.GetRates(context.Background(), "USD"); err != nil {
+		t.Errorf("GetRates() with the rotated signing key error = %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/currency"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// maxAgeDefault keys the max age of currencies without one of their own or of their class
+const maxAgeDefault = "DEFAULT"
+
+// maxAgeClasses maps the currency classes max ages may be keyed by to their keys
+var maxAgeClasses = map[currency.Type]string{
+	currency.TypeFiat:  strings.ToUpper(string(currency.TypeFiat)),
+	currency.TypeMetal: strings.ToUpper(string(currency.TypeMetal)),
+}
+
+// ValidateStaleness checks that max ages are positive and keyed by currency code or class
+func ValidateStaleness(configuration config.StalenessConfig) error {
+	for key, maxAge := range configuration.MaxAge {
+		if len(key) != 3 && !isMaxAgeClass(key) {
+			return fmt.Errorf("RATE_MAX_AGE_SECONDS key %q must be a currency code, fiat, metal or default", key)
+		}
+		if maxAge <= 0 {
+			return fmt.Errorf("RATE_MAX_AGE_SECONDS of %s must be positive", key)
+		}
+	}
+	return nil
+}
+
+// isMaxAgeClass reports whether a max age key names a currency class or the default
+func isMaxAgeClass(key string) bool {
+	for _, class := range maxAgeClasses {
+		if key == class {
+			return true
+		}
+	}
+	return key == maxAgeDefault
+}
+
+// maxAge returns the max age of a currency: its own, else its class's, else the default
+func (ratesService *RatesService) maxAge(code string) (time.Duration, bool) {
+	maxAges := ratesService.configuration.Staleness.MaxAge
+	if len(maxAges) == 0 {
+		return 0, false
+	}
+	if maxAge, found := maxAges[code]; found {
+		return maxAge, true
+	}
+	if known, found := currency.Lookup(code); found {
+		if maxAge, found := maxAges[maxAgeClasses[known.Type]]; found {
+			return maxAge, true
+		}
+	}
+	maxAge, found := maxAges[maxAgeDefault]
+	return maxAge, found
+}
+
+// pairMaxAge returns the max age of a rate between two currencies, the shorter of theirs,
+// since a rate moves with either currency
+func (ratesService *RatesService) pairMaxAge(base, code string) (time.Duration, bool) {
+	baseMaxAge, baseFound := ratesService.maxAge(base)
+	maxAge, found := ratesService.maxAge(code)
+	if !found || (baseFound && baseMaxAge < maxAge) {
+		return baseMaxAge, baseFound
+	}
+	return maxAge, true
+}
+
+// isStale reports whether a rate between two currencies is older than its max age at the age
+func (ratesService *RatesService) isStale(age time.Duration, base, code string) bool {
+	maxAge, found := ratesService.pairMaxAge(base, code)
+	return found && age > maxAge
+}
+
+// markStale lists the quoted currencies whose rates are older than their max age when served
+func (ratesService *RatesService) markStale(exchangeRates models.RatesResponse) models.RatesResponse {
+	if len(ratesService.configuration.Staleness.MaxAge) == 0 {
+		return exchangeRates
+	}
+	currencies := make([]string, 0, len(exchangeRates.Rates))
+	for code := range exchangeRates.Rates {
+		if code != exchangeRates.Base {
+			currencies = append(currencies, code)
+		}
+	}
+	sort.Strings(currencies)
+	age := time.Duration(exchangeRates.AgeSeconds) * time.Second
+	for _, code := range currencies {
+		if ratesService.isStale(age, exchangeRates.Base, code) {
+			exchangeRates.Stale = append(exchangeRates.Stale, code)
+		}
+	}
+	return exchangeRates
+}
+
+// needsRefresh reports whether a cached table should be fetched again before it serves
+// the symbols (none = every quoted currency): refreshing is enabled and one of their
+// rates has been cached for longer than its max age. The fetch age is used rather than
+// the publication age, so rates a provider publishes once a day are not fetched again on
+// every request once they are marked stale.
+func (ratesService *RatesService) needsRefresh(exchangeRates models.RatesResponse, symbols []string) bool {
+	staleness := ratesService.configuration.Staleness
+	if !staleness.Refresh || len(staleness.MaxAge) == 0 || exchangeRates.FetchedAt == 0 {
+		return false
+	}
+	age := ratesService.now().Sub(time.Unix(exchangeRates.FetchedAt, 0))
+	if symbols != nil {
+		for _, code := range symbols {
+			if ratesService.isStale(age, exchangeRates.Base, code) {
+				return true
+			}
+		}
+		return false
+	}
+	for code := range exchangeRates.Rates {
+		if ratesService.isStale(age, exchangeRates.Base, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// pairNeedsRefresh reports whether a cached table should be fetched again before it
+// serves a pair, as needsRefresh does for the pair's currencies
+func (ratesService *RatesService) pairNeedsRefresh(exchangeRates models.RatesResponse, fromCurrency, toCurrency string) bool {
+	staleness := ratesService.configuration.Staleness
+	if !staleness.Refresh || len(staleness.MaxAge) == 0 || exchangeRates.FetchedAt == 0 {
+		return false
+	}
+	return ratesService.isStale(ratesService.now().Sub(time.Unix(exchangeRates.FetchedAt, 0)), fromCurrency, toCurrency)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func stalenessService(staleness config.StalenessConfig) (*RatesService, *testutils.FakeProvider, *testutils.FakeClock) {
+	cfg := testutils.MockConfig()
+	cfg.RatesCacheTTL = time.Hour
+	cfg.Staleness = staleness
+	clock := testutils.NewFakeClock(time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC))
+	provider := &testutils.FakeProvider{
+		Name:    "test-provider",
+		Enabled: true,
+		Rates:   map[string]float64{"EUR": 0.90, "XAU": 0.0005, "BTC": 0.00002},
+		Clock:   clock,
+	}
+	return &RatesService{
+		configuration: cfg,
+		logger:        testutils.MockLogger(),
+		clock:         clock,
+		providers:     []ExchangeRateProvider{provider},
+	}, provider, clock
+}
+
+func TestRatesService_MaxAge(t *testing.T) {
+	service, _, _ := stalenessService(config.StalenessConfig{MaxAge: map[string]time.Duration{
+		"BTC":     30 * time.Second,
+		"METAL":   5 * time.Minute,
+		"DEFAULT": 6 * time.Hour,
+	}})
+
+	tests := []struct {
+		base, code string
+		want       time.Duration
+	}{
+		{base: "USD", code: "BTC", want: 30 * time.Second},
+		{base: "USD", code: "XAU", want: 5 * time.Minute},
+		{base: "USD", code: "EUR", want: 6 * time.Hour},
+		{base: "BTC", code: "EUR", want: 30 * time.Second}, // The rate moves with the base
+	}
+	for _, tt := range tests {
+		if maxAge, found := service.pairMaxAge(tt.base, tt.code); !found || maxAge != tt.want {
+			t.Errorf("pairMaxAge(%s, %s) = %v, %v, want %v", tt.base, tt.code, maxAge, found, tt.want)
+		}
+	}
+
+	service.configuration.Staleness.MaxAge = map[string]time.Duration{"BTC": 30 * time.Second}
+	if _, found := service.pairMaxAge("USD", "EUR"); found {
+		t.Error("pairMaxAge() without a threshold of either currency should not be found")
+	}
+}
+
+func TestRatesService_GetRates_MarksStale(t *testing.T) {
+	service, provider, clock := stalenessService(config.StalenessConfig{MaxAge: map[string]time.Duration{
+		"BTC":  30 * time.Second,
+		"FIAT": time.Hour,
+	}})
+	ctx := context.Background()
+
+	rates, err := service.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(rates.Stale) != 0 {
+		t.Errorf("GetRates() Stale = %v, want none when fresh", rates.Stale)
+	}
+
+	// Without refreshes the cached table is served, marking BTC as stale
+	clock.Advance(time.Minute)
+	rates, err = service.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if !reflect.DeepEqual(rates.Stale, []string{"BTC"}) || provider.Calls() != 1 {
+		t.Errorf("GetRates() Stale = %v after %d calls, want [BTC] from the cache", rates.Stale, provider.Calls())
+	}
+
+	pair, err := service.GetPairRate(ctx, "USD", "BTC")
+	if err != nil {
+		t.Fatalf("GetPairRate() error = %v", err)
+	}
+	if !pair.Stale {
+		t.Errorf("GetPairRate() = %+v, want stale", pair)
+	}
+	if pair, _ := service.GetPairRate(ctx, "USD", "EUR"); pair.Stale {
+		t.Errorf("GetPairRate() = %+v, want fresh", pair)
+	}
+}
+
+func TestRatesService_GetRates_RefreshesStaleSymbols(t *testing.T) {
+	service, provider, clock := stalenessService(config.StalenessConfig{
+		MaxAge:  map[string]time.Duration{"BTC": 30 * time.Second, "FIAT": time.Hour},
+		Refresh: true,
+	})
+	ctx := context.Background()
+
+	if _, err := service.GetRates(ctx, "USD"); err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	clock.Advance(time.Minute)
+
+	// Requests for currencies that are still fresh are served from the cache
+	if _, err := service.GetRatesForSymbols(ctx, "USD", []string{"EUR"}); err != nil || provider.Calls() != 1 {
+		t.Fatalf("GetRatesForSymbols(EUR) error = %v after %d calls, want a cache hit", err, provider.Calls())
+	}
+
+	// A request for a stale currency fetches the table again
+	if _, err := service.GetRatesForSymbols(ctx, "USD", []string{"BTC"}); err != nil || provider.Calls() != 2 {
+		t.Fatalf("GetRatesForSymbols(BTC) error = %v after %d calls, want a refresh", err, provider.Calls())
+	}
+	if _, err := service.GetRatesForSymbols(ctx, "USD", []string{"BTC"}); err != nil || provider.Calls() != 2 {
+		t.Fatalf("GetRatesForSymbols(BTC) error = %v after %d calls, want the refreshed table", err, provider.Calls())
+	}
+
+	// A failed refresh still serves the cached table
+	clock.Advance(time.Minute)
+	provider.Err = errors.New("provider down")
+	rates, err := service.GetRates(ctx, "USD")
+	if err != nil {
+		t.Fatalf("GetRates() with a failed refresh error = %v", err)
+	}
+	if provider.Calls() != 3 || !reflect.DeepEqual(rates.Stale, []string{"BTC"}) {
+		t.Errorf("GetRates() Stale = %v after %d calls, want the stale table after a refresh attempt", rates.Stale, provider.Calls())
+	}
+}
+
+func TestValidateStaleness(t *testing.T) {
+	if err := ValidateStaleness(config.StalenessConfig{MaxAge: map[string]time.Duration{"BTC": time.Second, "METAL": time.Minute, "DEFAULT": time.Hour}}); err != nil {
+		t.Errorf("ValidateStaleness() error = %v", err)
+	}
+
+	invalid := []map[string]time.Duration{
+		{"CRYPTO": time.Second},
+		{"EUR": 0},
+	}
+	for _, maxAge := range invalid {
+		if err := ValidateStaleness(config.StalenessConfig{MaxAge: maxAge}); err == nil {
+			t.Errorf("ValidateStaleness(%v) should fail", maxAge)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// DisableProvider holds a provider out of fetches on an operator's request. In standby
+// the provider is probed and re-enabled once enough probes succeed in a row; otherwise
+// it stays disabled until EnableProvider.
+func (ratesService *RatesService) DisableProvider(providerName string, standby bool, reason string) error {
+	if !ratesService.hasProvider(providerName) {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, providerName)
+	}
+	ratesService.gate.disable(providerName, standby, reason)
+	return nil
+}
+
+// EnableProvider puts a provider back into fetches on an operator's request
+func (ratesService *RatesService) EnableProvider(providerName, reason string) error {
+	if !ratesService.hasProvider(providerName) {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, providerName)
+	}
+	ratesService.gate.enable(providerName, reason)
+	return nil
+}
+
+// ProviderTransitions returns the recent provider state transitions, newest first
+func (ratesService *RatesService) ProviderTransitions() []models.ProviderTransition {
+	return ratesService.gate.transitionLog()
+}
+
+// SetTransitionListener sets the function told of every provider state transition, such
+// as a provider put in standby after its credentials were rejected. It is called while
+// the provider states are locked, so it must return quickly and not call the service.
+func (ratesService *RatesService) SetTransitionListener(listener func(models.ProviderTransition)) {
+	ratesService.gate.setListener(listener)
+}
+
+// StartStandbyProbes probes the providers in standby every interval until ctx ends
+func (ratesService *RatesService) StartStandbyProbes(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ratesService.probeStandby(ctx)
+			}
+		}
+	}()
+}
+
+// probeStandby asks each provider in standby for the default base's rates, feeding the
+// outcome to the gate
+func (ratesService *RatesService) probeStandby(ctx context.Context) {
+	standby := make(map[string]bool)
+	for _, name := range ratesService.gate.inStandby() {
+		standby[name] = true
+	}
+	if len(standby) == 0 {
+		return
+	}
+
+	baseCurrency := ratesService.DefaultBaseCurrency()
+	for _, provider := range ratesService.providers {
+		if !standby[provider.GetName()] {
+			continue
+		}
+		_, err := provider.GetRates(ctx, baseCurrency)
+		if ctx.Err() != nil {
+			return
+		}
+		ratesService.gate.observe(provider.GetName(), baseCurrency, err)
+	}
+}
+
+// hasProvider reports whether the provider is configured
+func (ratesService *RatesService) hasProvider(providerName string) bool {
+	for _, provider := range ratesService.providers {
+		if provider.GetName() == providerName {
+			return true
+		}
+	}
+	return false
+}
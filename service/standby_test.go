@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestProviderGate_Standby(t *testing.T) {
+	gate := newProviderGate(testutils.MockLogger(), 3)
+	authError := &ProviderError{Provider: "keyless", StatusCode: 401, Kind: ErrAuth}
+	var told []models.ProviderTransition
+	gate.setListener(func(transition models.ProviderTransition) { told = append(told, transition) })
+
+	gate.observe("keyless", "USD", authError)
+	if by, standby, _ := gate.standbyStatus("keyless"); by != "health" || !standby {
+		t.Fatalf("standbyStatus() after rejected credentials = %s/%v, want health/standby", by, standby)
+	}
+
+	// A failed probe restarts the streak
+	gate.observe("keyless", "USD", nil)
+	gate.observe("keyless", "USD", nil)
+	gate.observe("keyless", "USD", errors.New("connection refused"))
+	gate.observe("keyless", "USD", nil)
+	gate.observe("keyless", "USD", nil)
+	if err := gate.admit("keyless", "USD"); !errors.Is(err, ErrAuth) {
+		t.Fatalf("admit() after 2 consecutive successes = %v, want %v", err, ErrAuth)
+	}
+	if _, _, successes := gate.standbyStatus("keyless"); successes != 2 {
+		t.Errorf("standbyStatus() successes = %d, want 2", successes)
+	}
+
+	// Our own budget says nothing about the provider, so it does not break the streak
+	gate.observe("keyless", "USD", ErrBudgetExhausted)
+	gate.observe("keyless", "USD", nil)
+	if err := gate.admit("keyless", "USD"); err != nil {
+		t.Errorf("admit() after 3 consecutive successes = %v, want nil", err)
+	}
+	if names := gate.inStandby(); len(names) != 0 {
+		t.Errorf("inStandby() = %v, want none", names)
+	}
+
+	transitions := gate.transitionLog()
+	if len(transitions) != 2 {
+		t.Fatalf("transitionLog() = %+v, want 2 transitions", transitions)
+	}
+	if newest := transitions[0]; newest.From != stateStandby || newest.To != stateEnabled || newest.By != "health" {
+		t.Errorf("transitionLog()[0] = %+v, want standby -> enabled by health", newest)
+	}
+	if oldest := transitions[1]; oldest.From != stateEnabled || oldest.To != stateStandby || oldest.Reason == "" {
+		t.Errorf("transitionLog()[1] = %+v, want enabled -> standby with the error", oldest)
+	}
+	if len(told) != 2 || told[0] != transitions[1] || told[1] != transitions[0] {
+		t.Errorf("listener was told %+v, want the transitions in order", told)
+	}
+}
+
+func TestProviderGate_OperatorDisable(t *testing.T) {
+	gate := newProviderGate(testutils.MockLogger(), 1)
+
+	// Without standby, successes do not re-enable the provider
+	gate.disable("erapi", false, "incident 42")
+	gate.observe("erapi", "USD", nil)
+	if err := gate.admit("erapi", "USD"); !errors.Is(err, ErrProviderDisabled) {
+		t.Errorf("admit() of a disabled provider = %v, want %v", err, ErrProviderDisabled)
+	}
+	if names := gate.inStandby(); len(names) != 0 {
+		t.Errorf("inStandby() = %v, want none", names)
+	}
+
+	// In standby, it recovers on its own
+	gate.disable("erapi", true, "probe it")
+	if names := gate.inStandby(); len(names) != 1 || names[0] != "erapi" {
+		t.Errorf("inStandby() = %v, want [erapi]", names)
+	}
+	gate.observe("erapi", "USD", nil)
+	if err := gate.admit("erapi", "USD"); err != nil {
+		t.Errorf("admit() after recovering in standby = %v, want nil", err)
+	}
+
+	gate.disable("erapi", false, "again")
+	gate.enable("erapi", "fixed")
+	if err := gate.admit("erapi", "USD"); err != nil {
+		t.Errorf("admit() after enable = %v, want nil", err)
+	}
+
+	want := []string{"enabled->disabled", "disabled->standby", "standby->enabled", "enabled->disabled", "disabled->enabled"}
+	transitions := gate.transitionLog()
+	if len(transitions) != len(want) {
+		t.Fatalf("transitionLog() = %+v, want %d transitions", transitions, len(want))
+	}
+	for i, transition := range transitions {
+		if got := transition.From + "->" + transition.To; got != want[len(want)-1-i] {
+			t.Errorf("transitionLog()[%d] = %s, want %s", i, got, want[len(want)-1-i])
+		}
+	}
+}
+
+func TestRatesService_ProbeStandby(t *testing.T) {
+	recovered := &testutils.FakeProvider{Name: "recovered", Enabled: true, Priority: 1, Rates: map[string]float64{"EUR": 0.9}}
+	broken := &testutils.FakeProvider{Name: "broken", Enabled: true, Priority: 2, Err: errors.New("no such host")}
+	ratesService := &RatesService{
+		configuration: testutils.MockConfig(),
+		logger:        testutils.MockLogger(),
+		providers:     []ExchangeRateProvider{recovered, broken},
+		gate:          newProviderGate(testutils.MockLogger(), 2),
+	}
+
+	if err := ratesService.DisableProvider("missing", true, ""); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("DisableProvider() of an unknown provider = %v, want %v", err, ErrUnknownProvider)
+	}
+	for _, name := range []string{"recovered", "broken"} {
+		if err := ratesService.DisableProvider(name, true, "incident"); err != nil {
+			t.Fatalf("DisableProvider(%s) error = %v", name, err)
+		}
+	}
+
+	ratesService.probeStandby(context.Background())
+	ratesService.probeStandby(context.Background())
+
+	statuses := ratesService.GetProviderStatus()
+	if statuses[0].Disabled || statuses[0].Standby {
+		t.Errorf("recovered provider status = %+v, want enabled after 2 successful probes", statuses[0])
+	}
+	if !statuses[1].Disabled || !statuses[1].Standby || statuses[1].DisabledBy != "operator" {
+		t.Errorf("broken provider status = %+v, want still in standby", statuses[1])
+	}
+	if transitions := ratesService.ProviderTransitions(); len(transitions) != 3 || transitions[0].Provider != "recovered" {
+		t.Errorf("ProviderTransitions() = %+v, want the recovery first", transitions)
+	}
+}
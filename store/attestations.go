@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// RecordAttestation keeps an attestation; attestations are never updated or deleted
+func (store *Store) RecordAttestation(ctx context.Context, attestation models.Attestation) error {
+	_, err := store.db.ExecContext(ctx,
+		"INSERT INTO rate_attestations (id, tenant, issued_at, payload, jws, key_id) VALUES ($1, $2, $3, $4, $5, $6)",
+		attestation.ID, attestation.Tenant, attestation.IssuedAt.UTC(), attestation.Payload, attestation.JWS, attestation.KeyID)
+	if err != nil {
+		return fmt.Errorf("failed to record attestation: %w", err)
+	}
+	return nil
+}
+
+// Attestation returns the attestation with the ID, and false when there is none. Its
+// claims are left for the caller to decode from the payload.
+func (store *Store) Attestation(ctx context.Context, id string) (models.Attestation, bool, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT id, tenant, issued_at, payload, jws, key_id FROM rate_attestations WHERE id = $1", id)
+	if err != nil {
+		return models.Attestation{}, false, fmt.Errorf("failed to read attestation: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.Attestation{}, false, rows.Err()
+	}
+	var attestation models.Attestation
+	if err := rows.Scan(&attestation.ID, &attestation.Tenant, &attestation.IssuedAt, &attestation.Payload, &attestation.JWS, &attestation.KeyID); err != nil {
+		return models.Attestation{}, false, fmt.Errorf("failed to read attestation: %w", err)
+	}
+	attestation.IssuedAt = attestation.IssuedAt.UTC()
+	return attestation, true, nil
+}
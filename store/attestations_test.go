@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestStore_RecordAttestation(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+	issuedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	err := store.RecordAttestation(context.Background(), models.Attestation{
+		ID: "a1", Tenant: "acme", IssuedAt: issuedAt, Payload: "e30", JWS: "h..s", KeyID: "k1",
+	})
+	if err != nil {
+		t.Fatalf("RecordAttestation() error = %v", err)
+	}
+	if len(database.statements) != 1 || !strings.HasPrefix(database.statements[0], "INSERT INTO rate_attestations") {
+		t.Fatalf("RecordAttestation() statements = %v", database.statements)
+	}
+	if args := database.args[0]; len(args) != 6 || args[0] != "a1" || args[1] != "acme" || args[4] != "h..s" {
+		t.Errorf("RecordAttestation() args = %v", args)
+	}
+}
+
+func TestStore_Attestation(t *testing.T) {
+	issuedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	database := &fakeDatabase{rows: [][]driver.Value{{"a1", "acme", issuedAt, "e30", "h..s", "k1"}}}
+	store := openFakeStore(t, database)
+
+	attestation, found, err := store.Attestation(context.Background(), "a1")
+	if err != nil || !found {
+		t.Fatalf("Attestation() = %v, %v", found, err)
+	}
+	want := models.Attestation{ID: "a1", Tenant: "acme", IssuedAt: issuedAt, Payload: "e30", JWS: "h..s", KeyID: "k1"}
+	if attestation != want {
+		t.Errorf("Attestation() = %+v, want %+v", attestation, want)
+	}
+
+	database.rows = nil
+	if _, found, err := store.Attestation(context.Background(), "missing"); found || err != nil {
+		t.Errorf("Attestation() of a missing ID = %v, %v, want not found", found, err)
+	}
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// compactionTimeout bounds a single compaction run
+const compactionTimeout = 5 * time.Minute
+
+// rollupHourly upserts hourly OHLC rollups for complete hours of raw snapshots, starting
+// at the newest existing rollup so the last hour is recomputed and no hour is skipped
+const rollupHourly = `INSERT INTO rate_rollups_hourly (base, currency, bucket, open, high, low, close, samples)
+SELECT base, currency, date_trunc('hour', fetched_at, 'UTC') AS hour,
+       (array_agg(rate ORDER BY fetched_at))[1], MAX(rate), MIN(rate),
+       (array_agg(rate ORDER BY fetched_at DESC))[1], COUNT(*)
+FROM rate_snapshots
+WHERE fetched_at >= (SELECT COALESCE(MAX(bucket), '-infinity') FROM rate_rollups_hourly)
+  AND fetched_at < $1
+GROUP BY base, currency, hour
+ON CONFLICT (base, currency, bucket) DO UPDATE SET
+    open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low,
+    close = EXCLUDED.close, samples = EXCLUDED.samples`
+
+// rollupDaily upserts daily OHLC rollups for complete days of hourly rollups
+const rollupDaily = `INSERT INTO rate_rollups_daily (base, currency, bucket, open, high, low, close, samples)
+SELECT base, currency, date_trunc('day', bucket, 'UTC') AS day,
+       (array_agg(open ORDER BY bucket))[1], MAX(high), MIN(low),
+       (array_agg(close ORDER BY bucket DESC))[1], SUM(samples)
+FROM rate_rollups_hourly
+WHERE bucket >= (SELECT COALESCE(MAX(bucket), '-infinity') FROM rate_rollups_daily)
+  AND bucket < $1
+GROUP BY base, currency, day
+ON CONFLICT (base, currency, bucket) DO UPDATE SET
+    open = EXCLUDED.open, high = EXCLUDED.high, low = EXCLUDED.low,
+    close = EXCLUDED.close, samples = EXCLUDED.samples`
+
+// compactionStats accumulates compactor results for /stats
+type compactionStats struct {
+	mutex sync.Mutex
+	stats models.CompactionStats
+}
+
+// Compact rolls raw snapshots up into hourly and daily OHLC rows, then prunes each
+// resolution past its retention. Rows are only pruned once they have been rolled up.
+func (store *Store) Compact(ctx context.Context, retention config.HistoryConfig, now time.Time) (models.CompactionResult, error) {
+	now = now.UTC()
+	hourStart := now.Truncate(time.Hour)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	result := models.CompactionResult{}
+
+	var err error
+	if result.HourlyRolledUp, err = store.exec(ctx, rollupHourly, hourStart); err != nil {
+		return result, fmt.Errorf("hourly rollup failed: %w", err)
+	}
+	if result.DailyRolledUp, err = store.exec(ctx, rollupDaily, dayStart); err != nil {
+		return result, fmt.Errorf("daily rollup failed: %w", err)
+	}
+
+	if retention.RawRetention > 0 {
+		cutoff := earliest(now.Add(-retention.RawRetention), hourStart)
+		if result.RawPruned, err = store.exec(ctx, "DELETE FROM rate_snapshots WHERE fetched_at < $1", cutoff); err != nil {
+			return result, fmt.Errorf("pruning raw snapshots failed: %w", err)
+		}
+	}
+	if retention.HourlyRetention > 0 {
+		cutoff := earliest(now.Add(-retention.HourlyRetention), dayStart)
+		if result.HourlyPruned, err = store.exec(ctx, "DELETE FROM rate_rollups_hourly WHERE bucket < $1", cutoff); err != nil {
+			return result, fmt.Errorf("pruning hourly rollups failed: %w", err)
+		}
+	}
+	if retention.DailyRetention > 0 {
+		if result.DailyPruned, err = store.exec(ctx, "DELETE FROM rate_rollups_daily WHERE bucket < $1", now.Add(-retention.DailyRetention)); err != nil {
+			return result, fmt.Errorf("pruning daily rollups failed: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// StartCompactor runs Compact every CompactInterval until ctx is cancelled
+func (store *Store) StartCompactor(ctx context.Context, retention config.HistoryConfig) {
+	if retention.CompactInterval <= 0 {
+		store.logger.Info("History compaction disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(retention.CompactInterval)
+		defer ticker.Stop()
+		for {
+			store.runCompaction(ctx, retention)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// CompactionStats returns the totals and last outcome of the background compactor
+func (store *Store) CompactionStats() models.CompactionStats {
+	store.compaction.mutex.Lock()
+	defer store.compaction.mutex.Unlock()
+	return store.compaction.stats
+}
+
+// runCompaction runs one compaction and records its outcome
+func (store *Store) runCompaction(ctx context.Context, retention config.HistoryConfig) {
+	ctx, cancel := context.WithTimeout(ctx, compactionTimeout)
+	defer cancel()
+
+	now := time.Now()
+	result, err := store.Compact(ctx, retention, now)
+
+	store.compaction.mutex.Lock()
+	defer store.compaction.mutex.Unlock()
+	stats := &store.compaction.stats
+	stats.Runs++
+	stats.LastRunAt = now
+	stats.LastResult = result
+	stats.LastError = ""
+	stats.RawPruned += result.RawPruned
+	stats.HourlyPruned += result.HourlyPruned
+	stats.DailyPruned += result.DailyPruned
+	if err != nil {
+		stats.Failures++
+		stats.LastError = err.Error()
+		store.logger.Errorf("History compaction failed: %v", err)
+		return
+	}
+	store.logger.Infof("History compaction pruned %d raw, %d hourly and %d daily rows",
+		result.RawPruned, result.HourlyPruned, result.DailyPruned)
+}
+
+// exec runs a statement and returns the number of rows it affected
+func (store *Store) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	result, err := store.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// earliest returns the earlier of two times
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
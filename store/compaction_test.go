@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func TestStore_Compact(t *testing.T) {
+	now := time.Date(2024, 3, 10, 14, 25, 0, 0, time.UTC)
+	hourStart := time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC)
+	dayStart := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		retention  config.HistoryConfig
+		wantPrefix []string
+		wantCutoff []time.Time
+	}{
+		{
+			name:      "default retention",
+			retention: config.HistoryConfig{RawRetention: 30 * 24 * time.Hour, HourlyRetention: 365 * 24 * time.Hour},
+			wantPrefix: []string{
+				"INSERT INTO rate_rollups_hourly",
+				"INSERT INTO rate_rollups_daily",
+				"DELETE FROM rate_snapshots",
+				"DELETE FROM rate_rollups_hourly",
+			},
+			wantCutoff: []time.Time{hourStart, dayStart, now.Add(-30 * 24 * time.Hour), now.Add(-365 * 24 * time.Hour)},
+		},
+		{
+			// Retention shorter than a bucket never prunes rows that were not rolled up yet
+			name:      "short retention",
+			retention: config.HistoryConfig{RawRetention: time.Minute, HourlyRetention: time.Hour, DailyRetention: 24 * time.Hour},
+			wantPrefix: []string{
+				"INSERT INTO rate_rollups_hourly",
+				"INSERT INTO rate_rollups_daily",
+				"DELETE FROM rate_snapshots",
+				"DELETE FROM rate_rollups_hourly",
+				"DELETE FROM rate_rollups_daily",
+			},
+			wantCutoff: []time.Time{hourStart, dayStart, hourStart, dayStart, now.Add(-24 * time.Hour)},
+		},
+		{
+			name:       "keep everything",
+			retention:  config.HistoryConfig{},
+			wantPrefix: []string{"INSERT INTO rate_rollups_hourly", "INSERT INTO rate_rollups_daily"},
+			wantCutoff: []time.Time{hourStart, dayStart},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := &fakeDatabase{affected: 3}
+			store := openFakeStore(t, database)
+
+			result, err := store.Compact(context.Background(), tt.retention, now)
+			if err != nil {
+				t.Fatalf("Compact() error = %v", err)
+			}
+			if len(database.statements) != len(tt.wantPrefix) {
+				t.Fatalf("Compact() ran %d statements, want %d", len(database.statements), len(tt.wantPrefix))
+			}
+			for i, prefix := range tt.wantPrefix {
+				if !strings.HasPrefix(database.statements[i], prefix) {
+					t.Errorf("statement %d = %q, want prefix %q", i, database.statements[i], prefix)
+				}
+				if cutoff := database.args[i][0].(time.Time); !cutoff.Equal(tt.wantCutoff[i]) {
+					t.Errorf("statement %d cutoff = %v, want %v", i, cutoff, tt.wantCutoff[i])
+				}
+			}
+			if result.HourlyRolledUp != 3 || result.DailyRolledUp != 3 {
+				t.Errorf("Compact() result = %+v, want rollup counts from the database", result)
+			}
+		})
+	}
+}
+
+func TestStore_RunCompaction_Stats(t *testing.T) {
+	database := &fakeDatabase{affected: 2}
+	store := openFakeStore(t, database)
+	retention := config.HistoryConfig{RawRetention: time.Hour}
+
+	store.runCompaction(context.Background(), retention)
+	store.runCompaction(context.Background(), retention)
+
+	stats := store.CompactionStats()
+	if stats.Runs != 2 || stats.RawPruned != 4 || stats.HourlyPruned != 0 || stats.Failures != 0 {
+		t.Errorf("CompactionStats() = %+v, want 2 runs pruning 4 raw rows", stats)
+	}
+
+	database.failOn = "rate_rollups_daily"
+	store.runCompaction(context.Background(), retention)
+	stats = store.CompactionStats()
+	if stats.Failures != 1 || !strings.Contains(stats.LastError, "daily rollup failed") {
+		t.Errorf("CompactionStats() after failure = %+v", stats)
+	}
+}
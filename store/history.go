@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// RecordRates stores one raw snapshot row per currency of a fetched rates table
+func (store *Store) RecordRates(ctx context.Context, exchangeRates models.RatesResponse) error {
+	if len(exchangeRates.Rates) == 0 {
+		return nil
+	}
+
+	fetchedAt := time.Now().UTC()
+	if exchangeRates.FetchedAt > 0 {
+		fetchedAt = time.Unix(exchangeRates.FetchedAt, 0).UTC()
+	}
+	var publishedAt *time.Time
+	if exchangeRates.PublishedAt > 0 {
+		published := time.Unix(exchangeRates.PublishedAt, 0).UTC()
+		publishedAt = &published
+	}
+
+	currencies := make([]string, 0, len(exchangeRates.Rates))
+	for currency := range exchangeRates.Rates {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO rate_snapshots (base, currency, rate, provider, published_at, fetched_at) VALUES ")
+	args := make([]any, 0, len(currencies)*6)
+	for i, currency := range currencies {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, exchangeRates.Base, currency, exchangeRates.Rates[currency], exchangeRates.Provider, publishedAt, fetchedAt)
+	}
+
+	if _, err := store.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to record %s rates: %w", exchangeRates.Base, err)
+	}
+	return nil
+}
+
+// RecordDailyRates stores a rates table as the daily rollup of a UTC day, for history
+// imported from providers. Existing rollups are kept, so imports never overwrite rates
+// the service recorded itself.
+func (store *Store) RecordDailyRates(ctx context.Context, exchangeRates models.RatesResponse, day time.Time) (int64, error) {
+	if len(exchangeRates.Rates) == 0 {
+		return 0, nil
+	}
+	bucket := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	currencies := make([]string, 0, len(exchangeRates.Rates))
+	for currency := range exchangeRates.Rates {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO rate_rollups_daily (base, currency, bucket, open, high, low, close, samples) VALUES ")
+	args := make([]any, 0, len(currencies)*4)
+	for i, currency := range currencies {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, 1)", n+1, n+2, n+3, n+4, n+4, n+4, n+4)
+		args = append(args, exchangeRates.Base, currency, bucket, exchangeRates.Rates[currency])
+	}
+	query.WriteString(" ON CONFLICT (base, currency, bucket) DO NOTHING")
+
+	inserted, err := store.exec(ctx, query.String(), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record %s rates for %s: %w", exchangeRates.Base, bucket.Format("2006-01-02"), err)
+	}
+	return inserted, nil
+}
+
+// StoredDays returns the UTC days between from and to (inclusive) that already have a
+// daily rollup for the base, keyed by YYYY-MM-DD
+func (store *Store) StoredDays(ctx context.Context, base string, from, to time.Time) (map[string]bool, error) {
+	rows, err := store.db.QueryContext(ctx,
+		"SELECT DISTINCT bucket FROM rate_rollups_daily WHERE base = $1 AND bucket >= $2 AND bucket <= $3",
+		base, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored days: %w", err)
+	}
+	defer rows.Close()
+
+	days := make(map[string]bool)
+	for rows.Next() {
+		var bucket time.Time
+		if err := rows.Scan(&bucket); err != nil {
+			return nil, fmt.Errorf("failed to read stored days: %w", err)
+		}
+		days[bucket.UTC().Format("2006-01-02")] = true
+	}
+	return days, rows.Err()
+}
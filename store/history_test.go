@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestStore_RecordRates(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+
+	err := store.RecordRates(context.Background(), models.RatesResponse{
+		Base:      "USD",
+		Provider:  "frankfurter",
+		Rates:     models.RateTable{"GBP": 0.79, "EUR": 0.92},
+		FetchedAt: 1700000000,
+	})
+	if err != nil {
+		t.Fatalf("RecordRates() error = %v", err)
+	}
+
+	if len(database.statements) != 1 || !strings.HasPrefix(database.statements[0], "INSERT INTO rate_snapshots") {
+		t.Fatalf("RecordRates() statements = %v, want one insert", database.statements)
+	}
+	args := database.args[0]
+	if len(args) != 12 {
+		t.Fatalf("RecordRates() args = %d, want 12", len(args))
+	}
+	// Rows are ordered by currency; the unknown publish time is stored as NULL
+	if args[1] != "EUR" || args[2] != 0.92 || args[4] != nil || !args[5].(time.Time).Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("RecordRates() first row = %v", args[:6])
+	}
+	if args[7] != "GBP" {
+		t.Errorf("RecordRates() second row currency = %v, want GBP", args[7])
+	}
+}
+
+func TestStore_RecordRates_Empty(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+
+	if err := store.RecordRates(context.Background(), models.RatesResponse{Base: "USD"}); err != nil {
+		t.Fatalf("RecordRates() error = %v", err)
+	}
+	if len(database.statements) != 0 {
+		t.Errorf("RecordRates() with no rates ran %v", database.statements)
+	}
+}
+
+func TestStore_RecordDailyRates(t *testing.T) {
+	database := &fakeDatabase{affected: 2}
+	store := openFakeStore(t, database)
+
+	day := time.Date(2024, 1, 15, 16, 0, 0, 0, time.UTC)
+	inserted, err := store.RecordDailyRates(context.Background(), models.RatesResponse{
+		Base:  "EUR",
+		Rates: models.RateTable{"USD": 1.09, "GBP": 0.86},
+	}, day)
+	if err != nil {
+		t.Fatalf("RecordDailyRates() error = %v", err)
+	}
+	if inserted != 2 {
+		t.Errorf("RecordDailyRates() inserted = %v, want 2", inserted)
+	}
+
+	query := database.statements[0]
+	if !strings.HasPrefix(query, "INSERT INTO rate_rollups_daily") || !strings.HasSuffix(query, "DO NOTHING") {
+		t.Errorf("RecordDailyRates() query = %q, want a daily rollup insert that keeps existing rows", query)
+	}
+	args := database.args[0]
+	if args[1] != "GBP" || !args[2].(time.Time).Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) || args[3] != 0.86 {
+		t.Errorf("RecordDailyRates() first row = %v, want GBP at the start of the day", args[:4])
+	}
+}
+
+func TestStore_StoredDays(t *testing.T) {
+	database := &fakeDatabase{rows: [][]driver.Value{
+		{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)},
+	}}
+	store := openFakeStore(t, database)
+
+	days, err := store.StoredDays(context.Background(), "USD", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("StoredDays() error = %v", err)
+	}
+	if len(days) != 2 || !days["2024-01-02"] || !days["2024-01-04"] || days["2024-01-03"] {
+		t.Errorf("StoredDays() = %v, want 2024-01-02 and 2024-01-04", days)
+	}
+}
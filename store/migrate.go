@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles holds the versioned schema migrations, named NNNN_description.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one versioned schema change
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrations returns the embedded migrations ordered by version
+func migrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		prefix, _, found := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q: use NNNN_description.sql", entry.Name())
+		}
+
+		contents, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, migration{version: version, name: entry.Name(), sql: string(contents)})
+	}
+
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].version < loaded[j].version })
+	for i := 1; i < len(loaded); i++ {
+		if loaded[i].version == loaded[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", loaded[i].version)
+		}
+	}
+	return loaded, nil
+}
+
+// LatestSchemaVersion returns the version of the newest embedded migration
+func LatestSchemaVersion() int {
+	loaded, err := migrations()
+	if err != nil || len(loaded) == 0 {
+		return 0
+	}
+	return loaded[len(loaded)-1].version
+}
+
+// Migrate applies every pending migration, each in its own transaction, and returns
+// the number applied
+func (store *Store) Migrate(ctx context.Context) (int, error) {
+	loaded, err := migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := store.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`); err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := store.SchemaVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, pending := range loaded {
+		if pending.version <= current {
+			continue
+		}
+		if err := store.apply(ctx, pending); err != nil {
+			return applied, err
+		}
+		store.logger.Infof("Applied migration %s", pending.name)
+		applied++
+	}
+	return applied, nil
+}
+
+// SchemaVersion returns the highest applied migration version (0 when none)
+func (store *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := store.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// apply runs one migration and records its version atomically
+func (store *Store) apply(ctx context.Context, pending migration) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %s: %w", pending.name, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, pending.sql); err != nil {
+		return fmt.Errorf("migration %s failed: %w", pending.name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", pending.version); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", pending.name, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMigrations_Embedded(t *testing.T) {
+	loaded, err := migrations()
+	if err != nil {
+		t.Fatalf("migrations() error = %v", err)
+	}
+	if len(loaded) == 0 || loaded[0].version != 1 {
+		t.Fatalf("migrations() = %v, want versions starting at 1", loaded)
+	}
+	if LatestSchemaVersion() != loaded[len(loaded)-1].version {
+		t.Errorf("LatestSchemaVersion() = %v, want %v", LatestSchemaVersion(), loaded[len(loaded)-1].version)
+	}
+}
+
+func TestStore_Migrate(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+
+	applied, err := store.Migrate(context.Background())
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if applied != LatestSchemaVersion() {
+		t.Errorf("Migrate() applied = %v, want %v", applied, LatestSchemaVersion())
+	}
+	// schema_migrations is created and read before the first migration runs
+	if !strings.Contains(database.statements[2], "CREATE TABLE rate_snapshots") {
+		t.Errorf("first migration statement = %q, want rate_snapshots table", database.statements[2])
+	}
+
+	version, err := store.SchemaVersion(context.Background())
+	if err != nil || version != LatestSchemaVersion() {
+		t.Errorf("SchemaVersion() = %v, %v, want %v", version, err, LatestSchemaVersion())
+	}
+
+	// A second run finds nothing pending
+	applied, err = store.Migrate(context.Background())
+	if err != nil || applied != 0 {
+		t.Errorf("second Migrate() = %v, %v, want 0 applied", applied, err)
+	}
+}
+
+func TestStore_Migrate_Failure(t *testing.T) {
+	database := &fakeDatabase{failOn: "CREATE TABLE rate_snapshots"}
+	store := openFakeStore(t, database)
+
+	if _, err := store.Migrate(context.Background()); err == nil {
+		t.Fatal("Migrate() expected error when a migration fails")
+	}
+	if len(database.versions) != 0 {
+		t.Errorf("failed migration recorded versions %v, want none", database.versions)
+	}
+}
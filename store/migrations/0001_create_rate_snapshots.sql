@@ -0,0 +1,11 @@
+CREATE TABLE rate_snapshots (
+    id           BIGSERIAL PRIMARY KEY,
+    base         TEXT             NOT NULL,
+    currency     TEXT             NOT NULL,
+    rate         DOUBLE PRECISION NOT NULL,
+    provider     TEXT             NOT NULL,
+    published_at TIMESTAMPTZ,
+    fetched_at   TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX rate_snapshots_pair_time ON rate_snapshots (base, currency, fetched_at);
@@ -0,0 +1,25 @@
+CREATE TABLE rate_rollups_hourly (
+    base     TEXT             NOT NULL,
+    currency TEXT             NOT NULL,
+    bucket   TIMESTAMPTZ      NOT NULL,
+    open     DOUBLE PRECISION NOT NULL,
+    high     DOUBLE PRECISION NOT NULL,
+    low      DOUBLE PRECISION NOT NULL,
+    close    DOUBLE PRECISION NOT NULL,
+    samples  BIGINT           NOT NULL,
+    PRIMARY KEY (base, currency, bucket)
+);
+
+CREATE TABLE rate_rollups_daily (
+    base     TEXT             NOT NULL,
+    currency TEXT             NOT NULL,
+    bucket   TIMESTAMPTZ      NOT NULL,
+    open     DOUBLE PRECISION NOT NULL,
+    high     DOUBLE PRECISION NOT NULL,
+    low      DOUBLE PRECISION NOT NULL,
+    close    DOUBLE PRECISION NOT NULL,
+    samples  BIGINT           NOT NULL,
+    PRIMARY KEY (base, currency, bucket)
+);
+
+CREATE INDEX rate_snapshots_fetched_at ON rate_snapshots (fetched_at);
@@ -0,0 +1,12 @@
+CREATE TABLE api_usage (
+    bucket         TIMESTAMPTZ      NOT NULL,
+    tenant         TEXT             NOT NULL,
+    key_id         TEXT             NOT NULL,
+    endpoint       TEXT             NOT NULL,
+    requests       BIGINT           NOT NULL,
+    errors         BIGINT           NOT NULL,
+    bytes          BIGINT           NOT NULL,
+    latency_ms     DOUBLE PRECISION NOT NULL,
+    max_latency_ms DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (bucket, tenant, key_id, endpoint)
+);
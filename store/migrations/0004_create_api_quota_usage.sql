@@ -0,0 +1,6 @@
+CREATE TABLE api_quota_usage (
+    day      DATE   NOT NULL,
+    key_id   TEXT   NOT NULL,
+    requests BIGINT NOT NULL,
+    PRIMARY KEY (day, key_id)
+);
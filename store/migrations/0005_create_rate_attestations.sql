@@ -0,0 +1,8 @@
+CREATE TABLE rate_attestations (
+    id        TEXT        PRIMARY KEY,
+    tenant    TEXT        NOT NULL,
+    issued_at TIMESTAMPTZ NOT NULL,
+    payload   TEXT        NOT NULL,
+    jws       TEXT        NOT NULL,
+    key_id    TEXT        NOT NULL
+);
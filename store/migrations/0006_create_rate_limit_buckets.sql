@@ -0,0 +1,10 @@
+CREATE TABLE rate_limit_buckets (
+    client_key  TEXT        PRIMARY KEY,
+    tokens      INTEGER     NOT NULL,
+    capacity    INTEGER     NOT NULL,
+    refill_rate INTEGER     NOT NULL,
+    last_refill TIMESTAMPTZ NOT NULL,
+    last_seen   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_last_seen ON rate_limit_buckets (last_seen);
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// RecordQuotaUsage adds daily request counts to the stored ones, so counts flushed for
+// the same day accumulate
+func (store *Store) RecordQuotaUsage(ctx context.Context, counts []models.QuotaCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO api_quota_usage (day, key_id, requests) VALUES ")
+	args := make([]any, 0, len(counts)*3)
+	for i, count := range counts {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d)", n+1, n+2, n+3)
+		args = append(args, count.Day.UTC(), count.KeyID, count.Requests)
+	}
+	query.WriteString(" ON CONFLICT (day, key_id) DO UPDATE SET requests = api_quota_usage.requests + EXCLUDED.requests")
+
+	if _, err := store.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to record quota usage: %w", err)
+	}
+	return nil
+}
+
+// QuotaUsage returns the daily request counts of every key from since onwards
+func (store *Store) QuotaUsage(ctx context.Context, since time.Time) ([]models.QuotaCount, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT day, key_id, requests FROM api_quota_usage WHERE day >= $1 ORDER BY day, key_id", since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quota usage: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.QuotaCount{}
+	for rows.Next() {
+		var count models.QuotaCount
+		if err := rows.Scan(&count.Day, &count.KeyID, &count.Requests); err != nil {
+			return nil, fmt.Errorf("failed to read quota usage: %w", err)
+		}
+		count.Day = count.Day.UTC()
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
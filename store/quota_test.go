@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestStore_RecordQuotaUsage(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	err := store.RecordQuotaUsage(context.Background(), []models.QuotaCount{
+		{Day: day, KeyID: "k1", Requests: 3},
+		{Day: day, KeyID: "k2", Requests: 1},
+	})
+	if err != nil {
+		t.Fatalf("RecordQuotaUsage() error = %v", err)
+	}
+	if len(database.statements) != 1 || !strings.Contains(database.statements[0], "ON CONFLICT (day, key_id) DO UPDATE") {
+		t.Fatalf("RecordQuotaUsage() statements = %v, want one accumulating upsert", database.statements)
+	}
+	if args := database.args[0]; len(args) != 6 || args[1] != "k1" || args[2] != int64(3) || args[4] != "k2" {
+		t.Errorf("RecordQuotaUsage() args = %v", args)
+	}
+
+	if err := store.RecordQuotaUsage(context.Background(), nil); err != nil || len(database.statements) != 1 {
+		t.Errorf("RecordQuotaUsage(nil) = %v, want no statement", err)
+	}
+}
+
+func TestStore_QuotaUsage(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := since.AddDate(0, 0, 4)
+	database := &fakeDatabase{rows: [][]driver.Value{{day, "k1", int64(42)}}}
+	store := openFakeStore(t, database)
+
+	counts, err := store.QuotaUsage(context.Background(), since)
+	if err != nil {
+		t.Fatalf("QuotaUsage() error = %v", err)
+	}
+	want := models.QuotaCount{Day: day, KeyID: "k1", Requests: 42}
+	if len(counts) != 1 || counts[0] != want {
+		t.Errorf("QuotaUsage() = %+v, want %+v", counts, want)
+	}
+	if args := database.args[0]; !args[0].(time.Time).Equal(since) {
+		t.Errorf("QuotaUsage() args = %v", args)
+	}
+}
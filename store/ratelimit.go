@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// rateLimitBatch bounds the buckets written by one statement, keeping its parameters
+// under the PostgreSQL limit
+const rateLimitBatch = 1000
+
+// SaveRateLimitBuckets stores the rate limit buckets, keeping the most recently seen
+// bucket of a client saved by several instances, and drops the buckets last seen before
+// expiredBefore
+func (store *Store) SaveRateLimitBuckets(ctx context.Context, buckets []models.RateLimitBucket, expiredBefore time.Time) error {
+	for start := 0; start < len(buckets); start += rateLimitBatch {
+		batch := buckets[start:min(start+rateLimitBatch, len(buckets))]
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO rate_limit_buckets (client_key, tokens, capacity, refill_rate, last_refill, last_seen) VALUES ")
+		args := make([]any, 0, len(batch)*6)
+		for i, bucket := range batch {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6)
+			args = append(args, bucket.Key, bucket.Tokens, bucket.Capacity, bucket.RefillRate, bucket.LastRefill.UTC(), bucket.LastSeen.UTC())
+		}
+		query.WriteString(` ON CONFLICT (client_key) DO UPDATE SET
+    tokens = EXCLUDED.tokens, capacity = EXCLUDED.capacity, refill_rate = EXCLUDED.refill_rate,
+    last_refill = EXCLUDED.last_refill, last_seen = EXCLUDED.last_seen
+WHERE rate_limit_buckets.last_seen <= EXCLUDED.last_seen`)
+
+		if _, err := store.db.ExecContext(ctx, query.String(), args...); err != nil {
+			return fmt.Errorf("failed to save rate limit buckets: %w", err)
+		}
+	}
+
+	if _, err := store.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE last_seen < $1", expiredBefore.UTC()); err != nil {
+		return fmt.Errorf("failed to drop expired rate limit buckets: %w", err)
+	}
+	return nil
+}
+
+// RateLimitBuckets returns the rate limit buckets seen from since onwards, most recently
+// seen first
+func (store *Store) RateLimitBuckets(ctx context.Context, since time.Time) ([]models.RateLimitBucket, error) {
+	rows, err := store.db.QueryContext(ctx, "SELECT client_key, tokens, capacity, refill_rate, last_refill, last_seen FROM rate_limit_buckets WHERE last_seen >= $1 ORDER BY last_seen DESC", since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate limit buckets: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []models.RateLimitBucket{}
+	for rows.Next() {
+		var bucket models.RateLimitBucket
+		if err := rows.Scan(&bucket.Key, &bucket.Tokens, &bucket.Capacity, &bucket.RefillRate, &bucket.LastRefill, &bucket.LastSeen); err != nil {
+			return nil, fmt.Errorf("failed to read rate limit buckets: %w", err)
+		}
+		bucket.LastRefill, bucket.LastSeen = bucket.LastRefill.UTC(), bucket.LastSeen.UTC()
+		buckets = append(buckets, bucket)
+	}
+	return buckets, rows.Err()
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestStore_SaveRateLimitBuckets(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	buckets := make([]models.RateLimitBucket, rateLimitBatch+1)
+	for i := range buckets {
+		buckets[i] = models.RateLimitBucket{Key: "10.0.0.1", Tokens: 2, Capacity: 5, RefillRate: 1, LastRefill: now, LastSeen: now}
+	}
+	if err := store.SaveRateLimitBuckets(context.Background(), buckets, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("SaveRateLimitBuckets() error = %v", err)
+	}
+	if len(database.statements) != 3 || !strings.Contains(database.statements[0], "WHERE rate_limit_buckets.last_seen <= EXCLUDED.last_seen") {
+		t.Fatalf("SaveRateLimitBuckets() statements = %v, want two batched upserts keeping the latest bucket", database.statements)
+	}
+	if args := database.args[1]; len(args) != 6 || args[0] != "10.0.0.1" || args[1] != int64(2) {
+		t.Errorf("SaveRateLimitBuckets() args = %v", args)
+	}
+	if !strings.HasPrefix(database.statements[2], "DELETE FROM rate_limit_buckets") || !database.args[2][0].(time.Time).Equal(now.Add(-time.Minute)) {
+		t.Errorf("SaveRateLimitBuckets() = %v %v, want expired buckets dropped", database.statements[2], database.args[2])
+	}
+}
+
+func TestStore_RateLimitBuckets(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	database := &fakeDatabase{rows: [][]driver.Value{{"10.0.0.1", int64(0), int64(5), int64(1), now, now}}}
+	store := openFakeStore(t, database)
+
+	buckets, err := store.RateLimitBuckets(context.Background(), now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("RateLimitBuckets() error = %v", err)
+	}
+	want := models.RateLimitBucket{Key: "10.0.0.1", Capacity: 5, RefillRate: 1, LastRefill: now, LastSeen: now}
+	if len(buckets) != 1 || buckets[0] != want {
+		t.Errorf("RateLimitBuckets() = %+v, want %+v", buckets, want)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+
+	"github.com/dalfonso89/currency-exchange-service/health"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+)
+
+// Store is the PostgreSQL persistence layer for rate history
+type Store struct {
+	db     *sql.DB
+	logger logger.Logger
+
+	compaction compactionStats
+}
+
+// Open connects to the database at the PostgreSQL connection URL
+func Open(databaseURL string, logger logger.Logger) (*Store, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return New(db, logger), nil
+}
+
+// New wraps an existing database handle
+func New(db *sql.DB, logger logger.Logger) *Store {
+	return &Store{db: db, logger: logger}
+}
+
+// Ping checks that the database is reachable
+func (store *Store) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// Close closes the database connection pool
+func (store *Store) Close() error {
+	return store.db.Close()
+}
+
+// Checks returns the startup checks for the database: connectivity, and that the
+// schema has every embedded migration applied
+func (store *Store) Checks() []health.Check {
+	return []health.Check{
+		{Name: "database", Kind: health.KindDatabase, Run: store.Ping},
+		{Name: "migrations", Kind: health.KindDatabase, Run: func(ctx context.Context) error {
+			current, err := store.SchemaVersion(ctx)
+			if err != nil {
+				return err
+			}
+			if latest := LatestSchemaVersion(); current < latest {
+				return fmt.Errorf("schema version %d is behind %d; run with -migrate", current, latest)
+			}
+			return nil
+		}},
+	}
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// fakeDatabase is the state behind the fake driver: executed statements and applied versions
+type fakeDatabase struct {
+	mutex      sync.Mutex
+	statements []string
+	args       [][]driver.Value
+	versions   []int64
+	failOn     string
+	affected   int64            // Rows affected reported by every statement
+	rows       [][]driver.Value // Rows returned by queries other than the schema version
+}
+
+var (
+	fakeDatabasesMutex sync.Mutex
+	fakeDatabases      = map[string]*fakeDatabase{}
+)
+
+func init() {
+	sql.Register("fakemigrate", fakeDriver{})
+}
+
+// openFakeStore returns a store backed by a fresh fake database
+func openFakeStore(t *testing.T, database *fakeDatabase) *Store {
+	t.Helper()
+	fakeDatabasesMutex.Lock()
+	fakeDatabases[t.Name()] = database
+	fakeDatabasesMutex.Unlock()
+
+	db, err := sql.Open("fakemigrate", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db, testutils.MockLogger())
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDatabasesMutex.Lock()
+	defer fakeDatabasesMutex.Unlock()
+	return &fakeConn{database: fakeDatabases[name]}, nil
+}
+
+type fakeConn struct{ database *fakeDatabase }
+
+func (conn *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{database: conn.database, query: query}, nil
+}
+func (conn *fakeConn) Close() error              { return nil }
+func (conn *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	database *fakeDatabase
+	query    string
+}
+
+func (stmt *fakeStmt) Close() error  { return nil }
+func (stmt *fakeStmt) NumInput() int { return -1 }
+
+func (stmt *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	database := stmt.database
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	if database.failOn != "" && strings.Contains(stmt.query, database.failOn) {
+		return nil, errors.New("syntax error")
+	}
+	database.statements = append(database.statements, stmt.query)
+	database.args = append(database.args, args)
+	if strings.HasPrefix(stmt.query, "INSERT INTO schema_migrations") {
+		database.versions = append(database.versions, args[0].(int64))
+	}
+	return driver.RowsAffected(database.affected), nil
+}
+
+func (stmt *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	database := stmt.database
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	database.statements = append(database.statements, stmt.query)
+	database.args = append(database.args, args)
+	if !strings.Contains(stmt.query, "schema_migrations") {
+		return &fakeRows{values: database.rows}, nil
+	}
+
+	var latest int64
+	for _, version := range database.versions {
+		latest = max(latest, version)
+	}
+	return &fakeRows{values: [][]driver.Value{{latest}}}, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+func (rows *fakeRows) Close() error { return nil }
+func (rows *fakeRows) Columns() []string {
+	if len(rows.values) == 0 {
+		return []string{"value"}
+	}
+	return make([]string, len(rows.values[0]))
+}
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if rows.next >= len(rows.values) {
+		return io.EOF
+	}
+	copy(dest, rows.values[rows.next])
+	rows.next++
+	return nil
+}
+
+func TestStore_Checks(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+	checks := store.Checks()
+
+	migrationsCheck := checks[1]
+	if err := migrationsCheck.Run(context.Background()); err == nil {
+		t.Error("migrations check expected error before migrating")
+	}
+
+	store.Migrate(context.Background())
+	for _, check := range checks {
+		if err := check.Run(context.Background()); err != nil {
+			t.Errorf("%s check error = %v", check.Name, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// hourlySeries selects hourly OHLC points for a pair: compacted rollups, followed by
+// points computed from the raw snapshots of hours the compactor has not reached yet.
+// $1 base, $2 currency, $3 from (inclusive), $4 to (exclusive).
+const hourlySeries = `SELECT bucket, open, high, low, close, samples
+FROM rate_rollups_hourly
+WHERE base = $1 AND currency = $2 AND bucket >= $3 AND bucket < $4
+UNION ALL
+SELECT date_trunc('hour', fetched_at, 'UTC'),
+       (array_agg(rate ORDER BY fetched_at))[1], MAX(rate), MIN(rate),
+       (array_agg(rate ORDER BY fetched_at DESC))[1], COUNT(*)
+FROM rate_snapshots
+WHERE base = $1 AND currency = $2 AND fetched_at < $4
+  AND fetched_at >= GREATEST($3, (SELECT COALESCE(MAX(bucket) + interval '1 hour', '-infinity')
+                                  FROM rate_rollups_hourly WHERE base = $1 AND currency = $2))
+GROUP BY 1`
+
+// timeSeriesQueries select the points of each supported interval, ordered by time
+var timeSeriesQueries = map[time.Duration]string{
+	time.Hour: hourlySeries + `
+ORDER BY 1`,
+
+	// Daily rollups, followed by days aggregated from the hourly points
+	24 * time.Hour: `WITH hourly AS (` + hourlySeries + `)
+SELECT bucket, open, high, low, close, samples
+FROM rate_rollups_daily
+WHERE base = $1 AND currency = $2 AND bucket >= $3 AND bucket < $4
+UNION ALL
+SELECT date_trunc('day', bucket, 'UTC'),
+       (array_agg(open ORDER BY bucket))[1], MAX(high), MIN(low),
+       (array_agg(close ORDER BY bucket DESC))[1], SUM(samples)
+FROM hourly
+WHERE bucket >= (SELECT COALESCE(MAX(bucket) + interval '1 day', '-infinity')
+                 FROM rate_rollups_daily WHERE base = $1 AND currency = $2)
+GROUP BY 1
+ORDER BY 1`,
+}
+
+// TimeSeries returns OHLC points for the base/currency pair between from (inclusive)
+// and to (exclusive), one per hour or day of stored history
+func (store *Store) TimeSeries(ctx context.Context, base, currency string, from, to time.Time, interval time.Duration) ([]models.TimeSeriesPoint, error) {
+	query, supported := timeSeriesQueries[interval]
+	if !supported {
+		return nil, fmt.Errorf("unsupported interval %s", interval)
+	}
+
+	rows, err := store.db.QueryContext(ctx, query, base, currency, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s/%s history: %w", base, currency, err)
+	}
+	defer rows.Close()
+
+	points := []models.TimeSeriesPoint{}
+	for rows.Next() {
+		var point models.TimeSeriesPoint
+		if err := rows.Scan(&point.Time, &point.Open, &point.High, &point.Low, &point.Close, &point.Samples); err != nil {
+			return nil, fmt.Errorf("failed to read %s/%s history: %w", base, currency, err)
+		}
+		point.Time = point.Time.UTC()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_TimeSeries(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		interval   time.Duration
+		wantSource string
+	}{
+		{name: "hourly", interval: time.Hour, wantSource: "FROM rate_rollups_hourly"},
+		{name: "daily", interval: 24 * time.Hour, wantSource: "FROM rate_rollups_daily"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := &fakeDatabase{rows: [][]driver.Value{
+				{from, 1.08, 1.10, 1.07, 1.09, int64(24)},
+				{from.Add(24 * time.Hour), 1.09, 1.11, 1.08, 1.10, int64(12)},
+			}}
+			store := openFakeStore(t, database)
+
+			points, err := store.TimeSeries(context.Background(), "USD", "EUR", from, to, tt.interval)
+			if err != nil {
+				t.Fatalf("TimeSeries() error = %v", err)
+			}
+			if len(points) != 2 || points[1].Close != 1.10 || points[1].Samples != 12 || !points[0].Time.Equal(from) {
+				t.Errorf("TimeSeries() = %+v, want the two stored points", points)
+			}
+
+			query := database.statements[0]
+			if !strings.Contains(query, tt.wantSource) || !strings.Contains(query, "FROM rate_snapshots") {
+				t.Errorf("TimeSeries() query does not combine %s with raw snapshots: %s", tt.wantSource, query)
+			}
+			if args := database.args[0]; args[0] != "USD" || args[1] != "EUR" || !args[2].(time.Time).Equal(from) || !args[3].(time.Time).Equal(to) {
+				t.Errorf("TimeSeries() args = %v", args)
+			}
+		})
+	}
+}
+
+func TestStore_TimeSeries_UnsupportedInterval(t *testing.T) {
+	store := openFakeStore(t, &fakeDatabase{})
+
+	if _, err := store.TimeSeries(context.Background(), "USD", "EUR", time.Now().Add(-time.Hour), time.Now(), time.Minute); err == nil {
+		t.Error("TimeSeries() expected error for a 1m interval")
+	}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// usageTotals sums the usage of each key and endpoint over a range of hourly buckets
+const usageTotals = `SELECT tenant, key_id, endpoint, SUM(requests), SUM(errors), SUM(bytes), SUM(latency_ms), MAX(max_latency_ms)
+FROM api_usage
+WHERE bucket >= $1 AND bucket < $2
+GROUP BY tenant, key_id, endpoint
+ORDER BY tenant, key_id, endpoint`
+
+// RecordUsage adds hourly usage counts to the stored ones, so records flushed for the
+// same hour accumulate
+func (store *Store) RecordUsage(ctx context.Context, records []models.UsageRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO api_usage (bucket, tenant, key_id, endpoint, requests, errors, bytes, latency_ms, max_latency_ms) VALUES ")
+	args := make([]any, 0, len(records)*9)
+	for i, record := range records {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+		args = append(args, record.Bucket.UTC(), record.Tenant, record.KeyID, record.Endpoint,
+			record.Requests, record.Errors, record.Bytes, record.LatencyMS, record.MaxLatencyMS)
+	}
+	query.WriteString(` ON CONFLICT (bucket, tenant, key_id, endpoint) DO UPDATE SET
+    requests = api_usage.requests + EXCLUDED.requests, errors = api_usage.errors + EXCLUDED.errors,
+    bytes = api_usage.bytes + EXCLUDED.bytes, latency_ms = api_usage.latency_ms + EXCLUDED.latency_ms,
+    max_latency_ms = GREATEST(api_usage.max_latency_ms, EXCLUDED.max_latency_ms)`)
+
+	if _, err := store.db.ExecContext(ctx, query.String(), args...); err != nil {
+		return fmt.Errorf("failed to record API usage: %w", err)
+	}
+	return nil
+}
+
+// Usage returns the usage of each key and endpoint in the hourly buckets between from
+// (inclusive) and to (exclusive)
+func (store *Store) Usage(ctx context.Context, from, to time.Time) ([]models.UsageRecord, error) {
+	rows, err := store.db.QueryContext(ctx, usageTotals, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API usage: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.UsageRecord{}
+	for rows.Next() {
+		var record models.UsageRecord
+		if err := rows.Scan(&record.Tenant, &record.KeyID, &record.Endpoint, &record.Requests, &record.Errors,
+			&record.Bytes, &record.LatencyMS, &record.MaxLatencyMS); err != nil {
+			return nil, fmt.Errorf("failed to read API usage: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+func TestStore_RecordUsage(t *testing.T) {
+	database := &fakeDatabase{}
+	store := openFakeStore(t, database)
+	bucket := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	err := store.RecordUsage(context.Background(), []models.UsageRecord{
+		{Bucket: bucket, Tenant: "acme", KeyID: "k1", Endpoint: "GET /api/v1/rates", Requests: 3, Errors: 1, Bytes: 300, LatencyMS: 30, MaxLatencyMS: 20},
+		{Bucket: bucket, Endpoint: "GET /api/v1/convert", Requests: 1, Bytes: 80, LatencyMS: 5, MaxLatencyMS: 5},
+	})
+	if err != nil {
+		t.Fatalf("RecordUsage() error = %v", err)
+	}
+	if len(database.statements) != 1 || !strings.Contains(database.statements[0], "ON CONFLICT (bucket, tenant, key_id, endpoint) DO UPDATE") {
+		t.Fatalf("RecordUsage() statements = %v, want one accumulating upsert", database.statements)
+	}
+	if args := database.args[0]; len(args) != 18 || args[1] != "acme" || args[4] != int64(3) || args[12] != "GET /api/v1/convert" {
+		t.Errorf("RecordUsage() args = %v", args)
+	}
+
+	if err := store.RecordUsage(context.Background(), nil); err != nil || len(database.statements) != 1 {
+		t.Errorf("RecordUsage(nil) = %v, want no statement", err)
+	}
+}
+
+func TestStore_Usage(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	database := &fakeDatabase{rows: [][]driver.Value{
+		{"acme", "k1", "GET /api/v1/rates", int64(3), int64(1), int64(300), 30.0, 20.0},
+	}}
+	store := openFakeStore(t, database)
+
+	records, err := store.Usage(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	want := models.UsageRecord{Tenant: "acme", KeyID: "k1", Endpoint: "GET /api/v1/rates", Requests: 3, Errors: 1, Bytes: 300, LatencyMS: 30, MaxLatencyMS: 20}
+	if len(records) != 1 || records[0] != want {
+		t.Errorf("Usage() = %+v, want %+v", records, want)
+	}
+	if args := database.args[0]; !args[0].(time.Time).Equal(from) || !args[1].(time.Time).Equal(to) {
+		t.Errorf("Usage() args = %v", args)
+	}
+}
@@ -0,0 +1,133 @@
+package stream
+
+import (
+	"fmt"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// Policy decides what happens when a connection's send buffer is full
+type Policy string
+
+const (
+	PolicyDropOldest Policy = "drop-oldest" // Drop the oldest queued update to make room
+	PolicyCoalesce   Policy = "coalesce"    // Replace a queued update of the same pair; drop the oldest otherwise
+	PolicyDisconnect Policy = "disconnect"  // Disconnect the slow client
+)
+
+// ParsePolicy validates a configured backpressure policy
+func ParsePolicy(value string) (Policy, error) {
+	switch Policy(value) {
+	case PolicyDropOldest, PolicyCoalesce, PolicyDisconnect:
+		return Policy(value), nil
+	default:
+		return "", fmt.Errorf("invalid stream backpressure policy %q: use drop-oldest, coalesce or disconnect", value)
+	}
+}
+
+// queueOutcome reports how an update was queued
+type queueOutcome int
+
+const (
+	outcomeQueued    queueOutcome = iota
+	outcomeCoalesced              // Replaced a queued update of the same pair
+	outcomeDropped                // Queued after dropping the oldest update
+	outcomeOverflow               // Not queued: the buffer is full and the client must be disconnected
+	outcomeClosed                 // Not queued: the connection is closed
+)
+
+// enqueue adds an update to the connection's bounded buffer under the policy, returning
+// the outcome and, for outcomeDropped, the update that was dropped
+func (connection *Connection) enqueue(update models.PairRateResponse, policy Policy, size int) (queueOutcome, models.PairRateResponse) {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	if connection.closed {
+		return outcomeClosed, models.PairRateResponse{}
+	}
+	defer connection.signal()
+
+	if policy == PolicyCoalesce {
+		for i, queued := range connection.queue {
+			if queued.Pair == update.Pair {
+				connection.queue[i] = update
+				return outcomeCoalesced, models.PairRateResponse{}
+			}
+		}
+	}
+	if len(connection.queue) < size {
+		connection.queue = append(connection.queue, update)
+		return outcomeQueued, models.PairRateResponse{}
+	}
+	if policy == PolicyDisconnect {
+		return outcomeOverflow, models.PairRateResponse{}
+	}
+
+	dropped := connection.queue[0]
+	copy(connection.queue, connection.queue[1:])
+	connection.queue[len(connection.queue)-1] = update
+	connection.dropped++
+	return outcomeDropped, dropped
+}
+
+// Take removes and returns the queued updates. open is false once the connection has
+// been closed, after which nothing is queued again.
+func (connection *Connection) Take() (updates []models.PairRateResponse, open bool) {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	updates = connection.queue
+	connection.queue = nil
+	return updates, !connection.closed
+}
+
+// Dropped returns how many of the connection's updates were dropped by backpressure
+func (connection *Connection) Dropped() int64 {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return connection.dropped
+}
+
+// ResyncRequested reports whether the client asked for a snapshot since the last call
+func (connection *Connection) ResyncRequested() bool {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	resync := connection.resync
+	connection.resync = false
+	return resync
+}
+
+// requestResync flags the connection for a snapshot and wakes its reader
+func (connection *Connection) requestResync() {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	connection.resync = true
+	connection.signal()
+}
+
+// queued returns the number of queued updates
+func (connection *Connection) queued() int {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+	return len(connection.queue)
+}
+
+// close marks the connection closed, discards its buffered updates and wakes its reader
+func (connection *Connection) close() {
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	connection.closed = true
+	connection.queue = nil
+	connection.signal()
+}
+
+// signal wakes the reader without blocking; one pending signal covers any number of updates
+func (connection *Connection) signal() {
+	select {
+	case connection.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,53 @@
+package stream
+
+import "github.com/dalfonso89/currency-exchange-service/models"
+
+// Events of delta-encoded streams
+const (
+	EventSnapshot = "snapshot" // The rates of every subscribed pair
+	EventDelta    = "delta"    // The rates that changed since the previous message
+)
+
+// DeltaEncoder turns the updates of one connection into delta-encoded messages. The
+// first message is a snapshot, and so is every snapshotEvery-th message after it, the
+// first message after updates were dropped by backpressure and the first after the client
+// asked to resync. Every other message is a delta of the pairs that changed.
+type DeltaEncoder struct {
+	snapshotEvery int // Messages between snapshots (0 = only when needed)
+
+	sequence      uint64
+	sinceSnapshot int
+	dropped       int64
+}
+
+// NewDeltaEncoder creates the encoder of a connection
+func NewDeltaEncoder(snapshotEvery int) *DeltaEncoder {
+	return &DeltaEncoder{snapshotEvery: max(snapshotEvery, 0)}
+}
+
+// Encode returns the event and message of the next batch of updates. dropped is the
+// connection's count of dropped updates and resync whether its client asked for a
+// snapshot; snapshot is called for the rates of every subscribed pair when one is due.
+// Nothing is encoded when no snapshot is due and no rate changed.
+func (encoder *DeltaEncoder) Encode(updates []models.PairRateResponse, dropped int64, resync bool, snapshot func() []models.PairRateResponse) (event string, message models.StreamDelta, encoded bool) {
+	event = EventDelta
+	if encoder.sequence == 0 || resync || dropped > encoder.dropped ||
+		(encoder.snapshotEvery > 0 && encoder.sinceSnapshot >= encoder.snapshotEvery) {
+		event, updates = EventSnapshot, snapshot()
+		encoder.sinceSnapshot = 0
+	} else if len(updates) == 0 {
+		return "", models.StreamDelta{}, false
+	}
+	encoder.dropped = dropped
+
+	encoder.sequence++
+	encoder.sinceSnapshot++
+	message = models.StreamDelta{Sequence: encoder.sequence, Rates: make(map[string]float64, len(updates))}
+	for _, update := range updates {
+		message.Rates[update.Pair] = update.Rate
+		if update.Timestamp >= message.Timestamp {
+			message.Timestamp, message.Provider = update.Timestamp, update.Provider
+		}
+	}
+	return event, message, true
+}
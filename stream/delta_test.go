@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+func TestDeltaEncoder(t *testing.T) {
+	snapshots := 0
+	snapshot := func() []models.PairRateResponse {
+		snapshots++
+		return []models.PairRateResponse{
+			{Pair: "EUR/USD", Rate: 1.25, Provider: "erapi", Timestamp: 100},
+			{Pair: "GBP/USD", Rate: 2, Provider: "erapi", Timestamp: 100},
+		}
+	}
+	changed := []models.PairRateResponse{{Pair: "GBP/USD", Rate: 2.5, Provider: "erapi", Timestamp: 200}}
+	encoder := NewDeltaEncoder(3)
+
+	steps := []struct {
+		name      string
+		updates   []models.PairRateResponse
+		dropped   int64
+		resync    bool
+		wantEvent string
+		wantRates map[string]float64
+	}{
+		{name: "first message", updates: changed, wantEvent: EventSnapshot, wantRates: map[string]float64{"EUR/USD": 1.25, "GBP/USD": 2}},
+		{name: "changed pair", updates: changed, wantEvent: EventDelta, wantRates: map[string]float64{"GBP/USD": 2.5}},
+		{name: "nothing changed"},
+		{name: "second delta", updates: changed, wantEvent: EventDelta, wantRates: map[string]float64{"GBP/USD": 2.5}},
+		{name: "every third message", updates: changed, wantEvent: EventSnapshot, wantRates: map[string]float64{"EUR/USD": 1.25, "GBP/USD": 2}},
+		{name: "after drops", updates: changed, dropped: 1, wantEvent: EventSnapshot, wantRates: map[string]float64{"EUR/USD": 1.25, "GBP/USD": 2}},
+		{name: "drops already resynced", updates: changed, dropped: 1, wantEvent: EventDelta, wantRates: map[string]float64{"GBP/USD": 2.5}},
+		{name: "resync without updates", dropped: 1, resync: true, wantEvent: EventSnapshot, wantRates: map[string]float64{"EUR/USD": 1.25, "GBP/USD": 2}},
+	}
+
+	var sequence uint64
+	for _, step := range steps {
+		event, message, encoded := encoder.Encode(step.updates, step.dropped, step.resync, snapshot)
+		if step.wantEvent == "" {
+			if encoded {
+				t.Errorf("%s: Encode() = %s %+v, want nothing", step.name, event, message)
+			}
+			continue
+		}
+		sequence++
+		if !encoded || event != step.wantEvent || message.Sequence != sequence || !reflect.DeepEqual(message.Rates, step.wantRates) {
+			t.Errorf("%s: Encode() = %s %+v, want %s #%d of %v", step.name, event, message, step.wantEvent, sequence, step.wantRates)
+		}
+	}
+	if snapshots != 4 {
+		t.Errorf("snapshots taken = %d, want 4", snapshots)
+	}
+}
+
+func TestHub_SnapshotAndResync(t *testing.T) {
+	hub := NewHub(5, 64, PolicyCoalesce, testutils.MockLogger())
+	hub.RatesCached(models.RatesResponse{Base: "USD", Provider: "erapi", Rates: models.RateTable{"EUR": 0.8, "GBP": 0.5}})
+	connection, err := hub.Connect("acme", []string{"EUR/USD", "GBP/USD", "XAU/USD"})
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	connection.Take()
+
+	// Pairs without a known rate are left out of the snapshot
+	snapshot := hub.Snapshot(connection)
+	if len(snapshot) != 2 || snapshot[0].Pair != "EUR/USD" || snapshot[1].Pair != "GBP/USD" || snapshot[1].Rate != 2 {
+		t.Errorf("Snapshot() = %+v, want EUR/USD and GBP/USD", snapshot)
+	}
+
+	if _, err := hub.Resync("other", connection.ID); err != ErrConnectionNotFound {
+		t.Errorf("Resync() of another tenant's stream error = %v, want ErrConnectionNotFound", err)
+	}
+	subscriptions, err := hub.Resync("acme", connection.ID)
+	if err != nil || len(subscriptions) != 3 {
+		t.Fatalf("Resync() = %v, %v, want the three subscriptions", subscriptions, err)
+	}
+	select {
+	case <-connection.Ready:
+	default:
+		t.Fatal("Resync() should wake the stream")
+	}
+	if !connection.ResyncRequested() || connection.ResyncRequested() {
+		t.Error("ResyncRequested() should report the resync once")
+	}
+}
@@ -0,0 +1,385 @@
+package stream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+var (
+	// ErrConnectionNotFound is returned for unknown or closed connection IDs
+	ErrConnectionNotFound = errors.New("stream connection not found")
+	// ErrTooManySubscriptions is returned when a connection would exceed its pair cap
+	ErrTooManySubscriptions = errors.New("too many subscriptions")
+)
+
+// Connection is one streaming client and the pairs it is subscribed to. Updates wait in
+// a bounded send buffer; Ready is signalled when updates are queued or the connection
+// closes, and Take collects them.
+type Connection struct {
+	ID    string
+	Owner string // Tenant that opened the connection ("" without tenants)
+	Ready <-chan struct{}
+
+	ready         chan struct{}
+	subscriptions map[string]float64 // Pair -> last rate queued (0 = none yet); guarded by the hub
+
+	mutex   sync.Mutex
+	queue   []models.PairRateResponse
+	closed  bool
+	dropped int64
+	resync  bool // The client asked for a snapshot of its pairs
+}
+
+// Hub fans rate refreshes out to streaming connections as updates of their subscribed
+// pairs, and manages the pairs each connection is subscribed to
+type Hub struct {
+	maxSubscriptions int
+	bufferSize       int
+	policy           Policy
+	logger           logger.Logger
+
+	mutex       sync.Mutex
+	connections map[string]*Connection
+	latest      models.RatesResponse
+	stats       models.StreamStats
+}
+
+// NewHub creates a hub allowing at most maxSubscriptions pairs per connection. Each
+// connection buffers up to bufferSize updates; policy decides what happens when a slow
+// client lets its buffer fill up.
+func NewHub(maxSubscriptions, bufferSize int, policy Policy, logger logger.Logger) *Hub {
+	return &Hub{
+		maxSubscriptions: maxSubscriptions,
+		bufferSize:       max(bufferSize, 1),
+		policy:           policy,
+		logger:           logger,
+		connections:      make(map[string]*Connection),
+	}
+}
+
+// Connect registers a connection subscribed to the given pairs
+func (hub *Hub) Connect(owner string, pairs []string) (*Connection, error) {
+	normalized, err := normalizePairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) > hub.maxSubscriptions {
+		return nil, fmt.Errorf("%w: at most %d pairs per connection", ErrTooManySubscriptions, hub.maxSubscriptions)
+	}
+
+	id, err := newConnectionID()
+	if err != nil {
+		return nil, err
+	}
+	ready := make(chan struct{}, 1)
+	connection := &Connection{
+		ID:            id,
+		Owner:         owner,
+		Ready:         ready,
+		ready:         ready,
+		subscriptions: make(map[string]float64),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	hub.connections[id] = connection
+	hub.subscribe(connection, normalized)
+	return connection, nil
+}
+
+// Disconnect removes a connection and closes it
+func (hub *Hub) Disconnect(connection *Connection) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if hub.connections[connection.ID] == connection {
+		delete(hub.connections, connection.ID)
+		connection.close()
+	}
+}
+
+// Close disconnects every connection, ending their streams (used at shutdown)
+func (hub *Hub) Close() {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	for id, connection := range hub.connections {
+		delete(hub.connections, id)
+		connection.close()
+	}
+}
+
+// Subscribe adds pairs to an open connection and returns its subscriptions. The
+// current rate of each new pair is sent right away when known.
+func (hub *Hub) Subscribe(owner, id string, pairs []string) ([]string, error) {
+	normalized, err := normalizePairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	connection, err := hub.connection(owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	added := 0
+	for _, pair := range normalized {
+		if _, subscribed := connection.subscriptions[pair]; !subscribed {
+			added++
+		}
+	}
+	if len(connection.subscriptions)+added > hub.maxSubscriptions {
+		return nil, fmt.Errorf("%w: at most %d pairs per connection", ErrTooManySubscriptions, hub.maxSubscriptions)
+	}
+
+	hub.subscribe(connection, normalized)
+	return connection.pairs(), nil
+}
+
+// Unsubscribe removes pairs from an open connection and returns its subscriptions
+func (hub *Hub) Unsubscribe(owner, id string, pairs []string) ([]string, error) {
+	normalized, err := normalizePairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	connection, err := hub.connection(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, pair := range normalized {
+		delete(connection.subscriptions, pair)
+	}
+	return connection.pairs(), nil
+}
+
+// Resync asks for a snapshot of every subscribed pair to be sent on an open connection,
+// for delta-encoded clients that missed a message, and returns its subscriptions
+func (hub *Hub) Resync(owner, id string) ([]string, error) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	connection, err := hub.connection(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	connection.requestResync()
+	return connection.pairs(), nil
+}
+
+// Snapshot returns the latest rates of every pair the connection is subscribed to, and
+// counts them as sent so they are only sent again once they change
+func (hub *Hub) Snapshot(connection *Connection) []models.PairRateResponse {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	pairs := connection.pairs()
+	snapshot := make([]models.PairRateResponse, 0, len(pairs))
+	for _, pair := range pairs {
+		if update, found := hub.update(pair); found {
+			snapshot = append(snapshot, update)
+			connection.subscriptions[pair] = update.Rate
+		}
+	}
+	return snapshot
+}
+
+// Subscriptions returns the pairs an open connection is subscribed to
+func (hub *Hub) Subscriptions(owner, id string) ([]string, error) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	connection, err := hub.connection(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	return connection.pairs(), nil
+}
+
+// Connections returns the number of open connections
+func (hub *Hub) Connections() int {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	return len(hub.connections)
+}
+
+// Stats returns the connection count and the backpressure totals since startup
+func (hub *Hub) Stats() models.StreamStats {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	stats := hub.stats
+	stats.Connections = len(hub.connections)
+	stats.Policy = string(hub.policy)
+	stats.BufferSize = hub.bufferSize
+	for _, connection := range hub.connections {
+		stats.Pending += connection.queued()
+	}
+	return stats
+}
+
+// RatesCached sends every connection the subscribed pairs whose rate changed
+func (hub *Hub) RatesCached(exchangeRates models.RatesResponse) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.latest = exchangeRates
+	for _, connection := range hub.connections {
+		for pair := range connection.subscriptions {
+			if !hub.send(connection, pair) {
+				break
+			}
+		}
+	}
+}
+
+// Run keeps rates fresh while connections are open by calling refresh every interval
+// (at least a second). Refreshed rates reach the hub through RatesCached.
+func (hub *Hub) Run(ctx context.Context, interval time.Duration, refresh func(ctx context.Context) error) {
+	ticker := time.NewTicker(max(interval, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if hub.Connections() == 0 {
+			continue
+		}
+		if err := refresh(ctx); err != nil {
+			hub.logger.Warnf("Stream rate refresh failed: %v", err)
+		}
+	}
+}
+
+// connection looks up an open connection owned by owner (caller holds the lock)
+func (hub *Hub) connection(owner, id string) (*Connection, error) {
+	connection, found := hub.connections[id]
+	if !found || connection.Owner != owner {
+		return nil, ErrConnectionNotFound
+	}
+	return connection, nil
+}
+
+// subscribe adds pairs and sends their current rates (caller holds the lock)
+func (hub *Hub) subscribe(connection *Connection, pairs []string) {
+	for _, pair := range pairs {
+		if _, subscribed := connection.subscriptions[pair]; subscribed {
+			continue
+		}
+		connection.subscriptions[pair] = 0
+		if !hub.send(connection, pair) {
+			return
+		}
+	}
+}
+
+// send queues the pair's latest rate when it changed since the last one queued, applying
+// the backpressure policy when the buffer is full. It returns false when the connection
+// was disconnected as a slow consumer. (caller holds the lock)
+func (hub *Hub) send(connection *Connection, pair string) bool {
+	update, found := hub.update(pair)
+	if !found || update.Rate == connection.subscriptions[pair] {
+		return true
+	}
+	rate := update.Rate
+
+	outcome, dropped := connection.enqueue(update, hub.policy, hub.bufferSize)
+	switch outcome {
+	case outcomeClosed:
+		return false
+	case outcomeOverflow:
+		// The update and everything still buffered are lost with the connection
+		hub.stats.Dropped += int64(hub.bufferSize) + 1
+		hub.stats.SlowDisconnects++
+		hub.logger.Warnf("Disconnecting slow stream %s: %d updates queued", connection.ID, hub.bufferSize)
+		delete(hub.connections, connection.ID)
+		connection.close()
+		return false
+	case outcomeCoalesced:
+		hub.stats.Coalesced++
+	case outcomeDropped:
+		hub.stats.Dropped++
+		// The client never sees the dropped rate, so resend the pair on its next refresh
+		if _, subscribed := connection.subscriptions[dropped.Pair]; subscribed && dropped.Pair != pair {
+			connection.subscriptions[dropped.Pair] = 0
+		}
+	}
+	hub.stats.Queued++
+	connection.subscriptions[pair] = rate
+	return true
+}
+
+// update returns the pair's latest rate (caller holds the lock)
+func (hub *Hub) update(pair string) (models.PairRateResponse, bool) {
+	from, to, _ := strings.Cut(pair, "/")
+	rate, found := hub.latest.PairRate(from, to)
+	if !found {
+		return models.PairRateResponse{}, false
+	}
+	return models.PairRateResponse{
+		Pair:        pair,
+		Rate:        rate,
+		Inverse:     1 / rate,
+		Provider:    hub.latest.Provider,
+		Timestamp:   hub.latest.Timestamp,
+		PublishedAt: hub.latest.PublishedAt,
+		FetchedAt:   hub.latest.FetchedAt,
+	}, true
+}
+
+// pairs returns the subscribed pairs in order (caller holds the lock)
+func (connection *Connection) pairs() []string {
+	pairs := make([]string, 0, len(connection.subscriptions))
+	for pair := range connection.subscriptions {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// normalizePairs upper-cases FROM/TO pairs and drops duplicates, rejecting malformed pairs
+func normalizePairs(pairs []string) ([]string, error) {
+	normalized := make([]string, 0, len(pairs))
+	seen := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		pair = strings.ToUpper(strings.TrimSpace(pair))
+		from, to, found := strings.Cut(pair, "/")
+		if !found || from == "" || to == "" || strings.Contains(to, "/") {
+			return nil, fmt.Errorf("invalid pair %q: use FROM/TO", pair)
+		}
+		if !seen[pair] {
+			seen[pair] = true
+			normalized = append(normalized, pair)
+		}
+	}
+	return normalized, nil
+}
+
+// newConnectionID returns a random, unguessable connection ID
+func newConnectionID() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate connection ID: %w", err)
+	}
+	return hex.EncodeToString(buffer), nil
+}
@@ -0,0 +1,261 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// receive returns the single queued update, failing unless exactly one is queued
+func receive(t *testing.T, connection *Connection) models.PairRateResponse {
+	t.Helper()
+	updates, _ := connection.Take()
+	if len(updates) != 1 {
+		t.Fatalf("queued updates = %+v, want one", updates)
+	}
+	return updates[0]
+}
+
+// expectNone fails when an update is queued
+func expectNone(t *testing.T, connection *Connection) {
+	t.Helper()
+	if updates, _ := connection.Take(); len(updates) != 0 {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+}
+
+// queuedRates returns the pairs and rates of the queued updates, sorted by pair
+func queuedRates(connection *Connection) []string {
+	updates, _ := connection.Take()
+	rates := make([]string, len(updates))
+	for i, update := range updates {
+		rates[i] = fmt.Sprintf("%s=%g", update.Pair, update.Rate)
+	}
+	sort.Strings(rates)
+	return rates
+}
+
+func TestHub_Updates(t *testing.T) {
+	hub := NewHub(5, 64, PolicyCoalesce, testutils.MockLogger())
+	hub.RatesCached(models.RatesResponse{Base: "USD", Provider: "erapi", Rates: models.RateTable{"EUR": 0.8, "GBP": 0.5}})
+
+	connection, err := hub.Connect("", []string{"eur/usd"})
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	// The current rate is sent on subscribe
+	if update := receive(t, connection); update.Pair != "EUR/USD" || update.Rate != 1.25 || update.Inverse != 0.8 || update.Provider != "erapi" {
+		t.Errorf("initial update = %+v, want EUR/USD 1.25", update)
+	}
+
+	// Unchanged rates are not sent again; changed ones are
+	hub.RatesCached(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.8, "GBP": 0.4}})
+	expectNone(t, connection)
+	hub.RatesCached(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.5, "GBP": 0.4}})
+	if update := receive(t, connection); update.Rate != 2 {
+		t.Errorf("changed update rate = %v, want 2", update.Rate)
+	}
+
+	// A pair added mid-connection gets its current rate and later updates
+	subscriptions, err := hub.Subscribe("", connection.ID, []string{"GBP/EUR"})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if !reflect.DeepEqual(subscriptions, []string{"EUR/USD", "GBP/EUR"}) {
+		t.Errorf("Subscribe() = %v", subscriptions)
+	}
+	if update := receive(t, connection); update.Pair != "GBP/EUR" || update.Rate != 1.25 {
+		t.Errorf("subscribe update = %+v, want GBP/EUR 1.25", update)
+	}
+
+	// A removed pair stops updating
+	if subscriptions, err = hub.Unsubscribe("", connection.ID, []string{"EUR/USD"}); err != nil || !reflect.DeepEqual(subscriptions, []string{"GBP/EUR"}) {
+		t.Fatalf("Unsubscribe() = %v, %v", subscriptions, err)
+	}
+	hub.RatesCached(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.25, "GBP": 0.4}})
+	if update := receive(t, connection); update.Pair != "GBP/EUR" {
+		t.Errorf("update after unsubscribe = %+v, want GBP/EUR only", update)
+	}
+	expectNone(t, connection)
+
+	hub.Disconnect(connection)
+	if _, open := connection.Take(); open {
+		t.Error("Disconnect() left the connection open")
+	}
+	if hub.Connections() != 0 {
+		t.Errorf("Connections() = %d after disconnect, want 0", hub.Connections())
+	}
+}
+
+func TestHub_Backpressure(t *testing.T) {
+	// Each refresh moves both subscribed pairs
+	refresh := func(hub *Hub, step float64) {
+		hub.RatesCached(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 1 / step, "GBP": 1 / (step * 10)}})
+	}
+	pairs := []string{"EUR/USD", "GBP/USD"}
+
+	tests := []struct {
+		name            string
+		policy          Policy
+		wantQueued      []string
+		wantOpen        bool
+		wantCoalesced   int64
+		wantDropped     int64
+		wantDisconnects int64
+	}{
+		{
+			name:          "coalesce keeps the latest rate of each pair",
+			policy:        PolicyCoalesce,
+			wantQueued:    []string{"EUR/USD=3", "GBP/USD=30"},
+			wantOpen:      true,
+			wantCoalesced: 4,
+		},
+		{
+			name:        "drop-oldest keeps the newest updates",
+			policy:      PolicyDropOldest,
+			wantQueued:  []string{"EUR/USD=3", "GBP/USD=30"},
+			wantOpen:    true,
+			wantDropped: 4,
+		},
+		{
+			name:            "disconnect closes the slow client",
+			policy:          PolicyDisconnect,
+			wantQueued:      []string{},
+			wantOpen:        false,
+			wantDropped:     3,
+			wantDisconnects: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(5, 2, tt.policy, testutils.MockLogger())
+			connection, err := hub.Connect("", pairs)
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+
+			for step := 1.0; step <= 3; step++ {
+				refresh(hub, step)
+			}
+
+			rates := queuedRates(connection)
+			if len(rates) != len(tt.wantQueued) || (len(rates) > 0 && !reflect.DeepEqual(rates, tt.wantQueued)) {
+				t.Errorf("queued = %v, want %v", rates, tt.wantQueued)
+			}
+			if _, open := connection.Take(); open != tt.wantOpen {
+				t.Errorf("open = %v, want %v", open, tt.wantOpen)
+			}
+
+			stats := hub.Stats()
+			if stats.Coalesced != tt.wantCoalesced || stats.Dropped != tt.wantDropped || stats.SlowDisconnects != tt.wantDisconnects {
+				t.Errorf("Stats() = %+v", stats)
+			}
+			if stats.Policy != string(tt.policy) || stats.BufferSize != 2 || stats.Pending != 0 {
+				t.Errorf("Stats() = %+v, want policy %s, buffer 2 and nothing pending", stats, tt.policy)
+			}
+		})
+	}
+}
+
+func TestHub_DropOldestResendsDroppedPairs(t *testing.T) {
+	hub := NewHub(5, 1, PolicyDropOldest, testutils.MockLogger())
+	hub.RatesCached(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.5, "GBP": 0.25}})
+	connection, _ := hub.Connect("", []string{"EUR/USD"})
+	if _, err := hub.Subscribe("", connection.ID, []string{"GBP/USD"}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// EUR/USD was dropped to make room for GBP/USD, so an unchanged refresh sends it again
+	if rates := queuedRates(connection); !reflect.DeepEqual(rates, []string{"GBP/USD=4"}) {
+		t.Fatalf("queued = %v, want GBP/USD=4", rates)
+	}
+	hub.RatesCached(models.RatesResponse{Base: "USD", Rates: models.RateTable{"EUR": 0.5, "GBP": 0.25}})
+	if rates := queuedRates(connection); !reflect.DeepEqual(rates, []string{"EUR/USD=2"}) {
+		t.Errorf("queued after refresh = %v, want EUR/USD=2", rates)
+	}
+	if dropped := connection.Dropped(); dropped != 1 {
+		t.Errorf("Dropped() = %d, want 1", dropped)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, value := range []string{"drop-oldest", "coalesce", "disconnect"} {
+		if policy, err := ParsePolicy(value); err != nil || string(policy) != value {
+			t.Errorf("ParsePolicy(%q) = %v, %v", value, policy, err)
+		}
+	}
+	if _, err := ParsePolicy("block"); err == nil {
+		t.Error("ParsePolicy() expected error for an unknown policy")
+	}
+}
+
+func TestHub_SubscriptionErrors(t *testing.T) {
+	hub := NewHub(2, 64, PolicyCoalesce, testutils.MockLogger())
+
+	if _, err := hub.Connect("", []string{"USD/EUR", "USD/GBP", "USD/JPY"}); !errors.Is(err, ErrTooManySubscriptions) {
+		t.Errorf("Connect() over the cap error = %v, want ErrTooManySubscriptions", err)
+	}
+	if _, err := hub.Connect("", []string{"USDEUR"}); err == nil {
+		t.Error("Connect() expected error for a malformed pair")
+	}
+
+	connection, err := hub.Connect("acme", []string{"USD/EUR", "usd/eur"})
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if _, err := hub.Subscribe("acme", connection.ID, []string{"USD/GBP", "USD/JPY"}); !errors.Is(err, ErrTooManySubscriptions) {
+		t.Errorf("Subscribe() over the cap error = %v, want ErrTooManySubscriptions", err)
+	}
+	if _, err := hub.Subscribe("acme", connection.ID, []string{"USD/EUR", "USD/GBP"}); err != nil {
+		t.Errorf("Subscribe() up to the cap error = %v", err)
+	}
+	if _, err := hub.Subscribe("other", connection.ID, []string{"USD/CHF"}); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("Subscribe() by another tenant error = %v, want ErrConnectionNotFound", err)
+	}
+	if _, err := hub.Unsubscribe("acme", "unknown", []string{"USD/EUR"}); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("Unsubscribe() unknown connection error = %v, want ErrConnectionNotFound", err)
+	}
+
+	hub.Close()
+	if _, err := hub.Subscriptions("acme", connection.ID); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("Subscriptions() after Close error = %v, want ErrConnectionNotFound", err)
+	}
+}
+
+func TestHub_Run(t *testing.T) {
+	hub := NewHub(5, 64, PolicyCoalesce, testutils.MockLogger())
+	var refreshes atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go hub.Run(ctx, time.Second, func(ctx context.Context) error {
+		refreshes.Add(1)
+		return nil
+	})
+
+	// Nothing is refreshed without connections
+	time.Sleep(1100 * time.Millisecond)
+	if refreshes.Load() != 0 {
+		t.Fatalf("Run() refreshed %d times without connections", refreshes.Load())
+	}
+
+	connection, _ := hub.Connect("", []string{"USD/EUR"})
+	defer hub.Disconnect(connection)
+	deadline := time.Now().Add(2 * time.Second)
+	for refreshes.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if refreshes.Load() == 0 {
+		t.Error("Run() did not refresh while a connection was open")
+	}
+}
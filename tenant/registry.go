@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"sync"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+// Registry resolves API keys and tenant IDs to configured tenants
+type Registry struct {
+	mutex        sync.RWMutex // Guards tenantsByKey, which changes when keys rotate
+	tenantsByKey map[string]*config.Tenant
+	tenantsByID  map[string]*config.Tenant
+}
+
+// NewRegistry creates a registry from the configured tenants
+func NewRegistry(tenants []config.Tenant) *Registry {
+	registry := &Registry{
+		tenantsByKey: make(map[string]*config.Tenant),
+		tenantsByID:  make(map[string]*config.Tenant),
+	}
+
+	for i := range tenants {
+		registry.tenantsByID[tenants[i].ID] = &tenants[i]
+		for _, apiKey := range tenants[i].APIKeys {
+			registry.tenantsByKey[apiKey] = &tenants[i]
+		}
+	}
+
+	return registry
+}
+
+// Enabled reports whether any tenants are configured
+func (registry *Registry) Enabled() bool {
+	return len(registry.tenantsByID) > 0
+}
+
+// Resolve returns the tenant owning the given API key
+func (registry *Registry) Resolve(apiKey string) (*config.Tenant, bool) {
+	if apiKey == "" {
+		return nil, false
+	}
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	tenant, found := registry.tenantsByKey[apiKey]
+	return tenant, found
+}
+
+// RotateAPIKeys replaces the API keys of the registered tenants with those of the
+// reloaded tenants. Tenants added since are ignored; tenants missing from the reload
+// lose their keys.
+func (registry *Registry) RotateAPIKeys(tenants []config.Tenant) {
+	tenantsByKey := make(map[string]*config.Tenant)
+	for _, reloaded := range tenants {
+		registered, found := registry.tenantsByID[reloaded.ID]
+		if !found {
+			continue
+		}
+		for _, apiKey := range reloaded.APIKeys {
+			tenantsByKey[apiKey] = registered
+		}
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.tenantsByKey = tenantsByKey
+}
+
+// Lookup returns the tenant with the given ID, e.g. from the tenant claim of a JWT
+func (registry *Registry) Lookup(id string) (*config.Tenant, bool) {
+	if id == "" {
+		return nil, false
+	}
+	tenant, found := registry.tenantsByID[id]
+	return tenant, found
+}
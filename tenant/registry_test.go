@@ -0,0 +1,87 @@
+package tenant
+
+import (
+	"testing"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+)
+
+func TestRegistry_Resolve(t *testing.T) {
+	registry := NewRegistry([]config.Tenant{
+		{ID: "acme", APIKeys: []string{"key-1", "key-2"}},
+		{ID: "globex", APIKeys: []string{"key-3"}},
+	})
+
+	tests := []struct {
+		name     string
+		apiKey   string
+		wantID   string
+		wantFind bool
+	}{
+		{name: "first key", apiKey: "key-1", wantID: "acme", wantFind: true},
+		{name: "second key", apiKey: "key-2", wantID: "acme", wantFind: true},
+		{name: "other tenant", apiKey: "key-3", wantID: "globex", wantFind: true},
+		{name: "unknown key", apiKey: "key-4", wantFind: false},
+		{name: "empty key", apiKey: "", wantFind: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenant, found := registry.Resolve(tt.apiKey)
+			if found != tt.wantFind {
+				t.Fatalf("Resolve() found = %v, want %v", found, tt.wantFind)
+			}
+			if found && tenant.ID != tt.wantID {
+				t.Errorf("Resolve() ID = %v, want %v", tenant.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestRegistry_Enabled(t *testing.T) {
+	if NewRegistry(nil).Enabled() {
+		t.Error("Enabled() = true for empty registry, want false")
+	}
+	if !NewRegistry([]config.Tenant{{ID: "acme", APIKeys: []string{"key"}}}).Enabled() {
+		t.Error("Enabled() = false for configured registry, want true")
+	}
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	registry := NewRegistry([]config.Tenant{{ID: "acme", APIKeys: []string{"key-1"}}, {ID: "keyless"}})
+
+	if tenant, found := registry.Lookup("keyless"); !found || tenant.ID != "keyless" {
+		t.Errorf("Lookup(keyless) = %v, %v, want the tenant without keys", tenant, found)
+	}
+	for _, id := range []string{"unknown", ""} {
+		if _, found := registry.Lookup(id); found {
+			t.Errorf("Lookup(%q) found a tenant", id)
+		}
+	}
+}
+
+func TestRegistry_RotateAPIKeys(t *testing.T) {
+	registry := NewRegistry([]config.Tenant{
+		{ID: "acme", APIKeys: []string{"old-key"}},
+		{ID: "globex", APIKeys: []string{"globex-key"}},
+	})
+
+	registry.RotateAPIKeys([]config.Tenant{
+		{ID: "acme", APIKeys: []string{"new-key"}},
+		{ID: "globex", APIKeys: []string{"globex-key"}},
+		{ID: "initech", APIKeys: []string{"initech-key"}},
+	})
+
+	if tenant, found := registry.Resolve("new-key"); !found || tenant.ID != "acme" {
+		t.Errorf("Resolve(new-key) = %v, %v, want acme", tenant, found)
+	}
+	if _, found := registry.Resolve("old-key"); found {
+		t.Error("Resolve(old-key) found the tenant after its key rotated")
+	}
+	if _, found := registry.Resolve("globex-key"); !found {
+		t.Error("Resolve(globex-key) lost an unchanged key")
+	}
+	if _, found := registry.Resolve("initech-key"); found {
+		t.Error("Resolve(initech-key) found a tenant added after startup")
+	}
+}
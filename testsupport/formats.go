@@ -0,0 +1,75 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Format is the response format of an exchange rate provider, named as the service's
+// provider names select their parsers
+type Format string
+
+// Provider response formats
+const (
+	FormatERAPI             Format = "erapi"             // ExchangeRate-API: base_code and time_last_update_unix
+	FormatOpenExchangeRates Format = "openexchangerates" // Open Exchange Rates: base and timestamp
+	FormatFrankfurter       Format = "frankfurter"       // Frankfurter: base and date
+	FormatExchangeRateHost  Format = "exchangerate.host" // exchangerate.host: base, timestamp and date
+	FormatGeneric           Format = "generic"           // Custom providers: base and timestamp
+)
+
+// Quote is a table of rates as published by a provider
+type Quote struct {
+	Base  string
+	Rates map[string]float64
+	Time  time.Time // Publication time (zero = when the quote is served)
+}
+
+// Body returns the quote as the provider of the format would send it. Unknown formats
+// are encoded as FormatGeneric.
+func (format Format) Body(quote Quote) []byte {
+	published := quote.Time
+	if published.IsZero() {
+		published = time.Now()
+	}
+	published = published.UTC()
+
+	var body any
+	switch format {
+	case FormatERAPI:
+		body = struct {
+			Result             string             `json:"result"`
+			BaseCode           string             `json:"base_code"`
+			TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+			Rates              map[string]float64 `json:"rates"`
+		}{"success", quote.Base, published.Unix(), quote.Rates}
+	case FormatFrankfurter:
+		body = struct {
+			Amount float64            `json:"amount"`
+			Base   string             `json:"base"`
+			Date   string             `json:"date"`
+			Rates  map[string]float64 `json:"rates"`
+		}{1, quote.Base, published.Format("2006-01-02"), quote.Rates}
+	case FormatExchangeRateHost:
+		body = struct {
+			Success   bool               `json:"success"`
+			Timestamp int64              `json:"timestamp"`
+			Base      string             `json:"base"`
+			Date      string             `json:"date"`
+			Rates     map[string]float64 `json:"rates"`
+		}{true, published.Unix(), quote.Base, published.Format("2006-01-02"), quote.Rates}
+	default:
+		body = struct {
+			Timestamp int64              `json:"timestamp"`
+			Base      string             `json:"base"`
+			Rates     map[string]float64 `json:"rates"`
+		}{published.Unix(), quote.Base, quote.Rates}
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		panic(fmt.Sprintf("testsupport: quote cannot be encoded: %v", err)) // NaN or infinite rates
+	}
+	return encoded
+}
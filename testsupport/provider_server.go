@@ -0,0 +1,434 @@
+// Package testsupport fakes exchange rate providers for tests of the service and of
+// applications embedding its client. Its API is kept stable: additions are backwards
+// compatible, so suites outside this repository can depend on it.
+package testsupport
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/clock"
+)
+
+// ProviderServer is a fake exchange rate provider over HTTP. Requests are answered, in
+// order of precedence, by the pending script of their path, by the route of their path,
+// and otherwise with fixed rates in the format the path suggests.
+type ProviderServer struct {
+	server *httptest.Server
+
+	mutex      sync.Mutex
+	responses  map[string]ExchangeRateResponse
+	routes     map[string]http.Handler       // Programmed answers by path
+	scripts    map[string][]ScriptedResponse // Pending scripted answers by path
+	requests   map[string]int                // Requests served by path
+	drift      float64                       // Relative change of every rate per hour
+	driftClock clock.Clock
+	driftStart time.Time
+}
+
+// ScriptedResponse is a scripted answer of the mock server. The zero value answers
+// normally; a status or body replaces the rates.
+type ScriptedResponse struct {
+	Status int               // Status code (0 = 200)
+	Delay  time.Duration     // Wait before answering, cut short when the client goes away
+	Body   string            // Body replacing the rates, e.g. malformed JSON (empty = the rates, or an error for failures)
+	Header map[string]string // Extra response headers, e.g. Retry-After
+}
+
+// Status answers with the status code and a JSON error body
+func Status(code int) ScriptedResponse {
+	return ScriptedResponse{Status: code}
+}
+
+// Delayed answers normally after the delay
+func Delayed(delay time.Duration) ScriptedResponse {
+	return ScriptedResponse{Delay: delay}
+}
+
+// MalformedJSON answers 200 with a truncated JSON body
+func MalformedJSON() ScriptedResponse {
+	return ScriptedResponse{Status: http.StatusOK, Body: `{"base": "USD", "rates": {"EUR": 0.8`}
+}
+
+// QuotaExceeded answers 429 with a provider-style quota error and, when positive, a
+// Retry-After of the given seconds
+func QuotaExceeded(retryAfter time.Duration) ScriptedResponse {
+	response := ScriptedResponse{
+		Status: http.StatusTooManyRequests,
+		Body:   `{"error": true, "status": 429, "message": "usage quota exceeded"}`,
+	}
+	if retryAfter > 0 {
+		response.Header = map[string]string{"Retry-After": strconv.Itoa(int(retryAfter.Seconds()))}
+	}
+	return response
+}
+
+// ExchangeRateResponse represents a mock exchange rate API response
+type ExchangeRateResponse struct {
+	Base      string             `json:"base"`
+	Timestamp int64              `json:"timestamp"`
+	Rates     map[string]float64 `json:"rates"`
+	Provider  string             `json:"provider,omitempty"`
+}
+
+// NewProviderServer starts a provider server; Close shuts it down
+func NewProviderServer() *ProviderServer {
+	mock := &ProviderServer{
+		responses: make(map[string]ExchangeRateResponse),
+		routes:    make(map[string]http.Handler),
+		scripts:   make(map[string][]ScriptedResponse),
+		requests:  make(map[string]int),
+	}
+
+	// Set up default responses for different providers
+	mock.SetupDefaultResponses()
+
+	mock.server = httptest.NewServer(http.HandlerFunc(mock.handler))
+	return mock
+}
+
+// SetupDefaultResponses sets up default mock responses for different providers
+func (m *ProviderServer) SetupDefaultResponses() {
+	// Exchange Rate API response format
+	m.responses["/USD"] = ExchangeRateResponse{
+		Base:      "USD",
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]float64{
+			"EUR": 0.85,
+			"GBP": 0.73,
+			"JPY": 110.0,
+			"CAD": 1.25,
+			"AUD": 1.35,
+		},
+		Provider: "EXCHANGE_RATE_API",
+	}
+
+	// Open Exchange Rates response format
+	m.responses["/openexchangerates"] = ExchangeRateResponse{
+		Base:      "USD",
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]float64{
+			"EUR": 0.85,
+			"GBP": 0.73,
+			"JPY": 110.0,
+			"CAD": 1.25,
+			"AUD": 1.35,
+		},
+		Provider: "OPEN_EXCHANGE_RATES",
+	}
+
+	// Frankfurter API response format
+	m.responses["/frankfurter"] = ExchangeRateResponse{
+		Base:      "USD",
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]float64{
+			"EUR": 0.85,
+			"GBP": 0.73,
+			"JPY": 110.0,
+			"CAD": 1.25,
+			"AUD": 1.35,
+		},
+		Provider: "FRANKFURTER_API",
+	}
+
+	// Exchange Rate Host response format
+	m.responses["/exchangeratehost"] = ExchangeRateResponse{
+		Base:      "USD",
+		Timestamp: time.Now().Unix(),
+		Rates: map[string]float64{
+			"EUR": 0.85,
+			"GBP": 0.73,
+			"JPY": 110.0,
+			"CAD": 1.25,
+			"AUD": 1.35,
+		},
+		Provider: "EXCHANGE_RATE_HOST",
+	}
+}
+
+// handler handles HTTP requests to the mock server
+func (m *ProviderServer) handler(w http.ResponseWriter, r *http.Request) {
+	// Add CORS headers
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	// Handle HTTP method using type switch
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+		return
+	case "GET":
+		// Continue processing
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !m.answerScripted(w, r) {
+		return
+	}
+	m.mutex.Lock()
+	route := m.routes[r.URL.Path]
+	m.mutex.Unlock()
+	if route != nil {
+		route.ServeHTTP(w, r)
+		return
+	}
+
+	// Determine response based on URL path
+	var response ExchangeRateResponse
+	var found bool
+
+	path := r.URL.Path
+	query := r.URL.Query()
+
+	// Handle different API formats
+	m.mutex.Lock()
+	responses := m.responses
+	m.mutex.Unlock()
+	if path == "/USD" || path == "/latest" {
+		response, found = responses["/USD"]
+	} else if query.Get("app_id") != "" {
+		// Handle openexchangerates with dynamic base currency
+		baseCurrency := query.Get("base")
+		if baseCurrency == "" {
+			baseCurrency = "USD" // Default to USD
+		}
+		response = ExchangeRateResponse{
+			Base:      baseCurrency,
+			Timestamp: time.Now().Unix(),
+			Rates: map[string]float64{
+				"USD": 1.0,
+				"EUR": 0.85,
+				"GBP": 0.73,
+				"JPY": 110.0,
+				"CAD": 1.25,
+				"AUD": 1.35,
+			},
+			Provider: "openexchangerates",
+		}
+		found = true
+	} else if path == "/latest" && query.Get("base") != "" {
+		response, found = responses["/frankfurter"]
+	} else if query.Get("base") != "" {
+		response, found = responses["/exchangeratehost"]
+	} else if len(path) > 1 && path[0] == '/' {
+		// Handle dynamic base currency (e.g., /EUR, /GBP, etc.)
+		baseCurrency := path[1:] // Remove leading slash
+
+		// For erapi provider, return the correct format
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		erapiResponse := map[string]interface{}{
+			"base_code":             baseCurrency,
+			"time_last_update_unix": time.Now().Unix(),
+			"rates": m.drifted(map[string]float64{
+				"USD": 1.0,
+				"EUR": 0.85,
+				"GBP": 0.73,
+				"JPY": 110.0,
+				"CAD": 1.25,
+				"AUD": 1.35,
+			}),
+		}
+		json.NewEncoder(w).Encode(erapiResponse)
+		return
+	} else {
+		// Default response - always return USD response
+		response, found = responses["/USD"]
+	}
+
+	// If still not found, create a default response
+	if !found {
+		response = ExchangeRateResponse{
+			Base:      "USD",
+			Timestamp: time.Now().Unix(),
+			Rates: map[string]float64{
+				"EUR": 0.85,
+				"GBP": 0.73,
+				"JPY": 110.0,
+				"CAD": 1.25,
+				"AUD": 1.35,
+			},
+			Provider: "MOCK",
+		}
+		found = true
+	}
+
+	// Set content type
+	w.Header().Set("Content-Type", "application/json")
+	response.Rates = m.drifted(response.Rates)
+
+	// Return appropriate response format based on the request path using type switch
+	switch path {
+	case "/USD":
+		// ERAPI format
+		apiResponse := struct {
+			BaseCode           string             `json:"base_code"`
+			TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+			Rates              map[string]float64 `json:"rates"`
+		}{
+			BaseCode:           response.Base,
+			TimeLastUpdateUnix: response.Timestamp,
+			Rates:              response.Rates,
+		}
+		json.NewEncoder(w).Encode(apiResponse)
+	case "/latest":
+		// Open Exchange Rates format
+		apiResponse := struct {
+			Base      string             `json:"base"`
+			Timestamp int64              `json:"timestamp"`
+			Rates     map[string]float64 `json:"rates"`
+		}{
+			Base:      response.Base,
+			Timestamp: response.Timestamp,
+			Rates:     response.Rates,
+		}
+		json.NewEncoder(w).Encode(apiResponse)
+	default:
+		// Default format (generic)
+		apiResponse := struct {
+			Base      string             `json:"base"`
+			Timestamp int64              `json:"timestamp"`
+			Rates     map[string]float64 `json:"rates"`
+		}{
+			Base:      response.Base,
+			Timestamp: response.Timestamp,
+			Rates:     response.Rates,
+		}
+		json.NewEncoder(w).Encode(apiResponse)
+	}
+}
+
+// URL returns the mock server URL
+func (m *ProviderServer) URL() string {
+	return m.server.URL
+}
+
+// Close closes the mock server
+func (m *ProviderServer) Close() {
+	m.server.Close()
+}
+
+// SetResponse sets the fixed rates answered at a path
+func (m *ProviderServer) SetResponse(path string, response ExchangeRateResponse) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	responses := make(map[string]ExchangeRateResponse, len(m.responses)+1)
+	for responsePath, existing := range m.responses {
+		responses[responsePath] = existing
+	}
+	responses[path] = response
+	m.responses = responses
+}
+
+// Handle answers requests to the path with the handler, e.g. to fake an endpoint the
+// other answers do not cover
+func (m *ProviderServer) Handle(path string, handler http.Handler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.routes[path] = handler
+}
+
+// ServeQuote answers requests to the path with the quote in the provider's format,
+// moved by the drift when one is set
+func (m *ProviderServer) ServeQuote(path string, format Format, quote Quote) {
+	m.Handle(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		quote := quote
+		quote.Rates = m.drifted(quote.Rates)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(format.Body(quote))
+	}))
+}
+
+// Script answers the next requests to the path with the responses in order; requests
+// after the script are answered normally again
+func (m *ProviderServer) Script(path string, responses ...ScriptedResponse) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.scripts[path] = append(m.scripts[path], responses...)
+}
+
+// SetDrift makes every rate move by the relative drift per hour (e.g. 0.01 for +1%) from
+// now on, as read from the clock (nil = the system clock)
+func (m *ProviderServer) SetDrift(perHour float64, driftClock clock.Clock) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.drift = perHour
+	m.driftClock = clock.Or(driftClock)
+	m.driftStart = m.driftClock.Now()
+}
+
+// Requests returns how many requests to the path the server received
+func (m *ProviderServer) Requests(path string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.requests[path]
+}
+
+// answerScripted counts the request and plays the next scripted response of its path,
+// reporting whether the normal answer should follow
+func (m *ProviderServer) answerScripted(w http.ResponseWriter, r *http.Request) bool {
+	m.mutex.Lock()
+	m.requests[r.URL.Path]++
+	script := m.scripts[r.URL.Path]
+	if len(script) == 0 {
+		m.mutex.Unlock()
+		return true
+	}
+	scripted := script[0]
+	m.scripts[r.URL.Path] = script[1:]
+	m.mutex.Unlock()
+
+	if scripted.Delay > 0 {
+		timer := time.NewTimer(scripted.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return false
+		}
+	}
+	for name, value := range scripted.Header {
+		w.Header().Set(name, value)
+	}
+
+	status := scripted.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status == http.StatusOK && scripted.Body == "" {
+		return true
+	}
+	body := scripted.Body
+	if body == "" {
+		body = fmt.Sprintf(`{"error": %q}`, http.StatusText(status))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+	return false
+}
+
+// drifted returns the rates moved by the drift since it was set
+func (m *ProviderServer) drifted(rates map[string]float64) map[string]float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.drift == 0 || rates == nil {
+		return rates
+	}
+
+	factor := math.Pow(1+m.drift, m.driftClock.Now().Sub(m.driftStart).Hours())
+	driftedRates := make(map[string]float64, len(rates))
+	for currency, rate := range rates {
+		driftedRates[currency] = rate * factor
+	}
+	return driftedRates
+}
@@ -0,0 +1,82 @@
+package testsupport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/config"
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/service"
+)
+
+func TestFormat_Body_ParsedByProviders(t *testing.T) {
+	published := time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)
+	quote := Quote{Base: "EUR", Rates: map[string]float64{"USD": 1.0854, "GBP": 0.8565}, Time: published}
+
+	for _, format := range []Format{FormatERAPI, FormatOpenExchangeRates, FormatFrankfurter, FormatExchangeRateHost, FormatGeneric} {
+		t.Run(string(format), func(t *testing.T) {
+			provider := service.NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: string(format), Enabled: true}, logger.New("error"))
+
+			rates, err := provider.ParseResponse(format.Body(quote), "EUR")
+			if err != nil {
+				t.Fatalf("ParseResponse() error = %v", err)
+			}
+			if rates.Base != "EUR" || rates.Rates["USD"] != 1.0854 || rates.Rates["GBP"] != 0.8565 || rates.Timestamp != published.Unix() {
+				t.Errorf("ParseResponse() = %+v, want the quote", rates)
+			}
+		})
+	}
+}
+
+func TestProviderServer_Routes(t *testing.T) {
+	server := NewProviderServer()
+	defer server.Close()
+
+	server.ServeQuote("/v1/latest", FormatFrankfurter, Quote{Base: "USD", Rates: map[string]float64{"EUR": 0.92}})
+	server.Handle("/v1/down", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	server.Script("/v1/latest", Status(http.StatusTooManyRequests))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/v1/latest", wantStatus: http.StatusTooManyRequests}, // Scripts come first
+		{path: "/v1/latest", wantStatus: http.StatusOK, wantBody: string(FormatFrankfurter.Body(Quote{Base: "USD", Rates: map[string]float64{"EUR": 0.92}}))},
+		{path: "/v1/down", wantStatus: http.StatusServiceUnavailable},
+	}
+	for i, tt := range tests {
+		resp, err := http.Get(server.URL() + tt.path)
+		if err != nil {
+			t.Fatalf("request %d error = %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || (tt.wantBody != "" && string(body) != tt.wantBody) {
+			t.Errorf("request %d to %s = %d %s, want %d %s", i, tt.path, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+	if requests := server.Requests("/v1/latest"); requests != 2 {
+		t.Errorf("Requests() = %d, want 2", requests)
+	}
+}
+
+func TestProviderServer_ServeQuote_ToProvider(t *testing.T) {
+	server := NewProviderServer()
+	defer server.Close()
+	server.ServeQuote("/GBP", FormatERAPI, Quote{Base: "GBP", Rates: map[string]float64{"USD": 1.27}})
+
+	provider := service.NewHTTPExchangeRateProvider(config.ExchangeRateProvider{Name: "erapi", BaseURL: server.URL(), Enabled: true, Timeout: time.Second}, logger.New("error"))
+	rates, err := provider.GetRates(context.Background(), "GBP")
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if rates.Rates["USD"] != 1.27 {
+		t.Errorf("GetRates() = %+v, want the served quote", rates)
+	}
+}
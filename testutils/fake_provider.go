@@ -0,0 +1,155 @@
+package testutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// ErrFakeFailure is the error of FakeProvider calls failed by the error rate when no
+// Err is set
+var ErrFakeFailure = errors.New("fake provider failure")
+
+// LatencyDistribution draws the latency of one provider call
+type LatencyDistribution func(random *rand.Rand) time.Duration
+
+// FixedLatency delays every call by the same duration
+func FixedLatency(latency time.Duration) LatencyDistribution {
+	return func(*rand.Rand) time.Duration { return latency }
+}
+
+// UniformLatency delays each call by a duration drawn uniformly from [minimum, maximum)
+func UniformLatency(minimum, maximum time.Duration) LatencyDistribution {
+	return func(random *rand.Rand) time.Duration {
+		if maximum <= minimum {
+			return minimum
+		}
+		return minimum + time.Duration(random.Int63n(int64(maximum-minimum)))
+	}
+}
+
+// FakeProvider is an exchange rate provider whose behavior is scripted per test: how long
+// calls take, which of them fail and how its rates move between calls. The zero value of
+// each behavior answers at once with the same rates every time. Random draws are seeded
+// by Seed, so a test sees the same sequence on every run.
+type FakeProvider struct {
+	Name     string
+	Enabled  bool
+	Priority int
+	Rates    map[string]float64 // Rates of the first call
+	Bid      map[string]float64 // Bid and ask quotes of every call (nil = mid rates only)
+	Ask      map[string]float64
+
+	Err       error               // Error of failing calls; every call fails when ErrorRate is 0
+	ErrorRate float64             // Fraction of calls failing, drawn per call (0 = per Err)
+	Errors    []error             // Outcome of the first calls in order (nil = success), before Err and ErrorRate apply
+	Latency   LatencyDistribution // Delay of each call, cut short when the context ends (nil = none)
+	Drift     float64             // Relative change of every rate per call, e.g. 0.001 for +0.1%
+	Seed      int64
+	Clock     *FakeClock // Time of the rates it answers (nil = the wall clock)
+
+	mutex  sync.Mutex
+	random *rand.Rand
+	calls  int
+}
+
+// GetName returns the provider name
+func (provider *FakeProvider) GetName() string {
+	return provider.Name
+}
+
+// IsEnabled returns whether the provider is enabled
+func (provider *FakeProvider) IsEnabled() bool {
+	return provider.Enabled
+}
+
+// GetPriority returns the provider priority
+func (provider *FakeProvider) GetPriority() int {
+	return provider.Priority
+}
+
+// Calls returns how many times GetRates was called
+func (provider *FakeProvider) Calls() int {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	return provider.calls
+}
+
+// GetRates answers with the scripted outcome of the call after its scripted latency
+func (provider *FakeProvider) GetRates(ctx context.Context, baseCurrency string) (models.RatesResponse, error) {
+	provider.mutex.Lock()
+	provider.calls++
+	call := provider.calls
+	if provider.random == nil {
+		provider.random = rand.New(rand.NewSource(provider.Seed))
+	}
+	var latency time.Duration
+	if provider.Latency != nil {
+		latency = provider.Latency(provider.random)
+	}
+	err := provider.outcome(call)
+	rates := provider.drifted(call)
+	provider.mutex.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return models.RatesResponse{}, ctx.Err()
+		}
+	}
+	if err != nil {
+		return models.RatesResponse{}, err
+	}
+
+	now := time.Now().Unix()
+	if provider.Clock != nil {
+		now = provider.Clock.Now().Unix()
+	}
+	return models.RatesResponse{
+		Base:      baseCurrency,
+		Timestamp: now,
+		Rates:     rates,
+		Bid:       provider.Bid,
+		Ask:       provider.Ask,
+		Provider:  provider.Name,
+		FetchedAt: now,
+	}, nil
+}
+
+// outcome returns the error of the call, if it fails (caller holds the lock)
+func (provider *FakeProvider) outcome(call int) error {
+	if call <= len(provider.Errors) {
+		return provider.Errors[call-1]
+	}
+	if provider.ErrorRate > 0 {
+		if provider.random.Float64() >= provider.ErrorRate {
+			return nil
+		}
+		if provider.Err == nil {
+			return ErrFakeFailure
+		}
+	}
+	return provider.Err
+}
+
+// drifted returns the rates of the call, moved by the drift of the calls before it
+// (caller holds the lock)
+func (provider *FakeProvider) drifted(call int) map[string]float64 {
+	if provider.Drift == 0 || provider.Rates == nil {
+		return provider.Rates
+	}
+	factor := math.Pow(1+provider.Drift, float64(call-1))
+	rates := make(map[string]float64, len(provider.Rates))
+	for currency, rate := range provider.Rates {
+		rates[currency] = rate * factor
+	}
+	return rates
+}
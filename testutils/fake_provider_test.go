@@ -0,0 +1,83 @@
+package testutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestFakeProvider_Script(t *testing.T) {
+	down := errors.New("down")
+	provider := &FakeProvider{
+		Name:   "fake",
+		Rates:  map[string]float64{"EUR": 1},
+		Errors: []error{down, nil, down},
+		Drift:  0.01,
+	}
+
+	ctx := context.Background()
+	var outcomes []error
+	var lastRate float64
+	for i := 0; i < 4; i++ {
+		response, err := provider.GetRates(ctx, "USD")
+		outcomes = append(outcomes, err)
+		if err == nil {
+			lastRate = response.Rates["EUR"]
+		}
+	}
+
+	if outcomes[0] != down || outcomes[1] != nil || outcomes[2] != down || outcomes[3] != nil {
+		t.Errorf("GetRates() outcomes = %v, want the scripted ones, then success", outcomes)
+	}
+	if want := math.Pow(1.01, 3); math.Abs(lastRate-want) > 1e-12 {
+		t.Errorf("rate of the fourth call = %v, want %v", lastRate, want)
+	}
+	if provider.Rates["EUR"] != 1 {
+		t.Errorf("drift changed the configured rates to %v", provider.Rates)
+	}
+	if provider.Calls() != 4 {
+		t.Errorf("Calls() = %d, want 4", provider.Calls())
+	}
+}
+
+func TestFakeProvider_ErrorRate(t *testing.T) {
+	failures := func(seed int64) int {
+		provider := &FakeProvider{Name: "fake", ErrorRate: 0.3, Seed: seed}
+		failed := 0
+		for i := 0; i < 1000; i++ {
+			if _, err := provider.GetRates(context.Background(), "USD"); errors.Is(err, ErrFakeFailure) {
+				failed++
+			}
+		}
+		return failed
+	}
+
+	failed := failures(42)
+	if failed < 250 || failed > 350 {
+		t.Errorf("failed calls = %d of 1000, want about 300", failed)
+	}
+	if again := failures(42); again != failed {
+		t.Errorf("failed calls with the same seed = %d, want %d", again, failed)
+	}
+}
+
+func TestFakeProvider_Latency(t *testing.T) {
+	provider := &FakeProvider{Name: "fake", Latency: FixedLatency(time.Hour)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := provider.GetRates(ctx, "USD"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetRates() past the deadline error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	uniform := UniformLatency(10*time.Millisecond, 20*time.Millisecond)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if latency := uniform(random); latency < 10*time.Millisecond || latency >= 20*time.Millisecond {
+			t.Fatalf("UniformLatency() drew %v, want within [10ms, 20ms)", latency)
+		}
+	}
+}
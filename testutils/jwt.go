@@ -0,0 +1,98 @@
+package testutils
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// MockJWTIssuer serves a JSON Web Key Set and signs RS256 tokens with its current key
+type MockJWTIssuer struct {
+	server *httptest.Server
+
+	mutex   sync.Mutex
+	keys    []*rsa.PrivateKey
+	fetches int
+}
+
+// NewMockJWTIssuer creates an issuer with one signing key
+func NewMockJWTIssuer() *MockJWTIssuer {
+	issuer := &MockJWTIssuer{}
+	issuer.RotateKey()
+	issuer.server = httptest.NewServer(http.HandlerFunc(issuer.handler))
+	return issuer
+}
+
+// URL returns the key set URL
+func (issuer *MockJWTIssuer) URL() string {
+	return issuer.server.URL + "/.well-known/jwks.json"
+}
+
+// Close shuts down the key set server
+func (issuer *MockJWTIssuer) Close() {
+	issuer.server.Close()
+}
+
+// RotateKey adds a new signing key, which signs tokens from now on. Earlier keys stay
+// published so their tokens remain valid.
+func (issuer *MockJWTIssuer) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.keys = append(issuer.keys, key)
+}
+
+// Fetches returns how often the key set was fetched
+func (issuer *MockJWTIssuer) Fetches() int {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	return issuer.fetches
+}
+
+// Token signs the claims with the current key
+func (issuer *MockJWTIssuer) Token(claims map[string]interface{}) string {
+	issuer.mutex.Lock()
+	keyID, key := len(issuer.keys), issuer.keys[len(issuer.keys)-1]
+	issuer.mutex.Unlock()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": fmt.Sprintf("key-%d", keyID)})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (issuer *MockJWTIssuer) handler(w http.ResponseWriter, r *http.Request) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.fetches++
+
+	keys := make([]map[string]string, len(issuer.keys))
+	for i, key := range issuer.keys {
+		keys[i] = map[string]string{
+			"kty": "RSA",
+			"kid": fmt.Sprintf("key-%d", i+1),
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
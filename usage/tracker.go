@@ -0,0 +1,224 @@
+package usage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/logger"
+	"github.com/dalfonso89/currency-exchange-service/models"
+)
+
+// flushTimeout bounds a single flush to the store
+const flushTimeout = 30 * time.Second
+
+// Store persists flushed usage and reads it back for reports
+type Store interface {
+	RecordUsage(ctx context.Context, records []models.UsageRecord) error
+	Usage(ctx context.Context, from, to time.Time) ([]models.UsageRecord, error)
+}
+
+// recordKey identifies the usage of one endpoint with one key within an hour
+type recordKey struct {
+	bucket   time.Time
+	tenant   string
+	keyID    string
+	endpoint string
+}
+
+// Tracker aggregates API usage per key, endpoint and hour in memory and periodically
+// flushes it to the store. Without a store, usage is kept in memory since startup.
+type Tracker struct {
+	store  Store
+	logger logger.Logger
+
+	mutex   sync.Mutex
+	pending map[recordKey]*models.UsageRecord
+}
+
+// NewTracker creates a tracker flushing to store, or keeping usage in memory when store is nil
+func NewTracker(store Store, logger logger.Logger) *Tracker {
+	return &Tracker{
+		store:   store,
+		logger:  logger,
+		pending: make(map[recordKey]*models.UsageRecord),
+	}
+}
+
+// KeyID returns a fingerprint identifying an API key in usage reports without storing it
+func KeyID(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Record adds one completed request to the usage of its key and endpoint
+func (tracker *Tracker) Record(tenant, keyID, endpoint string, status int, bytes int64, latency time.Duration, at time.Time) {
+	key := recordKey{bucket: at.UTC().Truncate(time.Hour), tenant: tenant, keyID: keyID, endpoint: endpoint}
+	latencyMS := float64(latency.Microseconds()) / 1000
+
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	record, found := tracker.pending[key]
+	if !found {
+		record = &models.UsageRecord{Bucket: key.bucket, Tenant: tenant, KeyID: keyID, Endpoint: endpoint}
+		tracker.pending[key] = record
+	}
+	record.Requests++
+	if status >= 400 {
+		record.Errors++
+	}
+	record.Bytes += max(bytes, 0)
+	record.LatencyMS += latencyMS
+	record.MaxLatencyMS = max(record.MaxLatencyMS, latencyMS)
+}
+
+// Flush writes the pending usage to the store. Usage that fails to flush is kept and
+// retried with the next flush.
+func (tracker *Tracker) Flush(ctx context.Context) error {
+	if tracker.store == nil {
+		return nil
+	}
+
+	tracker.mutex.Lock()
+	flushing := tracker.pending
+	tracker.pending = make(map[recordKey]*models.UsageRecord)
+	tracker.mutex.Unlock()
+
+	if len(flushing) == 0 {
+		return nil
+	}
+	records := make([]models.UsageRecord, 0, len(flushing))
+	for _, record := range flushing {
+		records = append(records, *record)
+	}
+	if err := tracker.store.RecordUsage(ctx, records); err != nil {
+		tracker.restore(flushing)
+		return err
+	}
+	return nil
+}
+
+// Start flushes the pending usage every interval until ctx is cancelled
+func (tracker *Tracker) Start(ctx context.Context, interval time.Duration) {
+	if tracker.store == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				flushCtx, cancel := context.WithTimeout(ctx, flushTimeout)
+				if err := tracker.Flush(flushCtx); err != nil {
+					tracker.logger.Errorf("API usage flush failed: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Report returns the usage per key and endpoint in the hourly buckets between from
+// (inclusive) and to (exclusive). Pending usage is flushed first, so it is included.
+func (tracker *Tracker) Report(ctx context.Context, from, to time.Time) (models.UsageReport, error) {
+	report := models.UsageReport{From: from.UTC(), To: to.UTC()}
+
+	var records []models.UsageRecord
+	if tracker.store != nil {
+		if err := tracker.Flush(ctx); err != nil {
+			tracker.logger.Warnf("API usage flush before report failed: %v", err)
+		}
+		var err error
+		if records, err = tracker.store.Usage(ctx, from, to); err != nil {
+			return report, err
+		}
+	}
+
+	// Usage still pending after a failed flush, or all usage without a store
+	tracker.mutex.Lock()
+	for _, record := range tracker.pending {
+		if !record.Bucket.Before(from) && record.Bucket.Before(to) {
+			records = append(records, *record)
+		}
+	}
+	tracker.mutex.Unlock()
+
+	report.Entries = summarize(records)
+	return report, nil
+}
+
+// restore merges usage that failed to flush back into the pending usage
+func (tracker *Tracker) restore(records map[recordKey]*models.UsageRecord) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	for key, record := range records {
+		pending, found := tracker.pending[key]
+		if !found {
+			tracker.pending[key] = record
+			continue
+		}
+		pending.Requests += record.Requests
+		pending.Errors += record.Errors
+		pending.Bytes += record.Bytes
+		pending.LatencyMS += record.LatencyMS
+		pending.MaxLatencyMS = max(pending.MaxLatencyMS, record.MaxLatencyMS)
+	}
+}
+
+// summarize totals records per tenant, key and endpoint, sorted in that order
+func summarize(records []models.UsageRecord) []models.UsageEntry {
+	type entryKey struct{ tenant, keyID, endpoint string }
+	totals := make(map[entryKey]*models.UsageRecord)
+	for _, record := range records {
+		key := entryKey{record.Tenant, record.KeyID, record.Endpoint}
+		total, found := totals[key]
+		if !found {
+			total = &models.UsageRecord{Tenant: record.Tenant, KeyID: record.KeyID, Endpoint: record.Endpoint}
+			totals[key] = total
+		}
+		total.Requests += record.Requests
+		total.Errors += record.Errors
+		total.Bytes += record.Bytes
+		total.LatencyMS += record.LatencyMS
+		total.MaxLatencyMS = max(total.MaxLatencyMS, record.MaxLatencyMS)
+	}
+
+	entries := make([]models.UsageEntry, 0, len(totals))
+	for _, total := range totals {
+		entry := models.UsageEntry{
+			Tenant:       total.Tenant,
+			KeyID:        total.KeyID,
+			Endpoint:     total.Endpoint,
+			Requests:     total.Requests,
+			Errors:       total.Errors,
+			Bytes:        total.Bytes,
+			MaxLatencyMS: total.MaxLatencyMS,
+		}
+		if total.Requests > 0 {
+			entry.AverageLatencyMS = total.LatencyMS / float64(total.Requests)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Tenant != entries[j].Tenant {
+			return entries[i].Tenant < entries[j].Tenant
+		}
+		if entries[i].KeyID != entries[j].KeyID {
+			return entries[i].KeyID < entries[j].KeyID
+		}
+		return entries[i].Endpoint < entries[j].Endpoint
+	})
+	return entries
+}
@@ -0,0 +1,102 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dalfonso89/currency-exchange-service/models"
+	"github.com/dalfonso89/currency-exchange-service/testutils"
+)
+
+// memoryStore keeps flushed usage, failing every flush while fail is set
+type memoryStore struct {
+	records []models.UsageRecord
+	fail    bool
+}
+
+func (store *memoryStore) RecordUsage(ctx context.Context, records []models.UsageRecord) error {
+	if store.fail {
+		return errors.New("database unavailable")
+	}
+	store.records = append(store.records, records...)
+	return nil
+}
+
+func (store *memoryStore) Usage(ctx context.Context, from, to time.Time) ([]models.UsageRecord, error) {
+	matching := []models.UsageRecord{}
+	for _, record := range store.records {
+		if !record.Bucket.Before(from) && record.Bucket.Before(to) {
+			matching = append(matching, record)
+		}
+	}
+	return matching, nil
+}
+
+func TestTracker_Report(t *testing.T) {
+	hour := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tracker := NewTracker(nil, testutils.MockLogger())
+	tracker.Record("acme", "k1", "GET /api/v1/rates", 200, 100, 10*time.Millisecond, hour.Add(5*time.Minute))
+	tracker.Record("acme", "k1", "GET /api/v1/rates", 502, 50, 30*time.Millisecond, hour.Add(65*time.Minute))
+	tracker.Record("acme", "k1", "GET /api/v1/convert", 200, 80, 5*time.Millisecond, hour)
+	tracker.Record("", "", "GET /api/v1/rates", 401, -1, time.Millisecond, hour)
+	tracker.Record("acme", "k1", "GET /api/v1/rates", 200, 100, time.Millisecond, hour.Add(-time.Hour))
+
+	report, err := tracker.Report(context.Background(), hour, hour.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	want := []models.UsageEntry{
+		{Endpoint: "GET /api/v1/rates", Requests: 1, Errors: 1, Bytes: 0, AverageLatencyMS: 1, MaxLatencyMS: 1},
+		{Tenant: "acme", KeyID: "k1", Endpoint: "GET /api/v1/convert", Requests: 1, Bytes: 80, AverageLatencyMS: 5, MaxLatencyMS: 5},
+		{Tenant: "acme", KeyID: "k1", Endpoint: "GET /api/v1/rates", Requests: 2, Errors: 1, Bytes: 150, AverageLatencyMS: 20, MaxLatencyMS: 30},
+	}
+	if len(report.Entries) != len(want) {
+		t.Fatalf("Report() entries = %+v, want %+v", report.Entries, want)
+	}
+	for i := range want {
+		if report.Entries[i] != want[i] {
+			t.Errorf("Report() entry %d = %+v, want %+v", i, report.Entries[i], want[i])
+		}
+	}
+}
+
+func TestTracker_Flush(t *testing.T) {
+	hour := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	store := &memoryStore{fail: true}
+	tracker := NewTracker(store, testutils.MockLogger())
+	tracker.Record("acme", "k1", "GET /api/v1/rates", 200, 100, 10*time.Millisecond, hour)
+
+	if err := tracker.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() expected error from the store")
+	}
+	tracker.Record("acme", "k1", "GET /api/v1/rates", 200, 100, 20*time.Millisecond, hour)
+
+	// The failed flush is retried with the usage recorded since
+	store.fail = false
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(store.records) != 1 || store.records[0].Requests != 2 || store.records[0].Bytes != 200 || store.records[0].MaxLatencyMS != 20 {
+		t.Errorf("flushed records = %+v, want both requests in one record", store.records)
+	}
+
+	tracker.Record("acme", "k1", "GET /api/v1/rates", 200, 100, 30*time.Millisecond, hour)
+	report, err := tracker.Report(context.Background(), hour, hour.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if len(report.Entries) != 1 || report.Entries[0].Requests != 3 {
+		t.Errorf("Report() = %+v, want the stored and pending requests", report.Entries)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	if KeyID("") != "" {
+		t.Errorf("KeyID(\"\") = %q, want empty", KeyID(""))
+	}
+	if id := KeyID("secret-key"); len(id) != 12 || id != KeyID("secret-key") || id == KeyID("other-key") {
+		t.Errorf("KeyID() = %q, want a stable 12 character fingerprint", id)
+	}
+}